@@ -0,0 +1,12 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package e2e tests the whole pipeline end to end: the sample ExtProc server
+// is built and started on an ephemeral port, and manifests are run against
+// it through the manifest loader, the runner and the comparator.
+//
+// The tests build a binary and start processes, so they are guarded by the
+// e2e build tag:
+//
+//	go test -tags e2e ./e2e/
+package e2e
@@ -0,0 +1,230 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"zntr.io/extproctor/internal/client"
+	"zntr.io/extproctor/internal/manifest"
+	"zntr.io/extproctor/internal/runner"
+)
+
+// sampleServer is the path of the sample ExtProc server binary, built once
+// by TestMain.
+var sampleServer string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "extproctor-e2e")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	sampleServer = filepath.Join(dir, "extproc")
+	build := exec.Command("go", "build", "-o", sampleServer, "zntr.io/extproctor/sample/extproc")
+	build.Stdout, build.Stderr = os.Stderr, os.Stderr
+	if err := build.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to build the sample server: %v\n", err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// startSampleServer starts the sample server on an ephemeral port and waits
+// until it serves, returning its address.
+func startSampleServer(t *testing.T, args ...string) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	cmd := exec.Command(sampleServer, append([]string{"--addr", addr}, args...)...)
+	logs := &lockedBuffer{}
+	cmd.Stdout, cmd.Stderr = logs, logs
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		if t.Failed() {
+			t.Logf("sample server output:\n%s", logs.String())
+		}
+	})
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	require.Eventually(t, func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+		return err == nil && resp.GetStatus() == healthpb.HealthCheckResponse_SERVING
+	}, 10*time.Second, 50*time.Millisecond, "the sample server did not serve")
+
+	return addr
+}
+
+// lockedBuffer collects the output of the sample server.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// run runs the manifests of the paths against a target.
+func run(t *testing.T, target string, paths ...string) *runner.Results {
+	t.Helper()
+
+	manifests, err := manifest.NewLoader().LoadPaths(paths)
+	require.NoError(t, err)
+
+	c, err := client.New(client.WithTarget(target))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	results, err := runner.New(c, runner.WithParallel(4)).Run(ctx, manifests)
+	require.NoError(t, err)
+	return results
+}
+
+// writeManifest writes a manifest to a temporary file, returning its path.
+func writeManifest(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "e2e.textproto")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestSampleSuite(t *testing.T) {
+	target := startSampleServer(t)
+
+	results := run(t, target, "../sample/extproc/test/")
+	for _, test := range results.Tests {
+		assert.True(t, test.Passed || test.Skipped, "%s: %v %v", test.ID, test.Error, test.Differences)
+	}
+	assert.Positive(t, results.Passed)
+	assert.Zero(t, results.Failed)
+}
+
+func TestConfiguredSampleServer(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "server.textproto")
+	require.NoError(t, os.WriteFile(config, []byte(`
+request_headers: {
+  set_headers: { key: "x-tenant" value: "acme" }
+  remove_headers: "x-debug"
+}
+deny: { path_prefix: "/admin" status: 403 body: "forbidden" }
+`), 0o644))
+	target := startSampleServer(t, "--config", config)
+
+	path := writeManifest(t, `
+name: "configured"
+test_cases: {
+  name: "tenant-header"
+  request: { method: "GET" path: "/users" }
+  expectations: {
+    phase: REQUEST_HEADERS
+    headers_response: {
+      set_headers: { key: "x-tenant" value: "acme" }
+      remove_headers: "x-debug"
+    }
+  }
+}
+test_cases: {
+  name: "deny-admin"
+  request: { method: "GET" path: "/admin/users" process_response_headers: true }
+  expectations: {
+    phase: REQUEST_HEADERS
+    immediate_response: { status_code: 403 body: "forbidden" }
+  }
+}
+test_cases: {
+  name: "upstream-error"
+  request: { method: "GET" path: "/users" }
+  response: { status: 502 body: "bad gateway" process_response_body: true }
+  expectations: {
+    phase: RESPONSE_HEADERS
+    headers_response: { set_headers: { key: "x-extproc-response" value: "processed" } }
+  }
+  expectations: {
+    phase: RESPONSE_BODY
+    body_response: {}
+  }
+}
+`)
+
+	results := run(t, target, path)
+	for _, test := range results.Tests {
+		assert.True(t, test.Passed, "%s: %v %v", test.ID, test.Error, test.Differences)
+	}
+	assert.Equal(t, 3, results.Passed)
+}
+
+func TestFailuresAreReported(t *testing.T) {
+	target := startSampleServer(t)
+
+	path := writeManifest(t, `
+name: "failing"
+test_cases: {
+  name: "wrong-header"
+  request: { method: "GET" path: "/" }
+  expectations: {
+    phase: REQUEST_HEADERS
+    headers_response: { set_headers: { key: "x-extproc-processed" value: "false" } }
+  }
+}
+test_cases: {
+  name: "missing-phase"
+  request: { method: "GET" path: "/" }
+  expectations: {
+    phase: RESPONSE_HEADERS
+    headers_response: {}
+  }
+}
+`)
+
+	results := run(t, target, path)
+	require.Len(t, results.Tests, 2)
+	assert.Equal(t, 2, results.Failed)
+	for _, test := range results.Tests {
+		assert.False(t, test.Passed, test.ID)
+		assert.NoError(t, test.Error, test.ID)
+	}
+}
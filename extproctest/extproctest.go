@@ -0,0 +1,246 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package extproctest runs extproctor manifests from Go tests, to mix the
+// declarative expectations of the manifests with imperative assertions:
+//
+//	func TestFilter(t *testing.T) {
+//		target := extproctest.Serve(t, &myfilter.Server{})
+//		results := extproctest.Run(t, target, []string{"testdata/filter.textproto"})
+//		results.AssertNoDiff(t)
+//
+//		auth := results.Test("deny-anonymous")
+//		resp := auth.Response(extproctorv1.ProcessingPhase_REQUEST_HEADERS)
+//		// ... assert on the raw ExtProc response
+//	}
+package extproctest
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"google.golang.org/grpc"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+	"zntr.io/extproctor/internal/manifest"
+	"zntr.io/extproctor/internal/runner"
+)
+
+// Difference is a mismatch between an expectation and the response of the
+// ExtProc service.
+type Difference struct {
+	Phase    extproctorv1.ProcessingPhase
+	Path     string
+	Expected string
+	Actual   string
+
+	// Group is the assertion group of the expectation, empty for ungrouped
+	// expectations.
+	Group string
+}
+
+// String formats the difference like the human output.
+func (d Difference) String() string {
+	return fmt.Sprintf("[%s] %s: expected %s, actual %s", d.Phase, d.Path, d.Expected, d.Actual)
+}
+
+// Response is the response of the ExtProc service to a processing phase.
+type Response struct {
+	Phase    extproctorv1.ProcessingPhase
+	Response *extprocv3.ProcessingResponse
+
+	// Chunk is the index of the body chunk, for body phases.
+	Chunk int
+}
+
+// Result is the result of a test case.
+type Result struct {
+	// ID is the stable test ID, the manifest path and the test name.
+	ID   string
+	Name string
+
+	Passed  bool
+	Skipped bool
+
+	// Err is the error that prevented the test from completing.
+	Err error
+
+	Differences []Difference
+
+	// Unmatched lists the expectations no response matched.
+	Unmatched []*extproctorv1.ExtProcExpectation
+
+	// Responses are the responses of the ExtProc service, in order.
+	Responses []Response
+}
+
+// DiffFilter selects differences.
+type DiffFilter func(Difference) bool
+
+// ForPhase selects the differences of a processing phase.
+func ForPhase(phase extproctorv1.ProcessingPhase) DiffFilter {
+	return func(d Difference) bool {
+		return d.Phase == phase
+	}
+}
+
+// ForPath selects the differences whose path starts with a prefix, e.g.
+// "set_headers".
+func ForPath(prefix string) DiffFilter {
+	return func(d Difference) bool {
+		return strings.HasPrefix(d.Path, prefix)
+	}
+}
+
+// Diffs returns the differences matching all the filters.
+func (r *Result) Diffs(filters ...DiffFilter) []Difference {
+	var out []Difference
+	for _, d := range r.Differences {
+		matches := true
+		for _, f := range filters {
+			if !f(d) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Response returns the first response of a processing phase, nil when the
+// phase was not sent.
+func (r *Result) Response(phase extproctorv1.ProcessingPhase) *extprocv3.ProcessingResponse {
+	for _, resp := range r.Responses {
+		if resp.Phase == phase {
+			return resp.Response
+		}
+	}
+	return nil
+}
+
+// AssertNoDiff reports an error for each difference, unmatched expectation
+// or error of the test, and returns whether it has none.
+func (r *Result) AssertNoDiff(t testing.TB) bool {
+	t.Helper()
+
+	if r.Err != nil {
+		t.Errorf("%s: %v", r.ID, r.Err)
+	}
+	for _, d := range r.Differences {
+		t.Errorf("%s: %s", r.ID, d)
+	}
+	for _, u := range r.Unmatched {
+		t.Errorf("%s: [%s] expectation not matched by any response", r.ID, u.GetPhase())
+	}
+	return r.Err == nil && len(r.Differences) == 0 && len(r.Unmatched) == 0
+}
+
+// Results are the results of the test cases of a run, in order.
+type Results []*Result
+
+// Test returns the result of the test case with the given name or ID, nil
+// when no test matches.
+func (rs Results) Test(name string) *Result {
+	for _, r := range rs {
+		if r.Name == name || r.ID == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// AssertNoDiff asserts that no test of the run has a difference, an
+// unmatched expectation or an error, and returns whether all passed.
+func (rs Results) AssertNoDiff(t testing.TB) bool {
+	t.Helper()
+
+	ok := true
+	for _, r := range rs {
+		if !r.AssertNoDiff(t) {
+			ok = false
+		}
+	}
+	return ok
+}
+
+// Serve starts an in-process ExtProc service on a local port for the
+// duration of the test, and returns its address.
+func Serve(t testing.TB, srv extprocv3.ExternalProcessorServer) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	grpcServer := grpc.NewServer()
+	extprocv3.RegisterExternalProcessorServer(grpcServer, srv)
+	go func() { _ = grpcServer.Serve(lis) }()
+	t.Cleanup(grpcServer.Stop)
+
+	return lis.Addr().String()
+}
+
+// Run runs the manifests of the paths (files, directories or glob patterns)
+// against the ExtProc service at target, and returns the results of their
+// test cases. Loading or connection errors fail the test at once.
+func Run(t testing.TB, target string, paths []string, opts ...Option) Results {
+	t.Helper()
+
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	manifests, err := manifest.NewLoader(cfg.loaderOpts...).LoadPaths(paths)
+	if err != nil {
+		t.Fatalf("failed to load manifests: %v", err)
+	}
+
+	c, err := client.New(append([]client.Option{client.WithTarget(target)}, cfg.clientOpts...)...)
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %v", target, err)
+	}
+	defer func() { _ = c.Close() }()
+
+	results, err := runner.New(c, cfg.runnerOptions()...).Run(t.Context(), manifests)
+	if err != nil {
+		t.Fatalf("failed to run manifests: %v", err)
+	}
+
+	out := make(Results, 0, len(results.Tests))
+	for _, test := range results.Tests {
+		out = append(out, newResult(test))
+	}
+	return out
+}
+
+func newResult(test *runner.TestResult) *Result {
+	r := &Result{
+		ID:        test.ID,
+		Name:      test.Name,
+		Passed:    test.Passed,
+		Skipped:   test.Skipped,
+		Err:       test.Error,
+		Unmatched: test.Unmatched,
+	}
+	for _, d := range test.Differences {
+		r.Differences = append(r.Differences, Difference{
+			Phase:    d.Phase,
+			Path:     d.Path,
+			Expected: d.Expected,
+			Actual:   d.Actual,
+			Group:    d.Group,
+		})
+	}
+	if len(test.Responses) > 0 {
+		r.Responses = newResponses(test.Responses)
+	}
+	return r
+}
@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package extproctest
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// tenantProcessor sets the x-tenant header on the request headers.
+type tenantProcessor struct {
+	extprocv3.UnimplementedExternalProcessorServer
+}
+
+func (tenantProcessor) Process(stream extprocv3.ExternalProcessor_ProcessServer) error {
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		resp := &extprocv3.ProcessingResponse{}
+		switch req.Request.(type) {
+		case *extprocv3.ProcessingRequest_RequestHeaders:
+			resp.Response = &extprocv3.ProcessingResponse_RequestHeaders{RequestHeaders: &extprocv3.HeadersResponse{
+				Response: &extprocv3.CommonResponse{HeaderMutation: &extprocv3.HeaderMutation{
+					SetHeaders: []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "x-tenant", RawValue: []byte("acme")}}},
+				}},
+			}}
+		case *extprocv3.ProcessingRequest_ResponseHeaders:
+			resp.Response = &extprocv3.ProcessingResponse_ResponseHeaders{ResponseHeaders: &extprocv3.HeadersResponse{}}
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// recordingTB records the errors reported by the assertions.
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, format)
+}
+
+func writeManifest(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "tenant.textproto")
+	require.NoError(t, os.WriteFile(path, []byte(`
+name: "tenant"
+test_cases: {
+  name: "tenant-header"
+  request: { method: "GET" path: "/users" }
+  expectations: {
+    phase: REQUEST_HEADERS
+    headers_response: { set_headers: { key: "x-tenant" value: "acme" } }
+  }
+}
+test_cases: {
+  name: "wrong-tenant"
+  request: { method: "GET" path: "/users" }
+  expectations: {
+    phase: REQUEST_HEADERS
+    headers_response: { set_headers: { key: "x-tenant" value: "globex" } }
+  }
+}
+`), 0o644))
+	return path
+}
+
+func TestRun(t *testing.T) {
+	target := Serve(t, tenantProcessor{})
+	results := Run(t, target, []string{writeManifest(t)})
+	require.Len(t, results, 2)
+
+	passing := results.Test("tenant-header")
+	require.NotNil(t, passing)
+	assert.True(t, passing.Passed)
+	assert.True(t, passing.AssertNoDiff(t))
+
+	resp := passing.Response(extproctorv1.ProcessingPhase_REQUEST_HEADERS)
+	require.NotNil(t, resp)
+	assert.Equal(t, "x-tenant", resp.GetRequestHeaders().GetResponse().GetHeaderMutation().GetSetHeaders()[0].GetHeader().GetKey())
+	assert.Nil(t, passing.Response(extproctorv1.ProcessingPhase_RESPONSE_HEADERS))
+
+	failing := results.Test("wrong-tenant")
+	require.NotNil(t, failing)
+	assert.False(t, failing.Passed)
+	assert.NotEmpty(t, failing.Diffs(ForPhase(extproctorv1.ProcessingPhase_REQUEST_HEADERS), ForPath("set_headers")))
+	assert.Empty(t, failing.Diffs(ForPhase(extproctorv1.ProcessingPhase_RESPONSE_HEADERS)))
+
+	rec := &recordingTB{TB: t}
+	assert.False(t, results.AssertNoDiff(rec))
+	assert.Len(t, rec.errors, len(failing.Differences)+len(failing.Unmatched))
+
+	assert.Nil(t, results.Test("missing"))
+}
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package extproctest
+
+import (
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"google.golang.org/grpc"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+	"zntr.io/extproctor/internal/comparator"
+	"zntr.io/extproctor/internal/manifest"
+	"zntr.io/extproctor/internal/runner"
+)
+
+// Option configures a Run.
+type Option func(*config)
+
+// config is the configuration of a Run, forwarded to the runner components.
+type config struct {
+	loaderOpts     []manifest.LoaderOption
+	clientOpts     []client.Option
+	comparatorOpts []comparator.Option
+	runnerOpts     []runner.Option
+}
+
+// Comparator compares the expectations of a test case against the responses
+// of the ExtProc service, returning the differences found and the
+// expectations no response matched. The test passes when both are empty.
+type Comparator interface {
+	Compare(expectations []*extproctorv1.ExtProcExpectation, responses []Response) ([]Difference, []*extproctorv1.ExtProcExpectation)
+}
+
+// Matcher is an additional check of an expectation against a response of
+// its phase, returning the differences it found. Matchers run after the
+// built-in comparisons and their differences prevent the match likewise.
+type Matcher func(exp *extproctorv1.ExtProcExpectation, resp *extprocv3.ProcessingResponse) []Difference
+
+// Transformer transforms the parsed manifests, loaded or imported, before
+// their inheritance, macros and conditions are resolved, e.g. to expand
+// org-specific macros, inject secrets or generate test cases, like the
+// --plugin flag of the CLI.
+type Transformer func(m *extproctorv1.TestManifest, path string) error
+
+// WithTransformer adds a transformer applied to each loaded or imported
+// manifest, in the order the transformers are added.
+func WithTransformer(t Transformer) Option {
+	return func(cfg *config) {
+		cfg.loaderOpts = append(cfg.loaderOpts, manifest.WithTransformers(manifest.TransformerFunc(t)))
+	}
+}
+
+// WithUnaryInterceptor adds interceptors to the unary RPCs of the client,
+// run in the order they are added.
+func WithUnaryInterceptor(interceptors ...grpc.UnaryClientInterceptor) Option {
+	return func(cfg *config) {
+		cfg.clientOpts = append(cfg.clientOpts, client.WithUnaryInterceptor(interceptors...))
+	}
+}
+
+// WithStreamInterceptor adds interceptors to the streaming RPCs of the
+// client, such as the ExtProc processing stream, run in the order they are
+// added.
+func WithStreamInterceptor(interceptors ...grpc.StreamClientInterceptor) Option {
+	return func(cfg *config) {
+		cfg.clientOpts = append(cfg.clientOpts, client.WithStreamInterceptor(interceptors...))
+	}
+}
+
+// WithComparator replaces the default comparator matching the responses of
+// the ExtProc service against the expectations of the tests. The matchers
+// registered with WithMatcher only apply to the default comparator.
+func WithComparator(c Comparator) Option {
+	return func(cfg *config) {
+		cfg.runnerOpts = append(cfg.runnerOpts, runner.WithComparator(comparatorAdapter{c}))
+	}
+}
+
+// WithMatcher registers a matcher run by the default comparator on every
+// expectation compared.
+func WithMatcher(m Matcher) Option {
+	return func(cfg *config) {
+		cfg.comparatorOpts = append(cfg.comparatorOpts, comparator.WithMatcher(func(exp *extproctorv1.ExtProcExpectation, resp *extprocv3.ProcessingResponse) []comparator.Difference {
+			return toComparatorDiffs(m(exp, resp))
+		}))
+	}
+}
+
+// runnerOptions returns the runner options of the configuration, the default
+// comparator first so that WithComparator overrides it.
+func (cfg *config) runnerOptions() []runner.Option {
+	opts := []runner.Option{runner.WithResponses(true)}
+	if len(cfg.comparatorOpts) > 0 {
+		opts = append(opts, runner.WithComparator(comparator.New(cfg.comparatorOpts...)))
+	}
+	return append(opts, cfg.runnerOpts...)
+}
+
+// comparatorAdapter adapts a Comparator to the runner.
+type comparatorAdapter struct {
+	c Comparator
+}
+
+// Compare implements comparator.Interface.
+func (a comparatorAdapter) Compare(expectations []*extproctorv1.ExtProcExpectation, result *client.ProcessingResult) *comparator.ComparisonResult {
+	diffs, unmatched := a.c.Compare(expectations, newResponses(result.Responses))
+	return &comparator.ComparisonResult{
+		Passed:      len(diffs) == 0 && len(unmatched) == 0,
+		Differences: toComparatorDiffs(diffs),
+		Unmatched:   unmatched,
+	}
+}
+
+func toComparatorDiffs(diffs []Difference) []comparator.Difference {
+	out := make([]comparator.Difference, 0, len(diffs))
+	for _, d := range diffs {
+		out = append(out, comparator.Difference{
+			Phase:    d.Phase,
+			Path:     d.Path,
+			Expected: d.Expected,
+			Actual:   d.Actual,
+			Group:    d.Group,
+		})
+	}
+	return out
+}
+
+func newResponses(resps []*client.PhaseResponse) []Response {
+	out := make([]Response, 0, len(resps))
+	for _, resp := range resps {
+		out = append(out, Response{
+			Phase:    resp.Phase,
+			Response: resp.Response,
+			Chunk:    resp.Chunk,
+		})
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package extproctest
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func TestRun_WithMatcher(t *testing.T) {
+	target := Serve(t, tenantProcessor{})
+
+	var calls int
+	traced := func(exp *extproctorv1.ExtProcExpectation, resp *extprocv3.ProcessingResponse) []Difference {
+		calls++
+		return []Difference{{Phase: exp.GetPhase(), Path: "set_headers[x-trace-id]", Expected: "<set>", Actual: "<not set>"}}
+	}
+
+	results := Run(t, target, []string{writeManifest(t)}, WithMatcher(traced))
+	require.Len(t, results, 2)
+	assert.Positive(t, calls)
+
+	// The matcher fails the otherwise passing test
+	passing := results.Test("tenant-header")
+	require.NotNil(t, passing)
+	assert.False(t, passing.Passed)
+	diffs := passing.Diffs(ForPath("set_headers[x-trace-id]"))
+	require.Len(t, diffs, 1)
+	assert.Equal(t, extproctorv1.ProcessingPhase_REQUEST_HEADERS, diffs[0].Phase)
+}
+
+// responseCountComparator requires a number of responses, whatever the
+// expectations.
+type responseCountComparator struct {
+	responses int
+}
+
+func (c responseCountComparator) Compare(expectations []*extproctorv1.ExtProcExpectation, responses []Response) ([]Difference, []*extproctorv1.ExtProcExpectation) {
+	if len(responses) != c.responses {
+		return []Difference{{Path: "responses", Expected: strconv.Itoa(c.responses), Actual: strconv.Itoa(len(responses))}}, nil
+	}
+	return nil, nil
+}
+
+func TestRun_WithComparator(t *testing.T) {
+	target := Serve(t, tenantProcessor{})
+
+	// The default comparator fails wrong-tenant, the custom one passes it
+	results := Run(t, target, []string{writeManifest(t)}, WithComparator(responseCountComparator{responses: 1}))
+	require.Len(t, results, 2)
+	assert.True(t, results.AssertNoDiff(t))
+	assert.NotNil(t, results.Test("wrong-tenant").Response(extproctorv1.ProcessingPhase_REQUEST_HEADERS))
+
+	results = Run(t, target, []string{writeManifest(t)}, WithComparator(responseCountComparator{responses: 2}))
+	for _, r := range results {
+		assert.False(t, r.Passed)
+		assert.Len(t, r.Diffs(ForPath("responses")), 1)
+	}
+}
+
+func TestRun_WithTransformer(t *testing.T) {
+	target := Serve(t, tenantProcessor{})
+
+	var paths []string
+	fixTenant := func(m *extproctorv1.TestManifest, path string) error {
+		paths = append(paths, path)
+		for _, tc := range m.TestCases {
+			for _, exp := range tc.Expectations {
+				exp.GetHeadersResponse().SetHeaders["x-tenant"] = "acme"
+			}
+		}
+		return nil
+	}
+
+	path := writeManifest(t)
+	results := Run(t, target, []string{path}, WithTransformer(fixTenant))
+	require.Len(t, results, 2)
+	assert.True(t, results.AssertNoDiff(t))
+	assert.Equal(t, []string{path}, paths)
+}
+
+func TestRun_WithStreamInterceptor(t *testing.T) {
+	target := Serve(t, tenantProcessor{})
+
+	var methods []string
+	record := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		methods = append(methods, method)
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+
+	results := Run(t, target, []string{writeManifest(t)}, WithStreamInterceptor(record))
+	require.Len(t, results, 2)
+	assert.Equal(t, []string{extprocv3.ExternalProcessor_Process_FullMethodName, extprocv3.ExternalProcessor_Process_FullMethodName}, methods)
+}
@@ -0,0 +1,466 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: Apache-2.0
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: extproctor/sample/v1/server.proto
+
+package samplev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ServerConfig is the configuration file of the sample ExtProc server, in
+// Prototext (sample/extproc --config).
+type ServerConfig struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Address the gRPC server listens on, ":50051" when empty. The --addr flag
+	// takes precedence.
+	ListenAddress string `protobuf:"bytes,1,opt,name=listen_address,json=listenAddress,proto3" json:"listen_address,omitempty"`
+	// TLS serves the ExtProc and health services over TLS.
+	Tls *TlsConfig `protobuf:"bytes,2,opt,name=tls,proto3" json:"tls,omitempty"`
+	// Header mutations of each processing phase. Phases not configured keep
+	// the default behavior of the sample server, which sets an
+	// x-extproc-* header.
+	RequestHeaders   *PhaseBehavior `protobuf:"bytes,3,opt,name=request_headers,json=requestHeaders,proto3" json:"request_headers,omitempty"`
+	RequestTrailers  *PhaseBehavior `protobuf:"bytes,4,opt,name=request_trailers,json=requestTrailers,proto3" json:"request_trailers,omitempty"`
+	ResponseHeaders  *PhaseBehavior `protobuf:"bytes,5,opt,name=response_headers,json=responseHeaders,proto3" json:"response_headers,omitempty"`
+	ResponseTrailers *PhaseBehavior `protobuf:"bytes,6,opt,name=response_trailers,json=responseTrailers,proto3" json:"response_trailers,omitempty"`
+	// Deny rules reject the matching requests with an immediate response at
+	// the request headers phase. The first matching rule applies.
+	Deny []*DenyRule `protobuf:"bytes,7,rep,name=deny,proto3" json:"deny,omitempty"`
+	// Address of the HTTP server exposing the Prometheus metrics on /metrics,
+	// e.g. ":9090". Metrics are not served when empty.
+	MetricsAddress string `protobuf:"bytes,8,opt,name=metrics_address,json=metricsAddress,proto3" json:"metrics_address,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ServerConfig) Reset() {
+	*x = ServerConfig{}
+	mi := &file_extproctor_sample_v1_server_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerConfig) ProtoMessage() {}
+
+func (x *ServerConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_extproctor_sample_v1_server_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerConfig.ProtoReflect.Descriptor instead.
+func (*ServerConfig) Descriptor() ([]byte, []int) {
+	return file_extproctor_sample_v1_server_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ServerConfig) GetListenAddress() string {
+	if x != nil {
+		return x.ListenAddress
+	}
+	return ""
+}
+
+func (x *ServerConfig) GetTls() *TlsConfig {
+	if x != nil {
+		return x.Tls
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetRequestHeaders() *PhaseBehavior {
+	if x != nil {
+		return x.RequestHeaders
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetRequestTrailers() *PhaseBehavior {
+	if x != nil {
+		return x.RequestTrailers
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetResponseHeaders() *PhaseBehavior {
+	if x != nil {
+		return x.ResponseHeaders
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetResponseTrailers() *PhaseBehavior {
+	if x != nil {
+		return x.ResponseTrailers
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetDeny() []*DenyRule {
+	if x != nil {
+		return x.Deny
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetMetricsAddress() string {
+	if x != nil {
+		return x.MetricsAddress
+	}
+	return ""
+}
+
+// TlsConfig configures the server certificate, and optionally requires
+// client certificates.
+type TlsConfig struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// PEM certificate and private key of the server.
+	CertFile string `protobuf:"bytes,1,opt,name=cert_file,json=certFile,proto3" json:"cert_file,omitempty"`
+	KeyFile  string `protobuf:"bytes,2,opt,name=key_file,json=keyFile,proto3" json:"key_file,omitempty"`
+	// PEM certificate authorities verifying client certificates. When set,
+	// clients must present a certificate (mTLS).
+	ClientCaFile  string `protobuf:"bytes,3,opt,name=client_ca_file,json=clientCaFile,proto3" json:"client_ca_file,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TlsConfig) Reset() {
+	*x = TlsConfig{}
+	mi := &file_extproctor_sample_v1_server_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TlsConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TlsConfig) ProtoMessage() {}
+
+func (x *TlsConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_extproctor_sample_v1_server_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TlsConfig.ProtoReflect.Descriptor instead.
+func (*TlsConfig) Descriptor() ([]byte, []int) {
+	return file_extproctor_sample_v1_server_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TlsConfig) GetCertFile() string {
+	if x != nil {
+		return x.CertFile
+	}
+	return ""
+}
+
+func (x *TlsConfig) GetKeyFile() string {
+	if x != nil {
+		return x.KeyFile
+	}
+	return ""
+}
+
+func (x *TlsConfig) GetClientCaFile() string {
+	if x != nil {
+		return x.ClientCaFile
+	}
+	return ""
+}
+
+// PhaseBehavior configures the header mutation returned for a processing
+// phase.
+type PhaseBehavior struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Headers set, in order.
+	SetHeaders []*Header `protobuf:"bytes,1,rep,name=set_headers,json=setHeaders,proto3" json:"set_headers,omitempty"`
+	// Names of the headers removed.
+	RemoveHeaders []string `protobuf:"bytes,2,rep,name=remove_headers,json=removeHeaders,proto3" json:"remove_headers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PhaseBehavior) Reset() {
+	*x = PhaseBehavior{}
+	mi := &file_extproctor_sample_v1_server_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PhaseBehavior) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PhaseBehavior) ProtoMessage() {}
+
+func (x *PhaseBehavior) ProtoReflect() protoreflect.Message {
+	mi := &file_extproctor_sample_v1_server_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PhaseBehavior.ProtoReflect.Descriptor instead.
+func (*PhaseBehavior) Descriptor() ([]byte, []int) {
+	return file_extproctor_sample_v1_server_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PhaseBehavior) GetSetHeaders() []*Header {
+	if x != nil {
+		return x.SetHeaders
+	}
+	return nil
+}
+
+func (x *PhaseBehavior) GetRemoveHeaders() []string {
+	if x != nil {
+		return x.RemoveHeaders
+	}
+	return nil
+}
+
+// Header is a header name and value.
+type Header struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Header) Reset() {
+	*x = Header{}
+	mi := &file_extproctor_sample_v1_server_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Header) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Header) ProtoMessage() {}
+
+func (x *Header) ProtoReflect() protoreflect.Message {
+	mi := &file_extproctor_sample_v1_server_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Header.ProtoReflect.Descriptor instead.
+func (*Header) Descriptor() ([]byte, []int) {
+	return file_extproctor_sample_v1_server_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Header) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Header) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+// DenyRule rejects the requests whose path starts with a prefix.
+type DenyRule struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Path prefix of the denied requests, starting with "/".
+	PathPrefix string `protobuf:"bytes,1,opt,name=path_prefix,json=pathPrefix,proto3" json:"path_prefix,omitempty"`
+	// HTTP status of the immediate response, 403 when unset.
+	Status uint32 `protobuf:"varint,2,opt,name=status,proto3" json:"status,omitempty"`
+	// Body of the immediate response.
+	Body          string `protobuf:"bytes,3,opt,name=body,proto3" json:"body,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DenyRule) Reset() {
+	*x = DenyRule{}
+	mi := &file_extproctor_sample_v1_server_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DenyRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DenyRule) ProtoMessage() {}
+
+func (x *DenyRule) ProtoReflect() protoreflect.Message {
+	mi := &file_extproctor_sample_v1_server_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DenyRule.ProtoReflect.Descriptor instead.
+func (*DenyRule) Descriptor() ([]byte, []int) {
+	return file_extproctor_sample_v1_server_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DenyRule) GetPathPrefix() string {
+	if x != nil {
+		return x.PathPrefix
+	}
+	return ""
+}
+
+func (x *DenyRule) GetStatus() uint32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *DenyRule) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
+var File_extproctor_sample_v1_server_proto protoreflect.FileDescriptor
+
+const file_extproctor_sample_v1_server_proto_rawDesc = "" +
+	"\n" +
+	"!extproctor/sample/v1/server.proto\x12\x14extproctor.sample.v1\"\x85\x04\n" +
+	"\fServerConfig\x12%\n" +
+	"\x0elisten_address\x18\x01 \x01(\tR\rlistenAddress\x121\n" +
+	"\x03tls\x18\x02 \x01(\v2\x1f.extproctor.sample.v1.TlsConfigR\x03tls\x12L\n" +
+	"\x0frequest_headers\x18\x03 \x01(\v2#.extproctor.sample.v1.PhaseBehaviorR\x0erequestHeaders\x12N\n" +
+	"\x10request_trailers\x18\x04 \x01(\v2#.extproctor.sample.v1.PhaseBehaviorR\x0frequestTrailers\x12N\n" +
+	"\x10response_headers\x18\x05 \x01(\v2#.extproctor.sample.v1.PhaseBehaviorR\x0fresponseHeaders\x12P\n" +
+	"\x11response_trailers\x18\x06 \x01(\v2#.extproctor.sample.v1.PhaseBehaviorR\x10responseTrailers\x122\n" +
+	"\x04deny\x18\a \x03(\v2\x1e.extproctor.sample.v1.DenyRuleR\x04deny\x12'\n" +
+	"\x0fmetrics_address\x18\b \x01(\tR\x0emetricsAddress\"i\n" +
+	"\tTlsConfig\x12\x1b\n" +
+	"\tcert_file\x18\x01 \x01(\tR\bcertFile\x12\x19\n" +
+	"\bkey_file\x18\x02 \x01(\tR\akeyFile\x12$\n" +
+	"\x0eclient_ca_file\x18\x03 \x01(\tR\fclientCaFile\"u\n" +
+	"\rPhaseBehavior\x12=\n" +
+	"\vset_headers\x18\x01 \x03(\v2\x1c.extproctor.sample.v1.HeaderR\n" +
+	"setHeaders\x12%\n" +
+	"\x0eremove_headers\x18\x02 \x03(\tR\rremoveHeaders\"0\n" +
+	"\x06Header\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\"W\n" +
+	"\bDenyRule\x12\x1f\n" +
+	"\vpath_prefix\x18\x01 \x01(\tR\n" +
+	"pathPrefix\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\rR\x06status\x12\x12\n" +
+	"\x04body\x18\x03 \x01(\tR\x04bodyB\xcf\x01\n" +
+	"\x18com.extproctor.sample.v1B\vServerProtoP\x01Z4zntr.io/extproctor/gen/extproctor/sample/v1;samplev1\xa2\x02\x03ESX\xaa\x02\x14Extproctor.Sample.V1\xca\x02\x14Extproctor\\Sample\\V1\xe2\x02 Extproctor\\Sample\\V1\\GPBMetadata\xea\x02\x16Extproctor::Sample::V1b\x06proto3"
+
+var (
+	file_extproctor_sample_v1_server_proto_rawDescOnce sync.Once
+	file_extproctor_sample_v1_server_proto_rawDescData []byte
+)
+
+func file_extproctor_sample_v1_server_proto_rawDescGZIP() []byte {
+	file_extproctor_sample_v1_server_proto_rawDescOnce.Do(func() {
+		file_extproctor_sample_v1_server_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_extproctor_sample_v1_server_proto_rawDesc), len(file_extproctor_sample_v1_server_proto_rawDesc)))
+	})
+	return file_extproctor_sample_v1_server_proto_rawDescData
+}
+
+var file_extproctor_sample_v1_server_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_extproctor_sample_v1_server_proto_goTypes = []any{
+	(*ServerConfig)(nil),  // 0: extproctor.sample.v1.ServerConfig
+	(*TlsConfig)(nil),     // 1: extproctor.sample.v1.TlsConfig
+	(*PhaseBehavior)(nil), // 2: extproctor.sample.v1.PhaseBehavior
+	(*Header)(nil),        // 3: extproctor.sample.v1.Header
+	(*DenyRule)(nil),      // 4: extproctor.sample.v1.DenyRule
+}
+var file_extproctor_sample_v1_server_proto_depIdxs = []int32{
+	1, // 0: extproctor.sample.v1.ServerConfig.tls:type_name -> extproctor.sample.v1.TlsConfig
+	2, // 1: extproctor.sample.v1.ServerConfig.request_headers:type_name -> extproctor.sample.v1.PhaseBehavior
+	2, // 2: extproctor.sample.v1.ServerConfig.request_trailers:type_name -> extproctor.sample.v1.PhaseBehavior
+	2, // 3: extproctor.sample.v1.ServerConfig.response_headers:type_name -> extproctor.sample.v1.PhaseBehavior
+	2, // 4: extproctor.sample.v1.ServerConfig.response_trailers:type_name -> extproctor.sample.v1.PhaseBehavior
+	4, // 5: extproctor.sample.v1.ServerConfig.deny:type_name -> extproctor.sample.v1.DenyRule
+	3, // 6: extproctor.sample.v1.PhaseBehavior.set_headers:type_name -> extproctor.sample.v1.Header
+	7, // [7:7] is the sub-list for method output_type
+	7, // [7:7] is the sub-list for method input_type
+	7, // [7:7] is the sub-list for extension type_name
+	7, // [7:7] is the sub-list for extension extendee
+	0, // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_extproctor_sample_v1_server_proto_init() }
+func file_extproctor_sample_v1_server_proto_init() {
+	if File_extproctor_sample_v1_server_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_extproctor_sample_v1_server_proto_rawDesc), len(file_extproctor_sample_v1_server_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_extproctor_sample_v1_server_proto_goTypes,
+		DependencyIndexes: file_extproctor_sample_v1_server_proto_depIdxs,
+		MessageInfos:      file_extproctor_sample_v1_server_proto_msgTypes,
+	}.Build()
+	File_extproctor_sample_v1_server_proto = out.File
+	file_extproctor_sample_v1_server_proto_goTypes = nil
+	file_extproctor_sample_v1_server_proto_depIdxs = nil
+}
@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: Apache-2.0
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: extproctor/v1/golden.proto
+
+package extproctorv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// GoldenFile is the content of a golden file: the responses of the ExtProc
+// service to a test case, recorded as expectations.
+type GoldenFile struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Schema version of the golden file, 0 for golden files written before
+	// they were versioned
+	Version uint32 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	// Digest of the request the golden file was generated from
+	// ("sha256:<hex>"), to warn when the request of the test changed since
+	RequestDigest string `protobuf:"bytes,2,opt,name=request_digest,json=requestDigest,proto3" json:"request_digest,omitempty"`
+	// Expectations recorded from the responses of the ExtProc service
+	Expectations  []*ExtProcExpectation `protobuf:"bytes,3,rep,name=expectations,proto3" json:"expectations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GoldenFile) Reset() {
+	*x = GoldenFile{}
+	mi := &file_extproctor_v1_golden_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GoldenFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GoldenFile) ProtoMessage() {}
+
+func (x *GoldenFile) ProtoReflect() protoreflect.Message {
+	mi := &file_extproctor_v1_golden_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GoldenFile.ProtoReflect.Descriptor instead.
+func (*GoldenFile) Descriptor() ([]byte, []int) {
+	return file_extproctor_v1_golden_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GoldenFile) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *GoldenFile) GetRequestDigest() string {
+	if x != nil {
+		return x.RequestDigest
+	}
+	return ""
+}
+
+func (x *GoldenFile) GetExpectations() []*ExtProcExpectation {
+	if x != nil {
+		return x.Expectations
+	}
+	return nil
+}
+
+var File_extproctor_v1_golden_proto protoreflect.FileDescriptor
+
+const file_extproctor_v1_golden_proto_rawDesc = "" +
+	"\n" +
+	"\x1aextproctor/v1/golden.proto\x12\rextproctor.v1\x1a\x1cextproctor/v1/manifest.proto\"\x9a\x01\n" +
+	"\n" +
+	"GoldenFile\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\rR\aversion\x12%\n" +
+	"\x0erequest_digest\x18\x02 \x01(\tR\rrequestDigest\x12E\n" +
+	"\fexpectations\x18\x03 \x03(\v2!.extproctor.v1.ExtProcExpectationR\fexpectationsR\x04nameB3Z1zntr.io/extproctor/gen/extproctor/v1;extproctorv1b\x06proto3"
+
+var (
+	file_extproctor_v1_golden_proto_rawDescOnce sync.Once
+	file_extproctor_v1_golden_proto_rawDescData []byte
+)
+
+func file_extproctor_v1_golden_proto_rawDescGZIP() []byte {
+	file_extproctor_v1_golden_proto_rawDescOnce.Do(func() {
+		file_extproctor_v1_golden_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_extproctor_v1_golden_proto_rawDesc), len(file_extproctor_v1_golden_proto_rawDesc)))
+	})
+	return file_extproctor_v1_golden_proto_rawDescData
+}
+
+var file_extproctor_v1_golden_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_extproctor_v1_golden_proto_goTypes = []any{
+	(*GoldenFile)(nil),         // 0: extproctor.v1.GoldenFile
+	(*ExtProcExpectation)(nil), // 1: extproctor.v1.ExtProcExpectation
+}
+var file_extproctor_v1_golden_proto_depIdxs = []int32{
+	1, // 0: extproctor.v1.GoldenFile.expectations:type_name -> extproctor.v1.ExtProcExpectation
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_extproctor_v1_golden_proto_init() }
+func file_extproctor_v1_golden_proto_init() {
+	if File_extproctor_v1_golden_proto != nil {
+		return
+	}
+	file_extproctor_v1_manifest_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_extproctor_v1_golden_proto_rawDesc), len(file_extproctor_v1_golden_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_extproctor_v1_golden_proto_goTypes,
+		DependencyIndexes: file_extproctor_v1_golden_proto_depIdxs,
+		MessageInfos:      file_extproctor_v1_golden_proto_msgTypes,
+	}.Build()
+	File_extproctor_v1_golden_proto = out.File
+	file_extproctor_v1_golden_proto_goTypes = nil
+	file_extproctor_v1_golden_proto_depIdxs = nil
+}
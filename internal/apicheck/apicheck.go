@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package apicheck detects drift between the compiled ExtProc API and the
+// fields handled by the comparator and the golden converter.
+package apicheck
+
+import (
+	"sort"
+	"strings"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"zntr.io/extproctor/internal/comparator"
+	"zntr.io/extproctor/internal/golden"
+)
+
+// extProcPackage is the protobuf package whose messages are walked. Fields of
+// messages defined elsewhere (core types, well-known types) are leaves.
+const extProcPackage = "envoy.service.ext_proc.v3."
+
+// comparatorGaps and goldenGaps list the fields that existed when the
+// coverage was last reviewed and are knowingly not handled by the comparator
+// and the golden converter respectively. They are only asserted through
+// exact_response expectations.
+var comparatorGaps = map[protoreflect.FullName]bool{
+	"envoy.service.ext_proc.v3.ProcessingResponse.override_message_timeout": true,
+	"envoy.service.ext_proc.v3.BodyMutation.streamed_response":              true,
+	"envoy.service.ext_proc.v3.StreamedBodyResponse.body":                   true,
+	"envoy.service.ext_proc.v3.StreamedBodyResponse.end_of_stream":          true,
+	"envoy.service.ext_proc.v3.ImmediateResponse.details":                   true,
+	"envoy.service.ext_proc.v3.ImmediateResponse.grpc_status":               true,
+	"envoy.service.ext_proc.v3.GrpcStatus.status":                           true,
+}
+
+var goldenGaps = map[protoreflect.FullName]bool{
+	"envoy.service.ext_proc.v3.ProcessingResponse.dynamic_metadata":         true,
+	"envoy.service.ext_proc.v3.ProcessingResponse.mode_override":            true,
+	"envoy.service.ext_proc.v3.ProcessingResponse.override_message_timeout": true,
+	"envoy.service.ext_proc.v3.CommonResponse.status":                       true,
+	"envoy.service.ext_proc.v3.CommonResponse.trailers":                     true,
+	"envoy.service.ext_proc.v3.CommonResponse.clear_route_cache":            true,
+	"envoy.service.ext_proc.v3.BodyMutation.streamed_response":              true,
+	"envoy.service.ext_proc.v3.StreamedBodyResponse.body":                   true,
+	"envoy.service.ext_proc.v3.StreamedBodyResponse.end_of_stream":          true,
+}
+
+// Field describes the coverage of a single ProcessingResponse field.
+type Field struct {
+	Name       protoreflect.FullName
+	Comparator bool
+	Golden     bool
+	// New is set when a component neither handles the field nor lists it as
+	// a known gap, which means it appeared with a go-control-plane update.
+	New bool
+}
+
+// Handled reports whether both the comparator and the golden converter handle
+// the field.
+func (f Field) Handled() bool {
+	return f.Comparator && f.Golden
+}
+
+// Report is the outcome of a check.
+type Report struct {
+	Fields []Field
+}
+
+// Unhandled returns the fields not handled by at least one component.
+func (r *Report) Unhandled() []Field {
+	var out []Field
+	for _, f := range r.Fields {
+		if !f.Handled() {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// New returns the unhandled fields that are not known gaps.
+func (r *Report) New() []Field {
+	var out []Field
+	for _, f := range r.Fields {
+		if f.New {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Check walks the compiled ProcessingResponse descriptor and reports the
+// coverage of every reachable field.
+func Check() *Report {
+	return check(
+		(&extprocv3.ProcessingResponse{}).ProtoReflect().Descriptor(),
+		toSet(comparator.HandledFields),
+		toSet(golden.HandledFields),
+		comparatorGaps,
+		goldenGaps,
+	)
+}
+
+func check(root protoreflect.MessageDescriptor, comp, gold, compGaps, goldGaps map[protoreflect.FullName]bool) *Report {
+	report := &Report{}
+	seen := map[protoreflect.FullName]bool{}
+
+	var walk func(md protoreflect.MessageDescriptor)
+	walk = func(md protoreflect.MessageDescriptor) {
+		if seen[md.FullName()] {
+			return
+		}
+		seen[md.FullName()] = true
+
+		fields := md.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			f := Field{
+				Name:       fd.FullName(),
+				Comparator: comp[fd.FullName()],
+				Golden:     gold[fd.FullName()],
+			}
+			f.New = (!f.Comparator && !compGaps[fd.FullName()]) || (!f.Golden && !goldGaps[fd.FullName()])
+			report.Fields = append(report.Fields, f)
+
+			if msg := fd.Message(); msg != nil && strings.HasPrefix(string(msg.FullName()), extProcPackage) {
+				walk(msg)
+			}
+		}
+	}
+	walk(root)
+
+	sort.Slice(report.Fields, func(i, j int) bool {
+		return report.Fields[i].Name < report.Fields[j].Name
+	})
+
+	return report
+}
+
+func toSet(names []protoreflect.FullName) map[protoreflect.FullName]bool {
+	set := make(map[protoreflect.FullName]bool, len(names))
+	for _, n := range names {
+		set[n] = true
+	}
+	return set
+}
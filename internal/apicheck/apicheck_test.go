@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package apicheck
+
+import (
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"zntr.io/extproctor/internal/comparator"
+	"zntr.io/extproctor/internal/golden"
+)
+
+func TestCheck_NoNewFields(t *testing.T) {
+	report := Check()
+	require.NotEmpty(t, report.Fields)
+	assert.Empty(t, report.New(), "new ExtProc fields must be handled or listed as known gaps")
+}
+
+func TestCheck_DeclaredFieldsExist(t *testing.T) {
+	report := Check()
+	known := make(map[protoreflect.FullName]bool, len(report.Fields))
+	for _, f := range report.Fields {
+		known[f.Name] = true
+	}
+
+	for _, names := range [][]protoreflect.FullName{comparator.HandledFields, golden.HandledFields} {
+		for _, n := range names {
+			assert.True(t, known[n], "handled field %s does not exist", n)
+		}
+	}
+	for _, gaps := range []map[protoreflect.FullName]bool{comparatorGaps, goldenGaps} {
+		for n := range gaps {
+			assert.True(t, known[n], "known gap %s does not exist", n)
+		}
+	}
+}
+
+func TestCheck_HandledFieldsAreNotGaps(t *testing.T) {
+	for _, n := range comparator.HandledFields {
+		assert.False(t, comparatorGaps[n], "comparator handled field %s is still listed as a known gap", n)
+	}
+	for _, n := range golden.HandledFields {
+		assert.False(t, goldenGaps[n], "golden handled field %s is still listed as a known gap", n)
+	}
+}
+
+func TestCheck_ReportsNewField(t *testing.T) {
+	root := (&extprocv3.ProcessingResponse{}).ProtoReflect().Descriptor()
+	report := check(root, toSet(nil), toSet(nil), toSet(nil), toSet(nil))
+
+	var found bool
+	for _, f := range report.New() {
+		if f.Name == "envoy.service.ext_proc.v3.ProcessingResponse.mode_override" {
+			found = true
+		}
+	}
+	assert.True(t, found)
+	assert.Equal(t, len(report.Fields), len(report.Unhandled()))
+
+	// A field handled by the comparator only is new unless a golden gap
+	name := protoreflect.FullName("envoy.service.ext_proc.v3.ProcessingResponse.mode_override")
+	comp := toSet([]protoreflect.FullName{name})
+	for _, gold := range []map[protoreflect.FullName]bool{nil, comp} {
+		report = check(root, comp, toSet(nil), toSet(nil), gold)
+		for _, f := range report.Fields {
+			if f.Name == name {
+				assert.Equal(t, gold == nil, f.New)
+			}
+		}
+	}
+}
+
+func TestCheck_DoesNotWalkForeignMessages(t *testing.T) {
+	report := Check()
+	for _, f := range report.Fields {
+		assert.Contains(t, string(f.Name), extProcPackage)
+	}
+}
@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package artifacts writes the debugging artifacts of failed tests.
+package artifacts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+	"zntr.io/extproctor/internal/comparator"
+)
+
+// Artifact file names, written in the folder of each failed test.
+const (
+	RequestFile   = "request.textproto"
+	ResponsesFile = "responses.textproto"
+	DiffFile      = "diff.txt"
+	LogFile       = "filter.log"
+)
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+var marshalOptions = prototext.MarshalOptions{
+	Multiline: true,
+	Indent:    "  ",
+}
+
+// Test contains what is known about a failed test.
+type Test struct {
+	// ID is the persistent ID of the test, used to name its folder.
+	ID string
+
+	// Request is the HTTP request sent to the ExtProc service.
+	Request *extproctorv1.HttpRequest
+
+	// Result contains the raw responses of the ExtProc service, nil when the
+	// request could not be processed.
+	Result *client.ProcessingResult
+
+	Error       error
+	Differences []comparator.Difference
+	Unmatched   []*extproctorv1.ExtProcExpectation
+	Unexpected  []*client.PhaseResponse
+
+	// Logs contains the ExtProc service log lines written during the test.
+	Logs []string
+}
+
+// Dir returns the folder holding the artifacts of a test.
+func Dir(root, id string) string {
+	return filepath.Join(root, unsafeChars.ReplaceAllString(id, "_"))
+}
+
+// Write writes the artifacts of a failed test in its folder under root,
+// replacing the artifacts of a previous run. It returns the folder path.
+func Write(root string, t *Test) (string, error) {
+	dir := Dir(root, t.ID)
+	if err := os.RemoveAll(dir); err != nil {
+		return "", fmt.Errorf("failed to clean artifacts directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create artifacts directory: %w", err)
+	}
+
+	if t.Request != nil {
+		data, err := marshalOptions.Marshal(t.Request)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal request: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, RequestFile), data, 0o644); err != nil {
+			return "", fmt.Errorf("failed to write request: %w", err)
+		}
+	}
+
+	if t.Result != nil {
+		data, err := formatResponses(t.Result)
+		if err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(filepath.Join(dir, ResponsesFile), data, 0o644); err != nil {
+			return "", fmt.Errorf("failed to write responses: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, DiffFile), []byte(formatDiff(t)), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write differences: %w", err)
+	}
+
+	if len(t.Logs) > 0 {
+		if err := os.WriteFile(filepath.Join(dir, LogFile), []byte(strings.Join(t.Logs, "\n")+"\n"), 0o644); err != nil {
+			return "", fmt.Errorf("failed to write filter logs: %w", err)
+		}
+	}
+
+	return dir, nil
+}
+
+// formatResponses renders the raw responses in prototext, each preceded by a
+// comment naming its phase.
+func formatResponses(result *client.ProcessingResult) ([]byte, error) {
+	var sb strings.Builder
+	for i, resp := range result.Responses {
+		data, err := marshalOptions.Marshal(resp.Response)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s response: %w", resp.Phase, err)
+		}
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "# %s\n", resp.Phase)
+		sb.Write(data)
+	}
+	for _, phase := range result.SkippedPhases {
+		fmt.Fprintf(&sb, "\n# %s: skipped (stream ended by an immediate response)\n", phase)
+	}
+	for _, phase := range result.SuppressedPhases {
+		fmt.Fprintf(&sb, "\n# %s: suppressed (disabled by a mode_override)\n", phase)
+	}
+	return []byte(sb.String()), nil
+}
+
+// formatDiff renders the error, differences, unmatched expectations and
+// unexpected responses of a test.
+func formatDiff(t *Test) string {
+	var sb strings.Builder
+	if t.Error != nil {
+		fmt.Fprintf(&sb, "Error: %v\n", t.Error)
+	}
+	sb.WriteString(comparator.FormatDifferences(t.Differences))
+	sb.WriteString(comparator.FormatUnmatched(t.Unmatched))
+	if len(t.Unexpected) > 0 {
+		sb.WriteString("Unexpected responses:\n")
+		for _, resp := range t.Unexpected {
+			fmt.Fprintf(&sb, "  - Phase: %s\n", resp.Phase)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package artifacts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+	"zntr.io/extproctor/internal/comparator"
+)
+
+func TestDir(t *testing.T) {
+	assert.Equal(t, filepath.Join("out", "tests_auth.textproto_deny_all"), Dir("out", "tests/auth.textproto::deny all"))
+}
+
+func TestWrite(t *testing.T) {
+	root := t.TempDir()
+
+	dir, err := Write(root, &Test{
+		ID:      "auth.textproto::deny",
+		Request: &extproctorv1.HttpRequest{Method: "GET", Path: "/admin"},
+		Result: &client.ProcessingResult{
+			Responses: []*client.PhaseResponse{{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+						ImmediateResponse: &extprocv3.ImmediateResponse{Details: "denied"},
+					},
+				},
+			}},
+			SkippedPhases: []extproctorv1.ProcessingPhase{extproctorv1.ProcessingPhase_RESPONSE_HEADERS},
+		},
+		Differences: []comparator.Difference{{
+			Phase:    extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			Path:     "immediate_response.status_code",
+			Expected: "403",
+			Actual:   "0",
+		}},
+		Logs: []string{"denied /admin"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Dir(root, "auth.textproto::deny"), dir)
+
+	request, err := os.ReadFile(filepath.Join(dir, RequestFile))
+	require.NoError(t, err)
+	assert.Regexp(t, `path:\s+"/admin"`, string(request))
+
+	responses, err := os.ReadFile(filepath.Join(dir, ResponsesFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(responses), "# REQUEST_HEADERS\n")
+	assert.Regexp(t, `details:\s+"denied"`, string(responses))
+	assert.Contains(t, string(responses), "# RESPONSE_HEADERS: skipped")
+
+	diff, err := os.ReadFile(filepath.Join(dir, DiffFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(diff), "[REQUEST_HEADERS] immediate_response.status_code:")
+
+	logs, err := os.ReadFile(filepath.Join(dir, LogFile))
+	require.NoError(t, err)
+	assert.Equal(t, "denied /admin\n", string(logs))
+}
+
+func TestWrite_ErrorOnly(t *testing.T) {
+	root := t.TempDir()
+	stale := filepath.Join(Dir(root, "t::x"), ResponsesFile)
+	require.NoError(t, os.MkdirAll(filepath.Dir(stale), 0o755))
+	require.NoError(t, os.WriteFile(stale, []byte("stale"), 0o644))
+
+	dir, err := Write(root, &Test{ID: "t::x", Error: errors.New("connection refused")})
+	require.NoError(t, err)
+
+	diff, err := os.ReadFile(filepath.Join(dir, DiffFile))
+	require.NoError(t, err)
+	assert.Equal(t, "Error: connection refused\n", string(diff))
+
+	// Artifacts of a previous run are replaced
+	assert.NoFileExists(t, stale)
+	assert.NoFileExists(t, filepath.Join(dir, RequestFile))
+	assert.NoFileExists(t, filepath.Join(dir, LogFile))
+}
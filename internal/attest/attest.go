@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package attest signs JSON result files with a provenance attestation: an
+// in-toto statement naming the targets and the digests of the manifests the
+// results come from, wrapped in a DSSE envelope.
+package attest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	// StatementType is the type of in-toto v1 statements.
+	StatementType = "https://in-toto.io/Statement/v1"
+
+	// PredicateType identifies the extproctor conformance predicate.
+	PredicateType = "https://zntr.io/extproctor/conformance/v1"
+
+	// PayloadType is the DSSE payload type of in-toto statements.
+	PayloadType = "application/vnd.in-toto+json"
+
+	// Extension is appended to the path of a result file to name its
+	// attestation.
+	Extension = ".intoto.json"
+)
+
+// Statement is an in-toto statement about a result file.
+type Statement struct {
+	Type          string    `json:"_type"`
+	Subject       []Subject `json:"subject"`
+	PredicateType string    `json:"predicateType"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+// Subject is an artifact the statement is about.
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Predicate describes how the results were produced.
+type Predicate struct {
+	// Version is the version of extproctor.
+	Version string `json:"version"`
+
+	// Targets are the ExtProc services the tests ran against.
+	Targets []Target `json:"targets"`
+
+	// Manifests are the digests of the manifests of the tests, sorted by
+	// path.
+	Manifests []Subject `json:"manifests"`
+}
+
+// Target identifies an ExtProc service.
+type Target struct {
+	Name    string `json:"name,omitempty"`
+	Address string `json:"address"`
+}
+
+// Digest returns the SHA-256 digest of some content, as in-toto digest set.
+func Digest(data []byte) map[string]string {
+	sum := sha256.Sum256(data)
+	return map[string]string{"sha256": hex.EncodeToString(sum[:])}
+}
+
+// ManifestDigests returns the digests of manifest files, sorted by path.
+func ManifestDigests(paths []string) ([]Subject, error) {
+	manifests := make([]Subject, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		manifests = append(manifests, Subject{Name: filepath.ToSlash(path), Digest: Digest(data)})
+	}
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].Name < manifests[j].Name })
+	return manifests, nil
+}
+
+// NewStatement returns the statement about a result file.
+func NewStatement(name string, results []byte, predicate Predicate) *Statement {
+	return &Statement{
+		Type:          StatementType,
+		Subject:       []Subject{{Name: filepath.Base(name), Digest: Digest(results)}},
+		PredicateType: PredicateType,
+		Predicate:     predicate,
+	}
+}
+
+// Sign writes the signed attestation of a result file next to it, and
+// returns its path.
+func Sign(path string, predicate Predicate, key *Key) (string, error) {
+	results, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read results: %w", err)
+	}
+
+	payload, err := json.Marshal(NewStatement(path, results, predicate))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal statement: %w", err)
+	}
+	envelope, err := signEnvelope(payload, key)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal attestation: %w", err)
+	}
+	attestation := path + Extension
+	if err := os.WriteFile(attestation, append(data, '\n'), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write attestation: %w", err)
+	}
+	return attestation, nil
+}
+
+// Verify checks the attestation of a result file with a public key, and
+// returns its statement. The result file must match the subject of the
+// statement.
+func Verify(path string, key *PublicKey) (*Statement, error) {
+	results, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results: %w", err)
+	}
+	data, err := os.ReadFile(path + Extension)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attestation: %w", err)
+	}
+
+	var envelope Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse attestation: %w", err)
+	}
+	payload, err := verifyEnvelope(&envelope, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var statement Statement
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return nil, fmt.Errorf("failed to parse statement: %w", err)
+	}
+	if statement.Type != StatementType || statement.PredicateType != PredicateType {
+		return nil, fmt.Errorf("unexpected statement type %q with predicate %q", statement.Type, statement.PredicateType)
+	}
+	if len(statement.Subject) != 1 || statement.Subject[0].Digest["sha256"] != Digest(results)["sha256"] {
+		return nil, errors.New("the results do not match the attestation")
+	}
+
+	return &statement, nil
+}
+
+// CheckManifests returns an error unless the statement attests manifest files
+// with their current content.
+func (s *Statement) CheckManifests(paths []string) error {
+	attested := make(map[string]string, len(s.Predicate.Manifests))
+	for _, m := range s.Predicate.Manifests {
+		attested[m.Name] = m.Digest["sha256"]
+	}
+
+	current, err := ManifestDigests(paths)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, m := range current {
+		switch digest, ok := attested[m.Name]; {
+		case !ok:
+			errs = append(errs, fmt.Errorf("manifest %s is not attested", m.Name))
+		case digest != m.Digest["sha256"]:
+			errs = append(errs, fmt.Errorf("manifest %s changed since the attestation", m.Name))
+		}
+	}
+	return errors.Join(errs...)
+}
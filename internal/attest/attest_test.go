@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package attest
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeys writes a PEM private key and its public key, and returns their
+// paths.
+func writeKeys(t *testing.T, key any) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	private := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(private, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+	signer, err := LoadKey(private)
+	require.NoError(t, err)
+	der, err = x509.MarshalPKIXPublicKey(signer.Public().key)
+	require.NoError(t, err)
+	public := filepath.Join(dir, "key.pub")
+	require.NoError(t, os.WriteFile(public, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644))
+
+	return private, public
+}
+
+func TestSignVerify(t *testing.T) {
+	_, ed, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ec, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	for name, key := range map[string]any{"ed25519": ed, "ecdsa": ec} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			manifestPath := filepath.Join(dir, "auth.textproto")
+			require.NoError(t, os.WriteFile(manifestPath, []byte(`name: "auth"`), 0o644))
+			resultsPath := filepath.Join(dir, "results.json")
+			require.NoError(t, os.WriteFile(resultsPath, []byte(`{"summary":{"total":1}}`), 0o644))
+
+			manifests, err := ManifestDigests([]string{manifestPath})
+			require.NoError(t, err)
+			predicate := Predicate{
+				Version:   "v2025.12",
+				Targets:   []Target{{Name: "eu", Address: "eu.internal:50051"}},
+				Manifests: manifests,
+			}
+
+			privatePath, publicPath := writeKeys(t, key)
+			signer, err := LoadKey(privatePath)
+			require.NoError(t, err)
+			attestation, err := Sign(resultsPath, predicate, signer)
+			require.NoError(t, err)
+			assert.Equal(t, resultsPath+".intoto.json", attestation)
+
+			public, err := LoadPublicKey(publicPath)
+			require.NoError(t, err)
+			statement, err := Verify(resultsPath, public)
+			require.NoError(t, err)
+			assert.Equal(t, "results.json", statement.Subject[0].Name)
+			assert.Equal(t, predicate, statement.Predicate)
+			assert.NoError(t, statement.CheckManifests([]string{manifestPath}))
+
+			// The manifests must keep their attested content
+			require.NoError(t, os.WriteFile(manifestPath, []byte(`name: "changed"`), 0o644))
+			assert.ErrorContains(t, statement.CheckManifests([]string{manifestPath}), "changed since the attestation")
+
+			// The results must match the attestation
+			require.NoError(t, os.WriteFile(resultsPath, []byte(`{"summary":{"total":2}}`), 0o644))
+			_, err = Verify(resultsPath, public)
+			assert.EqualError(t, err, "the results do not match the attestation")
+		})
+	}
+}
+
+func TestVerify_InvalidSignature(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privatePath, _ := writeKeys(t, key)
+	_, other, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, otherPublic := writeKeys(t, other)
+
+	resultsPath := filepath.Join(t.TempDir(), "results.json")
+	require.NoError(t, os.WriteFile(resultsPath, []byte(`{}`), 0o644))
+	signer, err := LoadKey(privatePath)
+	require.NoError(t, err)
+	_, err = Sign(resultsPath, Predicate{}, signer)
+	require.NoError(t, err)
+
+	public, err := LoadPublicKey(otherPublic)
+	require.NoError(t, err)
+	_, err = Verify(resultsPath, public)
+	assert.EqualError(t, err, "invalid attestation signature")
+}
+
+func TestEnvelope_Format(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privatePath, _ := writeKeys(t, key)
+	signer, err := LoadKey(privatePath)
+	require.NoError(t, err)
+
+	resultsPath := filepath.Join(t.TempDir(), "results.json")
+	require.NoError(t, os.WriteFile(resultsPath, []byte(`{}`), 0o644))
+	attestation, err := Sign(resultsPath, Predicate{Version: "dev"}, signer)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(attestation)
+	require.NoError(t, err)
+	var envelope Envelope
+	require.NoError(t, json.Unmarshal(data, &envelope))
+	assert.Equal(t, "application/vnd.in-toto+json", envelope.PayloadType)
+	require.Len(t, envelope.Signatures, 1)
+
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	require.NoError(t, err)
+	var statement map[string]any
+	require.NoError(t, json.Unmarshal(payload, &statement))
+	assert.Equal(t, "https://in-toto.io/Statement/v1", statement["_type"])
+	assert.Equal(t, "https://zntr.io/extproctor/conformance/v1", statement["predicateType"])
+}
+
+func TestLoadKey_Errors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(path, []byte("not pem"), 0o600))
+	_, err := LoadKey(path)
+	assert.ErrorContains(t, err, "is not PEM encoded")
+
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: []byte{1}}), 0o600))
+	_, err = LoadKey(path)
+	assert.EqualError(t, err, `unsupported signing key "ENCRYPTED PRIVATE KEY": expected an unencrypted PRIVATE KEY`)
+}
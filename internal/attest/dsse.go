@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package attest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Envelope is a DSSE envelope.
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     string      `json:"payload"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+// Signature is a signature of a DSSE envelope.
+type Signature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// Key is a private signing key.
+type Key struct {
+	signer crypto.Signer
+}
+
+// PublicKey is a public verification key.
+type PublicKey struct {
+	key crypto.PublicKey
+}
+
+// LoadKey reads a PEM private key (PKCS #8, or SEC 1 EC key) with an ECDSA,
+// Ed25519 or RSA key.
+func LoadKey(path string) (*Key, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported signing key %q: expected an unencrypted PRIVATE KEY", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported signing key algorithm")
+	}
+	return &Key{signer: signer}, nil
+}
+
+// Public returns the public key of a signing key.
+func (k *Key) Public() *PublicKey {
+	return &PublicKey{key: k.signer.Public()}
+}
+
+// LoadPublicKey reads a PEM public key (PKIX).
+func LoadPublicKey(path string) (*PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("unsupported public key %q: expected a PUBLIC KEY", block.Type)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	return &PublicKey{key: key}, nil
+}
+
+// readPEM reads the first PEM block of a file.
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to read key: %s is not PEM encoded", path)
+	}
+	return block, nil
+}
+
+// pae returns the DSSE pre-authentication encoding of a payload, which is
+// what gets signed.
+func pae(payloadType string, payload []byte) []byte {
+	return fmt.Appendf(nil, "DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload)
+}
+
+// signEnvelope wraps an in-toto statement in a signed DSSE envelope.
+func signEnvelope(payload []byte, key *Key) (*Envelope, error) {
+	message := pae(PayloadType, payload)
+
+	var sig []byte
+	var err error
+	if _, ok := key.signer.(ed25519.PrivateKey); ok {
+		sig, err = key.signer.Sign(rand.Reader, message, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(message)
+		sig, err = key.signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign attestation: %w", err)
+	}
+
+	return &Envelope{
+		PayloadType: PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures:  []Signature{{Sig: base64.StdEncoding.EncodeToString(sig)}},
+	}, nil
+}
+
+// verifyEnvelope returns the payload of a DSSE envelope once one of its
+// signatures is verified with the public key.
+func verifyEnvelope(envelope *Envelope, key *PublicKey) ([]byte, error) {
+	if envelope.PayloadType != PayloadType {
+		return nil, fmt.Errorf("unexpected payload type %q", envelope.PayloadType)
+	}
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode payload: %w", err)
+	}
+
+	message := pae(envelope.PayloadType, payload)
+	digest := sha256.Sum256(message)
+	for _, s := range envelope.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err != nil {
+			continue
+		}
+
+		var valid bool
+		switch pub := key.key.(type) {
+		case ed25519.PublicKey:
+			valid = ed25519.Verify(pub, message, sig)
+		case *ecdsa.PublicKey:
+			valid = ecdsa.VerifyASN1(pub, digest[:], sig)
+		case *rsa.PublicKey:
+			valid = rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil
+		}
+		if valid {
+			return payload, nil
+		}
+	}
+
+	return nil, errors.New("invalid attestation signature")
+}
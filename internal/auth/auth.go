@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package auth provides the per-call credentials of authenticated ExtProc
+// endpoints: static bearer tokens, OAuth2 client credentials, Google
+// Application Default Credentials and AWS Signature Version 4.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/grpc/credentials"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// gcpDefaultScope is the scope of GCP access tokens when none is configured.
+const gcpDefaultScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// New creates the per-call credentials described by a configuration.
+// Environment variables referenced as ${NAME} in its strings are expanded.
+func New(ctx context.Context, cfg *extproctorv1.AuthConfig) (credentials.PerRPCCredentials, error) {
+	secure := !cfg.AllowInsecure
+
+	switch p := cfg.Provider.(type) {
+	case *extproctorv1.AuthConfig_StaticToken:
+		token := os.ExpandEnv(p.StaticToken.GetToken())
+		if token == "" {
+			return nil, errors.New("static_token: token is required")
+		}
+		return &tokenCredentials{source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}), secure: secure}, nil
+	case *extproctorv1.AuthConfig_Oauth2ClientCredentials:
+		c := p.Oauth2ClientCredentials
+		oc := &clientcredentials.Config{
+			ClientID:     os.ExpandEnv(c.GetClientId()),
+			ClientSecret: os.ExpandEnv(c.GetClientSecret()),
+			TokenURL:     os.ExpandEnv(c.GetTokenUrl()),
+			Scopes:       c.GetScopes(),
+		}
+		if oc.TokenURL == "" || oc.ClientID == "" {
+			return nil, errors.New("oauth2_client_credentials: token_url and client_id are required")
+		}
+		if len(c.GetEndpointParams()) > 0 {
+			oc.EndpointParams = make(map[string][]string, len(c.EndpointParams))
+			for k, v := range c.EndpointParams {
+				oc.EndpointParams[k] = []string{os.ExpandEnv(v)}
+			}
+		}
+		return &tokenCredentials{source: oc.TokenSource(ctx), secure: secure}, nil
+	case *extproctorv1.AuthConfig_Gcp:
+		scopes := p.Gcp.GetScopes()
+		if len(scopes) == 0 {
+			scopes = []string{gcpDefaultScope}
+		}
+		source, err := google.DefaultTokenSource(ctx, scopes...)
+		if err != nil {
+			return nil, fmt.Errorf("gcp: %w", err)
+		}
+		return &tokenCredentials{source: source, secure: secure}, nil
+	case *extproctorv1.AuthConfig_AwsSigv4:
+		signer, err := newSigV4Signer(p.AwsSigv4)
+		if err != nil {
+			return nil, fmt.Errorf("aws_sigv4: %w", err)
+		}
+		return &sigV4Credentials{signer: signer, secure: secure}, nil
+	default:
+		return nil, errors.New("auth has no provider")
+	}
+}
+
+// tokenCredentials sends the bearer tokens of a token source, which refreshes
+// them as needed.
+type tokenCredentials struct {
+	source oauth2.TokenSource
+	secure bool
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials.
+func (c *tokenCredentials) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	token, err := c.source.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access token: %w", err)
+	}
+	return map[string]string{"authorization": token.Type() + " " + token.AccessToken}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials.
+func (c *tokenCredentials) RequireTransportSecurity() bool {
+	return c.secure
+}
@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func TestNew_StaticToken(t *testing.T) {
+	t.Setenv("EXTPROC_TOKEN", "s3cr3t")
+
+	creds, err := New(context.Background(), &extproctorv1.AuthConfig{
+		Provider: &extproctorv1.AuthConfig_StaticToken{StaticToken: &extproctorv1.StaticTokenAuth{Token: "${EXTPROC_TOKEN}"}},
+	})
+	require.NoError(t, err)
+	assert.True(t, creds.RequireTransportSecurity())
+
+	md, err := creds.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"authorization": "Bearer s3cr3t"}, md)
+}
+
+func TestNew_OAuth2ClientCredentials(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.Form.Get("grant_type"))
+		assert.Equal(t, "extproc", r.Form.Get("audience"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"tok","token_type":"Bearer","expires_in":3600}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	creds, err := New(context.Background(), &extproctorv1.AuthConfig{
+		Provider: &extproctorv1.AuthConfig_Oauth2ClientCredentials{Oauth2ClientCredentials: &extproctorv1.OAuth2ClientCredentialsAuth{
+			TokenUrl:       srv.URL,
+			ClientId:       "extproctor",
+			ClientSecret:   "secret",
+			EndpointParams: map[string]string{"audience": "extproc"},
+		}},
+		AllowInsecure: true,
+	})
+	require.NoError(t, err)
+	assert.False(t, creds.RequireTransportSecurity())
+
+	for range 2 {
+		md, err := creds.GetRequestMetadata(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer tok", md["authorization"])
+	}
+
+	// The token is reused until it expires
+	assert.Equal(t, 1, requests)
+}
+
+func TestNew_Invalid(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+
+	tests := []struct {
+		name string
+		cfg  *extproctorv1.AuthConfig
+		err  string
+	}{
+		{name: "no provider", cfg: &extproctorv1.AuthConfig{}, err: "auth has no provider"},
+		{
+			name: "empty token",
+			cfg:  &extproctorv1.AuthConfig{Provider: &extproctorv1.AuthConfig_StaticToken{StaticToken: &extproctorv1.StaticTokenAuth{}}},
+			err:  "static_token: token is required",
+		},
+		{
+			name: "oauth2 without token url",
+			cfg: &extproctorv1.AuthConfig{Provider: &extproctorv1.AuthConfig_Oauth2ClientCredentials{
+				Oauth2ClientCredentials: &extproctorv1.OAuth2ClientCredentialsAuth{ClientId: "id"},
+			}},
+			err: "oauth2_client_credentials: token_url and client_id are required",
+		},
+		{
+			name: "aws without region",
+			cfg: &extproctorv1.AuthConfig{Provider: &extproctorv1.AuthConfig_AwsSigv4{
+				AwsSigv4: &extproctorv1.AwsSigV4Auth{Service: "execute-api"},
+			}},
+			err: "aws_sigv4: region and service are required",
+		},
+		{
+			name: "aws without credentials",
+			cfg: &extproctorv1.AuthConfig{Provider: &extproctorv1.AuthConfig_AwsSigv4{
+				AwsSigv4: &extproctorv1.AwsSigV4Auth{Region: "eu-west-1", Service: "execute-api"},
+			}},
+			err: "aws_sigv4: no credentials",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(context.Background(), tt.cfg)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestSigV4Signer_Sign(t *testing.T) {
+	// get-vanilla of the AWS Signature Version 4 test suite
+	s := &sigV4Signer{
+		region:          "us-east-1",
+		service:         "service",
+		accessKeyID:     "AKIDEXAMPLE",
+		secretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	headers := s.sign("GET", "/", map[string]string{"host": "example.amazonaws.com"}, hashHex(""), now)
+	assert.Equal(t, map[string]string{
+		"x-amz-date":    "20150830T123600Z",
+		"authorization": "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+	}, headers)
+
+	// The session token is signed and sent
+	s.sessionToken = "session"
+	headers = s.sign("GET", "/", map[string]string{"host": "example.amazonaws.com"}, hashHex(""), now)
+	assert.Equal(t, "session", headers["x-amz-security-token"])
+	assert.Contains(t, headers["authorization"], "SignedHeaders=host;x-amz-date;x-amz-security-token,")
+}
+
+func TestSigV4Credentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+
+	creds, err := New(context.Background(), &extproctorv1.AuthConfig{
+		Provider: &extproctorv1.AuthConfig_AwsSigv4{AwsSigv4: &extproctorv1.AwsSigV4Auth{Region: "eu-west-1", Service: "vpc-lattice-svcs"}},
+	})
+	require.NoError(t, err)
+
+	ctx := credentials.NewContextWithRequestInfo(context.Background(), credentials.RequestInfo{
+		Method: "/envoy.service.ext_proc.v3.ExternalProcessor/Process",
+	})
+	md, err := creds.GetRequestMetadata(ctx, "https://extproc.example.com:443/envoy.service.ext_proc.v3.ExternalProcessor")
+	require.NoError(t, err)
+	assert.Equal(t, "UNSIGNED-PAYLOAD", md["x-amz-content-sha256"])
+	assert.NotEmpty(t, md["x-amz-date"])
+	assert.Regexp(t, `^AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/\d{8}/eu-west-1/vpc-lattice-svcs/aws4_request, SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date, Signature=[0-9a-f]{64}$`, md["authorization"])
+}
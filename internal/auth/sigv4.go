@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+const (
+	// sigV4Algorithm is the signing algorithm of AWS Signature Version 4.
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+
+	// sigV4TimeFormat is the format of the x-amz-date header.
+	sigV4TimeFormat = "20060102T150405Z"
+
+	// unsignedPayload is the payload hash of streamed requests, whose body is
+	// not known when signing.
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// sigV4Signer signs requests with AWS Signature Version 4.
+type sigV4Signer struct {
+	region          string
+	service         string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+}
+
+// newSigV4Signer creates a signer from a configuration, reading the missing
+// credentials from the standard AWS environment variables.
+func newSigV4Signer(cfg *extproctorv1.AwsSigV4Auth) (*sigV4Signer, error) {
+	s := &sigV4Signer{
+		region:          os.ExpandEnv(cfg.GetRegion()),
+		service:         os.ExpandEnv(cfg.GetService()),
+		accessKeyID:     os.ExpandEnv(cfg.GetAccessKeyId()),
+		secretAccessKey: os.ExpandEnv(cfg.GetSecretAccessKey()),
+		sessionToken:    os.ExpandEnv(cfg.GetSessionToken()),
+	}
+	if s.accessKeyID == "" && s.secretAccessKey == "" {
+		s.accessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		s.secretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		s.sessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+
+	switch {
+	case s.region == "" || s.service == "":
+		return nil, errors.New("region and service are required")
+	case s.accessKeyID == "" || s.secretAccessKey == "":
+		return nil, errors.New("no credentials: set access_key_id and secret_access_key, or AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	return s, nil
+}
+
+// sign returns the headers authenticating a request: x-amz-date, the session
+// token when any, and authorization. The given headers, with lowercase keys,
+// must include host and are all signed.
+func (s *sigV4Signer) sign(method, path string, headers map[string]string, payloadHash string, now time.Time) map[string]string {
+	now = now.UTC()
+	date := now.Format(sigV4TimeFormat)
+
+	signed := make(map[string]string, len(headers)+2)
+	for k, v := range headers {
+		signed[k] = v
+	}
+	signed["x-amz-date"] = date
+	if s.sessionToken != "" {
+		signed["x-amz-security-token"] = s.sessionToken
+	}
+
+	keys := make([]string, 0, len(signed))
+	for k := range signed {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range keys {
+		canonicalHeaders.WriteString(k + ":" + strings.TrimSpace(signed[k]) + "\n")
+	}
+	signedHeaders := strings.Join(keys, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		path,
+		"", // query
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date[:8], s.region, s.service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{sigV4Algorithm, date, scope, hashHex(canonicalRequest)}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date[:8])
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	result := map[string]string{
+		"x-amz-date":    date,
+		"authorization": fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s", sigV4Algorithm, s.accessKeyID, scope, signedHeaders, signature),
+	}
+	if s.sessionToken != "" {
+		result["x-amz-security-token"] = s.sessionToken
+	}
+	return result
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hashHex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// sigV4Credentials signs each gRPC call as a POST request of its method
+// path, with an unsigned payload.
+type sigV4Credentials struct {
+	signer *sigV4Signer
+	secure bool
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials.
+func (c *sigV4Credentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	if len(uri) == 0 {
+		return nil, errors.New("aws_sigv4: no request URI")
+	}
+	u, err := url.Parse(uri[0])
+	if err != nil {
+		return nil, fmt.Errorf("aws_sigv4: invalid request URI: %w", err)
+	}
+	info, ok := credentials.RequestInfoFromContext(ctx)
+	if !ok {
+		return nil, errors.New("aws_sigv4: no request info")
+	}
+
+	md := c.signer.sign("POST", info.Method, map[string]string{
+		"host":                 u.Host,
+		"content-type":         "application/grpc",
+		"x-amz-content-sha256": unsignedPayload,
+	}, unsignedPayload, time.Now())
+	md["x-amz-content-sha256"] = unsignedPayload
+	return md, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials.
+func (c *sigV4Credentials) RequireTransportSecurity() bool {
+	return c.secure
+}
@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package badge renders shields-style SVG badges summarizing test results,
+// to embed in the README of a filter repository.
+package badge
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+
+	"zntr.io/extproctor/internal/compare"
+)
+
+// Badge colors, from the shields.io palette.
+const (
+	ColorBrightGreen = "#4c1"
+	ColorGreen       = "#97ca00"
+	ColorYellow      = "#dfb317"
+	ColorOrange      = "#fe7d37"
+	ColorRed         = "#e05d44"
+	ColorLightGrey   = "#9f9f9f"
+)
+
+// Badge is a label and a colored message.
+type Badge struct {
+	Label   string
+	Message string
+	Color   string
+}
+
+// PassRate returns the badge of the pass rate of the tests of a JSON report.
+// Skipped tests are left out; the rate is rounded down so that a single
+// failure never shows 100%.
+func PassRate(label string, results *compare.Results) Badge {
+	var passed, failed int
+	for _, t := range results.Tests {
+		switch t.Status {
+		case "passed":
+			passed++
+		case "failed":
+			failed++
+		}
+	}
+
+	total := passed + failed
+	if total == 0 {
+		return Badge{Label: label, Message: "no tests", Color: ColorLightGrey}
+	}
+
+	rate := math.Floor(float64(passed)*1000/float64(total)) / 10
+	return Badge{
+		Label:   label,
+		Message: fmt.Sprintf("%s%% passed", strconv.FormatFloat(rate, 'f', -1, 64)),
+		Color:   rateColor(rate),
+	}
+}
+
+// rateColor returns the color of a percentage.
+func rateColor(rate float64) string {
+	switch {
+	case rate >= 100:
+		return ColorBrightGreen
+	case rate >= 90:
+		return ColorGreen
+	case rate >= 75:
+		return ColorYellow
+	case rate >= 50:
+		return ColorOrange
+	default:
+		return ColorRed
+	}
+}
+
+// WriteSVG writes the badge in the flat shields style.
+func (b Badge) WriteSVG(w io.Writer) error {
+	labelWidth, messageWidth := textWidth(b.Label)+10, textWidth(b.Message)+10
+	width := labelWidth + messageWidth
+	label, message := html.EscapeString(b.Label), html.EscapeString(b.Message)
+
+	_, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[4]s: %[5]s">
+<title>%[4]s: %[5]s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="%[2]d" height="20" fill="#555"/><rect x="%[2]d" width="%[3]d" height="20" fill="%[6]s"/><rect width="%[1]d" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%[7]s" y="15" fill="#010101" fill-opacity=".3">%[4]s</text><text x="%[7]s" y="14">%[4]s</text>
+<text x="%[8]s" y="15" fill="#010101" fill-opacity=".3">%[5]s</text><text x="%[8]s" y="14">%[5]s</text>
+</g>
+</svg>
+`, width, labelWidth, messageWidth, label, message, html.EscapeString(b.Color),
+		center(0, labelWidth), center(labelWidth, messageWidth))
+	return err
+}
+
+// textWidth approximates the width in pixels of a text in 11px Verdana.
+func textWidth(s string) int {
+	var width float64
+	for _, r := range s {
+		switch {
+		case r == ' ' || r == '.' || r == ',' || r == ':' || r == 'i' || r == 'l' || r == 'j' || r == '!' || r == '|':
+			width += 3.9
+		case r == '%' || r == 'm' || r == 'w' || r == 'M' || r == 'W':
+			width += 11
+		default:
+			width += 7
+		}
+	}
+	return int(math.Ceil(width))
+}
+
+// center returns the x coordinate of the middle of a section.
+func center(x, width int) string {
+	return strconv.FormatFloat(float64(x)+float64(width)/2, 'f', -1, 64)
+}
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package badge
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"zntr.io/extproctor/internal/compare"
+)
+
+func results(statuses ...string) *compare.Results {
+	r := &compare.Results{}
+	for _, s := range statuses {
+		r.Tests = append(r.Tests, compare.ResultTest{Name: s, Status: s})
+	}
+	return r
+}
+
+func TestPassRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		results  *compare.Results
+		expected Badge
+	}{
+		{
+			name:     "all passed",
+			results:  results("passed", "passed", "skipped"),
+			expected: Badge{Label: "conformance", Message: "100% passed", Color: ColorBrightGreen},
+		},
+		{
+			name:     "rounded down",
+			results:  results(append(repeat("passed", 1999), "failed")...),
+			expected: Badge{Label: "conformance", Message: "99.9% passed", Color: ColorGreen},
+		},
+		{
+			name:     "half failed",
+			results:  results("passed", "failed"),
+			expected: Badge{Label: "conformance", Message: "50% passed", Color: ColorOrange},
+		},
+		{
+			name:     "all failed",
+			results:  results("failed"),
+			expected: Badge{Label: "conformance", Message: "0% passed", Color: ColorRed},
+		},
+		{
+			name:     "no tests",
+			results:  results("skipped"),
+			expected: Badge{Label: "conformance", Message: "no tests", Color: ColorLightGrey},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, PassRate("conformance", tt.results))
+		})
+	}
+}
+
+func repeat(s string, n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = s
+	}
+	return out
+}
+
+func TestBadge_WriteSVG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Badge{Label: "a<b", Message: "96% passed", Color: ColorGreen}.WriteSVG(&buf))
+
+	out := buf.String()
+	assert.Contains(t, out, `aria-label="a&lt;b: 96% passed"`)
+	assert.Contains(t, out, `fill="#97ca00"`)
+
+	// The badge is well-formed XML
+	decoder := xml.NewDecoder(&buf)
+	for {
+		_, err := decoder.Token()
+		if err != nil {
+			assert.ErrorIs(t, err, io.EOF)
+			break
+		}
+	}
+}
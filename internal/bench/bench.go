@@ -0,0 +1,234 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package bench measures the latency and throughput of an ExtProc service
+// processing the requests of test cases, and compares them to a baseline.
+package bench
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+)
+
+// Processor sends a request through an ExtProc processing session.
+type Processor interface {
+	Process(ctx context.Context, req *extproctorv1.HttpRequest) (*client.ProcessingResult, error)
+}
+
+// Case is a request to benchmark.
+type Case struct {
+	// ID identifies the test case the request comes from.
+	ID      string
+	Request *extproctorv1.HttpRequest
+}
+
+// Benchmark sends the requests of test cases repeatedly and measures their
+// processing latency.
+type Benchmark struct {
+	processor   Processor
+	iterations  int
+	concurrency int
+	warmup      int
+	steadyCV    float64
+	steadyWin   int
+	maxWarmup   int
+}
+
+// Option configures the benchmark.
+type Option func(*Benchmark)
+
+// WithIterations sets the number of requests sent per test case.
+func WithIterations(n int) Option {
+	return func(b *Benchmark) {
+		b.iterations = n
+	}
+}
+
+// WithConcurrency sets the number of requests of a test case in flight at
+// the same time.
+func WithConcurrency(n int) Option {
+	return func(b *Benchmark) {
+		b.concurrency = n
+	}
+}
+
+// WithWarmup sets the number of requests sent per test case before measuring,
+// to exclude the connection setup, JIT compilation and cache filling effects
+// from the statistics.
+func WithWarmup(n int) Option {
+	return func(b *Benchmark) {
+		b.warmup = n
+	}
+}
+
+// WithSteadyState delays the measurement after the warm-up until the
+// coefficient of variation (standard deviation divided by mean) of the
+// latencies of the last window requests is at most cv. At most maxWarmup
+// requests are sent waiting for the steady state; the measurement then
+// starts anyway and the result is flagged as unsteady.
+func WithSteadyState(cv float64, window, maxWarmup int) Option {
+	return func(b *Benchmark) {
+		b.steadyCV = cv
+		b.steadyWin = window
+		b.maxWarmup = maxWarmup
+	}
+}
+
+// New creates a benchmark sending requests to the given processor.
+func New(processor Processor, opts ...Option) *Benchmark {
+	b := &Benchmark{
+		processor:   processor,
+		iterations:  100,
+		concurrency: 1,
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	b.iterations = max(b.iterations, 1)
+	b.concurrency = max(b.concurrency, 1)
+	b.warmup = max(b.warmup, 0)
+	b.steadyWin = max(b.steadyWin, 2)
+
+	return b
+}
+
+// Run benchmarks the test cases one after the other.
+func (b *Benchmark) Run(ctx context.Context, cases []Case) (*Report, error) {
+	report := &Report{}
+	for _, c := range cases {
+		result, err := b.measure(ctx, c.Request)
+		if err != nil {
+			return nil, err
+		}
+		result.ID = c.ID
+		report.Tests = append(report.Tests, result)
+	}
+
+	return report, nil
+}
+
+// measure warms up the service, then sends a request the configured number
+// of times, recording the latency of the processing sessions and of each of
+// their phases.
+func (b *Benchmark) measure(ctx context.Context, req *extproctorv1.HttpRequest) (*Result, error) {
+	result := &Result{Histogram: NewHistogram()}
+
+	warmup, steady, err := b.warmUp(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	result.Warmup = warmup
+	result.Unsteady = !steady
+
+	var (
+		latencies = make([]time.Duration, 0, b.iterations)
+		failures  int
+		phases    = map[extproctorv1.ProcessingPhase]*Histogram{}
+	)
+
+	start := time.Now()
+	err = b.send(ctx, req, b.iterations, func(latency time.Duration, procResult *client.ProcessingResult, err error) {
+		if err != nil {
+			failures++
+			return
+		}
+
+		latencies = append(latencies, latency)
+		result.Histogram.Record(latency)
+		for _, resp := range procResult.Responses {
+			h, ok := phases[resp.Phase]
+			if !ok {
+				h = NewHistogram()
+				phases[resp.Phase] = h
+			}
+			h.Record(resp.Latency)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Stats = newStats(latencies, failures, time.Since(start))
+
+	// Report the phases in processing order
+	for _, phase := range slices.Sorted(maps.Keys(phases)) {
+		result.Phases = append(result.Phases, &PhaseHistogram{
+			Phase:     phase.String(),
+			Histogram: phases[phase],
+		})
+	}
+
+	return result, nil
+}
+
+// warmUp sends the warm-up requests, then the requests waiting for the steady
+// state when enabled. It returns the number of requests sent and whether the
+// steady state was reached.
+func (b *Benchmark) warmUp(ctx context.Context, req *extproctorv1.HttpRequest) (int, bool, error) {
+	ignore := func(time.Duration, *client.ProcessingResult, error) {}
+	if err := b.send(ctx, req, b.warmup, ignore); err != nil {
+		return 0, false, err
+	}
+	if b.steadyCV <= 0 {
+		return b.warmup, true, nil
+	}
+
+	sent := b.warmup
+	for sent < b.warmup+b.maxWarmup {
+		window := make([]time.Duration, 0, b.steadyWin)
+		n := min(b.steadyWin, b.warmup+b.maxWarmup-sent)
+		err := b.send(ctx, req, n, func(latency time.Duration, _ *client.ProcessingResult, err error) {
+			if err == nil {
+				window = append(window, latency)
+			}
+		})
+		if err != nil {
+			return 0, false, err
+		}
+		sent += n
+
+		if len(window) == b.steadyWin && variation(window) <= b.steadyCV {
+			return sent, true, nil
+		}
+	}
+
+	return sent, false, nil
+}
+
+// send sends a request n times with the configured concurrency, passing the
+// latency and outcome of each request to record, which calls are serialized.
+func (b *Benchmark) send(ctx context.Context, req *extproctorv1.HttpRequest, n int, record func(time.Duration, *client.ProcessingResult, error)) error {
+	var (
+		mu   sync.Mutex
+		sent atomic.Int64
+		wg   sync.WaitGroup
+	)
+
+	for range min(b.concurrency, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for sent.Add(1) <= int64(n) && ctx.Err() == nil {
+				begin := time.Now()
+				procResult, err := b.processor.Process(ctx, req)
+				latency := time.Since(begin)
+
+				mu.Lock()
+				record(latency, procResult, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package bench
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+)
+
+// fakeProcessor fails every failEvery-th request.
+type fakeProcessor struct {
+	calls     atomic.Int64
+	failEvery int64
+}
+
+func (p *fakeProcessor) Process(ctx context.Context, req *extproctorv1.HttpRequest) (*client.ProcessingResult, error) {
+	n := p.calls.Add(1)
+	if p.failEvery > 0 && n%p.failEvery == 0 {
+		return nil, errors.New("boom")
+	}
+	return &client.ProcessingResult{Responses: []*client.PhaseResponse{
+		{Phase: extproctorv1.ProcessingPhase_RESPONSE_HEADERS, Latency: 2 * time.Millisecond},
+		{Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS, Latency: time.Millisecond},
+	}}, nil
+}
+
+func TestBenchmark_Run(t *testing.T) {
+	p := &fakeProcessor{failEvery: 5}
+	b := New(p, WithIterations(20), WithConcurrency(4))
+
+	report, err := b.Run(context.Background(), []Case{
+		{ID: "a.textproto::one", Request: &extproctorv1.HttpRequest{}},
+		{ID: "a.textproto::two", Request: &extproctorv1.HttpRequest{}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(40), p.calls.Load())
+	if assert.Len(t, report.Tests, 2) {
+		assert.Equal(t, "a.textproto::one", report.Tests[0].ID)
+		assert.Equal(t, 20, report.Tests[0].Stats.Iterations)
+		assert.Equal(t, 4, report.Tests[0].Stats.Errors)
+		assert.Positive(t, report.Tests[0].Stats.Throughput)
+		assert.Equal(t, uint64(16), report.Tests[0].Histogram.Count())
+
+		phases := report.Tests[0].Phases
+		if assert.Len(t, phases, 2) {
+			assert.Equal(t, "REQUEST_HEADERS", phases[0].Phase)
+			assert.Equal(t, uint64(16), phases[0].Histogram.Count())
+			assert.InEpsilon(t, float64(time.Millisecond), float64(phases[0].Histogram.Quantile(0.5)), 0.01)
+			assert.Equal(t, "RESPONSE_HEADERS", phases[1].Phase)
+		}
+	}
+}
+
+func TestBenchmark_Run_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := New(&fakeProcessor{}).Run(ctx, []Case{{ID: "a", Request: &extproctorv1.HttpRequest{}}})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// jitteryProcessor alternates fast and slow requests for the first unsteady
+// requests, then answers at a constant pace.
+type jitteryProcessor struct {
+	calls    atomic.Int64
+	unsteady int64
+}
+
+func (p *jitteryProcessor) Process(ctx context.Context, req *extproctorv1.HttpRequest) (*client.ProcessingResult, error) {
+	n := p.calls.Add(1)
+	switch {
+	case n > p.unsteady:
+		time.Sleep(10 * time.Millisecond)
+	case n%2 == 0:
+		time.Sleep(20 * time.Millisecond)
+	}
+	return &client.ProcessingResult{}, nil
+}
+
+func TestBenchmark_Run_Warmup(t *testing.T) {
+	p := &fakeProcessor{}
+	report, err := New(p, WithIterations(10), WithWarmup(5)).Run(context.Background(), []Case{{ID: "a", Request: &extproctorv1.HttpRequest{}}})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(15), p.calls.Load())
+	assert.Equal(t, 5, report.Tests[0].Warmup)
+	assert.False(t, report.Tests[0].Unsteady)
+	assert.Equal(t, 10, report.Tests[0].Stats.Iterations)
+}
+
+func TestBenchmark_Run_SteadyState(t *testing.T) {
+	p := &jitteryProcessor{unsteady: 10}
+	report, err := New(p, WithIterations(5), WithWarmup(2), WithSteadyState(0.8, 4, 100)).Run(context.Background(), []Case{{ID: "a", Request: &extproctorv1.HttpRequest{}}})
+	require.NoError(t, err)
+
+	// 2 warm-up requests, 2 unsteady windows, then a steady one
+	assert.Equal(t, 14, report.Tests[0].Warmup)
+	assert.False(t, report.Tests[0].Unsteady)
+	assert.Equal(t, int64(19), p.calls.Load())
+}
+
+func TestBenchmark_Run_SteadyStateNotReached(t *testing.T) {
+	p := &jitteryProcessor{unsteady: 100}
+	report, err := New(p, WithIterations(1), WithSteadyState(0.8, 4, 10)).Run(context.Background(), []Case{{ID: "a", Request: &extproctorv1.HttpRequest{}}})
+	require.NoError(t, err)
+
+	assert.Equal(t, 10, report.Tests[0].Warmup)
+	assert.True(t, report.Tests[0].Unsteady)
+	assert.Equal(t, int64(11), p.calls.Load())
+}
+
+func TestVariation(t *testing.T) {
+	assert.InDelta(t, 0, variation([]time.Duration{time.Second, time.Second}), 1e-9)
+	assert.InDelta(t, 0.5, variation([]time.Duration{time.Second, 3 * time.Second}), 1e-9)
+	assert.InDelta(t, 0, variation([]time.Duration{0, 0}), 1e-9)
+}
+
+func TestNewStats(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	stats := newStats(latencies, 2, time.Second)
+	assert.Equal(t, 102, stats.Iterations)
+	assert.Equal(t, 2, stats.Errors)
+	assert.Equal(t, Duration(time.Millisecond), stats.Min)
+	assert.Equal(t, Duration(50500*time.Microsecond), stats.Mean)
+	assert.Equal(t, Duration(50*time.Millisecond), stats.P50)
+	assert.Equal(t, Duration(95*time.Millisecond), stats.P95)
+	assert.Equal(t, Duration(99*time.Millisecond), stats.P99)
+	assert.Equal(t, Duration(100*time.Millisecond), stats.Max)
+	assert.InDelta(t, 100.0, stats.Throughput, 0.001)
+}
+
+func TestNewStats_NoSuccess(t *testing.T) {
+	stats := newStats(nil, 3, time.Second)
+	assert.Equal(t, Stats{Iterations: 3, Errors: 3}, stats)
+}
+
+func TestCompare(t *testing.T) {
+	baseline := &Report{Tests: []*Result{
+		{ID: "fast", Stats: Stats{P95: Duration(10 * time.Millisecond)}},
+		{ID: "slow", Stats: Stats{P95: Duration(10 * time.Millisecond)}},
+	}}
+	current := &Report{Tests: []*Result{
+		{ID: "fast", Stats: Stats{P95: Duration(11 * time.Millisecond)}},
+		{ID: "slow", Stats: Stats{P95: Duration(15 * time.Millisecond)}},
+		{ID: "new", Stats: Stats{P95: Duration(time.Second)}},
+	}}
+
+	regressions := Compare(baseline, current, 0.1)
+	if assert.Len(t, regressions, 1) {
+		assert.Equal(t, "slow", regressions[0].ID)
+		assert.Equal(t, Duration(10*time.Millisecond), regressions[0].Baseline)
+		assert.Equal(t, Duration(15*time.Millisecond), regressions[0].Current)
+		assert.InDelta(t, 0.5, regressions[0].Change, 0.001)
+	}
+}
+
+func TestParseThreshold(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    float64
+		wantErr bool
+	}{
+		{"10%", 0.1, false},
+		{" 2.5 % ", 0.025, false},
+		{"0.2", 0.2, false},
+		{"0", 0, false},
+		{"-5%", 0, true},
+		{"ten", 0, true},
+		{"NaN", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseThreshold(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.InDelta(t, tt.want, got, 1e-9)
+		})
+	}
+}
+
+func TestWriteReport_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	report := &Report{Tests: []*Result{
+		{ID: "a", Stats: Stats{Iterations: 10, P95: Duration(1500 * time.Microsecond), Throughput: 42}},
+	}}
+
+	require.NoError(t, WriteReport(path, report))
+
+	got, err := ReadReport(path)
+	require.NoError(t, err)
+	assert.Equal(t, report, got)
+}
+
+func TestReadReport_Invalid(t *testing.T) {
+	_, err := ReadReport(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
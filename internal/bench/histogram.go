@@ -0,0 +1,196 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"time"
+)
+
+// precisionBits sets the number of linear sub-buckets per power of two of a
+// histogram: values are recorded with a relative error below 2^-(bits-1),
+// i.e. 0.8%.
+const precisionBits = 8
+
+// Histogram is an HDR-style log-linear latency histogram: values below
+// 2^precisionBits nanoseconds have their own bucket, and each following power
+// of two is split in 2^(precisionBits-1) buckets of equal width. Histograms
+// with the same layout are merged by adding their counts, so the histograms
+// of several runs can be combined without losing precision.
+type Histogram struct {
+	counts []uint64
+	total  uint64
+	sum    time.Duration
+}
+
+// NewHistogram creates an empty histogram.
+func NewHistogram() *Histogram {
+	return &Histogram{}
+}
+
+// Record adds a latency to the histogram. Negative latencies are recorded as
+// zero.
+func (h *Histogram) Record(d time.Duration) {
+	d = max(d, 0)
+	i := bucketIndex(uint64(d))
+	if i >= len(h.counts) {
+		h.counts = append(h.counts, make([]uint64, i-len(h.counts)+1)...)
+	}
+	h.counts[i]++
+	h.total++
+	h.sum += d
+}
+
+// Merge adds the counts of another histogram.
+func (h *Histogram) Merge(o *Histogram) {
+	if len(o.counts) > len(h.counts) {
+		h.counts = append(h.counts, make([]uint64, len(o.counts)-len(h.counts))...)
+	}
+	for i, c := range o.counts {
+		h.counts[i] += c
+	}
+	h.total += o.total
+	h.sum += o.sum
+}
+
+// Count returns the number of recorded latencies.
+func (h *Histogram) Count() uint64 {
+	return h.total
+}
+
+// Sum returns the sum of the recorded latencies.
+func (h *Histogram) Sum() time.Duration {
+	return h.sum
+}
+
+// Quantile returns the highest latency equivalent to the given quantile (0.95
+// for p95) within the histogram precision, or 0 when the histogram is empty.
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+
+	rank := uint64(q*float64(h.total) + 0.5)
+	rank = min(max(rank, 1), h.total)
+
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			_, upper := bucketBounds(i)
+			return time.Duration(upper)
+		}
+	}
+	return 0
+}
+
+// Bucket is a non-empty histogram bucket, holding the latencies between Lower
+// and Upper inclusive.
+type Bucket struct {
+	Lower time.Duration
+	Upper time.Duration
+	Count uint64
+}
+
+// Buckets returns the non-empty buckets, in increasing order.
+func (h *Histogram) Buckets() []Bucket {
+	var buckets []Bucket
+	for i, c := range h.counts {
+		if c == 0 {
+			continue
+		}
+		lower, upper := bucketBounds(i)
+		buckets = append(buckets, Bucket{Lower: time.Duration(lower), Upper: time.Duration(upper), Count: c})
+	}
+	return buckets
+}
+
+// jsonHistogram is the JSON encoding of a histogram, keeping the non-empty
+// buckets only. Bounds are in nanoseconds so that the buckets can be plotted
+// and merged exactly.
+type jsonHistogram struct {
+	PrecisionBits int          `json:"precision_bits"`
+	Count         uint64       `json:"count"`
+	SumNanos      int64        `json:"sum_ns"`
+	Buckets       []jsonBucket `json:"buckets"`
+}
+
+type jsonBucket struct {
+	LowerNanos int64  `json:"lower_ns"`
+	UpperNanos int64  `json:"upper_ns"`
+	Count      uint64 `json:"count"`
+}
+
+// MarshalJSON encodes the non-empty buckets of the histogram.
+func (h *Histogram) MarshalJSON() ([]byte, error) {
+	out := jsonHistogram{
+		PrecisionBits: precisionBits,
+		Count:         h.total,
+		SumNanos:      int64(h.sum),
+		Buckets:       []jsonBucket{},
+	}
+	for _, b := range h.Buckets() {
+		out.Buckets = append(out.Buckets, jsonBucket{LowerNanos: int64(b.Lower), UpperNanos: int64(b.Upper), Count: b.Count})
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a histogram encoded by MarshalJSON.
+func (h *Histogram) UnmarshalJSON(data []byte) error {
+	var in jsonHistogram
+	if err := json.Unmarshal(data, &in); err != nil {
+		return fmt.Errorf("invalid histogram: %w", err)
+	}
+	if in.PrecisionBits != precisionBits {
+		return fmt.Errorf("invalid histogram: unsupported precision of %d bits, expected %d", in.PrecisionBits, precisionBits)
+	}
+
+	*h = Histogram{sum: time.Duration(in.SumNanos)}
+	for _, b := range in.Buckets {
+		if b.LowerNanos < 0 {
+			return fmt.Errorf("invalid histogram: negative bucket bound %d", b.LowerNanos)
+		}
+		i := bucketIndex(uint64(b.LowerNanos))
+		if lower, _ := bucketBounds(i); lower != uint64(b.LowerNanos) {
+			return fmt.Errorf("invalid histogram: %d is not a bucket bound", b.LowerNanos)
+		}
+		if i >= len(h.counts) {
+			h.counts = append(h.counts, make([]uint64, i-len(h.counts)+1)...)
+		}
+		h.counts[i] += b.Count
+		h.total += b.Count
+	}
+	if h.total != in.Count {
+		return fmt.Errorf("invalid histogram: bucket counts sum to %d, expected %d", h.total, in.Count)
+	}
+
+	return nil
+}
+
+// bucketIndex returns the index of the bucket holding a value.
+func bucketIndex(v uint64) int {
+	const linear = 1 << precisionBits
+	if v < linear {
+		return int(v)
+	}
+
+	// Keep the precisionBits most significant bits of the value
+	shift := bits.Len64(v) - precisionBits
+	mantissa := v >> shift
+	return linear + (shift-1)*(linear/2) + int(mantissa-linear/2)
+}
+
+// bucketBounds returns the lowest and highest values of a bucket.
+func bucketBounds(i int) (lower, upper uint64) {
+	const linear = 1 << precisionBits
+	if i < linear {
+		return uint64(i), uint64(i)
+	}
+
+	shift := (i-linear)/(linear/2) + 1
+	mantissa := uint64(linear/2 + (i-linear)%(linear/2))
+	return mantissa << shift, (mantissa+1)<<shift - 1
+}
@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package bench
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucketBounds(t *testing.T) {
+	for _, v := range []uint64{0, 1, 255, 256, 257, 511, 512, 1000, 123456789, math.MaxInt64} {
+		i := bucketIndex(v)
+		lower, upper := bucketBounds(i)
+		assert.LessOrEqual(t, lower, v, "value %d", v)
+		assert.GreaterOrEqual(t, upper, v, "value %d", v)
+		assert.LessOrEqual(t, float64(upper-lower), float64(v)/128, "value %d", v)
+
+		// Buckets are contiguous
+		if i > 0 {
+			_, prevUpper := bucketBounds(i - 1)
+			assert.Equal(t, lower, prevUpper+1, "value %d", v)
+		}
+	}
+}
+
+func TestHistogram_Quantile(t *testing.T) {
+	h := NewHistogram()
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Microsecond)
+	}
+
+	assert.Equal(t, uint64(1000), h.Count())
+	assert.Equal(t, 500500*time.Microsecond, h.Sum())
+	assert.InEpsilon(t, float64(500*time.Microsecond), float64(h.Quantile(0.5)), 0.01)
+	assert.InEpsilon(t, float64(950*time.Microsecond), float64(h.Quantile(0.95)), 0.01)
+	assert.InEpsilon(t, float64(time.Millisecond), float64(h.Quantile(1)), 0.01)
+	assert.Equal(t, time.Duration(0), NewHistogram().Quantile(0.5))
+}
+
+func TestHistogram_Merge(t *testing.T) {
+	a, b, all := NewHistogram(), NewHistogram(), NewHistogram()
+	for i := range 100 {
+		d := time.Duration(i*i) * time.Microsecond
+		all.Record(d)
+		if i%2 == 0 {
+			a.Record(d)
+		} else {
+			b.Record(d)
+		}
+	}
+
+	a.Merge(b)
+	assert.Equal(t, all.Buckets(), a.Buckets())
+	assert.Equal(t, all.Count(), a.Count())
+	assert.Equal(t, all.Sum(), a.Sum())
+}
+
+func TestHistogram_JSONRoundTrip(t *testing.T) {
+	h := NewHistogram()
+	h.Record(3 * time.Millisecond)
+	h.Record(3 * time.Millisecond)
+	h.Record(40 * time.Nanosecond)
+
+	data, err := json.Marshal(h)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `{"lower_ns":40,"upper_ns":40,"count":1}`)
+
+	var got Histogram
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, h.Buckets(), got.Buckets())
+	assert.Equal(t, h.Count(), got.Count())
+	assert.Equal(t, h.Sum(), got.Sum())
+}
+
+func TestHistogram_UnmarshalJSON_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"precision": `{"precision_bits":4,"count":0,"buckets":[]}`,
+		"bound":     `{"precision_bits":8,"count":1,"buckets":[{"lower_ns":257,"upper_ns":258,"count":1}]}`,
+		"negative":  `{"precision_bits":8,"count":1,"buckets":[{"lower_ns":-1,"upper_ns":0,"count":1}]}`,
+		"count":     `{"precision_bits":8,"count":2,"buckets":[{"lower_ns":1,"upper_ns":1,"count":1}]}`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			var h Histogram
+			assert.Error(t, json.Unmarshal([]byte(data), &h))
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package bench
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// OpenMetrics metric families of the benchmark histograms.
+const (
+	sessionMetric = "extproctor_bench_session_latency_seconds"
+	phaseMetric   = "extproctor_bench_phase_latency_seconds"
+)
+
+// WriteOpenMetrics writes the histograms of a report in the OpenMetrics text
+// format, one series per test case (and phase), with a bucket per non-empty
+// histogram bucket.
+func WriteOpenMetrics(w io.Writer, r *Report) error {
+	bw := bufio.NewWriter(w)
+
+	writeFamily(bw, sessionMetric, "Latency of the ExtProc processing sessions.")
+	for _, t := range r.Tests {
+		if t.Histogram != nil {
+			writeHistogram(bw, sessionMetric, fmt.Sprintf("test=%s", labelValue(t.ID)), t.Histogram)
+		}
+	}
+
+	writeFamily(bw, phaseMetric, "Latency of the ExtProc processing phases.")
+	for _, t := range r.Tests {
+		for _, p := range t.Phases {
+			writeHistogram(bw, phaseMetric, fmt.Sprintf("test=%s,phase=%s", labelValue(t.ID), labelValue(p.Phase)), p.Histogram)
+		}
+	}
+
+	_, _ = bw.WriteString("# EOF\n")
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write OpenMetrics: %w", err)
+	}
+	return nil
+}
+
+// writeFamily writes the metadata of a histogram metric family.
+func writeFamily(w *bufio.Writer, name, help string) {
+	_, _ = fmt.Fprintf(w, "# TYPE %s histogram\n# UNIT %s seconds\n# HELP %s %s\n", name, name, name, help)
+}
+
+// writeHistogram writes the cumulative buckets, count and sum of a histogram.
+func writeHistogram(w *bufio.Writer, name, labels string, h *Histogram) {
+	var cumulative uint64
+	for _, b := range h.Buckets() {
+		cumulative += b.Count
+		_, _ = fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, seconds(b.Upper.Seconds()), cumulative)
+	}
+	_, _ = fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.Count())
+	_, _ = fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.Count())
+	_, _ = fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, seconds(h.Sum().Seconds()))
+}
+
+// seconds formats a number of seconds.
+func seconds(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// labelValue quotes and escapes an OpenMetrics label value.
+func labelValue(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
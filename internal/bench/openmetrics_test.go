@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package bench
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteOpenMetrics(t *testing.T) {
+	session := NewHistogram()
+	session.Record(100 * time.Nanosecond)
+	session.Record(200 * time.Nanosecond)
+	phase := NewHistogram()
+	phase.Record(50 * time.Nanosecond)
+
+	report := &Report{Tests: []*Result{{
+		ID:        `a.textproto::say "hi"`,
+		Histogram: session,
+		Phases:    []*PhaseHistogram{{Phase: "REQUEST_HEADERS", Histogram: phase}},
+	}}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteOpenMetrics(&buf, report))
+
+	assert.Equal(t, `# TYPE extproctor_bench_session_latency_seconds histogram
+# UNIT extproctor_bench_session_latency_seconds seconds
+# HELP extproctor_bench_session_latency_seconds Latency of the ExtProc processing sessions.
+extproctor_bench_session_latency_seconds_bucket{test="a.textproto::say \"hi\"",le="1e-07"} 1
+extproctor_bench_session_latency_seconds_bucket{test="a.textproto::say \"hi\"",le="2e-07"} 2
+extproctor_bench_session_latency_seconds_bucket{test="a.textproto::say \"hi\"",le="+Inf"} 2
+extproctor_bench_session_latency_seconds_count{test="a.textproto::say \"hi\""} 2
+extproctor_bench_session_latency_seconds_sum{test="a.textproto::say \"hi\""} 3e-07
+# TYPE extproctor_bench_phase_latency_seconds histogram
+# UNIT extproctor_bench_phase_latency_seconds seconds
+# HELP extproctor_bench_phase_latency_seconds Latency of the ExtProc processing phases.
+extproctor_bench_phase_latency_seconds_bucket{test="a.textproto::say \"hi\"",phase="REQUEST_HEADERS",le="5e-08"} 1
+extproctor_bench_phase_latency_seconds_bucket{test="a.textproto::say \"hi\"",phase="REQUEST_HEADERS",le="+Inf"} 1
+extproctor_bench_phase_latency_seconds_count{test="a.textproto::say \"hi\"",phase="REQUEST_HEADERS"} 1
+extproctor_bench_phase_latency_seconds_sum{test="a.textproto::say \"hi\"",phase="REQUEST_HEADERS"} 5e-08
+# EOF
+`, buf.String())
+}
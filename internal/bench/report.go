@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Report contains the statistics of the benchmarked test cases.
+type Report struct {
+	Tests []*Result `json:"tests"`
+}
+
+// Result contains the statistics of a test case.
+type Result struct {
+	ID    string `json:"id"`
+	Stats Stats  `json:"stats"`
+
+	// Warmup is the number of requests sent before measuring, and Unsteady
+	// is set when the steady state was not reached within the maximum
+	// number of warm-up requests.
+	Warmup   int  `json:"warmup,omitempty"`
+	Unsteady bool `json:"unsteady,omitempty"`
+
+	// Histogram holds the latencies of the successful processing sessions,
+	// Phases the latencies of each of their phases.
+	Histogram *Histogram        `json:"histogram,omitempty"`
+	Phases    []*PhaseHistogram `json:"phases,omitempty"`
+}
+
+// PhaseHistogram holds the latencies of a processing phase.
+type PhaseHistogram struct {
+	Phase     string     `json:"phase"`
+	Histogram *Histogram `json:"histogram"`
+}
+
+// Find returns the result of a test case, or nil.
+func (r *Report) Find(id string) *Result {
+	for _, t := range r.Tests {
+		if t.ID == id {
+			return t
+		}
+	}
+	return nil
+}
+
+// ReadReport reads a report written by WriteReport.
+func ReadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read benchmark report: %w", err)
+	}
+
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse benchmark report %s: %w", path, err)
+	}
+
+	return &r, nil
+}
+
+// WriteReport writes a report as JSON.
+func WriteReport(path string, r *Report) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal benchmark report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write benchmark report: %w", err)
+	}
+	return nil
+}
+
+// Regression is a test case whose p95 latency increased beyond the allowed
+// threshold compared to the baseline.
+type Regression struct {
+	ID       string
+	Baseline Duration
+	Current  Duration
+
+	// Change is the relative increase of the p95 latency (0.25 for +25%).
+	Change float64
+}
+
+// Compare returns the test cases whose p95 latency regressed by more than
+// maxRegression (0.1 for 10%) compared to the baseline. The test cases missing
+// from the baseline, or without successful requests, are not compared.
+func Compare(baseline, current *Report, maxRegression float64) []Regression {
+	var regressions []Regression
+	for _, t := range current.Tests {
+		base := baseline.Find(t.ID)
+		if base == nil || base.Stats.P95 <= 0 || t.Stats.P95 <= 0 {
+			continue
+		}
+
+		change := Change(base.Stats.P95, t.Stats.P95)
+		if change > maxRegression {
+			regressions = append(regressions, Regression{
+				ID:       t.ID,
+				Baseline: base.Stats.P95,
+				Current:  t.Stats.P95,
+				Change:   change,
+			})
+		}
+	}
+
+	return regressions
+}
+
+// Change returns the relative change from a baseline latency.
+func Change(baseline, current Duration) float64 {
+	return float64(current-baseline) / float64(baseline)
+}
+
+// ParseThreshold parses a regression threshold such as "10%" or "0.1".
+func ParseThreshold(s string) (float64, error) {
+	trimmed := strings.TrimSpace(s)
+	percent := strings.HasSuffix(trimmed, "%")
+
+	v, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(trimmed, "%")), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid threshold %q: expected a value like \"10%%\" or \"0.1\"", s)
+	}
+	if percent {
+		v /= 100
+	}
+	if v < 0 || math.IsNaN(v) {
+		return 0, fmt.Errorf("invalid threshold %q: must not be negative", s)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+// Duration is a time.Duration encoded as a duration string (e.g. "1.5ms") in
+// JSON.
+type Duration time.Duration
+
+// MarshalJSON encodes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON decodes a duration string.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid duration: %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration: %w", err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// Stats summarizes the latencies of the requests sent for a test case.
+type Stats struct {
+	// Iterations is the number of requests sent, Errors the number of them
+	// that failed and are excluded from the latencies.
+	Iterations int `json:"iterations"`
+	Errors     int `json:"errors,omitempty"`
+
+	Min  Duration `json:"min"`
+	Mean Duration `json:"mean"`
+	P50  Duration `json:"p50"`
+	P95  Duration `json:"p95"`
+	P99  Duration `json:"p99"`
+	Max  Duration `json:"max"`
+
+	// Throughput is the number of successful requests per second.
+	Throughput float64 `json:"throughput"`
+}
+
+// newStats computes the statistics of the successful request latencies.
+func newStats(latencies []time.Duration, failures int, elapsed time.Duration) Stats {
+	stats := Stats{
+		Iterations: len(latencies) + failures,
+		Errors:     failures,
+	}
+	if len(latencies) == 0 {
+		return stats
+	}
+
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+
+	var total time.Duration
+	for _, l := range sorted {
+		total += l
+	}
+
+	stats.Min = Duration(sorted[0])
+	stats.Mean = Duration(total / time.Duration(len(sorted)))
+	stats.P50 = Duration(percentile(sorted, 50))
+	stats.P95 = Duration(percentile(sorted, 95))
+	stats.P99 = Duration(percentile(sorted, 99))
+	stats.Max = Duration(sorted[len(sorted)-1])
+	if elapsed > 0 {
+		stats.Throughput = float64(len(sorted)) / elapsed.Seconds()
+	}
+
+	return stats
+}
+
+// variation returns the coefficient of variation of latencies: their standard
+// deviation divided by their mean.
+func variation(latencies []time.Duration) float64 {
+	var mean float64
+	for _, l := range latencies {
+		mean += float64(l)
+	}
+	mean /= float64(len(latencies))
+	if mean == 0 {
+		return 0
+	}
+
+	var variance float64
+	for _, l := range latencies {
+		variance += (float64(l) - mean) * (float64(l) - mean)
+	}
+	variance /= float64(len(latencies))
+
+	return math.Sqrt(variance) / mean
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
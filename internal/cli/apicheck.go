@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"zntr.io/extproctor/internal/apicheck"
+)
+
+var apicheckStrict bool
+
+var apicheckCmd = &cobra.Command{
+	Use:   "apicheck",
+	Short: "Detect ExtProc API fields not handled by extproctor",
+	Long: `Apicheck introspects the compiled Envoy ExtProc descriptors and reports
+the ProcessingResponse fields that the comparator or the golden converter do
+not handle yet.
+
+It fails when a field is neither handled nor a known gap, which typically
+happens after a go-control-plane update introduces a new field.
+
+Examples:
+  # Report new fields only
+  extproctor apicheck
+
+  # Also fail on known gaps
+  extproctor apicheck --strict`,
+	Args: cobra.NoArgs,
+	RunE: runAPICheck,
+}
+
+func init() {
+	apicheckCmd.Flags().BoolVar(&apicheckStrict, "strict", false, "Fail on known gaps as well as new fields")
+	rootCmd.AddCommand(apicheckCmd)
+}
+
+func runAPICheck(cmd *cobra.Command, args []string) error {
+	report := apicheck.Check()
+	printAPICheck(os.Stdout, report)
+
+	if n := len(report.New()); n > 0 {
+		return fmt.Errorf("%d new ExtProc field(s) not handled", n)
+	}
+	if n := len(report.Unhandled()); apicheckStrict && n > 0 {
+		return fmt.Errorf("%d ExtProc field(s) not handled", n)
+	}
+
+	return nil
+}
+
+func printAPICheck(w io.Writer, report *apicheck.Report) {
+	unhandled := report.Unhandled()
+
+	for _, f := range unhandled {
+		status := "known gap"
+		if f.New {
+			status = "NEW"
+		}
+		fmt.Fprintf(w, "%-9s  %s (comparator: %s, golden: %s)\n", status, f.Name, coverage(f.Comparator), coverage(f.Golden))
+	}
+
+	fmt.Fprintf(w, "Checked %d field(s): %d not handled, %d new\n", len(report.Fields), len(unhandled), len(report.New()))
+}
+
+func coverage(handled bool) string {
+	if handled {
+		return "yes"
+	}
+	return "no"
+}
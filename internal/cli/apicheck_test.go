@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"zntr.io/extproctor/internal/apicheck"
+)
+
+func TestAPICheckCmd_Registered(t *testing.T) {
+	found := false
+	for _, cmd := range rootCmd.Commands() {
+		if cmd.Name() == "apicheck" {
+			found = true
+			break
+		}
+	}
+	assert.True(t, found, "apicheck command should be registered")
+}
+
+func TestRunAPICheck(t *testing.T) {
+	apicheckStrict = false
+	assert.NoError(t, runAPICheck(&cobra.Command{}, nil))
+
+	apicheckStrict = true
+	defer func() { apicheckStrict = false }()
+	assert.Error(t, runAPICheck(&cobra.Command{}, nil))
+}
+
+func TestPrintAPICheck(t *testing.T) {
+	report := &apicheck.Report{
+		Fields: []apicheck.Field{
+			{Name: "a.B.handled", Comparator: true, Golden: true},
+			{Name: "a.B.gap", Golden: true},
+			{Name: "a.B.added", New: true},
+		},
+	}
+
+	var buf bytes.Buffer
+	printAPICheck(&buf, report)
+
+	out := buf.String()
+	assert.NotContains(t, out, "a.B.handled")
+	assert.Contains(t, out, "known gap  a.B.gap (comparator: no, golden: yes)")
+	assert.Contains(t, out, "NEW        a.B.added")
+	assert.Contains(t, out, "Checked 3 field(s): 2 not handled, 1 new")
+}
@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/attest"
+	"zntr.io/extproctor/internal/manifest"
+	"zntr.io/extproctor/internal/version"
+)
+
+var attestKey string
+
+var attestCmd = &cobra.Command{
+	Use:   "attest",
+	Short: "Verify signed result files",
+}
+
+var attestVerifyCmd = &cobra.Command{
+	Use:   "verify <results> [manifests...]",
+	Short: "Verify the provenance attestation of a JSON result file",
+	Long: `Attest verify checks the attestation written next to a JSON result file by
+run --sign-key (<results>.intoto.json) with the public key of the signer, and
+prints the targets and manifests it attests.
+
+When manifest paths are given, the command also fails unless the attestation
+lists each of their manifests with their current content, proving that the
+published results come from this test content.
+
+Examples:
+  # Verify a published conformance report
+  extproctor attest verify --key signer.pub results/latest.json
+
+  # Also verify that it comes from the manifests of ./tests/
+  extproctor attest verify --key signer.pub results/latest.json ./tests/`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runAttestVerify,
+}
+
+func init() {
+	attestVerifyCmd.Flags().StringVar(&attestKey, "key", "", "PEM public key of the signer")
+	_ = attestVerifyCmd.MarkFlagRequired("key")
+	attestCmd.AddCommand(attestVerifyCmd)
+	rootCmd.AddCommand(attestCmd)
+}
+
+func runAttestVerify(cmd *cobra.Command, args []string) error {
+	key, err := attest.LoadPublicKey(attestKey)
+	if err != nil {
+		return err
+	}
+
+	statement, err := attest.Verify(args[0], key)
+	if err != nil {
+		return fmt.Errorf("failed to verify %s: %w", args[0], err)
+	}
+
+	if len(args) > 1 {
+		loader, err := newLoader()
+		if err != nil {
+			return err
+		}
+		manifests, err := loadPaths(loader, args[1:])
+		if err != nil {
+			return fmt.Errorf("failed to load manifests: %w", err)
+		}
+		if err := statement.CheckManifests(manifestPaths(manifests)); err != nil {
+			return fmt.Errorf("failed to verify %s: %w", args[0], err)
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	_, _ = fmt.Fprintf(out, "Verified %s (extproctor %s)\n", args[0], statement.Predicate.Version)
+	for _, t := range statement.Predicate.Targets {
+		if t.Name != "" {
+			_, _ = fmt.Fprintf(out, "  target: %s (%s)\n", t.Name, t.Address)
+		} else {
+			_, _ = fmt.Fprintf(out, "  target: %s\n", t.Address)
+		}
+	}
+	for _, m := range statement.Predicate.Manifests {
+		_, _ = fmt.Fprintf(out, "  manifest: %s sha256:%s\n", m.Name, m.Digest["sha256"])
+	}
+	return nil
+}
+
+// manifestPaths returns the source paths of the loaded manifests.
+func manifestPaths(manifests []*manifest.LoadedManifest) []string {
+	paths := make([]string, 0, len(manifests))
+	for _, m := range manifests {
+		paths = append(paths, m.SourcePath)
+	}
+	return paths
+}
+
+// signedResultPaths returns the files of the json_file result sinks, which
+// --sign-key signs.
+func signedResultPaths(sinks []*extproctorv1.ResultSinkConfig) ([]string, error) {
+	var paths []string
+	for _, s := range sinks {
+		if f := s.GetJsonFile(); f != nil {
+			paths = append(paths, os.ExpandEnv(f.GetPath()))
+		}
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("--sign-key requires a json_file result sink in the configuration")
+	}
+	return paths, nil
+}
+
+// attestedTargets returns the identity of the targets of the run.
+func attestedTargets() []attest.Target {
+	if unixSocket != "" {
+		return []attest.Target{{Name: targetName, Address: "unix:" + unixSocket}}
+	}
+
+	var out []attest.Target
+	for _, value := range targets.values {
+		name, address, _ := parseTarget(value)
+		if name == address {
+			name = targetName
+		}
+		out = append(out, attest.Target{Name: name, Address: address})
+	}
+	return out
+}
+
+// signResults writes the attestation of the result files.
+func signResults(paths []string, manifests []*manifest.LoadedManifest, key *attest.Key) error {
+	digests, err := attest.ManifestDigests(manifestPaths(manifests))
+	if err != nil {
+		return err
+	}
+	predicate := attest.Predicate{
+		Version:   version.String(),
+		Targets:   attestedTargets(),
+		Manifests: digests,
+	}
+
+	for _, path := range paths {
+		attestation, err := attest.Sign(path, predicate, key)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Signed results: %s\n", attestation)
+	}
+	return nil
+}
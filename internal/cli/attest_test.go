@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTests_SignKey(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "test.textproto")
+	require.NoError(t, os.WriteFile(manifestPath, []byte(`
+name: "test-manifest"
+test_cases: {
+  name: "test-1"
+  request: { method: "GET", path: "/" }
+  expectations: { phase: REQUEST_HEADERS, headers_response: {} }
+}
+`), 0o644))
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	keyPath := filepath.Join(dir, "signer.pem")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	der, err = x509.MarshalPKIXPublicKey(public)
+	require.NoError(t, err)
+	publicPath := filepath.Join(dir, "signer.pub")
+	require.NoError(t, os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644))
+
+	oldConfigPath, oldSignKey, oldTargets := configPath, signKey, targets
+	defer func() {
+		configPath, signKey, targets = oldConfigPath, oldSignKey, oldTargets
+	}()
+	targets = targetList{values: []string{"eu=localhost:59999"}}
+	signKey = keyPath
+
+	// Only the json_file sinks are signed
+	configPath = filepath.Join(dir, "config.textproto")
+	require.NoError(t, os.WriteFile(configPath, nil, 0o644))
+	err = runTests(&cobra.Command{}, []string{manifestPath})
+	assert.EqualError(t, err, "--sign-key requires a json_file result sink in the configuration")
+
+	resultsPath := filepath.Join(dir, "results.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`result_sinks: { json_file: { path: "`+resultsPath+`" } }`), 0o644))
+	err = runTests(&cobra.Command{}, []string{manifestPath})
+	assert.EqualError(t, err, "1 test(s) failed")
+	assert.FileExists(t, resultsPath+".intoto.json")
+
+	out := &bytes.Buffer{}
+	attestVerifyCmd.SetOut(out)
+	attestKey = publicPath
+	require.NoError(t, runAttestVerify(attestVerifyCmd, []string{resultsPath, manifestPath}))
+	assert.Contains(t, out.String(), "  target: eu (localhost:59999)\n")
+	assert.Contains(t, out.String(), "  manifest: "+filepath.ToSlash(manifestPath)+" sha256:")
+
+	// Changed test content no longer matches the published results
+	require.NoError(t, os.WriteFile(manifestPath, []byte(`name: "other"`), 0o644))
+	err = runAttestVerify(attestVerifyCmd, []string{resultsPath, manifestPath})
+	assert.ErrorContains(t, err, "changed since the attestation")
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"zntr.io/extproctor/internal/badge"
+)
+
+var (
+	badgeOut   string
+	badgeLabel string
+)
+
+var badgeCmd = &cobra.Command{
+	Use:   "badge <results.json>",
+	Short: "Render a conformance badge from a JSON result file",
+	Long: `Badge renders a shields-style SVG badge with the pass rate of the tests of a
+JSON result file (--output json), skipped tests left out, to embed in the
+README of the filter repository. Generated by CI after each run, the badge
+stays up to date without any external service.
+
+The badge is printed, or written to --output.
+
+Examples:
+  # Render the badge of the last run
+  extproctor run ./tests/ --target localhost:50051 -o json > results.json
+  extproctor badge results.json -o badge.svg
+
+  # Use a custom label
+  extproctor badge results.json -o badge.svg --label "ext_proc conformance"`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runBadge,
+}
+
+func init() {
+	// Shadows the persistent --output format flag: the badge is always SVG
+	badgeCmd.Flags().StringVarP(&badgeOut, "output", "o", "", "Write the badge to this file instead of printing it")
+	badgeCmd.Flags().StringVar(&badgeLabel, "label", "extproctor", "Label of the badge")
+	rootCmd.AddCommand(badgeCmd)
+}
+
+func runBadge(cmd *cobra.Command, args []string) error {
+	results, err := readResultsFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := badge.PassRate(badgeLabel, results).WriteSVG(&buf); err != nil {
+		return err
+	}
+
+	if badgeOut == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(badgeOut, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write badge: %w", err)
+	}
+	return nil
+}
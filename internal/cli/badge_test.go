@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunBadge(t *testing.T) {
+	dir := t.TempDir()
+	results := filepath.Join(dir, "results.json")
+	require.NoError(t, os.WriteFile(results, []byte(`{"tests": [
+		{"name": "a", "status": "passed"},
+		{"name": "b", "status": "passed"},
+		{"name": "c", "status": "passed"},
+		{"name": "d", "status": "failed"},
+		{"name": "e", "status": "skipped"}
+	]}`), 0o644))
+
+	badgeOut = filepath.Join(dir, "badge.svg")
+	badgeLabel = "conformance"
+	defer func() { badgeOut, badgeLabel = "", "extproctor" }()
+
+	require.NoError(t, runBadge(&cobra.Command{}, []string{results}))
+
+	data, err := os.ReadFile(badgeOut)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `aria-label="conformance: 75% passed"`)
+
+	assert.Error(t, runBadge(&cobra.Command{}, []string{filepath.Join(dir, "missing.json")}))
+}
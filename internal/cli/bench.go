@@ -0,0 +1,289 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"zntr.io/extproctor/internal/bench"
+	"zntr.io/extproctor/internal/manifest"
+	"zntr.io/extproctor/internal/runner"
+)
+
+var (
+	benchIterations    int
+	benchBaseline      string
+	benchMaxRegression string
+	benchSave          string
+	benchOpenMetrics   string
+	benchWarmup        int
+	benchSteadyCV      string
+	benchSteadyWindow  int
+	benchMaxWarmup     int
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench [paths...]",
+	Short: "Benchmark the ExtProc service with the requests of test cases",
+	Long: `Bench sends the request of each test case repeatedly to the ExtProc service
+and reports its latency percentiles and throughput. Expectations are not
+checked; use run for that. --parallel sets the number of requests of a test
+case in flight at the same time.
+
+With --baseline, the p95 latency of each test case is compared to a report
+saved with --save, and the command fails when it regressed by more than
+--max-regression, turning the benchmark into a performance regression gate.
+
+Examples:
+  # Benchmark the tests of a directory
+  extproctor bench ./tests/ --target localhost:50051 --iterations 1000
+
+  # Warm up with 50 requests, then wait for a coefficient of variation below 5%
+  extproctor bench ./tests/ --target localhost:50051 --warmup 50 --steady-cv 5%
+
+  # Export the latency histograms in the OpenMetrics format
+  extproctor bench ./tests/ --target localhost:50051 --save report.json --openmetrics report.om
+
+  # Save a baseline
+  extproctor bench ./tests/ --target localhost:50051 --save baseline.json
+
+  # Fail when the p95 latency regressed by more than 10%
+  extproctor bench ./tests/ --target localhost:50051 --baseline baseline.json --max-regression 10%`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runBench,
+}
+
+func init() {
+	benchCmd.Flags().IntVar(&benchIterations, "iterations", 100, "Number of requests sent per test case")
+	benchCmd.Flags().StringVar(&benchBaseline, "baseline", "", "Benchmark report to compare the p95 latencies to")
+	benchCmd.Flags().StringVar(&benchMaxRegression, "max-regression", "10%", "Maximum p95 latency increase allowed over the baseline (e.g. 10%)")
+	benchCmd.Flags().StringVar(&benchSave, "save", "", "Write the benchmark report to this file, to be used as a baseline")
+	benchCmd.Flags().StringVar(&benchOpenMetrics, "openmetrics", "", "Write the latency histograms to this file in the OpenMetrics text format")
+	benchCmd.Flags().IntVar(&benchWarmup, "warmup", 0, "Number of requests sent per test case before measuring, excluded from the statistics")
+	benchCmd.Flags().StringVar(&benchSteadyCV, "steady-cv", "", "After the warm-up, wait until the coefficient of variation of the latencies is at most this value (e.g. 5%)")
+	benchCmd.Flags().IntVar(&benchSteadyWindow, "steady-window", 20, "Number of latencies the coefficient of variation is computed on")
+	benchCmd.Flags().IntVar(&benchMaxWarmup, "max-warmup", 1000, "Maximum number of requests sent waiting for the steady state")
+	addProfilingFlags(benchCmd)
+	rootCmd.AddCommand(benchCmd)
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	maxRegression, err := bench.ParseThreshold(benchMaxRegression)
+	if err != nil {
+		return fmt.Errorf("invalid --max-regression: %w", err)
+	}
+
+	var steadyCV float64
+	if benchSteadyCV != "" {
+		if steadyCV, err = bench.ParseThreshold(benchSteadyCV); err != nil {
+			return fmt.Errorf("invalid --steady-cv: %w", err)
+		}
+	}
+
+	var baseline *bench.Report
+	if benchBaseline != "" {
+		if baseline, err = bench.ReadReport(benchBaseline); err != nil {
+			return err
+		}
+	}
+
+	stop, err := startProfiling()
+	if err != nil {
+		return err
+	}
+	defer stopProfiling(stop)
+
+	loader, err := newLoader()
+	if err != nil {
+		return err
+	}
+	manifests, err := loadPaths(loader, args)
+	if err != nil {
+		return fmt.Errorf("failed to load manifests: %w", err)
+	}
+
+	cases := benchCases(manifests)
+	if len(cases) == 0 {
+		return fmt.Errorf("no test cases to benchmark in specified paths")
+	}
+
+	extProcClient, err := newClient()
+	if err != nil {
+		return fmt.Errorf("failed to create ExtProc client: %w", err)
+	}
+	defer func() { _ = extProcClient.Close() }()
+
+	b := bench.New(extProcClient,
+		bench.WithIterations(benchIterations),
+		bench.WithConcurrency(parallel),
+		bench.WithWarmup(benchWarmup),
+		bench.WithSteadyState(steadyCV, benchSteadyWindow, benchMaxWarmup),
+	)
+	report, err := b.Run(ctx, cases)
+	if err != nil {
+		return fmt.Errorf("benchmark failed: %w", err)
+	}
+
+	if benchSave != "" {
+		if err := bench.WriteReport(benchSave, report); err != nil {
+			return err
+		}
+	}
+
+	if benchOpenMetrics != "" {
+		if err := writeOpenMetricsFile(benchOpenMetrics, report); err != nil {
+			return err
+		}
+	}
+
+	var regressions []bench.Regression
+	if baseline != nil {
+		regressions = bench.Compare(baseline, report, maxRegression)
+	}
+
+	if output == "json" {
+		if err := printBenchJSON(os.Stdout, report, regressions); err != nil {
+			return err
+		}
+	} else {
+		printBenchReport(os.Stdout, report, baseline, verbose)
+	}
+
+	var failures int
+	for _, t := range report.Tests {
+		failures += t.Stats.Errors
+		if t.Unsteady {
+			fmt.Fprintf(os.Stderr, "WARNING: %s: steady state not reached after %d warm-up requests\n", t.ID, t.Warmup)
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d benchmark requests failed", failures)
+	}
+
+	if len(regressions) > 0 {
+		ids := make([]string, 0, len(regressions))
+		for _, r := range regressions {
+			ids = append(ids, fmt.Sprintf("%s (%s -> %s, %+.1f%%)", r.ID, r.Baseline, r.Current, r.Change*100))
+		}
+		return fmt.Errorf("p95 latency regressed by more than %s: %s", benchMaxRegression, strings.Join(ids, ", "))
+	}
+
+	return nil
+}
+
+// benchCases returns the requests of the test cases selected by the --filter
+// and --tags flags.
+func benchCases(manifests []*manifest.LoadedManifest) []bench.Case {
+	var cases []bench.Case
+	for _, m := range manifests {
+		if m.SkipReason != "" {
+			continue
+		}
+		for _, tc := range m.TestCases {
+			if tc.Request == nil {
+				continue
+			}
+			if filter != "" {
+				if matched, err := filepath.Match(filter, tc.Name); err != nil || !matched {
+					continue
+				}
+			}
+			if len(tags) > 0 && !slices.ContainsFunc(tc.Tags, func(tag string) bool {
+				return slices.ContainsFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) })
+			}) {
+				continue
+			}
+
+			cases = append(cases, bench.Case{
+				ID:      runner.TestID(m.SourcePath, tc.Name),
+				Request: tc.Request,
+			})
+		}
+	}
+	return cases
+}
+
+// writeOpenMetricsFile writes the histograms of a report to a file in the
+// OpenMetrics text format.
+func writeOpenMetricsFile(path string, report *bench.Report) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create OpenMetrics file: %w", err)
+	}
+	if err := bench.WriteOpenMetrics(f, report); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// printBenchReport prints the statistics of each test case as a table, with
+// the p95 latency change when compared to a baseline, and the latency
+// percentiles of each phase when verbose.
+func printBenchReport(w io.Writer, report *bench.Report, baseline *bench.Report, verbose bool) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := "TEST\tITERATIONS\tERRORS\tP50\tP95\tP99\tMAX\tREQ/S"
+	if baseline != nil {
+		header += "\tP95 CHANGE"
+	}
+	_, _ = fmt.Fprintln(tw, header)
+
+	for _, t := range report.Tests {
+		s := t.Stats
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%.1f", t.ID, s.Iterations, s.Errors, s.P50, s.P95, s.P99, s.Max, s.Throughput)
+		if baseline != nil {
+			change := "—"
+			if base := baseline.Find(t.ID); base != nil && base.Stats.P95 > 0 && s.P95 > 0 {
+				change = fmt.Sprintf("%+.1f%%", bench.Change(base.Stats.P95, s.P95)*100)
+			}
+			_, _ = fmt.Fprintf(tw, "\t%s", change)
+		}
+		_, _ = fmt.Fprintln(tw)
+
+		if !verbose {
+			continue
+		}
+		for _, p := range t.Phases {
+			h := p.Histogram
+			_, _ = fmt.Fprintf(tw, "  %s\t%d\t\t%s\t%s\t%s\t%s\t\n", p.Phase, h.Count(), h.Quantile(0.5), h.Quantile(0.95), h.Quantile(0.99), h.Quantile(1))
+		}
+	}
+	_ = tw.Flush()
+}
+
+// printBenchJSON prints the benchmark report and the regressions as JSON.
+func printBenchJSON(w io.Writer, report *bench.Report, regressions []bench.Regression) error {
+	type jsonRegression struct {
+		ID       string         `json:"id"`
+		Baseline bench.Duration `json:"baseline_p95"`
+		Current  bench.Duration `json:"p95"`
+		Change   float64        `json:"change"`
+	}
+	out := struct {
+		*bench.Report
+		Regressions []jsonRegression `json:"regressions,omitempty"`
+	}{Report: report}
+	for _, r := range regressions {
+		out.Regressions = append(out.Regressions, jsonRegression{r.ID, r.Baseline, r.Current, r.Change})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
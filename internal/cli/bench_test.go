@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/bench"
+	"zntr.io/extproctor/internal/manifest"
+)
+
+func TestBenchCmd_HasFlags(t *testing.T) {
+	flags := benchCmd.Flags()
+	assert.Equal(t, "100", flags.Lookup("iterations").DefValue)
+	assert.Equal(t, "10%", flags.Lookup("max-regression").DefValue)
+	assert.NotNil(t, flags.Lookup("baseline"))
+	assert.NotNil(t, flags.Lookup("save"))
+}
+
+func TestBenchCases_Filters(t *testing.T) {
+	manifests := []*manifest.LoadedManifest{{
+		SourcePath: "tests/auth.textproto",
+		TestManifest: &extproctorv1.TestManifest{TestCases: []*extproctorv1.TestCase{
+			{Name: "auth-ok", Tags: []string{"Smoke"}, Request: &extproctorv1.HttpRequest{Method: "GET"}},
+			{Name: "auth-ko", Request: &extproctorv1.HttpRequest{Method: "GET"}},
+			{Name: "other", Tags: []string{"smoke"}, Request: &extproctorv1.HttpRequest{Method: "GET"}},
+			{Name: "auth-no-request", Tags: []string{"smoke"}},
+		}},
+	}}
+
+	filter, tags = "auth*", []string{"smoke"}
+	t.Cleanup(func() { filter, tags = "", nil })
+
+	cases := benchCases(manifests)
+	if assert.Len(t, cases, 1) {
+		assert.Equal(t, "tests/auth.textproto::auth-ok", cases[0].ID)
+	}
+}
+
+func TestPrintBenchReport(t *testing.T) {
+	report := &bench.Report{Tests: []*bench.Result{
+		{ID: "a::one", Stats: bench.Stats{Iterations: 10, P50: bench.Duration(time.Millisecond), P95: bench.Duration(3 * time.Millisecond)}},
+		{ID: "a::two", Stats: bench.Stats{Iterations: 10, P95: bench.Duration(time.Millisecond)}},
+	}}
+	baseline := &bench.Report{Tests: []*bench.Result{
+		{ID: "a::one", Stats: bench.Stats{P95: bench.Duration(2 * time.Millisecond)}},
+	}}
+
+	var buf bytes.Buffer
+	printBenchReport(&buf, report, baseline, false)
+
+	out := buf.String()
+	assert.Contains(t, out, "P95 CHANGE")
+	assert.Regexp(t, `a::one\s+10\s+0\s+1ms\s+3ms.*\+50\.0%`, out)
+	assert.Regexp(t, `a::two\s+.*—`, out)
+}
+
+func TestPrintBenchReport_VerbosePhases(t *testing.T) {
+	h := bench.NewHistogram()
+	h.Record(100 * time.Microsecond)
+	report := &bench.Report{Tests: []*bench.Result{{
+		ID:     "a::one",
+		Stats:  bench.Stats{Iterations: 1},
+		Phases: []*bench.PhaseHistogram{{Phase: "REQUEST_HEADERS", Histogram: h}},
+	}}}
+
+	var buf bytes.Buffer
+	printBenchReport(&buf, report, nil, false)
+	assert.NotContains(t, buf.String(), "REQUEST_HEADERS")
+
+	buf.Reset()
+	printBenchReport(&buf, report, nil, true)
+	assert.Regexp(t, `\n  REQUEST_HEADERS\s+1\s+100\.\d+µs`, buf.String())
+}
+
+func TestPrintBenchJSON(t *testing.T) {
+	report := &bench.Report{Tests: []*bench.Result{
+		{ID: "a::one", Stats: bench.Stats{Iterations: 10, P95: bench.Duration(3 * time.Millisecond)}},
+	}}
+	regressions := []bench.Regression{
+		{ID: "a::one", Baseline: bench.Duration(2 * time.Millisecond), Current: bench.Duration(3 * time.Millisecond), Change: 0.5},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, printBenchJSON(&buf, report, regressions))
+	assert.Contains(t, buf.String(), `"p95": "3ms"`)
+	assert.Contains(t, buf.String(), `"baseline_p95": "2ms"`)
+	assert.Contains(t, buf.String(), `"change": 0.5`)
+}
@@ -5,12 +5,29 @@ package cli
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"zntr.io/extproctor/internal/units"
 )
 
+func TestMain(m *testing.M) {
+	// Keep the failed tests and durations recorded by runs out of the source tree
+	dir, err := os.MkdirTemp("", "extproctor-cli")
+	if err != nil {
+		panic(err)
+	}
+	lastFailedPath = filepath.Join(dir, "last-failed.json")
+	durationsPath = filepath.Join(dir, "durations.json")
+
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 func TestRootCmd_Basic(t *testing.T) {
 	assert.NotNil(t, rootCmd)
 	assert.Equal(t, "extproctor", rootCmd.Use)
@@ -98,3 +115,87 @@ func TestExecute_NoArgs(t *testing.T) {
 	err := Execute()
 	assert.NoError(t, err)
 }
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		value   string
+		name    string
+		address string
+		err     string
+	}{
+		{value: "localhost:50051", name: "localhost:50051", address: "localhost:50051"},
+		{value: "eu=eu.internal:50051", name: "eu", address: "eu.internal:50051"},
+		{value: "[::1]:50051", name: "[::1]:50051", address: "[::1]:50051"},
+		{value: "v6=[2001:db8::1]:50051", name: "v6", address: "[2001:db8::1]:50051"},
+		{value: "dns:///extproc.internal:50051", name: "dns:///extproc.internal:50051", address: "dns:///extproc.internal:50051"},
+		{value: "::1:50051", err: `invalid target "::1:50051": IPv6 addresses must be bracketed, e.g. [::1]:50051`},
+		{value: "eu=", err: `invalid target "eu=": expected address or name=address`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			name, address, err := parseTarget(tt.value)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.name, name)
+			assert.Equal(t, tt.address, address)
+		})
+	}
+}
+
+func TestTargetList(t *testing.T) {
+	l := targetList{values: []string{defaultTarget}}
+
+	// The first target replaces the default one
+	require.NoError(t, l.Set("eu=eu.internal:50051"))
+	require.NoError(t, l.Set("us=us.internal:50051"))
+	assert.Equal(t, []string{"eu=eu.internal:50051", "us=us.internal:50051"}, l.values)
+	assert.Equal(t, "eu=eu.internal:50051,us=us.internal:50051", l.String())
+
+	assert.Error(t, l.Set("::1:50051"))
+}
+
+func TestForbidInCI(t *testing.T) {
+	defer func() { ciMode, updateGolden, fmtWrite, migrateWrite = false, false, false, false }()
+	ciMode, updateGolden, fmtWrite, migrateWrite = true, true, true, true
+
+	manifestPath := filepath.Join(t.TempDir(), "test.textproto")
+	require.NoError(t, os.WriteFile(manifestPath, []byte(`name:"test"`), 0o644))
+	assert.EqualError(t, runTests(&cobra.Command{}, []string{manifestPath}), "--update-golden rewrites expectation files, which --ci forbids")
+	assert.EqualError(t, runFmt(fmtCmd, []string{manifestPath}), "fmt --write rewrites expectation files, which --ci forbids")
+	assert.EqualError(t, runMigrate(migrateCmd, []string{manifestPath}), "migrate --write rewrites expectation files, which --ci forbids")
+	assert.EqualError(t, runGoldenUpgrade(goldenUpgradeCmd, []string{manifestPath}), "golden upgrade rewrites expectation files, which --ci forbids")
+	assert.EqualError(t, triageTests(triageCmd, []string{manifestPath}), "triage rewrites expectation files, which --ci forbids")
+
+	// Checks are still allowed
+	fmtWrite = false
+	assert.NoError(t, runFmt(fmtCmd, []string{manifestPath}))
+	content, err := os.ReadFile(manifestPath)
+	require.NoError(t, err)
+	assert.Equal(t, `name:"test"`, string(content))
+}
+
+func TestHumanOptions(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("COLUMNS", "")
+	assert.Len(t, humanOptions(), 1)
+
+	t.Setenv("NO_COLOR", "1")
+	t.Setenv("COLUMNS", "100")
+	assert.Len(t, humanOptions(), 3)
+	assert.Equal(t, 100, terminalWidth())
+
+	defer func() { asciiOnly = false }()
+	asciiOnly = true
+	assert.Len(t, humanOptions(), 4)
+}
+
+func TestDurationFormatFlag(t *testing.T) {
+	value := durationFormatValue{format: units.DurationHuman}
+	require.NoError(t, value.Set("s"))
+	assert.Equal(t, "s", value.String())
+	assert.EqualError(t, value.Set("minutes"), `invalid duration format "minutes" (use ms, s or human)`)
+}
@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"zntr.io/extproctor/internal/compare"
+	"zntr.io/extproctor/internal/golden"
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare <a> <b>",
+	Short: "Show the semantic differences between two golden or result files",
+	Long: `Compare loads two golden files, or two JSON result files produced with
+--output json, and prints their semantic differences: added (+), removed (-)
+and changed (~) expectations or tests, field by field.
+
+The command fails when the files differ, like diff.
+
+Examples:
+  # Review a regenerated golden file
+  git show HEAD:golden/auth.textproto > /tmp/auth.old.textproto
+  extproctor compare /tmp/auth.old.textproto golden/auth.textproto
+
+  # Compare two test runs
+  extproctor compare before.json after.json`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE:         runCompare,
+}
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+}
+
+func runCompare(cmd *cobra.Command, args []string) error {
+	changes, err := compareFiles(args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	printChanges(os.Stdout, changes)
+
+	if len(changes) > 0 {
+		return fmt.Errorf("files differ")
+	}
+	return nil
+}
+
+// compareFiles compares two golden files, or two JSON result files when both
+// have the .json extension.
+func compareFiles(a, b string) ([]compare.Change, error) {
+	aJSON, bJSON := isJSONFile(a), isJSONFile(b)
+	if aJSON != bJSON {
+		return nil, fmt.Errorf("cannot compare a JSON result file with a golden file")
+	}
+
+	if aJSON {
+		left, err := readResultsFile(a)
+		if err != nil {
+			return nil, err
+		}
+		right, err := readResultsFile(b)
+		if err != nil {
+			return nil, err
+		}
+		return compare.TestResults(left, right), nil
+	}
+
+	left, err := golden.Read(a)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", a, err)
+	}
+	right, err := golden.Read(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b, err)
+	}
+	return compare.Expectations(left, right), nil
+}
+
+func isJSONFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
+
+func readResultsFile(path string) (*compare.Results, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open results file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	results, err := compare.ReadResults(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return results, nil
+}
+
+func printChanges(w io.Writer, changes []compare.Change) {
+	if len(changes) == 0 {
+		_, _ = fmt.Fprintln(w, "No differences")
+		return
+	}
+
+	for _, c := range changes {
+		_, _ = fmt.Fprintln(w, c.String())
+	}
+	_, _ = fmt.Fprintf(w, "%d difference(s)\n", len(changes))
+}
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareFiles_Golden(t *testing.T) {
+	tmpDir := t.TempDir()
+	a := filepath.Join(tmpDir, "a.textproto")
+	b := filepath.Join(tmpDir, "b.textproto")
+	require.NoError(t, os.WriteFile(a, []byte(`expectations: { phase: REQUEST_HEADERS headers_response: { set_headers: { key: "x" value: "1" } } }`), 0o644))
+	require.NoError(t, os.WriteFile(b, []byte(`expectations: { phase: REQUEST_HEADERS headers_response: { set_headers: { key: "x" value: "2" } } }`), 0o644))
+
+	changes, err := compareFiles(a, b)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, `~ REQUEST_HEADERS[0] headers_response.set_headers[x]: "1" -> "2"`, changes[0].String())
+
+	changes, err = compareFiles(a, a)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestCompareFiles_JSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	a := filepath.Join(tmpDir, "a.json")
+	b := filepath.Join(tmpDir, "b.json")
+	require.NoError(t, os.WriteFile(a, []byte(`{"tests": [{"name": "t", "status": "passed"}]}`), 0o644))
+	require.NoError(t, os.WriteFile(b, []byte(`{"tests": [{"name": "t", "status": "failed"}]}`), 0o644))
+
+	changes, err := compareFiles(a, b)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "~ t status: passed -> failed", changes[0].String())
+}
+
+func TestCompareFiles_MixedKinds(t *testing.T) {
+	_, err := compareFiles("a.json", "b.textproto")
+	assert.ErrorContains(t, err, "cannot compare")
+}
+
+func TestCompareFiles_Missing(t *testing.T) {
+	_, err := compareFiles(filepath.Join(t.TempDir(), "missing.textproto"), "other.textproto")
+	assert.Error(t, err)
+}
+
+func TestPrintChanges_NoDifferences(t *testing.T) {
+	var buf bytes.Buffer
+	printChanges(&buf, nil)
+	assert.Equal(t, "No differences\n", buf.String())
+}
@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"zntr.io/extproctor/internal/schemadoc"
+)
+
+var (
+	docsFormat string
+	docsOut    string
+)
+
+var docsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Generate reference documentation",
+}
+
+var docsManifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "Render the manifest schema reference",
+	Long: `Docs manifest renders the reference of the manifest schema: every message
+with its fields, types and descriptions, the enums with their values, and a
+text format example per message. Fields of a oneof select the match mode of
+an expectation.
+
+The reference is generated from the protobuf definitions embedded in the
+binary, so it always matches the manifests this version of extproctor
+accepts. It is printed in Markdown, or as a standalone HTML page with
+--format html, or written to --out.
+
+Examples:
+  # Print the reference in Markdown
+  extproctor docs manifest
+
+  # Publish the reference as an HTML page
+  extproctor docs manifest --format html --out site/manifest.html`,
+	Args: cobra.NoArgs,
+	RunE: runDocsManifest,
+}
+
+func init() {
+	docsManifestCmd.Flags().StringVar(&docsFormat, "format", "markdown", "Format of the reference (markdown, html)")
+	docsManifestCmd.Flags().StringVar(&docsOut, "out", "", "Write the reference to this file instead of printing it")
+	docsCmd.AddCommand(docsManifestCmd)
+	rootCmd.AddCommand(docsCmd)
+}
+
+func runDocsManifest(cmd *cobra.Command, args []string) error {
+	if docsOut == "" {
+		return writeManifestDocs(os.Stdout, docsFormat)
+	}
+
+	var buf bytes.Buffer
+	if err := writeManifestDocs(&buf, docsFormat); err != nil {
+		return err
+	}
+	if err := os.WriteFile(docsOut, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write reference: %w", err)
+	}
+	return nil
+}
+
+// writeManifestDocs renders the manifest schema reference in the given format.
+func writeManifestDocs(w io.Writer, format string) error {
+	schema, err := schemadoc.Load()
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "markdown":
+		return schema.WriteMarkdown(w)
+	case "html":
+		return schema.WriteHTML(w)
+	default:
+		return fmt.Errorf("unsupported format %q: expected markdown or html", format)
+	}
+}
@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteManifestDocs(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeManifestDocs(&buf, "markdown"))
+	assert.Contains(t, buf.String(), "### ExtProcExpectation\n")
+
+	buf.Reset()
+	require.NoError(t, writeManifestDocs(&buf, "html"))
+	assert.Contains(t, buf.String(), `<h3 id="extprocexpectation">ExtProcExpectation</h3>`)
+
+	assert.EqualError(t, writeManifestDocs(&buf, "pdf"), `unsupported format "pdf": expected markdown or html`)
+}
+
+func TestRunDocsManifest_Out(t *testing.T) {
+	docsFormat = "html"
+	docsOut = filepath.Join(t.TempDir(), "manifest.html")
+	defer func() { docsFormat, docsOut = "markdown", "" }()
+
+	require.NoError(t, runDocsManifest(&cobra.Command{}, nil))
+
+	data, err := os.ReadFile(docsOut)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<title>Manifest Reference</title>")
+}
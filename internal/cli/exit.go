@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import "errors"
+
+// Exit codes of the extproctor command.
+const (
+	// ExitFailure is returned on test failures and errors.
+	ExitFailure = 1
+	// ExitBudgetExceeded is returned when no test failed but tests were
+	// skipped because the --max-duration time budget was exceeded.
+	ExitBudgetExceeded = 3
+)
+
+// ExitError is an error requiring a specific process exit code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the process exit code for an error returned by Execute.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+
+	return ExitFailure
+}
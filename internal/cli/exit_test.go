@@ -0,0 +1,21 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 0, ExitCode(nil))
+	assert.Equal(t, ExitFailure, ExitCode(errors.New("boom")))
+
+	err := fmt.Errorf("run: %w", &ExitError{Code: ExitBudgetExceeded, Err: errors.New("time budget exceeded")})
+	assert.Equal(t, ExitBudgetExceeded, ExitCode(err))
+	assert.Equal(t, "run: time budget exceeded", err.Error())
+}
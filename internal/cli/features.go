@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"zntr.io/extproctor/internal/compare"
+	"zntr.io/extproctor/internal/traceability"
+)
+
+var featuresResults string
+
+var reportFeaturesCmd = &cobra.Command{
+	Use:   "features <paths...>",
+	Short: "Map the filter features to the tests verifying them",
+	Long: `Report features lists the filter features (or requirements) declared by the
+features field of the expectations, with the tests verifying them and their
+latest status, to demonstrate the requirement coverage of the filter.
+
+The statuses are read from the JSON result files (--output json) matching
+--results; a test is flaky when it both passed and failed in those runs. A
+feature fails when one of its tests fails.
+
+The report is printed for the console, or exported with --output json or
+--output csv.
+
+Examples:
+  # List the features and their tests
+  extproctor report features ./tests/
+
+  # Export the traceability matrix of the last run for an audit
+  extproctor report features ./tests/ --results results.json -o csv > features.csv`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runReportFeatures,
+}
+
+func init() {
+	reportFeaturesCmd.Flags().StringVar(&featuresResults, "results", "", "JSON result files (glob pattern) the test statuses are read from")
+	reportCmd.AddCommand(reportFeaturesCmd)
+}
+
+func runReportFeatures(cmd *cobra.Command, args []string) error {
+	loader, err := newLoader()
+	if err != nil {
+		return err
+	}
+	manifests, err := loadPaths(loader, args)
+	if err != nil {
+		return fmt.Errorf("failed to load manifests: %w", err)
+	}
+
+	var outcomes map[string]compare.Outcome
+	if featuresResults != "" {
+		runs, err := readRuns(featuresResults)
+		if err != nil {
+			return err
+		}
+		outcomes = compare.Outcomes(runs)
+	}
+
+	features := traceability.Build(manifests, outcomes)
+
+	switch output {
+	case "human":
+		printFeatures(os.Stdout, features)
+		return nil
+	case "json":
+		return printFeaturesJSON(os.Stdout, features)
+	case "csv":
+		return printFeaturesCSV(os.Stdout, features)
+	default:
+		return fmt.Errorf("unsupported output format %q: expected human, json or csv", output)
+	}
+}
+
+// printFeatures prints the features and their tests for the console.
+func printFeatures(w io.Writer, features []traceability.Feature) {
+	if len(features) == 0 {
+		_, _ = fmt.Fprintln(w, "No features declared")
+		return
+	}
+
+	for _, f := range features {
+		_, _ = fmt.Fprintf(w, "%s (%s)\n", f.ID, outcomeLabel(f.Outcome()))
+		for _, t := range f.Tests {
+			_, _ = fmt.Fprintf(w, "  %-8s %s\n", outcomeLabel(t.Outcome), t.ID)
+		}
+	}
+	_, _ = fmt.Fprintf(w, "\n%d feature(s)\n", len(features))
+}
+
+// printFeaturesJSON prints the features and their tests as JSON.
+func printFeaturesJSON(w io.Writer, features []traceability.Feature) error {
+	type test struct {
+		ID       string `json:"id"`
+		Name     string `json:"name"`
+		Manifest string `json:"manifest"`
+		Status   string `json:"status"`
+	}
+	type feature struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+		Tests  []test `json:"tests"`
+	}
+	doc := struct {
+		Features []feature `json:"features"`
+	}{
+		Features: []feature{},
+	}
+	for _, f := range features {
+		out := feature{ID: f.ID, Status: outcomeLabel(f.Outcome())}
+		for _, t := range f.Tests {
+			out.Tests = append(out.Tests, test{ID: t.ID, Name: t.Name, Manifest: t.Manifest, Status: outcomeLabel(t.Outcome)})
+		}
+		doc.Features = append(doc.Features, out)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	return nil
+}
+
+// printFeaturesCSV prints a row per feature and test.
+func printFeaturesCSV(w io.Writer, features []traceability.Feature) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"feature", "feature_status", "test_id", "test_name", "manifest", "test_status"})
+	for _, f := range features {
+		status := outcomeLabel(f.Outcome())
+		for _, t := range f.Tests {
+			_ = cw.Write([]string{f.ID, status, t.ID, t.Name, t.Manifest, outcomeLabel(t.Outcome)})
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
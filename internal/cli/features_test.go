@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"zntr.io/extproctor/internal/compare"
+	"zntr.io/extproctor/internal/traceability"
+)
+
+func sampleFeatures() []traceability.Feature {
+	return []traceability.Feature{
+		{ID: "REQ-1", Tests: []traceability.Test{
+			{ID: "auth.textproto::allow", Name: "allow", Manifest: "auth.textproto", Outcome: compare.OutcomePassed},
+			{ID: "auth.textproto::deny", Name: "deny", Manifest: "auth.textproto", Outcome: compare.OutcomeFailed},
+		}},
+		{ID: "REQ-2", Tests: []traceability.Test{
+			{ID: "auth.textproto::deny", Name: "deny", Manifest: "auth.textproto"},
+		}},
+	}
+}
+
+func TestPrintFeatures(t *testing.T) {
+	var buf bytes.Buffer
+	printFeatures(&buf, sampleFeatures())
+
+	out := buf.String()
+	assert.Contains(t, out, "REQ-1 (failed)\n  passed   auth.textproto::allow\n  failed   auth.textproto::deny\n")
+	assert.Contains(t, out, "REQ-2 (not run)\n  not run  auth.textproto::deny\n")
+	assert.Contains(t, out, "2 feature(s)\n")
+
+	buf.Reset()
+	printFeatures(&buf, nil)
+	assert.Equal(t, "No features declared\n", buf.String())
+}
+
+func TestPrintFeaturesJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, printFeaturesJSON(&buf, sampleFeatures()))
+
+	var doc struct {
+		Features []struct {
+			ID     string `json:"id"`
+			Status string `json:"status"`
+			Tests  []struct {
+				ID     string `json:"id"`
+				Status string `json:"status"`
+			} `json:"tests"`
+		} `json:"features"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	require.Len(t, doc.Features, 2)
+	assert.Equal(t, "REQ-1", doc.Features[0].ID)
+	assert.Equal(t, "failed", doc.Features[0].Status)
+	require.Len(t, doc.Features[0].Tests, 2)
+	assert.Equal(t, "passed", doc.Features[0].Tests[0].Status)
+
+	buf.Reset()
+	require.NoError(t, printFeaturesJSON(&buf, nil))
+	assert.JSONEq(t, `{"features": []}`, buf.String())
+}
+
+func TestPrintFeaturesCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, printFeaturesCSV(&buf, sampleFeatures()))
+
+	assert.Equal(t, "feature,feature_status,test_id,test_name,manifest,test_status\n"+
+		"REQ-1,failed,auth.textproto::allow,allow,auth.textproto,passed\n"+
+		"REQ-1,failed,auth.textproto::deny,deny,auth.textproto,failed\n"+
+		"REQ-2,not run,auth.textproto::deny,deny,auth.textproto,not run\n", buf.String())
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/protocolbuffers/txtpbfmt/parser"
 	"github.com/spf13/cobra"
+	"zntr.io/extproctor/internal/paths"
 )
 
 var (
@@ -50,9 +51,20 @@ func init() {
 }
 
 func runFmt(cmd *cobra.Command, args []string) error {
+	if fmtWrite {
+		if err := forbidInCI("fmt --write"); err != nil {
+			return err
+		}
+	}
+
+	expanded, err := paths.Expand(args, !noFollowSymlinks)
+	if err != nil {
+		return err
+	}
+
 	// Collect all textproto files from paths
 	var files []string
-	for _, path := range args {
+	for _, path := range expanded {
 		collected, err := collectTextprotoFiles(path)
 		if err != nil {
 			return fmt.Errorf("failed to collect files from %s: %w", path, err)
@@ -105,7 +117,7 @@ func collectTextprotoFiles(path string) ([]string, error) {
 
 	// Walk directory
 	var files []string
-	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+	err = paths.Walk(path, !noFollowSymlinks, func(p string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -133,6 +133,18 @@ func TestCollectTextprotoFiles_Directory(t *testing.T) {
 	assert.Len(t, files, 2)
 }
 
+func TestCollectTextprotoFiles_IgnoreFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".extproctorignore"), []byte("generated/\n"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(tmpDir, "generated"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "test.textproto"), []byte("content"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "generated", "out.textproto"), []byte("content"), 0o644))
+
+	files, err := collectTextprotoFiles(tmpDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(tmpDir, "test.textproto")}, files)
+}
+
 func TestCollectTextprotoFiles_Subdirectories(t *testing.T) {
 	tmpDir := t.TempDir()
 	subDir := filepath.Join(tmpDir, "subdir")
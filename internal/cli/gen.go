@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"zntr.io/extproctor/internal/gen"
+	"zntr.io/extproctor/internal/manifest"
+)
+
+var (
+	genFromGolden string
+	genOut        string
+)
+
+var genCmd = &cobra.Command{
+	Use:   "gen",
+	Short: "Generate manifests",
+}
+
+var genTestsCmd = &cobra.Command{
+	Use:   "tests --from-golden <dir> [paths...]",
+	Short: "Generate test case stubs for the golden files no test references",
+	Long: `Gen tests generates a manifest with a test case stub for each golden file found
+under --from-golden that no test case of the manifests in paths references,
+the reverse of --update-golden, e.g. after a bulk recording session.
+
+Test names are inferred from the golden paths relative to --from-golden.
+Golden files named after a processing phase (deny/request_headers.textproto
+or deny.request_headers.textproto) are grouped in a single test with a
+{phase} golden path. The requests are placeholders to complete, with the
+processing of the recorded phases enabled.
+
+The manifest is printed, or written to --out. Golden paths are relative to
+the directory of the manifest (the current directory when printed).
+
+Examples:
+  # Generate the stubs of the golden files not referenced by ./tests/
+  extproctor gen tests --from-golden ./golden/ ./tests/ --out tests/generated.textproto`,
+	SilenceUsage: true,
+	RunE:         runGenTests,
+}
+
+func init() {
+	genTestsCmd.Flags().StringVar(&genFromGolden, "from-golden", "", "Directory of the golden files to generate test cases for")
+	genTestsCmd.Flags().StringVar(&genOut, "out", "", "Write the generated manifest to this file instead of printing it")
+	_ = genTestsCmd.MarkFlagRequired("from-golden")
+	genCmd.AddCommand(genTestsCmd)
+	rootCmd.AddCommand(genCmd)
+}
+
+func runGenTests(cmd *cobra.Command, args []string) error {
+	var manifests []*manifest.LoadedManifest
+	if len(args) > 0 {
+		loader, err := newLoader()
+		if err != nil {
+			return err
+		}
+		manifests, err = loadPaths(loader, args)
+		if err != nil {
+			return fmt.Errorf("failed to load manifests: %w", err)
+		}
+	}
+
+	stubs, err := gen.FromGolden(genFromGolden, manifests)
+	if err != nil {
+		return err
+	}
+	if len(stubs) == 0 {
+		fmt.Fprintln(os.Stderr, "No unreferenced golden files")
+		return nil
+	}
+
+	if genOut == "" {
+		return gen.WriteManifest(os.Stdout, stubs, ".")
+	}
+
+	if _, err := os.Stat(genOut); err == nil {
+		return fmt.Errorf("%s already exists", genOut)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := gen.WriteManifest(&buf, stubs, filepath.Dir(genOut)); err != nil {
+		return err
+	}
+	if err := os.WriteFile(genOut, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Generated %d test case(s) in %s\n", len(stubs), genOut)
+	return nil
+}
@@ -0,0 +1,34 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunGenTests(t *testing.T) {
+	tmpDir := t.TempDir()
+	goldenDir := filepath.Join(tmpDir, "golden")
+	require.NoError(t, os.MkdirAll(goldenDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(goldenDir, "deny.textproto"),
+		[]byte(`name: "golden" expectations: { phase: REQUEST_HEADERS immediate_response: { status_code: 403 } }`), 0o644))
+
+	genFromGolden = goldenDir
+	genOut = filepath.Join(tmpDir, "generated.textproto")
+	t.Cleanup(func() { genFromGolden, genOut = "", "" })
+
+	require.NoError(t, runGenTests(genTestsCmd, nil))
+	content, err := os.ReadFile(genOut)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `name: "deny"`)
+	assert.Contains(t, string(content), `golden_file: "golden/deny.textproto"`)
+
+	// Existing manifests are not overwritten
+	assert.ErrorContains(t, runGenTests(genTestsCmd, nil), "already exists")
+}
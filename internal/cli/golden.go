@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"zntr.io/extproctor/internal/golden"
+	"zntr.io/extproctor/internal/paths"
+)
+
+var goldenCmd = &cobra.Command{
+	Use:   "golden",
+	Short: "Manage golden files",
+}
+
+var goldenUpgradeCmd = &cobra.Command{
+	Use:   "upgrade [paths...]",
+	Short: "Rewrite golden files in the current schema version",
+	Long: `Golden upgrade rewrites the golden files written in an older schema version
+in the current one. Older golden files are still read, upgraded in memory:
+rewriting them keeps the diffs of the next --update-golden focused on the
+responses.
+
+Golden files written by a newer version of extproctor are reported as errors.
+
+Examples:
+  # Upgrade all the golden files
+  extproctor golden upgrade ./golden/`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runGoldenUpgrade,
+}
+
+func init() {
+	goldenCmd.AddCommand(goldenUpgradeCmd)
+	rootCmd.AddCommand(goldenCmd)
+}
+
+func runGoldenUpgrade(cmd *cobra.Command, args []string) error {
+	if err := forbidInCI("golden upgrade"); err != nil {
+		return err
+	}
+
+	expanded, err := paths.Expand(args, !noFollowSymlinks)
+	if err != nil {
+		return err
+	}
+
+	var files []string
+	for _, path := range expanded {
+		collected, err := collectTextprotoFiles(path)
+		if err != nil {
+			return fmt.Errorf("failed to collect files from %s: %w", path, err)
+		}
+		files = append(files, collected...)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no .textproto files found in specified paths")
+	}
+
+	out := cmd.OutOrStdout()
+	var upgraded int
+	for _, file := range files {
+		from, err := golden.Upgrade(file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if from != golden.Version {
+			_, _ = fmt.Fprintf(out, "upgraded %s: version %d -> %d\n", file, from, golden.Version)
+			upgraded++
+		}
+	}
+
+	_, _ = fmt.Fprintf(out, "%d golden file(s) upgraded\n", upgraded)
+	return nil
+}
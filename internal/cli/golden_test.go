@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunGoldenUpgrade(t *testing.T) {
+	tmpDir := t.TempDir()
+	legacy := filepath.Join(tmpDir, "deny.textproto")
+	require.NoError(t, os.WriteFile(legacy,
+		[]byte(`name: "golden" expectations: { phase: REQUEST_HEADERS immediate_response: { status_code: 403 } }`), 0o644))
+	current := filepath.Join(tmpDir, "allow.textproto")
+	require.NoError(t, os.WriteFile(current,
+		[]byte(`version: 1 expectations: { phase: REQUEST_HEADERS headers_response: {} }`), 0o644))
+
+	out := &bytes.Buffer{}
+	goldenUpgradeCmd.SetOut(out)
+	require.NoError(t, runGoldenUpgrade(goldenUpgradeCmd, []string{tmpDir}))
+	assert.Equal(t, "upgraded "+legacy+": version 0 -> 1\n1 golden file(s) upgraded\n", out.String())
+
+	content, err := os.ReadFile(legacy)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "version: 1")
+	assert.NotContains(t, string(content), `name:`)
+
+	// Golden files of newer versions are not downgraded
+	require.NoError(t, os.WriteFile(current, []byte(`version: 2`), 0o644))
+	err = runGoldenUpgrade(goldenUpgradeCmd, []string{current})
+	assert.EqualError(t, err, current+": golden file version 2 is newer than the supported version 1: upgrade extproctor")
+}
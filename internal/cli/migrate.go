@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"zntr.io/extproctor/internal/migrate"
+	"zntr.io/extproctor/internal/paths"
+)
+
+var (
+	migrateWrite bool
+	migrateDiff  bool
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate [paths...]",
+	Short: "Rewrite manifests from legacy field shapes to the canonical ones",
+	Long: `Migrate rewrites the legacy field shapes of manifests to their canonical
+ones, such as the headers and trailers maps of requests to header entries,
+which allow repeated keys and are sent in order. Comments are preserved and
+the rewritten manifests are formatted like fmt.
+
+The legacy shapes are still accepted. Test cases extending or extended by
+another one, and manifests imported by other ones, are left unchanged: their
+map entries are merged by key when extending, while entries are replaced.
+
+By default, migrate reports the manifests that would be rewritten and fails
+when there are any, for CI usage.
+
+Examples:
+  # List the manifests to migrate
+  extproctor migrate ./tests/
+
+  # Show the changes
+  extproctor migrate --diff ./tests/
+
+  # Migrate in-place
+  extproctor migrate --write ./tests/`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runMigrate,
+}
+
+func init() {
+	migrateCmd.Flags().BoolVarP(&migrateWrite, "write", "w", false, "Write migrated manifests back to files (in-place)")
+	migrateCmd.Flags().BoolVarP(&migrateDiff, "diff", "d", false, "Show diff of what would change")
+	rootCmd.AddCommand(migrateCmd)
+}
+
+func runMigrate(cmd *cobra.Command, args []string) error {
+	if migrateWrite {
+		if err := forbidInCI("migrate --write"); err != nil {
+			return err
+		}
+	}
+
+	expanded, err := paths.Expand(args, !noFollowSymlinks)
+	if err != nil {
+		return err
+	}
+
+	var files []string
+	for _, path := range expanded {
+		collected, err := collectTextprotoFiles(path)
+		if err != nil {
+			return fmt.Errorf("failed to collect files from %s: %w", path, err)
+		}
+		files = append(files, collected...)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no .textproto files found in specified paths")
+	}
+
+	imported, err := importedFiles(files)
+	if err != nil {
+		return err
+	}
+
+	var pending int
+	for _, file := range files {
+		if imported[filepath.Clean(file)] {
+			fmt.Fprintf(os.Stderr, "WARNING: %s: imported by other manifests, left unchanged\n", file)
+			continue
+		}
+
+		changed, err := migrateFile(os.Stdout, file, migrateWrite, migrateDiff)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if changed {
+			pending++
+		}
+	}
+
+	if !migrateWrite && pending > 0 {
+		return fmt.Errorf("%d manifest(s) need migration (use --write to migrate)", pending)
+	}
+	return nil
+}
+
+// importedFiles returns the cleaned paths of the manifests imported by the
+// given ones.
+func importedFiles(files []string) (map[string]bool, error) {
+	imported := map[string]bool{}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		imports, err := migrate.Imports(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		for _, imp := range imports {
+			if !filepath.IsAbs(imp) {
+				imp = filepath.Join(filepath.Dir(file), imp)
+			}
+			imported[filepath.Clean(imp)] = true
+		}
+	}
+	return imported, nil
+}
+
+// migrateFile migrates a manifest and returns whether it was changed.
+func migrateFile(w io.Writer, path string, write, showDiff bool) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := migrate.File(content)
+	if err != nil {
+		return false, err
+	}
+	for _, name := range result.Skipped {
+		fmt.Fprintf(os.Stderr, "WARNING: %s: test case %q extends or is extended by another one, left unchanged\n", path, name)
+	}
+	if !result.Changed() {
+		return false, nil
+	}
+
+	changes := make([]string, 0, len(result.Changes))
+	for _, c := range result.Changes {
+		changes = append(changes, fmt.Sprintf("%s -> %s (%d)", c.Migration.From, c.Migration.To, c.Count))
+	}
+
+	switch {
+	case write:
+		if err := os.WriteFile(path, result.Content, 0o644); err != nil {
+			return true, fmt.Errorf("write error: %w", err)
+		}
+		_, _ = fmt.Fprintf(w, "migrated %s: %s\n", path, strings.Join(changes, ", "))
+	case showDiff:
+		_, _ = fmt.Fprintf(w, "--- %s (original)\n+++ %s (migrated)\n", path, path)
+		printSimpleDiff(string(content), string(result.Content))
+	default:
+		_, _ = fmt.Fprintf(w, "%s needs migration: %s\n", path, strings.Join(changes, ", "))
+	}
+
+	return true, nil
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"zntr.io/extproctor/internal/manifest"
+)
+
+func TestMigrateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.textproto")
+	require.NoError(t, os.WriteFile(path, []byte(`test_cases: {
+  name: "a"
+  request: {
+    method: "GET"
+    path: "/"
+    # Authentication
+    headers: { key: "authorization" value: "Bearer token" }
+    headers: { key: "accept" value: "application/json" }
+  }
+}
+`), 0o644))
+
+	var buf bytes.Buffer
+	changed, err := migrateFile(&buf, path, false, false)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, path+" needs migration: request.headers -> request.header_entries (2)\n", buf.String())
+
+	before, err := manifest.NewLoader().LoadFile(path)
+	require.NoError(t, err)
+
+	buf.Reset()
+	changed, err = migrateFile(&buf, path, true, false)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Contains(t, buf.String(), "migrated "+path)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "# Authentication\n    header_entries: { key: \"authorization\" value: \"Bearer token\" }")
+
+	after, err := manifest.NewLoader().LoadFile(path)
+	require.NoError(t, err)
+	req := after.TestCases[0].Request
+	assert.Empty(t, req.Headers)
+	require.Len(t, req.HeaderEntries, 2)
+	assert.Equal(t, before.TestCases[0].Request.Headers[req.HeaderEntries[0].Key], req.HeaderEntries[0].Value)
+	assert.Equal(t, before.TestCases[0].Request.Headers[req.HeaderEntries[1].Key], req.HeaderEntries[1].Value)
+
+	// Migrated manifests are left unchanged
+	changed, err = migrateFile(&buf, path, true, false)
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestImportedFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	child := filepath.Join(tmpDir, "child.textproto")
+	require.NoError(t, os.WriteFile(child, []byte(`imports: "common/base.textproto"`), 0o644))
+
+	imported, err := importedFiles([]string{child})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{filepath.Join(tmpDir, "common", "base.textproto"): true}, imported)
+}
@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	cpuProfile string
+	memProfile string
+	traceFile  string
+)
+
+// addProfilingFlags adds the flags profiling extproctor itself, to tell
+// whether a slow suite is bound by the tool, the network or the filter.
+func addProfilingFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&cpuProfile, "cpuprofile", "", "Write a CPU profile of extproctor to this file")
+	cmd.Flags().StringVar(&memProfile, "memprofile", "", "Write a heap profile of extproctor to this file when the command ends")
+	cmd.Flags().StringVar(&traceFile, "trace", "", "Write an execution trace of extproctor to this file")
+}
+
+// startProfiling starts the CPU profile and the execution trace requested by
+// flags. The returned function stops them and writes the heap profile.
+func startProfiling() (func() error, error) {
+	var stops []func() error
+	stopAll := func() error {
+		var errs []error
+		for i := len(stops) - 1; i >= 0; i-- {
+			errs = append(errs, stops[i]())
+		}
+		return errors.Join(errs...)
+	}
+
+	if cpuProfile != "" {
+		f, err := os.Create(cpuProfile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+		stops = append(stops, func() error {
+			pprof.StopCPUProfile()
+			return f.Close()
+		})
+	}
+
+	if traceFile != "" {
+		f, err := os.Create(traceFile)
+		if err != nil {
+			_ = stopAll()
+			return nil, fmt.Errorf("failed to create execution trace: %w", err)
+		}
+		if err := trace.Start(f); err != nil {
+			_ = f.Close()
+			_ = stopAll()
+			return nil, fmt.Errorf("failed to start execution trace: %w", err)
+		}
+		stops = append(stops, func() error {
+			trace.Stop()
+			return f.Close()
+		})
+	}
+
+	return func() error {
+		err := stopAll()
+		if memProfile != "" {
+			err = errors.Join(err, writeHeapProfile(memProfile))
+		}
+		return err
+	}, nil
+}
+
+// stopProfiling stops the profiling, reporting failures as warnings so that
+// they do not change the command outcome.
+func stopProfiling(stop func() error) {
+	if err := stop(); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: failed to write profiles: %v\n", err)
+	}
+}
+
+// writeHeapProfile writes a heap profile reflecting the allocations of the
+// command.
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create heap profile: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	// Collect garbage to get up-to-date statistics
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		return fmt.Errorf("failed to write heap profile: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfilingFlags(t *testing.T) {
+	for _, cmd := range []string{"run", "bench"} {
+		c, _, err := rootCmd.Find([]string{cmd})
+		require.NoError(t, err)
+		for _, flag := range []string{"cpuprofile", "memprofile", "trace"} {
+			assert.NotNil(t, c.Flags().Lookup(flag), "%s --%s", cmd, flag)
+		}
+	}
+}
+
+func TestStartProfiling(t *testing.T) {
+	dir := t.TempDir()
+	cpuProfile = filepath.Join(dir, "cpu.pprof")
+	memProfile = filepath.Join(dir, "mem.pprof")
+	traceFile = filepath.Join(dir, "trace.out")
+	t.Cleanup(func() { cpuProfile, memProfile, traceFile = "", "", "" })
+
+	stop, err := startProfiling()
+	require.NoError(t, err)
+	require.NoError(t, stop())
+
+	for _, path := range []string{cpuProfile, memProfile, traceFile} {
+		info, err := os.Stat(path)
+		if assert.NoError(t, err) {
+			assert.Positive(t, info.Size(), path)
+		}
+	}
+}
+
+func TestStartProfiling_Disabled(t *testing.T) {
+	stop, err := startProfiling()
+	require.NoError(t, err)
+	assert.NoError(t, stop())
+}
+
+func TestStartProfiling_CreateError(t *testing.T) {
+	dir := t.TempDir()
+	cpuProfile = filepath.Join(dir, "cpu.pprof")
+	traceFile = filepath.Join(dir, "missing", "trace.out")
+	t.Cleanup(func() { cpuProfile, traceFile = "", "" })
+
+	_, err := startProfiling()
+	assert.ErrorContains(t, err, "failed to create execution trace")
+
+	// The CPU profile was stopped, so profiling can start again
+	traceFile = ""
+	stop, err := startProfiling()
+	require.NoError(t, err)
+	assert.NoError(t, stop())
+}
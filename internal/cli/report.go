@@ -0,0 +1,246 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"zntr.io/extproctor/internal/bench"
+	"zntr.io/extproctor/internal/compare"
+)
+
+var (
+	reportMaxSlowdown string
+	reportMinSlowdown time.Duration
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Analyze JSON result files and test coverage",
+}
+
+var reportDiffCmd = &cobra.Command{
+	Use:   "diff <before> <after>",
+	Short: "Show the tests that regressed or recovered between two runs",
+	Long: `Report diff compares the JSON result files (--output json) of two runs, typically
+before and after upgrading the ExtProc filter, and lists the tests that are
+newly failing, newly passing, newly flaky or slower.
+
+Each side may be a glob pattern matching the result files of several runs: a
+test is flaky on a side when it both passed and failed in its runs. A test is
+slower when its mean duration increased by more than --max-slowdown and by at
+least --min-slowdown.
+
+The report is printed for the console, or in Markdown with --output markdown
+(e.g. for a pull request comment), or in JSON. The command fails when tests
+are newly failing, newly flaky or slower.
+
+Examples:
+  # Compare the runs before and after an upgrade
+  extproctor report diff before.json after.json
+
+  # Compare 5 runs on each side, in Markdown
+  extproctor report diff 'before/*.json' 'after/*.json' -o markdown`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE:         runReportDiff,
+}
+
+func init() {
+	reportDiffCmd.Flags().StringVar(&reportMaxSlowdown, "max-slowdown", "20%", "Mean duration increase above which a test is reported as slower (e.g. 20%)")
+	reportDiffCmd.Flags().DurationVar(&reportMinSlowdown, "min-slowdown", 10*time.Millisecond, "Minimum mean duration increase for a test to be reported as slower")
+	reportCmd.AddCommand(reportDiffCmd)
+	rootCmd.AddCommand(reportCmd)
+}
+
+func runReportDiff(cmd *cobra.Command, args []string) error {
+	maxSlowdown, err := bench.ParseThreshold(reportMaxSlowdown)
+	if err != nil {
+		return fmt.Errorf("invalid --max-slowdown: %w", err)
+	}
+
+	before, err := readRuns(args[0])
+	if err != nil {
+		return err
+	}
+	after, err := readRuns(args[1])
+	if err != nil {
+		return err
+	}
+
+	diff := compare.Runs(before, after, maxSlowdown, reportMinSlowdown)
+
+	switch output {
+	case "human":
+		printRunDiff(os.Stdout, diff)
+	case "markdown":
+		printRunDiffMarkdown(os.Stdout, diff)
+	case "json":
+		if err := printRunDiffJSON(os.Stdout, diff); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported output format %q: expected human, markdown or json", output)
+	}
+
+	if n := diff.Regressions(); n > 0 {
+		return fmt.Errorf("%d test(s) regressed", n)
+	}
+	return nil
+}
+
+// readRuns reads the result files matching a glob pattern.
+func readRuns(pattern string) ([]*compare.Results, error) {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	if len(paths) == 0 {
+		// Not a pattern, or a missing file reported by readResultsFile.
+		paths = []string{pattern}
+	}
+
+	runs := make([]*compare.Results, 0, len(paths))
+	for _, path := range paths {
+		results, err := readResultsFile(path)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, results)
+	}
+	return runs, nil
+}
+
+// runDiffSection is a section of a run comparison report.
+type runDiffSection struct {
+	title string
+	ids   []string
+}
+
+func runDiffSections(diff *compare.RunDiff) []runDiffSection {
+	return []runDiffSection{
+		{"Newly failing", diff.NewlyFailing},
+		{"Newly flaky", diff.NewlyFlaky},
+		{"Newly passing", diff.NewlyPassing},
+	}
+}
+
+// printRunDiff prints a run comparison for the console.
+func printRunDiff(w io.Writer, diff *compare.RunDiff) {
+	empty := true
+	for _, s := range runDiffSections(diff) {
+		if len(s.ids) == 0 {
+			continue
+		}
+		empty = false
+		_, _ = fmt.Fprintf(w, "%s (%d):\n", s.title, len(s.ids))
+		for _, id := range s.ids {
+			_, _ = fmt.Fprintf(w, "  %s (%s -> %s)\n", id, outcomeLabel(diff.Before[id]), outcomeLabel(diff.After[id]))
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+	if len(diff.Slower) > 0 {
+		empty = false
+		_, _ = fmt.Fprintf(w, "Slower (%d):\n", len(diff.Slower))
+		for _, c := range diff.Slower {
+			_, _ = fmt.Fprintf(w, "  %s (%s -> %s, %+.1f%%)\n", c.ID, c.Before, c.After, c.Change*100)
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+
+	if empty {
+		_, _ = fmt.Fprintln(w, "No changes")
+		return
+	}
+	_, _ = fmt.Fprintf(w, "%d newly failing, %d newly flaky, %d newly passing, %d slower\n",
+		len(diff.NewlyFailing), len(diff.NewlyFlaky), len(diff.NewlyPassing), len(diff.Slower))
+}
+
+// printRunDiffMarkdown prints a run comparison in Markdown.
+func printRunDiffMarkdown(w io.Writer, diff *compare.RunDiff) {
+	_, _ = fmt.Fprintln(w, "## Test run comparison")
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "| Newly failing | Newly flaky | Newly passing | Slower |")
+	_, _ = fmt.Fprintln(w, "|---:|---:|---:|---:|")
+	_, _ = fmt.Fprintf(w, "| %d | %d | %d | %d |\n", len(diff.NewlyFailing), len(diff.NewlyFlaky), len(diff.NewlyPassing), len(diff.Slower))
+
+	for _, s := range runDiffSections(diff) {
+		if len(s.ids) == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "\n### %s\n\n", s.title)
+		_, _ = fmt.Fprintln(w, "| Test | Before | After |")
+		_, _ = fmt.Fprintln(w, "|------|--------|-------|")
+		for _, id := range s.ids {
+			_, _ = fmt.Fprintf(w, "| `%s` | %s | %s |\n", markdownCode(id), outcomeLabel(diff.Before[id]), outcomeLabel(diff.After[id]))
+		}
+	}
+
+	if len(diff.Slower) > 0 {
+		_, _ = fmt.Fprint(w, "\n### Slower\n\n")
+		_, _ = fmt.Fprintln(w, "| Test | Before | After | Change |")
+		_, _ = fmt.Fprintln(w, "|------|-------:|------:|-------:|")
+		for _, c := range diff.Slower {
+			_, _ = fmt.Fprintf(w, "| `%s` | %s | %s | %+.1f%% |\n", markdownCode(c.ID), c.Before, c.After, c.Change*100)
+		}
+	}
+}
+
+// printRunDiffJSON prints a run comparison as JSON.
+func printRunDiffJSON(w io.Writer, diff *compare.RunDiff) error {
+	type slower struct {
+		ID     string  `json:"id"`
+		Before string  `json:"before"`
+		After  string  `json:"after"`
+		Change float64 `json:"change"`
+	}
+	doc := struct {
+		NewlyFailing []string `json:"newly_failing"`
+		NewlyFlaky   []string `json:"newly_flaky"`
+		NewlyPassing []string `json:"newly_passing"`
+		Slower       []slower `json:"slower"`
+	}{
+		NewlyFailing: nonNil(diff.NewlyFailing),
+		NewlyFlaky:   nonNil(diff.NewlyFlaky),
+		NewlyPassing: nonNil(diff.NewlyPassing),
+		Slower:       []slower{},
+	}
+	for _, c := range diff.Slower {
+		doc.Slower = append(doc.Slower, slower{ID: c.ID, Before: c.Before.String(), After: c.After.String(), Change: c.Change})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	return nil
+}
+
+// outcomeLabel renders the outcome of a test.
+func outcomeLabel(o compare.Outcome) string {
+	if o == compare.OutcomeNone {
+		return "not run"
+	}
+	return string(o)
+}
+
+// markdownCode escapes a string for a Markdown code span in a table cell.
+func markdownCode(s string) string {
+	return strings.NewReplacer("`", "'", "|", `\|`).Replace(s)
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
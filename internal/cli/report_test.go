@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"zntr.io/extproctor/internal/compare"
+)
+
+func sampleRunDiff() *compare.RunDiff {
+	return &compare.RunDiff{
+		NewlyFailing: []string{"a.textproto::breaks"},
+		NewlyPassing: []string{"a.textproto::fixed"},
+		Slower: []compare.DurationChange{
+			{ID: "a.textproto::slows", Before: 100 * time.Millisecond, After: 150 * time.Millisecond, Change: 0.5},
+		},
+		Before: map[string]compare.Outcome{"a.textproto::breaks": compare.OutcomePassed, "a.textproto::fixed": compare.OutcomeFailed},
+		After:  map[string]compare.Outcome{"a.textproto::breaks": compare.OutcomeFailed, "a.textproto::fixed": compare.OutcomePassed},
+	}
+}
+
+func TestReadRuns_Glob(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"run1.json", "run2.json"} {
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, name), []byte(`{"tests": [{"id": "t", "name": "t", "status": "passed"}]}`), 0o644))
+	}
+
+	runs, err := readRuns(filepath.Join(tmpDir, "*.json"))
+	require.NoError(t, err)
+	assert.Len(t, runs, 2)
+
+	runs, err = readRuns(filepath.Join(tmpDir, "run1.json"))
+	require.NoError(t, err)
+	assert.Len(t, runs, 1)
+
+	_, err = readRuns(filepath.Join(tmpDir, "missing.json"))
+	assert.ErrorContains(t, err, "failed to open results file")
+}
+
+func TestPrintRunDiff(t *testing.T) {
+	var buf bytes.Buffer
+	printRunDiff(&buf, sampleRunDiff())
+
+	out := buf.String()
+	assert.Contains(t, out, "Newly failing (1):\n  a.textproto::breaks (passed -> failed)\n")
+	assert.Contains(t, out, "Newly passing (1):\n  a.textproto::fixed (failed -> passed)\n")
+	assert.Contains(t, out, "Slower (1):\n  a.textproto::slows (100ms -> 150ms, +50.0%)\n")
+	assert.NotContains(t, out, "Newly flaky")
+	assert.Contains(t, out, "1 newly failing, 0 newly flaky, 1 newly passing, 1 slower\n")
+}
+
+func TestPrintRunDiff_NoChanges(t *testing.T) {
+	var buf bytes.Buffer
+	printRunDiff(&buf, &compare.RunDiff{})
+	assert.Equal(t, "No changes\n", buf.String())
+}
+
+func TestPrintRunDiffMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	printRunDiffMarkdown(&buf, sampleRunDiff())
+
+	out := buf.String()
+	assert.Contains(t, out, "| 1 | 0 | 1 | 1 |\n")
+	assert.Contains(t, out, "### Newly failing\n\n| Test | Before | After |\n|------|--------|-------|\n| `a.textproto::breaks` | passed | failed |\n")
+	assert.Contains(t, out, "| `a.textproto::slows` | 100ms | 150ms | +50.0% |\n")
+	assert.NotContains(t, out, "### Newly flaky")
+}
+
+func TestPrintRunDiffJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, printRunDiffJSON(&buf, sampleRunDiff()))
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, []any{"a.textproto::breaks"}, doc["newly_failing"])
+	assert.Equal(t, []any{}, doc["newly_flaky"])
+}
+
+func TestMarkdownCode(t *testing.T) {
+	assert.Equal(t, `a\|b'c'`, markdownCode("a|b`c`"))
+}
@@ -4,22 +4,56 @@
 package cli
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"zntr.io/extproctor/internal/auth"
+	"zntr.io/extproctor/internal/client"
+	"zntr.io/extproctor/internal/config"
+	"zntr.io/extproctor/internal/manifest"
+	"zntr.io/extproctor/internal/plugin"
+	"zntr.io/extproctor/internal/reporter"
+	"zntr.io/extproctor/internal/units"
+	"zntr.io/extproctor/internal/version"
 )
 
 var (
 	// Global flags
-	target     string
+	targets    = targetList{values: []string{defaultTarget}}
 	unixSocket string
 	tlsEnable  bool
 	tlsCert    string
 	tlsKey     string
 	tlsCA      string
+	tlsServer  string
+	authToken  string
+	grpcLog    bool
+	retries    int
+	proxyURL   string
 	parallel   int
 	output     string
 	verbose    bool
 	filter     string
 	tags       []string
+	owners     []string
+	profile    string
+	vars       map[string]string
+	plugins    []string
+	configPath string
+
+	noFollowSymlinks bool
+	rawHeaderValues  bool
+	skipUnsupported  bool
+	lenient          bool
+	ciMode           bool
+	noColor          bool
+	asciiOnly        bool
+	durationFormat   = durationFormatValue{format: units.DurationHuman}
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -30,6 +64,7 @@ var rootCmd = &cobra.Command{
 Processing (ExtProc) filter implementations. It reads test manifests defined 
 using protobuf messages encoded in Prototext and validates that a given ExtProc 
 service behaves as expected.`,
+	Version: version.String(),
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -39,12 +74,18 @@ func Execute() error {
 
 func init() {
 	// Connection flags
-	rootCmd.PersistentFlags().StringVar(&target, "target", "localhost:50051", "ExtProc service address (host:port)")
+	rootCmd.PersistentFlags().Var(&targets, "target", "ExtProc service address (host:port, [ipv6]:port), repeatable to run against several services (name=address names them)")
 	rootCmd.PersistentFlags().StringVar(&unixSocket, "unix-socket", "", "Unix domain socket path for ExtProc service (alternative to --target)")
 	rootCmd.PersistentFlags().BoolVar(&tlsEnable, "tls", false, "Enable TLS for gRPC connection")
 	rootCmd.PersistentFlags().StringVar(&tlsCert, "tls-cert", "", "TLS client certificate file")
 	rootCmd.PersistentFlags().StringVar(&tlsKey, "tls-key", "", "TLS client key file")
 	rootCmd.PersistentFlags().StringVar(&tlsCA, "tls-ca", "", "TLS CA certificate file")
+	rootCmd.PersistentFlags().StringVar(&tlsServer, "tls-server-name", "", "TLS server name (SNI) presented to the ExtProc service and expected in its certificate")
+	rootCmd.PersistentFlags().StringVar(&authToken, "auth-token", "", "Bearer token sent in the authorization metadata of the gRPC calls (defaults to $EXTPROCTOR_AUTH_TOKEN)")
+	rootCmd.PersistentFlags().BoolVar(&grpcLog, "grpc-log", false, "Log the gRPC calls and stream messages to stderr")
+	rootCmd.PersistentFlags().BoolVar(&rawHeaderValues, "raw-header-values", false, "Also send the header values as raw_value, like recent Envoy versions")
+	rootCmd.PersistentFlags().StringVar(&proxyURL, "proxy", "", "Proxy the ExtProc service is reached through (http:// for HTTP CONNECT, socks5://), overriding the proxies of the configuration")
+	rootCmd.PersistentFlags().IntVar(&retries, "grpc-retries", 0, "Retry the gRPC calls failing because the ExtProc service is unavailable, up to this many times")
 
 	// Mark target and unix-socket as mutually exclusive
 	rootCmd.MarkFlagsMutuallyExclusive("target", "unix-socket")
@@ -53,8 +94,202 @@ func init() {
 	rootCmd.PersistentFlags().IntVarP(&parallel, "parallel", "p", 1, "Number of parallel test executions")
 	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", "human", "Output format (human, json)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colors in human output (also with $NO_COLOR)")
+	rootCmd.PersistentFlags().BoolVar(&asciiOnly, "ascii", false, "Restrict human output to ASCII, escaping other characters")
+	rootCmd.PersistentFlags().Var(&durationFormat, "duration-format", "Format of the durations in human and JSON output (ms, s, human)")
 
 	// Filtering flags
 	rootCmd.PersistentFlags().StringVar(&filter, "filter", "", "Filter tests by name pattern")
 	rootCmd.PersistentFlags().StringSliceVar(&tags, "tags", nil, "Filter tests by tags (comma-separated)")
+	rootCmd.PersistentFlags().StringSliceVar(&owners, "owner", nil, "Filter tests by manifest owner (comma-separated)")
+	rootCmd.PersistentFlags().BoolVar(&noFollowSymlinks, "no-follow-symlinks", false, "Do not walk symlinked directories when discovering manifests")
+	rootCmd.PersistentFlags().BoolVar(&skipUnsupported, "skip-unsupported", false, "Skip the test cases of manifests whose requirements this extproctor does not meet, instead of failing")
+	rootCmd.PersistentFlags().BoolVar(&lenient, "lenient", false, "Discard the manifest fields unknown to this extproctor with a warning, instead of failing to parse the manifest")
+
+	// Environment flags
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Profile conditional expectations are evaluated against")
+	rootCmd.PersistentFlags().StringToStringVar(&vars, "var", nil, "Variables conditional expectations are evaluated against (key=value)")
+
+	// Configuration flags
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Configuration file (defaults to .extproctor.textproto when present)")
+	rootCmd.PersistentFlags().BoolVar(&ciMode, "ci", false, "Read-only mode for pipelines: fail instead of rewriting manifests or golden files (--update-golden, fmt --write, ...) and do not record the run state")
+
+	// Extension flags
+	rootCmd.PersistentFlags().StringArrayVar(&plugins, "plugin", nil, "Plugin command transforming the loaded manifests (repeatable)")
+}
+
+// humanOptions returns the formatting options of the human reporter: durations
+// follow --duration-format, colors are disabled by --no-color or $NO_COLOR,
+// and difference values wrapped to the width of the terminal (or $COLUMNS).
+func humanOptions() []reporter.HumanOption {
+	opts := []reporter.HumanOption{reporter.WithDurationFormat(durationFormat.format)}
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		opts = append(opts, reporter.WithNoColor())
+	}
+	if asciiOnly {
+		opts = append(opts, reporter.WithASCII())
+	}
+	if width := terminalWidth(); width > 0 {
+		opts = append(opts, reporter.WithWidth(width))
+	}
+	return opts
+}
+
+// terminalWidth returns the width of the terminal of stdout, or $COLUMNS when
+// it is not a terminal (0 when unknown).
+func terminalWidth() int {
+	if fd := int(os.Stdout.Fd()); term.IsTerminal(fd) {
+		if width, _, err := term.GetSize(fd); err == nil {
+			return width
+		}
+	}
+	width, _ := strconv.Atoi(os.Getenv("COLUMNS"))
+	return width
+}
+
+// durationFormatValue is the value of the --duration-format flag.
+type durationFormatValue struct {
+	format units.DurationFormat
+}
+
+// String implements pflag.Value.
+func (v *durationFormatValue) String() string {
+	return string(v.format)
+}
+
+// Set implements pflag.Value.
+func (v *durationFormatValue) Set(value string) error {
+	format, err := units.ParseDurationFormat(value)
+	if err != nil {
+		return err
+	}
+	v.format = format
+	return nil
+}
+
+// Type implements pflag.Value.
+func (v *durationFormatValue) Type() string {
+	return "format"
+}
+
+// forbidInCI returns an error when an operation rewriting manifests or golden
+// files is requested with --ci.
+func forbidInCI(operation string) error {
+	if ciMode {
+		return fmt.Errorf("%s rewrites expectation files, which --ci forbids", operation)
+	}
+	return nil
+}
+
+// newLoader creates a manifest loader for the environment and plugins
+// selected by flags.
+func newLoader() (*manifest.Loader, error) {
+	opts := []manifest.LoaderOption{
+		manifest.WithProfile(profile),
+		manifest.WithVars(vars),
+		manifest.WithFollowSymlinks(!noFollowSymlinks),
+		manifest.WithSkipUnsupported(skipUnsupported),
+		manifest.WithLenient(lenient),
+	}
+	for _, command := range plugins {
+		p, err := plugin.New(command)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, manifest.WithTransformers(p))
+	}
+
+	return manifest.NewLoader(opts...), nil
+}
+
+// loadPaths loads the manifests of the given paths, printing the fields
+// discarded by --lenient as warnings.
+func loadPaths(loader *manifest.Loader, paths []string) ([]*manifest.LoadedManifest, error) {
+	manifests, err := loader.LoadPaths(paths)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range manifests {
+		printDiscarded(m)
+	}
+	return manifests, nil
+}
+
+// printDiscarded prints the fields of a manifest discarded by --lenient.
+func printDiscarded(m *manifest.LoadedManifest) {
+	for _, w := range m.Warnings {
+		fmt.Fprintf(os.Stderr, "WARNING: %s: %s\n", m.SourcePath, w)
+	}
+}
+
+// newClient creates an ExtProc client for the connection selected by flags.
+// Only the run command accepts several targets.
+func newClient() (*client.Client, error) {
+	if len(targets.values) > 1 {
+		return nil, errors.New("several --target values are only supported by the run command")
+	}
+	_, address, err := parseTarget(targets.values[0])
+	if err != nil {
+		return nil, err
+	}
+	return newTargetClient(address)
+}
+
+// newTargetClient creates an ExtProc client for the given target address, or
+// the --unix-socket, through the proxy and with the credentials of the
+// configuration file unless --proxy and --auth-token are given.
+func newTargetClient(address string) (*client.Client, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var clientOpts []client.Option
+	if unixSocket != "" {
+		clientOpts = append(clientOpts, client.WithUnixSocket(unixSocket))
+	} else {
+		clientOpts = append(clientOpts, client.WithTarget(address))
+		proxy := proxyURL
+		if proxy == "" {
+			proxy = config.Proxy(cfg, profile)
+		}
+		clientOpts = append(clientOpts, client.WithProxy(proxy))
+		if tlsEnable {
+			clientOpts = append(clientOpts, client.WithTLS(tlsCert, tlsKey, tlsCA), client.WithServerName(tlsServer))
+		}
+	}
+
+	token := authToken
+	if token == "" {
+		token = os.Getenv("EXTPROCTOR_AUTH_TOKEN")
+	}
+	switch {
+	case token != "":
+		clientOpts = append(clientOpts, client.WithAuthToken(token))
+	case cfg.Auth != nil:
+		creds, err := auth.New(context.Background(), cfg.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration: auth: %w", err)
+		}
+		clientOpts = append(clientOpts, client.WithPerRPCCredentials(creds))
+	}
+	if grpcLog {
+		clientOpts = append(clientOpts, client.WithRequestLog(os.Stderr))
+	}
+	if rawHeaderValues {
+		clientOpts = append(clientOpts, client.WithRawHeaderValues(true))
+	}
+	clientOpts = append(clientOpts, client.WithRetry(retries))
+	if maxReconnects > 0 {
+		clientOpts = append(clientOpts, client.WithReconnectBackoff(reconnectDelay))
+	}
+	md, err := parseMetadata(grpcMetadata)
+	if err != nil {
+		return nil, err
+	}
+	if md != nil {
+		clientOpts = append(clientOpts, client.WithMetadata(md))
+	}
+
+	return client.New(clientOpts...)
 }
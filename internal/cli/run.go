@@ -5,19 +5,63 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+	"zntr.io/extproctor/internal/attest"
 	"zntr.io/extproctor/internal/client"
-	"zntr.io/extproctor/internal/manifest"
+	"zntr.io/extproctor/internal/config"
+	"zntr.io/extproctor/internal/durations"
+	"zntr.io/extproctor/internal/lastfailed"
+	"zntr.io/extproctor/internal/random"
 	"zntr.io/extproctor/internal/reporter"
 	"zntr.io/extproctor/internal/runner"
+	"zntr.io/extproctor/internal/sink"
+	"zntr.io/extproctor/internal/units"
+	"zntr.io/extproctor/internal/vcs"
 )
 
-var updateGolden bool
+var (
+	updateGolden   bool
+	groupByOwner   bool
+	targetName     string
+	maxDiffBytes   int
+	changedSince   string
+	artifactsDir   string
+	filterLog      string
+	noTestIDHeader bool
+	rerunFailed    bool
+	smokeFirst     bool
+	failFast       bool
+	infraFailures  string
+	maxDuration    time.Duration
+	testTimeout    time.Duration
+	failedFirst    bool
+	seed           uint64
+	healthInterval time.Duration
+	healthTimeout  time.Duration
+	healthService  string
+	maxReconnects  int
+	reconnectWait  time.Duration
+	reconnectDelay time.Duration
+	budget         map[string]string
+	grpcMetadata   []string
+	labels         []string
+	signKey        string
+
+	// lastFailedPath is the file recording the failed tests between runs.
+	lastFailedPath = lastfailed.DefaultPath
+
+	// durationsPath is the file recording the test durations between runs.
+	durationsPath = durations.DefaultPath
+)
 
 var runCmd = &cobra.Command{
 	Use:   "run [paths...]",
@@ -38,8 +82,50 @@ Examples:
   # JSON output for CI
   extproctor run ./tests/ --target localhost:50051 --output json
 
+  # Run the tests of a team, grouped by owner
+  extproctor run ./tests/ --target localhost:50051 --owner payments --group-by-owner
+
+  # Evaluate conditional expectations for the prod profile
+  extproctor run ./tests/ --target localhost:50051 --profile prod --var region=eu
+
+  # Only run the tests affected by the changes of a pull request
+  extproctor run ./tests/ --target localhost:50051 --changed-since origin/main
+
+  # Only run the tests that failed during the previous run
+  extproctor run ./tests/ --target localhost:50051 --rerun-failed
+
+  # Keep the details of failed tests as CI artifacts
+  extproctor run ./tests/ --target localhost:50051 --artifacts-dir ./artifacts
+
+  # Attach the ExtProc service logs to failed tests
+  extproctor run ./tests/ --target localhost:50051 --filter-log /var/log/extproc.log
+
+  # Abort quickly when the smoke tests fail
+  extproctor run ./tests/ --target localhost:50051 --smoke-first --fail-fast --parallel 8
+
+  # Pause the tests while the target reports NOT_SERVING on its health service
+  extproctor run ./tests/ --target localhost:50051 --health-interval 10s
+
+  # Monitor a deployment without failing on transient network errors
+  extproctor run ./tests/ --target localhost:50051 --infra-failures skip
+
+  # Wait for a restarting target instead of failing the remaining tests
+  extproctor run ./tests/ --target localhost:50051 --max-reconnects 3
+
+  # Keep the run within 10 minutes
+  extproctor run ./tests/ --target localhost:50051 --max-duration 10m
+
+  # Profile extproctor itself on a slow suite
+  extproctor run ./tests/ --target localhost:50051 --cpuprofile cpu.pprof --trace trace.out
+
   # Update golden files
-  extproctor run ./tests/ --target localhost:50051 --update-golden`,
+  extproctor run ./tests/ --target localhost:50051 --update-golden
+
+  # Run the suite against several targets, in parallel
+  extproctor run ./tests/ --target eu=eu.internal:50051 --target us=us.internal:50051 --parallel 4
+
+  # Use per-environment golden files (golden_file: "golden/{target}/{test_name}.textproto")
+  extproctor run ./tests/ --target staging.internal:50051 --target-name staging`,
 	Args:         cobra.MinimumNArgs(1),
 	SilenceUsage: true,
 	RunE:         runTests,
@@ -47,6 +133,32 @@ Examples:
 
 func init() {
 	runCmd.Flags().BoolVar(&updateGolden, "update-golden", false, "Update golden files with actual responses")
+	runCmd.Flags().StringVar(&targetName, "target-name", "", "Name substituted for {target} in golden paths (defaults to the target address)")
+	runCmd.Flags().IntVar(&maxDiffBytes, "max-diff-bytes", 1024, "Truncate difference values longer than this many bytes in reports (0 disables)")
+	runCmd.Flags().StringVar(&changedSince, "changed-since", "", "Only run tests whose manifest, imported manifests, request files or golden files changed since this git ref")
+	runCmd.Flags().StringVar(&artifactsDir, "artifacts-dir", "", "Write the request, raw responses and differences of each failed test in this directory")
+	runCmd.Flags().StringVar(&filterLog, "filter-log", "", "Log file of the ExtProc service, whose lines written during a failed test are attached to its result")
+	runCmd.Flags().BoolVar(&noTestIDHeader, "no-test-id-header", false, "Do not inject the x-extproctor-test-id header in test requests")
+	runCmd.Flags().BoolVar(&smokeFirst, "smoke-first", false, "Run the smoke tests (positive priority) to completion before the others")
+	runCmd.Flags().BoolVar(&failFast, "fail-fast", false, "Stop running tests after the first failure")
+	runCmd.Flags().StringVar(&infraFailures, "infra-failures", "fail", "Whether connection errors and timeouts fail the tests (fail) or skip them with a warning (skip)")
+	runCmd.Flags().DurationVar(&maxDuration, "max-duration", 0, "Stop starting tests after this duration (e.g. 10m), the remaining tests are reported as skipped")
+	runCmd.Flags().DurationVar(&testTimeout, "test-timeout", 0, "Timeout of the tests without a timeout field (e.g. 30s), failing a test whose ExtProc service stalls (0 disables)")
+	runCmd.Flags().BoolVar(&rerunFailed, "rerun-failed", false, "Only run the tests that failed during the previous run")
+	runCmd.Flags().BoolVar(&failedFirst, "rerun-failed-first", false, "Run the tests that failed during the previous run first")
+	runCmd.Flags().Uint64Var(&seed, "seed", 0, "Seed of the random template functions of test requests (random by default, printed in the summary)")
+	runCmd.Flags().DurationVar(&healthInterval, "health-interval", 0, "Check the gRPC health of the targets before the run and at this interval, pausing the tests while a target is not serving (0 disables)")
+	runCmd.Flags().DurationVar(&healthTimeout, "health-timeout", time.Minute, "How long the tests wait for a target to serve again before being skipped")
+	runCmd.Flags().StringVar(&healthService, "health-service", "", "Service name sent in the health checks (defaults to the overall server status)")
+	runCmd.Flags().IntVar(&maxReconnects, "max-reconnects", 0, "Pause the tests of a target whose connection dropped until it reconnects, up to this many times per target (0 disables)")
+	runCmd.Flags().DurationVar(&reconnectWait, "reconnect-timeout", time.Minute, "How long the tests wait for a target to reconnect")
+	runCmd.Flags().DurationVar(&reconnectDelay, "reconnect-backoff", 5*time.Second, "Maximum delay between two reconnection attempts, the delay growing exponentially from 100ms")
+	runCmd.Flags().StringToStringVar(&budget, "budget", nil, "Refuse to run suites whose cost exceeds these limits (tests=500,body_size=1GiB,duration=10m), from the cost hints of the tests")
+	runCmd.Flags().StringArrayVar(&grpcMetadata, "metadata", nil, "gRPC metadata sent on the processing streams (key=value, repeatable), overridden by the grpc_metadata of the manifests")
+	runCmd.Flags().StringArrayVar(&labels, "label", nil, "Label attached to the suite summary of the outputs and result sinks (key=value, repeatable, e.g. env=staging)")
+	runCmd.Flags().StringVar(&signKey, "sign-key", "", "PEM private key signing a provenance attestation of the json_file result sinks (<path>.intoto.json)")
+	runCmd.Flags().BoolVar(&groupByOwner, "group-by-owner", false, "Group test results by manifest owner in human output")
+	addProfilingFlags(runCmd)
 	rootCmd.AddCommand(runCmd)
 }
 
@@ -63,9 +175,37 @@ func runTests(cmd *cobra.Command, args []string) error {
 		cancel()
 	}()
 
+	if updateGolden {
+		if err := forbidInCI("--update-golden"); err != nil {
+			return err
+		}
+	}
+
+	runBudget, err := parseBudget(budget)
+	if err != nil {
+		return err
+	}
+	skipInfra, err := parseInfraFailures(infraFailures)
+	if err != nil {
+		return err
+	}
+	runLabels, err := parseLabels(labels)
+	if err != nil {
+		return err
+	}
+
+	stop, err := startProfiling()
+	if err != nil {
+		return err
+	}
+	defer stopProfiling(stop)
+
 	// Load manifests from paths
-	loader := manifest.NewLoader()
-	manifests, err := loader.LoadPaths(args)
+	loader, err := newLoader()
+	if err != nil {
+		return err
+	}
+	manifests, err := loadPaths(loader, args)
 	if err != nil {
 		return fmt.Errorf("failed to load manifests: %w", err)
 	}
@@ -78,32 +218,74 @@ func runTests(cmd *cobra.Command, args []string) error {
 	var rep reporter.Reporter
 	switch output {
 	case "json":
-		rep = reporter.NewJSONReporter(os.Stdout)
+		rep = reporter.NewJSONReporter(os.Stdout, reporter.WithJSONDurationFormat(durationFormat.format))
 	default:
-		rep = reporter.NewHumanReporter(os.Stdout, verbose)
+		humanOpts := humanOptions()
+		if groupByOwner {
+			humanOpts = append(humanOpts, reporter.WithGroupByOwner())
+		}
+		rep = reporter.NewHumanReporter(os.Stdout, verbose, humanOpts...)
 	}
 
-	// Create ExtProc client
-	var clientOpts []client.Option
-	if unixSocket != "" {
-		clientOpts = append(clientOpts, client.WithUnixSocket(unixSocket))
-	} else {
-		clientOpts = append(clientOpts, client.WithTarget(target))
-		if tlsEnable {
-			clientOpts = append(clientOpts, client.WithTLS(tlsCert, tlsKey, tlsCA))
-		}
+	// Write the results to the sinks of the configuration
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return err
 	}
-	extProcClient, err := client.New(clientOpts...)
+	sinks, err := sink.FromConfig(cfg.ResultSinks)
 	if err != nil {
-		return fmt.Errorf("failed to create ExtProc client: %w", err)
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	sinkReporter := sink.NewReporter(sinks...)
+
+	var signer *attest.Key
+	var signedPaths []string
+	if signKey != "" {
+		if signedPaths, err = signedResultPaths(cfg.ResultSinks); err != nil {
+			return err
+		}
+		if signer, err = attest.LoadKey(signKey); err != nil {
+			return err
+		}
+	}
+	if len(sinks) > 0 {
+		rep = reporter.NewMultiReporter(rep, sinkReporter)
+	}
+
+	// Create the ExtProc client, or one per target of a fan-out run
+	var extProcClient *client.Client
+	var fanOut []runner.Target
+	if len(targets.values) > 1 {
+		if targetName != "" {
+			return errors.New("--target-name only applies to a single target, name the targets with --target name=address")
+		}
+		fanOut, err = newFanOutTargets()
+		if err != nil {
+			return fmt.Errorf("failed to create ExtProc client: %w", err)
+		}
+		defer closeTargets(fanOut)
+	} else {
+		extProcClient, err = newClient()
+		if err != nil {
+			return fmt.Errorf("failed to create ExtProc client: %w", err)
+		}
+		defer func() { _ = extProcClient.Close() }()
 	}
-	defer func() { _ = extProcClient.Close() }()
 
 	// Create and configure runner
 	runnerOpts := []runner.Option{
 		runner.WithParallel(parallel),
 		runner.WithReporter(rep),
 		runner.WithVerbose(verbose),
+		runner.WithMaxDiffBytes(maxDiffBytes),
+		runner.WithSmokeFirst(smokeFirst),
+		runner.WithFailFast(failFast),
+		runner.WithSkipInfraFailures(skipInfra),
+		runner.WithMaxDuration(maxDuration),
+		runner.WithTestTimeout(testTimeout),
+		runner.WithSeed(runSeed(cmd)),
+		runner.WithBudget(runBudget),
+		runner.WithLabels(runLabels),
 	}
 	if filter != "" {
 		runnerOpts = append(runnerOpts, runner.WithFilter(filter))
@@ -111,9 +293,76 @@ func runTests(cmd *cobra.Command, args []string) error {
 	if len(tags) > 0 {
 		runnerOpts = append(runnerOpts, runner.WithTags(tags))
 	}
+	if len(owners) > 0 {
+		runnerOpts = append(runnerOpts, runner.WithOwners(owners))
+	}
 	if updateGolden {
 		runnerOpts = append(runnerOpts, runner.WithUpdateGolden(true))
 	}
+	runnerOpts = append(runnerOpts, runner.WithTargetName(goldenTargetName()))
+	if len(fanOut) > 0 {
+		runnerOpts = append(runnerOpts, runner.WithTargets(fanOut...))
+	}
+	if healthInterval > 0 {
+		runnerOpts = append(runnerOpts, runner.WithHealthCheck(runner.HealthCheck{
+			Service:  healthService,
+			Interval: healthInterval,
+			Timeout:  healthTimeout,
+			Log:      os.Stderr,
+		}))
+	}
+	if maxReconnects > 0 {
+		runnerOpts = append(runnerOpts, runner.WithReconnect(runner.Reconnect{
+			Max:     maxReconnects,
+			Timeout: reconnectWait,
+			Log:     os.Stderr,
+		}))
+	}
+	if artifactsDir != "" {
+		runnerOpts = append(runnerOpts, runner.WithArtifactsDir(artifactsDir))
+	}
+	if noTestIDHeader {
+		runnerOpts = append(runnerOpts, runner.WithTestIDHeader(false))
+	}
+	if filterLog != "" {
+		runnerOpts = append(runnerOpts, runner.WithFilterLog(filterLog))
+	}
+	if changedSince != "" {
+		files, err := vcs.ChangedFiles(ctx, ".", changedSince)
+		if err != nil {
+			return fmt.Errorf("failed to list files changed since %s: %w", changedSince, err)
+		}
+		if files == nil {
+			files = []string{}
+		}
+		runnerOpts = append(runnerOpts, runner.WithChangedFiles(files))
+	}
+
+	// The failures recorded by the previous run are only required to rerun
+	// them
+	previousFailures, err := lastfailed.Load(lastFailedPath)
+	if err != nil {
+		if rerunFailed || failedFirst {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	switch {
+	case rerunFailed && len(previousFailures) == 0:
+		fmt.Fprintln(os.Stderr, "No failed tests recorded, running all tests")
+	case rerunFailed:
+		runnerOpts = append(runnerOpts, runner.WithTestIDs(previousFailures))
+	case failedFirst:
+		runnerOpts = append(runnerOpts, runner.WithFirst(previousFailures))
+	}
+
+	// The durations are a scheduling hint: the tests keep their default order
+	// without them
+	previousDurations, err := durations.Load(durationsPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	runnerOpts = append(runnerOpts, runner.WithDurations(previousDurations))
 
 	testRunner := runner.New(extProcClient, runnerOpts...)
 
@@ -123,10 +372,172 @@ func runTests(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("test execution failed: %w", err)
 	}
 
+	if err := sinkReporter.Err(); err != nil {
+		return fmt.Errorf("failed to write results: %w", err)
+	}
+	if signer != nil {
+		if err := signResults(signedPaths, manifests, signer); err != nil {
+			return fmt.Errorf("failed to sign results: %w", err)
+		}
+	}
+
+	// Record the failures for the next --rerun-failed and the durations to
+	// start the longest tests first next time, without failing the run whose
+	// results are already emitted. --ci leaves the working tree untouched.
+	if !ciMode {
+		if err := lastfailed.Save(lastFailedPath, lastfailed.Merge(previousFailures, ranIDs(results), failedIDs(results))); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+		if err := durations.Save(durationsPath, durations.Merge(previousDurations, ranDurations(results))); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+
+	if results.InfraSkipped > 0 {
+		fmt.Fprintf(os.Stderr, "warning: %d test(s) skipped because of infrastructure failures (connection errors or timeouts)\n", results.InfraSkipped)
+	}
+
 	// Check for failures
 	if results.Failed > 0 {
 		return fmt.Errorf("%d test(s) failed", results.Failed)
 	}
+	if results.NotServing {
+		return errors.New("tests skipped because the target was not serving")
+	}
+	if results.BudgetExceeded {
+		return &ExitError{
+			Code: ExitBudgetExceeded,
+			Err:  fmt.Errorf("time budget of %s exceeded, remaining tests skipped", maxDuration),
+		}
+	}
 
 	return nil
 }
+
+// parseInfraFailures parses the --infra-failures policy, returning whether
+// infrastructure failures skip the tests.
+func parseInfraFailures(policy string) (bool, error) {
+	switch policy {
+	case "fail":
+		return false, nil
+	case "skip":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid --infra-failures %q (use skip or fail)", policy)
+	}
+}
+
+// parseBudget parses the --budget limits.
+func parseBudget(limits map[string]string) (runner.Budget, error) {
+	var b runner.Budget
+	for key, value := range limits {
+		var err error
+		switch key {
+		case "tests":
+			b.Tests, err = strconv.Atoi(value)
+			if err == nil && b.Tests <= 0 {
+				err = errors.New("must be positive")
+			}
+		case "body_size":
+			b.BodySize, err = units.ParseSize(value)
+		case "duration":
+			b.Duration, err = units.ParseDuration(value)
+		default:
+			return b, fmt.Errorf("invalid --budget: unknown limit %q (use tests, body_size or duration)", key)
+		}
+		if err != nil {
+			return b, fmt.Errorf("invalid --budget %s: %w", key, err)
+		}
+	}
+	return b, nil
+}
+
+// parseMetadata parses the --metadata key=value pairs.
+func parseMetadata(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	md := make(map[string]string, len(values))
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --metadata %q: expected key=value", v)
+		}
+		md[key] = value
+	}
+	if err := client.ValidateMetadata(md); err != nil {
+		return nil, fmt.Errorf("invalid --metadata: %w", err)
+	}
+	return md, nil
+}
+
+// parseLabels parses the --label key=value pairs.
+func parseLabels(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(values))
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --label %q: expected key=value", v)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// ranIDs returns the IDs of the tests that were executed.
+func ranIDs(results *runner.Results) []string {
+	var ids []string
+	for _, t := range results.Tests {
+		if !t.Skipped {
+			ids = append(ids, t.ID)
+		}
+	}
+	return ids
+}
+
+// ranDurations returns the duration of the tests that were executed, the
+// longest one of a test run against several targets.
+func ranDurations(results *runner.Results) map[string]time.Duration {
+	ran := make(map[string]time.Duration)
+	for _, t := range results.Tests {
+		if !t.Skipped && t.Duration > ran[t.ID] {
+			ran[t.ID] = t.Duration
+		}
+	}
+	return ran
+}
+
+// failedIDs returns the IDs of the tests that failed.
+func failedIDs(results *runner.Results) []string {
+	var ids []string
+	for _, t := range results.Tests {
+		if !t.Passed && !t.Skipped {
+			ids = append(ids, t.ID)
+		}
+	}
+	return ids
+}
+
+// goldenTargetName returns the name substituted for {target} in golden paths.
+func goldenTargetName() string {
+	switch {
+	case targetName != "":
+		return targetName
+	case unixSocket != "":
+		return unixSocket
+	default:
+		name, _, _ := parseTarget(targets.values[0])
+		return name
+	}
+}
+
+// runSeed returns the --seed flag, or a random seed when not set.
+func runSeed(cmd *cobra.Command) uint64 {
+	if cmd.Flags().Changed("seed") {
+		return seed
+	}
+	return random.NewSeed()
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
@@ -44,9 +45,9 @@ func TestRunTests_NoManifests(t *testing.T) {
 	tmpDir := t.TempDir()
 
 	// Override global flags for this test
-	oldTarget := target
-	target = "localhost:59999"
-	defer func() { target = oldTarget }()
+	oldTargets := targets
+	targets = targetList{values: []string{"localhost:59999"}}
+	defer func() { targets = oldTargets }()
 
 	cmd := &cobra.Command{}
 
@@ -57,9 +58,9 @@ func TestRunTests_NoManifests(t *testing.T) {
 
 func TestRunTests_InvalidPath(t *testing.T) {
 	// Override global flags for this test
-	oldTarget := target
-	target = "localhost:59999"
-	defer func() { target = oldTarget }()
+	oldTargets := targets
+	targets = targetList{values: []string{"localhost:59999"}}
+	defer func() { targets = oldTargets }()
 
 	cmd := &cobra.Command{}
 
@@ -84,18 +85,18 @@ test_cases: {
 	require.NoError(t, err)
 
 	// Override global flags
-	oldTarget := target
+	oldTargets := targets
 	oldOutput := output
 	oldVerbose := verbose
 	oldParallel := parallel
 
-	target = "localhost:59999"
+	targets = targetList{values: []string{"localhost:59999"}}
 	output = "human"
 	verbose = false
 	parallel = 1
 
 	defer func() {
-		target = oldTarget
+		targets = oldTargets
 		output = oldOutput
 		verbose = oldVerbose
 		parallel = oldParallel
@@ -126,14 +127,14 @@ test_cases: {
 	require.NoError(t, err)
 
 	// Override global flags
-	oldTarget := target
+	oldTargets := targets
 	oldOutput := output
 
-	target = "localhost:59999"
+	targets = targetList{values: []string{"localhost:59999"}}
 	output = "json"
 
 	defer func() {
-		target = oldTarget
+		targets = oldTargets
 		output = oldOutput
 	}()
 
@@ -160,14 +161,14 @@ test_cases: {
 	require.NoError(t, err)
 
 	// Override global flags
-	oldTarget := target
+	oldTargets := targets
 	oldFilter := filter
 
-	target = "localhost:59999"
+	targets = targetList{values: []string{"localhost:59999"}}
 	filter = "test-*"
 
 	defer func() {
-		target = oldTarget
+		targets = oldTargets
 		filter = oldFilter
 	}()
 
@@ -194,14 +195,14 @@ test_cases: {
 	require.NoError(t, err)
 
 	// Override global flags
-	oldTarget := target
+	oldTargets := targets
 	oldTags := tags
 
-	target = "localhost:59999"
+	targets = targetList{values: []string{"localhost:59999"}}
 	tags = []string{"smoke"}
 
 	defer func() {
-		target = oldTarget
+		targets = oldTargets
 		tags = oldTags
 	}()
 
@@ -227,14 +228,14 @@ test_cases: {
 	require.NoError(t, err)
 
 	// Override global flags
-	oldTarget := target
+	oldTargets := targets
 	oldUpdateGolden := updateGolden
 
-	target = "localhost:59999"
+	targets = targetList{values: []string{"localhost:59999"}}
 	updateGolden = true
 
 	defer func() {
-		target = oldTarget
+		targets = oldTargets
 		updateGolden = oldUpdateGolden
 	}()
 
@@ -290,20 +291,20 @@ test_cases: {
 	require.NoError(t, err)
 
 	// Override global flags
-	oldTarget := target
+	oldTargets := targets
 	oldTLSEnable := tlsEnable
 	oldTLSCert := tlsCert
 	oldTLSKey := tlsKey
 	oldTLSCA := tlsCA
 
-	target = "localhost:59999"
+	targets = targetList{values: []string{"localhost:59999"}}
 	tlsEnable = true
 	tlsCert = ""
 	tlsKey = ""
 	tlsCA = ""
 
 	defer func() {
-		target = oldTarget
+		targets = oldTargets
 		tlsEnable = oldTLSEnable
 		tlsCert = oldTLSCert
 		tlsKey = oldTLSKey
@@ -332,14 +333,14 @@ test_cases: {
 	require.NoError(t, err)
 
 	// Override global flags
-	oldTarget := target
+	oldTargets := targets
 	oldParallel := parallel
 
-	target = "localhost:59999"
+	targets = targetList{values: []string{"localhost:59999"}}
 	parallel = 4
 
 	defer func() {
-		target = oldTarget
+		targets = oldTargets
 		parallel = oldParallel
 	}()
 
@@ -365,14 +366,14 @@ test_cases: {
 	require.NoError(t, err)
 
 	// Override global flags
-	oldTarget := target
+	oldTargets := targets
 	oldVerbose := verbose
 
-	target = "localhost:59999"
+	targets = targetList{values: []string{"localhost:59999"}}
 	verbose = true
 
 	defer func() {
-		target = oldTarget
+		targets = oldTargets
 		verbose = oldVerbose
 	}()
 
@@ -381,3 +382,253 @@ test_cases: {
 	err = runTests(cmd, []string{tmpDir})
 	assert.Error(t, err)
 }
+
+func TestRunTests_Targets(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := `
+name: "test-manifest"
+test_cases: {
+  name: "test-1"
+  request: { method: "GET", path: "/" }
+  expectations: { phase: REQUEST_HEADERS, headers_response: {} }
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "test.textproto"), []byte(content), 0o644))
+
+	oldTargets := targets
+	oldTargetName := targetName
+	defer func() {
+		targets = oldTargets
+		targetName = oldTargetName
+	}()
+
+	// Every target runs the suite
+	targets = targetList{values: []string{"eu=localhost:59998", "us=localhost:59999"}}
+	err := runTests(&cobra.Command{}, []string{tmpDir})
+	assert.EqualError(t, err, "2 test(s) failed")
+
+	targetName = "staging"
+	err = runTests(&cobra.Command{}, []string{tmpDir})
+	assert.ErrorContains(t, err, "--target-name only applies to a single target")
+
+	targetName = ""
+	targets = targetList{values: []string{"eu=localhost:59998", "eu=localhost:59999"}}
+	err = runTests(&cobra.Command{}, []string{tmpDir})
+	assert.ErrorContains(t, err, `duplicate target "eu"`)
+
+	// Other commands take a single target
+	_, err = newClient()
+	assert.EqualError(t, err, "several --target values are only supported by the run command")
+}
+
+func TestRunTests_Budget(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := `
+name: "test-manifest"
+test_cases: {
+  name: "huge-upload"
+  request: { method: "POST", path: "/" }
+  cost: { body_size: "2GiB" duration: "5m" }
+  expectations: { phase: REQUEST_HEADERS, headers_response: {} }
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "test.textproto"), []byte(content), 0o644))
+
+	oldBudget := budget
+	defer func() { budget = oldBudget }()
+
+	budget = map[string]string{"body_size": "1GiB", "duration": "10m"}
+	err := runTests(&cobra.Command{}, []string{tmpDir})
+	assert.EqualError(t, err, "test execution failed: cost budget exceeded: body size 2GiB > 1GiB")
+
+	budget = map[string]string{"memory": "1GiB"}
+	err = runTests(&cobra.Command{}, []string{tmpDir})
+	assert.EqualError(t, err, `invalid --budget: unknown limit "memory" (use tests, body_size or duration)`)
+
+	budget = map[string]string{"tests": "0"}
+	err = runTests(&cobra.Command{}, []string{tmpDir})
+	assert.EqualError(t, err, "invalid --budget tests: must be positive")
+}
+
+func TestRunTests_InfraFailures(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := `
+name: "test-manifest"
+test_cases: {
+  name: "test-1"
+  request: { method: "GET", path: "/" }
+  expectations: { phase: REQUEST_HEADERS, headers_response: {} }
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "test.textproto"), []byte(content), 0o644))
+
+	oldTargets, oldInfraFailures := targets, infraFailures
+	defer func() {
+		targets, infraFailures = oldTargets, oldInfraFailures
+	}()
+	targets = targetList{values: []string{"localhost:59999"}}
+
+	infraFailures = "fail"
+	err := runTests(&cobra.Command{}, []string{tmpDir})
+	assert.EqualError(t, err, "1 test(s) failed")
+
+	// The unreachable target skips the test
+	infraFailures = "skip"
+	require.NoError(t, runTests(&cobra.Command{}, []string{tmpDir}))
+
+	infraFailures = "ignore"
+	err = runTests(&cobra.Command{}, []string{tmpDir})
+	assert.EqualError(t, err, `invalid --infra-failures "ignore" (use skip or fail)`)
+}
+
+func TestRunTests_LastFailedState(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := `
+name: "test-manifest"
+test_cases: {
+  name: "test-1"
+  request: { method: "GET", path: "/" }
+  expectations: { phase: REQUEST_HEADERS, headers_response: {} }
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "test.textproto"), []byte(content), 0o644))
+
+	oldTargets, oldInfraFailures, oldLastFailedPath, oldRerunFailed := targets, infraFailures, lastFailedPath, rerunFailed
+	defer func() {
+		targets, infraFailures, lastFailedPath, rerunFailed = oldTargets, oldInfraFailures, oldLastFailedPath, oldRerunFailed
+	}()
+	targets = targetList{values: []string{"localhost:59999"}}
+	infraFailures = "skip"
+
+	// A corrupt state file only matters to rerun the failures
+	stateDir := t.TempDir()
+	lastFailedPath = filepath.Join(stateDir, "last-failed.json")
+	require.NoError(t, os.WriteFile(lastFailedPath, []byte("{"), 0o644))
+	require.NoError(t, runTests(&cobra.Command{}, []string{tmpDir}))
+
+	require.NoError(t, os.WriteFile(lastFailedPath, []byte("{"), 0o644))
+	rerunFailed = true
+	err := runTests(&cobra.Command{}, []string{tmpDir})
+	assert.ErrorContains(t, err, "failed to parse last failed tests")
+	rerunFailed = false
+
+	// A state file that cannot be written does not fail the run
+	lastFailedPath = filepath.Join(tmpDir, "test.textproto", "last-failed.json")
+	require.NoError(t, runTests(&cobra.Command{}, []string{tmpDir}))
+}
+
+func TestRunTests_DurationsState(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := `
+name: "test-manifest"
+test_cases: {
+  name: "test-1"
+  request: { method: "GET", path: "/" }
+  expectations: { phase: REQUEST_HEADERS, headers_response: {} }
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "test.textproto"), []byte(content), 0o644))
+
+	oldTargets, oldInfraFailures, oldDurationsPath := targets, infraFailures, durationsPath
+	defer func() {
+		targets, infraFailures, durationsPath = oldTargets, oldInfraFailures, oldDurationsPath
+	}()
+	targets = targetList{values: []string{"localhost:59999"}}
+	infraFailures = "skip"
+
+	// A corrupt state file falls back to the default order
+	durationsPath = filepath.Join(t.TempDir(), "durations.json")
+	require.NoError(t, os.WriteFile(durationsPath, []byte("{"), 0o644))
+	require.NoError(t, runTests(&cobra.Command{}, []string{tmpDir}))
+
+	// A state file that cannot be written does not fail the run
+	durationsPath = filepath.Join(tmpDir, "test.textproto", "durations.json")
+	require.NoError(t, runTests(&cobra.Command{}, []string{tmpDir}))
+}
+
+func TestRunTests_CIStateUntouched(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := `
+name: "test-manifest"
+test_cases: {
+  name: "test-1"
+  request: { method: "GET", path: "/" }
+  expectations: { phase: REQUEST_HEADERS, headers_response: {} }
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "test.textproto"), []byte(content), 0o644))
+
+	oldTargets, oldCIMode, oldLastFailedPath, oldDurationsPath := targets, ciMode, lastFailedPath, durationsPath
+	defer func() {
+		targets, ciMode, lastFailedPath, durationsPath = oldTargets, oldCIMode, oldLastFailedPath, oldDurationsPath
+	}()
+	targets = targetList{values: []string{"localhost:59999"}}
+	ciMode = true
+	stateDir := t.TempDir()
+	lastFailedPath = filepath.Join(stateDir, "last-failed.json")
+	durationsPath = filepath.Join(stateDir, "durations.json")
+
+	err := runTests(&cobra.Command{}, []string{tmpDir})
+	assert.EqualError(t, err, "1 test(s) failed")
+	assert.NoFileExists(t, lastFailedPath)
+	assert.NoFileExists(t, durationsPath)
+}
+
+func TestRunTests_MaxReconnects(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := `
+name: "test-manifest"
+test_cases: {
+  name: "test-1"
+  request: { method: "GET", path: "/" }
+  expectations: { phase: REQUEST_HEADERS, headers_response: {} }
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "test.textproto"), []byte(content), 0o644))
+
+	oldTargets, oldMaxReconnects, oldReconnectWait := targets, maxReconnects, reconnectWait
+	defer func() {
+		targets, maxReconnects, reconnectWait = oldTargets, oldMaxReconnects, oldReconnectWait
+	}()
+	targets = targetList{values: []string{"localhost:59999"}}
+	maxReconnects = 1
+	reconnectWait = 100 * time.Millisecond
+
+	// The target never comes back
+	start := time.Now()
+	err := runTests(&cobra.Command{}, []string{tmpDir})
+	assert.EqualError(t, err, "1 test(s) failed")
+	assert.GreaterOrEqual(t, time.Since(start), reconnectWait)
+}
+
+func TestParseMetadata(t *testing.T) {
+	md, err := parseMetadata(nil)
+	require.NoError(t, err)
+	assert.Nil(t, md)
+
+	md, err = parseMetadata([]string{"x-tenant-id=acme", "authorization=Bearer a=b"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"x-tenant-id": "acme", "authorization": "Bearer a=b"}, md)
+
+	_, err = parseMetadata([]string{"x-tenant-id"})
+	assert.EqualError(t, err, `invalid --metadata "x-tenant-id": expected key=value`)
+
+	_, err = parseMetadata([]string{"grpc-timeout=1s"})
+	assert.EqualError(t, err, `invalid --metadata: metadata key "grpc-timeout" is reserved by gRPC`)
+}
+
+func TestParseLabels(t *testing.T) {
+	labels, err := parseLabels(nil)
+	require.NoError(t, err)
+	assert.Nil(t, labels)
+
+	labels, err = parseLabels([]string{"env=staging", "filter-version=1.4.2", "note="})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"env": "staging", "filter-version": "1.4.2", "note": ""}, labels)
+
+	_, err = parseLabels([]string{"staging"})
+	assert.EqualError(t, err, `invalid --label "staging": expected key=value`)
+
+	_, err = parseLabels([]string{"=staging"})
+	assert.EqualError(t, err, `invalid --label "=staging": expected key=value`)
+}
@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"zntr.io/extproctor/internal/security"
+)
+
+var (
+	secCategories []string
+	secTimeout    time.Duration
+)
+
+var secCmd = &cobra.Command{
+	Use:   "sec",
+	Short: "Probe the ExtProc service with header injection and smuggling requests",
+	Long: `Sec runs the built-in security test pack against the ExtProc service. It
+sends malformed and ambiguous requests that a lenient proxy could let
+through, bypassing the request checks of run:
+
+  injection  CR, LF and NUL in header values, the path and the authority
+  smuggling  conflicting content-length and transfer-encoding headers
+  limits     oversized header counts and values
+  path       percent-encoded, overlong UTF-8 and unicode path tricks
+
+A probe fails when the processing stream fails, when the service no longer
+answers a well-formed request afterwards, or when the service sets a
+dangerous header: a name or value that is not valid HTTP, the header injected
+by the probe, transfer-encoding, or content-length on a request with
+transfer-encoding.
+
+--filter selects probes by name pattern.
+
+Examples:
+  # Run every probe
+  extproctor sec --target localhost:50051
+
+  # Run the injection and smuggling probes
+  extproctor sec --target localhost:50051 --category injection,smuggling`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runSec,
+}
+
+func init() {
+	secCmd.Flags().StringSliceVar(&secCategories, "category", nil, "Probe categories to run (injection, smuggling, limits, path)")
+	secCmd.Flags().DurationVar(&secTimeout, "timeout", 5*time.Second, "Timeout of each probe")
+	rootCmd.AddCommand(secCmd)
+}
+
+func runSec(cmd *cobra.Command, args []string) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	for _, c := range secCategories {
+		if !slices.Contains(security.Categories(), c) {
+			return fmt.Errorf("unknown probe category %q (expected one of %s)", c, strings.Join(security.Categories(), ", "))
+		}
+	}
+
+	probes := selectProbes(security.Probes(), secCategories, filter)
+	if len(probes) == 0 {
+		return fmt.Errorf("no probes selected")
+	}
+
+	extProcClient, err := newClient()
+	if err != nil {
+		return fmt.Errorf("failed to create ExtProc client: %w", err)
+	}
+	defer func() { _ = extProcClient.Close() }()
+
+	results := security.Run(ctx, extProcClient, probes, secTimeout)
+
+	if output == "json" {
+		if err := printSecJSON(os.Stdout, results); err != nil {
+			return err
+		}
+	} else {
+		printSecReport(os.Stdout, results, verbose)
+	}
+
+	var failed int
+	for _, r := range results {
+		if !r.Passed() {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d probe(s) failed", failed)
+	}
+	return nil
+}
+
+// selectProbes returns the probes of the given categories (all when empty)
+// whose name matches the pattern (all when empty).
+func selectProbes(probes []*security.Probe, categories []string, pattern string) []*security.Probe {
+	var selected []*security.Probe
+	for _, p := range probes {
+		if len(categories) > 0 && !slices.Contains(categories, p.Category) {
+			continue
+		}
+		if pattern != "" {
+			if matched, err := filepath.Match(pattern, p.Name); err != nil || !matched {
+				continue
+			}
+		}
+		selected = append(selected, p)
+	}
+	return selected
+}
+
+func printSecReport(w io.Writer, results []*security.Result, verbose bool) {
+	var failed int
+	for _, r := range results {
+		status := "PASS"
+		if !r.Passed() {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Fprintf(w, "%s  %-9s  %s", status, r.Probe.Category, r.Probe.Name)
+		if verbose {
+			fmt.Fprintf(w, " (%s, %s)", r.Probe.Description, r.Duration.Round(time.Microsecond))
+		}
+		fmt.Fprintln(w)
+		for _, f := range r.Findings {
+			fmt.Fprintf(w, "        %s\n", f)
+		}
+	}
+	fmt.Fprintf(w, "\n%d probe(s): %d passed, %d failed\n", len(results), len(results)-failed, failed)
+}
+
+// secResultJSON is the JSON shape of a probe result.
+type secResultJSON struct {
+	Name        string   `json:"name"`
+	Category    string   `json:"category"`
+	Description string   `json:"description"`
+	Passed      bool     `json:"passed"`
+	Findings    []string `json:"findings,omitempty"`
+	DurationMs  float64  `json:"duration_ms"`
+}
+
+func printSecJSON(w io.Writer, results []*security.Result) error {
+	out := make([]secResultJSON, 0, len(results))
+	for _, r := range results {
+		out = append(out, secResultJSON{
+			Name:        r.Probe.Name,
+			Category:    r.Probe.Category,
+			Description: r.Probe.Description,
+			Passed:      r.Passed(),
+			Findings:    r.Findings,
+			DurationMs:  float64(r.Duration.Microseconds()) / 1000,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"zntr.io/extproctor/internal/security"
+)
+
+func TestSecCmd_HasFlags(t *testing.T) {
+	flags := secCmd.Flags()
+	assert.NotNil(t, flags.Lookup("category"))
+	assert.Equal(t, "5s", flags.Lookup("timeout").DefValue)
+}
+
+func TestSelectProbes(t *testing.T) {
+	probes := security.Probes()
+
+	assert.Len(t, selectProbes(probes, nil, ""), len(probes))
+
+	for _, p := range selectProbes(probes, []string{security.CategorySmuggling}, "") {
+		assert.Equal(t, security.CategorySmuggling, p.Category)
+	}
+
+	selected := selectProbes(probes, nil, "crlf-*")
+	require.NotEmpty(t, selected)
+	for _, p := range selected {
+		assert.Contains(t, p.Name, "crlf-")
+	}
+
+	assert.Empty(t, selectProbes(probes, []string{security.CategoryPath}, "crlf-*"))
+}
+
+func secResults() []*security.Result {
+	return []*security.Result{
+		{Probe: &security.Probe{Name: "safe", Category: "injection"}, Duration: time.Millisecond},
+		{Probe: &security.Probe{Name: "unsafe", Category: "smuggling"}, Findings: []string{"request_headers: sets transfer-encoding"}},
+	}
+}
+
+func TestPrintSecReport(t *testing.T) {
+	var buf bytes.Buffer
+	printSecReport(&buf, secResults(), false)
+
+	out := buf.String()
+	assert.Contains(t, out, "PASS  injection  safe\n")
+	assert.Contains(t, out, "FAIL  smuggling  unsafe\n        request_headers: sets transfer-encoding\n")
+	assert.Contains(t, out, "2 probe(s): 1 passed, 1 failed")
+}
+
+func TestPrintSecJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, printSecJSON(&buf, secResults()))
+
+	var out []secResultJSON
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	require.Len(t, out, 2)
+	assert.True(t, out[0].Passed)
+	assert.InDelta(t, 1.0, out[0].DurationMs, 0.001)
+	assert.False(t, out[1].Passed)
+	assert.Equal(t, []string{"request_headers: sets transfer-encoding"}, out[1].Findings)
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"zntr.io/extproctor/internal/runner"
+)
+
+// defaultTarget is the ExtProc service address when no --target is given.
+const defaultTarget = "localhost:50051"
+
+// targetList is the value of the repeatable --target flag. The default
+// address is replaced by the first given target.
+type targetList struct {
+	values []string
+	set    bool
+}
+
+// String implements pflag.Value.
+func (l *targetList) String() string {
+	return strings.Join(l.values, ",")
+}
+
+// Set implements pflag.Value.
+func (l *targetList) Set(value string) error {
+	if _, _, err := parseTarget(value); err != nil {
+		return err
+	}
+	if !l.set {
+		l.values = nil
+		l.set = true
+	}
+	l.values = append(l.values, value)
+	return nil
+}
+
+// Type implements pflag.Value.
+func (l *targetList) Type() string {
+	return "target"
+}
+
+// parseTarget splits a --target value, "address" or "name=address", in the
+// name of the target in reports and golden paths (the address when not
+// named) and its address. IPv6 addresses must be bracketed to be told apart
+// from the port, e.g. [::1]:50051.
+func parseTarget(value string) (name, address string, err error) {
+	name, address, named := strings.Cut(value, "=")
+	if !named {
+		name = value
+		address = value
+	}
+
+	switch {
+	case name == "" || address == "":
+		return "", "", fmt.Errorf("invalid target %q: expected address or name=address", value)
+	case !strings.Contains(address, "://") && !strings.HasPrefix(address, "[") && strings.Count(address, ":") > 1:
+		return "", "", fmt.Errorf("invalid target %q: IPv6 addresses must be bracketed, e.g. [::1]:50051", value)
+	}
+
+	return name, address, nil
+}
+
+// newFanOutTargets creates a client for each --target, to run the suite
+// against all of them.
+func newFanOutTargets() ([]runner.Target, error) {
+	var out []runner.Target
+	seen := map[string]bool{}
+	for _, value := range targets.values {
+		name, address, err := parseTarget(value)
+		if err == nil && seen[name] {
+			err = fmt.Errorf("duplicate target %q", name)
+		}
+		if err != nil {
+			closeTargets(out)
+			return nil, err
+		}
+		seen[name] = true
+
+		c, err := newTargetClient(address)
+		if err != nil {
+			closeTargets(out)
+			return nil, fmt.Errorf("target %s: %w", name, err)
+		}
+		out = append(out, runner.Target{Name: name, Client: c})
+	}
+	return out, nil
+}
+
+// closeTargets closes the clients of the fan-out targets.
+func closeTargets(targets []runner.Target) {
+	for _, t := range targets {
+		_ = t.Client.Close()
+	}
+}
@@ -0,0 +1,294 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+	"zntr.io/extproctor/internal/lastfailed"
+	"zntr.io/extproctor/internal/manifest"
+	"zntr.io/extproctor/internal/reporter"
+	"zntr.io/extproctor/internal/runner"
+)
+
+var triageCmd = &cobra.Command{
+	Use:   "triage [paths...]",
+	Short: "Interactively triage the tests that failed during the previous run",
+	Long: `Triage runs the tests recorded as failed by the previous run one at a time,
+shows their differences and offers an action for each of them:
+
+  [a]ccept  update the golden file with the actual responses
+  [s]kip    mark the test as an expected failure in its manifest
+  [r]etry   run the test again
+  [e]dit    open the manifest in $VISUAL or $EDITOR, then run the test again
+  [n]ext    keep the test failed and move to the next one
+  [q]uit    stop triaging
+
+Tests that are accepted, skipped or passing again are removed from the
+recorded failures.
+
+Examples:
+  # Triage the failures of the previous run
+  extproctor run ./tests/ --target localhost:50051
+  extproctor triage ./tests/ --target localhost:50051`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE:         triageTests,
+}
+
+func init() {
+	rootCmd.AddCommand(triageCmd)
+}
+
+// triageAction is an action chosen for a failed test.
+type triageAction int
+
+const (
+	actionUnknown triageAction = iota
+	actionAccept
+	actionSkip
+	actionRetry
+	actionEdit
+	actionNext
+	actionQuit
+)
+
+// parseTriageAction parses an action typed at the prompt.
+func parseTriageAction(input string) triageAction {
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "a", "accept":
+		return actionAccept
+	case "s", "skip":
+		return actionSkip
+	case "r", "retry":
+		return actionRetry
+	case "e", "edit":
+		return actionEdit
+	case "n", "next", "":
+		return actionNext
+	case "q", "quit":
+		return actionQuit
+	default:
+		return actionUnknown
+	}
+}
+
+// triage walks through failed tests interactively.
+type triage struct {
+	paths  []string
+	client *client.Client
+	in     *bufio.Reader
+	out    io.Writer
+	edit   func(path string) error
+}
+
+func triageTests(cmd *cobra.Command, args []string) error {
+	// Accepting and skipping tests rewrite golden files and manifests
+	if err := forbidInCI("triage"); err != nil {
+		return err
+	}
+
+	failures, err := lastfailed.Load(lastFailedPath)
+	if err != nil {
+		return err
+	}
+	if len(failures) == 0 {
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), "No failed tests recorded")
+		return nil
+	}
+
+	extProcClient, err := newClient()
+	if err != nil {
+		return fmt.Errorf("failed to create ExtProc client: %w", err)
+	}
+	defer func() { _ = extProcClient.Close() }()
+
+	t := &triage{
+		paths:  args,
+		client: extProcClient,
+		in:     bufio.NewReader(cmd.InOrStdin()),
+		out:    cmd.OutOrStdout(),
+		edit:   editFile,
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var remaining []string
+	for i, id := range failures {
+		_, _ = fmt.Fprintf(t.out, "\n(%d/%d) %s\n", i+1, len(failures), id)
+
+		resolved, quit, err := t.triageTest(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !resolved {
+			remaining = append(remaining, id)
+		}
+		if quit {
+			remaining = append(remaining, failures[i+1:]...)
+			break
+		}
+	}
+
+	return lastfailed.Save(lastFailedPath, remaining)
+}
+
+// triageTest runs a failed test and applies the actions chosen for it until
+// it is resolved or left aside.
+func (t *triage) triageTest(ctx context.Context, id string) (resolved, quit bool, err error) {
+	for {
+		// Reload the manifests as they may have been edited
+		loader, err := newLoader()
+		if err != nil {
+			return false, false, err
+		}
+		manifests, err := loadPaths(loader, t.paths)
+		if err != nil {
+			return false, false, fmt.Errorf("failed to load manifests: %w", err)
+		}
+
+		tc, sourcePath := findTest(manifests, id)
+		if tc == nil {
+			_, _ = fmt.Fprintln(t.out, "Test no longer exists, dropped")
+			return true, false, nil
+		}
+
+		result, err := t.runTest(ctx, manifests, id, false)
+		if err != nil {
+			return false, false, err
+		}
+		if result.Passed || result.Skipped {
+			_, _ = fmt.Fprintln(t.out, "Test no longer fails")
+			return true, false, nil
+		}
+
+	prompt:
+		for {
+			_, _ = fmt.Fprint(t.out, "Action? [a]ccept, [s]kip, [r]etry, [e]dit, [n]ext, [q]uit: ")
+			line, err := t.in.ReadString('\n')
+			if err != nil && !errors.Is(err, io.EOF) {
+				return false, false, fmt.Errorf("failed to read action: %w", err)
+			}
+			if errors.Is(err, io.EOF) && line == "" {
+				_, _ = fmt.Fprintln(t.out)
+				return false, true, nil
+			}
+
+			switch parseTriageAction(line) {
+			case actionAccept:
+				if tc.GoldenFile == "" {
+					_, _ = fmt.Fprintln(t.out, "Only golden files can be accepted, edit the inline expectations instead")
+					continue
+				}
+				updated, err := t.runTest(ctx, manifests, id, true)
+				if err != nil {
+					return false, false, err
+				}
+				if updated.Error != nil {
+					_, _ = fmt.Fprintf(t.out, "Failed to update the golden file: %v\n", updated.Error)
+					continue
+				}
+				_, _ = fmt.Fprintln(t.out, "Golden file updated")
+				return true, false, nil
+			case actionSkip:
+				if err := manifest.SetExpectedFailure(sourcePath, tc.Name); err != nil {
+					return false, false, fmt.Errorf("failed to mark %s as an expected failure: %w", id, err)
+				}
+				_, _ = fmt.Fprintln(t.out, "Marked as an expected failure")
+				return true, false, nil
+			case actionRetry:
+				break prompt
+			case actionEdit:
+				if err := t.edit(sourcePath); err != nil {
+					_, _ = fmt.Fprintf(t.out, "Failed to open the editor: %v\n", err)
+					continue
+				}
+				break prompt
+			case actionNext:
+				return false, false, nil
+			case actionQuit:
+				return false, true, nil
+			default:
+				_, _ = fmt.Fprintf(t.out, "Unknown action %q\n", strings.TrimSpace(line))
+			}
+		}
+	}
+}
+
+// findTest returns the test case with the given ID and the path of its
+// manifest, or nil when it does not exist anymore.
+func findTest(manifests []*manifest.LoadedManifest, id string) (*extproctorv1.TestCase, string) {
+	for _, m := range manifests {
+		for _, tc := range m.TestCases {
+			if runner.TestID(m.SourcePath, tc.Name) == id {
+				return tc, m.SourcePath
+			}
+		}
+	}
+
+	return nil, ""
+}
+
+// runTest runs a single test, reporting it with its differences.
+func (t *triage) runTest(ctx context.Context, manifests []*manifest.LoadedManifest, id string, update bool) (*runner.TestResult, error) {
+	testRunner := runner.New(t.client,
+		runner.WithTestIDs([]string{id}),
+		runner.WithReporter(testReporter{reporter.NewHumanReporter(t.out, true, humanOptions()...)}),
+		runner.WithUpdateGolden(update),
+		runner.WithTargetName(goldenTargetName()),
+		runner.WithMaxDiffBytes(maxDiffBytes),
+	)
+
+	results, err := testRunner.Run(ctx, manifests)
+	if err != nil {
+		return nil, fmt.Errorf("test execution failed: %w", err)
+	}
+	if len(results.Tests) == 0 {
+		return nil, fmt.Errorf("test %s not found", id)
+	}
+
+	return results.Tests[0], nil
+}
+
+// testReporter reports individual tests without the suite header and summary.
+type testReporter struct {
+	reporter.Reporter
+}
+
+func (testReporter) StartSuite(int)                 {}
+func (testReporter) EndSuite(reporter.SuiteSummary) {}
+
+// editFile opens a file in the editor of the user.
+func editFile(path string) error {
+	args := editorCommand(os.Getenv("VISUAL"), os.Getenv("EDITOR"))
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// editorCommand returns the editor command line, preferring $VISUAL over
+// $EDITOR and falling back to vi.
+func editorCommand(visual, editor string) []string {
+	for _, candidate := range []string{visual, editor} {
+		if args := strings.Fields(candidate); len(args) > 0 {
+			return args
+		}
+	}
+	return []string{"vi"}
+}
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"zntr.io/extproctor/internal/lastfailed"
+	"zntr.io/extproctor/internal/runner"
+)
+
+func TestParseTriageAction(t *testing.T) {
+	assert.Equal(t, actionAccept, parseTriageAction("a\n"))
+	assert.Equal(t, actionSkip, parseTriageAction("Skip"))
+	assert.Equal(t, actionRetry, parseTriageAction(" r "))
+	assert.Equal(t, actionEdit, parseTriageAction("edit"))
+	assert.Equal(t, actionNext, parseTriageAction("\n"))
+	assert.Equal(t, actionQuit, parseTriageAction("q"))
+	assert.Equal(t, actionUnknown, parseTriageAction("x"))
+}
+
+func TestEditorCommand(t *testing.T) {
+	assert.Equal(t, []string{"code", "-w"}, editorCommand("code -w", "nano"))
+	assert.Equal(t, []string{"nano"}, editorCommand("", "nano"))
+	assert.Equal(t, []string{"vi"}, editorCommand("", " "))
+}
+
+func TestTriageTests_NoFailures(t *testing.T) {
+	require.NoError(t, lastfailed.Save(lastFailedPath, nil))
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	require.NoError(t, triageTests(cmd, []string{t.TempDir()}))
+	assert.Equal(t, "No failed tests recorded\n", out.String())
+}
+
+func TestTriageTests_SkipAndDrop(t *testing.T) {
+	tmpDir := t.TempDir()
+	manifestPath := filepath.Join(tmpDir, "test.textproto")
+	content := `name: "test-manifest"
+test_cases {
+  name: "test-1"
+  request { method: "GET" path: "/" }
+  expectations { phase: REQUEST_HEADERS headers_response {} }
+}
+`
+	require.NoError(t, os.WriteFile(manifestPath, []byte(content), 0o644))
+
+	failing := runner.TestID(manifestPath, "test-1")
+	removed := runner.TestID(manifestPath, "removed")
+	require.NoError(t, lastfailed.Save(lastFailedPath, []string{failing, removed}))
+
+	oldTargets := targets
+	targets = targetList{values: []string{"localhost:59999"}}
+	defer func() { targets = oldTargets }()
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	cmd.SetIn(strings.NewReader("x\naccept\nskip\n"))
+
+	// The test fails as no server is running
+	require.NoError(t, triageTests(cmd, []string{tmpDir}))
+	assert.Contains(t, out.String(), `Unknown action "x"`)
+	assert.Contains(t, out.String(), "Only golden files can be accepted")
+	assert.Contains(t, out.String(), "Marked as an expected failure")
+	assert.Contains(t, out.String(), "Test no longer exists, dropped")
+
+	data, err := os.ReadFile(manifestPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "  name: \"test-1\"\n  expected_failure: true\n")
+
+	failures, err := lastfailed.Load(lastFailedPath)
+	require.NoError(t, err)
+	assert.Empty(t, failures)
+}
+
+func TestTriageTests_Quit(t *testing.T) {
+	tmpDir := t.TempDir()
+	manifestPath := filepath.Join(tmpDir, "test.textproto")
+	require.NoError(t, os.WriteFile(manifestPath, []byte(`test_cases { name: "a" request { method: "GET" path: "/" } expectations { phase: REQUEST_HEADERS headers_response {} } }
+test_cases { name: "b" request { method: "GET" path: "/" } expectations { phase: REQUEST_HEADERS headers_response {} } }`), 0o644))
+
+	ids := []string{runner.TestID(manifestPath, "a"), runner.TestID(manifestPath, "b")}
+	require.NoError(t, lastfailed.Save(lastFailedPath, ids))
+
+	oldTargets := targets
+	targets = targetList{values: []string{"localhost:59999"}}
+	defer func() { targets = oldTargets }()
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	cmd.SetIn(strings.NewReader("q\n"))
+
+	require.NoError(t, triageTests(cmd, []string{tmpDir}))
+
+	failures, err := lastfailed.Load(lastFailedPath)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, ids, failures)
+}
@@ -8,7 +8,6 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
-	"zntr.io/extproctor/internal/manifest"
 )
 
 var validateCmd = &cobra.Command{
@@ -32,12 +31,20 @@ func init() {
 }
 
 func validateManifests(cmd *cobra.Command, args []string) error {
-	loader := manifest.NewLoader()
+	loader, err := newLoader()
+	if err != nil {
+		return err
+	}
 
 	var hasErrors bool
 	var totalManifests, totalTestCases int
 
-	for _, path := range args {
+	expanded, err := loader.ExpandPaths(args)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range expanded {
 		manifests, err := loader.LoadPath(path)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "ERROR: %s: %v\n", path, err)
@@ -46,12 +53,18 @@ func validateManifests(cmd *cobra.Command, args []string) error {
 		}
 
 		for _, m := range manifests {
+			printDiscarded(m)
+			if m.SkipReason != "" {
+				fmt.Fprintf(os.Stderr, "WARNING: %s: skipped: %s\n", m.SourcePath, m.SkipReason)
+				continue
+			}
+
 			totalManifests++
 			totalTestCases += len(m.TestCases)
 
 			// Validate each test case
 			for _, tc := range m.TestCases {
-				if err := manifest.ValidateTestCase(tc); err != nil {
+				if err := m.ValidateTestCase(tc); err != nil {
 					fmt.Fprintf(os.Stderr, "ERROR: %s: test case %q: %v\n", m.SourcePath, tc.Name, err)
 					hasErrors = true
 				}
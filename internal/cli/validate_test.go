@@ -82,6 +82,38 @@ func TestValidateManifests_InvalidManifest(t *testing.T) {
 	assert.Contains(t, buf.String(), "ERROR")
 }
 
+func TestValidateManifests_Lenient(t *testing.T) {
+	tmpDir := t.TempDir()
+	manifestPath := filepath.Join(tmpDir, "newer.textproto")
+
+	content := `
+name: "newer"
+test_cases: {
+  name: "test-1"
+  request: { method: "GET", path: "/" }
+  expectations: { phase: REQUEST_HEADERS, headers_response: {} future_assertion: {} }
+}
+`
+	require.NoError(t, os.WriteFile(manifestPath, []byte(content), 0o644))
+
+	lenient = true
+	defer func() { lenient = false }()
+
+	oldStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+
+	err := validateManifests(&cobra.Command{}, []string{tmpDir})
+
+	_ = w.Close()
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(r)
+	os.Stderr = oldStderr
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "WARNING: "+manifestPath+": line 6:64: unknown field future_assertion discarded")
+}
+
 func TestValidateManifests_InvalidTestCase(t *testing.T) {
 	tmpDir := t.TempDir()
 	manifestPath := filepath.Join(tmpDir, "test.textproto")
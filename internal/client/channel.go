@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"google.golang.org/grpc"
+)
+
+// Channel overrides the gRPC channel of the processing calls, e.g. to
+// exercise each tenant of a multi-tenant ExtProc gateway routing by
+// authority.
+type Channel struct {
+	// Authority is the :authority of the calls. With TLS, the certificate of
+	// the target must be valid for it.
+	Authority string
+
+	// ServerName is the TLS server name (SNI) presented to the target. The
+	// calls use a dedicated connection, shared by the overrides presenting
+	// the same name.
+	ServerName string
+
+	// Metadata is the gRPC metadata of the calls, overriding the keys of the
+	// client metadata.
+	Metadata map[string]string
+}
+
+// WithChannel returns a client sending its processing calls on the given
+// channel. The returned client shares the connections of c, closed with it.
+func (c *Client) WithChannel(ch Channel) (*Client, error) {
+	derived := *c
+	derived.derived = true
+	derived.callOpts = slices.Clone(c.callOpts)
+
+	if ch.ServerName != "" && (c.cfg == nil || ch.ServerName != c.cfg.serverName) {
+		if c.cfg == nil || !c.cfg.tls || c.cfg.unixSocket != "" {
+			return nil, errors.New("server name override requires TLS")
+		}
+		conn, err := c.channels.get(ch.ServerName, func() (*grpc.ClientConn, error) {
+			cfg := *c.cfg
+			cfg.serverName = ch.ServerName
+			conn, _, err := dial(&cfg)
+			return conn, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		derived.conn = conn
+		derived.client = extprocv3.NewExternalProcessorClient(conn)
+	}
+	if ch.Authority != "" {
+		derived.callOpts = append(derived.callOpts, grpc.CallAuthority(ch.Authority))
+	}
+	if len(ch.Metadata) > 0 {
+		derived.metadata = maps.Clone(c.metadata)
+		if derived.metadata == nil {
+			derived.metadata = make(map[string]string, len(ch.Metadata))
+		}
+		for k, v := range ch.Metadata {
+			derived.metadata[strings.ToLower(k)] = v
+		}
+	}
+
+	return &derived, nil
+}
+
+// channelCache holds the connections presenting another TLS server name than
+// the one of the client, by server name.
+type channelCache struct {
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+// get returns the connection presenting the server name, opened with dial
+// the first time.
+func (cc *channelCache) get(serverName string, dial func() (*grpc.ClientConn, error)) (*grpc.ClientConn, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if conn, ok := cc.conns[serverName]; ok {
+		return conn, nil
+	}
+	conn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	if cc.conns == nil {
+		cc.conns = map[string]*grpc.ClientConn{}
+	}
+	cc.conns[serverName] = conn
+	return conn, nil
+}
+
+// close closes the cached connections.
+func (cc *channelCache) close() error {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	var errs []error
+	for name, conn := range cc.conns {
+		errs = append(errs, conn.Close())
+		delete(cc.conns, name)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// tenantGateway starts a TLS ExtProc service whose certificate is valid for
+// the given names. It returns its address, the path of its certificate, and
+// the server name and authority of each call.
+func tenantGateway(t *testing.T, names ...string) (string, string, chan [2]string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: names[0]},
+		DNSNames:              names,
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644))
+
+	calls := make(chan [2]string, 10)
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)))
+	extprocv3.RegisterExternalProcessorServer(grpcServer, &recordingProcessor{calls: calls})
+	go func() { _ = grpcServer.Serve(lis) }()
+	t.Cleanup(grpcServer.Stop)
+
+	return lis.Addr().String(), caPath, calls
+}
+
+// recordingProcessor answers the request headers, recording the server name
+// of the connection and the authority of each call.
+type recordingProcessor struct {
+	extprocv3.UnimplementedExternalProcessorServer
+	calls chan [2]string
+}
+
+func (p *recordingProcessor) Process(stream extprocv3.ExternalProcessor_ProcessServer) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	var serverName string
+	if pr, ok := peer.FromContext(stream.Context()); ok {
+		serverName = pr.AuthInfo.(credentials.TLSInfo).State.ServerName
+	}
+	p.calls <- [2]string{serverName, md.Get(":authority")[0]}
+	if _, err := stream.Recv(); err != nil {
+		return err
+	}
+	return stream.Send(&extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestHeaders{
+		RequestHeaders: &extprocv3.HeadersResponse{},
+	}})
+}
+
+func TestWithChannel(t *testing.T) {
+	addr, caPath, calls := tenantGateway(t, "gateway.example", "tenant-a.example", "tenant-b.example")
+
+	c, err := New(WithTarget(addr), WithTLS("", "", caPath), WithServerName("gateway.example"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	req := &extproctorv1.HttpRequest{Method: "GET", Path: "/"}
+	_, err = c.Process(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, [2]string{"gateway.example", "gateway.example"}, <-calls)
+
+	// Authority override on the shared connection
+	tenantA, err := c.WithChannel(Channel{Authority: "tenant-a.example"})
+	require.NoError(t, err)
+	_, err = tenantA.Process(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, [2]string{"gateway.example", "tenant-a.example"}, <-calls)
+
+	// Server name override on a dedicated connection
+	tenantB, err := c.WithChannel(Channel{Authority: "tenant-b.example", ServerName: "tenant-b.example"})
+	require.NoError(t, err)
+	_, err = tenantB.Process(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, [2]string{"tenant-b.example", "tenant-b.example"}, <-calls)
+	assert.NotSame(t, c.conn, tenantB.conn)
+
+	again, err := c.WithChannel(Channel{ServerName: "tenant-b.example"})
+	require.NoError(t, err)
+	assert.Same(t, tenantB.conn, again.conn)
+
+	// Derived clients do not own their connection
+	require.NoError(t, tenantB.Close())
+	_, err = again.Process(context.Background(), req)
+	require.NoError(t, err)
+	<-calls
+
+	// The certificate must be valid for the authority
+	other, err := c.WithChannel(Channel{Authority: "other.example"})
+	require.NoError(t, err)
+	_, err = other.Process(context.Background(), req)
+	assert.ErrorContains(t, err, `failed to validate authority "other.example"`)
+}
+
+func TestWithChannel_ServerNameRequiresTLS(t *testing.T) {
+	c, err := New(WithTarget("localhost:50051"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	_, err = c.WithChannel(Channel{ServerName: "tenant.example"})
+	assert.EqualError(t, err, "server name override requires TLS")
+
+	derived, err := c.WithChannel(Channel{Authority: "tenant.example"})
+	require.NoError(t, err)
+	assert.Len(t, derived.callOpts, 1)
+	assert.Empty(t, c.callOpts)
+}
@@ -7,15 +7,27 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocfilterv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
 	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/compression"
+	"zntr.io/extproctor/internal/units"
 )
 
 // Client wraps the ExtProc gRPC client.
@@ -23,6 +35,28 @@ type Client struct {
 	conn   *grpc.ClientConn
 	client extprocv3.ExternalProcessorClient
 	target string
+
+	// cfg is the configuration the client was created with, to open the
+	// connections of channel overrides.
+	cfg *clientConfig
+
+	// callOpts are added to the processing calls, e.g. an :authority
+	// override.
+	callOpts []grpc.CallOption
+
+	// metadata is the gRPC metadata sent on the processing streams.
+	metadata map[string]string
+
+	// rawHeaderValues mirrors the header values into their raw_value.
+	rawHeaderValues bool
+
+	// channels caches the connections presenting another TLS server name,
+	// shared with the clients derived by WithChannel.
+	channels *channelCache
+
+	// derived is set on the clients returned by WithChannel, which do not
+	// own their connection.
+	derived bool
 }
 
 // Option configures the client.
@@ -35,6 +69,23 @@ type clientConfig struct {
 	tlsCert    string
 	tlsKey     string
 	tlsCA      string
+	serverName string
+	proxy      string
+
+	perRPCCredentials credentials.PerRPCCredentials
+
+	// metadata is the gRPC metadata sent on the processing streams.
+	metadata map[string]string
+
+	// rawHeaderValues mirrors the header values into their raw_value.
+	rawHeaderValues bool
+
+	// reconnectBackoff bounds the delay between two reconnection attempts,
+	// zero for the default of gRPC.
+	reconnectBackoff time.Duration
+
+	unaryInterceptors  []grpc.UnaryClientInterceptor
+	streamInterceptors []grpc.StreamClientInterceptor
 }
 
 // WithTarget sets the target address.
@@ -44,8 +95,10 @@ func WithTarget(target string) Option {
 	}
 }
 
-// WithUnixSocket sets the Unix domain socket path for the connection.
-// When set, this takes precedence over the TCP target address.
+// WithUnixSocket sets the Unix domain socket path for the connection: a
+// file path, "@name" for an abstract socket (Linux), or "\\.\pipe\name" for
+// a named pipe (Windows). When set, this takes precedence over the TCP target
+// address.
 func WithUnixSocket(path string) Option {
 	return func(c *clientConfig) {
 		c.unixSocket = path
@@ -62,6 +115,31 @@ func WithTLS(cert, key, ca string) Option {
 	}
 }
 
+// WithServerName sets the TLS server name (SNI) presented to the target and
+// expected in its certificate, instead of the host of the target address.
+func WithServerName(name string) Option {
+	return func(c *clientConfig) {
+		c.serverName = name
+	}
+}
+
+// WithPerRPCCredentials authenticates every call with the given credentials,
+// e.g. the ones of an auth provider.
+func WithPerRPCCredentials(creds credentials.PerRPCCredentials) Option {
+	return func(c *clientConfig) {
+		c.perRPCCredentials = creds
+	}
+}
+
+// WithRawHeaderValues mirrors the value of every header and trailer sent into
+// its raw_value, like recent Envoy versions, for ExtProc services only
+// reading raw_value.
+func WithRawHeaderValues(enabled bool) Option {
+	return func(c *clientConfig) {
+		c.rawHeaderValues = enabled
+	}
+}
+
 // New creates a new ExtProc client.
 func New(opts ...Option) (*Client, error) {
 	cfg := &clientConfig{
@@ -72,41 +150,81 @@ func New(opts ...Option) (*Client, error) {
 		opt(cfg)
 	}
 
+	conn, target, err := dial(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		conn:     conn,
+		client:   extprocv3.NewExternalProcessorClient(conn),
+		target:   target,
+		cfg:      cfg,
+		metadata: cfg.metadata,
+		channels: &channelCache{},
+
+		rawHeaderValues: cfg.rawHeaderValues,
+	}, nil
+}
+
+// dial opens the gRPC connection described by a configuration, and returns
+// it with its target.
+func dial(cfg *clientConfig) (*grpc.ClientConn, string, error) {
 	var dialOpts []grpc.DialOption
 
 	// Determine the connection target
 	target := cfg.target
 	if cfg.unixSocket != "" {
-		// Use Unix domain socket - format: unix:///path/to/socket
-		target = "unix://" + cfg.unixSocket
-		// TLS is typically not used with Unix sockets
+		// Use a local socket: Unix domain socket, abstract socket or named
+		// pipe
+		var dialer func(context.Context, string) (net.Conn, error)
+		target, dialer = socketTarget(cfg.unixSocket)
+		if dialer != nil {
+			dialOpts = append(dialOpts, grpc.WithContextDialer(dialer))
+		}
+		// TLS is typically not used with local sockets
 		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	} else if cfg.tls {
 		tlsConfig, err := buildTLSConfig(cfg)
 		if err != nil {
-			return nil, fmt.Errorf("failed to build TLS config: %w", err)
+			return nil, "", fmt.Errorf("failed to build TLS config: %w", err)
 		}
 		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
 	} else {
 		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	}
 
+	if cfg.proxy != "" && cfg.unixSocket == "" {
+		dialer, err := proxyDialer(cfg.proxy)
+		if err != nil {
+			return nil, "", err
+		}
+		dialOpts = append(dialOpts, grpc.WithContextDialer(dialer), grpc.WithNoProxy())
+	}
+	if cfg.perRPCCredentials != nil {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(cfg.perRPCCredentials))
+	}
+	if cfg.reconnectBackoff > 0 {
+		dialOpts = append(dialOpts, connectParams(cfg.reconnectBackoff))
+	}
+	dialOpts = append(dialOpts,
+		grpc.WithChainUnaryInterceptor(cfg.unaryInterceptors...),
+		grpc.WithChainStreamInterceptor(cfg.streamInterceptors...),
+	)
+
 	conn, err := grpc.NewClient(target, dialOpts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect: %w", err)
+		return nil, "", fmt.Errorf("failed to connect: %w", err)
 	}
 
-	return &Client{
-		conn:   conn,
-		client: extprocv3.NewExternalProcessorClient(conn),
-		target: target,
-	}, nil
+	return conn, target, nil
 }
 
 // buildTLSConfig creates a TLS configuration from the provided files.
 func buildTLSConfig(cfg *clientConfig) (*tls.Config, error) {
 	tlsConfig := &tls.Config{
 		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.serverName,
 	}
 
 	if cfg.tlsCert != "" && cfg.tlsKey != "" {
@@ -132,155 +250,517 @@ func buildTLSConfig(cfg *clientConfig) (*tls.Config, error) {
 	return tlsConfig, nil
 }
 
-// Close closes the client connection.
+// Close closes the client connection, and the ones of its channel
+// overrides. Closing a client derived by WithChannel does nothing.
 func (c *Client) Close() error {
+	if c.derived {
+		return nil
+	}
+	var errs []error
 	if c.conn != nil {
-		return c.conn.Close()
+		errs = append(errs, c.conn.Close())
+	}
+	if c.channels != nil {
+		errs = append(errs, c.channels.close())
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 // ProcessingResult contains the responses from an ExtProc processing session.
 type ProcessingResult struct {
 	Responses []*PhaseResponse
+
+	// SkippedPhases lists the configured phases that were never sent because
+	// the ExtProc service short-circuited the stream with an immediate response.
+	SkippedPhases []extproctorv1.ProcessingPhase
+
+	// SuppressedPhases lists the configured phases that were never sent
+	// because the ExtProc service disabled them with a mode_override, like
+	// Envoy does.
+	SuppressedPhases []extproctorv1.ProcessingPhase
 }
 
 // PhaseResponse represents a response for a specific processing phase.
 type PhaseResponse struct {
 	Phase    extproctorv1.ProcessingPhase
 	Response *extprocv3.ProcessingResponse
+
+	// Latency is the time between sending the phase request and receiving
+	// its response.
+	Latency time.Duration
+
+	// Chunk is the index of the body chunk sent, and LastChunk whether it was
+	// the final one, for body phases.
+	Chunk     int
+	LastChunk bool
 }
 
-// Process executes an ExtProc session with the given HTTP request definition.
-func (c *Client) Process(ctx context.Context, req *extproctorv1.HttpRequest) (*ProcessingResult, error) {
-	stream, err := c.client.Process(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to start processing stream: %w", err)
-	}
+// PhaseError reports a processing phase that could not be exchanged with
+// the ExtProc service.
+type PhaseError struct {
+	Phase extproctorv1.ProcessingPhase
 
-	result := &ProcessingResult{}
+	// Op describes the failed operation, e.g. "receive response for request
+	// headers".
+	Op  string
+	Err error
+}
+
+func (e *PhaseError) Error() string {
+	return fmt.Sprintf("failed to %s: %v", e.Op, e.Err)
+}
+
+func (e *PhaseError) Unwrap() error {
+	return e.Err
+}
+
+// phaseStep describes a processing phase sent to the ExtProc service.
+type phaseStep struct {
+	phase extproctorv1.ProcessingPhase
+	name  string
+	build func(*extproctorv1.HttpRequest) *extprocv3.ProcessingRequest
 
-	// Send request headers
-	headersReq := buildRequestHeaders(req)
-	if err := stream.Send(headersReq); err != nil {
-		return nil, fmt.Errorf("failed to send request headers: %w", err)
+	// chunk is the index of the body chunk sent, and lastChunk whether it is
+	// the final one, for body phases
+	chunk     int
+	lastChunk bool
+}
+
+// plannedPhases returns the phases to send for the given HTTP request and
+// simulated upstream response, in the order Envoy would send them.
+func plannedPhases(req *extproctorv1.HttpRequest, resp *extproctorv1.HttpResponse) []phaseStep {
+	steps := []phaseStep{
+		{phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS, name: "request headers", build: buildRequestHeaders},
 	}
 
-	// Receive response for request headers
-	resp, err := stream.Recv()
-	if err != nil {
-		return nil, fmt.Errorf("failed to receive response for request headers: %w", err)
+	if req.ProcessRequestBody && len(req.Body) > 0 {
+		chunks := requestBodyChunks(req)
+		for i := range chunks {
+			steps = append(steps, phaseStep{
+				phase: extproctorv1.ProcessingPhase_REQUEST_BODY,
+				name:  "request body",
+				build: func(req *extproctorv1.HttpRequest) *extprocv3.ProcessingRequest {
+					return buildRequestBodyChunk(req, chunks[i], i == len(chunks)-1)
+				},
+				chunk:     i,
+				lastChunk: i == len(chunks)-1,
+			})
+		}
+	}
+	if req.ProcessRequestTrailers && (len(req.Trailers) > 0 || len(req.TrailerEntries) > 0) {
+		steps = append(steps, phaseStep{phase: extproctorv1.ProcessingPhase_REQUEST_TRAILERS, name: "request trailers", build: buildRequestTrailers})
+	}
+	if req.ProcessResponseHeaders || resp != nil {
+		steps = append(steps, responseStep(extproctorv1.ProcessingPhase_RESPONSE_HEADERS, resp))
+	}
+	if processResponseBody(req, resp) {
+		steps = append(steps, responseStep(extproctorv1.ProcessingPhase_RESPONSE_BODY, resp))
+	}
+	if req.ProcessResponseTrailers || resp.GetProcessResponseTrailers() {
+		steps = append(steps, responseStep(extproctorv1.ProcessingPhase_RESPONSE_TRAILERS, resp))
 	}
-	result.Responses = append(result.Responses, &PhaseResponse{
-		Phase:    extproctorv1.ProcessingPhase_REQUEST_HEADERS,
-		Response: resp,
-	})
 
-	// Check if we should continue processing
-	if isImmediateResponse(resp) {
-		return result, stream.CloseSend()
+	return steps
+}
+
+// responseStep returns the step sending a response phase of the simulated
+// upstream response.
+func responseStep(phase extproctorv1.ProcessingPhase, resp *extproctorv1.HttpResponse) phaseStep {
+	switch phase {
+	case extproctorv1.ProcessingPhase_RESPONSE_HEADERS:
+		return phaseStep{phase: phase, name: "response headers", build: func(req *extproctorv1.HttpRequest) *extprocv3.ProcessingRequest {
+			return buildResponseHeaders(req, resp)
+		}}
+	case extproctorv1.ProcessingPhase_RESPONSE_BODY:
+		return phaseStep{phase: phase, name: "response body", build: func(req *extproctorv1.HttpRequest) *extprocv3.ProcessingRequest {
+			return buildResponseBody(req, resp)
+		}, lastChunk: true}
+	default:
+		return phaseStep{phase: phase, name: "response trailers", build: func(req *extproctorv1.HttpRequest) *extprocv3.ProcessingRequest {
+			return buildResponseTrailers(req, resp)
+		}}
 	}
+}
 
-	// Send request body if configured
-	if req.ProcessRequestBody && len(req.Body) > 0 {
-		bodyReq := buildRequestBody(req)
-		if err := stream.Send(bodyReq); err != nil {
-			return nil, fmt.Errorf("failed to send request body: %w", err)
+// scriptedPhases returns the phases of a phase sequence, in its order. The
+// n-th REQUEST_BODY phase sends the n-th body chunk, and the last chunk again
+// once all were sent.
+func scriptedPhases(req *extproctorv1.HttpRequest, resp *extproctorv1.HttpResponse, sequence []extproctorv1.ProcessingPhase) []phaseStep {
+	chunks := requestBodyChunks(req)
+	sentChunks := 0
+
+	steps := make([]phaseStep, 0, len(sequence))
+	for _, phase := range sequence {
+		switch phase {
+		case extproctorv1.ProcessingPhase_REQUEST_HEADERS:
+			steps = append(steps, phaseStep{phase: phase, name: "request headers", build: buildRequestHeaders})
+		case extproctorv1.ProcessingPhase_REQUEST_BODY:
+			i := min(sentChunks, len(chunks)-1)
+			sentChunks++
+			steps = append(steps, phaseStep{
+				phase: phase,
+				name:  "request body",
+				build: func(req *extproctorv1.HttpRequest) *extprocv3.ProcessingRequest {
+					return buildRequestBodyChunk(req, chunks[i], i == len(chunks)-1)
+				},
+				chunk:     i,
+				lastChunk: i == len(chunks)-1,
+			})
+		case extproctorv1.ProcessingPhase_REQUEST_TRAILERS:
+			steps = append(steps, phaseStep{phase: phase, name: "request trailers", build: buildRequestTrailers})
+		case extproctorv1.ProcessingPhase_RESPONSE_HEADERS,
+			extproctorv1.ProcessingPhase_RESPONSE_BODY,
+			extproctorv1.ProcessingPhase_RESPONSE_TRAILERS:
+			steps = append(steps, responseStep(phase, resp))
 		}
+	}
 
-		resp, err := stream.Recv()
+	return steps
+}
+
+// encodeRequest returns a copy of the request with the multipart, GraphQL or
+// gRPC body built, then the body compressed according to body_encoding, with
+// the matching headers, and the WebSocket upgrade and x-forwarded-for headers,
+// unless the request already sets them. The request is returned as is when
+// there is nothing to encode.
+func encodeRequest(req *extproctorv1.HttpRequest) (*extproctorv1.HttpRequest, error) {
+	if req.Multipart == nil && req.Graphql == nil && req.Grpc == nil && req.Websocket == nil && req.ForwardedFor == nil && req.BodyEncoding == extproctorv1.BodyEncoding_BODY_ENCODING_UNSPECIFIED {
+		return req, nil
+	}
+
+	encoded := proto.Clone(req).(*extproctorv1.HttpRequest)
+	encoded.Method, encoded.Path = requestLine(req)
+
+	var headers []*extproctorv1.HeaderEntry
+	switch {
+	case req.Multipart != nil:
+		body, contentType, err := encodeMultipart(req.Multipart)
 		if err != nil {
-			return nil, fmt.Errorf("failed to receive response for request body: %w", err)
+			return nil, err
 		}
-		result.Responses = append(result.Responses, &PhaseResponse{
-			Phase:    extproctorv1.ProcessingPhase_REQUEST_BODY,
-			Response: resp,
-		})
-
-		if isImmediateResponse(resp) {
-			return result, stream.CloseSend()
+		encoded.Body = body
+		headers = append(headers, &extproctorv1.HeaderEntry{Key: "content-type", Value: contentType})
+	case req.Graphql != nil:
+		body, err := encodeGraphql(req.Graphql)
+		if err != nil {
+			return nil, err
+		}
+		encoded.Body = body
+		headers = append(headers, &extproctorv1.HeaderEntry{Key: "content-type", Value: "application/json"})
+	case req.Grpc != nil:
+		// gRPC compresses each message and announces it with grpc-encoding
+		body, err := encodeGrpc(req.Grpc, req.BodyEncoding)
+		if err != nil {
+			return nil, err
+		}
+		encoded.Body = body
+		headers = append(headers,
+			&extproctorv1.HeaderEntry{Key: "content-type", Value: grpcContentType},
+			&extproctorv1.HeaderEntry{Key: "te", Value: "trailers"},
+		)
+		if req.BodyEncoding != extproctorv1.BodyEncoding_BODY_ENCODING_UNSPECIFIED {
+			headers = append(headers, &extproctorv1.HeaderEntry{Key: "grpc-encoding", Value: compression.ContentEncoding(req.BodyEncoding)})
 		}
 	}
 
-	// Send request trailers if configured
-	if req.ProcessRequestTrailers && len(req.Trailers) > 0 {
-		trailersReq := buildRequestTrailers(req)
-		if err := stream.Send(trailersReq); err != nil {
-			return nil, fmt.Errorf("failed to send request trailers: %w", err)
-		}
+	if req.Websocket != nil {
+		headers = append(headers, websocketHeaders(req.Websocket)...)
+	}
+	if req.ForwardedFor != nil {
+		headers = append(headers, &extproctorv1.HeaderEntry{Key: "x-forwarded-for", Value: forwardedForHeader(req.ForwardedFor)})
+	}
 
-		resp, err := stream.Recv()
+	if req.Grpc == nil && req.BodyEncoding != extproctorv1.BodyEncoding_BODY_ENCODING_UNSPECIFIED {
+		body, err := compression.Encode(req.BodyEncoding, encoded.Body)
 		if err != nil {
-			return nil, fmt.Errorf("failed to receive response for request trailers: %w", err)
+			return nil, err
 		}
-		result.Responses = append(result.Responses, &PhaseResponse{
-			Phase:    extproctorv1.ProcessingPhase_REQUEST_TRAILERS,
-			Response: resp,
-		})
+		encoded.Body = body
+		headers = append(headers, &extproctorv1.HeaderEntry{Key: "content-encoding", Value: compression.ContentEncoding(req.BodyEncoding)})
 	}
 
-	// Send response headers if configured
-	if req.ProcessResponseHeaders {
-		respHeadersReq := buildResponseHeaders(req)
-		if err := stream.Send(respHeadersReq); err != nil {
-			return nil, fmt.Errorf("failed to send response headers: %w", err)
+	for _, h := range headers {
+		if !hasHeader(req, h.Key) {
+			encoded.HeaderEntries = append(encoded.HeaderEntries, h)
 		}
+	}
 
-		resp, err := stream.Recv()
-		if err != nil {
-			return nil, fmt.Errorf("failed to receive response for response headers: %w", err)
+	return encoded, nil
+}
+
+// hasHeader reports whether the request sets a header, case-insensitively.
+func hasHeader(req *extproctorv1.HttpRequest, name string) bool {
+	for k := range req.Headers {
+		if strings.EqualFold(k, name) {
+			return true
 		}
-		result.Responses = append(result.Responses, &PhaseResponse{
-			Phase:    extproctorv1.ProcessingPhase_RESPONSE_HEADERS,
-			Response: resp,
-		})
+	}
+	for _, e := range req.HeaderEntries {
+		if strings.EqualFold(e.Key, name) {
+			return true
+		}
+	}
+	for k := range req.RawHeaders {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+	return false
+}
 
-		if isImmediateResponse(resp) {
-			return result, stream.CloseSend()
+// Process executes an ExtProc session with the given HTTP request definition.
+//
+// An immediate response ends the session like Envoy does, and the remaining
+// phases are recorded as skipped, unless the request sets
+// continue_after_immediate. In observability mode, the phases are sent
+// without waiting for responses, and the responses received within
+// ObservabilityGrace are recorded.
+func (c *Client) Process(ctx context.Context, req *extproctorv1.HttpRequest) (*ProcessingResult, error) {
+	return c.ProcessExchange(ctx, req, nil, nil)
+}
+
+// ProcessSequence executes an ExtProc session like Process, sending the
+// processing requests in the order of the phase sequence instead of the order
+// Envoy would use.
+func (c *Client) ProcessSequence(ctx context.Context, req *extproctorv1.HttpRequest, sequence []extproctorv1.ProcessingPhase) (*ProcessingResult, error) {
+	if len(sequence) == 0 {
+		return nil, errors.New("empty phase sequence")
+	}
+	return c.ProcessExchange(ctx, req, nil, sequence)
+}
+
+// ProcessExchange executes an ExtProc session like Process, sending the
+// simulated upstream response in the response phases once the request phases
+// completed. A non-empty phase sequence is sent in its order like
+// ProcessSequence.
+func (c *Client) ProcessExchange(ctx context.Context, req *extproctorv1.HttpRequest, resp *extproctorv1.HttpResponse, sequence []extproctorv1.ProcessingPhase) (*ProcessingResult, error) {
+	for _, phase := range sequence {
+		if phase == extproctorv1.ProcessingPhase_PROCESSING_PHASE_UNSPECIFIED {
+			return nil, errors.New("phase sequence has an unspecified phase")
+		}
+	}
+	if req.BodyChunkSize != "" {
+		if _, err := units.ParseSize(req.BodyChunkSize); err != nil {
+			return nil, fmt.Errorf("invalid body_chunk_size: %w", err)
 		}
 	}
+	if err := ValidateRequest(req); err != nil {
+		return nil, err
+	}
+	if err := ValidateResponse(req, resp); err != nil {
+		return nil, err
+	}
+	return c.process(ctx, req, resp, sequence)
+}
 
-	// Send response body if configured
-	if req.ProcessResponseBody {
-		respBodyReq := buildResponseBody(req)
-		if err := stream.Send(respBodyReq); err != nil {
-			return nil, fmt.Errorf("failed to send response body: %w", err)
+// ProcessUnchecked executes an ExtProc session like Process, without checking
+// that the request is well-formed, to probe how the ExtProc service handles
+// malformed requests.
+func (c *Client) ProcessUnchecked(ctx context.Context, req *extproctorv1.HttpRequest) (*ProcessingResult, error) {
+	return c.process(ctx, req, nil, nil)
+}
+
+// process executes an ExtProc session with the given HTTP request definition
+// and simulated upstream response, sending the phases of the sequence when
+// one is given.
+func (c *Client) process(ctx context.Context, req *extproctorv1.HttpRequest, resp *extproctorv1.HttpResponse, sequence []extproctorv1.ProcessingPhase) (*ProcessingResult, error) {
+	req, err := encodeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// In observability mode, the stream is canceled once the grace period of
+	// the responses expires
+	streamCtx, cancel := ctx, context.CancelFunc(func() {})
+	if req.ObservabilityMode {
+		streamCtx, cancel = context.WithCancel(ctx)
+		defer cancel()
+	}
+
+	stream, err := c.client.Process(c.withOutgoingMetadata(streamCtx), c.callOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start processing stream: %w", err)
+	}
+
+	result := &ProcessingResult{}
+	shortCircuited := false
+
+	steps := plannedPhases(req, resp)
+	if sequence != nil {
+		steps = scriptedPhases(req, resp, sequence)
+	}
+
+	suppressed := map[extproctorv1.ProcessingPhase]bool{}
+	var observed []observedStep
+	for _, step := range steps {
+		if shortCircuited {
+			// Body chunks are recorded once per phase
+			if !slices.Contains(result.SkippedPhases, step.phase) {
+				result.SkippedPhases = append(result.SkippedPhases, step.phase)
+			}
+			continue
+		}
+		if suppressed[step.phase] {
+			if !slices.Contains(result.SuppressedPhases, step.phase) {
+				result.SuppressedPhases = append(result.SuppressedPhases, step.phase)
+			}
+			continue
+		}
+
+		pr := step.build(req)
+		pr.ObservabilityMode = req.ObservabilityMode
+		if c.rawHeaderValues {
+			mirrorRawValues(pr)
+		}
+		sent := time.Now()
+		if err := stream.Send(pr); err != nil {
+			return nil, &PhaseError{Phase: step.phase, Op: "send " + step.name, Err: err}
+		}
+		if req.ObservabilityMode {
+			// Envoy does not wait for the responses
+			observed = append(observed, observedStep{phaseStep: step, sent: sent})
+			continue
 		}
 
 		resp, err := stream.Recv()
 		if err != nil {
-			return nil, fmt.Errorf("failed to receive response for response body: %w", err)
+			return nil, &PhaseError{Phase: step.phase, Op: "receive response for " + step.name, Err: err}
 		}
 		result.Responses = append(result.Responses, &PhaseResponse{
-			Phase:    extproctorv1.ProcessingPhase_RESPONSE_BODY,
-			Response: resp,
+			Phase:     step.phase,
+			Response:  resp,
+			Latency:   time.Since(sent),
+			Chunk:     step.chunk,
+			LastChunk: step.lastChunk,
 		})
 
-		if isImmediateResponse(resp) {
-			return result, stream.CloseSend()
+		// Check if we should continue processing
+		if isImmediateResponse(resp) && !req.ContinueAfterImmediate {
+			shortCircuited = true
+		}
+		// Scripted sequences send their phases whatever the mode overrides
+		if sequence == nil {
+			suppressPhases(suppressed, step.phase, resp.GetModeOverride())
 		}
 	}
 
-	// Send response trailers if configured
-	if req.ProcessResponseTrailers {
-		respTrailersReq := buildResponseTrailers(req)
-		if err := stream.Send(respTrailersReq); err != nil {
-			return nil, fmt.Errorf("failed to send response trailers: %w", err)
+	if req.ObservabilityMode {
+		if err := stream.CloseSend(); err != nil {
+			return nil, fmt.Errorf("failed to close processing stream: %w", err)
 		}
-
-		resp, err := stream.Recv()
-		if err != nil {
-			return nil, fmt.Errorf("failed to receive response for response trailers: %w", err)
+		result.Responses = drainObserved(stream, observed, cancel)
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
-		result.Responses = append(result.Responses, &PhaseResponse{
-			Phase:    extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
-			Response: resp,
-		})
+		return result, nil
 	}
 
 	return result, stream.CloseSend()
 }
 
+// ObservabilityGrace is how long the responses of the ExtProc service are
+// collected after the phases were sent in observability mode.
+var ObservabilityGrace = 100 * time.Millisecond
+
+// observedStep is a phase sent in observability mode.
+type observedStep struct {
+	phaseStep
+	sent time.Time
+}
+
+// drainObserved collects the responses the ExtProc service sent in
+// observability mode, which Envoy would ignore, until the stream ends or the
+// grace period expires. The responses are attributed to the phases in the
+// order they were sent.
+func drainObserved(stream extprocv3.ExternalProcessor_ProcessClient, steps []observedStep, cancel context.CancelFunc) []*PhaseResponse {
+	received := make(chan *extprocv3.ProcessingResponse)
+	go func() {
+		defer close(received)
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			received <- resp
+		}
+	}()
+
+	grace := time.NewTimer(ObservabilityGrace)
+	defer grace.Stop()
+
+	var responses []*PhaseResponse
+	for {
+		select {
+		case resp, ok := <-received:
+			if !ok {
+				return responses
+			}
+			pr := &PhaseResponse{Response: resp}
+			if i := len(responses); i < len(steps) {
+				pr.Phase = steps[i].phase
+				pr.Latency = time.Since(steps[i].sent)
+				pr.Chunk, pr.LastChunk = steps[i].chunk, steps[i].lastChunk
+			}
+			responses = append(responses, pr)
+		case <-grace.C:
+			// Unblock the receiving goroutine, which closes the channel
+			cancel()
+		}
+	}
+}
+
+// suppressPhases records the phases a mode_override disables. Like Envoy,
+// only the overrides answering a headers phase apply, and they never enable
+// a phase that is not configured.
+func suppressPhases(suppressed map[extproctorv1.ProcessingPhase]bool, phase extproctorv1.ProcessingPhase, mode *extprocfilterv3.ProcessingMode) {
+	if mode == nil {
+		return
+	}
+	if phase != extproctorv1.ProcessingPhase_REQUEST_HEADERS && phase != extproctorv1.ProcessingPhase_RESPONSE_HEADERS {
+		return
+	}
+
+	if phase == extproctorv1.ProcessingPhase_REQUEST_HEADERS {
+		if mode.RequestBodyMode == extprocfilterv3.ProcessingMode_NONE {
+			suppressed[extproctorv1.ProcessingPhase_REQUEST_BODY] = true
+		}
+		if mode.RequestTrailerMode == extprocfilterv3.ProcessingMode_SKIP {
+			suppressed[extproctorv1.ProcessingPhase_REQUEST_TRAILERS] = true
+		}
+		if mode.ResponseHeaderMode == extprocfilterv3.ProcessingMode_SKIP {
+			suppressed[extproctorv1.ProcessingPhase_RESPONSE_HEADERS] = true
+		}
+	}
+	if mode.ResponseBodyMode == extprocfilterv3.ProcessingMode_NONE {
+		suppressed[extproctorv1.ProcessingPhase_RESPONSE_BODY] = true
+	}
+	if mode.ResponseTrailerMode == extprocfilterv3.ProcessingMode_SKIP {
+		suppressed[extproctorv1.ProcessingPhase_RESPONSE_TRAILERS] = true
+	}
+}
+
+// mirrorRawValues copies the values of the headers or trailers of a
+// processing request into their raw_value, when not set.
+func mirrorRawValues(pr *extprocv3.ProcessingRequest) {
+	var headers *corev3.HeaderMap
+	switch r := pr.Request.(type) {
+	case *extprocv3.ProcessingRequest_RequestHeaders:
+		headers = r.RequestHeaders.GetHeaders()
+	case *extprocv3.ProcessingRequest_RequestTrailers:
+		headers = r.RequestTrailers.GetTrailers()
+	case *extprocv3.ProcessingRequest_ResponseHeaders:
+		headers = r.ResponseHeaders.GetHeaders()
+	case *extprocv3.ProcessingRequest_ResponseTrailers:
+		headers = r.ResponseTrailers.GetTrailers()
+	}
+
+	for _, h := range headers.GetHeaders() {
+		if len(h.RawValue) == 0 {
+			h.RawValue = []byte(h.Value)
+		}
+	}
+}
+
 // isImmediateResponse checks if the response is an immediate response (short-circuit).
 func isImmediateResponse(resp *extprocv3.ProcessingResponse) bool {
 	return resp.GetImmediateResponse() != nil
@@ -288,7 +768,7 @@ func isImmediateResponse(resp *extprocv3.ProcessingResponse) bool {
 
 // buildRequestHeaders creates a ProcessingRequest for request headers.
 func buildRequestHeaders(req *extproctorv1.HttpRequest) *extprocv3.ProcessingRequest {
-	headers := make([]*corev3.HeaderValue, 0, len(req.Headers)+4)
+	headers := make([]*corev3.HeaderValue, 0, len(req.Headers)+len(req.HeaderEntries)+4)
 
 	// Add pseudo-headers
 	headers = append(headers,
@@ -305,11 +785,13 @@ func buildRequestHeaders(req *extproctorv1.HttpRequest) *extprocv3.ProcessingReq
 	}
 
 	// Add regular headers
-	for k, v := range req.Headers {
-		headers = append(headers, &corev3.HeaderValue{Key: k, Value: v})
+	headers = append(headers, headerValues(req.Headers, req.HeaderEntries)...)
+	for k, v := range req.RawHeaders {
+		headers = append(headers, &corev3.HeaderValue{Key: k, RawValue: v})
 	}
 
-	return &extprocv3.ProcessingRequest{
+	pr := &extprocv3.ProcessingRequest{
+		MetadataContext: metadataContext(req),
 		Request: &extprocv3.ProcessingRequest_RequestHeaders{
 			RequestHeaders: &extprocv3.HttpHeaders{
 				Headers: &corev3.HeaderMap{
@@ -319,15 +801,83 @@ func buildRequestHeaders(req *extproctorv1.HttpRequest) *extprocv3.ProcessingReq
 			},
 		},
 	}
+
+	// Envoy sends the attributes with the first message of the stream
+	pr.Attributes = requestAttributes(req)
+
+	return pr
+}
+
+// requestAttributes merges the downstream attributes and the attributes of
+// the request, the latter overriding the fields of a shared namespace.
+func requestAttributes(req *extproctorv1.HttpRequest) map[string]*structpb.Struct {
+	var attrs map[string]*structpb.Struct
+	if req.Downstream != nil {
+		attrs = downstreamAttributes(req.Downstream)
+	}
+	if len(req.Attributes) == 0 {
+		return attrs
+	}
+
+	if attrs == nil {
+		attrs = make(map[string]*structpb.Struct, len(req.Attributes))
+	}
+	for namespace, s := range req.Attributes {
+		merged, ok := attrs[namespace]
+		if !ok {
+			merged = &structpb.Struct{Fields: make(map[string]*structpb.Value, len(s.GetFields()))}
+			attrs[namespace] = merged
+		}
+		for k, v := range s.GetFields() {
+			merged.Fields[k] = proto.Clone(v).(*structpb.Value)
+		}
+	}
+	return attrs
+}
+
+// metadataContext returns the dynamic metadata of the request, sent with
+// every processing request as Envoy does.
+func metadataContext(req *extproctorv1.HttpRequest) *corev3.Metadata {
+	if len(req.MetadataContext) == 0 {
+		return nil
+	}
+	return &corev3.Metadata{FilterMetadata: req.MetadataContext}
+}
+
+// requestBodyChunks splits the request body according to body_chunk_size. The
+// body is returned whole when the chunk size is unset or invalid.
+func requestBodyChunks(req *extproctorv1.HttpRequest) [][]byte {
+	if req.BodyChunkSize == "" {
+		return [][]byte{req.Body}
+	}
+	size, err := units.ParseSize(req.BodyChunkSize)
+	if err != nil || size >= int64(len(req.Body)) {
+		return [][]byte{req.Body}
+	}
+
+	var chunks [][]byte
+	for body := req.Body; len(body) > 0; {
+		n := min(int(size), len(body))
+		chunks = append(chunks, body[:n])
+		body = body[n:]
+	}
+	return chunks
 }
 
-// buildRequestBody creates a ProcessingRequest for the request body.
+// buildRequestBody creates a ProcessingRequest for the whole request body.
 func buildRequestBody(req *extproctorv1.HttpRequest) *extprocv3.ProcessingRequest {
+	return buildRequestBodyChunk(req, req.Body, true)
+}
+
+// buildRequestBodyChunk creates a ProcessingRequest for a request body chunk.
+// Only the last chunk ends the stream, when no trailers follow.
+func buildRequestBodyChunk(req *extproctorv1.HttpRequest, chunk []byte, last bool) *extprocv3.ProcessingRequest {
 	return &extprocv3.ProcessingRequest{
+		MetadataContext: metadataContext(req),
 		Request: &extprocv3.ProcessingRequest_RequestBody{
 			RequestBody: &extprocv3.HttpBody{
-				Body:        req.Body,
-				EndOfStream: !req.ProcessRequestTrailers,
+				Body:        chunk,
+				EndOfStream: last && !req.ProcessRequestTrailers,
 			},
 		},
 	}
@@ -335,12 +885,10 @@ func buildRequestBody(req *extproctorv1.HttpRequest) *extprocv3.ProcessingReques
 
 // buildRequestTrailers creates a ProcessingRequest for request trailers.
 func buildRequestTrailers(req *extproctorv1.HttpRequest) *extprocv3.ProcessingRequest {
-	trailers := make([]*corev3.HeaderValue, 0, len(req.Trailers))
-	for k, v := range req.Trailers {
-		trailers = append(trailers, &corev3.HeaderValue{Key: k, Value: v})
-	}
+	trailers := headerValues(req.Trailers, req.TrailerEntries)
 
 	return &extprocv3.ProcessingRequest{
+		MetadataContext: metadataContext(req),
 		Request: &extprocv3.ProcessingRequest_RequestTrailers{
 			RequestTrailers: &extprocv3.HttpTrailers{
 				Trailers: &corev3.HeaderMap{
@@ -351,48 +899,101 @@ func buildRequestTrailers(req *extproctorv1.HttpRequest) *extprocv3.ProcessingRe
 	}
 }
 
-// buildResponseHeaders creates a ProcessingRequest for response headers.
-func buildResponseHeaders(req *extproctorv1.HttpRequest) *extprocv3.ProcessingRequest {
-	// Simulate response headers from upstream
-	headers := []*corev3.HeaderValue{
-		{Key: ":status", Value: "200"},
-		{Key: "content-type", Value: "application/json"},
+// hasResponseTrailers reports whether the simulated upstream response ends
+// with trailers, in which case neither the response headers nor the response
+// body carry end_of_stream.
+func hasResponseTrailers(req *extproctorv1.HttpRequest, resp *extproctorv1.HttpResponse) bool {
+	return req.ProcessResponseTrailers || len(req.ResponseTrailers) > 0 || len(req.ResponseTrailerEntries) > 0 ||
+		resp.GetProcessResponseTrailers() || len(resp.GetTrailers()) > 0
+}
+
+// processResponseBody reports whether the response body is sent to the
+// ExtProc service.
+func processResponseBody(req *extproctorv1.HttpRequest, resp *extproctorv1.HttpResponse) bool {
+	return req.ProcessResponseBody || resp.GetProcessResponseBody()
+}
+
+// headerValues returns the headers of a map followed by the entries, which
+// may repeat keys.
+func headerValues(headers map[string]string, entries []*extproctorv1.HeaderEntry) []*corev3.HeaderValue {
+	values := make([]*corev3.HeaderValue, 0, len(headers)+len(entries))
+	for k, v := range headers {
+		values = append(values, &corev3.HeaderValue{Key: k, Value: v})
+	}
+	for _, e := range entries {
+		values = append(values, &corev3.HeaderValue{Key: e.Key, Value: e.Value})
+	}
+	return values
+}
+
+// buildResponseHeaders creates a ProcessingRequest for response headers, of
+// the simulated upstream response when one is given.
+func buildResponseHeaders(req *extproctorv1.HttpRequest, resp *extproctorv1.HttpResponse) *extprocv3.ProcessingRequest {
+	status := resp.GetStatus()
+	if status == 0 {
+		status = http.StatusOK
+	}
+	headers := []*corev3.HeaderValue{{Key: ":status", Value: strconv.FormatUint(uint64(status), 10)}}
+	if len(resp.GetHeaders()) > 0 {
+		headers = append(headers, headerValues(nil, resp.GetHeaders())...)
+	} else {
+		headers = append(headers, &corev3.HeaderValue{Key: "content-type", Value: "application/json"})
 	}
 
+	// A simulated response with a body does not end with its headers, even
+	// when the body is not sent to the ExtProc service
+	hasBody := processResponseBody(req, resp) || len(resp.GetBody()) > 0
+
 	return &extprocv3.ProcessingRequest{
+		MetadataContext: metadataContext(req),
 		Request: &extprocv3.ProcessingRequest_ResponseHeaders{
 			ResponseHeaders: &extprocv3.HttpHeaders{
 				Headers: &corev3.HeaderMap{
 					Headers: headers,
 				},
-				EndOfStream: !req.ProcessResponseBody && !req.ProcessResponseTrailers,
+				EndOfStream: !hasBody && !hasResponseTrailers(req, resp),
 			},
 		},
 	}
 }
 
-// buildResponseBody creates a ProcessingRequest for the response body.
-func buildResponseBody(req *extproctorv1.HttpRequest) *extprocv3.ProcessingRequest {
-	// Simulate response body from upstream
+// buildResponseBody creates a ProcessingRequest for the response body, of
+// the simulated upstream response when one is given.
+func buildResponseBody(req *extproctorv1.HttpRequest, resp *extproctorv1.HttpResponse) *extprocv3.ProcessingRequest {
+	body := []byte(`{"status":"ok"}`)
+	if resp != nil {
+		body = resp.GetBody()
+	}
+
 	return &extprocv3.ProcessingRequest{
+		MetadataContext: metadataContext(req),
 		Request: &extprocv3.ProcessingRequest_ResponseBody{
 			ResponseBody: &extprocv3.HttpBody{
-				Body:        []byte(`{"status":"ok"}`),
-				EndOfStream: !req.ProcessResponseTrailers,
+				Body:        body,
+				EndOfStream: !hasResponseTrailers(req, resp),
 			},
 		},
 	}
 }
 
-// buildResponseTrailers creates a ProcessingRequest for response trailers.
-func buildResponseTrailers(req *extproctorv1.HttpRequest) *extprocv3.ProcessingRequest {
-	// Simulate response trailers from upstream (common in gRPC)
-	trailers := []*corev3.HeaderValue{
-		{Key: "grpc-status", Value: "0"},
-		{Key: "grpc-message", Value: "OK"},
+// buildResponseTrailers creates a ProcessingRequest for response trailers, of
+// the simulated upstream response when it sets some.
+func buildResponseTrailers(req *extproctorv1.HttpRequest, resp *extproctorv1.HttpResponse) *extprocv3.ProcessingRequest {
+	var trailers []*corev3.HeaderValue
+	if len(resp.GetTrailers()) > 0 {
+		trailers = headerValues(nil, resp.GetTrailers())
+	} else if len(req.ResponseTrailers) > 0 || len(req.ResponseTrailerEntries) > 0 {
+		trailers = headerValues(req.ResponseTrailers, req.ResponseTrailerEntries)
+	} else {
+		// Simulate response trailers from upstream (common in gRPC)
+		trailers = []*corev3.HeaderValue{
+			{Key: "grpc-status", Value: "0"},
+			{Key: "grpc-message", Value: "OK"},
+		}
 	}
 
 	return &extprocv3.ProcessingRequest{
+		MetadataContext: metadataContext(req),
 		Request: &extprocv3.ProcessingRequest_ResponseTrailers{
 			ResponseTrailers: &extprocv3.HttpTrailers{
 				Trailers: &corev3.HeaderMap{
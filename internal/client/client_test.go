@@ -19,7 +19,9 @@ import (
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
 	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/compression"
 )
 
 func TestWithTarget(t *testing.T) {
@@ -258,6 +260,109 @@ func TestBuildRequestTrailers_Empty(t *testing.T) {
 	assert.Empty(t, trailers.Trailers.Headers)
 }
 
+func TestBuildRequestTrailers_RepeatedKeys(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Trailers: map[string]string{"x-checksum": "abc123"},
+		TrailerEntries: []*extproctorv1.HeaderEntry{
+			{Key: "grpc-status-details-bin", Value: "first"},
+			{Key: "grpc-status-details-bin", Value: "second"},
+		},
+	}
+
+	trailers := buildRequestTrailers(req).GetRequestTrailers()
+	require.NotNil(t, trailers)
+	require.Len(t, trailers.Trailers.Headers, 3)
+	assert.Equal(t, "x-checksum", trailers.Trailers.Headers[0].Key)
+	assert.Equal(t, "first", trailers.Trailers.Headers[1].Value)
+	assert.Equal(t, "second", trailers.Trailers.Headers[2].Value)
+
+	// Entries alone enable the request trailers phase
+	req = &extproctorv1.HttpRequest{
+		ProcessRequestTrailers: true,
+		TrailerEntries:         []*extproctorv1.HeaderEntry{{Key: "x-a", Value: "1"}},
+	}
+	var phases []extproctorv1.ProcessingPhase
+	for _, step := range plannedPhases(req, nil) {
+		phases = append(phases, step.phase)
+	}
+	assert.Contains(t, phases, extproctorv1.ProcessingPhase_REQUEST_TRAILERS)
+}
+
+func TestBuildResponseBody_EndOfStream(t *testing.T) {
+	req := &extproctorv1.HttpRequest{ProcessResponseBody: true}
+	body := buildResponseBody(req, nil).GetResponseBody()
+	require.NotNil(t, body)
+	assert.True(t, body.EndOfStream)
+
+	// Upstream trailers keep the body stream open, even when they are not
+	// sent to the ExtProc service.
+	req.ResponseTrailers = map[string]string{"x-checksum": "abc123"}
+	body = buildResponseBody(req, nil).GetResponseBody()
+	require.NotNil(t, body)
+	assert.False(t, body.EndOfStream)
+
+	headers := buildResponseHeaders(req, nil).GetResponseHeaders()
+	require.NotNil(t, headers)
+	assert.False(t, headers.EndOfStream)
+}
+
+func TestBuildResponseTrailers_Default(t *testing.T) {
+	req := &extproctorv1.HttpRequest{ProcessResponseTrailers: true}
+
+	trailers := buildResponseTrailers(req, nil).GetResponseTrailers()
+	require.NotNil(t, trailers)
+	require.NotNil(t, trailers.Trailers)
+	assert.Len(t, trailers.Trailers.Headers, 2)
+	assert.Equal(t, "grpc-status", trailers.Trailers.Headers[0].Key)
+}
+
+func TestBuildResponseTrailers_Custom(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		ProcessResponseTrailers: true,
+		ResponseTrailers: map[string]string{
+			"x-checksum": "abc123",
+		},
+	}
+
+	trailers := buildResponseTrailers(req, nil).GetResponseTrailers()
+	require.NotNil(t, trailers)
+	require.Len(t, trailers.Trailers.Headers, 1)
+	assert.Equal(t, "x-checksum", trailers.Trailers.Headers[0].Key)
+	assert.Equal(t, "abc123", trailers.Trailers.Headers[0].Value)
+}
+
+func TestBuildResponseTrailers_RepeatedKeys(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		ResponseTrailerEntries: []*extproctorv1.HeaderEntry{
+			{Key: "x-experiment", Value: "a"},
+			{Key: "x-experiment", Value: "b"},
+		},
+	}
+
+	trailers := buildResponseTrailers(req, nil).GetResponseTrailers()
+	require.NotNil(t, trailers)
+	require.Len(t, trailers.Trailers.Headers, 2)
+	assert.Equal(t, "a", trailers.Trailers.Headers[0].Value)
+	assert.Equal(t, "b", trailers.Trailers.Headers[1].Value)
+	assert.True(t, hasResponseTrailers(req, nil))
+}
+
+func TestBuildResponseTrailers_MapAndEntries(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		ResponseTrailers: map[string]string{"x-checksum": "abc123"},
+		ResponseTrailerEntries: []*extproctorv1.HeaderEntry{
+			{Key: "x-experiment", Value: "a"},
+		},
+	}
+
+	// The map entries come first, followed by the entries in order
+	trailers := buildResponseTrailers(req, nil).GetResponseTrailers()
+	require.NotNil(t, trailers)
+	require.Len(t, trailers.Trailers.Headers, 2)
+	assert.Equal(t, "x-checksum", trailers.Trailers.Headers[0].Key)
+	assert.Equal(t, "x-experiment", trailers.Trailers.Headers[1].Key)
+}
+
 func TestProcessingResult_Types(t *testing.T) {
 	result := &ProcessingResult{
 		Responses: []*PhaseResponse{
@@ -323,6 +428,14 @@ func TestNew_WithUnixSocket(t *testing.T) {
 	assert.Equal(t, "unix:///tmp/test.sock", client.Target())
 }
 
+func TestNew_WithRawHeaderValues(t *testing.T) {
+	client, err := New(WithRawHeaderValues(true))
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	assert.True(t, client.rawHeaderValues)
+}
+
 func TestClient_Close_WithConn(t *testing.T) {
 	client, err := New()
 	require.NoError(t, err)
@@ -535,3 +648,121 @@ func generateTestCertificate(t *testing.T) (certPEM, keyPEM []byte) {
 
 	return certPEM, keyPEM
 }
+
+func TestBuildRequestHeaders_HeaderEntries(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Method:  "GET",
+		Path:    "/",
+		Headers: map[string]string{"x-map": "1"},
+		HeaderEntries: []*extproctorv1.HeaderEntry{
+			{Key: "via", Value: "1.1 a"},
+			{Key: "via", Value: "1.1 b"},
+		},
+	}
+
+	headers := buildRequestHeaders(req).GetRequestHeaders().GetHeaders().GetHeaders()
+	require.Len(t, headers, 5)
+	assert.Equal(t, "x-map", headers[2].Key)
+	assert.Equal(t, "1.1 a", headers[3].Value)
+	assert.Equal(t, "1.1 b", headers[4].Value)
+}
+
+func TestBuildRequestHeaders_Attributes(t *testing.T) {
+	route, err := structpb.NewStruct(map[string]any{"tenant": "a"})
+	require.NoError(t, err)
+	req := &extproctorv1.HttpRequest{
+		Method:     "GET",
+		Path:       "/",
+		Downstream: &extproctorv1.DownstreamAddress{Address: "10.0.0.1", Port: 1234},
+		Attributes: map[string]*structpb.Struct{
+			ExtProcAttributesKey: {Fields: map[string]*structpb.Value{
+				"request.path":       structpb.NewStringValue("/"),
+				"source.port":        structpb.NewNumberValue(4321),
+				"xds.route_metadata": structpb.NewStructValue(route),
+			}},
+			"custom": {Fields: map[string]*structpb.Value{"enabled": structpb.NewBoolValue(true)}},
+		},
+	}
+
+	attrs := buildRequestHeaders(req).Attributes
+	require.Len(t, attrs, 2)
+	extProc := attrs[ExtProcAttributesKey].GetFields()
+	assert.Equal(t, "10.0.0.1:1234", extProc["source.address"].GetStringValue())
+	assert.Equal(t, float64(4321), extProc["source.port"].GetNumberValue(), "request attributes override the downstream ones")
+	assert.Equal(t, "/", extProc["request.path"].GetStringValue())
+	assert.Equal(t, "a", extProc["xds.route_metadata"].GetStructValue().GetFields()["tenant"].GetStringValue())
+	assert.True(t, attrs["custom"].GetFields()["enabled"].GetBoolValue())
+
+	// The manifest request is left unchanged
+	assert.NotContains(t, req.Attributes[ExtProcAttributesKey].Fields, "source.address")
+}
+
+func TestBuildRequests_MetadataContext(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Method: "POST",
+		Path:   "/",
+		MetadataContext: map[string]*structpb.Struct{
+			"envoy.filters.http.jwt_authn": {Fields: map[string]*structpb.Value{"sub": structpb.NewStringValue("alice")}},
+		},
+	}
+
+	for _, pr := range []*extprocv3.ProcessingRequest{
+		buildRequestHeaders(req),
+		buildRequestBody(req),
+		buildRequestTrailers(req),
+		buildResponseHeaders(req, nil),
+		buildResponseBody(req, nil),
+		buildResponseTrailers(req, nil),
+	} {
+		claims := pr.GetMetadataContext().GetFilterMetadata()["envoy.filters.http.jwt_authn"]
+		assert.Equal(t, "alice", claims.GetFields()["sub"].GetStringValue(), "%T", pr.Request)
+	}
+
+	assert.Nil(t, buildRequestHeaders(&extproctorv1.HttpRequest{Method: "GET", Path: "/"}).MetadataContext)
+}
+
+func TestEncodeRequest_Gzip(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Method:       "POST",
+		Path:         "/",
+		Body:         []byte("hello"),
+		BodyEncoding: extproctorv1.BodyEncoding_GZIP,
+	}
+
+	encoded, err := encodeRequest(req)
+	require.NoError(t, err)
+
+	decoded, err := compression.Decode(extproctorv1.BodyEncoding_GZIP, encoded.Body)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello"), decoded)
+	require.Len(t, encoded.HeaderEntries, 1)
+	assert.Equal(t, "content-encoding", encoded.HeaderEntries[0].Key)
+	assert.Equal(t, "gzip", encoded.HeaderEntries[0].Value)
+
+	// The manifest request is left unchanged
+	assert.Equal(t, []byte("hello"), req.Body)
+	assert.Empty(t, req.HeaderEntries)
+}
+
+func TestEncodeRequest_KeepsContentEncoding(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Method:       "POST",
+		Path:         "/",
+		Headers:      map[string]string{"Content-Encoding": "x-gzip"},
+		Body:         []byte("hello"),
+		BodyEncoding: extproctorv1.BodyEncoding_GZIP,
+	}
+
+	encoded, err := encodeRequest(req)
+	require.NoError(t, err)
+	assert.Empty(t, encoded.HeaderEntries)
+	assert.Equal(t, "x-gzip", encoded.Headers["Content-Encoding"])
+}
+
+func TestEncodeRequest_Unspecified(t *testing.T) {
+	req := &extproctorv1.HttpRequest{Method: "POST", Path: "/", Body: []byte("hello")}
+
+	encoded, err := encodeRequest(req)
+	require.NoError(t, err)
+	assert.Same(t, req, encoded)
+}
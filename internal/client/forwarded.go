@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// ExtProcAttributesKey is the key of the attributes Envoy sends to the
+// ExtProc service.
+const ExtProcAttributesKey = "envoy.filters.http.ext_proc"
+
+// maxGeneratedHops bounds the generated x-forwarded-for hops, to stay below
+// the header size limits of proxies.
+const maxGeneratedHops = 4096
+
+// validateForwarding checks the downstream address and x-forwarded-for chain
+// of a request.
+func validateForwarding(req *extproctorv1.HttpRequest) []error {
+	var errs []error
+
+	if d := req.Downstream; d != nil {
+		if _, err := netip.ParseAddr(d.Address); err != nil {
+			errs = append(errs, fmt.Errorf("downstream address %q is not an IP address", d.Address))
+		}
+		if d.Port > 65535 {
+			errs = append(errs, fmt.Errorf("downstream port %d is out of range", d.Port))
+		}
+	}
+
+	if ff := req.ForwardedFor; ff != nil {
+		if hasHeader(req, "x-forwarded-for") {
+			errs = append(errs, errors.New("forwarded_for and an x-forwarded-for header are exclusive"))
+		}
+		for _, hop := range ff.Hops {
+			if invalidValue(hop) {
+				errs = append(errs, fmt.Errorf("forwarded_for hop %q must not contain CR, LF or NUL", hop))
+			}
+		}
+		if ff.GeneratedHops > maxGeneratedHops {
+			errs = append(errs, fmt.Errorf("forwarded_for generated_hops %d exceeds %d", ff.GeneratedHops, maxGeneratedHops))
+		}
+	}
+
+	return errs
+}
+
+// forwardedForHeader returns the x-forwarded-for value of a chain.
+func forwardedForHeader(ff *extproctorv1.ForwardedFor) string {
+	hops := make([]string, 0, len(ff.Hops)+int(ff.GeneratedHops))
+	hops = append(hops, ff.Hops...)
+	for i := range int(ff.GeneratedHops) {
+		hops = append(hops, fmt.Sprintf("198.51.100.%d", i%254+1))
+	}
+	return strings.Join(hops, ", ")
+}
+
+// downstreamAttributes returns the ExtProc attributes describing the
+// downstream address, keyed as Envoy does.
+func downstreamAttributes(d *extproctorv1.DownstreamAddress) map[string]*structpb.Struct {
+	addr, err := netip.ParseAddr(d.Address)
+	if err != nil {
+		return nil
+	}
+
+	return map[string]*structpb.Struct{
+		ExtProcAttributesKey: {
+			Fields: map[string]*structpb.Value{
+				"source.address": structpb.NewStringValue(netip.AddrPortFrom(addr, uint16(d.Port)).String()),
+				"source.port":    structpb.NewNumberValue(float64(d.Port)),
+			},
+		},
+	}
+}
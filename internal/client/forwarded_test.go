@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func TestForwardedForHeader(t *testing.T) {
+	assert.Equal(t, "1.2.3.4, unknown", forwardedForHeader(&extproctorv1.ForwardedFor{Hops: []string{"1.2.3.4", "unknown"}}))
+
+	value := forwardedForHeader(&extproctorv1.ForwardedFor{Hops: []string{"10.0.0.1"}, GeneratedHops: 300})
+	hops := strings.Split(value, ", ")
+	require.Len(t, hops, 301)
+	assert.Equal(t, "10.0.0.1", hops[0])
+	assert.Equal(t, "198.51.100.1", hops[1])
+	assert.Equal(t, "198.51.100.254", hops[254])
+	assert.Equal(t, "198.51.100.1", hops[255])
+}
+
+func TestBuildRequestHeaders_Downstream(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Method:     "GET",
+		Path:       "/",
+		Downstream: &extproctorv1.DownstreamAddress{Address: "2001:db8::7", Port: 54321},
+	}
+
+	pr := buildRequestHeaders(req)
+	attrs := pr.Attributes[ExtProcAttributesKey]
+	require.NotNil(t, attrs)
+	assert.Equal(t, "[2001:db8::7]:54321", attrs.Fields["source.address"].GetStringValue())
+	assert.Equal(t, float64(54321), attrs.Fields["source.port"].GetNumberValue())
+
+	assert.Nil(t, buildRequestHeaders(&extproctorv1.HttpRequest{Method: "GET", Path: "/"}).Attributes)
+}
+
+func TestEncodeRequest_ForwardedFor(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Method:       "GET",
+		Path:         "/",
+		ForwardedFor: &extproctorv1.ForwardedFor{Hops: []string{"127.0.0.1"}, GeneratedHops: 1},
+	}
+	require.NoError(t, ValidateRequest(req))
+
+	encoded, err := encodeRequest(req)
+	require.NoError(t, err)
+	require.Len(t, encoded.HeaderEntries, 1)
+	assert.Equal(t, "x-forwarded-for", encoded.HeaderEntries[0].Key)
+	assert.Equal(t, "127.0.0.1, 198.51.100.1", encoded.HeaderEntries[0].Value)
+}
+
+func TestValidateRequest_Forwarding(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Method:       "GET",
+		Path:         "/",
+		Headers:      map[string]string{"X-Forwarded-For": "1.1.1.1"},
+		Downstream:   &extproctorv1.DownstreamAddress{Address: "localhost", Port: 70000},
+		ForwardedFor: &extproctorv1.ForwardedFor{Hops: []string{"1.1.1.1\r\nx-admin: 1"}, GeneratedHops: 5000},
+	}
+
+	err := ValidateRequest(req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `downstream address "localhost" is not an IP address`)
+	assert.Contains(t, err.Error(), "downstream port 70000 is out of range")
+	assert.Contains(t, err.Error(), "forwarded_for and an x-forwarded-for header are exclusive")
+	assert.Contains(t, err.Error(), "must not contain CR, LF or NUL")
+	assert.Contains(t, err.Error(), "generated_hops 5000 exceeds 4096")
+}
@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// graphqlBody is the JSON body of a GraphQL operation sent over HTTP POST.
+type graphqlBody struct {
+	Query         string          `json:"query"`
+	OperationName string          `json:"operationName,omitempty"`
+	Variables     json.RawMessage `json:"variables,omitempty"`
+}
+
+// validateGraphql checks the GraphQL operation of a request.
+func validateGraphql(req *extproctorv1.HttpRequest) []error {
+	g := req.Graphql
+	if g == nil {
+		return nil
+	}
+
+	var errs []error
+	if len(req.Body) > 0 || req.Multipart != nil {
+		errs = append(errs, errors.New("graphql is exclusive with body and multipart"))
+	}
+	if !strings.EqualFold(req.Method, "POST") {
+		errs = append(errs, fmt.Errorf("graphql operations are sent with POST, not %s", req.Method))
+	}
+	if strings.TrimSpace(g.Query) == "" {
+		errs = append(errs, errors.New("graphql query is required"))
+	}
+	if g.Variables != "" {
+		var vars map[string]any
+		if err := json.Unmarshal([]byte(g.Variables), &vars); err != nil {
+			errs = append(errs, fmt.Errorf("graphql variables must be a JSON object: %w", err))
+		}
+	}
+
+	return errs
+}
+
+// encodeGraphql encodes a GraphQL operation as a JSON POST body.
+func encodeGraphql(g *extproctorv1.GraphqlRequest) ([]byte, error) {
+	body := graphqlBody{
+		Query:         g.Query,
+		OperationName: g.OperationName,
+	}
+	if g.Variables != "" {
+		body.Variables = json.RawMessage(g.Variables)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode graphql body: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func TestEncodeGraphql(t *testing.T) {
+	body, err := encodeGraphql(&extproctorv1.GraphqlRequest{
+		Query:         "query GetUser($id: ID!) { user(id: $id) { name } }",
+		Variables:     `{"id": "42"}`,
+		OperationName: "GetUser",
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"query": "query GetUser($id: ID!) { user(id: $id) { name } }",
+		"operationName": "GetUser",
+		"variables": {"id": "42"}
+	}`, string(body))
+}
+
+func TestEncodeGraphql_QueryOnly(t *testing.T) {
+	body, err := encodeGraphql(&extproctorv1.GraphqlRequest{Query: "{ me { id } }"})
+	require.NoError(t, err)
+	assert.Equal(t, `{"query":"{ me { id } }"}`, string(body))
+}
+
+func TestEncodeRequest_Graphql(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Method:  "POST",
+		Path:    "/graphql",
+		Graphql: &extproctorv1.GraphqlRequest{Query: "{ me { id } }"},
+	}
+
+	encoded, err := encodeRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, `{"query":"{ me { id } }"}`, string(encoded.Body))
+	require.Len(t, encoded.HeaderEntries, 1)
+	assert.Equal(t, "content-type", encoded.HeaderEntries[0].Key)
+	assert.Equal(t, "application/json", encoded.HeaderEntries[0].Value)
+}
+
+func TestValidateRequest_Graphql(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Method:  "GET",
+		Path:    "/graphql",
+		Body:    []byte("raw"),
+		Graphql: &extproctorv1.GraphqlRequest{Variables: `"not an object"`},
+	}
+
+	err := ValidateRequest(req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "graphql is exclusive with body and multipart")
+	assert.Contains(t, err.Error(), "graphql operations are sent with POST, not GET")
+	assert.Contains(t, err.Error(), "graphql query is required")
+	assert.Contains(t, err.Error(), "graphql variables must be a JSON object")
+}
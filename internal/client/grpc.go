@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/compression"
+)
+
+// grpcContentType is the content type of gRPC requests.
+const grpcContentType = "application/grpc"
+
+// requestLine returns the method and path of a request, defaulted for gRPC
+// calls to POST /<service>/<method>.
+func requestLine(req *extproctorv1.HttpRequest) (method, path string) {
+	method, path = req.Method, req.Path
+	if g := req.Grpc; g != nil {
+		if method == "" {
+			method = "POST"
+		}
+		if path == "" && g.Service != "" && g.Method != "" {
+			path = "/" + g.Service + "/" + g.Method
+		}
+	}
+	return method, path
+}
+
+// validateGrpc checks the gRPC call of a request.
+func validateGrpc(req *extproctorv1.HttpRequest) []error {
+	g := req.Grpc
+	if g == nil {
+		return nil
+	}
+
+	var errs []error
+	if len(req.Body) > 0 || req.Multipart != nil || req.Graphql != nil {
+		errs = append(errs, errors.New("grpc is exclusive with body, multipart and graphql"))
+	}
+	if method, _ := requestLine(req); !strings.EqualFold(method, "POST") {
+		errs = append(errs, fmt.Errorf("grpc calls are sent with POST, not %s", method))
+	}
+	if req.Path == "" && (g.Service == "" || g.Method == "") {
+		errs = append(errs, errors.New("grpc service and method are required without a path"))
+	}
+	if strings.ContainsRune(g.Service, '/') || strings.ContainsRune(g.Method, '/') {
+		errs = append(errs, errors.New("grpc service and method must not contain /"))
+	}
+
+	return errs
+}
+
+// encodeGrpc encodes the messages of a gRPC call as length-prefixed frames:
+// a compressed flag byte, the big-endian message length and the message. The
+// messages are compressed with the given encoding, if any.
+func encodeGrpc(g *extproctorv1.GrpcRequest, enc extproctorv1.BodyEncoding) ([]byte, error) {
+	var body []byte
+	for i, m := range g.Messages {
+		payload := m.GetPayload()
+		if m.GetFile() != "" {
+			data, err := os.ReadFile(m.GetFile())
+			if err != nil {
+				return nil, fmt.Errorf("grpc message %d: failed to read file: %w", i, err)
+			}
+			payload = data
+		}
+
+		var flag byte
+		if enc != extproctorv1.BodyEncoding_BODY_ENCODING_UNSPECIFIED {
+			compressed, err := compression.Encode(enc, payload)
+			if err != nil {
+				return nil, fmt.Errorf("grpc message %d: %w", i, err)
+			}
+			payload, flag = compressed, 1
+		}
+
+		body = append(body, flag)
+		body = binary.BigEndian.AppendUint32(body, uint32(len(payload)))
+		body = append(body, payload...)
+	}
+	return body, nil
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/compression"
+)
+
+func TestEncodeGrpc_Frames(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "second.bin")
+	require.NoError(t, os.WriteFile(file, []byte{0x0a, 0x03, 'b', 'o', 'b'}, 0o644))
+
+	body, err := encodeGrpc(&extproctorv1.GrpcRequest{
+		Messages: []*extproctorv1.GrpcMessage{
+			{Content: &extproctorv1.GrpcMessage_Payload{Payload: []byte{0x0a, 0x02, '4', '2'}}},
+			{Content: &extproctorv1.GrpcMessage_File{File: file}},
+		},
+	}, extproctorv1.BodyEncoding_BODY_ENCODING_UNSPECIFIED)
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte{
+		0, 0, 0, 0, 4, 0x0a, 0x02, '4', '2',
+		0, 0, 0, 0, 5, 0x0a, 0x03, 'b', 'o', 'b',
+	}, body)
+}
+
+func TestEncodeGrpc_Compressed(t *testing.T) {
+	payload := []byte{0x0a, 0x02, '4', '2'}
+	body, err := encodeGrpc(&extproctorv1.GrpcRequest{
+		Messages: []*extproctorv1.GrpcMessage{
+			{Content: &extproctorv1.GrpcMessage_Payload{Payload: payload}},
+		},
+	}, extproctorv1.BodyEncoding_GZIP)
+	require.NoError(t, err)
+
+	require.Greater(t, len(body), 5)
+	assert.Equal(t, byte(1), body[0])
+	assert.Equal(t, len(body)-5, int(binary.BigEndian.Uint32(body[1:5])))
+	decoded, err := compression.Decode(extproctorv1.BodyEncoding_GZIP, body[5:])
+	require.NoError(t, err)
+	assert.Equal(t, payload, decoded)
+}
+
+func TestEncodeRequest_Grpc(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Grpc: &extproctorv1.GrpcRequest{
+			Service: "acme.users.v1.UserService",
+			Method:  "GetUser",
+			Messages: []*extproctorv1.GrpcMessage{
+				{Content: &extproctorv1.GrpcMessage_Payload{Payload: []byte{0x0a, 0x02, '4', '2'}}},
+			},
+		},
+		BodyEncoding: extproctorv1.BodyEncoding_GZIP,
+	}
+	require.NoError(t, ValidateRequest(req))
+
+	encoded, err := encodeRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, "POST", encoded.Method)
+	assert.Equal(t, "/acme.users.v1.UserService/GetUser", encoded.Path)
+
+	headers := map[string]string{}
+	for _, h := range encoded.HeaderEntries {
+		headers[h.Key] = h.Value
+	}
+	assert.Equal(t, map[string]string{
+		"content-type":  "application/grpc",
+		"te":            "trailers",
+		"grpc-encoding": "gzip",
+	}, headers)
+}
+
+func TestEncodeRequest_GrpcKeepsHeaders(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Method:        "POST",
+		Path:          "/custom/Path",
+		HeaderEntries: []*extproctorv1.HeaderEntry{{Key: "content-type", Value: "application/grpc+proto"}},
+		Grpc:          &extproctorv1.GrpcRequest{},
+	}
+	require.NoError(t, ValidateRequest(req))
+
+	encoded, err := encodeRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, "/custom/Path", encoded.Path)
+	require.Len(t, encoded.HeaderEntries, 2)
+	assert.Equal(t, "application/grpc+proto", encoded.HeaderEntries[0].Value)
+	assert.Equal(t, "te", encoded.HeaderEntries[1].Key)
+	assert.Empty(t, encoded.Body)
+}
+
+func TestValidateRequest_Grpc(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Method: "GET",
+		Body:   []byte("raw"),
+		Grpc:   &extproctorv1.GrpcRequest{Service: "acme/Users"},
+	}
+
+	err := ValidateRequest(req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "grpc is exclusive with body, multipart and graphql")
+	assert.Contains(t, err.Error(), "grpc calls are sent with POST, not GET")
+	assert.Contains(t, err.Error(), "grpc service and method are required without a path")
+	assert.Contains(t, err.Error(), "grpc service and method must not contain /")
+}
@@ -0,0 +1,209 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxRetryBackoff bounds the delay between two attempts of a retried RPC.
+const maxRetryBackoff = 2 * time.Second
+
+// WithUnaryInterceptor adds interceptors to the unary RPCs of the client.
+// Interceptors run in the order they are added, the built-in ones included.
+func WithUnaryInterceptor(interceptors ...grpc.UnaryClientInterceptor) Option {
+	return func(c *clientConfig) {
+		c.unaryInterceptors = append(c.unaryInterceptors, interceptors...)
+	}
+}
+
+// WithStreamInterceptor adds interceptors to the streaming RPCs of the
+// client, such as the ExtProc processing stream. Interceptors run in the
+// order they are added, the built-in ones included.
+func WithStreamInterceptor(interceptors ...grpc.StreamClientInterceptor) Option {
+	return func(c *clientConfig) {
+		c.streamInterceptors = append(c.streamInterceptors, interceptors...)
+	}
+}
+
+// WithAuthToken sends the token as a bearer token in the authorization
+// metadata of every RPC.
+func WithAuthToken(token string) Option {
+	return func(c *clientConfig) {
+		c.unaryInterceptors = append(c.unaryInterceptors, func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return invoker(withBearerToken(ctx, token), method, req, reply, cc, opts...)
+		})
+		c.streamInterceptors = append(c.streamInterceptors, func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return streamer(withBearerToken(ctx, token), desc, cc, method, opts...)
+		})
+	}
+}
+
+// withBearerToken returns the context with the token in the authorization
+// metadata.
+func withBearerToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+// WithRequestLog logs every RPC, and every message of the streams, to w.
+func WithRequestLog(w io.Writer) Option {
+	l := &requestLogger{w: w}
+	return func(c *clientConfig) {
+		c.unaryInterceptors = append(c.unaryInterceptors, l.unary)
+		c.streamInterceptors = append(c.streamInterceptors, l.stream)
+	}
+}
+
+// WithRetry retries the RPCs, and the opening of the streams, failing
+// because the service is unavailable, up to the given number of times with
+// an exponential backoff. Messages of an opened stream are never retried.
+func WithRetry(retries int) Option {
+	return func(c *clientConfig) {
+		if retries <= 0 {
+			return
+		}
+		c.unaryInterceptors = append(c.unaryInterceptors, func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return retry(ctx, retries, func() error {
+				return invoker(ctx, method, req, reply, cc, opts...)
+			})
+		})
+		c.streamInterceptors = append(c.streamInterceptors, func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			var stream grpc.ClientStream
+			err := retry(ctx, retries, func() error {
+				var err error
+				stream, err = streamer(ctx, desc, cc, method, opts...)
+				return err
+			})
+			return stream, err
+		})
+	}
+}
+
+// retryObserverKey is the context key of retry observers.
+type retryObserverKey struct{}
+
+// WithRetryObserver returns a context whose calls retried by WithRetry call
+// observe before each retry, with the number of the retry (from 1) and the
+// error of the failed attempt.
+func WithRetryObserver(ctx context.Context, observe func(attempt int, err error)) context.Context {
+	return context.WithValue(ctx, retryObserverKey{}, observe)
+}
+
+// retry calls fn until it succeeds, fails with another code than
+// Unavailable, or the retries are exhausted.
+func retry(ctx context.Context, retries int, fn func() error) error {
+	observe, _ := ctx.Value(retryObserverKey{}).(func(int, error))
+
+	backoff := 100 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt == retries || status.Code(err) != codes.Unavailable {
+			return err
+		}
+		if observe != nil {
+			observe(attempt+1, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxRetryBackoff)
+	}
+}
+
+// requestLogger writes a line per RPC and stream message.
+type requestLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// logf writes a log line.
+func (l *requestLogger) logf(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.w, "grpc: "+format+"\n", args...)
+}
+
+func (l *requestLogger) unary(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	l.logf("%s %s in %s", method, status.Code(err), time.Since(start))
+	return err
+}
+
+func (l *requestLogger) stream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		l.logf("%s open failed: %v", method, err)
+		return nil, err
+	}
+	l.logf("%s opened", method)
+	return &loggedStream{ClientStream: stream, logger: l, method: method}, nil
+}
+
+// loggedStream logs the messages of a stream.
+type loggedStream struct {
+	grpc.ClientStream
+	logger *requestLogger
+	method string
+	sent   time.Time
+}
+
+func (s *loggedStream) SendMsg(m any) error {
+	s.sent = time.Now()
+	err := s.ClientStream.SendMsg(m)
+	if err != nil {
+		s.logger.logf("%s send %s failed: %v", s.method, messageName(m), err)
+		return err
+	}
+	s.logger.logf("%s sent %s", s.method, messageName(m))
+	return nil
+}
+
+func (s *loggedStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == io.EOF:
+		s.logger.logf("%s closed by the server", s.method)
+	case err != nil:
+		s.logger.logf("%s receive failed: %v", s.method, err)
+	default:
+		s.logger.logf("%s received %s after %s", s.method, messageName(m), time.Since(s.sent))
+	}
+	return err
+}
+
+func (s *loggedStream) CloseSend() error {
+	s.logger.logf("%s closed", s.method)
+	return s.ClientStream.CloseSend()
+}
+
+// messageName names a stream message by its set oneof field (e.g.
+// request_headers), or its type.
+func messageName(m any) string {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return fmt.Sprintf("%T", m)
+	}
+
+	r := msg.ProtoReflect()
+	if oneofs := r.Descriptor().Oneofs(); oneofs.Len() > 0 {
+		if fd := r.WhichOneof(oneofs.Get(0)); fd != nil {
+			return string(fd.Name())
+		}
+	}
+	return string(r.Descriptor().Name())
+}
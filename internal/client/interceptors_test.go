@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// metadataProcessor is an ExtProc service recording the metadata of the
+// processing streams.
+type metadataProcessor struct {
+	extprocv3.UnimplementedExternalProcessorServer
+	metadata chan metadata.MD
+}
+
+func (p *metadataProcessor) Process(stream extprocv3.ExternalProcessor_ProcessServer) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	p.metadata <- md
+	for {
+		if _, err := stream.Recv(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := stream.Send(&extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestHeaders{
+			RequestHeaders: &extprocv3.HeadersResponse{},
+		}}); err != nil {
+			return err
+		}
+	}
+}
+
+func TestNew_Interceptors(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &metadataProcessor{metadata: make(chan metadata.MD, 1)}
+	grpcServer := grpc.NewServer()
+	extprocv3.RegisterExternalProcessorServer(grpcServer, srv)
+	go func() { _ = grpcServer.Serve(lis) }()
+	t.Cleanup(grpcServer.Stop)
+
+	var calls []string
+	log := &bytes.Buffer{}
+	c, err := New(
+		WithTarget(lis.Addr().String()),
+		WithAuthToken("s3cr3t"),
+		WithRequestLog(log),
+		WithStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			calls = append(calls, method)
+			return streamer(metadata.AppendToOutgoingContext(ctx, "x-team", "edge"), desc, cc, method, opts...)
+		}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	_, err = c.Process(context.Background(), &extproctorv1.HttpRequest{Method: "GET", Path: "/"})
+	require.NoError(t, err)
+
+	md := <-srv.metadata
+	assert.Equal(t, []string{"Bearer s3cr3t"}, md.Get("authorization"))
+	assert.Equal(t, []string{"edge"}, md.Get("x-team"))
+	assert.Equal(t, []string{"/envoy.service.ext_proc.v3.ExternalProcessor/Process"}, calls)
+
+	assert.Contains(t, log.String(), "grpc: /envoy.service.ext_proc.v3.ExternalProcessor/Process opened\n")
+	assert.Contains(t, log.String(), "grpc: /envoy.service.ext_proc.v3.ExternalProcessor/Process sent request_headers\n")
+	assert.Contains(t, log.String(), "grpc: /envoy.service.ext_proc.v3.ExternalProcessor/Process received request_headers after ")
+}
+
+func TestWithRetry(t *testing.T) {
+	cfg := &clientConfig{}
+	WithRetry(2)(cfg)
+	require.Len(t, cfg.streamInterceptors, 1)
+	require.Len(t, cfg.unaryInterceptors, 1)
+
+	attempts := 0
+	failing := func(code codes.Code) grpc.Streamer {
+		return func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+			attempts++
+			return nil, status.Error(code, "down")
+		}
+	}
+
+	// Unavailable services are retried
+	_, err := cfg.streamInterceptors[0](context.Background(), &grpc.StreamDesc{}, nil, "/m", failing(codes.Unavailable))
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 3, attempts)
+
+	// Retries are observed
+	var observed []int
+	ctx := WithRetryObserver(context.Background(), func(attempt int, err error) {
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		observed = append(observed, attempt)
+	})
+	attempts = 0
+	_, _ = cfg.streamInterceptors[0](ctx, &grpc.StreamDesc{}, nil, "/m", failing(codes.Unavailable))
+	assert.Equal(t, []int{1, 2}, observed)
+
+	// Other failures are not
+	attempts = 0
+	_, err = cfg.streamInterceptors[0](context.Background(), &grpc.StreamDesc{}, nil, "/m", failing(codes.PermissionDenied))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, 1, attempts)
+
+	// Unary RPCs succeed once the service is back
+	attempts = 0
+	err = cfg.unaryInterceptors[0](context.Background(), "/m", nil, nil, nil, func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		attempts++
+		if attempts < 2 {
+			return status.Error(codes.Unavailable, "down")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	// No retries, no interceptor
+	cfg = &clientConfig{}
+	WithRetry(0)(cfg)
+	assert.Empty(t, cfg.streamInterceptors)
+}
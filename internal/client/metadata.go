@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// WithMetadata sends the gRPC metadata on every processing stream, e.g. the
+// tenant an ExtProc deployment routes by. Channel metadata overrides its
+// keys.
+func WithMetadata(md map[string]string) Option {
+	return func(c *clientConfig) {
+		if c.metadata == nil {
+			c.metadata = make(map[string]string, len(md))
+		}
+		for k, v := range md {
+			c.metadata[strings.ToLower(k)] = v
+		}
+	}
+}
+
+// ValidateMetadata checks that the gRPC metadata can be sent: keys made of
+// lowercase letters, digits, "-", "_" and ".", not reserved by gRPC, and
+// values without CR, LF or NUL characters.
+func ValidateMetadata(md map[string]string) error {
+	var errs []error
+	for _, k := range slices.Sorted(maps.Keys(md)) {
+		key := strings.ToLower(k)
+		switch {
+		case key == "":
+			errs = append(errs, errors.New("metadata key is required"))
+		case strings.HasPrefix(key, "grpc-") || strings.HasPrefix(key, ":"):
+			errs = append(errs, fmt.Errorf("metadata key %q is reserved by gRPC", k))
+		case strings.ContainsFunc(key, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.')
+		}):
+			errs = append(errs, fmt.Errorf("metadata key %q must only contain letters, digits, -, _ and .", k))
+		}
+		if invalidValue(md[k]) {
+			errs = append(errs, fmt.Errorf("metadata %q value must not contain CR, LF or NUL", k))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// withOutgoingMetadata returns the context with the metadata of the client
+// added to its outgoing metadata.
+func (c *Client) withOutgoingMetadata(ctx context.Context) context.Context {
+	if len(c.metadata) == 0 {
+		return ctx
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	for k, v := range c.metadata {
+		md.Set(k, v)
+	}
+	return metadata.NewOutgoingContext(ctx, md)
+}
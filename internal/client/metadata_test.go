@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func TestWithMetadata(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	calls := make(chan metadata.MD, 10)
+	grpcServer := grpc.NewServer()
+	extprocv3.RegisterExternalProcessorServer(grpcServer, &metadataProcessor{metadata: calls})
+	go func() { _ = grpcServer.Serve(lis) }()
+	t.Cleanup(grpcServer.Stop)
+
+	c, err := New(WithTarget(lis.Addr().String()), WithMetadata(map[string]string{"X-Tenant-ID": "acme", "x-region": "eu"}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	req := &extproctorv1.HttpRequest{Method: "GET", Path: "/"}
+	_, err = c.Process(context.Background(), req)
+	require.NoError(t, err)
+	md := <-calls
+	assert.Equal(t, []string{"acme"}, md.Get("x-tenant-id"))
+	assert.Equal(t, []string{"eu"}, md.Get("x-region"))
+
+	// Channel metadata overrides the keys of the client, and is added to the
+	// outgoing metadata of the context
+	tenant, err := c.WithChannel(Channel{Metadata: map[string]string{"x-tenant-id": "globex"}})
+	require.NoError(t, err)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-trace", "1")
+	_, err = tenant.Process(ctx, req)
+	require.NoError(t, err)
+	md = <-calls
+	assert.Equal(t, []string{"globex"}, md.Get("x-tenant-id"))
+	assert.Equal(t, []string{"eu"}, md.Get("x-region"))
+	assert.Equal(t, []string{"1"}, md.Get("x-trace"))
+
+	// The client keeps its metadata
+	_, err = c.Process(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme"}, (<-calls).Get("x-tenant-id"))
+}
+
+func TestValidateMetadata(t *testing.T) {
+	assert.NoError(t, ValidateMetadata(nil))
+	assert.NoError(t, ValidateMetadata(map[string]string{"Authorization": "Bearer token", "x-tenant_id.v1": "acme"}))
+
+	err := ValidateMetadata(map[string]string{
+		"":            "empty",
+		"grpc-status": "0",
+		"x tenant":    "acme",
+		"x-region":    "eu\r\nx-injected: 1",
+	})
+	assert.ErrorContains(t, err, "metadata key is required")
+	assert.ErrorContains(t, err, `metadata key "grpc-status" is reserved by gRPC`)
+	assert.ErrorContains(t, err, `metadata key "x tenant" must only contain letters, digits, -, _ and .`)
+	assert.ErrorContains(t, err, `metadata "x-region" value must not contain CR, LF or NUL`)
+}
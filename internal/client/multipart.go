@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// Multipart body defaults.
+const (
+	DefaultMultipartBoundary = "extproctor-boundary"
+	DefaultMultipartSubtype  = "form-data"
+)
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// validateMultipart checks the multipart section of a request.
+func validateMultipart(req *extproctorv1.HttpRequest) []error {
+	m := req.Multipart
+	if m == nil {
+		return nil
+	}
+
+	var errs []error
+	if len(req.Body) > 0 {
+		errs = append(errs, errors.New("body and multipart are exclusive"))
+	}
+	if m.Boundary != "" {
+		if err := multipart.NewWriter(nil).SetBoundary(m.Boundary); err != nil {
+			errs = append(errs, fmt.Errorf("multipart boundary %q is invalid", m.Boundary))
+		}
+	}
+	if m.Subtype != "" && !isToken(m.Subtype) {
+		errs = append(errs, fmt.Errorf("multipart subtype %q is not a valid RFC 9110 token", m.Subtype))
+	}
+
+	for i, p := range m.Parts {
+		if multipartSubtype(m) == DefaultMultipartSubtype && p.Name == "" {
+			errs = append(errs, fmt.Errorf("multipart part %d: name is required for form-data", i))
+		}
+		if invalidValue(p.Name) || invalidValue(p.Filename) || invalidValue(p.ContentType) {
+			errs = append(errs, fmt.Errorf("multipart part %d: name, filename and content type must not contain CR, LF or NUL", i))
+		}
+		errs = append(errs, validateFields(fmt.Sprintf("multipart part %d header", i), nil, p.Headers)...)
+	}
+
+	return errs
+}
+
+// encodeMultipart encodes a multipart body and returns it with its
+// content-type header value.
+func encodeMultipart(m *extproctorv1.Multipart) ([]byte, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	boundary := m.Boundary
+	if boundary == "" {
+		boundary = DefaultMultipartBoundary
+	}
+	if err := w.SetBoundary(boundary); err != nil {
+		return nil, "", fmt.Errorf("invalid multipart boundary: %w", err)
+	}
+
+	for i, p := range m.Parts {
+		content := p.GetValue()
+		if p.GetFile() != "" {
+			data, err := os.ReadFile(p.GetFile())
+			if err != nil {
+				return nil, "", fmt.Errorf("multipart part %d: failed to read file: %w", i, err)
+			}
+			content = data
+		}
+
+		part, err := w.CreatePart(partHeader(multipartSubtype(m), p))
+		if err != nil {
+			return nil, "", fmt.Errorf("multipart part %d: %w", i, err)
+		}
+		if _, err := part.Write(content); err != nil {
+			return nil, "", fmt.Errorf("multipart part %d: %w", i, err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to encode multipart body: %w", err)
+	}
+
+	contentType := fmt.Sprintf("multipart/%s; boundary=%s", multipartSubtype(m), boundary)
+	return buf.Bytes(), contentType, nil
+}
+
+// partHeader returns the headers of a part. Form-data parts are named by their
+// content disposition; parts of other subtypes only get one for a file name.
+func partHeader(subtype string, p *extproctorv1.MultipartPart) textproto.MIMEHeader {
+	h := textproto.MIMEHeader{}
+
+	filename := p.Filename
+	if filename == "" && p.GetFile() != "" {
+		filename = filepath.Base(p.GetFile())
+	}
+
+	var disposition string
+	switch {
+	case subtype == DefaultMultipartSubtype:
+		disposition = fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(p.Name))
+	case filename != "":
+		disposition = "attachment"
+	}
+	if disposition != "" {
+		if filename != "" {
+			disposition += fmt.Sprintf(`; filename="%s"`, quoteEscaper.Replace(filename))
+		}
+		h.Set("Content-Disposition", disposition)
+	}
+
+	switch {
+	case p.ContentType != "":
+		h.Set("Content-Type", p.ContentType)
+	case p.GetFile() != "":
+		h.Set("Content-Type", "application/octet-stream")
+	}
+
+	for _, e := range p.Headers {
+		h.Add(e.Key, e.Value)
+	}
+
+	return h
+}
+
+// multipartSubtype returns the subtype of a multipart body.
+func multipartSubtype(m *extproctorv1.Multipart) string {
+	if m.Subtype == "" {
+		return DefaultMultipartSubtype
+	}
+	return strings.ToLower(m.Subtype)
+}
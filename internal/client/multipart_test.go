@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func TestEncodeMultipart_FormData(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "report.pdf")
+	require.NoError(t, os.WriteFile(file, []byte("%PDF-1.7"), 0o644))
+
+	body, contentType, err := encodeMultipart(&extproctorv1.Multipart{
+		Parts: []*extproctorv1.MultipartPart{
+			{Name: "title", Content: &extproctorv1.MultipartPart_Value{Value: []byte("Q3 report")}},
+			{Name: "doc", Content: &extproctorv1.MultipartPart_File{File: file}, Headers: []*extproctorv1.HeaderEntry{{Key: "x-scan", Value: "yes"}}},
+			{Name: "data", Filename: "data.json", ContentType: "application/json", Content: &extproctorv1.MultipartPart_Value{Value: []byte("{}")}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/form-data; boundary=extproctor-boundary", contentType)
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/form-data", mediaType)
+
+	r := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+
+	part, err := r.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "title", part.FormName())
+	assert.Empty(t, part.FileName())
+	content, _ := io.ReadAll(part)
+	assert.Equal(t, "Q3 report", string(content))
+
+	part, err = r.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "doc", part.FormName())
+	assert.Equal(t, "report.pdf", part.FileName())
+	assert.Equal(t, "application/octet-stream", part.Header.Get("Content-Type"))
+	assert.Equal(t, "yes", part.Header.Get("X-Scan"))
+	content, _ = io.ReadAll(part)
+	assert.Equal(t, "%PDF-1.7", string(content))
+
+	part, err = r.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "data.json", part.FileName())
+	assert.Equal(t, "application/json", part.Header.Get("Content-Type"))
+
+	_, err = r.NextPart()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestEncodeMultipart_Mixed(t *testing.T) {
+	body, contentType, err := encodeMultipart(&extproctorv1.Multipart{
+		Boundary: "custom",
+		Subtype:  "mixed",
+		Parts: []*extproctorv1.MultipartPart{
+			{ContentType: "text/plain", Content: &extproctorv1.MultipartPart_Value{Value: []byte("hello")}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed; boundary=custom", contentType)
+	assert.Equal(t, "--custom\r\nContent-Type: text/plain\r\n\r\nhello\r\n--custom--\r\n", string(body))
+}
+
+func TestEncodeMultipart_MissingFile(t *testing.T) {
+	_, _, err := encodeMultipart(&extproctorv1.Multipart{
+		Parts: []*extproctorv1.MultipartPart{
+			{Name: "doc", Content: &extproctorv1.MultipartPart_File{File: filepath.Join(t.TempDir(), "missing")}},
+		},
+	})
+	assert.ErrorContains(t, err, "multipart part 0: failed to read file")
+}
+
+func TestEncodeRequest_Multipart(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Method: "POST",
+		Path:   "/upload",
+		Multipart: &extproctorv1.Multipart{
+			Parts: []*extproctorv1.MultipartPart{
+				{Name: "field", Content: &extproctorv1.MultipartPart_Value{Value: []byte("value")}},
+			},
+		},
+		BodyEncoding: extproctorv1.BodyEncoding_GZIP,
+	}
+
+	encoded, err := encodeRequest(req)
+	require.NoError(t, err)
+	require.Len(t, encoded.HeaderEntries, 2)
+	assert.Equal(t, "content-type", encoded.HeaderEntries[0].Key)
+	assert.Equal(t, "multipart/form-data; boundary=extproctor-boundary", encoded.HeaderEntries[0].Value)
+	assert.Equal(t, "content-encoding", encoded.HeaderEntries[1].Key)
+	assert.Empty(t, req.Body)
+	assert.NotEmpty(t, encoded.Body)
+}
+
+func TestValidateRequest_Multipart(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Method: "POST",
+		Path:   "/upload",
+		Body:   []byte("raw"),
+		Multipart: &extproctorv1.Multipart{
+			Boundary: "invalid boundary!",
+			Parts: []*extproctorv1.MultipartPart{
+				{Content: &extproctorv1.MultipartPart_Value{Value: []byte("value")}},
+				{Name: "doc", Headers: []*extproctorv1.HeaderEntry{{Key: "bad header", Value: "x"}}},
+			},
+		},
+	}
+
+	err := ValidateRequest(req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "body and multipart are exclusive")
+	assert.Contains(t, err.Error(), `multipart boundary "invalid boundary!" is invalid`)
+	assert.Contains(t, err.Error(), "multipart part 0: name is required for form-data")
+	assert.Contains(t, err.Error(), `multipart part 1 header "bad header"`)
+}
@@ -0,0 +1,17 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+//go:build !windows
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// dialPipe fails: named pipes are only supported on Windows.
+func dialPipe(context.Context, string) (net.Conn, error) {
+	return nil, errors.New("named pipes are only supported on Windows")
+}
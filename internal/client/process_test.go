@@ -0,0 +1,595 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	extprocfilterv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// fakeProcessor is an in-memory ExtProc service answering each request with
+// the response returned by handle, or not at all without handle.
+type fakeProcessor struct {
+	extprocv3.UnimplementedExternalProcessorServer
+	handle   func(*extprocv3.ProcessingRequest) *extprocv3.ProcessingResponse
+	received []*extprocv3.ProcessingRequest
+}
+
+func (f *fakeProcessor) Process(stream extprocv3.ExternalProcessor_ProcessServer) error {
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		f.received = append(f.received, req)
+		if f.handle == nil {
+			continue
+		}
+		if err := stream.Send(f.handle(req)); err != nil {
+			return err
+		}
+	}
+}
+
+// newTestClient starts the fake processor on an in-memory listener and
+// returns a client connected to it.
+func newTestClient(t *testing.T, srv *fakeProcessor) *Client {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	extprocv3.RegisterExternalProcessorServer(grpcServer, srv)
+	go func() { _ = grpcServer.Serve(lis) }()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	c := &Client{
+		conn:   conn,
+		client: extprocv3.NewExternalProcessorClient(conn),
+		target: "bufnet",
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c
+}
+
+// denyRequestHeaders answers request headers with a 403 immediate response
+// and every other phase with an empty response.
+func denyRequestHeaders(req *extprocv3.ProcessingRequest) *extprocv3.ProcessingResponse {
+	if req.GetRequestHeaders() != nil {
+		return &extprocv3.ProcessingResponse{
+			Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+				ImmediateResponse: &extprocv3.ImmediateResponse{
+					Status: &typev3.HttpStatus{Code: typev3.StatusCode_Forbidden},
+				},
+			},
+		}
+	}
+	return &extprocv3.ProcessingResponse{}
+}
+
+func TestPlannedPhases(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Body:                    []byte("body"),
+		ProcessRequestBody:      true,
+		ProcessResponseHeaders:  true,
+		ProcessResponseTrailers: true,
+	}
+
+	var phases []extproctorv1.ProcessingPhase
+	for _, step := range plannedPhases(req, nil) {
+		phases = append(phases, step.phase)
+	}
+
+	assert.Equal(t, []extproctorv1.ProcessingPhase{
+		extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+		extproctorv1.ProcessingPhase_REQUEST_BODY,
+		extproctorv1.ProcessingPhase_RESPONSE_HEADERS,
+		extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
+	}, phases)
+}
+
+func TestProcess_ImmediateResponseSkipsPhases(t *testing.T) {
+	srv := &fakeProcessor{handle: denyRequestHeaders}
+	c := newTestClient(t, srv)
+
+	result, err := c.Process(context.Background(), &extproctorv1.HttpRequest{
+		Method:                 "POST",
+		Path:                   "/",
+		Body:                   []byte("body"),
+		ProcessRequestBody:     true,
+		ProcessResponseHeaders: true,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Responses, 1)
+	assert.Equal(t, extproctorv1.ProcessingPhase_REQUEST_HEADERS, result.Responses[0].Phase)
+	assert.Equal(t, []extproctorv1.ProcessingPhase{
+		extproctorv1.ProcessingPhase_REQUEST_BODY,
+		extproctorv1.ProcessingPhase_RESPONSE_HEADERS,
+	}, result.SkippedPhases)
+	assert.Len(t, srv.received, 1)
+}
+
+func TestProcess_ContinueAfterImmediate(t *testing.T) {
+	srv := &fakeProcessor{handle: denyRequestHeaders}
+	c := newTestClient(t, srv)
+
+	result, err := c.Process(context.Background(), &extproctorv1.HttpRequest{
+		Method:                 "POST",
+		Path:                   "/",
+		Body:                   []byte("body"),
+		ProcessRequestBody:     true,
+		ProcessResponseHeaders: true,
+		ContinueAfterImmediate: true,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Responses, 3)
+	assert.Equal(t, extproctorv1.ProcessingPhase_RESPONSE_HEADERS, result.Responses[2].Phase)
+	assert.Empty(t, result.SkippedPhases)
+	assert.Len(t, srv.received, 3)
+}
+
+func TestProcess_ModeOverrideSuppressesPhases(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Method:                  "POST",
+		Path:                    "/",
+		Body:                    []byte("body"),
+		ProcessRequestBody:      true,
+		ProcessResponseHeaders:  true,
+		ProcessResponseTrailers: true,
+	}
+	overrideMode := func(r *extprocv3.ProcessingRequest) *extprocv3.ProcessingResponse {
+		if r.GetRequestHeaders() != nil {
+			return &extprocv3.ProcessingResponse{
+				Response: &extprocv3.ProcessingResponse_RequestHeaders{RequestHeaders: &extprocv3.HeadersResponse{}},
+				ModeOverride: &extprocfilterv3.ProcessingMode{
+					RequestBodyMode:     extprocfilterv3.ProcessingMode_NONE,
+					ResponseTrailerMode: extprocfilterv3.ProcessingMode_SKIP,
+				},
+			}
+		}
+		return &extprocv3.ProcessingResponse{}
+	}
+
+	t.Run("suppresses the disabled phases", func(t *testing.T) {
+		srv := &fakeProcessor{handle: overrideMode}
+		c := newTestClient(t, srv)
+
+		result, err := c.Process(context.Background(), req)
+		require.NoError(t, err)
+
+		var phases []extproctorv1.ProcessingPhase
+		for _, r := range result.Responses {
+			phases = append(phases, r.Phase)
+		}
+		assert.Equal(t, []extproctorv1.ProcessingPhase{
+			extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			extproctorv1.ProcessingPhase_RESPONSE_HEADERS,
+		}, phases)
+		assert.Equal(t, []extproctorv1.ProcessingPhase{
+			extproctorv1.ProcessingPhase_REQUEST_BODY,
+			extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
+		}, result.SuppressedPhases)
+		assert.Empty(t, result.SkippedPhases)
+		assert.Len(t, srv.received, 2)
+	})
+
+	t.Run("scripted sequences ignore overrides", func(t *testing.T) {
+		srv := &fakeProcessor{handle: overrideMode}
+		c := newTestClient(t, srv)
+
+		result, err := c.ProcessSequence(context.Background(), req, []extproctorv1.ProcessingPhase{
+			extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			extproctorv1.ProcessingPhase_REQUEST_BODY,
+		})
+		require.NoError(t, err)
+
+		assert.Len(t, result.Responses, 2)
+		assert.Empty(t, result.SuppressedPhases)
+	})
+}
+
+func TestProcess_ObservabilityMode(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Method:                 "POST",
+		Path:                   "/",
+		Body:                   []byte("body"),
+		ProcessRequestBody:     true,
+		ProcessResponseHeaders: true,
+		ObservabilityMode:      true,
+	}
+
+	t.Run("does not wait for responses", func(t *testing.T) {
+		srv := &fakeProcessor{}
+		c := newTestClient(t, srv)
+
+		result, err := c.Process(context.Background(), req)
+		require.NoError(t, err)
+
+		assert.Empty(t, result.Responses)
+		require.Len(t, srv.received, 3)
+		for _, r := range srv.received {
+			assert.True(t, r.GetObservabilityMode())
+		}
+	})
+
+	t.Run("records the responses sent anyway", func(t *testing.T) {
+		srv := &fakeProcessor{handle: denyRequestHeaders}
+		c := newTestClient(t, srv)
+
+		result, err := c.Process(context.Background(), req)
+		require.NoError(t, err)
+
+		// Immediate responses do not end the stream either
+		require.Len(t, result.Responses, 3)
+		assert.Equal(t, extproctorv1.ProcessingPhase_REQUEST_HEADERS, result.Responses[0].Phase)
+		assert.NotNil(t, result.Responses[0].Response.GetImmediateResponse())
+		assert.Equal(t, extproctorv1.ProcessingPhase_RESPONSE_HEADERS, result.Responses[2].Phase)
+		assert.Empty(t, result.SkippedPhases)
+	})
+}
+
+func TestProcess_RawHeaderValues(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Method:                  "GET",
+		Path:                    "/",
+		Headers:                 map[string]string{"x-plain": "a"},
+		RawHeaders:              map[string][]byte{"x-raw": {0xff, 0xfe}},
+		ProcessRequestTrailers:  true,
+		Trailers:                map[string]string{"x-trailer": "b"},
+		ProcessResponseHeaders:  true,
+		ProcessResponseTrailers: true,
+	}
+	srv := &fakeProcessor{handle: func(*extprocv3.ProcessingRequest) *extprocv3.ProcessingResponse {
+		return &extprocv3.ProcessingResponse{}
+	}}
+	c := newTestClient(t, srv)
+	c.rawHeaderValues = true
+
+	_, err := c.Process(context.Background(), req)
+	require.NoError(t, err)
+
+	require.Len(t, srv.received, 4)
+	raw := map[string]string{}
+	for _, h := range srv.received[0].GetRequestHeaders().GetHeaders().GetHeaders() {
+		raw[h.Key] = string(h.RawValue)
+	}
+	assert.Equal(t, map[string]string{":method": "GET", ":path": "/", "x-plain": "a", "x-raw": "\xff\xfe"}, raw)
+	assert.Equal(t, []byte("b"), srv.received[1].GetRequestTrailers().GetTrailers().GetHeaders()[0].RawValue)
+	assert.Equal(t, []byte("200"), srv.received[2].GetResponseHeaders().GetHeaders().GetHeaders()[0].RawValue)
+	assert.Equal(t, []byte("0"), srv.received[3].GetResponseTrailers().GetTrailers().GetHeaders()[0].RawValue)
+
+	// Without the option, only the raw headers of the request set raw_value
+	srv.received = nil
+	c.rawHeaderValues = false
+	_, err = c.Process(context.Background(), req)
+	require.NoError(t, err)
+	for _, h := range srv.received[0].GetRequestHeaders().GetHeaders().GetHeaders() {
+		if h.Key == "x-raw" {
+			assert.Equal(t, []byte{0xff, 0xfe}, h.RawValue)
+			assert.Empty(t, h.Value)
+		} else {
+			assert.Empty(t, h.RawValue, h.Key)
+		}
+	}
+}
+
+func TestRequestBodyChunks(t *testing.T) {
+	tests := []struct {
+		name string
+		size string
+		want []string
+	}{
+		{name: "unset", size: "", want: []string{"0123456789"}},
+		{name: "larger than body", size: "1KiB", want: []string{"0123456789"}},
+		{name: "even split", size: "5B", want: []string{"01234", "56789"}},
+		{name: "remainder", size: "4", want: []string{"0123", "4567", "89"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := requestBodyChunks(&extproctorv1.HttpRequest{
+				Body:          []byte("0123456789"),
+				BodyChunkSize: tt.size,
+			})
+
+			var got []string
+			for _, c := range chunks {
+				got = append(got, string(c))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestProcess_BodyChunks(t *testing.T) {
+	srv := &fakeProcessor{handle: func(*extprocv3.ProcessingRequest) *extprocv3.ProcessingResponse {
+		return &extprocv3.ProcessingResponse{}
+	}}
+	c := newTestClient(t, srv)
+
+	result, err := c.Process(context.Background(), &extproctorv1.HttpRequest{
+		Method:             "POST",
+		Path:               "/",
+		Body:               []byte("0123456789"),
+		ProcessRequestBody: true,
+		BodyChunkSize:      "4B",
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Responses, 4)
+	require.Len(t, srv.received, 4)
+	for i, want := range []string{"0123", "4567", "89"} {
+		body := srv.received[i+1].GetRequestBody()
+		require.NotNil(t, body)
+		assert.Equal(t, want, string(body.Body))
+		assert.Equal(t, i == 2, body.EndOfStream)
+		assert.Equal(t, extproctorv1.ProcessingPhase_REQUEST_BODY, result.Responses[i+1].Phase)
+		assert.Equal(t, i, result.Responses[i+1].Chunk)
+		assert.Equal(t, i == 2, result.Responses[i+1].LastChunk)
+	}
+}
+
+func TestProcess_BodyChunks_SkippedOnce(t *testing.T) {
+	srv := &fakeProcessor{handle: denyRequestHeaders}
+	c := newTestClient(t, srv)
+
+	result, err := c.Process(context.Background(), &extproctorv1.HttpRequest{
+		Method:             "POST",
+		Path:               "/",
+		Body:               []byte("0123456789"),
+		ProcessRequestBody: true,
+		BodyChunkSize:      "4B",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []extproctorv1.ProcessingPhase{extproctorv1.ProcessingPhase_REQUEST_BODY}, result.SkippedPhases)
+}
+
+func TestProcess_BodyChunks_LargeBody(t *testing.T) {
+	srv := &fakeProcessor{handle: func(*extprocv3.ProcessingRequest) *extprocv3.ProcessingResponse {
+		return &extprocv3.ProcessingResponse{}
+	}}
+	c := newTestClient(t, srv)
+
+	result, err := c.Process(context.Background(), &extproctorv1.HttpRequest{
+		Method:             "POST",
+		Path:               "/upload",
+		Body:               bytes.Repeat([]byte("x"), 1<<20),
+		ProcessRequestBody: true,
+		BodyChunkSize:      "64KiB",
+	})
+	require.NoError(t, err)
+
+	// One request headers exchange, then 16 body exchanges of 64 KiB
+	require.Len(t, result.Responses, 17)
+	for i, r := range result.Responses[1:] {
+		assert.Equal(t, extproctorv1.ProcessingPhase_REQUEST_BODY, r.Phase)
+		assert.Equal(t, i, r.Chunk)
+		assert.Len(t, srv.received[i+1].GetRequestBody().GetBody(), 64<<10)
+		assert.Equal(t, i == 15, srv.received[i+1].GetRequestBody().GetEndOfStream())
+	}
+}
+
+func TestProcess_BodyChunks_ImmediateResponseMidStream(t *testing.T) {
+	// The service rejects the body once it buffered 8 bytes
+	var buffered int
+	srv := &fakeProcessor{handle: func(req *extprocv3.ProcessingRequest) *extprocv3.ProcessingResponse {
+		buffered += len(req.GetRequestBody().GetBody())
+		if buffered >= 8 {
+			return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+				ImmediateResponse: &extprocv3.ImmediateResponse{Status: &typev3.HttpStatus{Code: typev3.StatusCode_PayloadTooLarge}},
+			}}
+		}
+		return &extprocv3.ProcessingResponse{}
+	}}
+	c := newTestClient(t, srv)
+
+	result, err := c.Process(context.Background(), &extproctorv1.HttpRequest{
+		Method:                 "POST",
+		Path:                   "/",
+		Body:                   []byte("0123456789abcdef"),
+		ProcessRequestBody:     true,
+		ProcessResponseHeaders: true,
+		BodyChunkSize:          "4B",
+	})
+	require.NoError(t, err)
+
+	// The last two chunks and the response headers are never sent
+	require.Len(t, srv.received, 3)
+	require.Len(t, result.Responses, 3)
+	assert.Equal(t, 1, result.Responses[2].Chunk)
+	assert.NotNil(t, result.Responses[2].Response.GetImmediateResponse())
+	assert.Equal(t, []extproctorv1.ProcessingPhase{
+		extproctorv1.ProcessingPhase_REQUEST_BODY,
+		extproctorv1.ProcessingPhase_RESPONSE_HEADERS,
+	}, result.SkippedPhases)
+}
+
+func TestProcess_InvalidBodyChunkSize(t *testing.T) {
+	c := &Client{}
+
+	_, err := c.Process(context.Background(), &extproctorv1.HttpRequest{BodyChunkSize: "64 bytes"})
+	assert.ErrorContains(t, err, "invalid body_chunk_size")
+}
+
+func TestProcessSequence(t *testing.T) {
+	srv := &fakeProcessor{handle: func(*extprocv3.ProcessingRequest) *extprocv3.ProcessingResponse {
+		return &extprocv3.ProcessingResponse{}
+	}}
+	c := newTestClient(t, srv)
+
+	sequence := []extproctorv1.ProcessingPhase{
+		extproctorv1.ProcessingPhase_REQUEST_BODY,
+		extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+		extproctorv1.ProcessingPhase_REQUEST_BODY,
+		extproctorv1.ProcessingPhase_REQUEST_BODY,
+		extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+		extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
+	}
+	result, err := c.ProcessSequence(context.Background(), &extproctorv1.HttpRequest{
+		Method:        "POST",
+		Path:          "/",
+		Body:          []byte("0123456789"),
+		BodyChunkSize: "6B",
+	}, sequence)
+	require.NoError(t, err)
+
+	// The process_* flags are ignored
+	require.Len(t, srv.received, len(sequence))
+	for i, phase := range sequence {
+		assert.Equal(t, phase, result.Responses[i].Phase)
+	}
+
+	// Body entries send the chunks in order, then the last one again
+	for i, want := range map[int]string{0: "012345", 2: "6789", 3: "6789"} {
+		body := srv.received[i].GetRequestBody()
+		require.NotNil(t, body)
+		assert.Equal(t, want, string(body.Body))
+		assert.Equal(t, i != 0, body.EndOfStream)
+		assert.Equal(t, i != 0, result.Responses[i].LastChunk)
+	}
+	assert.NotNil(t, srv.received[1].GetRequestHeaders())
+	assert.NotNil(t, srv.received[4].GetRequestHeaders())
+	assert.NotNil(t, srv.received[5].GetResponseTrailers())
+}
+
+func TestProcessSequence_ImmediateResponseSkipsPhases(t *testing.T) {
+	srv := &fakeProcessor{handle: denyRequestHeaders}
+	c := newTestClient(t, srv)
+
+	result, err := c.ProcessSequence(context.Background(), &extproctorv1.HttpRequest{Method: "GET", Path: "/"}, []extproctorv1.ProcessingPhase{
+		extproctorv1.ProcessingPhase_RESPONSE_HEADERS,
+		extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+		extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Responses, 2)
+	assert.Equal(t, []extproctorv1.ProcessingPhase{extproctorv1.ProcessingPhase_REQUEST_HEADERS}, result.SkippedPhases)
+}
+
+func TestProcessSequence_Invalid(t *testing.T) {
+	c := &Client{}
+	req := &extproctorv1.HttpRequest{Method: "GET", Path: "/"}
+
+	_, err := c.ProcessSequence(context.Background(), req, nil)
+	assert.EqualError(t, err, "empty phase sequence")
+
+	_, err = c.ProcessSequence(context.Background(), req, []extproctorv1.ProcessingPhase{extproctorv1.ProcessingPhase_PROCESSING_PHASE_UNSPECIFIED})
+	assert.EqualError(t, err, "phase sequence has an unspecified phase")
+}
+
+func TestProcessExchange(t *testing.T) {
+	srv := &fakeProcessor{handle: func(*extprocv3.ProcessingRequest) *extprocv3.ProcessingResponse {
+		return &extprocv3.ProcessingResponse{}
+	}}
+	c := newTestClient(t, srv)
+
+	result, err := c.ProcessExchange(context.Background(), &extproctorv1.HttpRequest{Method: "GET", Path: "/users/42"}, &extproctorv1.HttpResponse{
+		Status:                  404,
+		Headers:                 []*extproctorv1.HeaderEntry{{Key: "content-type", Value: "text/plain"}},
+		Body:                    []byte("not found"),
+		Trailers:                []*extproctorv1.HeaderEntry{{Key: "x-checksum", Value: "abc"}},
+		ProcessResponseBody:     true,
+		ProcessResponseTrailers: true,
+	}, nil)
+	require.NoError(t, err)
+
+	var phases []extproctorv1.ProcessingPhase
+	for _, r := range result.Responses {
+		phases = append(phases, r.Phase)
+	}
+	assert.Equal(t, []extproctorv1.ProcessingPhase{
+		extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+		extproctorv1.ProcessingPhase_RESPONSE_HEADERS,
+		extproctorv1.ProcessingPhase_RESPONSE_BODY,
+		extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
+	}, phases)
+
+	require.Len(t, srv.received, 4)
+	headers := srv.received[1].GetResponseHeaders()
+	assert.Equal(t, ":status", headers.GetHeaders().GetHeaders()[0].GetKey())
+	assert.Equal(t, "404", headers.GetHeaders().GetHeaders()[0].GetValue())
+	assert.Equal(t, "text/plain", headers.GetHeaders().GetHeaders()[1].GetValue())
+	assert.False(t, headers.GetEndOfStream())
+	assert.Equal(t, []byte("not found"), srv.received[2].GetResponseBody().GetBody())
+	assert.False(t, srv.received[2].GetResponseBody().GetEndOfStream())
+	assert.Equal(t, "x-checksum", srv.received[3].GetResponseTrailers().GetTrailers().GetHeaders()[0].GetKey())
+}
+
+func TestProcessExchange_ImmediateResponseInResponsePhase(t *testing.T) {
+	srv := &fakeProcessor{handle: func(req *extprocv3.ProcessingRequest) *extprocv3.ProcessingResponse {
+		if req.GetResponseHeaders() != nil {
+			return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+				ImmediateResponse: &extprocv3.ImmediateResponse{Status: &typev3.HttpStatus{Code: typev3.StatusCode_BadGateway}},
+			}}
+		}
+		return &extprocv3.ProcessingResponse{}
+	}}
+	c := newTestClient(t, srv)
+
+	result, err := c.ProcessExchange(context.Background(), &extproctorv1.HttpRequest{Method: "GET", Path: "/"}, &extproctorv1.HttpResponse{
+		Status:              500,
+		Body:                []byte("oops"),
+		ProcessResponseBody: true,
+	}, nil)
+	require.NoError(t, err)
+
+	require.Len(t, result.Responses, 2)
+	assert.Equal(t, extproctorv1.ProcessingPhase_RESPONSE_HEADERS, result.Responses[1].Phase)
+	assert.Equal(t, []extproctorv1.ProcessingPhase{extproctorv1.ProcessingPhase_RESPONSE_BODY}, result.SkippedPhases)
+	assert.Len(t, srv.received, 2)
+}
+
+func TestProcessExchange_InvalidResponse(t *testing.T) {
+	c := newTestClient(t, &fakeProcessor{})
+
+	_, err := c.ProcessExchange(context.Background(), &extproctorv1.HttpRequest{Method: "GET", Path: "/"}, &extproctorv1.HttpResponse{
+		Status:  42,
+		Headers: []*extproctorv1.HeaderEntry{{Key: ":status", Value: "200"}},
+	}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid response: :status 42 is not a valid HTTP status code (100-599)")
+	assert.Contains(t, err.Error(), `response header ":status": pseudo-headers`)
+}
+
+func TestProcessExchange_ConflictingTrailers(t *testing.T) {
+	c := newTestClient(t, &fakeProcessor{})
+
+	_, err := c.ProcessExchange(context.Background(), &extproctorv1.HttpRequest{
+		Method:                 "GET",
+		Path:                   "/",
+		ResponseTrailerEntries: []*extproctorv1.HeaderEntry{{Key: "x-checksum", Value: "abc123"}},
+	}, &extproctorv1.HttpResponse{
+		Trailers: []*extproctorv1.HeaderEntry{{Key: "grpc-status", Value: "0"}},
+	}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid response: trailers and the request response_trailers or response_trailer_entries are mutually exclusive")
+}
@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// startTCPProcessor starts an ExtProc service on a local TCP port and returns
+// its address.
+func startTCPProcessor(t *testing.T) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	extprocv3.RegisterExternalProcessorServer(grpcServer, &metadataProcessor{metadata: make(chan metadata.MD, 16)})
+	go func() { _ = grpcServer.Serve(lis) }()
+	t.Cleanup(grpcServer.Stop)
+
+	return lis.Addr().String()
+}
+
+// startProxy serves the connections of a proxy with handle, which returns
+// the address to tunnel to, or an empty string to refuse.
+func startProxy(t *testing.T, handle func(conn net.Conn, r *bufio.Reader) string) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = lis.Close() })
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				addr := handle(conn, r)
+				if addr == "" {
+					return
+				}
+				upstream, err := net.Dial("tcp", addr)
+				if err != nil {
+					return
+				}
+				defer upstream.Close()
+				go func() { _, _ = io.Copy(upstream, r) }()
+				_, _ = io.Copy(conn, upstream)
+			}()
+		}
+	}()
+
+	return lis.Addr().String()
+}
+
+func processThrough(t *testing.T, target, proxyURL string) error {
+	t.Helper()
+
+	c, err := New(WithTarget(target), WithProxy(proxyURL))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	_, err = c.Process(context.Background(), &extproctorv1.HttpRequest{Method: "GET", Path: "/"})
+	return err
+}
+
+func TestWithProxy_HTTPConnect(t *testing.T) {
+	target := startTCPProcessor(t)
+	connects := make(chan string, 16)
+	proxyAddr := startProxy(t, func(conn net.Conn, r *bufio.Reader) string {
+		req, err := http.ReadRequest(r)
+		if err != nil {
+			return ""
+		}
+		if req.Header.Get("Proxy-Authorization") != "Basic YWxpY2U6czNjcjN0" {
+			_, _ = io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+			return ""
+		}
+		connects <- req.Method + " " + req.Host
+		_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		return req.Host
+	})
+
+	require.NoError(t, processThrough(t, target, "http://alice:s3cr3t@"+proxyAddr))
+	assert.Equal(t, "CONNECT "+target, <-connects)
+
+	err := processThrough(t, target, "http://alice:wrong@"+proxyAddr)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "proxy refused CONNECT to "+target+": 407 Proxy Authentication Required")
+}
+
+func TestWithProxy_SOCKS5(t *testing.T) {
+	target := startTCPProcessor(t)
+	users := make(chan string, 16)
+	proxyAddr := startProxy(t, func(conn net.Conn, r *bufio.Reader) string {
+		// Greeting: version, methods; select username/password
+		header := make([]byte, 2)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return ""
+		}
+		if _, err := io.ReadFull(r, make([]byte, header[1])); err != nil {
+			return ""
+		}
+		_, _ = conn.Write([]byte{5, 2})
+
+		// Username/password authentication
+		user := readSOCKSString(r, 1)
+		password := readSOCKSString(r, 0)
+		users <- user + ":" + password
+		_, _ = conn.Write([]byte{1, 0})
+
+		// CONNECT request with an IPv4 address
+		request := make([]byte, 10)
+		if _, err := io.ReadFull(r, request); err != nil || request[3] != 1 {
+			return ""
+		}
+		_, _ = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+		ip := net.IP(request[4:8])
+		return net.JoinHostPort(ip.String(), strconv.Itoa(int(binary.BigEndian.Uint16(request[8:]))))
+	})
+
+	require.NoError(t, processThrough(t, target, "socks5://alice:s3cr3t@"+proxyAddr))
+	assert.Equal(t, "alice:s3cr3t", <-users)
+}
+
+// readSOCKSString reads a length-prefixed string, after skipping bytes.
+func readSOCKSString(r *bufio.Reader, skip int) string {
+	if _, err := r.Discard(skip); err != nil {
+		return ""
+	}
+	n, err := r.ReadByte()
+	if err != nil {
+		return ""
+	}
+	b := make([]byte, n)
+	_, _ = io.ReadFull(r, b)
+	return string(b)
+}
+
+func TestWithProxy_Invalid(t *testing.T) {
+	_, err := New(WithProxy("ftp://proxy:21"))
+	assert.EqualError(t, err, `unsupported proxy scheme "ftp": expected http or socks5`)
+
+	_, err = New(WithProxy("http://"))
+	assert.EqualError(t, err, `invalid proxy URL "http://": host is required`)
+}
@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
+)
+
+// minReconnectBackoff is the delay before the first reconnection attempt.
+const minReconnectBackoff = 100 * time.Millisecond
+
+// WithReconnectBackoff makes the connection redial a lost target with an
+// exponential backoff starting at 100ms and bounded by the given delay,
+// instead of the default of gRPC (from 1s up to 2 minutes).
+func WithReconnectBackoff(maxDelay time.Duration) Option {
+	return func(c *clientConfig) {
+		c.reconnectBackoff = maxDelay
+	}
+}
+
+// connectParams returns the dial option of the reconnection backoff.
+func connectParams(maxDelay time.Duration) grpc.DialOption {
+	cfg := backoff.DefaultConfig
+	cfg.BaseDelay = min(minReconnectBackoff, maxDelay)
+	cfg.MaxDelay = maxDelay
+	return grpc.WithConnectParams(grpc.ConnectParams{Backoff: cfg})
+}
+
+// Ready reports whether the connection to the target is established.
+func (c *Client) Ready() bool {
+	return c.conn.GetState() == connectivity.Ready
+}
+
+// WaitReady connects to the target, and blocks until the connection is
+// established or the context ends.
+func (c *Client) WaitReady(ctx context.Context) error {
+	for {
+		state := c.conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.Idle:
+			c.conn.Connect()
+		case connectivity.Shutdown:
+			return errors.New("connection closed")
+		}
+		if !c.conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("connection %s: %w", state, ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestWaitReady(t *testing.T) {
+	// The target is down when the client connects
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	c, err := New(WithTarget(address), WithReconnectBackoff(200*time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = c.Health(context.Background(), "")
+	require.Error(t, err)
+	assert.False(t, c.Ready())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, c.WaitReady(ctx), context.DeadlineExceeded)
+
+	// The target restarts
+	lis, err = net.Listen("tcp", address)
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+	go func() { _ = grpcServer.Serve(lis) }()
+	t.Cleanup(grpcServer.Stop)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, c.WaitReady(ctx))
+	assert.True(t, c.Ready())
+
+	require.NoError(t, c.Close())
+	assert.EqualError(t, c.WaitReady(context.Background()), "connection closed")
+}
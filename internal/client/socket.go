@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"context"
+	"net"
+	"strings"
+)
+
+// socketTarget returns the gRPC target of a local socket path, and the dialer
+// it needs when gRPC cannot dial it itself:
+//   - "@name" is an abstract Unix socket (Linux);
+//   - "\\.\pipe\name" (or "//./pipe/name") is a named pipe (Windows);
+//   - any other path is a Unix domain socket.
+func socketTarget(path string) (string, func(context.Context, string) (net.Conn, error)) {
+	switch {
+	case strings.HasPrefix(path, "@"):
+		return "unix-abstract:" + path[1:], nil
+	case isNamedPipe(path):
+		return "passthrough:///" + path, func(ctx context.Context, _ string) (net.Conn, error) {
+			return dialPipe(ctx, path)
+		}
+	default:
+		return "unix://" + path, nil
+	}
+}
+
+// isNamedPipe reports whether a path names a Windows named pipe.
+func isNamedPipe(path string) bool {
+	return strings.HasPrefix(path, `\\.\pipe\`) || strings.HasPrefix(path, `//./pipe/`)
+}
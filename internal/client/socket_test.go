@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func TestSocketTarget(t *testing.T) {
+	tests := []struct {
+		path   string
+		target string
+		dialer bool
+	}{
+		{path: "/var/run/extproc.sock", target: "unix:///var/run/extproc.sock"},
+		{path: "@extproc", target: "unix-abstract:extproc"},
+		{path: `\\.\pipe\extproc`, target: `passthrough:///\\.\pipe\extproc`, dialer: true},
+		{path: "//./pipe/extproc", target: "passthrough://///./pipe/extproc", dialer: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			target, dialer := socketTarget(tt.path)
+			assert.Equal(t, tt.target, target)
+			assert.Equal(t, tt.dialer, dialer != nil)
+		})
+	}
+}
+
+func TestWithUnixSocket_Abstract(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("abstract sockets are only supported on Linux")
+	}
+
+	name := fmt.Sprintf("@extproctor-test-%d", os.Getpid())
+	lis, err := net.Listen("unix", name)
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	extprocv3.RegisterExternalProcessorServer(grpcServer, &metadataProcessor{metadata: make(chan metadata.MD, 1)})
+	go func() { _ = grpcServer.Serve(lis) }()
+	t.Cleanup(grpcServer.Stop)
+
+	c, err := New(WithUnixSocket(name))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	_, err = c.Process(context.Background(), &extproctorv1.HttpRequest{Method: "GET", Path: "/"})
+	assert.NoError(t, err)
+}
+
+func TestWithUnixSocket_NamedPipe(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("named pipes are supported on Windows")
+	}
+
+	_, err := dialPipe(context.Background(), `\\.\pipe\extproc`)
+	assert.EqualError(t, err, "named pipes are only supported on Windows")
+}
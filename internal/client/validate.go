@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// ValidateRequest checks that the request can be sent as a well-formed HTTP
+// request: legal :method, :path and :scheme pseudo-headers, no pseudo-header
+// in the headers, header, raw header and trailer names matching the RFC 9110
+// token syntax and values without CR, LF or NUL characters.
+func ValidateRequest(req *extproctorv1.HttpRequest) error {
+	var errs []error
+
+	method, path := requestLine(req)
+
+	switch {
+	case method == "":
+		errs = append(errs, errors.New(":method is required"))
+	case !isToken(method):
+		errs = append(errs, fmt.Errorf(":method %q is not a valid RFC 9110 token", method))
+	}
+
+	switch {
+	case path == "":
+		errs = append(errs, errors.New(":path is required"))
+	case path == "*":
+		if method != "OPTIONS" {
+			errs = append(errs, fmt.Errorf(`:path "*" is only valid for OPTIONS, not %s`, method))
+		}
+	case !strings.HasPrefix(path, "/"):
+		errs = append(errs, fmt.Errorf(":path %q must start with /", path))
+	case strings.ContainsFunc(path, func(r rune) bool { return r <= ' ' || r == 0x7f }):
+		errs = append(errs, fmt.Errorf(":path %q must not contain whitespace or control characters", path))
+	}
+
+	if req.Scheme != "" && !isScheme(req.Scheme) {
+		errs = append(errs, fmt.Errorf(":scheme %q is not a valid URI scheme", req.Scheme))
+	}
+
+	if invalidValue(req.Authority) {
+		errs = append(errs, fmt.Errorf(":authority %q must not contain CR, LF or NUL", req.Authority))
+	}
+
+	errs = append(errs, validateFields("header", req.Headers, req.HeaderEntries)...)
+	raw := make(map[string]string, len(req.RawHeaders))
+	for name, value := range req.RawHeaders {
+		raw[name] = string(value)
+	}
+	errs = append(errs, validateFields("raw header", raw, nil)...)
+	errs = append(errs, validateFields("trailer", req.Trailers, req.TrailerEntries)...)
+	errs = append(errs, validateFields("response trailer", req.ResponseTrailers, req.ResponseTrailerEntries)...)
+	errs = append(errs, validateMultipart(req)...)
+	errs = append(errs, validateGraphql(req)...)
+	errs = append(errs, validateGrpc(req)...)
+	errs = append(errs, validateWebsocket(req)...)
+	errs = append(errs, validateForwarding(req)...)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid request: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// ValidateResponse checks that the simulated upstream response of a request
+// can be sent as a well-formed HTTP response: a 1xx to 5xx status code, and
+// header and trailer names and values like ValidateRequest. Its trailers are
+// exclusive with the response trailers of the request. A nil response is
+// valid.
+func ValidateResponse(req *extproctorv1.HttpRequest, resp *extproctorv1.HttpResponse) error {
+	if resp == nil {
+		return nil
+	}
+
+	var errs []error
+
+	if len(resp.GetTrailers()) > 0 && (len(req.GetResponseTrailers()) > 0 || len(req.GetResponseTrailerEntries()) > 0) {
+		errs = append(errs, errors.New("trailers and the request response_trailers or response_trailer_entries are mutually exclusive"))
+	}
+
+	if status := resp.GetStatus(); status != 0 && (status < 100 || status > 599) {
+		errs = append(errs, fmt.Errorf(":status %d is not a valid HTTP status code (100-599)", status))
+	}
+	errs = append(errs, validateFields("response header", nil, resp.GetHeaders())...)
+	errs = append(errs, validateFields("response trailer", nil, resp.GetTrailers())...)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid response: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// validateFields checks the names and values of a header or trailer section.
+func validateFields(kind string, fields map[string]string, entries []*extproctorv1.HeaderEntry) []error {
+	var errs []error
+
+	check := func(name, value string) {
+		switch {
+		case strings.HasPrefix(name, ":"):
+			errs = append(errs, fmt.Errorf("%s %q: pseudo-headers are set by the request fields, not as %ss", kind, name, kind))
+		case !isToken(name):
+			errs = append(errs, fmt.Errorf("%s %q: name is not a valid RFC 9110 token", kind, name))
+		}
+		if invalidValue(value) {
+			errs = append(errs, fmt.Errorf("%s %q: value must not contain CR, LF or NUL", kind, name))
+		}
+	}
+
+	// Sort the map keys for a stable error message
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		check(name, fields[name])
+	}
+	for _, e := range entries {
+		check(e.Key, e.Value)
+	}
+
+	return errs
+}
+
+// isToken reports whether s matches the RFC 9110 token syntax.
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isTokenChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isTokenChar reports whether c is a RFC 9110 tchar.
+func isTokenChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	default:
+		return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+	}
+}
+
+// isScheme reports whether s matches the RFC 3986 scheme syntax.
+func isScheme(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case i > 0 && (c >= '0' && c <= '9' || c == '+' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
+
+// invalidValue reports whether a field value contains CR, LF or NUL, which
+// are never valid in HTTP field values.
+func invalidValue(v string) bool {
+	return strings.ContainsAny(v, "\r\n\x00")
+}
@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func TestValidateRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *extproctorv1.HttpRequest
+		wantErr []string
+	}{
+		{
+			name: "valid",
+			req: &extproctorv1.HttpRequest{
+				Method:    "GET",
+				Path:      "/api?q=1",
+				Scheme:    "https",
+				Authority: "example.com",
+				Headers:   map[string]string{"content-type": "application/json", "x-empty": ""},
+				Trailers:  map[string]string{"x-checksum": "abc"},
+			},
+		},
+		{
+			name: "asterisk form",
+			req:  &extproctorv1.HttpRequest{Method: "OPTIONS", Path: "*"},
+		},
+		{
+			name:    "missing method and path",
+			req:     &extproctorv1.HttpRequest{},
+			wantErr: []string{":method is required", ":path is required"},
+		},
+		{
+			name:    "invalid method",
+			req:     &extproctorv1.HttpRequest{Method: "GET /", Path: "/"},
+			wantErr: []string{`:method "GET /" is not a valid RFC 9110 token`},
+		},
+		{
+			name:    "relative path",
+			req:     &extproctorv1.HttpRequest{Method: "GET", Path: "api"},
+			wantErr: []string{`:path "api" must start with /`},
+		},
+		{
+			name:    "path with whitespace",
+			req:     &extproctorv1.HttpRequest{Method: "GET", Path: "/a b"},
+			wantErr: []string{`:path "/a b" must not contain whitespace or control characters`},
+		},
+		{
+			name:    "asterisk form outside OPTIONS",
+			req:     &extproctorv1.HttpRequest{Method: "GET", Path: "*"},
+			wantErr: []string{`:path "*" is only valid for OPTIONS, not GET`},
+		},
+		{
+			name:    "invalid scheme",
+			req:     &extproctorv1.HttpRequest{Method: "GET", Path: "/", Scheme: "1http"},
+			wantErr: []string{`:scheme "1http" is not a valid URI scheme`},
+		},
+		{
+			name: "pseudo-header in headers",
+			req: &extproctorv1.HttpRequest{
+				Method:  "GET",
+				Path:    "/",
+				Headers: map[string]string{":path": "/admin"},
+			},
+			wantErr: []string{`header ":path": pseudo-headers are set by the request fields, not as headers`},
+		},
+		{
+			name: "invalid header names and values",
+			req: &extproctorv1.HttpRequest{
+				Method:  "GET",
+				Path:    "/",
+				Headers: map[string]string{"x bad": "v", "x-inject": "a\r\nx-evil: 1"},
+			},
+			wantErr: []string{
+				`header "x bad": name is not a valid RFC 9110 token`,
+				`header "x-inject": value must not contain CR, LF or NUL`,
+			},
+		},
+		{
+			name: "invalid raw headers",
+			req: &extproctorv1.HttpRequest{
+				Method: "GET",
+				Path:   "/",
+				RawHeaders: map[string][]byte{
+					":authority": []byte("evil.example"),
+					"x bad":      []byte("v"),
+					"x-inject":   []byte("a\r\nx-evil: 1"),
+					"x-latin1":   {0xe9},
+				},
+			},
+			wantErr: []string{
+				`raw header ":authority": pseudo-headers are set by the request fields, not as raw headers`,
+				`raw header "x bad": name is not a valid RFC 9110 token`,
+				`raw header "x-inject": value must not contain CR, LF or NUL`,
+			},
+		},
+		{
+			name: "invalid trailer entries",
+			req: &extproctorv1.HttpRequest{
+				Method:                 "GET",
+				Path:                   "/",
+				TrailerEntries:         []*extproctorv1.HeaderEntry{{Key: "x(a)", Value: "1"}},
+				ResponseTrailerEntries: []*extproctorv1.HeaderEntry{{Key: ":status", Value: "200"}},
+			},
+			wantErr: []string{
+				`trailer "x(a)": name is not a valid RFC 9110 token`,
+				`response trailer ":status": pseudo-headers are set by the request fields`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRequest(tt.req)
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid request: ")
+			for _, want := range tt.wantErr {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestProcess_InvalidRequest(t *testing.T) {
+	c := &Client{}
+
+	_, err := c.Process(context.Background(), &extproctorv1.HttpRequest{
+		Method:  "GET",
+		Path:    "/",
+		Headers: map[string]string{":authority": "example.com"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pseudo-headers are set by the request fields")
+}
@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// DefaultWebsocketKey is the handshake key of WebSocket upgrades, the sample
+// key of RFC 6455.
+const DefaultWebsocketKey = "dGhlIHNhbXBsZSBub25jZQ=="
+
+// validateWebsocket checks the WebSocket upgrade of a request.
+func validateWebsocket(req *extproctorv1.HttpRequest) []error {
+	ws := req.Websocket
+	if ws == nil {
+		return nil
+	}
+
+	var errs []error
+	if len(req.Body) > 0 || req.Multipart != nil || req.Graphql != nil || req.Grpc != nil {
+		errs = append(errs, errors.New("websocket upgrades have no body"))
+	}
+	if req.Method != "GET" {
+		errs = append(errs, fmt.Errorf("websocket upgrades are sent with GET, not %s", req.Method))
+	}
+	if ws.Key != "" {
+		if key, err := base64.StdEncoding.DecodeString(ws.Key); err != nil || len(key) != 16 {
+			errs = append(errs, fmt.Errorf("websocket key %q must be 16 base64-encoded bytes", ws.Key))
+		}
+	}
+	for _, p := range ws.Protocols {
+		if !isToken(p) {
+			errs = append(errs, fmt.Errorf("websocket protocol %q is not a valid RFC 9110 token", p))
+		}
+	}
+	if invalidValue(ws.Origin) {
+		errs = append(errs, fmt.Errorf("websocket origin %q must not contain CR, LF or NUL", ws.Origin))
+	}
+
+	return errs
+}
+
+// websocketHeaders returns the headers of a WebSocket opening handshake.
+func websocketHeaders(ws *extproctorv1.WebsocketUpgrade) []*extproctorv1.HeaderEntry {
+	key := ws.Key
+	if key == "" {
+		key = DefaultWebsocketKey
+	}
+
+	headers := []*extproctorv1.HeaderEntry{
+		{Key: "connection", Value: "Upgrade"},
+		{Key: "upgrade", Value: "websocket"},
+		{Key: "sec-websocket-key", Value: key},
+		{Key: "sec-websocket-version", Value: "13"},
+	}
+	if len(ws.Protocols) > 0 {
+		headers = append(headers, &extproctorv1.HeaderEntry{Key: "sec-websocket-protocol", Value: strings.Join(ws.Protocols, ", ")})
+	}
+	if ws.Origin != "" {
+		headers = append(headers, &extproctorv1.HeaderEntry{Key: "origin", Value: ws.Origin})
+	}
+	return headers
+}
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func TestEncodeRequest_Websocket(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Method:        "GET",
+		Path:          "/chat",
+		HeaderEntries: []*extproctorv1.HeaderEntry{{Key: "Origin", Value: "https://app.example.com"}},
+		Websocket: &extproctorv1.WebsocketUpgrade{
+			Protocols: []string{"chat", "superchat"},
+			Origin:    "https://evil.example.com",
+		},
+	}
+	require.NoError(t, ValidateRequest(req))
+
+	encoded, err := encodeRequest(req)
+	require.NoError(t, err)
+
+	var headers [][2]string
+	for _, h := range encoded.HeaderEntries {
+		headers = append(headers, [2]string{h.Key, h.Value})
+	}
+	assert.Equal(t, [][2]string{
+		{"Origin", "https://app.example.com"},
+		{"connection", "Upgrade"},
+		{"upgrade", "websocket"},
+		{"sec-websocket-key", DefaultWebsocketKey},
+		{"sec-websocket-version", "13"},
+		{"sec-websocket-protocol", "chat, superchat"},
+	}, headers)
+}
+
+func TestValidateRequest_Websocket(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Method: "POST",
+		Path:   "/chat",
+		Body:   []byte("hello"),
+		Websocket: &extproctorv1.WebsocketUpgrade{
+			Key:       "short",
+			Protocols: []string{"bad protocol"},
+		},
+	}
+
+	err := ValidateRequest(req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "websocket upgrades have no body")
+	assert.Contains(t, err.Error(), "websocket upgrades are sent with GET, not POST")
+	assert.Contains(t, err.Error(), `websocket key "short" must be 16 base64-encoded bytes`)
+	assert.Contains(t, err.Error(), `websocket protocol "bad protocol" is not a valid RFC 9110 token`)
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+const (
+	// maxBodyDiffBytes is the body size above which differences only show a
+	// window around the first differing byte, with the body size and digest,
+	// instead of copying the whole bodies in the report.
+	maxBodyDiffBytes = 4096
+
+	// bodyDiffContext is the number of bytes shown on each side of the first
+	// differing byte of large bodies.
+	bodyDiffContext = 32
+)
+
+// compareBody compares an expected body against the actual one without
+// copying them, and returns a difference when they differ.
+func compareBody(phase extproctorv1.ProcessingPhase, path string, expected, actual []byte) []Difference {
+	if bytes.Equal(expected, actual) {
+		return nil
+	}
+
+	offset := commonPrefixLen(expected, actual)
+	return []Difference{{
+		Phase:    phase,
+		Path:     path,
+		Expected: describeBody(expected, offset),
+		Actual:   describeBody(actual, offset),
+	}}
+}
+
+// describeBody renders a body for a difference. Bodies up to maxBodyDiffBytes
+// are rendered whole; larger ones as their size, SHA-256 digest prefix and
+// the bytes around the given offset.
+func describeBody(body []byte, offset int) string {
+	if len(body) <= maxBodyDiffBytes {
+		return string(body)
+	}
+
+	start := max(offset-bodyDiffContext, 0)
+	end := min(offset+bodyDiffContext, len(body))
+	digest := sha256.Sum256(body)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<%d bytes, sha256:%x> at offset %d: ", len(body), digest[:8], offset)
+	if start > 0 {
+		sb.WriteString("…")
+	}
+	sb.Write(body[start:end])
+	if end < len(body) {
+		sb.WriteString("…")
+	}
+	return sb.String()
+}
+
+// commonPrefixLen returns the length of the common prefix of two byte slices,
+// the offset of their first difference.
+func commonPrefixLen(a, b []byte) int {
+	n := min(len(a), len(b))
+	for i := range n {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func TestCompareBody_Small(t *testing.T) {
+	assert.Nil(t, compareBody(extproctorv1.ProcessingPhase_REQUEST_BODY, "p", []byte("same"), []byte("same")))
+
+	diffs := compareBody(extproctorv1.ProcessingPhase_REQUEST_BODY, "p", []byte("expected"), []byte("actual"))
+	if assert.Len(t, diffs, 1) {
+		assert.Equal(t, "expected", diffs[0].Expected)
+		assert.Equal(t, "actual", diffs[0].Actual)
+	}
+}
+
+func TestCompareBody_LargeWindow(t *testing.T) {
+	expected := bytes.Repeat([]byte("a"), 1<<20)
+	actual := bytes.Clone(expected)
+	actual[500000] = 'b'
+
+	diffs := compareBody(extproctorv1.ProcessingPhase_REQUEST_BODY, "body.body_mutation.body", expected, actual)
+	if assert.Len(t, diffs, 1) {
+		window := strings.Repeat("a", 32)
+		assert.Regexp(t, `^<1048576 bytes, sha256:[0-9a-f]{16}> at offset 500000: …`+window+window+`…$`, diffs[0].Expected)
+		assert.Regexp(t, `^<1048576 bytes, sha256:[0-9a-f]{16}> at offset 500000: …`+window+`b`+window[1:]+`…$`, diffs[0].Actual)
+		assert.NotEqual(t, diffs[0].Expected[:40], diffs[0].Actual[:40])
+	}
+}
+
+func TestCompareBody_LargePrefix(t *testing.T) {
+	expected := bytes.Repeat([]byte("x"), 5000)
+
+	diffs := compareBody(extproctorv1.ProcessingPhase_RESPONSE_BODY, "p", expected, expected[:10])
+	if assert.Len(t, diffs, 1) {
+		assert.Regexp(t, `^<5000 bytes, sha256:[0-9a-f]{16}> at offset 10: x{42}…$`, diffs[0].Expected)
+		assert.Equal(t, "xxxxxxxxxx", diffs[0].Actual)
+	}
+}
@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"fmt"
+
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+)
+
+// appliesTo reports whether an expectation applies to a response: the phase
+// must match or be covered by the phase wildcard of the expectation, and the
+// body chunk when the expectation selects one.
+func appliesTo(exp *extproctorv1.ExtProcExpectation, resp *client.PhaseResponse) bool {
+	if !PhaseMatches(exp.Phase, resp.Phase) {
+		return false
+	}
+
+	switch chunk := exp.Chunk; {
+	case chunk == nil:
+		return true
+	case chunk.Last:
+		return resp.LastChunk
+	default:
+		return resp.Chunk == int(chunk.Index)
+	}
+}
+
+// sentChunks returns the number of body chunks of a phase sent to the
+// ExtProc service.
+func sentChunks(phase extproctorv1.ProcessingPhase, result *client.ProcessingResult) int {
+	chunks := 0
+	for _, resp := range result.Responses {
+		if resp.Phase == phase {
+			chunks = max(chunks, resp.Chunk+1)
+		}
+	}
+	return chunks
+}
+
+// chunkName returns the name of a selected body chunk.
+func chunkName(chunk *extproctorv1.BodyChunk) string {
+	if chunk.Last {
+		return "last chunk"
+	}
+	return fmt.Sprintf("chunk %d", chunk.Index)
+}
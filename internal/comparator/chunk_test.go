@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+)
+
+// chunkedBodyResult returns the responses to a request body sent in three
+// chunks, the final one replaced by the filter.
+func chunkedBodyResult() *client.ProcessingResult {
+	result := &client.ProcessingResult{}
+	for i := range 3 {
+		common := &extprocv3.CommonResponse{}
+		if i == 2 {
+			common.BodyMutation = &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: []byte("replaced")}}
+		}
+		result.Responses = append(result.Responses, &client.PhaseResponse{
+			Phase: extproctorv1.ProcessingPhase_REQUEST_BODY,
+			Response: &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestBody{
+				RequestBody: &extprocv3.BodyResponse{Response: common},
+			}},
+			Chunk:     i,
+			LastChunk: i == 2,
+		})
+	}
+	return result
+}
+
+// bodyChunkExpectation expects the filter to replace the body of a chunk, or
+// to let it continue unchanged when body is nil.
+func bodyChunkExpectation(chunk *extproctorv1.BodyChunk, body []byte) *extproctorv1.ExtProcExpectation {
+	return &extproctorv1.ExtProcExpectation{
+		Phase: extproctorv1.ProcessingPhase_REQUEST_BODY,
+		Response: &extproctorv1.ExtProcExpectation_BodyResponse{
+			BodyResponse: &extproctorv1.BodyExpectation{Body: body},
+		},
+		Chunk: chunk,
+	}
+}
+
+func TestComparator_Compare_BodyChunks(t *testing.T) {
+	exps := []*extproctorv1.ExtProcExpectation{
+		bodyChunkExpectation(&extproctorv1.BodyChunk{Index: 0}, nil),
+		bodyChunkExpectation(&extproctorv1.BodyChunk{Last: true}, []byte("replaced")),
+		bodyChunkExpectation(&extproctorv1.BodyChunk{Index: 1}, nil),
+	}
+
+	cr := New().Compare(exps, chunkedBodyResult())
+	assert.True(t, cr.Passed)
+	require.Len(t, cr.Matched, 3)
+	assert.Equal(t, 0, cr.Matched[0].Response.Chunk)
+	assert.Equal(t, 2, cr.Matched[1].Response.Chunk)
+	assert.Equal(t, 1, cr.Matched[2].Response.Chunk)
+}
+
+func TestComparator_Compare_BodyChunks_WrongChunk(t *testing.T) {
+	// The replacement happens on the final chunk, not the first one
+	exp := bodyChunkExpectation(&extproctorv1.BodyChunk{Index: 0}, []byte("replaced"))
+
+	cr := New().Compare([]*extproctorv1.ExtProcExpectation{exp}, chunkedBodyResult())
+	assert.False(t, cr.Passed)
+	require.Len(t, cr.Differences, 1)
+	assert.Equal(t, "body.body_mutation", cr.Differences[0].Path)
+	assert.NotContains(t, cr.UnmatchedReasons, exp)
+}
+
+func TestComparator_Compare_BodyChunks_NeverReached(t *testing.T) {
+	exp := bodyChunkExpectation(&extproctorv1.BodyChunk{Index: 5}, nil)
+
+	cr := New().Compare([]*extproctorv1.ExtProcExpectation{exp}, chunkedBodyResult())
+	assert.False(t, cr.Passed)
+	assert.Empty(t, cr.Differences)
+	assert.Equal(t, "chunk 5 of phase REQUEST_BODY never reached: the body was sent in 3 chunk(s)", cr.UnmatchedReasons[exp])
+
+	// A stream ended early never sends the final chunk
+	result := chunkedBodyResult()
+	result.Responses = result.Responses[:1]
+	result.SkippedPhases = []extproctorv1.ProcessingPhase{extproctorv1.ProcessingPhase_REQUEST_BODY}
+	last := bodyChunkExpectation(&extproctorv1.BodyChunk{Last: true}, nil)
+
+	cr = New().Compare([]*extproctorv1.ExtProcExpectation{last}, result)
+	assert.Equal(t, "last chunk of phase REQUEST_BODY never reached: stream ended", cr.UnmatchedReasons[last])
+}
@@ -5,12 +5,14 @@ package comparator
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
 	"zntr.io/extproctor/internal/client"
+	"zntr.io/extproctor/internal/compression"
 )
 
 // getHeaderValue extracts the value from a HeaderValue, supporting both
@@ -36,6 +38,10 @@ type ComparisonResult struct {
 	Matched     []*MatchedExpectation
 	Unmatched   []*extproctorv1.ExtProcExpectation
 	Unexpected  []*client.PhaseResponse
+
+	// UnmatchedReasons explains why an unmatched expectation could not be
+	// matched when its phase was never sent to the ExtProc service.
+	UnmatchedReasons map[*extproctorv1.ExtProcExpectation]string
 }
 
 // MatchedExpectation represents an expectation that was matched.
@@ -50,14 +56,51 @@ type Difference struct {
 	Path     string
 	Expected string
 	Actual   string
+
+	// Group is the assertion group of the expectation the difference comes
+	// from, empty for ungrouped expectations.
+	Group string
 }
 
+// Interface compares the expectations of a test case against the responses
+// of the ExtProc service. Comparator is the default implementation.
+type Interface interface {
+	Compare(expectations []*extproctorv1.ExtProcExpectation, result *client.ProcessingResult) *ComparisonResult
+}
+
+var _ Interface = (*Comparator)(nil)
+
+// Matcher is an additional check of an expectation against a response of
+// its phase, returning the differences it found. Matchers run after the
+// built-in comparisons and their differences prevent the match likewise.
+type Matcher func(exp *extproctorv1.ExtProcExpectation, resp *extprocv3.ProcessingResponse) []Difference
+
 // Comparator compares expected expectations against actual responses.
-type Comparator struct{}
+type Comparator struct {
+	matchers []Matcher
+
+	// headerValues relaxes the header value comparisons of the expectation
+	// being compared.
+	headerValues *extproctorv1.HeaderValueComparison
+}
+
+// Option configures a Comparator.
+type Option func(*Comparator)
+
+// WithMatcher registers a matcher run on every expectation compared.
+func WithMatcher(m Matcher) Option {
+	return func(c *Comparator) {
+		c.matchers = append(c.matchers, m)
+	}
+}
 
 // New creates a new comparator.
-func New() *Comparator {
-	return &Comparator{}
+func New(opts ...Option) *Comparator {
+	c := &Comparator{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // Compare compares expectations against actual responses using unordered matching.
@@ -83,8 +126,22 @@ func (c *Comparator) Compare(expectations []*extproctorv1.ExtProcExpectation, re
 	// Track which responses have been matched
 	matchedResponses := make(map[int]bool)
 
-	// Try to match each expectation with a response
-	for _, exp := range expectations {
+	// Try to match each expectation with a response, groups at the position
+	// of their first member
+	comparedGroups := make(map[string]bool)
+	for _, exp := range matchOrder(expectations) {
+		if exp.Group != "" {
+			if !comparedGroups[exp.Group] {
+				comparedGroups[exp.Group] = true
+				c.compareGroup(cr, groupMembers(expectations, exp.Group), result, matchedResponses)
+			}
+			continue
+		}
+		if exp.GetNoResponse() {
+			cr.compareNoResponse(exp, result)
+			continue
+		}
+
 		matched := false
 		var bestDiffs []Difference
 
@@ -94,8 +151,8 @@ func (c *Comparator) Compare(expectations []*extproctorv1.ExtProcExpectation, re
 				continue
 			}
 
-			// Phase must match
-			if resp.Phase != exp.Phase {
+			// Phase and body chunk must match
+			if !appliesTo(exp, resp) {
 				continue
 			}
 
@@ -119,8 +176,7 @@ func (c *Comparator) Compare(expectations []*extproctorv1.ExtProcExpectation, re
 		}
 
 		if !matched {
-			cr.Unmatched = append(cr.Unmatched, exp)
-			cr.Passed = false
+			cr.addUnmatched(exp, explainUnmatched(exp, result))
 			// Only record differences from the best match attempt
 			if bestDiffs != nil {
 				cr.Differences = append(cr.Differences, bestDiffs...)
@@ -138,10 +194,98 @@ func (c *Comparator) Compare(expectations []*extproctorv1.ExtProcExpectation, re
 	return cr
 }
 
+// addUnmatched records an unmatched expectation, with the reason it could not
+// be matched when not empty.
+func (cr *ComparisonResult) addUnmatched(exp *extproctorv1.ExtProcExpectation, reason string) {
+	cr.Unmatched = append(cr.Unmatched, exp)
+	cr.Passed = false
+	if reason != "" {
+		if cr.UnmatchedReasons == nil {
+			cr.UnmatchedReasons = make(map[*extproctorv1.ExtProcExpectation]string)
+		}
+		cr.UnmatchedReasons[exp] = reason
+	}
+}
+
+// explainUnmatched returns why the phase (or body chunk) of an unmatched
+// expectation never reached the ExtProc service, or an empty string when it
+// was sent.
+func explainUnmatched(exp *extproctorv1.ExtProcExpectation, result *client.ProcessingResult) string {
+	for _, resp := range result.Responses {
+		if appliesTo(exp, resp) {
+			return ""
+		}
+	}
+
+	subject := "phase " + phaseName(exp.Phase)
+	if exp.Chunk != nil {
+		subject = chunkName(exp.Chunk) + " of " + subject
+	}
+
+	if slices.Contains(result.SkippedPhases, exp.Phase) {
+		reason := subject + " never reached: stream ended"
+		for _, resp := range result.Responses {
+			if imm := resp.Response.GetImmediateResponse(); imm != nil {
+				reason += fmt.Sprintf(" at %s", phaseName(resp.Phase))
+				if imm.Status != nil {
+					reason += fmt.Sprintf(" with %d", imm.Status.Code)
+				}
+				break
+			}
+		}
+		return reason
+	}
+
+	if slices.Contains(result.SuppressedPhases, exp.Phase) {
+		reason := subject + " never reached: disabled by a mode_override"
+		for _, resp := range result.Responses {
+			if resp.Response.GetModeOverride() != nil {
+				reason += fmt.Sprintf(" at %s", phaseName(resp.Phase))
+				break
+			}
+		}
+		return reason
+	}
+
+	if chunks := sentChunks(exp.Phase, result); chunks > 0 {
+		return fmt.Sprintf("%s never reached: the body was sent in %d chunk(s)", subject, chunks)
+	}
+
+	if flag := phaseFlag(exp.Phase); flag != "" {
+		return fmt.Sprintf("%s never reached: not sent by the request (%s)", subject, flag)
+	}
+
+	return subject + " never reached"
+}
+
+// phaseFlag returns the request setting controlling whether a phase is sent.
+func phaseFlag(phase extproctorv1.ProcessingPhase) string {
+	switch phase {
+	case extproctorv1.ProcessingPhase_REQUEST_BODY:
+		return "process_request_body with a non-empty body"
+	case extproctorv1.ProcessingPhase_REQUEST_TRAILERS:
+		return "process_request_trailers with non-empty trailers"
+	case extproctorv1.ProcessingPhase_RESPONSE_HEADERS:
+		return "process_response_headers"
+	case extproctorv1.ProcessingPhase_RESPONSE_BODY:
+		return "process_response_body"
+	case extproctorv1.ProcessingPhase_RESPONSE_TRAILERS:
+		return "process_response_trailers"
+	default:
+		return ""
+	}
+}
+
 // compareExpectation compares a single expectation against a response.
 func (c *Comparator) compareExpectation(exp *extproctorv1.ExtProcExpectation, resp *extprocv3.ProcessingResponse) []Difference {
 	var diffs []Difference
 
+	if exp.HeaderValues != nil {
+		ec := *c
+		ec.headerValues = exp.HeaderValues
+		c = &ec
+	}
+
 	switch r := exp.Response.(type) {
 	case *extproctorv1.ExtProcExpectation_HeadersResponse:
 		diffs = c.compareHeadersResponse(exp.Phase, r.HeadersResponse, resp)
@@ -151,21 +295,83 @@ func (c *Comparator) compareExpectation(exp *extproctorv1.ExtProcExpectation, re
 		diffs = c.compareTrailersResponse(exp.Phase, r.TrailersResponse, resp)
 	case *extproctorv1.ExtProcExpectation_ImmediateResponse:
 		diffs = c.compareImmediateResponse(exp.Phase, r.ImmediateResponse, resp)
+	case *extproctorv1.ExtProcExpectation_ExactResponse:
+		diffs = c.compareExactResponse(exp.Phase, r.ExactResponse, resp)
+	case *extproctorv1.ExtProcExpectation_UpgradeResponse:
+		diffs = c.compareUpgradeResponse(exp.Phase, r.UpgradeResponse, resp)
+	case *extproctorv1.ExtProcExpectation_Passthrough:
+		diffs = c.comparePassthrough(exp.Phase, resp)
 	}
 
-	return diffs
+	for _, m := range c.matchers {
+		diffs = append(diffs, m(exp, resp)...)
+	}
+
+	return filterIgnored(diffs, exp.IgnorePaths)
+}
+
+// FilterIgnored drops the test-level differences (e.g. redaction or stream
+// assertions) covered by the ignored paths of any of the expectations. The
+// differences of an expectation are filtered by its own ignored paths when it
+// is compared, since they decide which response it matches.
+func FilterIgnored(diffs []Difference, expectations []*extproctorv1.ExtProcExpectation) []Difference {
+	var ignorePaths []string
+	for _, exp := range expectations {
+		ignorePaths = append(ignorePaths, exp.IgnorePaths...)
+	}
+	return filterIgnored(diffs, ignorePaths)
+}
+
+// filterIgnored drops the differences whose path is covered by one of the
+// ignored paths.
+func filterIgnored(diffs []Difference, ignorePaths []string) []Difference {
+	if len(ignorePaths) == 0 || len(diffs) == 0 {
+		return diffs
+	}
+
+	var kept []Difference
+	for _, d := range diffs {
+		ignored := false
+		for _, p := range ignorePaths {
+			if isIgnoredPath(d.Path, p) {
+				ignored = true
+				break
+			}
+		}
+		if !ignored {
+			kept = append(kept, d)
+		}
+	}
+
+	return kept
+}
+
+// isIgnoredPath reports whether path equals the ignored path or is nested
+// below it (e.g. "set_headers" covers "set_headers[x-request-id]").
+func isIgnoredPath(path, ignore string) bool {
+	if ignore == "" {
+		return false
+	}
+	if path == ignore {
+		return true
+	}
+	if !strings.HasPrefix(path, ignore) {
+		return false
+	}
+	switch path[len(ignore)] {
+	case '.', '[':
+		return true
+	default:
+		return false
+	}
 }
 
 // compareHeadersResponse compares expected headers response against actual.
 func (c *Comparator) compareHeadersResponse(phase extproctorv1.ProcessingPhase, exp *extproctorv1.HeadersExpectation, resp *extprocv3.ProcessingResponse) []Difference {
 	var diffs []Difference
 
-	actual := resp.GetRequestHeaders()
-	if actual == nil {
-		actual = resp.GetResponseHeaders()
-	}
-
-	if actual == nil {
+	actual, ok := headerMutationResponse(phase, resp)
+	if !ok {
 		diffs = append(diffs, Difference{
 			Phase:    phase,
 			Path:     "response_type",
@@ -177,17 +383,86 @@ func (c *Comparator) compareHeadersResponse(phase extproctorv1.ProcessingPhase,
 
 	// Compare header mutations
 	if exp.CommonResponse != nil && exp.CommonResponse.HeaderMutation != nil {
-		diffs = append(diffs, c.compareHeaderMutation(phase, exp.CommonResponse.HeaderMutation, actual.Response)...)
+		diffs = append(diffs, c.compareHeaderMutation(phase, exp.CommonResponse.HeaderMutation, actual)...)
 	}
 
 	// Compare set headers
 	if len(exp.SetHeaders) > 0 {
-		diffs = append(diffs, c.compareSetHeaders(phase, exp.SetHeaders, actual.Response)...)
+		diffs = append(diffs, c.compareSetHeaders(phase, exp.SetHeaders, actual)...)
 	}
 
 	// Compare remove headers
 	if len(exp.RemoveHeaders) > 0 {
-		diffs = append(diffs, c.compareRemoveHeaders(phase, exp.RemoveHeaders, actual.Response)...)
+		diffs = append(diffs, c.compareRemoveHeaders(phase, exp.RemoveHeaders, actual)...)
+	}
+
+	// Compare set headers with their options
+	if len(exp.SetHeaderOptions) > 0 {
+		diffs = append(diffs, c.compareSetHeaderOptions(phase, exp.SetHeaderOptions, actual)...)
+	}
+
+	// Compare the order of set headers
+	if len(exp.OrderedSetHeaders) > 0 {
+		diffs = append(diffs, c.compareOrderedSetHeaders(phase, exp.OrderedSetHeaders, actual)...)
+	}
+
+	// Compare the sanitization of the x-forwarded-for chain
+	if exp.ForwardedFor != nil {
+		diffs = append(diffs, compareForwardedFor(phase, exp.ForwardedFor, actual.GetHeaderMutation())...)
+	}
+
+	// Report headers set beyond the expected ones
+	if exp.ExactHeaders && actual != nil {
+		expected := make(map[string]bool, len(exp.SetHeaders))
+		for k := range exp.SetHeaders {
+			expected[k] = true
+		}
+		for k := range exp.AppendHeaders {
+			expected[k] = true
+		}
+		for _, h := range exp.OrderedSetHeaders {
+			expected[h.Key] = true
+		}
+		for _, h := range exp.SetHeaderOptions {
+			expected[h.Key] = true
+		}
+		if exp.CommonResponse != nil && exp.CommonResponse.HeaderMutation != nil {
+			for k := range exp.CommonResponse.HeaderMutation.SetHeaders {
+				expected[k] = true
+			}
+			for k := range exp.CommonResponse.HeaderMutation.AppendHeaders {
+				expected[k] = true
+			}
+		}
+		if exp.GetForwardedFor().GetChain() != nil {
+			expected[forwardedForHeader] = true
+		}
+		diffs = append(diffs, c.compareExactHeaders(phase, "set_headers", expected, actual.HeaderMutation)...)
+	}
+
+	return diffs
+}
+
+// compareExactHeaders reports every header set by the filter that is not
+// part of the expected keys. The path prefix names the expectation field the
+// extra entries are reported under (e.g. "set_headers" or "set_trailers").
+func (c *Comparator) compareExactHeaders(phase extproctorv1.ProcessingPhase, path string, expected map[string]bool, mutation *extprocv3.HeaderMutation) []Difference {
+	var diffs []Difference
+
+	if mutation == nil {
+		return diffs
+	}
+
+	for _, h := range mutation.SetHeaders {
+		if h.Header == nil || expected[h.Header.Key] {
+			continue
+		}
+		diffs = append(diffs, Difference{
+			Phase:    phase,
+			Path:     fmt.Sprintf("%s[%s]", path, h.Header.Key),
+			Expected: headerNotSet,
+			Actual:   displayHeaderValue(getHeaderValue(h.Header)),
+		})
 	}
 
 	return diffs
@@ -216,12 +491,12 @@ func (c *Comparator) compareHeaderMutation(phase extproctorv1.ProcessingPhase, e
 			if h.Header != nil && h.Header.Key == k {
 				found = true
 				actualValue := getHeaderValue(h.Header)
-				if actualValue != v {
+				if !c.headerValueEqual(actualValue, v) {
 					diffs = append(diffs, Difference{
 						Phase:    phase,
 						Path:     fmt.Sprintf("header_mutation.set_headers[%s]", k),
-						Expected: v,
-						Actual:   actualValue,
+						Expected: displayHeaderValue(v),
+						Actual:   displayHeaderValue(actualValue),
 					})
 				}
 				break
@@ -231,8 +506,8 @@ func (c *Comparator) compareHeaderMutation(phase extproctorv1.ProcessingPhase, e
 			diffs = append(diffs, Difference{
 				Phase:    phase,
 				Path:     fmt.Sprintf("header_mutation.set_headers[%s]", k),
-				Expected: v,
-				Actual:   "<not set>",
+				Expected: displayHeaderValue(v),
+				Actual:   missingHeader(resp.HeaderMutation, k),
 			})
 		}
 	}
@@ -281,12 +556,12 @@ func (c *Comparator) compareSetHeaders(phase extproctorv1.ProcessingPhase, exp m
 			if h.Header != nil && h.Header.Key == k {
 				found = true
 				actualValue := getHeaderValue(h.Header)
-				if actualValue != v {
+				if !c.headerValueEqual(actualValue, v) {
 					diffs = append(diffs, Difference{
 						Phase:    phase,
 						Path:     fmt.Sprintf("set_headers[%s]", k),
-						Expected: v,
-						Actual:   actualValue,
+						Expected: displayHeaderValue(v),
+						Actual:   displayHeaderValue(actualValue),
 					})
 				}
 				break
@@ -296,8 +571,8 @@ func (c *Comparator) compareSetHeaders(phase extproctorv1.ProcessingPhase, exp m
 			diffs = append(diffs, Difference{
 				Phase:    phase,
 				Path:     fmt.Sprintf("set_headers[%s]", k),
-				Expected: v,
-				Actual:   "<not set>",
+				Expected: displayHeaderValue(v),
+				Actual:   missingHeader(resp.HeaderMutation, k),
 			})
 		}
 	}
@@ -305,6 +580,54 @@ func (c *Comparator) compareSetHeaders(phase extproctorv1.ProcessingPhase, exp m
 	return diffs
 }
 
+// compareOrderedSetHeaders checks that the expected headers are set in the
+// expected relative order. Headers with other keys may be interleaved.
+func (c *Comparator) compareOrderedSetHeaders(phase extproctorv1.ProcessingPhase, exp []*extproctorv1.HeaderEntry, resp *extprocv3.CommonResponse) []Difference {
+	keys := make(map[string]bool, len(exp))
+	for _, h := range exp {
+		keys[h.Key] = true
+	}
+
+	// Keep the set headers having an expected key, in order
+	var actual []string
+	next := 0
+	for _, h := range resp.GetHeaderMutation().GetSetHeaders() {
+		if h.Header == nil || !keys[h.Header.Key] {
+			continue
+		}
+		value := getHeaderValue(h.Header)
+		actual = append(actual, formatHeaderEntry(h.Header.Key, value))
+		if next < len(exp) && exp[next].Key == h.Header.Key && c.headerValueEqual(value, exp[next].Value) {
+			next++
+		}
+	}
+
+	if next == len(exp) {
+		return nil
+	}
+
+	expected := make([]string, len(exp))
+	for i, h := range exp {
+		expected[i] = formatHeaderEntry(h.Key, h.Value)
+	}
+	actualValue := strings.Join(actual, ", ")
+	if len(actual) == 0 {
+		actualValue = "<not set>"
+	}
+
+	return []Difference{{
+		Phase:    phase,
+		Path:     "ordered_set_headers",
+		Expected: strings.Join(expected, ", "),
+		Actual:   actualValue,
+	}}
+}
+
+// formatHeaderEntry renders a header as "key: value".
+func formatHeaderEntry(key, value string) string {
+	return key + ": " + displayHeaderValue(value)
+}
+
 // compareRemoveHeaders compares remove headers expectations.
 func (c *Comparator) compareRemoveHeaders(phase extproctorv1.ProcessingPhase, exp []string, resp *extprocv3.CommonResponse) []Difference {
 	var diffs []Difference
@@ -373,22 +696,33 @@ func (c *Comparator) compareBodyResponse(phase extproctorv1.ProcessingPhase, exp
 		}
 	}
 
-	if len(exp.Body) > 0 && actual.Response != nil {
+	if (len(exp.Body) > 0 || exp.Graphql != nil) && actual.Response != nil {
 		bodyMut := actual.Response.BodyMutation
 		if bodyMut == nil {
+			expected := describeBody(exp.Body, 0)
+			if len(exp.Body) == 0 {
+				expected = "GraphQL response"
+			}
 			diffs = append(diffs, Difference{
 				Phase:    phase,
 				Path:     "body.body_mutation",
-				Expected: string(exp.Body),
+				Expected: expected,
 				Actual:   "<nil>",
 			})
-		} else if string(bodyMut.GetBody()) != string(exp.Body) {
+		} else if body, err := compression.Decode(exp.Decode, bodyMut.GetBody()); err != nil {
 			diffs = append(diffs, Difference{
 				Phase:    phase,
 				Path:     "body.body_mutation.body",
-				Expected: string(exp.Body),
-				Actual:   string(bodyMut.GetBody()),
+				Expected: fmt.Sprintf("%s encoded body", compression.ContentEncoding(exp.Decode)),
+				Actual:   err.Error(),
 			})
+		} else {
+			if len(exp.Body) > 0 {
+				diffs = append(diffs, compareBody(phase, "body.body_mutation.body", exp.Body, body)...)
+			}
+			if exp.Graphql != nil {
+				diffs = append(diffs, compareGraphql(phase, "body.body_mutation.body.graphql", exp.Graphql, body)...)
+			}
 		}
 	}
 
@@ -422,7 +756,7 @@ func (c *Comparator) compareTrailersResponse(phase extproctorv1.ProcessingPhase,
 				diffs = append(diffs, Difference{
 					Phase:    phase,
 					Path:     fmt.Sprintf("set_trailers[%s]", k),
-					Expected: v,
+					Expected: displayHeaderValue(v),
 					Actual:   "<no header mutation>",
 				})
 			}
@@ -433,12 +767,12 @@ func (c *Comparator) compareTrailersResponse(phase extproctorv1.ProcessingPhase,
 					if h.Header != nil && h.Header.Key == k {
 						found = true
 						actualValue := getHeaderValue(h.Header)
-						if actualValue != v {
+						if !c.headerValueEqual(actualValue, v) {
 							diffs = append(diffs, Difference{
 								Phase:    phase,
 								Path:     fmt.Sprintf("set_trailers[%s]", k),
-								Expected: v,
-								Actual:   actualValue,
+								Expected: displayHeaderValue(v),
+								Actual:   displayHeaderValue(actualValue),
 							})
 						}
 						break
@@ -448,14 +782,19 @@ func (c *Comparator) compareTrailersResponse(phase extproctorv1.ProcessingPhase,
 					diffs = append(diffs, Difference{
 						Phase:    phase,
 						Path:     fmt.Sprintf("set_trailers[%s]", k),
-						Expected: v,
-						Actual:   "<not set>",
+						Expected: displayHeaderValue(v),
+						Actual:   missingHeader(actual.HeaderMutation, k),
 					})
 				}
 			}
 		}
 	}
 
+	// Compare set trailers with repeated keys
+	if len(exp.SetTrailerEntries) > 0 {
+		diffs = append(diffs, c.compareHeaderEntries(phase, "set_trailer_entries", exp.SetTrailerEntries, actual.HeaderMutation)...)
+	}
+
 	// Compare remove trailers
 	if len(exp.RemoveTrailers) > 0 {
 		if actual.HeaderMutation == nil {
@@ -489,6 +828,18 @@ func (c *Comparator) compareTrailersResponse(phase extproctorv1.ProcessingPhase,
 		}
 	}
 
+	// Report trailers set beyond the expected ones
+	if exp.ExactTrailers {
+		expected := make(map[string]bool, len(exp.SetTrailers))
+		for k := range exp.SetTrailers {
+			expected[k] = true
+		}
+		for _, e := range exp.SetTrailerEntries {
+			expected[e.Key] = true
+		}
+		diffs = append(diffs, c.compareExactHeaders(phase, "set_trailers", expected, actual.HeaderMutation)...)
+	}
+
 	return diffs
 }
 
@@ -525,14 +876,10 @@ func (c *Comparator) compareImmediateResponse(phase extproctorv1.ProcessingPhase
 
 	// Compare body
 	if len(exp.Body) > 0 {
-		if string(actual.Body) != string(exp.Body) {
-			diffs = append(diffs, Difference{
-				Phase:    phase,
-				Path:     "immediate_response.body",
-				Expected: string(exp.Body),
-				Actual:   string(actual.Body),
-			})
-		}
+		diffs = append(diffs, compareBody(phase, "immediate_response.body", exp.Body, actual.Body)...)
+	}
+	if exp.Graphql != nil {
+		diffs = append(diffs, compareGraphql(phase, "immediate_response.body.graphql", exp.Graphql, actual.Body)...)
 	}
 
 	// Compare headers
@@ -543,7 +890,7 @@ func (c *Comparator) compareImmediateResponse(phase extproctorv1.ProcessingPhase
 				if h.Header != nil && h.Header.Key == k {
 					found = true
 					actualValue := getHeaderValue(h.Header)
-					if actualValue != v {
+					if !c.headerValueEqual(actualValue, v) {
 						diffs = append(diffs, Difference{
 							Phase:    phase,
 							Path:     fmt.Sprintf("immediate_response.headers[%s]", k),
@@ -7,11 +7,13 @@ import (
 	"testing"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocfilterv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/stretchr/testify/assert"
 	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
 	"zntr.io/extproctor/internal/client"
+	"zntr.io/extproctor/internal/compression"
 )
 
 func TestComparator_Compare_ExactMatch(t *testing.T) {
@@ -1660,6 +1662,73 @@ func TestComparator_Compare_TrailersResponse_NilHeaderMutation(t *testing.T) {
 	}
 }
 
+func TestComparator_Compare_TrailersResponse_EmptyValueDisplay(t *testing.T) {
+	comp := New()
+
+	expectations := []*extproctorv1.ExtProcExpectation{
+		{
+			Phase: extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
+			Response: &extproctorv1.ExtProcExpectation_TrailersResponse{
+				TrailersResponse: &extproctorv1.TrailersExpectation{
+					SetTrailers: map[string]string{"x-empty": ""},
+				},
+			},
+		},
+	}
+	result := &client.ProcessingResult{
+		Responses: []*client.PhaseResponse{
+			{
+				Phase: extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_ResponseTrailers{
+						ResponseTrailers: &extprocv3.TrailersResponse{},
+					},
+				},
+			},
+		},
+	}
+
+	compResult := comp.Compare(expectations, result)
+	assert.Len(t, compResult.Differences, 1)
+	assert.Equal(t, displayHeaderValue(""), compResult.Differences[0].Expected)
+}
+
+func TestComparator_Compare_TrailersResponse_HeaderValues(t *testing.T) {
+	comp := New()
+
+	expectations := []*extproctorv1.ExtProcExpectation{
+		{
+			Phase: extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
+			Response: &extproctorv1.ExtProcExpectation_TrailersResponse{
+				TrailersResponse: &extproctorv1.TrailersExpectation{
+					SetTrailers: map[string]string{"x-checksum": "ABC123"},
+				},
+			},
+			HeaderValues: &extproctorv1.HeaderValueComparison{TrimWhitespace: true, CaseInsensitiveValue: true},
+		},
+	}
+	result := &client.ProcessingResult{
+		Responses: []*client.PhaseResponse{
+			{
+				Phase: extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_ResponseTrailers{
+						ResponseTrailers: &extprocv3.TrailersResponse{
+							HeaderMutation: &extprocv3.HeaderMutation{
+								SetHeaders: []*corev3.HeaderValueOption{
+									{Header: &corev3.HeaderValue{Key: "x-checksum", Value: " abc123 "}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	assert.True(t, comp.Compare(expectations, result).Passed)
+}
+
 func TestComparator_Compare_TrailersResponse_RemoveTrailers_NilHeaderMutation(t *testing.T) {
 	comp := New()
 
@@ -1698,3 +1767,926 @@ func TestComparator_Compare_TrailersResponse_RemoveTrailers_NilHeaderMutation(t
 	assert.Equal(t, "remove_trailers[x-custom-trailer]", compResult.Differences[0].Path)
 	assert.Equal(t, "<no header mutation>", compResult.Differences[0].Actual)
 }
+
+func TestComparator_Compare_IgnorePaths(t *testing.T) {
+	comp := New()
+
+	expectations := []*extproctorv1.ExtProcExpectation{
+		{
+			Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+				HeadersResponse: &extproctorv1.HeadersExpectation{
+					SetHeaders: map[string]string{
+						"x-custom-header": "custom-value",
+						"x-request-id":    "placeholder",
+					},
+				},
+			},
+			IgnorePaths: []string{"set_headers[x-request-id]"},
+		},
+	}
+
+	result := &client.ProcessingResult{
+		Responses: []*client.PhaseResponse{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_RequestHeaders{
+						RequestHeaders: &extprocv3.HeadersResponse{
+							Response: &extprocv3.CommonResponse{
+								HeaderMutation: &extprocv3.HeaderMutation{
+									SetHeaders: []*corev3.HeaderValueOption{
+										{Header: &corev3.HeaderValue{Key: "x-custom-header", Value: "custom-value"}},
+										{Header: &corev3.HeaderValue{Key: "x-request-id", Value: "4f1c2a"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	compResult := comp.Compare(expectations, result)
+	assert.True(t, compResult.Passed)
+	assert.Empty(t, compResult.Differences)
+}
+
+func TestComparator_Compare_IgnorePaths_OtherDifferencesKept(t *testing.T) {
+	comp := New()
+
+	expectations := []*extproctorv1.ExtProcExpectation{
+		{
+			Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+				HeadersResponse: &extproctorv1.HeadersExpectation{
+					SetHeaders: map[string]string{
+						"x-custom-header": "expected-value",
+						"x-request-id":    "placeholder",
+					},
+				},
+			},
+			IgnorePaths: []string{"set_headers[x-request-id]"},
+		},
+	}
+
+	result := &client.ProcessingResult{
+		Responses: []*client.PhaseResponse{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_RequestHeaders{
+						RequestHeaders: &extprocv3.HeadersResponse{
+							Response: &extprocv3.CommonResponse{
+								HeaderMutation: &extprocv3.HeaderMutation{
+									SetHeaders: []*corev3.HeaderValueOption{
+										{Header: &corev3.HeaderValue{Key: "x-custom-header", Value: "actual-value"}},
+										{Header: &corev3.HeaderValue{Key: "x-request-id", Value: "4f1c2a"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	compResult := comp.Compare(expectations, result)
+	assert.False(t, compResult.Passed)
+	assert.Len(t, compResult.Differences, 1)
+	assert.Equal(t, "set_headers[x-custom-header]", compResult.Differences[0].Path)
+}
+
+func TestFilterIgnored(t *testing.T) {
+	expectations := []*extproctorv1.ExtProcExpectation{
+		{Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS, IgnorePaths: []string{"stream.max_set_headers"}},
+		{Phase: extproctorv1.ProcessingPhase_RESPONSE_HEADERS, IgnorePaths: []string{"redaction.email"}},
+	}
+	diffs := []Difference{
+		{Path: "stream.max_set_headers"},
+		{Path: "redaction.email"},
+		{Path: "stream.never_set_headers[x-debug]"},
+	}
+
+	kept := FilterIgnored(diffs, expectations)
+	assert.Equal(t, []Difference{{Path: "stream.never_set_headers[x-debug]"}}, kept)
+
+	assert.Equal(t, diffs, FilterIgnored(diffs, nil))
+}
+
+func TestIsIgnoredPath(t *testing.T) {
+	tests := []struct {
+		path   string
+		ignore string
+		want   bool
+	}{
+		{"set_headers[x-request-id]", "set_headers[x-request-id]", true},
+		{"set_headers[x-request-id]", "set_headers", true},
+		{"header_mutation.set_headers[x-id]", "header_mutation", true},
+		{"set_headers_extra", "set_headers", false},
+		{"set_headers[x-id]", "set_headers[x-other]", false},
+		{"set_headers[x-id]", "", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isIgnoredPath(tt.path, tt.ignore), "path=%q ignore=%q", tt.path, tt.ignore)
+	}
+}
+
+func TestComparator_Compare_ExactHeaders(t *testing.T) {
+	comp := New()
+
+	newResult := func(headers ...*corev3.HeaderValueOption) *client.ProcessingResult {
+		return &client.ProcessingResult{
+			Responses: []*client.PhaseResponse{
+				{
+					Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+					Response: &extprocv3.ProcessingResponse{
+						Response: &extprocv3.ProcessingResponse_RequestHeaders{
+							RequestHeaders: &extprocv3.HeadersResponse{
+								Response: &extprocv3.CommonResponse{
+									HeaderMutation: &extprocv3.HeaderMutation{
+										SetHeaders: headers,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	expectations := []*extproctorv1.ExtProcExpectation{
+		{
+			Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+				HeadersResponse: &extproctorv1.HeadersExpectation{
+					SetHeaders: map[string]string{
+						"x-custom-header": "custom-value",
+					},
+					ExactHeaders: true,
+				},
+			},
+		},
+	}
+
+	t.Run("exact set passes", func(t *testing.T) {
+		compResult := comp.Compare(expectations, newResult(
+			&corev3.HeaderValueOption{Header: &corev3.HeaderValue{Key: "x-custom-header", Value: "custom-value"}},
+		))
+		assert.True(t, compResult.Passed)
+	})
+
+	t.Run("extra header fails", func(t *testing.T) {
+		compResult := comp.Compare(expectations, newResult(
+			&corev3.HeaderValueOption{Header: &corev3.HeaderValue{Key: "x-custom-header", Value: "custom-value"}},
+			&corev3.HeaderValueOption{Header: &corev3.HeaderValue{Key: "x-leaked", Value: "secret"}},
+		))
+		assert.False(t, compResult.Passed)
+		assert.Len(t, compResult.Differences, 1)
+		assert.Equal(t, "set_headers[x-leaked]", compResult.Differences[0].Path)
+		assert.Equal(t, "<not set>", compResult.Differences[0].Expected)
+		assert.Equal(t, "secret", compResult.Differences[0].Actual)
+	})
+
+	t.Run("appended header passes", func(t *testing.T) {
+		expectations := []*extproctorv1.ExtProcExpectation{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+					HeadersResponse: &extproctorv1.HeadersExpectation{
+						AppendHeaders: map[string]string{
+							"x-trace": "hop",
+						},
+						ExactHeaders: true,
+					},
+				},
+			},
+		}
+		compResult := comp.Compare(expectations, newResult(
+			&corev3.HeaderValueOption{
+				Header:       &corev3.HeaderValue{Key: "x-trace", Value: "hop"},
+				AppendAction: corev3.HeaderValueOption_APPEND_IF_EXISTS_OR_ADD,
+			},
+		))
+		assert.True(t, compResult.Passed, "%v", compResult.Differences)
+	})
+}
+
+func TestComparator_Compare_TrailersResponse_RemoveTrailers(t *testing.T) {
+	comp := New()
+
+	expectations := []*extproctorv1.ExtProcExpectation{
+		{
+			Phase: extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
+			Response: &extproctorv1.ExtProcExpectation_TrailersResponse{
+				TrailersResponse: &extproctorv1.TrailersExpectation{
+					RemoveTrailers: []string{"x-internal-trailer", "x-debug-trailer"},
+				},
+			},
+		},
+	}
+
+	result := &client.ProcessingResult{
+		Responses: []*client.PhaseResponse{
+			{
+				Phase: extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_ResponseTrailers{
+						ResponseTrailers: &extprocv3.TrailersResponse{
+							HeaderMutation: &extprocv3.HeaderMutation{
+								RemoveHeaders: []string{"x-internal-trailer"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	compResult := comp.Compare(expectations, result)
+	assert.False(t, compResult.Passed)
+	assert.Len(t, compResult.Differences, 1)
+	assert.Equal(t, "remove_trailers[x-debug-trailer]", compResult.Differences[0].Path)
+	assert.Equal(t, "<not removed>", compResult.Differences[0].Actual)
+}
+
+func TestComparator_Compare_ExactTrailers(t *testing.T) {
+	comp := New()
+
+	expectations := []*extproctorv1.ExtProcExpectation{
+		{
+			Phase: extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
+			Response: &extproctorv1.ExtProcExpectation_TrailersResponse{
+				TrailersResponse: &extproctorv1.TrailersExpectation{
+					SetTrailers: map[string]string{
+						"x-checksum": "abc",
+					},
+					ExactTrailers: true,
+				},
+			},
+		},
+	}
+
+	result := &client.ProcessingResult{
+		Responses: []*client.PhaseResponse{
+			{
+				Phase: extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_ResponseTrailers{
+						ResponseTrailers: &extprocv3.TrailersResponse{
+							HeaderMutation: &extprocv3.HeaderMutation{
+								SetHeaders: []*corev3.HeaderValueOption{
+									{Header: &corev3.HeaderValue{Key: "x-checksum", Value: "abc"}},
+									{Header: &corev3.HeaderValue{Key: "x-extra", Value: "leak"}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	compResult := comp.Compare(expectations, result)
+	assert.False(t, compResult.Passed)
+	assert.Len(t, compResult.Differences, 1)
+	assert.Equal(t, "set_trailers[x-extra]", compResult.Differences[0].Path)
+	assert.Equal(t, "leak", compResult.Differences[0].Actual)
+}
+
+func TestComparator_Compare_SetTrailerEntries(t *testing.T) {
+	comp := New()
+
+	trailersResult := func(values ...string) *client.ProcessingResult {
+		var set []*corev3.HeaderValueOption
+		for _, v := range values {
+			set = append(set, &corev3.HeaderValueOption{Header: &corev3.HeaderValue{Key: "grpc-status-details-bin", Value: v}})
+		}
+		return &client.ProcessingResult{
+			Responses: []*client.PhaseResponse{
+				{
+					Phase: extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
+					Response: &extprocv3.ProcessingResponse{
+						Response: &extprocv3.ProcessingResponse_ResponseTrailers{
+							ResponseTrailers: &extprocv3.TrailersResponse{
+								HeaderMutation: &extprocv3.HeaderMutation{SetHeaders: set},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	expectations := []*extproctorv1.ExtProcExpectation{
+		{
+			Phase: extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
+			Response: &extproctorv1.ExtProcExpectation_TrailersResponse{
+				TrailersResponse: &extproctorv1.TrailersExpectation{
+					SetTrailerEntries: []*extproctorv1.HeaderEntry{
+						{Key: "grpc-status-details-bin", Value: "a"},
+						{Key: "grpc-status-details-bin", Value: "b"},
+					},
+					ExactTrailers: true,
+				},
+			},
+		},
+	}
+
+	compResult := comp.Compare(expectations, trailersResult("b", "a"))
+	assert.True(t, compResult.Passed)
+
+	compResult = comp.Compare(expectations, trailersResult("a", "c"))
+	assert.False(t, compResult.Passed)
+	if assert.Len(t, compResult.Differences, 1) {
+		assert.Equal(t, "set_trailer_entries[grpc-status-details-bin]", compResult.Differences[0].Path)
+		assert.Equal(t, "b", compResult.Differences[0].Expected)
+		assert.Equal(t, "c", compResult.Differences[0].Actual)
+	}
+
+	// A key listed twice must be set twice
+	compResult = comp.Compare(expectations, trailersResult("a"))
+	assert.False(t, compResult.Passed)
+	if assert.Len(t, compResult.Differences, 1) {
+		assert.Equal(t, "<not set>", compResult.Differences[0].Actual)
+	}
+}
+
+func TestComparator_Compare_UnmatchedReason_ShortCircuit(t *testing.T) {
+	comp := New()
+
+	bodyExp := &extproctorv1.ExtProcExpectation{
+		Phase: extproctorv1.ProcessingPhase_REQUEST_BODY,
+		Response: &extproctorv1.ExtProcExpectation_BodyResponse{
+			BodyResponse: &extproctorv1.BodyExpectation{},
+		},
+	}
+
+	result := &client.ProcessingResult{
+		Responses: []*client.PhaseResponse{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+						ImmediateResponse: &extprocv3.ImmediateResponse{
+							Status: &typev3.HttpStatus{Code: typev3.StatusCode_Forbidden},
+						},
+					},
+				},
+			},
+		},
+		SkippedPhases: []extproctorv1.ProcessingPhase{extproctorv1.ProcessingPhase_REQUEST_BODY},
+	}
+
+	compResult := comp.Compare([]*extproctorv1.ExtProcExpectation{bodyExp}, result)
+	assert.False(t, compResult.Passed)
+	assert.Equal(t, "phase REQUEST_BODY never reached: stream ended at REQUEST_HEADERS with 403", compResult.UnmatchedReasons[bodyExp])
+}
+
+func TestComparator_Compare_UnmatchedReason_ModeOverride(t *testing.T) {
+	comp := New()
+
+	bodyExp := &extproctorv1.ExtProcExpectation{
+		Phase: extproctorv1.ProcessingPhase_REQUEST_BODY,
+		Response: &extproctorv1.ExtProcExpectation_BodyResponse{
+			BodyResponse: &extproctorv1.BodyExpectation{},
+		},
+	}
+
+	result := &client.ProcessingResult{
+		Responses: []*client.PhaseResponse{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_RequestHeaders{RequestHeaders: &extprocv3.HeadersResponse{}},
+					ModeOverride: &extprocfilterv3.ProcessingMode{
+						RequestBodyMode: extprocfilterv3.ProcessingMode_NONE,
+					},
+				},
+			},
+		},
+		SuppressedPhases: []extproctorv1.ProcessingPhase{extproctorv1.ProcessingPhase_REQUEST_BODY},
+	}
+
+	compResult := comp.Compare([]*extproctorv1.ExtProcExpectation{bodyExp}, result)
+	assert.False(t, compResult.Passed)
+	assert.Equal(t, "phase REQUEST_BODY never reached: disabled by a mode_override at REQUEST_HEADERS", compResult.UnmatchedReasons[bodyExp])
+}
+
+func TestComparator_Compare_NoResponse(t *testing.T) {
+	comp := New()
+
+	silent := &extproctorv1.ExtProcExpectation{
+		Phase:    extproctorv1.ProcessingPhase_ANY_REQUEST,
+		Response: &extproctorv1.ExtProcExpectation_NoResponse{NoResponse: true},
+	}
+
+	cr := comp.Compare([]*extproctorv1.ExtProcExpectation{silent}, &client.ProcessingResult{})
+	assert.True(t, cr.Passed)
+	assert.Empty(t, cr.Differences)
+
+	answered := &client.ProcessingResult{
+		Responses: []*client.PhaseResponse{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_RequestHeaders{RequestHeaders: &extprocv3.HeadersResponse{}},
+				},
+			},
+		},
+	}
+	cr = comp.Compare([]*extproctorv1.ExtProcExpectation{silent}, answered)
+	assert.False(t, cr.Passed)
+	assert.Equal(t, []*extproctorv1.ExtProcExpectation{silent}, cr.Unmatched)
+	if assert.Len(t, cr.Differences, 1) {
+		assert.Equal(t, "no_response", cr.Differences[0].Path)
+		assert.Equal(t, "no response", cr.Differences[0].Expected)
+		assert.Contains(t, cr.Differences[0].Actual, "request_headers")
+	}
+	assert.Len(t, cr.Unexpected, 1)
+}
+
+func TestComparator_Compare_UnmatchedReason_NotConfigured(t *testing.T) {
+	comp := New()
+
+	trailersExp := &extproctorv1.ExtProcExpectation{
+		Phase: extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
+		Response: &extproctorv1.ExtProcExpectation_TrailersResponse{
+			TrailersResponse: &extproctorv1.TrailersExpectation{},
+		},
+	}
+
+	result := &client.ProcessingResult{
+		Responses: []*client.PhaseResponse{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_RequestHeaders{
+						RequestHeaders: &extprocv3.HeadersResponse{},
+					},
+				},
+			},
+		},
+	}
+
+	compResult := comp.Compare([]*extproctorv1.ExtProcExpectation{trailersExp}, result)
+	assert.False(t, compResult.Passed)
+	assert.Contains(t, compResult.UnmatchedReasons[trailersExp], "process_response_trailers")
+}
+
+func TestComparator_Compare_UnmatchedReason_PhaseSent(t *testing.T) {
+	comp := New()
+
+	headersExp := &extproctorv1.ExtProcExpectation{
+		Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+		Response: &extproctorv1.ExtProcExpectation_BodyResponse{
+			BodyResponse: &extproctorv1.BodyExpectation{},
+		},
+	}
+
+	result := &client.ProcessingResult{
+		Responses: []*client.PhaseResponse{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_RequestHeaders{
+						RequestHeaders: &extprocv3.HeadersResponse{},
+					},
+				},
+			},
+		},
+	}
+
+	compResult := comp.Compare([]*extproctorv1.ExtProcExpectation{headersExp}, result)
+	assert.False(t, compResult.Passed)
+	assert.Empty(t, compResult.UnmatchedReasons)
+}
+
+func TestComparator_Compare_ExactResponse(t *testing.T) {
+	actual := &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_RequestHeaders{
+			RequestHeaders: &extprocv3.HeadersResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation: &extprocv3.HeaderMutation{
+						SetHeaders: []*corev3.HeaderValueOption{
+							{Header: &corev3.HeaderValue{Key: "x-user-id", Value: "42"}},
+						},
+					},
+					ClearRouteCache: true,
+				},
+			},
+		},
+	}
+	result := &client.ProcessingResult{
+		Responses: []*client.PhaseResponse{
+			{Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS, Response: actual},
+		},
+	}
+
+	expected := &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_RequestHeaders{
+			RequestHeaders: &extprocv3.HeadersResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation: &extprocv3.HeaderMutation{
+						SetHeaders: []*corev3.HeaderValueOption{
+							{Header: &corev3.HeaderValue{Key: "x-user-id", Value: "42"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		ignoreFields []string
+		passed       bool
+		path         string
+	}{
+		{name: "mismatch", passed: false, path: "exact_response"},
+		{name: "ignored field", ignoreFields: []string{"envoy.service.ext_proc.v3.CommonResponse.clear_route_cache"}, passed: true},
+		{name: "unknown field", ignoreFields: []string{"nope"}, passed: false, path: "exact_response.ignore_fields[0]"},
+		{name: "unknown message", ignoreFields: []string{"foo.Bar.baz"}, passed: false, path: "exact_response.ignore_fields[0]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectations := []*extproctorv1.ExtProcExpectation{
+				{
+					Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+					Response: &extproctorv1.ExtProcExpectation_ExactResponse{
+						ExactResponse: &extproctorv1.ExactResponseExpectation{
+							Response:     expected,
+							IgnoreFields: tt.ignoreFields,
+						},
+					},
+				},
+			}
+
+			compResult := New().Compare(expectations, result)
+			assert.Equal(t, tt.passed, compResult.Passed)
+			if !tt.passed {
+				if assert.Len(t, compResult.Differences, 1) {
+					assert.Equal(t, tt.path, compResult.Differences[0].Path)
+				}
+			}
+		})
+	}
+}
+
+func TestComparator_Compare_ExactResponse_TopLevelIgnore(t *testing.T) {
+	expectations := []*extproctorv1.ExtProcExpectation{
+		{
+			Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			Response: &extproctorv1.ExtProcExpectation_ExactResponse{
+				ExactResponse: &extproctorv1.ExactResponseExpectation{
+					Response: &extprocv3.ProcessingResponse{
+						Response: &extprocv3.ProcessingResponse_RequestHeaders{
+							RequestHeaders: &extprocv3.HeadersResponse{},
+						},
+					},
+					IgnoreFields: []string{"mode_override"},
+				},
+			},
+		},
+	}
+	result := &client.ProcessingResult{
+		Responses: []*client.PhaseResponse{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_RequestHeaders{
+						RequestHeaders: &extprocv3.HeadersResponse{},
+					},
+					ModeOverride: &extprocfilterv3.ProcessingMode{
+						ResponseHeaderMode: extprocfilterv3.ProcessingMode_SKIP,
+					},
+				},
+			},
+		},
+	}
+
+	compResult := New().Compare(expectations, result)
+	assert.True(t, compResult.Passed)
+}
+
+func TestComparator_Compare_OrderedSetHeaders(t *testing.T) {
+	comp := New()
+
+	newResult := func(headers ...string) *client.ProcessingResult {
+		var options []*corev3.HeaderValueOption
+		for i := 0; i < len(headers); i += 2 {
+			options = append(options, &corev3.HeaderValueOption{
+				Header: &corev3.HeaderValue{Key: headers[i], RawValue: []byte(headers[i+1])},
+			})
+		}
+		return &client.ProcessingResult{
+			Responses: []*client.PhaseResponse{
+				{
+					Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+					Response: &extprocv3.ProcessingResponse{
+						Response: &extprocv3.ProcessingResponse_RequestHeaders{
+							RequestHeaders: &extprocv3.HeadersResponse{
+								Response: &extprocv3.CommonResponse{
+									HeaderMutation: &extprocv3.HeaderMutation{SetHeaders: options},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	expectations := []*extproctorv1.ExtProcExpectation{
+		{
+			Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+				HeadersResponse: &extproctorv1.HeadersExpectation{
+					OrderedSetHeaders: []*extproctorv1.HeaderEntry{
+						{Key: "via", Value: "1.1 edge"},
+						{Key: "via", Value: "1.1 gateway"},
+						{Key: "forwarded", Value: "for=192.0.2.1"},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("expected order with interleaved headers passes", func(t *testing.T) {
+		compResult := comp.Compare(expectations, newResult(
+			"via", "1.1 edge",
+			"x-request-id", "abc",
+			"via", "1.1 gateway",
+			"forwarded", "for=192.0.2.1",
+		))
+		assert.True(t, compResult.Passed)
+		assert.Empty(t, compResult.Differences)
+	})
+
+	t.Run("wrong order fails", func(t *testing.T) {
+		compResult := comp.Compare(expectations, newResult(
+			"via", "1.1 gateway",
+			"via", "1.1 edge",
+			"forwarded", "for=192.0.2.1",
+		))
+		assert.False(t, compResult.Passed)
+		if assert.Len(t, compResult.Differences, 1) {
+			assert.Equal(t, "ordered_set_headers", compResult.Differences[0].Path)
+			assert.Equal(t, "via: 1.1 edge, via: 1.1 gateway, forwarded: for=192.0.2.1", compResult.Differences[0].Expected)
+			assert.Equal(t, "via: 1.1 gateway, via: 1.1 edge, forwarded: for=192.0.2.1", compResult.Differences[0].Actual)
+		}
+	})
+
+	t.Run("missing header fails", func(t *testing.T) {
+		compResult := comp.Compare(expectations, newResult("via", "1.1 edge"))
+		assert.False(t, compResult.Passed)
+		if assert.Len(t, compResult.Differences, 1) {
+			assert.Equal(t, "via: 1.1 edge", compResult.Differences[0].Actual)
+		}
+	})
+
+	t.Run("no header mutation fails", func(t *testing.T) {
+		compResult := comp.Compare(expectations, newResult())
+		assert.False(t, compResult.Passed)
+		if assert.Len(t, compResult.Differences, 1) {
+			assert.Equal(t, "<not set>", compResult.Differences[0].Actual)
+		}
+	})
+
+	t.Run("values compared like the other headers", func(t *testing.T) {
+		relaxed := []*extproctorv1.ExtProcExpectation{{
+			Phase:        expectations[0].Phase,
+			Response:     expectations[0].Response,
+			HeaderValues: &extproctorv1.HeaderValueComparison{CaseInsensitiveValue: true},
+		}}
+
+		// Raw values (above) and values compare alike
+		result := newResult("via", "1.1 EDGE", "via", "1.1 Gateway", "forwarded", "for=192.0.2.1")
+		for _, r := range result.Responses {
+			for _, h := range r.Response.GetRequestHeaders().GetResponse().GetHeaderMutation().GetSetHeaders() {
+				h.Header.Value, h.Header.RawValue = string(h.Header.RawValue), nil
+			}
+		}
+		assert.False(t, comp.Compare(expectations, result).Passed)
+		assert.True(t, comp.Compare(relaxed, result).Passed)
+	})
+
+	t.Run("empty values are displayed", func(t *testing.T) {
+		compResult := comp.Compare(expectations, newResult("via", ""))
+		if assert.Len(t, compResult.Differences, 1) {
+			assert.Equal(t, "via: "+displayHeaderValue(""), compResult.Differences[0].Actual)
+		}
+	})
+}
+
+func TestComparator_Compare_SetHeaderOptions(t *testing.T) {
+	comp := New()
+
+	newResult := func(mutation *extprocv3.HeaderMutation) *client.ProcessingResult {
+		return &client.ProcessingResult{
+			Responses: []*client.PhaseResponse{
+				{
+					Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+					Response: &extprocv3.ProcessingResponse{
+						Response: &extprocv3.ProcessingResponse_RequestHeaders{
+							RequestHeaders: &extprocv3.HeadersResponse{
+								Response: &extprocv3.CommonResponse{HeaderMutation: mutation},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	expectations := []*extproctorv1.ExtProcExpectation{
+		{
+			Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+				HeadersResponse: &extproctorv1.HeadersExpectation{
+					SetHeaderOptions: []*extproctorv1.SetHeaderExpectation{
+						{Key: "x-debug", KeepEmptyValue: true, AppendAction: "OVERWRITE_IF_EXISTS_OR_ADD"},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("cleared header passes", func(t *testing.T) {
+		compResult := comp.Compare(expectations, newResult(&extprocv3.HeaderMutation{
+			SetHeaders: []*corev3.HeaderValueOption{{
+				Header:         &corev3.HeaderValue{Key: "x-debug"},
+				KeepEmptyValue: true,
+				AppendAction:   corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+			}},
+		}))
+		assert.True(t, compResult.Passed)
+	})
+
+	t.Run("options mismatch", func(t *testing.T) {
+		compResult := comp.Compare(expectations, newResult(&extprocv3.HeaderMutation{
+			SetHeaders: []*corev3.HeaderValueOption{{
+				Header: &corev3.HeaderValue{Key: "x-debug", Value: "1"},
+			}},
+		}))
+		assert.False(t, compResult.Passed)
+		assert.ElementsMatch(t, []Difference{
+			{Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS, Path: "set_header_options[x-debug].value", Expected: "<empty>", Actual: "1"},
+			{Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS, Path: "set_header_options[x-debug].keep_empty_value", Expected: "true", Actual: "false"},
+			{Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS, Path: "set_header_options[x-debug].append_action", Expected: "OVERWRITE_IF_EXISTS_OR_ADD", Actual: "APPEND_IF_EXISTS_OR_ADD"},
+		}, compResult.Differences)
+	})
+
+	t.Run("removed header", func(t *testing.T) {
+		compResult := comp.Compare(expectations, newResult(&extprocv3.HeaderMutation{
+			RemoveHeaders: []string{"x-debug"},
+		}))
+		assert.False(t, compResult.Passed)
+		if assert.Len(t, compResult.Differences, 1) {
+			assert.Equal(t, "<empty>", compResult.Differences[0].Expected)
+			assert.Equal(t, "<removed>", compResult.Differences[0].Actual)
+		}
+	})
+
+	t.Run("not set header", func(t *testing.T) {
+		compResult := comp.Compare(expectations, newResult(nil))
+		assert.False(t, compResult.Passed)
+		if assert.Len(t, compResult.Differences, 1) {
+			assert.Equal(t, "<not set>", compResult.Differences[0].Actual)
+		}
+	})
+}
+
+func TestComparator_Compare_EmptySetHeader(t *testing.T) {
+	result := &client.ProcessingResult{
+		Responses: []*client.PhaseResponse{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_RequestHeaders{
+						RequestHeaders: &extprocv3.HeadersResponse{
+							Response: &extprocv3.CommonResponse{
+								HeaderMutation: &extprocv3.HeaderMutation{
+									SetHeaders: []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "x-debug"}}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	expectations := []*extproctorv1.ExtProcExpectation{
+		{
+			Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+				HeadersResponse: &extproctorv1.HeadersExpectation{
+					SetHeaders: map[string]string{"x-debug": "on"},
+				},
+			},
+		},
+	}
+
+	compResult := New().Compare(expectations, result)
+	if assert.Len(t, compResult.Differences, 1) {
+		assert.Equal(t, "on", compResult.Differences[0].Expected)
+		assert.Equal(t, "<empty>", compResult.Differences[0].Actual)
+	}
+}
+
+func TestComparator_Compare_BodyResponse_Decode(t *testing.T) {
+	comp := New()
+
+	encoded, err := compression.Encode(extproctorv1.BodyEncoding_GZIP, []byte("modified body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := &client.ProcessingResult{
+		Responses: []*client.PhaseResponse{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_BODY,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_RequestBody{
+						RequestBody: &extprocv3.BodyResponse{
+							Response: &extprocv3.CommonResponse{
+								BodyMutation: &extprocv3.BodyMutation{
+									Mutation: &extprocv3.BodyMutation_Body{Body: encoded},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	expect := func(body string, decode extproctorv1.BodyEncoding) []*extproctorv1.ExtProcExpectation {
+		return []*extproctorv1.ExtProcExpectation{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_BODY,
+				Response: &extproctorv1.ExtProcExpectation_BodyResponse{
+					BodyResponse: &extproctorv1.BodyExpectation{Body: []byte(body), Decode: decode},
+				},
+			},
+		}
+	}
+
+	assert.True(t, comp.Compare(expect("modified body", extproctorv1.BodyEncoding_GZIP), result).Passed)
+	assert.False(t, comp.Compare(expect("modified body", extproctorv1.BodyEncoding_BODY_ENCODING_UNSPECIFIED), result).Passed)
+	assert.False(t, comp.Compare(expect("other body", extproctorv1.BodyEncoding_GZIP), result).Passed)
+
+	compResult := comp.Compare(expect("modified body", extproctorv1.BodyEncoding_BR), result)
+	assert.False(t, compResult.Passed)
+	if assert.Len(t, compResult.Differences, 1) {
+		assert.Equal(t, "br encoded body", compResult.Differences[0].Expected)
+		assert.Contains(t, compResult.Differences[0].Actual, "invalid br body")
+	}
+}
+
+func TestComparator_Compare_WithMatcher(t *testing.T) {
+	// The matcher requires the dynamic metadata the expectations cannot express
+	comp := New(WithMatcher(func(exp *extproctorv1.ExtProcExpectation, resp *extprocv3.ProcessingResponse) []Difference {
+		if resp.GetDynamicMetadata() == nil {
+			return []Difference{{Phase: exp.Phase, Path: "dynamic_metadata", Expected: "set", Actual: "<none>"}}
+		}
+		return nil
+	}))
+
+	expectations := []*extproctorv1.ExtProcExpectation{
+		{
+			Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+				HeadersResponse: &extproctorv1.HeadersExpectation{},
+			},
+		},
+	}
+	result := &client.ProcessingResult{
+		Responses: []*client.PhaseResponse{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_RequestHeaders{
+						RequestHeaders: &extprocv3.HeadersResponse{},
+					},
+				},
+			},
+		},
+	}
+
+	cr := comp.Compare(expectations, result)
+	assert.False(t, cr.Passed)
+	assert.Equal(t, []Difference{{
+		Phase:    extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+		Path:     "dynamic_metadata",
+		Expected: "set",
+		Actual:   "<none>",
+	}}, cr.Differences)
+
+	// Ignored paths apply to the differences of the matchers
+	expectations[0].IgnorePaths = []string{"dynamic_metadata"}
+	assert.True(t, comp.Compare(expectations, result).Passed)
+}
@@ -6,6 +6,8 @@ package comparator
 import (
 	"fmt"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
 )
@@ -20,9 +22,59 @@ func FormatDifferences(diffs []Difference) string {
 	sb.WriteString("Differences:\n")
 
 	for _, d := range diffs {
-		sb.WriteString(fmt.Sprintf("  [%s] %s:\n", phaseName(d.Phase), d.Path))
-		sb.WriteString(fmt.Sprintf("    expected: %s\n", d.Expected))
-		sb.WriteString(fmt.Sprintf("    actual:   %s\n", d.Actual))
+		if d.Group != "" {
+			sb.WriteString(fmt.Sprintf("  [%s] %s (group %s):\n", phaseName(d.Phase), d.Path, d.Group))
+		} else {
+			sb.WriteString(fmt.Sprintf("  [%s] %s:\n", phaseName(d.Phase), d.Path))
+		}
+		sb.WriteString(fmt.Sprintf("    expected: %s\n", FormatValue(d.Expected, 0)))
+		sb.WriteString(fmt.Sprintf("    actual:   %s\n", FormatValue(d.Actual, 0)))
+	}
+
+	return sb.String()
+}
+
+// FormatValue renders a difference value safely for terminals and reports.
+// Invalid UTF-8 and non-printable bytes are hex-escaped (common control
+// characters use their C escape), and values longer than maxBytes are
+// truncated with an ellipsis and their total length. A zero maxBytes disables
+// truncation.
+func FormatValue(s string, maxBytes int) string {
+	truncated := false
+	value := s
+	if maxBytes > 0 && len(s) > maxBytes {
+		value = s[:maxBytes]
+		// Do not split a multi-byte character
+		for n := 0; n < utf8.UTFMax-1 && len(value) > 0 && !utf8.RuneStart(s[len(value)]); n++ {
+			value = value[:len(value)-1]
+		}
+		truncated = true
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(value); {
+		r, size := utf8.DecodeRuneInString(value[i:])
+		switch {
+		case r == utf8.RuneError && size <= 1:
+			fmt.Fprintf(&sb, "\\x%02x", value[i])
+		case r == '\n':
+			sb.WriteString("\\n")
+		case r == '\r':
+			sb.WriteString("\\r")
+		case r == '\t':
+			sb.WriteString("\\t")
+		case !unicode.IsPrint(r) && r != ' ':
+			for j := 0; j < size; j++ {
+				fmt.Fprintf(&sb, "\\x%02x", value[i+j])
+			}
+		default:
+			sb.WriteString(value[i : i+size])
+		}
+		i += size
+	}
+
+	if truncated {
+		fmt.Fprintf(&sb, "… (%d of %d bytes)", len(value), len(s))
 	}
 
 	return sb.String()
@@ -60,6 +112,12 @@ func phaseName(phase extproctorv1.ProcessingPhase) string {
 		return "RESPONSE_BODY"
 	case extproctorv1.ProcessingPhase_RESPONSE_TRAILERS:
 		return "RESPONSE_TRAILERS"
+	case extproctorv1.ProcessingPhase_ANY_REQUEST:
+		return "ANY_REQUEST"
+	case extproctorv1.ProcessingPhase_ANY_RESPONSE:
+		return "ANY_RESPONSE"
+	case extproctorv1.ProcessingPhase_ANY:
+		return "ANY"
 	default:
 		return "UNKNOWN"
 	}
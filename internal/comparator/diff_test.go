@@ -126,3 +126,41 @@ func TestPhaseName_AllPhases(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		maxBytes int
+		want     string
+	}{
+		{name: "plain", in: "hello world", want: "hello world"},
+		{name: "unicode", in: "héllo 世界", want: "héllo 世界"},
+		{name: "control characters", in: "a\nb\tc\rd\x00e\x1b", want: `a\nb\tc\rd\x00e\x1b`},
+		{name: "invalid utf-8", in: "ok\xff\xfe", want: `ok\xff\xfe`},
+		{name: "non-printable rune", in: "a​b", want: `a\xe2\x80\x8bb`},
+		{name: "truncated", in: "0123456789", maxBytes: 4, want: "0123… (4 of 10 bytes)"},
+		{name: "not truncated at limit", in: "0123", maxBytes: 4, want: "0123"},
+		{name: "truncated on rune boundary", in: "aé世界", maxBytes: 4, want: "aé… (3 of 9 bytes)"},
+		{name: "truncated binary", in: "\x00\x01\x02\x03", maxBytes: 2, want: `\x00\x01… (2 of 4 bytes)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FormatValue(tt.in, tt.maxBytes))
+		})
+	}
+}
+
+func TestFormatDifferences_EscapesValues(t *testing.T) {
+	result := FormatDifferences([]Difference{
+		{
+			Phase:    extproctorv1.ProcessingPhase_REQUEST_BODY,
+			Path:     "body.body_mutation.body",
+			Expected: "line1\nline2",
+			Actual:   "\xff",
+		},
+	})
+	assert.Contains(t, result, `expected: line1\nline2`)
+	assert.Contains(t, result, `actual:   \xff`)
+}
@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"fmt"
+	"strings"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/testing/protocmp"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// compareExactResponse compares the whole ProcessingResponse against the
+// expected one, field by field.
+func (c *Comparator) compareExactResponse(phase extproctorv1.ProcessingPhase, exp *extproctorv1.ExactResponseExpectation, resp *extprocv3.ProcessingResponse) []Difference {
+	var diffs []Difference
+
+	opts := []cmp.Option{protocmp.Transform()}
+	for i, name := range exp.IgnoreFields {
+		opt, err := ignoreFieldOption(name)
+		if err != nil {
+			diffs = append(diffs, Difference{
+				Phase:    phase,
+				Path:     fmt.Sprintf("exact_response.ignore_fields[%d]", i),
+				Expected: "a known field name",
+				Actual:   err.Error(),
+			})
+			continue
+		}
+		opts = append(opts, opt)
+	}
+	if len(diffs) > 0 {
+		return diffs
+	}
+
+	expected := exp.Response
+	if expected == nil {
+		expected = &extprocv3.ProcessingResponse{}
+	}
+
+	if !cmp.Equal(expected, resp, opts...) {
+		diffs = append(diffs, Difference{
+			Phase:    phase,
+			Path:     "exact_response",
+			Expected: formatMessage(expected),
+			Actual:   formatMessage(resp),
+		})
+	}
+
+	return diffs
+}
+
+// ignoreFieldOption builds the protocmp option ignoring the named field. Names
+// without a message qualifier refer to ProcessingResponse fields.
+func ignoreFieldOption(name string) (cmp.Option, error) {
+	messageName := protoreflect.FullName("envoy.service.ext_proc.v3.ProcessingResponse")
+	fieldName := protoreflect.Name(name)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		messageName = protoreflect.FullName(name[:i])
+		fieldName = protoreflect.Name(name[i+1:])
+	}
+
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(messageName)
+	if err != nil {
+		return nil, fmt.Errorf("unknown message %q", messageName)
+	}
+	if mt.Descriptor().Fields().ByName(fieldName) == nil {
+		return nil, fmt.Errorf("unknown field %q in %s", fieldName, messageName)
+	}
+
+	return protocmp.IgnoreFields(mt.New().Interface(), fieldName), nil
+}
+
+// formatMessage renders a ProcessingResponse on a single line for reports.
+func formatMessage(resp *extprocv3.ProcessingResponse) string {
+	if resp == nil {
+		return "<nil>"
+	}
+	return prototext.MarshalOptions{}.Format(resp)
+}
@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import "google.golang.org/protobuf/reflect/protoreflect"
+
+// HandledFields lists the ProcessingResponse fields asserted by the typed
+// expectations. It is checked by `extproctor apicheck` against the compiled
+// Envoy descriptors; keep it in sync when a comparison is added.
+var HandledFields = []protoreflect.FullName{
+	"envoy.service.ext_proc.v3.ProcessingResponse.request_headers",
+	"envoy.service.ext_proc.v3.ProcessingResponse.response_headers",
+	"envoy.service.ext_proc.v3.ProcessingResponse.request_body",
+	"envoy.service.ext_proc.v3.ProcessingResponse.response_body",
+	"envoy.service.ext_proc.v3.ProcessingResponse.request_trailers",
+	"envoy.service.ext_proc.v3.ProcessingResponse.response_trailers",
+	"envoy.service.ext_proc.v3.ProcessingResponse.immediate_response",
+	"envoy.service.ext_proc.v3.ProcessingResponse.dynamic_metadata",
+	"envoy.service.ext_proc.v3.ProcessingResponse.mode_override",
+	"envoy.service.ext_proc.v3.HeadersResponse.response",
+	"envoy.service.ext_proc.v3.BodyResponse.response",
+	"envoy.service.ext_proc.v3.TrailersResponse.header_mutation",
+	"envoy.service.ext_proc.v3.CommonResponse.header_mutation",
+	"envoy.service.ext_proc.v3.CommonResponse.body_mutation",
+	"envoy.service.ext_proc.v3.CommonResponse.status",
+	"envoy.service.ext_proc.v3.CommonResponse.trailers",
+	"envoy.service.ext_proc.v3.CommonResponse.clear_route_cache",
+	"envoy.service.ext_proc.v3.HeaderMutation.set_headers",
+	"envoy.service.ext_proc.v3.HeaderMutation.remove_headers",
+	"envoy.service.ext_proc.v3.BodyMutation.body",
+	"envoy.service.ext_proc.v3.BodyMutation.clear_body",
+	"envoy.service.ext_proc.v3.ImmediateResponse.status",
+	"envoy.service.ext_proc.v3.ImmediateResponse.headers",
+	"envoy.service.ext_proc.v3.ImmediateResponse.body",
+}
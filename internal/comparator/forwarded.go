@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"strings"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// forwardedForHeader is the header carrying the client address chain.
+const forwardedForHeader = "x-forwarded-for"
+
+// forwardedForUnchanged is the marker of an x-forwarded-for header left
+// untouched, as rendered in differences.
+const forwardedForUnchanged = "<unchanged>"
+
+// compareForwardedFor compares the sanitization of the x-forwarded-for chain
+// by the header mutation against the expected one.
+func compareForwardedFor(phase extproctorv1.ProcessingPhase, exp *extproctorv1.ForwardedForExpectation, mutation *extprocv3.HeaderMutation) []Difference {
+	value, set := "", false
+	for _, h := range mutation.GetSetHeaders() {
+		if strings.EqualFold(h.GetHeader().GetKey(), forwardedForHeader) {
+			value, set = getHeaderValue(h.GetHeader()), true
+		}
+	}
+	removed := false
+	for _, h := range mutation.GetRemoveHeaders() {
+		if strings.EqualFold(h, forwardedForHeader) {
+			removed = true
+		}
+	}
+
+	// actual renders the treatment of the header by the filter
+	actual := func() string {
+		switch {
+		case set:
+			return displayHeaderValue(value)
+		case removed:
+			return headerRemoved
+		default:
+			return forwardedForUnchanged
+		}
+	}
+
+	var expected string
+	switch s := exp.Sanitization.(type) {
+	case *extproctorv1.ForwardedForExpectation_Removed:
+		if !s.Removed || (removed && !set) {
+			return nil
+		}
+		expected = headerRemoved
+	case *extproctorv1.ForwardedForExpectation_Unchanged:
+		if !s.Unchanged || (!removed && !set) {
+			return nil
+		}
+		expected = forwardedForUnchanged
+	case *extproctorv1.ForwardedForExpectation_Chain:
+		expected = strings.Join(s.Chain.GetHops(), ", ")
+		if set && slicesEqualTrimmed(strings.Split(value, ","), s.Chain.GetHops()) {
+			return nil
+		}
+		return []Difference{{
+			Phase:    phase,
+			Path:     "forwarded_for.chain",
+			Expected: expected,
+			Actual:   actual(),
+		}}
+	default:
+		return nil
+	}
+
+	return []Difference{{
+		Phase:    phase,
+		Path:     "forwarded_for",
+		Expected: expected,
+		Actual:   actual(),
+	}}
+}
+
+// slicesEqualTrimmed reports whether the hops are equal to the expected ones,
+// ignoring the spaces around them.
+func slicesEqualTrimmed(hops, expected []string) bool {
+	if len(hops) != len(expected) {
+		return false
+	}
+	for i := range hops {
+		if strings.TrimSpace(hops[i]) != strings.TrimSpace(expected[i]) {
+			return false
+		}
+	}
+	return true
+}
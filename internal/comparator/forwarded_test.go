@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func TestCompareForwardedFor(t *testing.T) {
+	setXFF := &extprocv3.HeaderMutation{
+		SetHeaders: []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{Key: "x-forwarded-for", Value: "203.0.113.7,10.0.0.1"}},
+		},
+	}
+	removeXFF := &extprocv3.HeaderMutation{RemoveHeaders: []string{"X-Forwarded-For"}}
+
+	chain := &extproctorv1.ForwardedForExpectation{
+		Sanitization: &extproctorv1.ForwardedForExpectation_Chain{
+			Chain: &extproctorv1.ForwardedForChain{Hops: []string{"203.0.113.7", "10.0.0.1"}},
+		},
+	}
+	removed := &extproctorv1.ForwardedForExpectation{
+		Sanitization: &extproctorv1.ForwardedForExpectation_Removed{Removed: true},
+	}
+	unchanged := &extproctorv1.ForwardedForExpectation{
+		Sanitization: &extproctorv1.ForwardedForExpectation_Unchanged{Unchanged: true},
+	}
+
+	tests := []struct {
+		name     string
+		exp      *extproctorv1.ForwardedForExpectation
+		mutation *extprocv3.HeaderMutation
+		path     string
+		actual   string
+	}{
+		{name: "chain matches", exp: chain, mutation: setXFF},
+		{name: "chain untouched", exp: chain, path: "forwarded_for.chain", actual: "<unchanged>"},
+		{name: "chain removed", exp: chain, mutation: removeXFF, path: "forwarded_for.chain", actual: "<removed>"},
+		{name: "removed matches", exp: removed, mutation: removeXFF},
+		{name: "removed but set", exp: removed, mutation: setXFF, path: "forwarded_for", actual: "203.0.113.7,10.0.0.1"},
+		{name: "unchanged matches", exp: unchanged},
+		{name: "unchanged but removed", exp: unchanged, mutation: removeXFF, path: "forwarded_for", actual: "<removed>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs := compareForwardedFor(extproctorv1.ProcessingPhase_REQUEST_HEADERS, tt.exp, tt.mutation)
+			if tt.path == "" {
+				assert.Empty(t, diffs)
+				return
+			}
+			require.Len(t, diffs, 1)
+			assert.Equal(t, tt.path, diffs[0].Path)
+			assert.Equal(t, tt.actual, diffs[0].Actual)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// graphqlResponse is the shape of a GraphQL response body.
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []graphqlError  `json:"errors"`
+}
+
+// graphqlError is an error of a GraphQL response.
+type graphqlError struct {
+	Message    string `json:"message"`
+	Extensions struct {
+		Code string `json:"code"`
+	} `json:"extensions"`
+}
+
+// compareGraphql compares a body, as a GraphQL response, against the
+// matcher, and returns a difference per unmet assertion.
+func compareGraphql(phase extproctorv1.ProcessingPhase, path string, exp *extproctorv1.GraphqlResponseMatcher, body []byte) []Difference {
+	var resp graphqlResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return []Difference{{
+			Phase:    phase,
+			Path:     path,
+			Expected: "GraphQL response",
+			Actual:   fmt.Sprintf("invalid JSON: %v", err),
+		}}
+	}
+
+	messages := make([]string, 0, len(resp.Errors))
+	codes := make([]string, 0, len(resp.Errors))
+	for _, e := range resp.Errors {
+		messages = append(messages, e.Message)
+		if e.Extensions.Code != "" {
+			codes = append(codes, e.Extensions.Code)
+		}
+	}
+
+	var diffs []Difference
+	if exp.NoErrors && len(resp.Errors) > 0 {
+		diffs = append(diffs, Difference{
+			Phase:    phase,
+			Path:     path + ".errors",
+			Expected: "<none>",
+			Actual:   strings.Join(messages, "; "),
+		})
+	}
+	for _, m := range exp.ErrorMessages {
+		if !slices.Contains(messages, m) {
+			diffs = append(diffs, Difference{
+				Phase:    phase,
+				Path:     fmt.Sprintf("%s.error_messages[%s]", path, m),
+				Expected: m,
+				Actual:   describeList(messages),
+			})
+		}
+	}
+	for _, c := range exp.ErrorCodes {
+		if !slices.Contains(codes, c) {
+			diffs = append(diffs, Difference{
+				Phase:    phase,
+				Path:     fmt.Sprintf("%s.error_codes[%s]", path, c),
+				Expected: c,
+				Actual:   describeList(codes),
+			})
+		}
+	}
+	if exp.Data != "" {
+		diffs = append(diffs, compareJSON(phase, path+".data", exp.Data, resp.Data)...)
+	}
+
+	return diffs
+}
+
+// compareJSON compares JSON values whatever their key order and formatting.
+func compareJSON(phase extproctorv1.ProcessingPhase, path, expected string, actual json.RawMessage) []Difference {
+	var want, got any
+	if err := json.Unmarshal([]byte(expected), &want); err != nil {
+		return []Difference{{
+			Phase:    phase,
+			Path:     path,
+			Expected: expected,
+			Actual:   fmt.Sprintf("invalid expected JSON: %v", err),
+		}}
+	}
+	if len(actual) > 0 {
+		if err := json.Unmarshal(actual, &got); err != nil {
+			return []Difference{{Phase: phase, Path: path, Expected: expected, Actual: string(actual)}}
+		}
+	}
+	if reflect.DeepEqual(want, got) {
+		return nil
+	}
+
+	// Render both sides canonically for a readable difference
+	canonical := func(v any) string {
+		out, _ := json.Marshal(v)
+		return string(out)
+	}
+	return []Difference{{
+		Phase:    phase,
+		Path:     path,
+		Expected: canonical(want),
+		Actual:   canonical(got),
+	}}
+}
+
+// describeList renders the actual values of a list assertion.
+func describeList(values []string) string {
+	if len(values) == 0 {
+		return "<none>"
+	}
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+const graphqlDenied = `{
+  "data": null,
+  "errors": [
+    {"message": "query depth 12 exceeds limit 10", "extensions": {"code": "DEPTH_LIMIT"}},
+    {"message": "field user.ssn is forbidden"}
+  ]
+}`
+
+func TestCompareGraphql_Errors(t *testing.T) {
+	exp := &extproctorv1.GraphqlResponseMatcher{
+		ErrorMessages: []string{"query depth 12 exceeds limit 10"},
+		ErrorCodes:    []string{"DEPTH_LIMIT"},
+		Data:          "null",
+	}
+	assert.Empty(t, compareGraphql(extproctorv1.ProcessingPhase_REQUEST_BODY, "body", exp, []byte(graphqlDenied)))
+
+	exp = &extproctorv1.GraphqlResponseMatcher{
+		NoErrors:      true,
+		ErrorMessages: []string{"rate limited"},
+		ErrorCodes:    []string{"RATE_LIMITED"},
+	}
+	diffs := compareGraphql(extproctorv1.ProcessingPhase_REQUEST_BODY, "body", exp, []byte(graphqlDenied))
+	require.Len(t, diffs, 3)
+	assert.Equal(t, "body.errors", diffs[0].Path)
+	assert.Equal(t, "query depth 12 exceeds limit 10; field user.ssn is forbidden", diffs[0].Actual)
+	assert.Equal(t, "body.error_messages[rate limited]", diffs[1].Path)
+	assert.Equal(t, "body.error_codes[RATE_LIMITED]", diffs[2].Path)
+	assert.Equal(t, "DEPTH_LIMIT", diffs[2].Actual)
+}
+
+func TestCompareGraphql_Data(t *testing.T) {
+	body := []byte(`{"data": {"user": {"name": "alice", "id": "42"}}}`)
+
+	exp := &extproctorv1.GraphqlResponseMatcher{NoErrors: true, Data: `{"user": {"id": "42", "name": "alice"}}`}
+	assert.Empty(t, compareGraphql(extproctorv1.ProcessingPhase_RESPONSE_BODY, "body", exp, body))
+
+	exp = &extproctorv1.GraphqlResponseMatcher{Data: `{"user": {"id": "42", "name": "bob"}}`}
+	diffs := compareGraphql(extproctorv1.ProcessingPhase_RESPONSE_BODY, "body", exp, body)
+	require.Len(t, diffs, 1)
+	assert.Equal(t, "body.data", diffs[0].Path)
+	assert.Equal(t, `{"user":{"id":"42","name":"bob"}}`, diffs[0].Expected)
+	assert.Equal(t, `{"user":{"id":"42","name":"alice"}}`, diffs[0].Actual)
+}
+
+func TestCompareGraphql_InvalidJSON(t *testing.T) {
+	diffs := compareGraphql(extproctorv1.ProcessingPhase_RESPONSE_BODY, "body", &extproctorv1.GraphqlResponseMatcher{NoErrors: true}, []byte("<html>"))
+	require.Len(t, diffs, 1)
+	assert.Equal(t, "GraphQL response", diffs[0].Expected)
+	assert.Contains(t, diffs[0].Actual, "invalid JSON")
+}
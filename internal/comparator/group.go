@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"fmt"
+
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+)
+
+// groupMembers returns the expectations of a group, in declaration order.
+func groupMembers(expectations []*extproctorv1.ExtProcExpectation, group string) []*extproctorv1.ExtProcExpectation {
+	var members []*extproctorv1.ExtProcExpectation
+	for _, exp := range expectations {
+		if exp.Group == group {
+			members = append(members, exp)
+		}
+	}
+	return members
+}
+
+// compareGroup compares the members of an assertion group against a single
+// response of their phase and body chunk: the first one they all match, or
+// the one with the fewest differences. Each member reports its own
+// differences; a failed member stops the evaluation of the group unless it
+// continues on failure.
+func (c *Comparator) compareGroup(cr *ComparisonResult, members []*extproctorv1.ExtProcExpectation, result *client.ProcessingResult, matchedResponses map[int]bool) {
+	best := -1
+	var bestDiffs [][]Difference
+	bestCount := 0
+	for j, resp := range result.Responses {
+		if matchedResponses[j] || !appliesTo(members[0], resp) {
+			continue
+		}
+
+		diffs := make([][]Difference, len(members))
+		count := 0
+		for i, exp := range members {
+			diffs[i] = c.compareExpectation(exp, resp.Response)
+			count += len(diffs[i])
+		}
+		if best == -1 || count < bestCount {
+			best, bestDiffs, bestCount = j, diffs, count
+		}
+		if count == 0 {
+			break
+		}
+	}
+
+	if best == -1 {
+		for _, exp := range members {
+			cr.addUnmatched(exp, explainUnmatched(exp, result))
+		}
+		return
+	}
+
+	resp := result.Responses[best]
+	stoppedAt := -1
+	for i, exp := range members {
+		if stoppedAt >= 0 {
+			cr.addUnmatched(exp, fmt.Sprintf("not evaluated: group %q stopped at the failure of its expectation #%d", exp.Group, stoppedAt+1))
+			continue
+		}
+
+		if len(bestDiffs[i]) == 0 {
+			matchedResponses[best] = true
+			cr.Matched = append(cr.Matched, &MatchedExpectation{
+				Expectation: exp,
+				Response:    resp,
+			})
+			continue
+		}
+
+		cr.addUnmatched(exp, "")
+		for _, d := range bestDiffs[i] {
+			d.Group = exp.Group
+			cr.Differences = append(cr.Differences, d)
+		}
+		if !exp.ContinueOnFailure {
+			stoppedAt = i
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+)
+
+// headerExpectation expects a request header set by the filter.
+func headerExpectation(group, key, value string, continueOnFailure bool) *extproctorv1.ExtProcExpectation {
+	return &extproctorv1.ExtProcExpectation{
+		Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+		Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+			HeadersResponse: &extproctorv1.HeadersExpectation{SetHeaders: map[string]string{key: value}},
+		},
+		Group:             group,
+		ContinueOnFailure: continueOnFailure,
+	}
+}
+
+func requestHeadersResult(headers map[string]string) *client.ProcessingResult {
+	mutation := &extprocv3.HeaderMutation{}
+	for k, v := range headers {
+		mutation.SetHeaders = append(mutation.SetHeaders, &corev3.HeaderValueOption{Header: &corev3.HeaderValue{Key: k, Value: v}})
+	}
+	return &client.ProcessingResult{Responses: []*client.PhaseResponse{{
+		Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+		Response: &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestHeaders{
+			RequestHeaders: &extprocv3.HeadersResponse{Response: &extprocv3.CommonResponse{HeaderMutation: mutation}},
+		}},
+	}}}
+}
+
+func TestComparator_Compare_Group_AllMatch(t *testing.T) {
+	result := requestHeadersResult(map[string]string{"x-user": "alice", "x-role": "admin"})
+
+	// Ungrouped, the second expectation cannot match the consumed response
+	ungrouped := New().Compare([]*extproctorv1.ExtProcExpectation{
+		headerExpectation("", "x-user", "alice", false),
+		headerExpectation("", "x-role", "admin", false),
+	}, result)
+	assert.False(t, ungrouped.Passed)
+
+	grouped := New().Compare([]*extproctorv1.ExtProcExpectation{
+		headerExpectation("auth", "x-user", "alice", false),
+		headerExpectation("auth", "x-role", "admin", false),
+	}, result)
+	assert.True(t, grouped.Passed)
+	assert.Len(t, grouped.Matched, 2)
+	assert.Empty(t, grouped.Unexpected)
+}
+
+func TestComparator_Compare_Group_StopsAtFailure(t *testing.T) {
+	result := requestHeadersResult(map[string]string{"x-user": "alice", "x-role": "admin"})
+	exps := []*extproctorv1.ExtProcExpectation{
+		headerExpectation("auth", "x-user", "bob", false),
+		headerExpectation("auth", "x-role", "user", false),
+		headerExpectation("auth", "x-tenant", "acme", false),
+	}
+
+	cr := New().Compare(exps, result)
+	assert.False(t, cr.Passed)
+	require.Len(t, cr.Differences, 1)
+	assert.Equal(t, "auth", cr.Differences[0].Group)
+	assert.Equal(t, "set_headers[x-user]", cr.Differences[0].Path)
+	assert.Equal(t, exps, cr.Unmatched)
+	assert.NotContains(t, cr.UnmatchedReasons, exps[0])
+	assert.Equal(t, `not evaluated: group "auth" stopped at the failure of its expectation #1`, cr.UnmatchedReasons[exps[1]])
+	assert.Equal(t, `not evaluated: group "auth" stopped at the failure of its expectation #1`, cr.UnmatchedReasons[exps[2]])
+}
+
+func TestComparator_Compare_Group_ContinueOnFailure(t *testing.T) {
+	result := requestHeadersResult(map[string]string{"x-user": "alice", "x-role": "admin"})
+	exps := []*extproctorv1.ExtProcExpectation{
+		headerExpectation("auth", "x-user", "bob", true),
+		headerExpectation("auth", "x-role", "admin", true),
+		headerExpectation("auth", "x-tenant", "acme", false),
+		headerExpectation("auth", "x-plan", "pro", false),
+	}
+
+	cr := New().Compare(exps, result)
+	assert.False(t, cr.Passed)
+
+	paths := make([]string, 0, len(cr.Differences))
+	for _, d := range cr.Differences {
+		assert.Equal(t, "auth", d.Group)
+		paths = append(paths, d.Path)
+	}
+	assert.Equal(t, []string{"set_headers[x-user]", "set_headers[x-tenant]"}, paths)
+
+	require.Len(t, cr.Matched, 1)
+	assert.Same(t, exps[1], cr.Matched[0].Expectation)
+	assert.Equal(t, []*extproctorv1.ExtProcExpectation{exps[0], exps[2], exps[3]}, cr.Unmatched)
+	assert.Contains(t, cr.UnmatchedReasons[exps[3]], "stopped at the failure of its expectation #3")
+	assert.Empty(t, cr.Unexpected)
+}
+
+func TestComparator_Compare_Group_PhaseNotSent(t *testing.T) {
+	exps := []*extproctorv1.ExtProcExpectation{
+		{Phase: extproctorv1.ProcessingPhase_RESPONSE_HEADERS, Group: "resp", Response: &extproctorv1.ExtProcExpectation_HeadersResponse{HeadersResponse: &extproctorv1.HeadersExpectation{}}},
+		{Phase: extproctorv1.ProcessingPhase_RESPONSE_HEADERS, Group: "resp", Response: &extproctorv1.ExtProcExpectation_HeadersResponse{HeadersResponse: &extproctorv1.HeadersExpectation{}}},
+	}
+
+	cr := New().Compare(exps, requestHeadersResult(nil))
+	assert.False(t, cr.Passed)
+	assert.Equal(t, exps, cr.Unmatched)
+	for _, exp := range exps {
+		assert.Contains(t, cr.UnmatchedReasons[exp], "phase RESPONSE_HEADERS never reached")
+	}
+}
+
+func TestFormatDifferences_Group(t *testing.T) {
+	out := FormatDifferences([]Difference{{
+		Phase:    extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+		Path:     "set_headers[x-user]",
+		Expected: "bob",
+		Actual:   "alice",
+		Group:    "auth",
+	}})
+	assert.Contains(t, out, "[REQUEST_HEADERS] set_headers[x-user] (group auth):")
+}
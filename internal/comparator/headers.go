@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"fmt"
+	"strconv"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// Markers of header states, as rendered in differences.
+const (
+	headerNotSet  = "<not set>"
+	headerRemoved = "<removed>"
+	headerEmpty   = "<empty>"
+)
+
+// displayHeaderValue renders a header value in differences, telling an empty
+// value apart from a missing header.
+func displayHeaderValue(v string) string {
+	if v == "" {
+		return headerEmpty
+	}
+	return v
+}
+
+// missingHeader returns the marker of a header absent from the set headers:
+// removed when the mutation removes it, not set otherwise.
+func missingHeader(mutation *extprocv3.HeaderMutation, key string) string {
+	for _, h := range mutation.GetRemoveHeaders() {
+		if h == key {
+			return headerRemoved
+		}
+	}
+	return headerNotSet
+}
+
+// compareSetHeaderOptions compares the expected set headers with their value
+// and header value options.
+func (c *Comparator) compareSetHeaderOptions(phase extproctorv1.ProcessingPhase, exp []*extproctorv1.SetHeaderExpectation, resp *extprocv3.CommonResponse) []Difference {
+	var diffs []Difference
+
+	mutation := resp.GetHeaderMutation()
+	for _, e := range exp {
+		path := fmt.Sprintf("set_header_options[%s]", e.Key)
+
+		var actual *corev3.HeaderValueOption
+		for _, h := range mutation.GetSetHeaders() {
+			if h.Header != nil && h.Header.Key == e.Key {
+				actual = h
+				break
+			}
+		}
+		if actual == nil {
+			diffs = append(diffs, Difference{
+				Phase:    phase,
+				Path:     path,
+				Expected: displayHeaderValue(e.Value),
+				Actual:   missingHeader(mutation, e.Key),
+			})
+			continue
+		}
+
+		if value := getHeaderValue(actual.Header); !c.headerValueEqual(value, e.Value) {
+			diffs = append(diffs, Difference{
+				Phase:    phase,
+				Path:     path + ".value",
+				Expected: displayHeaderValue(e.Value),
+				Actual:   displayHeaderValue(value),
+			})
+		}
+
+		if actual.KeepEmptyValue != e.KeepEmptyValue {
+			diffs = append(diffs, Difference{
+				Phase:    phase,
+				Path:     path + ".keep_empty_value",
+				Expected: strconv.FormatBool(e.KeepEmptyValue),
+				Actual:   strconv.FormatBool(actual.KeepEmptyValue),
+			})
+		}
+
+		if e.AppendAction != "" && actual.AppendAction.String() != e.AppendAction {
+			diffs = append(diffs, Difference{
+				Phase:    phase,
+				Path:     path + ".append_action",
+				Expected: e.AppendAction,
+				Actual:   actual.AppendAction.String(),
+			})
+		}
+	}
+
+	return diffs
+}
+
+// compareHeaderEntries compares expected set headers allowing repeated keys:
+// each expected entry consumes one matching set header, so a key listed twice
+// must be set twice.
+func (c *Comparator) compareHeaderEntries(phase extproctorv1.ProcessingPhase, path string, exp []*extproctorv1.HeaderEntry, mutation *extprocv3.HeaderMutation) []Difference {
+	var diffs []Difference
+
+	used := make([]bool, len(mutation.GetSetHeaders()))
+	var missing []*extproctorv1.HeaderEntry
+	for _, e := range exp {
+		found := false
+		for i, h := range mutation.GetSetHeaders() {
+			if !used[i] && h.Header != nil && h.Header.Key == e.Key && c.headerValueEqual(getHeaderValue(h.Header), e.Value) {
+				used[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, e)
+		}
+	}
+
+	for _, e := range missing {
+		actual := missingHeader(mutation, e.Key)
+		for i, h := range mutation.GetSetHeaders() {
+			if !used[i] && h.Header != nil && h.Header.Key == e.Key {
+				// Report the first unmatched value of the key instead
+				used[i] = true
+				actual = displayHeaderValue(getHeaderValue(h.Header))
+				break
+			}
+		}
+		diffs = append(diffs, Difference{
+			Phase:    phase,
+			Path:     fmt.Sprintf("%s[%s]", path, e.Key),
+			Expected: displayHeaderValue(e.Value),
+			Actual:   actual,
+		})
+	}
+
+	return diffs
+}
@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+)
+
+// compareNoResponse checks that the ExtProc service did not answer the phase
+// of a no_response expectation, as expected in observability mode. The
+// responses of the phase are left unexpected.
+func (cr *ComparisonResult) compareNoResponse(exp *extproctorv1.ExtProcExpectation, result *client.ProcessingResult) {
+	for _, resp := range result.Responses {
+		if !appliesTo(exp, resp) {
+			continue
+		}
+
+		actual := formatMessage(resp.Response)
+		if actual == "" {
+			actual = "empty response"
+		}
+		cr.addUnmatched(exp, "")
+		cr.Differences = append(cr.Differences, Difference{
+			Phase:    exp.Phase,
+			Path:     "no_response",
+			Expected: "no response",
+			Actual:   actual,
+		})
+		return
+	}
+}
@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"fmt"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"google.golang.org/protobuf/encoding/prototext"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// comparePassthrough checks that the filter answered a phase with a bare
+// CONTINUE: the response of the phase, without any mutation, route cache
+// clearing, dynamic metadata or mode override.
+func (c *Comparator) comparePassthrough(phase extproctorv1.ProcessingPhase, resp *extprocv3.ProcessingResponse) []Difference {
+	if immediate := resp.GetImmediateResponse(); immediate != nil {
+		return []Difference{{
+			Phase:    phase,
+			Path:     "passthrough",
+			Expected: "CONTINUE",
+			Actual:   fmt.Sprintf("immediate response (status %d)", immediate.GetStatus().GetCode()),
+		}}
+	}
+
+	var (
+		common   *extprocv3.CommonResponse
+		mutation *extprocv3.HeaderMutation
+		expected string
+		matched  bool
+	)
+	switch phase {
+	case extproctorv1.ProcessingPhase_REQUEST_HEADERS:
+		expected = "request_headers"
+		_, matched = resp.Response.(*extprocv3.ProcessingResponse_RequestHeaders)
+		common = resp.GetRequestHeaders().GetResponse()
+	case extproctorv1.ProcessingPhase_RESPONSE_HEADERS:
+		expected = "response_headers"
+		_, matched = resp.Response.(*extprocv3.ProcessingResponse_ResponseHeaders)
+		common = resp.GetResponseHeaders().GetResponse()
+	case extproctorv1.ProcessingPhase_REQUEST_BODY:
+		expected = "request_body"
+		_, matched = resp.Response.(*extprocv3.ProcessingResponse_RequestBody)
+		common = resp.GetRequestBody().GetResponse()
+	case extproctorv1.ProcessingPhase_RESPONSE_BODY:
+		expected = "response_body"
+		_, matched = resp.Response.(*extprocv3.ProcessingResponse_ResponseBody)
+		common = resp.GetResponseBody().GetResponse()
+	case extproctorv1.ProcessingPhase_REQUEST_TRAILERS:
+		expected = "request_trailers"
+		_, matched = resp.Response.(*extprocv3.ProcessingResponse_RequestTrailers)
+		mutation = resp.GetRequestTrailers().GetHeaderMutation()
+	case extproctorv1.ProcessingPhase_RESPONSE_TRAILERS:
+		expected = "response_trailers"
+		_, matched = resp.Response.(*extprocv3.ProcessingResponse_ResponseTrailers)
+		mutation = resp.GetResponseTrailers().GetHeaderMutation()
+	}
+	if !matched {
+		return []Difference{{
+			Phase:    phase,
+			Path:     "response_type",
+			Expected: expected,
+			Actual:   fmt.Sprintf("%T", resp.Response),
+		}}
+	}
+
+	var diffs []Difference
+	unexpected := func(path, actual string) {
+		diffs = append(diffs, Difference{
+			Phase:    phase,
+			Path:     "passthrough." + path,
+			Expected: "<none>",
+			Actual:   actual,
+		})
+	}
+
+	if common != nil {
+		if common.Status != extprocv3.CommonResponse_CONTINUE {
+			diffs = append(diffs, Difference{
+				Phase:    phase,
+				Path:     "passthrough.status",
+				Expected: extprocv3.CommonResponse_CONTINUE.String(),
+				Actual:   common.Status.String(),
+			})
+		}
+		mutation = common.HeaderMutation
+		if common.BodyMutation != nil {
+			unexpected("body_mutation", prototext.MarshalOptions{}.Format(common.BodyMutation))
+		}
+		if len(common.GetTrailers().GetHeaders()) > 0 {
+			unexpected("trailers", headerKeys(common.Trailers.Headers))
+		}
+		if common.ClearRouteCache {
+			unexpected("clear_route_cache", "true")
+		}
+	}
+
+	if len(mutation.GetSetHeaders()) > 0 {
+		headers := make([]*corev3.HeaderValue, 0, len(mutation.SetHeaders))
+		for _, h := range mutation.SetHeaders {
+			headers = append(headers, h.GetHeader())
+		}
+		unexpected("header_mutation.set_headers", headerKeys(headers))
+	}
+	if len(mutation.GetRemoveHeaders()) > 0 {
+		unexpected("header_mutation.remove_headers", strings.Join(mutation.RemoveHeaders, ", "))
+	}
+
+	if resp.DynamicMetadata != nil {
+		unexpected("dynamic_metadata", prototext.MarshalOptions{}.Format(resp.DynamicMetadata))
+	}
+	if resp.ModeOverride != nil {
+		unexpected("mode_override", prototext.MarshalOptions{}.Format(resp.ModeOverride))
+	}
+
+	return diffs
+}
+
+// headerKeys returns the keys of headers, comma-separated.
+func headerKeys(headers []*corev3.HeaderValue) string {
+	keys := make([]string, 0, len(headers))
+	for _, h := range headers {
+		keys = append(keys, h.GetKey())
+	}
+	return strings.Join(keys, ", ")
+}
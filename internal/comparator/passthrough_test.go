@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocfilterv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+)
+
+func TestComparator_Compare_Passthrough(t *testing.T) {
+	requestHeaders := func(common *extprocv3.CommonResponse) *extprocv3.ProcessingResponse {
+		return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestHeaders{
+			RequestHeaders: &extprocv3.HeadersResponse{Response: common},
+		}}
+	}
+
+	tests := []struct {
+		name  string
+		phase extproctorv1.ProcessingPhase
+		resp  *extprocv3.ProcessingResponse
+		paths []string
+	}{
+		{
+			name:  "bare continue",
+			phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			resp:  requestHeaders(nil),
+		},
+		{
+			name:  "empty mutation",
+			phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			resp:  requestHeaders(&extprocv3.CommonResponse{HeaderMutation: &extprocv3.HeaderMutation{}}),
+		},
+		{
+			name:  "empty trailers",
+			phase: extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
+			resp: &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseTrailers{
+				ResponseTrailers: &extprocv3.TrailersResponse{},
+			}},
+		},
+		{
+			name:  "mutations",
+			phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			resp: requestHeaders(&extprocv3.CommonResponse{
+				Status: extprocv3.CommonResponse_CONTINUE_AND_REPLACE,
+				HeaderMutation: &extprocv3.HeaderMutation{
+					SetHeaders:    []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "x-user", Value: "alice"}}},
+					RemoveHeaders: []string{"authorization"},
+				},
+				BodyMutation:    &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_ClearBody{ClearBody: true}},
+				Trailers:        &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: "x-trailer"}}},
+				ClearRouteCache: true,
+			}),
+			paths: []string{
+				"passthrough.status",
+				"passthrough.body_mutation",
+				"passthrough.trailers",
+				"passthrough.clear_route_cache",
+				"passthrough.header_mutation.set_headers",
+				"passthrough.header_mutation.remove_headers",
+			},
+		},
+		{
+			name:  "trailer mutation",
+			phase: extproctorv1.ProcessingPhase_REQUEST_TRAILERS,
+			resp: &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestTrailers{
+				RequestTrailers: &extprocv3.TrailersResponse{HeaderMutation: &extprocv3.HeaderMutation{RemoveHeaders: []string{"grpc-status"}}},
+			}},
+			paths: []string{"passthrough.header_mutation.remove_headers"},
+		},
+		{
+			name:  "dynamic metadata and mode override",
+			phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			resp: &extprocv3.ProcessingResponse{
+				Response:        requestHeaders(nil).Response,
+				DynamicMetadata: &structpb.Struct{Fields: map[string]*structpb.Value{"user": structpb.NewStringValue("alice")}},
+				ModeOverride:    &extprocfilterv3.ProcessingMode{ResponseHeaderMode: extprocfilterv3.ProcessingMode_SKIP},
+			},
+			paths: []string{"passthrough.dynamic_metadata", "passthrough.mode_override"},
+		},
+		{
+			name:  "immediate response",
+			phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			resp: &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+				ImmediateResponse: &extprocv3.ImmediateResponse{Status: &typev3.HttpStatus{Code: typev3.StatusCode_Forbidden}},
+			}},
+			paths: []string{"passthrough"},
+		},
+		{
+			name:  "wrong response type",
+			phase: extproctorv1.ProcessingPhase_REQUEST_BODY,
+			resp:  requestHeaders(nil),
+			paths: []string{"response_type"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp := &extproctorv1.ExtProcExpectation{
+				Phase:    tt.phase,
+				Response: &extproctorv1.ExtProcExpectation_Passthrough{Passthrough: true},
+			}
+			cr := New().Compare([]*extproctorv1.ExtProcExpectation{exp}, &client.ProcessingResult{
+				Responses: []*client.PhaseResponse{{Phase: tt.phase, Response: tt.resp}},
+			})
+
+			var paths []string
+			for _, d := range cr.Differences {
+				paths = append(paths, d.Path)
+			}
+			assert.Equal(t, tt.paths, paths)
+			assert.Equal(t, len(tt.paths) == 0, cr.Passed)
+		})
+	}
+}
+
+func TestComparator_Compare_Passthrough_Actual(t *testing.T) {
+	exp := &extproctorv1.ExtProcExpectation{
+		Phase:    extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+		Response: &extproctorv1.ExtProcExpectation_Passthrough{Passthrough: true},
+	}
+
+	cr := New().Compare([]*extproctorv1.ExtProcExpectation{exp}, requestHeadersResult(map[string]string{"x-user": "alice"}))
+	require.Len(t, cr.Differences, 1)
+	assert.Equal(t, "<none>", cr.Differences[0].Expected)
+	assert.Equal(t, "x-user", cr.Differences[0].Actual)
+}
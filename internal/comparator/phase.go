@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// IsPhaseWildcard reports whether the phase of an expectation is a wildcard
+// (ANY_REQUEST, ANY_RESPONSE or ANY) rather than a processing phase.
+func IsPhaseWildcard(phase extproctorv1.ProcessingPhase) bool {
+	switch phase {
+	case extproctorv1.ProcessingPhase_ANY_REQUEST, extproctorv1.ProcessingPhase_ANY_RESPONSE, extproctorv1.ProcessingPhase_ANY:
+		return true
+	default:
+		return false
+	}
+}
+
+// PhaseMatches reports whether the phase of an expectation, possibly a
+// wildcard, covers the processing phase of a response.
+func PhaseMatches(expected, actual extproctorv1.ProcessingPhase) bool {
+	switch expected {
+	case extproctorv1.ProcessingPhase_ANY:
+		return actual != extproctorv1.ProcessingPhase_PROCESSING_PHASE_UNSPECIFIED
+	case extproctorv1.ProcessingPhase_ANY_REQUEST:
+		return isRequestPhase(actual)
+	case extproctorv1.ProcessingPhase_ANY_RESPONSE:
+		return isResponsePhase(actual)
+	default:
+		return expected == actual
+	}
+}
+
+func isRequestPhase(phase extproctorv1.ProcessingPhase) bool {
+	switch phase {
+	case extproctorv1.ProcessingPhase_REQUEST_HEADERS, extproctorv1.ProcessingPhase_REQUEST_BODY, extproctorv1.ProcessingPhase_REQUEST_TRAILERS:
+		return true
+	default:
+		return false
+	}
+}
+
+func isResponsePhase(phase extproctorv1.ProcessingPhase) bool {
+	switch phase {
+	case extproctorv1.ProcessingPhase_RESPONSE_HEADERS, extproctorv1.ProcessingPhase_RESPONSE_BODY, extproctorv1.ProcessingPhase_RESPONSE_TRAILERS:
+		return true
+	default:
+		return false
+	}
+}
+
+// matchOrder returns the expectations in the order they are matched with the
+// responses: the expectations of a phase wildcard last, so that they do not
+// take the response expected by the expectation of its phase.
+func matchOrder(expectations []*extproctorv1.ExtProcExpectation) []*extproctorv1.ExtProcExpectation {
+	ordered := make([]*extproctorv1.ExtProcExpectation, 0, len(expectations))
+	var wildcards []*extproctorv1.ExtProcExpectation
+	for _, exp := range expectations {
+		if IsPhaseWildcard(exp.Phase) {
+			wildcards = append(wildcards, exp)
+		} else {
+			ordered = append(ordered, exp)
+		}
+	}
+	return append(ordered, wildcards...)
+}
+
+// headerMutationResponse returns the common response carrying the header
+// mutation of a response. Expectations of a phase wildcard also accept the
+// header mutation of a body response, the filter being allowed to mutate the
+// headers at either phase.
+func headerMutationResponse(phase extproctorv1.ProcessingPhase, resp *extprocv3.ProcessingResponse) (*extprocv3.CommonResponse, bool) {
+	if headers := resp.GetRequestHeaders(); headers != nil {
+		return headers.Response, true
+	}
+	if headers := resp.GetResponseHeaders(); headers != nil {
+		return headers.Response, true
+	}
+	if !IsPhaseWildcard(phase) {
+		return nil, false
+	}
+	if body := resp.GetRequestBody(); body != nil {
+		return body.Response, true
+	}
+	if body := resp.GetResponseBody(); body != nil {
+		return body.Response, true
+	}
+	return nil, false
+}
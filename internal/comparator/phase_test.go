@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+)
+
+// injectedAtBodyResult returns the responses of a filter continuing the
+// request headers and setting x-tenant at the request body phase.
+func injectedAtBodyResult() *client.ProcessingResult {
+	return &client.ProcessingResult{Responses: []*client.PhaseResponse{
+		{
+			Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			Response: &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestHeaders{
+				RequestHeaders: &extprocv3.HeadersResponse{},
+			}},
+		},
+		{
+			Phase: extproctorv1.ProcessingPhase_REQUEST_BODY,
+			Response: &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestBody{
+				RequestBody: &extprocv3.BodyResponse{Response: &extprocv3.CommonResponse{
+					HeaderMutation: &extprocv3.HeaderMutation{SetHeaders: []*corev3.HeaderValueOption{
+						{Header: &corev3.HeaderValue{Key: "x-tenant", RawValue: []byte("acme")}},
+					}},
+				}},
+			}},
+		},
+	}}
+}
+
+// tenantExpectation expects x-tenant to be set at the given phase.
+func tenantExpectation(phase extproctorv1.ProcessingPhase) *extproctorv1.ExtProcExpectation {
+	return &extproctorv1.ExtProcExpectation{
+		Phase: phase,
+		Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+			HeadersResponse: &extproctorv1.HeadersExpectation{SetHeaders: map[string]string{"x-tenant": "acme"}},
+		},
+	}
+}
+
+func TestPhaseMatches(t *testing.T) {
+	assert.True(t, PhaseMatches(extproctorv1.ProcessingPhase_REQUEST_BODY, extproctorv1.ProcessingPhase_REQUEST_BODY))
+	assert.False(t, PhaseMatches(extproctorv1.ProcessingPhase_REQUEST_BODY, extproctorv1.ProcessingPhase_REQUEST_HEADERS))
+	assert.True(t, PhaseMatches(extproctorv1.ProcessingPhase_ANY_REQUEST, extproctorv1.ProcessingPhase_REQUEST_TRAILERS))
+	assert.False(t, PhaseMatches(extproctorv1.ProcessingPhase_ANY_REQUEST, extproctorv1.ProcessingPhase_RESPONSE_HEADERS))
+	assert.True(t, PhaseMatches(extproctorv1.ProcessingPhase_ANY_RESPONSE, extproctorv1.ProcessingPhase_RESPONSE_BODY))
+	assert.False(t, PhaseMatches(extproctorv1.ProcessingPhase_ANY_RESPONSE, extproctorv1.ProcessingPhase_REQUEST_BODY))
+	assert.True(t, PhaseMatches(extproctorv1.ProcessingPhase_ANY, extproctorv1.ProcessingPhase_RESPONSE_TRAILERS))
+}
+
+func TestComparator_Compare_PhaseWildcard(t *testing.T) {
+	// The header is set at the body phase
+	cr := New().Compare([]*extproctorv1.ExtProcExpectation{tenantExpectation(extproctorv1.ProcessingPhase_ANY_REQUEST)}, injectedAtBodyResult())
+	assert.True(t, cr.Passed, cr.Differences)
+	require.Len(t, cr.Matched, 1)
+	assert.Equal(t, extproctorv1.ProcessingPhase_REQUEST_BODY, cr.Matched[0].Response.Phase)
+
+	// Without the wildcard, the header is expected at the headers phase
+	cr = New().Compare([]*extproctorv1.ExtProcExpectation{tenantExpectation(extproctorv1.ProcessingPhase_REQUEST_HEADERS)}, injectedAtBodyResult())
+	assert.False(t, cr.Passed)
+
+	// No response phase was sent
+	cr = New().Compare([]*extproctorv1.ExtProcExpectation{tenantExpectation(extproctorv1.ProcessingPhase_ANY_RESPONSE)}, injectedAtBodyResult())
+	assert.False(t, cr.Passed)
+	assert.Equal(t, "phase ANY_RESPONSE never reached", cr.UnmatchedReasons[cr.Unmatched[0]])
+}
+
+func TestComparator_Compare_PhaseWildcardMatchedLast(t *testing.T) {
+	// The wildcard would match the headers response the second expectation
+	// needs
+	exps := []*extproctorv1.ExtProcExpectation{
+		{
+			Phase:    extproctorv1.ProcessingPhase_ANY,
+			Response: &extproctorv1.ExtProcExpectation_HeadersResponse{HeadersResponse: &extproctorv1.HeadersExpectation{}},
+		},
+		{
+			Phase:    extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			Response: &extproctorv1.ExtProcExpectation_HeadersResponse{HeadersResponse: &extproctorv1.HeadersExpectation{}},
+		},
+	}
+
+	cr := New().Compare(exps, injectedAtBodyResult())
+	assert.True(t, cr.Passed, cr.Differences)
+	require.Len(t, cr.Matched, 2)
+	assert.Equal(t, extproctorv1.ProcessingPhase_REQUEST_HEADERS, cr.Matched[0].Response.Phase)
+	assert.Equal(t, extproctorv1.ProcessingPhase_REQUEST_BODY, cr.Matched[1].Response.Phase)
+}
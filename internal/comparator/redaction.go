@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+
+	// cardCandidatePattern matches digit runs that may be card numbers, the
+	// Luhn checksum rules out most of the false positives.
+	cardCandidatePattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+)
+
+// detector finds sensitive data in a text.
+type detector struct {
+	path string
+	find func(text string) string
+}
+
+// CompareRedaction checks that none of the sensitive data of the expectation
+// appears in the mutations and immediate responses of the ExtProc service.
+// Matches are masked in the differences, so that reports do not leak them.
+func CompareRedaction(exp *extproctorv1.RedactionExpectation, result *client.ProcessingResult) []Difference {
+	var diffs []Difference
+
+	var detectors []detector
+	for _, d := range exp.GetDetectors() {
+		switch d {
+		case extproctorv1.SensitiveData_EMAIL:
+			detectors = append(detectors, detector{path: "redaction.email", find: emailPattern.FindString})
+		case extproctorv1.SensitiveData_CREDIT_CARD:
+			detectors = append(detectors, detector{path: "redaction.credit_card", find: findCardNumber})
+		}
+	}
+	for i, p := range exp.GetPatterns() {
+		path := fmt.Sprintf("redaction.patterns[%d]", i)
+		re, err := regexp.Compile(p)
+		if err != nil {
+			diffs = append(diffs, Difference{
+				Path:     path,
+				Expected: "valid regular expression",
+				Actual:   err.Error(),
+			})
+			continue
+		}
+		detectors = append(detectors, detector{path: path, find: re.FindString})
+	}
+
+	for _, resp := range result.Responses {
+		for _, loc := range mutatedTexts(resp.Response) {
+			for _, d := range detectors {
+				if match := d.find(loc.text); match != "" {
+					diffs = append(diffs, Difference{
+						Phase:    resp.Phase,
+						Path:     d.path,
+						Expected: "no match",
+						Actual:   fmt.Sprintf("%s in %s", maskMatch(match), loc.name),
+					})
+				}
+			}
+		}
+	}
+
+	return diffs
+}
+
+// mutatedText is a text produced by the ExtProc service.
+type mutatedText struct {
+	name string
+	text string
+}
+
+// mutatedTexts returns the header values, bodies and details set by a
+// response.
+func mutatedTexts(resp *extprocv3.ProcessingResponse) []mutatedText {
+	var texts []mutatedText
+
+	addMutation := func(section string, mutation *extprocv3.HeaderMutation) {
+		for _, h := range mutation.GetSetHeaders() {
+			if h.GetHeader() == nil {
+				continue
+			}
+			texts = append(texts, mutatedText{
+				name: fmt.Sprintf("%s header %q", section, h.GetHeader().GetKey()),
+				text: getHeaderValue(h.GetHeader()),
+			})
+		}
+	}
+	addCommon := func(common *extprocv3.CommonResponse) {
+		addMutation("set", common.GetHeaderMutation())
+		if body := common.GetBodyMutation().GetBody(); len(body) > 0 {
+			texts = append(texts, mutatedText{name: "body mutation", text: string(body)})
+		}
+		if body := common.GetBodyMutation().GetStreamedResponse().GetBody(); len(body) > 0 {
+			texts = append(texts, mutatedText{name: "streamed body mutation", text: string(body)})
+		}
+	}
+
+	switch {
+	case resp.GetImmediateResponse() != nil:
+		imm := resp.GetImmediateResponse()
+		addMutation("immediate response", imm.GetHeaders())
+		if len(imm.GetBody()) > 0 {
+			texts = append(texts, mutatedText{name: "immediate response body", text: string(imm.GetBody())})
+		}
+		if imm.GetDetails() != "" {
+			texts = append(texts, mutatedText{name: "immediate response details", text: imm.GetDetails()})
+		}
+	case resp.GetRequestHeaders() != nil:
+		addCommon(resp.GetRequestHeaders().GetResponse())
+	case resp.GetRequestBody() != nil:
+		addCommon(resp.GetRequestBody().GetResponse())
+	case resp.GetRequestTrailers() != nil:
+		addMutation("trailer", resp.GetRequestTrailers().GetHeaderMutation())
+	case resp.GetResponseHeaders() != nil:
+		addCommon(resp.GetResponseHeaders().GetResponse())
+	case resp.GetResponseBody() != nil:
+		addCommon(resp.GetResponseBody().GetResponse())
+	case resp.GetResponseTrailers() != nil:
+		addMutation("trailer", resp.GetResponseTrailers().GetHeaderMutation())
+	}
+
+	return texts
+}
+
+// findCardNumber returns the first payment card number of a text.
+func findCardNumber(text string) string {
+	for _, candidate := range cardCandidatePattern.FindAllString(text, -1) {
+		digits := strings.NewReplacer(" ", "", "-", "").Replace(candidate)
+		if len(digits) >= 13 && len(digits) <= 19 && luhnValid(digits) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// luhnValid reports whether a digit string passes the Luhn checksum.
+func luhnValid(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// maskMatch hides all but the first and last characters of a match.
+func maskMatch(match string) string {
+	runes := []rune(match)
+	if len(runes) <= 2 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[0]) + strings.Repeat("*", len(runes)-2) + string(runes[len(runes)-1])
+}
@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+)
+
+func TestCompareRedaction(t *testing.T) {
+	headers := &client.PhaseResponse{
+		Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+		Response: &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestHeaders{
+			RequestHeaders: &extprocv3.HeadersResponse{Response: &extprocv3.CommonResponse{
+				HeaderMutation: &extprocv3.HeaderMutation{SetHeaders: []*corev3.HeaderValueOption{
+					{Header: &corev3.HeaderValue{Key: "x-user", Value: "jane@example.com"}},
+					{Header: &corev3.HeaderValue{Key: "x-employee", RawValue: []byte("EMP-123456")}},
+				}},
+			}},
+		}},
+	}
+	body := &client.PhaseResponse{
+		Phase: extproctorv1.ProcessingPhase_RESPONSE_BODY,
+		Response: &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseBody{
+			ResponseBody: &extprocv3.BodyResponse{Response: &extprocv3.CommonResponse{
+				BodyMutation: &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{
+					Body: []byte(`{"card":"4111 1111 1111 1111","order":"1234567890123"}`),
+				}},
+			}},
+		}},
+	}
+	immediate := &client.PhaseResponse{
+		Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+		Response: &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Body:    []byte("denied for jane@example.com"),
+				Details: "card 5500-0000-0000-0004",
+			},
+		}},
+	}
+
+	tests := []struct {
+		name      string
+		exp       *extproctorv1.RedactionExpectation
+		responses []*client.PhaseResponse
+		want      []Difference
+	}{
+		{
+			name: "email in a set header",
+			exp: &extproctorv1.RedactionExpectation{
+				Detectors: []extproctorv1.SensitiveData{extproctorv1.SensitiveData_EMAIL},
+			},
+			responses: []*client.PhaseResponse{headers, body},
+			want: []Difference{{
+				Phase:    extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Path:     "redaction.email",
+				Expected: "no match",
+				Actual:   `j**************m in set header "x-user"`,
+			}},
+		},
+		{
+			name: "card number in a body mutation",
+			exp: &extproctorv1.RedactionExpectation{
+				Detectors: []extproctorv1.SensitiveData{extproctorv1.SensitiveData_CREDIT_CARD},
+			},
+			responses: []*client.PhaseResponse{headers, body},
+			want: []Difference{{
+				Phase:    extproctorv1.ProcessingPhase_RESPONSE_BODY,
+				Path:     "redaction.credit_card",
+				Expected: "no match",
+				Actual:   "4*****************1 in body mutation",
+			}},
+		},
+		{
+			name: "custom pattern",
+			exp: &extproctorv1.RedactionExpectation{
+				Patterns: []string{`secret`, `EMP-\d{6}`},
+			},
+			responses: []*client.PhaseResponse{headers},
+			want: []Difference{{
+				Phase:    extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Path:     "redaction.patterns[1]",
+				Expected: "no match",
+				Actual:   `E********6 in set header "x-employee"`,
+			}},
+		},
+		{
+			name: "immediate response",
+			exp: &extproctorv1.RedactionExpectation{
+				Detectors: []extproctorv1.SensitiveData{extproctorv1.SensitiveData_EMAIL, extproctorv1.SensitiveData_CREDIT_CARD},
+			},
+			responses: []*client.PhaseResponse{immediate},
+			want: []Difference{
+				{
+					Phase:    extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+					Path:     "redaction.email",
+					Expected: "no match",
+					Actual:   "j**************m in immediate response body",
+				},
+				{
+					Phase:    extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+					Path:     "redaction.credit_card",
+					Expected: "no match",
+					Actual:   "5*****************4 in immediate response details",
+				},
+			},
+		},
+		{
+			name: "redacted",
+			exp: &extproctorv1.RedactionExpectation{
+				Detectors: []extproctorv1.SensitiveData{extproctorv1.SensitiveData_EMAIL},
+				Patterns:  []string{`ssn=\d+`},
+			},
+			responses: []*client.PhaseResponse{body},
+		},
+		{
+			name: "invalid pattern",
+			exp: &extproctorv1.RedactionExpectation{
+				Patterns: []string{`(`},
+			},
+			want: []Difference{{
+				Path:     "redaction.patterns[0]",
+				Expected: "valid regular expression",
+				Actual:   "error parsing regexp: missing closing ): `(`",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CompareRedaction(tt.exp, &client.ProcessingResult{Responses: tt.responses})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFindCardNumber(t *testing.T) {
+	assert.Equal(t, "4111111111111111", findCardNumber("pan=4111111111111111;"))
+	assert.Equal(t, "3782-822463-10005", findCardNumber("amex 3782-822463-10005"))
+	assert.Empty(t, findCardNumber("4111111111111112"), "fails the Luhn checksum")
+	assert.Empty(t, findCardNumber("order 1234567890123"))
+	assert.Empty(t, findCardNumber("short 4242"))
+}
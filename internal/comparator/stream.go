@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"fmt"
+	"strings"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+)
+
+// CompareStream checks the stream-level assertions of the expectation
+// against the union of the responses of all the phases.
+func CompareStream(exp *extproctorv1.StreamExpectation, result *client.ProcessingResult) []Difference {
+	if exp == nil {
+		return nil
+	}
+
+	var diffs []Difference
+
+	// Phases setting each header, and the total number of set headers
+	setIn := make(map[string][]extproctorv1.ProcessingPhase)
+	total := 0
+	for _, resp := range result.Responses {
+		for _, h := range streamMutation(resp.Response).GetSetHeaders() {
+			if h.GetHeader() == nil {
+				continue
+			}
+			key := strings.ToLower(h.GetHeader().GetKey())
+			setIn[key] = append(setIn[key], resp.Phase)
+			total++
+		}
+	}
+
+	for _, name := range exp.SetOnceHeaders {
+		if phases := setIn[strings.ToLower(name)]; len(phases) != 1 {
+			diffs = append(diffs, Difference{
+				Path:     fmt.Sprintf("stream.set_once_headers[%s]", name),
+				Expected: "set once",
+				Actual:   describeSetCount(phases),
+			})
+		}
+	}
+
+	for _, name := range exp.NeverSetHeaders {
+		if phases := setIn[strings.ToLower(name)]; len(phases) > 0 {
+			diffs = append(diffs, Difference{
+				Path:     fmt.Sprintf("stream.never_set_headers[%s]", name),
+				Expected: "never set",
+				Actual:   describeSetCount(phases),
+			})
+		}
+	}
+
+	if exp.NoImmediateResponse {
+		for _, resp := range result.Responses {
+			if imm := resp.Response.GetImmediateResponse(); imm != nil {
+				diffs = append(diffs, Difference{
+					Phase:    resp.Phase,
+					Path:     "stream.no_immediate_response",
+					Expected: "no immediate response",
+					Actual:   fmt.Sprintf("immediate response (status %d)", imm.GetStatus().GetCode()),
+				})
+			}
+		}
+	}
+
+	if exp.MaxSetHeaders != nil && total > int(exp.GetMaxSetHeaders()) {
+		diffs = append(diffs, Difference{
+			Path:     "stream.max_set_headers",
+			Expected: fmt.Sprintf("<= %d", exp.GetMaxSetHeaders()),
+			Actual:   fmt.Sprintf("%d", total),
+		})
+	}
+
+	return diffs
+}
+
+// streamMutation returns the header mutation of the response of a headers or
+// body phase.
+func streamMutation(resp *extprocv3.ProcessingResponse) *extprocv3.HeaderMutation {
+	switch {
+	case resp.GetRequestHeaders() != nil:
+		return resp.GetRequestHeaders().GetResponse().GetHeaderMutation()
+	case resp.GetRequestBody() != nil:
+		return resp.GetRequestBody().GetResponse().GetHeaderMutation()
+	case resp.GetResponseHeaders() != nil:
+		return resp.GetResponseHeaders().GetResponse().GetHeaderMutation()
+	case resp.GetResponseBody() != nil:
+		return resp.GetResponseBody().GetResponse().GetHeaderMutation()
+	default:
+		return nil
+	}
+}
+
+// describeSetCount describes how many times, and in which phases, a header
+// was set.
+func describeSetCount(phases []extproctorv1.ProcessingPhase) string {
+	if len(phases) == 0 {
+		return "never set"
+	}
+
+	names := make([]string, len(phases))
+	for i, p := range phases {
+		names[i] = phaseName(p)
+	}
+	return fmt.Sprintf("set %d time(s) (%s)", len(phases), strings.Join(names, ", "))
+}
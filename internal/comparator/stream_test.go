@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+)
+
+func TestCompareStream(t *testing.T) {
+	// x-tenant is set at the request body phase, then again with x-authz at
+	// the response headers phase
+	result := injectedAtBodyResult()
+	result.Responses = append(result.Responses, &client.PhaseResponse{
+		Phase: extproctorv1.ProcessingPhase_RESPONSE_HEADERS,
+		Response: &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseHeaders{
+			ResponseHeaders: &extprocv3.HeadersResponse{Response: &extprocv3.CommonResponse{
+				HeaderMutation: &extprocv3.HeaderMutation{SetHeaders: []*corev3.HeaderValueOption{
+					{Header: &corev3.HeaderValue{Key: "X-Authz", RawValue: []byte("allow")}},
+					{Header: &corev3.HeaderValue{Key: "x-tenant", RawValue: []byte("acme")}},
+				}},
+			}},
+		}},
+	})
+
+	exp := &extproctorv1.StreamExpectation{
+		SetOnceHeaders:      []string{"x-authz"},
+		NeverSetHeaders:     []string{"x-debug"},
+		NoImmediateResponse: true,
+		MaxSetHeaders:       proto.Uint32(3),
+	}
+	assert.Empty(t, CompareStream(exp, result))
+	assert.Nil(t, CompareStream(nil, result))
+
+	exp = &extproctorv1.StreamExpectation{
+		SetOnceHeaders:  []string{"x-tenant", "x-user"},
+		NeverSetHeaders: []string{"x-authz"},
+		MaxSetHeaders:   proto.Uint32(2),
+	}
+	assert.Equal(t, []Difference{
+		{Path: "stream.set_once_headers[x-tenant]", Expected: "set once", Actual: "set 2 time(s) (REQUEST_BODY, RESPONSE_HEADERS)"},
+		{Path: "stream.set_once_headers[x-user]", Expected: "set once", Actual: "never set"},
+		{Path: "stream.never_set_headers[x-authz]", Expected: "never set", Actual: "set 1 time(s) (RESPONSE_HEADERS)"},
+		{Path: "stream.max_set_headers", Expected: "<= 2", Actual: "3"},
+	}, CompareStream(exp, result))
+}
+
+func TestCompareStream_NoImmediateResponse(t *testing.T) {
+	result := &client.ProcessingResult{Responses: []*client.PhaseResponse{{
+		Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+		Response: &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{Status: &typev3.HttpStatus{Code: typev3.StatusCode_Forbidden}},
+		}},
+	}}}
+
+	assert.Equal(t, []Difference{{
+		Phase:    extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+		Path:     "stream.no_immediate_response",
+		Expected: "no immediate response",
+		Actual:   "immediate response (status 403)",
+	}}, CompareStream(&extproctorv1.StreamExpectation{NoImmediateResponse: true}, result))
+}
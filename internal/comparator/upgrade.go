@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"fmt"
+	"strings"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// upgradeHeaders are the headers an upgrade needs to reach the upstream.
+var upgradeHeaders = []string{"connection", "upgrade"}
+
+// compareUpgradeResponse compares how the filter treated an upgrade request
+// against the expected handling: passed through with its upgrade headers, or
+// rejected with an immediate response.
+func (c *Comparator) compareUpgradeResponse(phase extproctorv1.ProcessingPhase, exp *extproctorv1.UpgradeExpectation, resp *extprocv3.ProcessingResponse) []Difference {
+	immediate := resp.GetImmediateResponse()
+
+	if exp.Handling == extproctorv1.UpgradeHandling_REJECT {
+		if immediate == nil {
+			return []Difference{{
+				Phase:    phase,
+				Path:     "upgrade_response.handling",
+				Expected: extproctorv1.UpgradeHandling_REJECT.String(),
+				Actual:   extproctorv1.UpgradeHandling_PASS_THROUGH.String(),
+			}}
+		}
+		if exp.StatusCode > 0 && int32(immediate.GetStatus().GetCode()) != exp.StatusCode {
+			return []Difference{{
+				Phase:    phase,
+				Path:     "upgrade_response.status_code",
+				Expected: fmt.Sprintf("%d", exp.StatusCode),
+				Actual:   fmt.Sprintf("%d", immediate.GetStatus().GetCode()),
+			}}
+		}
+		return nil
+	}
+
+	if immediate != nil {
+		return []Difference{{
+			Phase:    phase,
+			Path:     "upgrade_response.handling",
+			Expected: extproctorv1.UpgradeHandling_PASS_THROUGH.String(),
+			Actual:   fmt.Sprintf("%s (status %d)", extproctorv1.UpgradeHandling_REJECT, immediate.GetStatus().GetCode()),
+		}}
+	}
+
+	actual := resp.GetRequestHeaders()
+	if actual == nil {
+		return []Difference{{
+			Phase:    phase,
+			Path:     "response_type",
+			Expected: "request_headers",
+			Actual:   fmt.Sprintf("%T", resp.Response),
+		}}
+	}
+
+	// The upgrade is broken when the filter drops its headers or rewrites
+	// them without the upgrade
+	var diffs []Difference
+	mutation := actual.GetResponse().GetHeaderMutation()
+	for _, key := range upgradeHeaders {
+		for _, removed := range mutation.GetRemoveHeaders() {
+			if strings.EqualFold(removed, key) {
+				diffs = append(diffs, Difference{
+					Phase:    phase,
+					Path:     fmt.Sprintf("upgrade_response.header_mutation[%s]", key),
+					Expected: "<kept>",
+					Actual:   headerRemoved,
+				})
+			}
+		}
+		for _, h := range mutation.GetSetHeaders() {
+			if strings.EqualFold(h.GetHeader().GetKey(), key) && !keepsUpgrade(key, getHeaderValue(h.GetHeader())) {
+				diffs = append(diffs, Difference{
+					Phase:    phase,
+					Path:     fmt.Sprintf("upgrade_response.header_mutation[%s]", key),
+					Expected: "<kept>",
+					Actual:   displayHeaderValue(getHeaderValue(h.GetHeader())),
+				})
+			}
+		}
+	}
+
+	return diffs
+}
+
+// keepsUpgrade reports whether a value set on an upgrade header keeps the
+// upgrade: connection must still list the upgrade option, and upgrade must
+// still name a protocol.
+func keepsUpgrade(key, value string) bool {
+	if key == "connection" {
+		for _, option := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(option), "upgrade") {
+				return true
+			}
+		}
+		return false
+	}
+	return strings.TrimSpace(value) != ""
+}
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func upgradeHeadersResponse(mutation *extprocv3.HeaderMutation) *extprocv3.ProcessingResponse {
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_RequestHeaders{
+			RequestHeaders: &extprocv3.HeadersResponse{
+				Response: &extprocv3.CommonResponse{HeaderMutation: mutation},
+			},
+		},
+	}
+}
+
+func upgradeRejection(code typev3.StatusCode) *extprocv3.ProcessingResponse {
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{Status: &typev3.HttpStatus{Code: code}},
+		},
+	}
+}
+
+func TestCompareUpgradeResponse_PassThrough(t *testing.T) {
+	comp := New()
+	exp := &extproctorv1.UpgradeExpectation{Handling: extproctorv1.UpgradeHandling_PASS_THROUGH}
+
+	// Adding headers or keeping the upgrade option keeps the upgrade
+	diffs := comp.compareUpgradeResponse(extproctorv1.ProcessingPhase_REQUEST_HEADERS, exp, upgradeHeadersResponse(&extprocv3.HeaderMutation{
+		SetHeaders: []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{Key: "x-user", Value: "alice"}},
+			{Header: &corev3.HeaderValue{Key: "connection", Value: "keep-alive, Upgrade"}},
+		},
+	}))
+	assert.Empty(t, diffs)
+
+	diffs = comp.compareUpgradeResponse(extproctorv1.ProcessingPhase_REQUEST_HEADERS, exp, upgradeHeadersResponse(&extprocv3.HeaderMutation{
+		SetHeaders:    []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "connection", Value: "close"}}},
+		RemoveHeaders: []string{"upgrade"},
+	}))
+	require.Len(t, diffs, 2)
+	assert.Equal(t, "upgrade_response.header_mutation[connection]", diffs[0].Path)
+	assert.Equal(t, "close", diffs[0].Actual)
+	assert.Equal(t, "upgrade_response.header_mutation[upgrade]", diffs[1].Path)
+	assert.Equal(t, headerRemoved, diffs[1].Actual)
+
+	diffs = comp.compareUpgradeResponse(extproctorv1.ProcessingPhase_REQUEST_HEADERS, exp, upgradeRejection(typev3.StatusCode_Unauthorized))
+	require.Len(t, diffs, 1)
+	assert.Equal(t, "upgrade_response.handling", diffs[0].Path)
+	assert.Equal(t, "REJECT (status 401)", diffs[0].Actual)
+}
+
+func TestCompareUpgradeResponse_Reject(t *testing.T) {
+	comp := New()
+	exp := &extproctorv1.UpgradeExpectation{Handling: extproctorv1.UpgradeHandling_REJECT, StatusCode: 403}
+
+	assert.Empty(t, comp.compareUpgradeResponse(extproctorv1.ProcessingPhase_REQUEST_HEADERS, exp, upgradeRejection(typev3.StatusCode_Forbidden)))
+
+	diffs := comp.compareUpgradeResponse(extproctorv1.ProcessingPhase_REQUEST_HEADERS, exp, upgradeRejection(typev3.StatusCode_Unauthorized))
+	require.Len(t, diffs, 1)
+	assert.Equal(t, "upgrade_response.status_code", diffs[0].Path)
+	assert.Equal(t, "401", diffs[0].Actual)
+
+	diffs = comp.compareUpgradeResponse(extproctorv1.ProcessingPhase_REQUEST_HEADERS, exp, upgradeHeadersResponse(nil))
+	require.Len(t, diffs, 1)
+	assert.Equal(t, "upgrade_response.handling", diffs[0].Path)
+	assert.Equal(t, "PASS_THROUGH", diffs[0].Actual)
+}
@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// headerValueEqual reports whether an actual header value equals the expected
+// one, under the header value comparison options of the expectation being
+// compared.
+func (c *Comparator) headerValueEqual(actual, expected string) bool {
+	opts := c.headerValues
+	if opts == nil {
+		return actual == expected
+	}
+
+	actual, expected = normalizeHeaderValue(opts, actual), normalizeHeaderValue(opts, expected)
+	if opts.CaseInsensitiveValue {
+		return strings.EqualFold(actual, expected)
+	}
+	return actual == expected
+}
+
+// normalizeHeaderValue applies the whitespace and Unicode normalizations of
+// the options to a header value.
+func normalizeHeaderValue(opts *extproctorv1.HeaderValueComparison, v string) string {
+	if opts.TrimWhitespace {
+		v = strings.TrimSpace(v)
+	}
+	if opts.UnicodeNfc {
+		v = norm.NFC.String(v)
+	}
+	return v
+}
@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package comparator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func TestHeaderValueEqual(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     *extproctorv1.HeaderValueComparison
+		actual   string
+		expected string
+		want     bool
+	}{
+		{name: "exact", actual: "Bearer x", expected: "Bearer x", want: true},
+		{name: "whitespace without option", actual: " Bearer x\t", expected: "Bearer x"},
+		{name: "case without option", actual: "GZIP", expected: "gzip"},
+		{
+			name:     "trim whitespace",
+			opts:     &extproctorv1.HeaderValueComparison{TrimWhitespace: true},
+			actual:   " Bearer x\t",
+			expected: "Bearer x",
+			want:     true,
+		},
+		{
+			name:     "inner whitespace kept",
+			opts:     &extproctorv1.HeaderValueComparison{TrimWhitespace: true},
+			actual:   "Bearer  x",
+			expected: "Bearer x",
+		},
+		{
+			name:     "case insensitive",
+			opts:     &extproctorv1.HeaderValueComparison{CaseInsensitiveValue: true},
+			actual:   "GZIP",
+			expected: "gzip",
+			want:     true,
+		},
+		{
+			name:     "decomposed without nfc",
+			opts:     &extproctorv1.HeaderValueComparison{TrimWhitespace: true},
+			actual:   "Jose\u0301",
+			expected: "José",
+		},
+		{
+			name:     "nfc",
+			opts:     &extproctorv1.HeaderValueComparison{UnicodeNfc: true},
+			actual:   "Jose\u0301",
+			expected: "José",
+			want:     true,
+		},
+		{
+			name:     "all options",
+			opts:     &extproctorv1.HeaderValueComparison{TrimWhitespace: true, CaseInsensitiveValue: true, UnicodeNfc: true},
+			actual:   " JOSE\u0301 ",
+			expected: "josé",
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Comparator{headerValues: tt.opts}
+			assert.Equal(t, tt.want, c.headerValueEqual(tt.actual, tt.expected))
+		})
+	}
+}
+
+func TestComparator_Compare_HeaderValues(t *testing.T) {
+	result := requestHeadersResult(map[string]string{"x-user": " Jose\u0301 "})
+	exp := headerExpectation("", "x-user", "josé", false)
+
+	cr := New().Compare([]*extproctorv1.ExtProcExpectation{exp}, result)
+	assert.False(t, cr.Passed)
+
+	exp.HeaderValues = &extproctorv1.HeaderValueComparison{TrimWhitespace: true, CaseInsensitiveValue: true, UnicodeNfc: true}
+	cr = New().Compare([]*extproctorv1.ExtProcExpectation{exp}, result)
+	assert.True(t, cr.Passed)
+
+	// The options of an expectation do not leak to the next ones
+	strict := headerExpectation("", "x-user", "josé", false)
+	cr = New().Compare([]*extproctorv1.ExtProcExpectation{exp, strict}, requestHeadersResult(map[string]string{"x-user": " Jose\u0301 "}))
+	assert.Equal(t, []*extproctorv1.ExtProcExpectation{strict}, cr.Unmatched)
+}
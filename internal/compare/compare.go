@@ -0,0 +1,217 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package compare computes semantic differences between two golden files or
+// two JSON result files.
+package compare
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// ChangeKind is the kind of a change between two files.
+type ChangeKind string
+
+const (
+	// Added marks an entry only present in the second file.
+	Added ChangeKind = "+"
+	// Removed marks an entry only present in the first file.
+	Removed ChangeKind = "-"
+	// Changed marks an entry present in both files with different values.
+	Changed ChangeKind = "~"
+)
+
+// Change is a single semantic difference.
+type Change struct {
+	Kind ChangeKind
+	// Subject identifies the expectation or test (e.g. "REQUEST_HEADERS[0]").
+	Subject string
+	// Path is the field path inside the subject, empty for whole entries.
+	Path string
+	Old  string
+	New  string
+}
+
+// String renders the change on a single line.
+func (c Change) String() string {
+	subject := c.Subject
+	if c.Path != "" {
+		subject += " " + c.Path
+	}
+
+	switch c.Kind {
+	case Added:
+		if c.New != "" {
+			return fmt.Sprintf("+ %s: %s", subject, c.New)
+		}
+		return fmt.Sprintf("+ %s", subject)
+	case Removed:
+		if c.Old != "" {
+			return fmt.Sprintf("- %s: %s", subject, c.Old)
+		}
+		return fmt.Sprintf("- %s", subject)
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", subject, c.Old, c.New)
+	}
+}
+
+// Expectations compares two expectation lists. Expectations are paired by
+// phase and by their position among the expectations of that phase, and
+// paired expectations are compared field by field.
+func Expectations(a, b []*extproctorv1.ExtProcExpectation) []Change {
+	left, right := byPhase(a), byPhase(b)
+
+	var changes []Change
+	for _, phase := range phases(left, right) {
+		l, r := left[phase], right[phase]
+		for i := 0; i < max(len(l), len(r)); i++ {
+			subject := fmt.Sprintf("%s[%d]", phase, i)
+			switch {
+			case i >= len(l):
+				changes = append(changes, Change{Kind: Added, Subject: subject})
+			case i >= len(r):
+				changes = append(changes, Change{Kind: Removed, Subject: subject})
+			default:
+				changes = append(changes, Fields(subject, l[i], r[i])...)
+			}
+		}
+	}
+
+	return changes
+}
+
+// Fields compares two messages of the same type field by field.
+func Fields(subject string, a, b proto.Message) []Change {
+	left, right := map[string]string{}, map[string]string{}
+	flatten("", a.ProtoReflect(), left)
+	flatten("", b.ProtoReflect(), right)
+
+	return diffMaps(subject, left, right)
+}
+
+// diffMaps compares two flattened field maps.
+func diffMaps(subject string, left, right map[string]string) []Change {
+	paths := make([]string, 0, len(left)+len(right))
+	for p := range left {
+		paths = append(paths, p)
+	}
+	for p := range right {
+		if _, ok := left[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	var changes []Change
+	for _, p := range paths {
+		l, inLeft := left[p]
+		r, inRight := right[p]
+		switch {
+		case !inLeft:
+			changes = append(changes, Change{Kind: Added, Subject: subject, Path: p, New: r})
+		case !inRight:
+			changes = append(changes, Change{Kind: Removed, Subject: subject, Path: p, Old: l})
+		case l != r:
+			changes = append(changes, Change{Kind: Changed, Subject: subject, Path: p, Old: l, New: r})
+		}
+	}
+
+	return changes
+}
+
+// flatten records every populated scalar field of the message under its path
+// (e.g. "headers_response.set_headers[x-user]").
+func flatten(prefix string, m protoreflect.Message, out map[string]string) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		path := string(fd.Name())
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		switch {
+		case fd.IsMap():
+			v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				flattenValue(fmt.Sprintf("%s[%s]", path, k.String()), fd.MapValue(), mv, out)
+				return true
+			})
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				flattenValue(fmt.Sprintf("%s[%d]", path, i), fd, list.Get(i), out)
+			}
+		default:
+			flattenValue(path, fd, v, out)
+		}
+		return true
+	})
+}
+
+func flattenValue(path string, fd protoreflect.FieldDescriptor, v protoreflect.Value, out map[string]string) {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		before := len(out)
+		flatten(path, v.Message(), out)
+		if len(out) == before {
+			// Keep empty messages visible (e.g. "headers_response: {}")
+			out[path] = "{}"
+		}
+	case protoreflect.StringKind:
+		out[path] = strconv.Quote(v.String())
+	case protoreflect.BytesKind:
+		out[path] = quoteBytes(v.Bytes())
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			out[path] = string(ev.Name())
+		} else {
+			out[path] = strconv.Itoa(int(v.Enum()))
+		}
+	default:
+		out[path] = v.String()
+	}
+}
+
+// maxQuotedBytes is the size above which bytes values, such as large bodies,
+// are summarized by their size and digest instead of being quoted whole.
+const maxQuotedBytes = 4096
+
+// quoteBytes quotes a bytes value, or summarizes it when large.
+func quoteBytes(b []byte) string {
+	if len(b) > maxQuotedBytes {
+		digest := sha256.Sum256(b)
+		return fmt.Sprintf("<%d bytes, sha256:%x>", len(b), digest[:8])
+	}
+	return strconv.Quote(string(b))
+}
+
+func byPhase(exps []*extproctorv1.ExtProcExpectation) map[extproctorv1.ProcessingPhase][]*extproctorv1.ExtProcExpectation {
+	out := map[extproctorv1.ProcessingPhase][]*extproctorv1.ExtProcExpectation{}
+	for _, exp := range exps {
+		out[exp.Phase] = append(out[exp.Phase], exp)
+	}
+	return out
+}
+
+// phases returns the phases present on either side, in stream order.
+func phases(a, b map[extproctorv1.ProcessingPhase][]*extproctorv1.ExtProcExpectation) []extproctorv1.ProcessingPhase {
+	seen := map[extproctorv1.ProcessingPhase]bool{}
+	for p := range a {
+		seen[p] = true
+	}
+	for p := range b {
+		seen[p] = true
+	}
+
+	out := make([]extproctorv1.ProcessingPhase, 0, len(seen))
+	for p := range seen {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package compare
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func headersExpectation(phase extproctorv1.ProcessingPhase, set map[string]string, remove ...string) *extproctorv1.ExtProcExpectation {
+	return &extproctorv1.ExtProcExpectation{
+		Phase: phase,
+		Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+			HeadersResponse: &extproctorv1.HeadersExpectation{
+				SetHeaders:    set,
+				RemoveHeaders: remove,
+			},
+		},
+	}
+}
+
+func TestExpectations_NoChanges(t *testing.T) {
+	a := []*extproctorv1.ExtProcExpectation{
+		headersExpectation(extproctorv1.ProcessingPhase_REQUEST_HEADERS, map[string]string{"x-user": "42"}),
+	}
+	b := []*extproctorv1.ExtProcExpectation{
+		headersExpectation(extproctorv1.ProcessingPhase_REQUEST_HEADERS, map[string]string{"x-user": "42"}),
+	}
+
+	assert.Empty(t, Expectations(a, b))
+}
+
+func TestExpectations_FieldChanges(t *testing.T) {
+	a := []*extproctorv1.ExtProcExpectation{
+		headersExpectation(extproctorv1.ProcessingPhase_REQUEST_HEADERS, map[string]string{"x-user": "42", "x-old": "1"}),
+	}
+	b := []*extproctorv1.ExtProcExpectation{
+		headersExpectation(extproctorv1.ProcessingPhase_REQUEST_HEADERS, map[string]string{"x-user": "43"}, "x-secret"),
+	}
+
+	changes := Expectations(a, b)
+
+	var lines []string
+	for _, c := range changes {
+		lines = append(lines, c.String())
+	}
+	assert.Equal(t, []string{
+		`+ REQUEST_HEADERS[0] headers_response.remove_headers[0]: "x-secret"`,
+		`- REQUEST_HEADERS[0] headers_response.set_headers[x-old]: "1"`,
+		`~ REQUEST_HEADERS[0] headers_response.set_headers[x-user]: "42" -> "43"`,
+	}, lines)
+}
+
+func TestExpectations_AddedAndRemoved(t *testing.T) {
+	a := []*extproctorv1.ExtProcExpectation{
+		headersExpectation(extproctorv1.ProcessingPhase_REQUEST_HEADERS, nil),
+		headersExpectation(extproctorv1.ProcessingPhase_RESPONSE_HEADERS, nil),
+	}
+	b := []*extproctorv1.ExtProcExpectation{
+		headersExpectation(extproctorv1.ProcessingPhase_REQUEST_HEADERS, nil),
+		headersExpectation(extproctorv1.ProcessingPhase_REQUEST_HEADERS, nil),
+	}
+
+	changes := Expectations(a, b)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "+ REQUEST_HEADERS[1]", changes[0].String())
+	assert.Equal(t, "- RESPONSE_HEADERS[0]", changes[1].String())
+}
+
+func TestExpectations_ResponseTypeChange(t *testing.T) {
+	a := []*extproctorv1.ExtProcExpectation{
+		headersExpectation(extproctorv1.ProcessingPhase_REQUEST_HEADERS, nil),
+	}
+	b := []*extproctorv1.ExtProcExpectation{
+		{
+			Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			Response: &extproctorv1.ExtProcExpectation_ImmediateResponse{
+				ImmediateResponse: &extproctorv1.ImmediateExpectation{StatusCode: 403},
+			},
+		},
+	}
+
+	changes := Expectations(a, b)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "- REQUEST_HEADERS[0] headers_response: {}", changes[0].String())
+	assert.Equal(t, "+ REQUEST_HEADERS[0] immediate_response.status_code: 403", changes[1].String())
+}
+
+func TestTestResults(t *testing.T) {
+	a, err := ReadResults(strings.NewReader(`{"tests": [
+		{"name": "kept", "status": "passed"},
+		{"name": "flaky", "status": "passed"},
+		{"name": "dropped", "status": "passed"}
+	]}`))
+	require.NoError(t, err)
+	b, err := ReadResults(strings.NewReader(`{"tests": [
+		{"name": "kept", "status": "passed"},
+		{"name": "flaky", "status": "failed", "differences": [
+			{"phase": "REQUEST_HEADERS", "path": "set_headers[x-user]", "expected": "42", "actual": "43"}
+		]},
+		{"name": "new", "status": "failed"}
+	]}`))
+	require.NoError(t, err)
+
+	var lines []string
+	for _, c := range TestResults(a, b) {
+		lines = append(lines, c.String())
+	}
+	assert.Equal(t, []string{
+		"- dropped: passed",
+		`+ flaky differences[REQUEST_HEADERS set_headers[x-user]]: expected "42", actual "43"`,
+		"~ flaky status: passed -> failed",
+		"+ new: failed",
+	}, lines)
+}
+
+func TestTestResults_SharedNames(t *testing.T) {
+	a, err := ReadResults(strings.NewReader(`{"tests": [
+		{"id": "auth.textproto::smoke", "name": "smoke", "status": "passed"},
+		{"id": "cors.textproto::smoke", "name": "smoke", "status": "passed"},
+		{"id": "cors.textproto::smoke", "name": "smoke", "target": "eu", "status": "passed"}
+	]}`))
+	require.NoError(t, err)
+	b, err := ReadResults(strings.NewReader(`{"tests": [
+		{"id": "auth.textproto::smoke", "name": "smoke", "status": "passed"},
+		{"id": "cors.textproto::smoke", "name": "smoke", "status": "failed"},
+		{"id": "cors.textproto::smoke", "name": "smoke", "target": "eu", "status": "passed"}
+	]}`))
+	require.NoError(t, err)
+
+	var lines []string
+	for _, c := range TestResults(a, b) {
+		lines = append(lines, c.String())
+	}
+	assert.Equal(t, []string{"~ cors.textproto::smoke status: passed -> failed"}, lines)
+}
+
+func TestReadResults_Invalid(t *testing.T) {
+	_, err := ReadResults(strings.NewReader("not json"))
+	assert.ErrorContains(t, err, "failed to parse JSON results")
+}
+
+func TestQuoteBytes(t *testing.T) {
+	assert.Equal(t, `"a\x00b"`, quoteBytes([]byte("a\x00b")))
+	assert.Regexp(t, `^<5000 bytes, sha256:[0-9a-f]{16}>$`, quoteBytes(make([]byte, 5000)))
+}
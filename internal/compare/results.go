@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// Results is the subset of a JSON report (--output json) used to compare runs.
+type Results struct {
+	Tests []ResultTest `json:"tests"`
+}
+
+// ResultTest is a test entry of a JSON report.
+type ResultTest struct {
+	ID          string             `json:"id,omitempty"`
+	Name        string             `json:"name"`
+	Target      string             `json:"target,omitempty"`
+	Status      string             `json:"status"`
+	Duration    string             `json:"duration,omitempty"`
+	Error       string             `json:"error,omitempty"`
+	Differences []ResultDifference `json:"differences,omitempty"`
+}
+
+// ResultDifference is a difference entry of a JSON report.
+type ResultDifference struct {
+	Phase    string `json:"phase"`
+	Path     string `json:"path"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// key identifies a test in a report: its ID, or its name for reports without
+// IDs, along with the target of multi-target runs.
+func (t ResultTest) key() string {
+	key := t.ID
+	if key == "" {
+		key = t.Name
+	}
+	if t.Target != "" {
+		key += " @ " + t.Target
+	}
+	return key
+}
+
+// ReadResults decodes a JSON report.
+func ReadResults(r io.Reader) (*Results, error) {
+	results := &Results{}
+	if err := json.NewDecoder(r).Decode(results); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON results: %w", err)
+	}
+	return results, nil
+}
+
+// TestResults compares two JSON reports. Tests are paired by ID and target
+// (by name for reports without IDs), and paired tests are compared on their
+// status, error and reported differences.
+func TestResults(a, b *Results) []Change {
+	left, right := indexTests(a), indexTests(b)
+
+	names := make([]string, 0, len(left)+len(right))
+	for n := range left {
+		names = append(names, n)
+	}
+	for n := range right {
+		if _, ok := left[n]; !ok {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []Change
+	for _, name := range names {
+		l, inLeft := left[name]
+		r, inRight := right[name]
+		switch {
+		case !inLeft:
+			changes = append(changes, Change{Kind: Added, Subject: name, New: r.Status})
+		case !inRight:
+			changes = append(changes, Change{Kind: Removed, Subject: name, Old: l.Status})
+		default:
+			changes = append(changes, diffMaps(name, flattenTest(l), flattenTest(r))...)
+		}
+	}
+
+	return changes
+}
+
+func indexTests(results *Results) map[string]ResultTest {
+	out := make(map[string]ResultTest, len(results.Tests))
+	for _, t := range results.Tests {
+		out[t.key()] = t
+	}
+	return out
+}
+
+// flattenTest maps the comparable values of a test to their path.
+func flattenTest(t ResultTest) map[string]string {
+	out := map[string]string{
+		"status": t.Status,
+	}
+	if t.Error != "" {
+		out["error"] = strconv.Quote(t.Error)
+	}
+	for _, d := range t.Differences {
+		out[fmt.Sprintf("differences[%s %s]", d.Phase, d.Path)] = fmt.Sprintf("expected %q, actual %q", d.Expected, d.Actual)
+	}
+	return out
+}
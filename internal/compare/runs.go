@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package compare
+
+import (
+	"sort"
+	"time"
+)
+
+// Outcome is the outcome of a test over the runs of a side of a comparison.
+type Outcome string
+
+const (
+	// OutcomeNone is the outcome of a test missing or skipped in every run.
+	OutcomeNone Outcome = ""
+	// OutcomePassed is the outcome of a test passing in every run.
+	OutcomePassed Outcome = "passed"
+	// OutcomeFailed is the outcome of a test failing in every run.
+	OutcomeFailed Outcome = "failed"
+	// OutcomeFlaky is the outcome of a test both passing and failing.
+	OutcomeFlaky Outcome = "flaky"
+)
+
+// RunDiff lists the tests whose outcome or duration changed between two sets
+// of runs. Tests are identified by their ID, or their name when the results
+// have no IDs.
+type RunDiff struct {
+	NewlyFailing []string
+	NewlyPassing []string
+	NewlyFlaky   []string
+	Slower       []DurationChange
+
+	// Before and After are the outcomes of every test of each side.
+	Before map[string]Outcome
+	After  map[string]Outcome
+}
+
+// DurationChange is the mean duration change of a test.
+type DurationChange struct {
+	ID     string
+	Before time.Duration
+	After  time.Duration
+	Change float64
+}
+
+// Regressions returns the number of newly failing, newly flaky and slower
+// tests.
+func (d *RunDiff) Regressions() int {
+	return len(d.NewlyFailing) + len(d.NewlyFlaky) + len(d.Slower)
+}
+
+// testRuns aggregates the results of a test over several runs.
+type testRuns struct {
+	passed, failed int
+	total          time.Duration
+}
+
+func (t *testRuns) outcome() Outcome {
+	switch {
+	case t.passed > 0 && t.failed > 0:
+		return OutcomeFlaky
+	case t.failed > 0:
+		return OutcomeFailed
+	case t.passed > 0:
+		return OutcomePassed
+	default:
+		return OutcomeNone
+	}
+}
+
+func (t *testRuns) mean() time.Duration {
+	n := t.passed + t.failed
+	if n == 0 {
+		return 0
+	}
+	return t.total / time.Duration(n)
+}
+
+// Runs compares the runs before a change to the runs after it. A test is flaky
+// on a side when it both passed and failed in its runs. A test is slower when
+// its mean duration increased by more than maxSlowdown (a ratio) and by at
+// least minSlowdown, which keeps the noise of fast tests out.
+func Runs(before, after []*Results, maxSlowdown float64, minSlowdown time.Duration) *RunDiff {
+	left, right := aggregateRuns(before), aggregateRuns(after)
+
+	diff := &RunDiff{
+		Before: make(map[string]Outcome, len(left)),
+		After:  make(map[string]Outcome, len(right)),
+	}
+	for id, t := range left {
+		diff.Before[id] = t.outcome()
+	}
+
+	ids := make([]string, 0, len(right))
+	for id, t := range right {
+		diff.After[id] = t.outcome()
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		l, ok := left[id]
+		if !ok {
+			continue
+		}
+		r := right[id]
+
+		was, is := l.outcome(), r.outcome()
+		switch {
+		case was == OutcomeNone || is == OutcomeNone:
+			continue
+		case is == OutcomeFlaky && was != OutcomeFlaky:
+			diff.NewlyFlaky = append(diff.NewlyFlaky, id)
+		case is == OutcomeFailed && was != OutcomeFailed:
+			diff.NewlyFailing = append(diff.NewlyFailing, id)
+		case is == OutcomePassed && was == OutcomeFailed:
+			diff.NewlyPassing = append(diff.NewlyPassing, id)
+		}
+
+		b, a := l.mean(), r.mean()
+		if b > 0 && a-b >= minSlowdown {
+			if change := float64(a-b) / float64(b); change > maxSlowdown {
+				diff.Slower = append(diff.Slower, DurationChange{ID: id, Before: b, After: a, Change: change})
+			}
+		}
+	}
+
+	return diff
+}
+
+// Outcomes returns the outcome of every test over several runs, by test ID or
+// name when the results have no IDs.
+func Outcomes(runs []*Results) map[string]Outcome {
+	agg := aggregateRuns(runs)
+	out := make(map[string]Outcome, len(agg))
+	for id, t := range agg {
+		out[id] = t.outcome()
+	}
+	return out
+}
+
+// aggregateRuns aggregates the results of the tests of several runs.
+func aggregateRuns(runs []*Results) map[string]*testRuns {
+	out := make(map[string]*testRuns)
+	for _, run := range runs {
+		for _, t := range run.Tests {
+			id := t.ID
+			if id == "" {
+				id = t.Name
+			}
+			agg, ok := out[id]
+			if !ok {
+				agg = &testRuns{}
+				out[id] = agg
+			}
+
+			switch t.Status {
+			case "passed":
+				agg.passed++
+			case "failed":
+				agg.failed++
+			default:
+				continue
+			}
+			if d, err := time.ParseDuration(t.Duration); err == nil {
+				agg.total += d
+			}
+		}
+	}
+	return out
+}
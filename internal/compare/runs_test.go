@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package compare
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func run(tests ...ResultTest) *Results {
+	return &Results{Tests: tests}
+}
+
+func TestRuns(t *testing.T) {
+	before := []*Results{
+		run(
+			ResultTest{ID: "a#stable", Status: "passed", Duration: "10ms"},
+			ResultTest{ID: "a#breaks", Status: "passed", Duration: "10ms"},
+			ResultTest{ID: "a#fixed", Status: "failed", Duration: "10ms"},
+			ResultTest{ID: "a#flakes", Status: "passed", Duration: "10ms"},
+			ResultTest{ID: "a#slows", Status: "passed", Duration: "100ms"},
+			ResultTest{ID: "a#removed", Status: "failed"},
+		),
+		run(
+			ResultTest{ID: "a#stable", Status: "passed", Duration: "12ms"},
+			ResultTest{ID: "a#flakes", Status: "passed", Duration: "10ms"},
+			ResultTest{ID: "a#slows", Status: "passed", Duration: "100ms"},
+		),
+	}
+	after := []*Results{
+		run(
+			ResultTest{ID: "a#stable", Status: "passed", Duration: "11ms"},
+			ResultTest{ID: "a#breaks", Status: "failed", Duration: "10ms"},
+			ResultTest{ID: "a#fixed", Status: "passed", Duration: "10ms"},
+			ResultTest{ID: "a#flakes", Status: "failed", Duration: "10ms"},
+			ResultTest{ID: "a#slows", Status: "passed", Duration: "200ms"},
+			ResultTest{ID: "a#added", Status: "failed"},
+		),
+		run(
+			ResultTest{ID: "a#flakes", Status: "passed", Duration: "10ms"},
+			ResultTest{ID: "a#slows", Status: "passed", Duration: "160ms"},
+		),
+	}
+
+	diff := Runs(before, after, 0.2, 10*time.Millisecond)
+
+	assert.Equal(t, []string{"a#breaks"}, diff.NewlyFailing)
+	assert.Equal(t, []string{"a#fixed"}, diff.NewlyPassing)
+	assert.Equal(t, []string{"a#flakes"}, diff.NewlyFlaky)
+	require.Len(t, diff.Slower, 1)
+	assert.Equal(t, DurationChange{ID: "a#slows", Before: 100 * time.Millisecond, After: 180 * time.Millisecond, Change: 0.8}, diff.Slower[0])
+	assert.Equal(t, 3, diff.Regressions())
+	assert.Equal(t, OutcomeFlaky, diff.After["a#flakes"])
+	assert.Equal(t, OutcomeFailed, diff.Before["a#removed"])
+}
+
+func TestRuns_MinSlowdown(t *testing.T) {
+	before := []*Results{run(ResultTest{ID: "fast", Status: "passed", Duration: "1ms"})}
+	after := []*Results{run(ResultTest{ID: "fast", Status: "passed", Duration: "3ms"})}
+
+	assert.Empty(t, Runs(before, after, 0.2, 10*time.Millisecond).Slower)
+	assert.Len(t, Runs(before, after, 0.2, 0).Slower, 1)
+}
+
+func TestRuns_SkippedAndNames(t *testing.T) {
+	before := []*Results{run(ResultTest{Name: "t", Status: "skipped"})}
+	after := []*Results{run(ResultTest{Name: "t", Status: "failed"})}
+
+	diff := Runs(before, after, 0.2, 0)
+	assert.Empty(t, diff.NewlyFailing)
+	assert.Equal(t, OutcomeNone, diff.Before["t"])
+	assert.Equal(t, OutcomeFailed, diff.After["t"])
+}
+
+func TestOutcomes(t *testing.T) {
+	outcomes := Outcomes([]*Results{
+		run(ResultTest{ID: "a", Status: "passed"}, ResultTest{ID: "b", Status: "failed"}, ResultTest{ID: "c", Status: "skipped"}),
+		run(ResultTest{ID: "a", Status: "failed"}, ResultTest{ID: "b", Status: "failed"}),
+	})
+
+	assert.Equal(t, map[string]Outcome{"a": OutcomeFlaky, "b": OutcomeFailed, "c": OutcomeNone}, outcomes)
+}
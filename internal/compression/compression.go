@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package compression encodes and decodes bodies with the HTTP content
+// codings of manifests (gzip, deflate and br).
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+
+	"github.com/andybalholm/brotli"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// ContentEncoding returns the content-encoding header value of an encoding,
+// or an empty string when the encoding is unspecified.
+func ContentEncoding(enc extproctorv1.BodyEncoding) string {
+	switch enc {
+	case extproctorv1.BodyEncoding_GZIP:
+		return "gzip"
+	case extproctorv1.BodyEncoding_DEFLATE:
+		return "deflate"
+	case extproctorv1.BodyEncoding_BR:
+		return "br"
+	default:
+		return ""
+	}
+}
+
+// Encode compresses data with the given encoding. Data is returned as is when
+// the encoding is unspecified.
+func Encode(enc extproctorv1.BodyEncoding, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch enc {
+	case extproctorv1.BodyEncoding_BODY_ENCODING_UNSPECIFIED:
+		return data, nil
+	case extproctorv1.BodyEncoding_GZIP:
+		w = gzip.NewWriter(&buf)
+	case extproctorv1.BodyEncoding_DEFLATE:
+		w = zlib.NewWriter(&buf)
+	case extproctorv1.BodyEncoding_BR:
+		w = brotli.NewWriter(&buf)
+	default:
+		return nil, fmt.Errorf("unsupported body encoding %s", enc)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to encode body: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode decompresses data encoded with the given encoding. Data is returned
+// as is when the encoding is unspecified.
+func Decode(enc extproctorv1.BodyEncoding, data []byte) ([]byte, error) {
+	var r io.Reader
+	switch enc {
+	case extproctorv1.BodyEncoding_BODY_ENCODING_UNSPECIFIED:
+		return data, nil
+	case extproctorv1.BodyEncoding_GZIP:
+		gr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		r = gr
+	case extproctorv1.BodyEncoding_DEFLATE:
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid deflate body: %w", err)
+		}
+		r = zr
+	case extproctorv1.BodyEncoding_BR:
+		r = brotli.NewReader(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("unsupported body encoding %s", enc)
+	}
+
+	decoded, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s body: %w", ContentEncoding(enc), err)
+	}
+	return decoded, nil
+}
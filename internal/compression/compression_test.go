@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package compression
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func TestEncodeDecode_RoundTrip(t *testing.T) {
+	body := []byte(`{"message": "hello, compressed world"}`)
+
+	for _, enc := range []extproctorv1.BodyEncoding{
+		extproctorv1.BodyEncoding_GZIP,
+		extproctorv1.BodyEncoding_DEFLATE,
+		extproctorv1.BodyEncoding_BR,
+	} {
+		t.Run(enc.String(), func(t *testing.T) {
+			encoded, err := Encode(enc, body)
+			require.NoError(t, err)
+			assert.NotEqual(t, body, encoded)
+
+			decoded, err := Decode(enc, encoded)
+			require.NoError(t, err)
+			assert.Equal(t, body, decoded)
+		})
+	}
+}
+
+func TestEncodeDecode_Unspecified(t *testing.T) {
+	body := []byte("plain")
+
+	encoded, err := Encode(extproctorv1.BodyEncoding_BODY_ENCODING_UNSPECIFIED, body)
+	require.NoError(t, err)
+	assert.Equal(t, body, encoded)
+
+	decoded, err := Decode(extproctorv1.BodyEncoding_BODY_ENCODING_UNSPECIFIED, body)
+	require.NoError(t, err)
+	assert.Equal(t, body, decoded)
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	_, err := Decode(extproctorv1.BodyEncoding_GZIP, []byte("not gzip"))
+	assert.ErrorContains(t, err, "invalid gzip body")
+
+	_, err = Decode(extproctorv1.BodyEncoding_DEFLATE, []byte("not deflate"))
+	assert.ErrorContains(t, err, "invalid deflate body")
+
+	_, err = Decode(extproctorv1.BodyEncoding_BR, []byte("not brotli"))
+	assert.ErrorContains(t, err, "invalid br body")
+}
+
+func TestContentEncoding(t *testing.T) {
+	assert.Equal(t, "gzip", ContentEncoding(extproctorv1.BodyEncoding_GZIP))
+	assert.Equal(t, "deflate", ContentEncoding(extproctorv1.BodyEncoding_DEFLATE))
+	assert.Equal(t, "br", ContentEncoding(extproctorv1.BodyEncoding_BR))
+	assert.Empty(t, ContentEncoding(extproctorv1.BodyEncoding_BODY_ENCODING_UNSPECIFIED))
+}
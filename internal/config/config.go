@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package config loads the extproctor configuration file.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// DefaultPath is the configuration file read when none is given, relative to
+// the working directory.
+const DefaultPath = ".extproctor.textproto"
+
+// Load reads a prototext configuration file. An empty path reads DefaultPath,
+// whose absence yields an empty configuration.
+func Load(path string) (*extproctorv1.Config, error) {
+	optional := path == ""
+	if optional {
+		path = DefaultPath
+	}
+
+	data, err := os.ReadFile(path)
+	if optional && errors.Is(err, fs.ErrNotExist) {
+		return &extproctorv1.Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration: %w", err)
+	}
+
+	cfg := &extproctorv1.Config{}
+	if err := prototext.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Proxy returns the URL of the first proxy of the configuration used for the
+// profile, with its environment variables expanded, or an empty string when
+// none is.
+func Proxy(cfg *extproctorv1.Config, profile string) string {
+	for _, p := range cfg.GetProxies() {
+		if len(p.Profiles) == 0 || slices.Contains(p.Profiles, profile) {
+			return os.ExpandEnv(p.Url)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package durations persists the duration of the tests of the last runs, to
+// start the longest tests first under parallelism.
+package durations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultPath is the location of the durations file, relative to the working
+// directory.
+var DefaultPath = filepath.Join(".extproctor", "durations.json")
+
+type file struct {
+	// Durations are in milliseconds, by test ID.
+	Durations map[string]int64 `json:"durations"`
+}
+
+// Load reads the test durations. A missing file yields no durations.
+func Load(path string) (map[string]time.Duration, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test durations: %w", err)
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse test durations: %w", err)
+	}
+
+	durations := make(map[string]time.Duration, len(f.Durations))
+	for id, ms := range f.Durations {
+		durations[id] = time.Duration(ms) * time.Millisecond
+	}
+	return durations, nil
+}
+
+// Save writes the test durations, rounded to the millisecond.
+func Save(path string, durations map[string]time.Duration) error {
+	f := file{Durations: make(map[string]int64, len(durations))}
+	for id, d := range durations {
+		f.Durations[id] = d.Milliseconds()
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal test durations: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write test durations: %w", err)
+	}
+
+	return nil
+}
+
+// Merge updates the previous durations with the ones of a run: the tests
+// that ran get their new duration, the others keep theirs.
+func Merge(previous, ran map[string]time.Duration) map[string]time.Duration {
+	merged := make(map[string]time.Duration, len(previous)+len(ran))
+	for id, d := range previous {
+		merged[id] = d
+	}
+	for id, d := range ran {
+		merged[id] = d
+	}
+	return merged
+}
@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package durations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".extproctor", "durations.json")
+
+	require.NoError(t, Save(path, map[string]time.Duration{
+		"a.textproto::a": 1500 * time.Microsecond,
+		"b.textproto::b": 2 * time.Second,
+	}))
+
+	durations, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{
+		"a.textproto::a": time.Millisecond,
+		"b.textproto::b": 2 * time.Second,
+	}, durations)
+}
+
+func TestLoad_Missing(t *testing.T) {
+	durations, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	require.NoError(t, err)
+	assert.Empty(t, durations)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "durations.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o644))
+
+	_, err := Load(path)
+	assert.ErrorContains(t, err, "failed to parse test durations")
+}
+
+func TestMerge(t *testing.T) {
+	previous := map[string]time.Duration{"m::not-run": time.Second, "m::faster": time.Minute}
+	ran := map[string]time.Duration{"m::faster": time.Second, "m::new": time.Millisecond}
+
+	assert.Equal(t, map[string]time.Duration{
+		"m::not-run": time.Second,
+		"m::faster":  time.Second,
+		"m::new":     time.Millisecond,
+	}, Merge(previous, ran))
+}
@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package filterlog captures the lines written by the ExtProc service to its
+// log file while a test runs.
+package filterlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// MaxBytes is the maximum amount of log captured for a test; only the last
+// lines are kept beyond it.
+const MaxBytes = 64 * 1024
+
+// Tail reads the lines appended to a log file.
+type Tail struct {
+	path string
+}
+
+// NewTail creates a tail of the given log file. The file does not need to
+// exist yet.
+func NewTail(path string) *Tail {
+	return &Tail{path: path}
+}
+
+// Offset returns the current end of the log file, to be passed to Since once
+// the test completes.
+func (t *Tail) Offset() (int64, error) {
+	info, err := os.Stat(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat filter log: %w", err)
+	}
+	return info.Size(), nil
+}
+
+// Since returns the lines appended to the log file after the given offset. A
+// log file truncated in the meantime (e.g. rotated) is read from its start.
+func (t *Tail) Since(offset int64) ([]string, error) {
+	f, err := os.Open(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open filter log: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat filter log: %w", err)
+	}
+	end := info.Size()
+	if end < offset {
+		offset = 0
+	}
+
+	truncated := false
+	if end-offset > MaxBytes {
+		offset = end - MaxBytes
+		truncated = true
+	}
+
+	data := make([]byte, end-offset)
+	if _, err := f.ReadAt(data, offset); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read filter log: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if truncated {
+		// Drop the partial first line
+		lines = lines[1:]
+	}
+	if len(lines) == 1 && lines[0] == "" {
+		return nil, nil
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package filterlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func appendLog(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString(content)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func TestTail_Since(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "filter.log")
+	tail := NewTail(path)
+
+	// Missing file
+	offset, err := tail.Offset()
+	require.NoError(t, err)
+	assert.Zero(t, offset)
+
+	appendLog(t, path, "before\n")
+	offset, err = tail.Offset()
+	require.NoError(t, err)
+
+	lines, err := tail.Since(offset)
+	require.NoError(t, err)
+	assert.Empty(t, lines)
+
+	appendLog(t, path, "request received\ndenied\n")
+	lines, err = tail.Since(offset)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"request received", "denied"}, lines)
+}
+
+func TestTail_Since_Truncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "filter.log")
+	tail := NewTail(path)
+
+	appendLog(t, path, strings.Repeat("old line\n", 10))
+	offset, err := tail.Offset()
+	require.NoError(t, err)
+
+	// Rotated log
+	require.NoError(t, os.WriteFile(path, []byte("new\n"), 0o644))
+	lines, err := tail.Since(offset)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new"}, lines)
+}
+
+func TestTail_Since_MaxBytes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "filter.log")
+	tail := NewTail(path)
+
+	appendLog(t, path, strings.Repeat("0123456789abcdef\n", MaxBytes/17+10)+"last\n")
+	lines, err := tail.Since(0)
+	require.NoError(t, err)
+	assert.Equal(t, "last", lines[len(lines)-1])
+	assert.Equal(t, "0123456789abcdef", lines[0])
+	assert.LessOrEqual(t, len(strings.Join(lines, "\n")), MaxBytes)
+}
+
+func TestTail_Since_Missing(t *testing.T) {
+	lines, err := NewTail(filepath.Join(t.TempDir(), "missing.log")).Since(0)
+	require.NoError(t, err)
+	assert.Nil(t, lines)
+}
@@ -0,0 +1,258 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package gen generates test case stubs from golden files no test case
+// references, e.g. after a bulk recording session.
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/protocolbuffers/txtpbfmt/parser"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/golden"
+	"zntr.io/extproctor/internal/manifest"
+	"zntr.io/extproctor/internal/paths"
+)
+
+// Stub is a test case generated for unreferenced golden files.
+type Stub struct {
+	// Name is the test name, inferred from the golden file path.
+	Name string
+
+	// GoldenFile is the golden path template of the test, with {phase} when
+	// the golden files are split per phase.
+	GoldenFile string
+
+	// Phases lists the processing phases of the golden expectations.
+	Phases []extproctorv1.ProcessingPhase
+}
+
+// FromGolden returns the stubs of the golden files found under dir and
+// referenced by no test case of the manifests. Files that are not golden
+// files, such as manifests, are ignored.
+func FromGolden(dir string, manifests []*manifest.LoadedManifest) ([]*Stub, error) {
+	files, err := goldenFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var globs []string
+	names := map[string]bool{}
+	for _, m := range manifests {
+		for _, tc := range m.TestCases {
+			names[tc.Name] = true
+			if tc.GoldenFile == "" {
+				continue
+			}
+			template := tc.GoldenFile
+			if !filepath.IsAbs(template) {
+				template = filepath.Join(filepath.Dir(m.SourcePath), template)
+			}
+			abs, err := filepath.Abs(golden.PathGlob(template, tc.Name))
+			if err != nil {
+				return nil, err
+			}
+			globs = append(globs, abs)
+		}
+	}
+
+	groups := map[string]*Stub{}
+	var stubs []*Stub
+	for _, file := range files {
+		if referenced(file.abs, globs) {
+			continue
+		}
+
+		template, name := inferTemplate(dir, file.path)
+		stub, ok := groups[template]
+		if !ok {
+			stub = &Stub{Name: uniqueName(name, names), GoldenFile: template}
+			groups[template] = stub
+			stubs = append(stubs, stub)
+		}
+		for _, exp := range file.expectations {
+			if !slices.Contains(stub.Phases, exp.Phase) {
+				stub.Phases = append(stub.Phases, exp.Phase)
+			}
+		}
+	}
+
+	for _, s := range stubs {
+		slices.Sort(s.Phases)
+	}
+	return stubs, nil
+}
+
+// goldenFile is a golden file found under the golden directory.
+type goldenFile struct {
+	path         string
+	abs          string
+	expectations []*extproctorv1.ExtProcExpectation
+}
+
+// goldenFiles returns the golden files under dir, in lexical order.
+func goldenFiles(dir string) ([]goldenFile, error) {
+	var files []goldenFile
+	err := paths.Walk(dir, true, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".textproto" {
+			return nil
+		}
+
+		expectations, err := golden.Read(path)
+		if err != nil || len(expectations) == 0 {
+			// Not a golden file
+			return nil
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		files = append(files, goldenFile{path: path, abs: abs, expectations: expectations})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// referenced reports whether a file matches one of the golden globs.
+func referenced(path string, globs []string) bool {
+	for _, g := range globs {
+		if ok, _ := filepath.Match(g, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// inferTemplate infers the golden path template and test name of a golden
+// file. Files named after a phase (golden/deny/request_headers.textproto) or
+// suffixed with one (golden/deny.request_headers.textproto) get a {phase}
+// template, grouping the phases of a test.
+func inferTemplate(dir, path string) (template, name string) {
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	base := filepath.Base(stem)
+
+	for _, phase := range phaseNames() {
+		switch {
+		case base == phase:
+			prefix := filepath.Dir(stem)
+			return filepath.Join(prefix, golden.PlaceholderPhase) + ext, testName(dir, prefix)
+		case len(base) > len(phase) && strings.HasSuffix(base, phase) && strings.ContainsRune("._-", rune(base[len(base)-len(phase)-1])):
+			prefix := stem[:len(stem)-len(phase)-1]
+			sep := stem[len(stem)-len(phase)-1 : len(stem)-len(phase)]
+			return prefix + sep + golden.PlaceholderPhase + ext, testName(dir, prefix)
+		}
+	}
+
+	return path, testName(dir, stem)
+}
+
+// testName infers a test name from a golden path without extension, relative
+// to the golden directory.
+func testName(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(path)
+	}
+	return strings.ReplaceAll(filepath.ToSlash(rel), "/", "-")
+}
+
+// uniqueName returns a name not used yet, suffixed with a number if needed,
+// and records it.
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	for i := 2; used[unique]; i++ {
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	used[unique] = true
+	return unique
+}
+
+// phaseNames returns the lower-case names of the processing phases, as
+// substituted for {phase}.
+func phaseNames() []string {
+	return []string{
+		"request_headers", "request_body", "request_trailers",
+		"response_headers", "response_body", "response_trailers",
+	}
+}
+
+// WriteManifest writes a manifest with the test cases of the stubs. Golden
+// paths are written relative to baseDir, the directory of the manifest. The
+// requests are placeholders to complete, with the processing of the phases
+// recorded in the golden files enabled.
+func WriteManifest(w io.Writer, stubs []*Stub, baseDir string) error {
+	var buf bytes.Buffer
+	buf.WriteString("# Generated by extproctor gen tests from golden files.\n")
+	buf.WriteString("# TODO: replace the placeholder requests by the recorded ones.\n")
+	buf.WriteString("name: \"generated\"\n")
+
+	for _, s := range stubs {
+		goldenFile := s.GoldenFile
+		if rel, err := relPath(baseDir, goldenFile); err == nil {
+			goldenFile = rel
+		}
+
+		fmt.Fprintf(&buf, "test_cases: {\n  name: %s\n  tags: [\"generated\"]\n", strconv.Quote(s.Name))
+		buf.WriteString("  # TODO: set the request recorded in the golden file\n")
+		buf.WriteString("  request: {\n    method: \"GET\"\n    path: \"/\"\n")
+		for _, flag := range processingFlags(s.Phases) {
+			fmt.Fprintf(&buf, "    %s: true\n", flag)
+		}
+		fmt.Fprintf(&buf, "  }\n  golden_file: %s\n}\n", strconv.Quote(filepath.ToSlash(goldenFile)))
+	}
+
+	formatted, err := parser.Format(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to format manifest: %w", err)
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+// processingFlags returns the request fields enabling the given phases.
+func processingFlags(phases []extproctorv1.ProcessingPhase) []string {
+	var flags []string
+	for _, p := range phases {
+		switch p {
+		case extproctorv1.ProcessingPhase_REQUEST_BODY:
+			flags = append(flags, "process_request_body")
+		case extproctorv1.ProcessingPhase_REQUEST_TRAILERS:
+			flags = append(flags, "process_request_trailers")
+		case extproctorv1.ProcessingPhase_RESPONSE_HEADERS:
+			flags = append(flags, "process_response_headers")
+		case extproctorv1.ProcessingPhase_RESPONSE_BODY:
+			flags = append(flags, "process_response_body")
+		case extproctorv1.ProcessingPhase_RESPONSE_TRAILERS:
+			flags = append(flags, "process_response_trailers")
+		}
+	}
+	return flags
+}
+
+// relPath returns path relative to base, both made absolute.
+func relPath(base, path string) (string, error) {
+	absBase, err := filepath.Abs(base)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(absBase, absPath)
+}
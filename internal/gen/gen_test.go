@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package gen
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/manifest"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+const (
+	requestHeadersGolden  = `name: "golden" expectations: { phase: REQUEST_HEADERS headers_response: {} }`
+	responseHeadersGolden = `name: "golden" expectations: { phase: RESPONSE_HEADERS headers_response: {} }`
+)
+
+func TestFromGolden(t *testing.T) {
+	tmpDir := t.TempDir()
+	goldenDir := filepath.Join(tmpDir, "golden")
+	writeFile(t, filepath.Join(goldenDir, "ok.textproto"), requestHeadersGolden)
+	writeFile(t, filepath.Join(goldenDir, "auth", "deny.textproto"), requestHeadersGolden)
+	writeFile(t, filepath.Join(goldenDir, "flow", "request_headers.textproto"), requestHeadersGolden)
+	writeFile(t, filepath.Join(goldenDir, "flow", "response_headers.textproto"), responseHeadersGolden)
+	writeFile(t, filepath.Join(goldenDir, "cors.response_headers.textproto"), responseHeadersGolden)
+	// Not golden files
+	writeFile(t, filepath.Join(goldenDir, "manifest.textproto"), `test_cases: { name: "x" }`)
+	writeFile(t, filepath.Join(goldenDir, "empty.textproto"), ``)
+
+	manifests := []*manifest.LoadedManifest{{
+		SourcePath: filepath.Join(tmpDir, "tests", "m.textproto"),
+		TestManifest: &extproctorv1.TestManifest{TestCases: []*extproctorv1.TestCase{
+			{Name: "ok", GoldenFile: "../golden/{test_name}.textproto"},
+			{Name: "auth-deny"},
+		}},
+	}}
+
+	stubs, err := FromGolden(goldenDir, manifests)
+	require.NoError(t, err)
+	require.Len(t, stubs, 3)
+
+	assert.Equal(t, &Stub{
+		Name:       "auth-deny-2",
+		GoldenFile: filepath.Join(goldenDir, "auth", "deny.textproto"),
+		Phases:     []extproctorv1.ProcessingPhase{extproctorv1.ProcessingPhase_REQUEST_HEADERS},
+	}, stubs[0])
+	assert.Equal(t, &Stub{
+		Name:       "cors",
+		GoldenFile: filepath.Join(goldenDir, "cors.{phase}.textproto"),
+		Phases:     []extproctorv1.ProcessingPhase{extproctorv1.ProcessingPhase_RESPONSE_HEADERS},
+	}, stubs[1])
+	assert.Equal(t, &Stub{
+		Name:       "flow",
+		GoldenFile: filepath.Join(goldenDir, "flow", "{phase}.textproto"),
+		Phases: []extproctorv1.ProcessingPhase{
+			extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			extproctorv1.ProcessingPhase_RESPONSE_HEADERS,
+		},
+	}, stubs[2])
+}
+
+func TestFromGolden_MissingDir(t *testing.T) {
+	_, err := FromGolden(filepath.Join(t.TempDir(), "missing"), nil)
+	assert.ErrorContains(t, err, "failed to walk")
+}
+
+func TestWriteManifest(t *testing.T) {
+	tmpDir := t.TempDir()
+	goldenDir := filepath.Join(tmpDir, "golden")
+	writeFile(t, filepath.Join(goldenDir, "flow", "request_headers.textproto"), requestHeadersGolden)
+	writeFile(t, filepath.Join(goldenDir, "flow", "response_headers.textproto"), responseHeadersGolden)
+
+	stubs, err := FromGolden(goldenDir, nil)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteManifest(&buf, stubs, filepath.Join(tmpDir, "tests")))
+	assert.Contains(t, buf.String(), "# TODO: set the request recorded in the golden file\n")
+	assert.Contains(t, buf.String(), `golden_file: "../golden/flow/{phase}.textproto"`)
+
+	// The generated manifest is valid and references the golden files
+	path := filepath.Join(tmpDir, "tests", "generated.textproto")
+	writeFile(t, path, buf.String())
+	m, err := manifest.NewLoader().LoadFile(path)
+	require.NoError(t, err)
+	require.Len(t, m.TestCases, 1)
+	tc := m.TestCases[0]
+	assert.NoError(t, manifest.ValidateTestCase(tc))
+	assert.Equal(t, "flow", tc.Name)
+	assert.True(t, tc.Request.ProcessResponseHeaders)
+
+	stubs, err = FromGolden(goldenDir, []*manifest.LoadedManifest{m})
+	require.NoError(t, err)
+	assert.Empty(t, stubs)
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package glob translates gitignore-style glob patterns to regular
+// expressions matching slash-separated paths.
+package glob
+
+import (
+	"regexp"
+	"strings"
+)
+
+// HasMeta reports whether a pattern contains glob characters.
+func HasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
+
+// Compile compiles a glob pattern into a regular expression matching whole
+// slash-separated paths. "*" and "?" do not match "/", "[...]" matches a
+// character class ("[!...]" negated), "**/" matches any number of
+// directories and a trailing "/**" everything below a directory. Matching
+// is case-sensitive whatever the file system.
+func Compile(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("^" + Translate(pattern) + "$")
+}
+
+// Translate converts a glob pattern to an unanchored regular expression.
+func Translate(pattern string) string {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/") && (i == 0 || pattern[i-1] == '/'):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case pattern[i:] == "**" && i > 0 && pattern[i-1] == '/':
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{pattern: "*.textproto", path: "auth.textproto", match: true},
+		{pattern: "*.textproto", path: "sub/auth.textproto", match: false},
+		{pattern: "auth?.textproto", path: "auth1.textproto", match: true},
+		{pattern: "**/auth*.textproto", path: "auth.textproto", match: true},
+		{pattern: "**/auth*.textproto", path: "a/b/auth-deny.textproto", match: true},
+		{pattern: "a/**/b.textproto", path: "a/b.textproto", match: true},
+		{pattern: "a/**/b.textproto", path: "a/x/y/b.textproto", match: true},
+		{pattern: "a/**", path: "a/x/y", match: true},
+		{pattern: "[ab].textproto", path: "a.textproto", match: true},
+		{pattern: "[!ab].textproto", path: "a.textproto", match: false},
+		{pattern: "[!ab].textproto", path: "c.textproto", match: true},
+		{pattern: `\*.textproto`, path: "*.textproto", match: true},
+		{pattern: `\*.textproto`, path: "a.textproto", match: false},
+		{pattern: "Auth.textproto", path: "auth.textproto", match: false},
+		{pattern: "a.b", path: "axb", match: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			re, err := Compile(tt.pattern)
+			require.NoError(t, err)
+			assert.Equal(t, tt.match, re.MatchString(tt.path))
+		})
+	}
+}
+
+func TestHasMeta(t *testing.T) {
+	assert.True(t, HasMeta("tests/**/*.textproto"))
+	assert.True(t, HasMeta("test?.textproto"))
+	assert.True(t, HasMeta("[ab].textproto"))
+	assert.False(t, HasMeta("tests/auth.textproto"))
+}
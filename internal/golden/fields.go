@@ -0,0 +1,34 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package golden
+
+import "google.golang.org/protobuf/reflect/protoreflect"
+
+// HandledFields lists the ProcessingResponse fields converted into
+// expectations by the golden converter. It is checked by `extproctor apicheck`
+// against the compiled Envoy descriptors; keep it in sync with the converters.
+var HandledFields = []protoreflect.FullName{
+	"envoy.service.ext_proc.v3.ProcessingResponse.request_headers",
+	"envoy.service.ext_proc.v3.ProcessingResponse.response_headers",
+	"envoy.service.ext_proc.v3.ProcessingResponse.request_body",
+	"envoy.service.ext_proc.v3.ProcessingResponse.response_body",
+	"envoy.service.ext_proc.v3.ProcessingResponse.request_trailers",
+	"envoy.service.ext_proc.v3.ProcessingResponse.response_trailers",
+	"envoy.service.ext_proc.v3.ProcessingResponse.immediate_response",
+	"envoy.service.ext_proc.v3.HeadersResponse.response",
+	"envoy.service.ext_proc.v3.BodyResponse.response",
+	"envoy.service.ext_proc.v3.TrailersResponse.header_mutation",
+	"envoy.service.ext_proc.v3.CommonResponse.header_mutation",
+	"envoy.service.ext_proc.v3.CommonResponse.body_mutation",
+	"envoy.service.ext_proc.v3.HeaderMutation.set_headers",
+	"envoy.service.ext_proc.v3.HeaderMutation.remove_headers",
+	"envoy.service.ext_proc.v3.BodyMutation.body",
+	"envoy.service.ext_proc.v3.BodyMutation.clear_body",
+	"envoy.service.ext_proc.v3.ImmediateResponse.status",
+	"envoy.service.ext_proc.v3.ImmediateResponse.headers",
+	"envoy.service.ext_proc.v3.ImmediateResponse.body",
+	"envoy.service.ext_proc.v3.ImmediateResponse.details",
+	"envoy.service.ext_proc.v3.ImmediateResponse.grpc_status",
+	"envoy.service.ext_proc.v3.GrpcStatus.status",
+}
@@ -4,6 +4,8 @@
 package golden
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,6 +13,7 @@ import (
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
 	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
 	"zntr.io/extproctor/internal/client"
 )
@@ -31,23 +34,79 @@ func getHeaderValue(h *corev3.HeaderValue) string {
 	return ""
 }
 
+// Version is the schema version of the golden files written by Write.
+const Version = 1
+
+// upgrades upgrade golden files from a schema version to the next one:
+// upgrades[v] upgrades a golden file of version v to version v+1.
+var upgrades = []func(wrapper *extproctorv1.GoldenFile){
+	// Version 0 golden files have no version header, their placeholder name
+	// is a reserved field skipped when parsed.
+	func(*extproctorv1.GoldenFile) {},
+}
+
+// Metadata is recorded in golden files besides the expectations.
+type Metadata struct {
+	// RequestDigest is the digest of the request the golden file was
+	// generated from (see RequestDigest), empty in golden files written by
+	// older versions.
+	RequestDigest string
+}
+
+// RequestDigest returns the digest of the request of a test case, as
+// recorded in its golden files: "sha256:" followed by the hex SHA-256 of its
+// deterministic wire encoding.
+func RequestDigest(req *extproctorv1.HttpRequest) string {
+	data, _ := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
 // Write writes the processing result as a golden file.
-func Write(path string, result *client.ProcessingResult) error {
+func Write(path string, result *client.ProcessingResult, meta Metadata) error {
 	expectations := convertToExpectations(result)
 
 	// Create wrapper message for serialization
-	wrapper := &extproctorv1.TestCase{
-		Name:         "golden",
-		Expectations: expectations,
+	wrapper := &extproctorv1.GoldenFile{
+		Version:       Version,
+		RequestDigest: meta.RequestDigest,
+		Expectations:  expectations,
 	}
 
+	return writeFile(path, wrapper)
+}
+
+// Upgrade rewrites a golden file written in an older schema version in the
+// current one, and returns the version it was written in.
+func Upgrade(path string) (uint32, error) {
+	wrapper, err := parseFile(path)
+	if err != nil {
+		return 0, err
+	}
+	from := wrapper.Version
+	if from == Version {
+		return from, nil
+	}
+
+	if err := upgrade(wrapper); err != nil {
+		return from, err
+	}
+	return from, writeFile(path, wrapper)
+}
+
+// writeFile writes a golden file.
+func writeFile(path string, wrapper *extproctorv1.GoldenFile) error {
+	// The version is written first, as a header
+	body := proto.Clone(wrapper).(*extproctorv1.GoldenFile)
+	body.Version = 0
 	data, err := prototext.MarshalOptions{
 		Multiline: true,
 		Indent:    "  ",
-	}.Marshal(wrapper)
+	}.Marshal(body)
 	if err != nil {
 		return fmt.Errorf("failed to marshal golden file: %w", err)
 	}
+	data = append(fmt.Appendf(nil, "version: %d\n", wrapper.Version), data...)
 
 	// Ensure directory exists
 	dir := filepath.Dir(path)
@@ -64,17 +123,57 @@ func Write(path string, result *client.ProcessingResult) error {
 
 // Read reads expectations from a golden file.
 func Read(path string) ([]*extproctorv1.ExtProcExpectation, error) {
+	wrapper, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return wrapper.Expectations, nil
+}
+
+// readFile reads a golden file, upgraded to the current schema version.
+func readFile(path string) (*extproctorv1.GoldenFile, error) {
+	wrapper, err := parseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := upgrade(wrapper); err != nil {
+		return nil, err
+	}
+	return wrapper, nil
+}
+
+// parseFile reads a golden file as written.
+func parseFile(path string) (*extproctorv1.GoldenFile, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read golden file: %w", err)
 	}
 
-	wrapper := &extproctorv1.TestCase{}
+	wrapper := &extproctorv1.GoldenFile{}
 	if err := prototext.Unmarshal(data, wrapper); err != nil {
 		return nil, fmt.Errorf("failed to parse golden file: %w", err)
 	}
 
-	return wrapper.Expectations, nil
+	return wrapper, nil
+}
+
+// upgrade upgrades a golden file to the current schema version.
+func upgrade(wrapper *extproctorv1.GoldenFile) error {
+	return migrate(wrapper, upgrades)
+}
+
+// migrate upgrades a golden file to the version following the steps:
+// steps[v] upgrades a golden file of version v to version v+1.
+func migrate(wrapper *extproctorv1.GoldenFile, steps []func(*extproctorv1.GoldenFile)) error {
+	target := uint32(len(steps))
+	if wrapper.Version > target {
+		return fmt.Errorf("golden file version %d is newer than the supported version %d: upgrade extproctor", wrapper.Version, target)
+	}
+	for v := wrapper.Version; v < target; v++ {
+		steps[v](wrapper)
+	}
+	wrapper.Version = target
+	return nil
 }
 
 // convertToExpectations converts processing results to expectations.
@@ -161,10 +260,22 @@ func convertEnvoyTrailersResponse(resp *extprocv3.TrailersResponse) *extproctorv
 	trailersExp := &extproctorv1.TrailersExpectation{}
 
 	if resp != nil && resp.HeaderMutation != nil {
-		trailersExp.SetTrailers = make(map[string]string)
-		for _, h := range resp.HeaderMutation.SetHeaders {
-			if h.Header != nil {
-				trailersExp.SetTrailers[h.Header.Key] = getHeaderValue(h.Header)
+		// A map would lose repeated trailers, record them as entries then
+		if hasRepeatedKeys(resp.HeaderMutation.SetHeaders) {
+			for _, h := range resp.HeaderMutation.SetHeaders {
+				if h.Header != nil {
+					trailersExp.SetTrailerEntries = append(trailersExp.SetTrailerEntries, &extproctorv1.HeaderEntry{
+						Key:   h.Header.Key,
+						Value: getHeaderValue(h.Header),
+					})
+				}
+			}
+		} else {
+			trailersExp.SetTrailers = make(map[string]string)
+			for _, h := range resp.HeaderMutation.SetHeaders {
+				if h.Header != nil {
+					trailersExp.SetTrailers[h.Header.Key] = getHeaderValue(h.Header)
+				}
 			}
 		}
 		trailersExp.RemoveTrailers = resp.HeaderMutation.RemoveHeaders
@@ -175,6 +286,21 @@ func convertEnvoyTrailersResponse(resp *extprocv3.TrailersResponse) *extproctorv
 	}
 }
 
+// hasRepeatedKeys reports whether several headers share the same key.
+func hasRepeatedKeys(headers []*corev3.HeaderValueOption) bool {
+	seen := make(map[string]bool, len(headers))
+	for _, h := range headers {
+		if h.Header == nil {
+			continue
+		}
+		if seen[h.Header.Key] {
+			return true
+		}
+		seen[h.Header.Key] = true
+	}
+	return false
+}
+
 // convertEnvoyImmediateResponse converts an ExtProc immediate response to our expectation format.
 func convertEnvoyImmediateResponse(resp *extprocv3.ImmediateResponse) *extproctorv1.ExtProcExpectation_ImmediateResponse {
 	immExp := &extproctorv1.ImmediateExpectation{}
@@ -4,8 +4,10 @@
 package golden
 
 import (
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
@@ -13,6 +15,7 @@ import (
 	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
 	"zntr.io/extproctor/internal/client"
 )
@@ -48,7 +51,7 @@ func TestWrite_RequestHeaders(t *testing.T) {
 		},
 	}
 
-	err := Write(goldenPath, result)
+	err := Write(goldenPath, result, Metadata{})
 	require.NoError(t, err)
 
 	// Verify file exists
@@ -92,7 +95,7 @@ func TestWrite_ResponseHeaders(t *testing.T) {
 		},
 	}
 
-	err := Write(goldenPath, result)
+	err := Write(goldenPath, result, Metadata{})
 	require.NoError(t, err)
 
 	expectations, err := Read(goldenPath)
@@ -125,7 +128,7 @@ func TestWrite_RequestBody(t *testing.T) {
 		},
 	}
 
-	err := Write(goldenPath, result)
+	err := Write(goldenPath, result, Metadata{})
 	require.NoError(t, err)
 
 	expectations, err := Read(goldenPath)
@@ -158,7 +161,7 @@ func TestWrite_ResponseBody(t *testing.T) {
 		},
 	}
 
-	err := Write(goldenPath, result)
+	err := Write(goldenPath, result, Metadata{})
 	require.NoError(t, err)
 
 	expectations, err := Read(goldenPath)
@@ -195,7 +198,7 @@ func TestWrite_RequestTrailers(t *testing.T) {
 		},
 	}
 
-	err := Write(goldenPath, result)
+	err := Write(goldenPath, result, Metadata{})
 	require.NoError(t, err)
 
 	expectations, err := Read(goldenPath)
@@ -203,6 +206,43 @@ func TestWrite_RequestTrailers(t *testing.T) {
 	assert.Len(t, expectations, 1)
 }
 
+func TestWrite_RepeatedTrailers(t *testing.T) {
+	goldenPath := filepath.Join(t.TempDir(), "golden.textproto")
+
+	result := &client.ProcessingResult{
+		Responses: []*client.PhaseResponse{
+			{
+				Phase: extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_ResponseTrailers{
+						ResponseTrailers: &extprocv3.TrailersResponse{
+							HeaderMutation: &extprocv3.HeaderMutation{
+								SetHeaders: []*corev3.HeaderValueOption{
+									{Header: &corev3.HeaderValue{Key: "x-experiment", Value: "a"}},
+									{Header: &corev3.HeaderValue{Key: "x-experiment", Value: "b"}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	require.NoError(t, Write(goldenPath, result, Metadata{}))
+
+	expectations, err := Read(goldenPath)
+	require.NoError(t, err)
+	require.Len(t, expectations, 1)
+
+	trailers := expectations[0].GetTrailersResponse()
+	require.NotNil(t, trailers)
+	assert.Empty(t, trailers.SetTrailers)
+	require.Len(t, trailers.SetTrailerEntries, 2)
+	assert.Equal(t, "a", trailers.SetTrailerEntries[0].Value)
+	assert.Equal(t, "b", trailers.SetTrailerEntries[1].Value)
+}
+
 func TestWrite_ResponseTrailers(t *testing.T) {
 	tmpDir := t.TempDir()
 	goldenPath := filepath.Join(tmpDir, "golden.textproto")
@@ -231,7 +271,7 @@ func TestWrite_ResponseTrailers(t *testing.T) {
 		},
 	}
 
-	err := Write(goldenPath, result)
+	err := Write(goldenPath, result, Metadata{})
 	require.NoError(t, err)
 
 	expectations, err := Read(goldenPath)
@@ -275,7 +315,7 @@ func TestWrite_ImmediateResponse(t *testing.T) {
 		},
 	}
 
-	err := Write(goldenPath, result)
+	err := Write(goldenPath, result, Metadata{})
 	require.NoError(t, err)
 
 	expectations, err := Read(goldenPath)
@@ -300,7 +340,7 @@ func TestWrite_CreatesDirectory(t *testing.T) {
 		},
 	}
 
-	err := Write(goldenPath, result)
+	err := Write(goldenPath, result, Metadata{})
 	require.NoError(t, err)
 
 	// Verify directory was created
@@ -316,7 +356,7 @@ func TestWrite_EmptyResult(t *testing.T) {
 		Responses: []*client.PhaseResponse{},
 	}
 
-	err := Write(goldenPath, result)
+	err := Write(goldenPath, result, Metadata{})
 	require.NoError(t, err)
 
 	expectations, err := Read(goldenPath)
@@ -340,6 +380,113 @@ func TestRead_InvalidPrototext(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestRead_Versions(t *testing.T) {
+	tmpDir := t.TempDir()
+	goldenPath := filepath.Join(tmpDir, "golden.textproto")
+
+	// Golden files written before they were versioned are upgraded
+	require.NoError(t, os.WriteFile(goldenPath, []byte(`name: "golden" expectations: { phase: REQUEST_HEADERS headers_response: {} }`), 0o644))
+	wrapper, err := readFile(goldenPath)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(Version), wrapper.Version)
+	assert.Len(t, wrapper.Expectations, 1)
+
+	require.NoError(t, os.WriteFile(goldenPath, []byte(`version: 99`), 0o644))
+	_, err = Read(goldenPath)
+	assert.EqualError(t, err, "golden file version 99 is newer than the supported version 1: upgrade extproctor")
+}
+
+func TestUpgrade(t *testing.T) {
+	tmpDir := t.TempDir()
+	goldenPath := filepath.Join(tmpDir, "golden.textproto")
+	require.NoError(t, os.WriteFile(goldenPath, []byte(`name: "golden" request_digest: "sha256:0123" expectations: { phase: REQUEST_HEADERS headers_response: {} }`), 0o644))
+
+	from, err := Upgrade(goldenPath)
+	require.NoError(t, err)
+	assert.Zero(t, from)
+
+	content, err := os.ReadFile(goldenPath)
+	require.NoError(t, err)
+	assert.Regexp(t, `^version: 1\n`, string(content))
+	wrapper, err := parseFile(goldenPath)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(Version), wrapper.Version)
+	assert.NotContains(t, string(content), "name")
+	assert.Equal(t, "sha256:0123", wrapper.RequestDigest)
+	assert.Len(t, wrapper.Expectations, 1)
+
+	// Up-to-date golden files are left untouched
+	info, err := os.Stat(goldenPath)
+	require.NoError(t, err)
+	from, err = Upgrade(goldenPath)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(Version), from)
+	after, err := os.Stat(goldenPath)
+	require.NoError(t, err)
+	assert.Equal(t, info.ModTime(), after.ModTime())
+}
+
+func TestUpgrades(t *testing.T) {
+	// Each schema version needs an upgrade from the previous one
+	assert.Len(t, upgrades, Version)
+}
+
+func TestMigrate(t *testing.T) {
+	// A version 2 reshaping the set_trailers map of version 1 into
+	// set_trailer_entries, sorted by key.
+	steps := append(slices.Clone(upgrades), func(wrapper *extproctorv1.GoldenFile) {
+		for _, exp := range wrapper.Expectations {
+			trailers := exp.GetTrailersResponse()
+			if trailers == nil {
+				continue
+			}
+			for _, k := range slices.Sorted(maps.Keys(trailers.SetTrailers)) {
+				trailers.SetTrailerEntries = append(trailers.SetTrailerEntries, &extproctorv1.HeaderEntry{Key: k, Value: trailers.SetTrailers[k]})
+			}
+			trailers.SetTrailers = nil
+		}
+	})
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "version 1",
+			content: `version: 1 expectations: { phase: RESPONSE_TRAILERS trailers_response: { set_trailers: { key: "x-b" value: "2" } set_trailers: { key: "x-a" value: "1" } } }`,
+		},
+		{
+			name:    "version 0",
+			content: `name: "golden" expectations: { phase: RESPONSE_TRAILERS trailers_response: { set_trailers: { key: "x-b" value: "2" } set_trailers: { key: "x-a" value: "1" } } }`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goldenPath := filepath.Join(t.TempDir(), "golden.textproto")
+			require.NoError(t, os.WriteFile(goldenPath, []byte(tt.content), 0o644))
+			wrapper, err := parseFile(goldenPath)
+			require.NoError(t, err)
+
+			require.NoError(t, migrate(wrapper, steps))
+			assert.Equal(t, uint32(2), wrapper.Version)
+			require.Len(t, wrapper.Expectations, 1)
+			trailers := wrapper.Expectations[0].GetTrailersResponse()
+			require.NotNil(t, trailers)
+			assert.Empty(t, trailers.SetTrailers)
+			require.Len(t, trailers.SetTrailerEntries, 2)
+			assert.Equal(t, "x-a", trailers.SetTrailerEntries[0].Key)
+			assert.Equal(t, "1", trailers.SetTrailerEntries[0].Value)
+			assert.Equal(t, "x-b", trailers.SetTrailerEntries[1].Key)
+			assert.Equal(t, "2", trailers.SetTrailerEntries[1].Value)
+		})
+	}
+
+	// Golden files newer than the steps are rejected
+	err := migrate(&extproctorv1.GoldenFile{Version: 3}, steps)
+	assert.EqualError(t, err, "golden file version 3 is newer than the supported version 2: upgrade extproctor")
+}
+
 func TestWrite_NilHeaderInResponse(t *testing.T) {
 	tmpDir := t.TempDir()
 	goldenPath := filepath.Join(tmpDir, "golden.textproto")
@@ -371,7 +518,7 @@ func TestWrite_NilHeaderInResponse(t *testing.T) {
 		},
 	}
 
-	err := Write(goldenPath, result)
+	err := Write(goldenPath, result, Metadata{})
 	require.NoError(t, err)
 
 	expectations, err := Read(goldenPath)
@@ -449,7 +596,7 @@ func TestWrite_MultipleResponses(t *testing.T) {
 		},
 	}
 
-	err := Write(goldenPath, result)
+	err := Write(goldenPath, result, Metadata{})
 	require.NoError(t, err)
 
 	expectations, err := Read(goldenPath)
@@ -546,7 +693,7 @@ func TestWrite_InvalidPath(t *testing.T) {
 	}
 
 	// Use /dev/null as parent which can't have subdirectories
-	err := Write("/dev/null/subdir/golden.textproto", result)
+	err := Write("/dev/null/subdir/golden.textproto", result, Metadata{})
 	assert.Error(t, err)
 }
 
@@ -574,7 +721,7 @@ func TestWrite_ReadOnlyDirectory(t *testing.T) {
 		},
 	}
 
-	err = Write(filepath.Join(readOnlyDir, "golden.textproto"), result)
+	err = Write(filepath.Join(readOnlyDir, "golden.textproto"), result, Metadata{})
 	assert.Error(t, err)
 }
 
@@ -619,3 +766,17 @@ func TestConvertEnvoyHeadersResponse_WithNilHeader(t *testing.T) {
 	assert.Contains(t, result.HeadersResponse.SetHeaders, "x-valid")
 	assert.Contains(t, result.HeadersResponse.RemoveHeaders, "x-remove")
 }
+
+func TestRequestDigest(t *testing.T) {
+	req := &extproctorv1.HttpRequest{
+		Method:  "GET",
+		Path:    "/api/users",
+		Headers: map[string]string{"a": "1", "b": "2", "c": "3"},
+	}
+	digest := RequestDigest(req)
+	assert.Regexp(t, `^sha256:[0-9a-f]{64}$`, digest)
+	assert.Equal(t, digest, RequestDigest(proto.Clone(req).(*extproctorv1.HttpRequest)))
+
+	req.Path = "/api/groups"
+	assert.NotEqual(t, digest, RequestDigest(req))
+}
@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package golden
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+)
+
+// Golden path placeholders resolved by the runner.
+const (
+	PlaceholderTestName = "{test_name}"
+	PlaceholderPhase    = "{phase}"
+	PlaceholderTarget   = "{target}"
+)
+
+var (
+	placeholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+	unsafeSegmentChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// PathVars holds the values substituted in golden path templates.
+type PathVars struct {
+	TestName string
+	Target   string
+}
+
+// ValidatePathTemplate checks that a golden path only uses known placeholders.
+func ValidatePathTemplate(pattern string) error {
+	for _, p := range placeholderPattern.FindAllString(pattern, -1) {
+		switch p {
+		case PlaceholderTestName, PlaceholderPhase, PlaceholderTarget:
+		default:
+			return fmt.Errorf("unknown placeholder %s", p)
+		}
+	}
+	return nil
+}
+
+// IsPerPhase reports whether the golden path template stores one file per
+// processing phase.
+func IsPerPhase(pattern string) bool {
+	return strings.Contains(pattern, PlaceholderPhase)
+}
+
+// ExpandPath resolves the placeholders of a golden path template. The phase is
+// only used when the template contains {phase}. Values are sanitized into
+// single path segments.
+func ExpandPath(pattern string, vars PathVars, phase extproctorv1.ProcessingPhase) string {
+	return strings.ReplaceAll(ExpandVars(pattern, vars), PlaceholderPhase, strings.ToLower(phase.String()))
+}
+
+// PathGlob returns a glob pattern matching the golden files of a path
+// template for a test, whatever the target and phase.
+func PathGlob(pattern, testName string) string {
+	return strings.NewReplacer(
+		PlaceholderTestName, sanitizeSegment(testName),
+		PlaceholderTarget, "*",
+		PlaceholderPhase, "*",
+	).Replace(pattern)
+}
+
+// ExpandVars resolves every placeholder of a golden path template but {phase}.
+func ExpandVars(pattern string, vars PathVars) string {
+	return strings.NewReplacer(
+		PlaceholderTestName, sanitizeSegment(vars.TestName),
+		PlaceholderTarget, sanitizeSegment(vars.Target),
+	).Replace(pattern)
+}
+
+// sanitizeSegment replaces the characters unsafe in a file name.
+func sanitizeSegment(s string) string {
+	return unsafeSegmentChars.ReplaceAllString(s, "_")
+}
+
+// WriteTemplate writes the processing result to the golden file(s) designated
+// by the path template. Per-phase templates get one file per phase, and the
+// files of phases absent from the result are removed.
+func WriteTemplate(pattern string, vars PathVars, result *client.ProcessingResult, meta Metadata) error {
+	if !IsPerPhase(pattern) {
+		return Write(ExpandPath(pattern, vars, extproctorv1.ProcessingPhase_PROCESSING_PHASE_UNSPECIFIED), result, meta)
+	}
+
+	byPhase := map[extproctorv1.ProcessingPhase][]*client.PhaseResponse{}
+	for _, resp := range result.Responses {
+		byPhase[resp.Phase] = append(byPhase[resp.Phase], resp)
+	}
+
+	for _, phase := range goldenPhases() {
+		path := ExpandPath(pattern, vars, phase)
+		responses, ok := byPhase[phase]
+		if !ok {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to remove stale golden file: %w", err)
+			}
+			continue
+		}
+		if err := Write(path, &client.ProcessingResult{Responses: responses}, meta); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadTemplate reads the expectations and metadata from the golden file(s)
+// designated by the path template. Per-phase templates require at least one
+// phase file; their metadata is the one of the first file recording some.
+func ReadTemplate(pattern string, vars PathVars) ([]*extproctorv1.ExtProcExpectation, Metadata, error) {
+	if !IsPerPhase(pattern) {
+		wrapper, err := readFile(ExpandPath(pattern, vars, extproctorv1.ProcessingPhase_PROCESSING_PHASE_UNSPECIFIED))
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		return wrapper.Expectations, Metadata{RequestDigest: wrapper.RequestDigest}, nil
+	}
+
+	var expectations []*extproctorv1.ExtProcExpectation
+	var meta Metadata
+	found := false
+	for _, phase := range goldenPhases() {
+		path := ExpandPath(pattern, vars, phase)
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		wrapper, err := readFile(path)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		found = true
+		expectations = append(expectations, wrapper.Expectations...)
+		if meta.RequestDigest == "" {
+			meta.RequestDigest = wrapper.RequestDigest
+		}
+	}
+
+	if !found {
+		return nil, Metadata{}, fmt.Errorf("failed to read golden file: no file matches %s: %w", ExpandVars(pattern, vars), os.ErrNotExist)
+	}
+
+	return expectations, meta, nil
+}
+
+// goldenPhases returns the processing phases in stream order.
+func goldenPhases() []extproctorv1.ProcessingPhase {
+	return []extproctorv1.ProcessingPhase{
+		extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+		extproctorv1.ProcessingPhase_REQUEST_BODY,
+		extproctorv1.ProcessingPhase_REQUEST_TRAILERS,
+		extproctorv1.ProcessingPhase_RESPONSE_HEADERS,
+		extproctorv1.ProcessingPhase_RESPONSE_BODY,
+		extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
+	}
+}
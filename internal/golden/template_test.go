@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package golden
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+)
+
+func TestValidatePathTemplate(t *testing.T) {
+	assert.NoError(t, ValidatePathTemplate("golden/test.textproto"))
+	assert.NoError(t, ValidatePathTemplate("golden/{target}/{test_name}.{phase}.textproto"))
+	assert.EqualError(t, ValidatePathTemplate("golden/{env}.textproto"), "unknown placeholder {env}")
+}
+
+func TestExpandPath(t *testing.T) {
+	vars := PathVars{TestName: "auth/deny anonymous", Target: "localhost:50051"}
+
+	assert.Equal(t,
+		"golden/localhost_50051/auth_deny_anonymous.request_headers.textproto",
+		ExpandPath("golden/{target}/{test_name}.{phase}.textproto", vars, extproctorv1.ProcessingPhase_REQUEST_HEADERS),
+	)
+	assert.Equal(t, "golden/plain.textproto", ExpandPath("golden/plain.textproto", vars, extproctorv1.ProcessingPhase_REQUEST_HEADERS))
+}
+
+func TestWriteReadTemplate_SingleFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	pattern := filepath.Join(tmpDir, "{target}", "{test_name}.textproto")
+	vars := PathVars{TestName: "test-1", Target: "staging"}
+
+	result := &client.ProcessingResult{
+		Responses: []*client.PhaseResponse{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_RequestHeaders{
+						RequestHeaders: &extprocv3.HeadersResponse{},
+					},
+				},
+			},
+		},
+	}
+
+	require.NoError(t, WriteTemplate(pattern, vars, result, Metadata{}))
+	_, err := os.Stat(filepath.Join(tmpDir, "staging", "test-1.textproto"))
+	require.NoError(t, err)
+
+	expectations, _, err := ReadTemplate(pattern, vars)
+	require.NoError(t, err)
+	assert.Len(t, expectations, 1)
+
+	_, _, err = ReadTemplate(pattern, PathVars{TestName: "test-1", Target: "prod"})
+	assert.Error(t, err)
+}
+
+func TestWriteReadTemplate_PerPhase(t *testing.T) {
+	tmpDir := t.TempDir()
+	pattern := filepath.Join(tmpDir, "{test_name}.{phase}.textproto")
+	vars := PathVars{TestName: "test-1"}
+
+	result := &client.ProcessingResult{
+		Responses: []*client.PhaseResponse{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_RequestHeaders{
+						RequestHeaders: &extprocv3.HeadersResponse{},
+					},
+				},
+			},
+			{
+				Phase: extproctorv1.ProcessingPhase_RESPONSE_HEADERS,
+				Response: &extprocv3.ProcessingResponse{
+					Response: &extprocv3.ProcessingResponse_ResponseHeaders{
+						ResponseHeaders: &extprocv3.HeadersResponse{},
+					},
+				},
+			},
+		},
+	}
+
+	// A stale file for a phase absent from the result is removed
+	stale := filepath.Join(tmpDir, "test-1.request_body.textproto")
+	require.NoError(t, os.WriteFile(stale, []byte("name: \"golden\"\n"), 0o644))
+
+	meta := Metadata{RequestDigest: "sha256:0123"}
+	require.NoError(t, WriteTemplate(pattern, vars, result, meta))
+
+	for _, name := range []string{"test-1.request_headers.textproto", "test-1.response_headers.textproto"} {
+		_, err := os.Stat(filepath.Join(tmpDir, name))
+		require.NoError(t, err)
+	}
+	_, err := os.Stat(stale)
+	assert.True(t, os.IsNotExist(err))
+
+	expectations, read, err := ReadTemplate(pattern, vars)
+	require.NoError(t, err)
+	assert.Equal(t, meta, read)
+	require.Len(t, expectations, 2)
+	assert.Equal(t, extproctorv1.ProcessingPhase_REQUEST_HEADERS, expectations[0].Phase)
+	assert.Equal(t, extproctorv1.ProcessingPhase_RESPONSE_HEADERS, expectations[1].Phase)
+}
+
+func TestReadTemplate_PerPhaseMissing(t *testing.T) {
+	_, _, err := ReadTemplate(filepath.Join(t.TempDir(), "{test_name}.{phase}.textproto"), PathVars{TestName: "missing"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing.{phase}.textproto")
+}
+
+func TestPathGlob(t *testing.T) {
+	assert.Equal(t, "golden/*/auth_deny.*.textproto", PathGlob("golden/{target}/{test_name}.{phase}.textproto", "auth/deny"))
+	assert.Equal(t, "golden/plain.textproto", PathGlob("golden/plain.textproto", "t"))
+}
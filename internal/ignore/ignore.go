@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package ignore implements the .extproctorignore files, excluding paths from
+// the manifest discovery with the gitignore syntax.
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"zntr.io/extproctor/internal/glob"
+)
+
+// FileName is the name of the ignore files.
+const FileName = ".extproctorignore"
+
+// rule is a single pattern of an ignore file.
+type rule struct {
+	// base is the directory of the ignore file, patterns are relative to it.
+	base string
+
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Matcher matches paths against the rules of the loaded ignore files. Like
+// gitignore, the last matching rule wins and negated rules re-include paths.
+type Matcher struct {
+	rules []rule
+}
+
+// AddFile loads the ignore file of a directory, if any.
+func (m *Matcher) AddFile(dir string) error {
+	f, err := os.Open(filepath.Join(dir, FileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open ignore file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if r, ok := parseRule(dir, scanner.Text()); ok {
+			m.rules = append(m.rules, r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read ignore file: %w", err)
+	}
+
+	return nil
+}
+
+// Ignored reports whether a path is excluded by the loaded rules.
+func (m *Matcher) Ignored(path string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		rel, err := filepath.Rel(r.base, path)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if r.pattern.MatchString(filepath.ToSlash(rel)) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// parseRule parses a line of an ignore file, skipping blank lines and
+// comments.
+func parseRule(base, line string) (rule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	r := rule{base: base}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		// Escaped leading "#" or "!"
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+
+	// A pattern without slash matches at any level, otherwise it is relative
+	// to the directory of the ignore file.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := glob.Translate(line)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return rule{}, false
+	}
+	r.pattern = re
+
+	return r, true
+}
@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcher_Ignored(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte(`# Vendored fixtures
+vendor/
+*.wip.textproto
+/generated
+docs/**/draft-*.textproto
+!keep.wip.textproto
+\#literal.textproto
+fixtures/[ab].textproto
+`), 0o644))
+
+	m := &Matcher{}
+	require.NoError(t, m.AddFile(dir))
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{path: "vendor", isDir: true, ignored: true},
+		{path: "sub/vendor", isDir: true, ignored: true},
+		{path: "vendor", isDir: false, ignored: false},
+		{path: "auth.wip.textproto", ignored: true},
+		{path: "sub/deep/auth.wip.textproto", ignored: true},
+		{path: "keep.wip.textproto", ignored: false},
+		{path: "generated", isDir: true, ignored: true},
+		{path: "sub/generated", isDir: true, ignored: false},
+		{path: "docs/draft-a.textproto", ignored: true},
+		{path: "docs/x/y/draft-b.textproto", ignored: true},
+		{path: "docs/final.textproto", ignored: false},
+		{path: "#literal.textproto", ignored: true},
+		{path: "fixtures/a.textproto", ignored: true},
+		{path: "fixtures/c.textproto", ignored: false},
+		{path: "auth.textproto", ignored: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.ignored, m.Ignored(filepath.Join(dir, filepath.FromSlash(tt.path)), tt.isDir))
+		})
+	}
+
+	// Paths outside the ignore file directory are never ignored
+	assert.False(t, m.Ignored(filepath.Join(filepath.Dir(dir), "auth.wip.textproto"), false))
+}
+
+func TestMatcher_AddFile_Missing(t *testing.T) {
+	m := &Matcher{}
+	require.NoError(t, m.AddFile(t.TempDir()))
+	assert.Empty(t, m.rules)
+}
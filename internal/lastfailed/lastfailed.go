@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package lastfailed persists the IDs of the tests that failed in the last
+// runs, to rerun them first or alone.
+package lastfailed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DefaultPath is the location of the last-failed file, relative to the
+// working directory.
+var DefaultPath = filepath.Join(".extproctor", "last-failed.json")
+
+type file struct {
+	Failed []string `json:"failed"`
+}
+
+// Load reads the failed test IDs. A missing file yields no IDs.
+func Load(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read last failed tests: %w", err)
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse last failed tests: %w", err)
+	}
+
+	return f.Failed, nil
+}
+
+// Save writes the failed test IDs, sorted.
+func Save(path string, ids []string) error {
+	sorted := append([]string{}, ids...)
+	sort.Strings(sorted)
+
+	data, err := json.MarshalIndent(file{Failed: sorted}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal last failed tests: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write last failed tests: %w", err)
+	}
+
+	return nil
+}
+
+// Merge updates the previously failed IDs with the outcome of a run: the tests
+// that ran are replaced by their new outcome, the others are kept.
+func Merge(previous, ran, failed []string) []string {
+	ranSet := make(map[string]bool, len(ran))
+	for _, id := range ran {
+		ranSet[id] = true
+	}
+
+	seen := map[string]bool{}
+	var merged []string
+	for _, id := range previous {
+		if !ranSet[id] && !seen[id] {
+			seen[id] = true
+			merged = append(merged, id)
+		}
+	}
+	for _, id := range failed {
+		if !seen[id] {
+			seen[id] = true
+			merged = append(merged, id)
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package lastfailed
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".extproctor", "last-failed.json")
+
+	require.NoError(t, Save(path, []string{"b.textproto::b", "a.textproto::a"}))
+
+	ids, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.textproto::a", "b.textproto::b"}, ids)
+}
+
+func TestLoad_Missing(t *testing.T) {
+	ids, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "last-failed.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o644))
+
+	_, err := Load(path)
+	assert.ErrorContains(t, err, "failed to parse last failed tests")
+}
+
+func TestMerge(t *testing.T) {
+	previous := []string{"m::fixed", "m::not-run", "m::still-failing"}
+	ran := []string{"m::fixed", "m::still-failing", "m::new-failure", "m::passing"}
+	failed := []string{"m::still-failing", "m::new-failure"}
+
+	assert.Equal(t, []string{"m::not-run", "m::still-failing", "m::new-failure"}, Merge(previous, ran, failed))
+}
@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package manifest
+
+import (
+	"slices"
+
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// Environment describes the profile and variables manifests are loaded for.
+type Environment struct {
+	Profile string
+	Vars    map[string]string
+}
+
+// Matches reports whether the condition holds in the environment. A nil
+// condition always matches.
+func (e Environment) Matches(cond *extproctorv1.Condition) bool {
+	if cond == nil {
+		return true
+	}
+
+	if len(cond.Profiles) > 0 && !slices.Contains(cond.Profiles, e.Profile) {
+		return false
+	}
+
+	for k, v := range cond.Vars {
+		actual, ok := e.Vars[k]
+		if !ok || actual != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filter returns the expectations whose condition matches the environment.
+func (e Environment) filter(exps []*extproctorv1.ExtProcExpectation) []*extproctorv1.ExtProcExpectation {
+	kept := exps[:0]
+	for _, exp := range exps {
+		if e.Matches(exp.When) {
+			kept = append(kept, exp)
+		}
+	}
+	return kept
+}
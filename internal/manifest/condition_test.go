@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package manifest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func TestEnvironment_Matches(t *testing.T) {
+	env := Environment{
+		Profile: "prod",
+		Vars:    map[string]string{"region": "eu", "tier": "gold"},
+	}
+
+	tests := []struct {
+		name string
+		cond *extproctorv1.Condition
+		want bool
+	}{
+		{name: "nil condition", cond: nil, want: true},
+		{name: "empty condition", cond: &extproctorv1.Condition{}, want: true},
+		{name: "matching profile", cond: &extproctorv1.Condition{Profiles: []string{"staging", "prod"}}, want: true},
+		{name: "other profile", cond: &extproctorv1.Condition{Profiles: []string{"dev"}}, want: false},
+		{name: "matching vars", cond: &extproctorv1.Condition{Vars: map[string]string{"region": "eu"}}, want: true},
+		{name: "other var value", cond: &extproctorv1.Condition{Vars: map[string]string{"region": "us"}}, want: false},
+		{name: "missing var", cond: &extproctorv1.Condition{Vars: map[string]string{"zone": "a"}}, want: false},
+		{
+			name: "profile and vars",
+			cond: &extproctorv1.Condition{Profiles: []string{"prod"}, Vars: map[string]string{"tier": "gold"}},
+			want: true,
+		},
+		{
+			name: "profile matches but vars do not",
+			cond: &extproctorv1.Condition{Profiles: []string{"prod"}, Vars: map[string]string{"tier": "silver"}},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, env.Matches(tt.cond))
+		})
+	}
+}
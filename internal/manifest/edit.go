@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+var nameFieldPattern = regexp.MustCompile(`\bname\s*:\s*("(?:[^"\\\n]|\\.)*")`)
+
+// SetExpectedFailure marks a test case of a manifest file as an expected
+// failure. The field is inserted after the test case name so the formatting
+// and comments of the file are preserved.
+func SetExpectedFailure(path, testName string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var m extproctorv1.TestManifest
+	if err := prototext.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("failed to parse prototext: %w", err)
+	}
+	tc := findTestCase(&m, testName)
+	if tc == nil {
+		return fmt.Errorf("test case %q not found", testName)
+	}
+	if tc.ExpectedFailure {
+		return nil
+	}
+
+	offset := testCaseNameEnd(string(data), testName)
+	if offset < 0 {
+		return fmt.Errorf("test case %q name not found in source", testName)
+	}
+
+	// Keep the field on its own line when the name is
+	lineStart := strings.LastIndexByte(string(data[:offset]), '\n') + 1
+	line := string(data[lineStart:offset])
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	insert := " expected_failure: true"
+	if strings.HasPrefix(strings.TrimSpace(line), "name") {
+		insert = "\n" + indent + "expected_failure: true"
+	}
+	updated := string(data[:offset]) + insert + string(data[offset:])
+
+	// Make sure the edit produced the expected manifest
+	var check extproctorv1.TestManifest
+	if err := prototext.Unmarshal([]byte(updated), &check); err != nil {
+		return fmt.Errorf("failed to update manifest: %w", err)
+	}
+	if tc := findTestCase(&check, testName); tc == nil || !tc.ExpectedFailure {
+		return fmt.Errorf("failed to update manifest: test case %q not marked", testName)
+	}
+
+	if err := os.WriteFile(path, []byte(updated), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// findTestCase returns the test case with the given name.
+func findTestCase(m *extproctorv1.TestManifest, name string) *extproctorv1.TestCase {
+	for _, tc := range m.TestCases {
+		if tc.Name == name {
+			return tc
+		}
+	}
+	return nil
+}
+
+// testCaseNameEnd returns the offset right after the name field of the given
+// test case, or -1 when not found. Only name fields nested one level deep
+// (inside test_cases) are considered, strings and comments are ignored.
+func testCaseNameEnd(src, testName string) int {
+	depths := nestingDepths(src)
+	for _, match := range nameFieldPattern.FindAllStringSubmatchIndex(src, -1) {
+		if depths[match[0]] != 1 {
+			continue
+		}
+		value, err := strconv.Unquote(src[match[2]:match[3]])
+		if err != nil || value != testName {
+			continue
+		}
+		return match[1]
+	}
+	return -1
+}
+
+// nestingDepths returns the message nesting depth of each byte of the source,
+// or -1 for bytes inside strings and comments.
+func nestingDepths(src string) []int {
+	depths := make([]int, len(src))
+	depth := 0
+	for i := 0; i < len(src); i++ {
+		switch c := src[i]; c {
+		case '#':
+			for ; i < len(src) && src[i] != '\n'; i++ {
+				depths[i] = -1
+			}
+			if i < len(src) {
+				depths[i] = depth
+			}
+		case '"', '\'':
+			depths[i] = -1
+			for i++; i < len(src) && src[i] != c && src[i] != '\n'; i++ {
+				depths[i] = -1
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+					depths[i] = -1
+				}
+			}
+			if i < len(src) {
+				depths[i] = -1
+			}
+		case '{', '<':
+			depths[i] = depth
+			depth++
+		case '}', '>':
+			depth--
+			depths[i] = depth
+		default:
+			depths[i] = depth
+		}
+	}
+	return depths
+}
@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetExpectedFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.textproto")
+	content := `# name: "deny"
+name: "deny"
+test_cases {
+  # Flaky upstream
+  name: "deny"
+  request { method: "GET" path: "/" }
+}
+test_cases { name: "allow" request { method: "GET" path: "/" } }
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	require.NoError(t, SetExpectedFailure(path, "deny"))
+	require.NoError(t, SetExpectedFailure(path, "allow"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `# name: "deny"
+name: "deny"
+test_cases {
+  # Flaky upstream
+  name: "deny"
+  expected_failure: true
+  request { method: "GET" path: "/" }
+}
+test_cases { name: "allow" expected_failure: true request { method: "GET" path: "/" } }
+`, string(data))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// Already marked
+	require.NoError(t, SetExpectedFailure(path, "deny"))
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(after))
+}
+
+func TestSetExpectedFailure_NotFound(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.textproto")
+	require.NoError(t, os.WriteFile(path, []byte(`test_cases { name: "allow" }`), 0o644))
+
+	assert.ErrorContains(t, SetExpectedFailure(path, "deny"), "not found")
+}
+
+func TestSetExpectedFailure_InvalidManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.textproto")
+	require.NoError(t, os.WriteFile(path, []byte(`test_cases {`), 0o644))
+
+	assert.Error(t, SetExpectedFailure(path, "deny"))
+}
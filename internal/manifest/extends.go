@@ -0,0 +1,213 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package manifest
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// extendsResolver resolves the test cases extending other ones, loading the
+// imported manifests once.
+type extendsResolver struct {
+	loader *Loader
+
+	// manifests holds the imported manifests by path, nil while resolving to
+	// detect import cycles.
+	manifests map[string]*extproctorv1.TestManifest
+
+	// warnings lists the fields discarded from the imported manifests by a
+	// lenient loader.
+	warnings []string
+
+	// dependencies lists the imported manifests the resolved test cases
+	// inherit from.
+	dependencies map[*extproctorv1.TestCase][]string
+}
+
+// resolveExtends replaces the test cases extending another one by their base
+// overridden with their own fields, then drops the abstract test cases. It
+// returns the fields discarded from the imported manifests by a lenient
+// loader, and the imported manifests each test case inherits from.
+func (l *Loader) resolveExtends(m *extproctorv1.TestManifest, path string) ([]string, map[*extproctorv1.TestCase][]string, error) {
+	r := &extendsResolver{
+		loader:       l,
+		manifests:    map[string]*extproctorv1.TestManifest{filepath.Clean(path): nil},
+		dependencies: make(map[*extproctorv1.TestCase][]string),
+	}
+	if err := r.resolve(m, path); err != nil {
+		return nil, nil, err
+	}
+
+	concrete := m.TestCases[:0]
+	for _, tc := range m.TestCases {
+		if !tc.Abstract {
+			concrete = append(concrete, tc)
+		}
+	}
+	m.TestCases = concrete
+
+	dependencies := make(map[*extproctorv1.TestCase][]string)
+	for _, tc := range m.TestCases {
+		if deps := r.dependencies[tc]; len(deps) > 0 {
+			dependencies[tc] = deps
+		}
+	}
+
+	return r.warnings, dependencies, nil
+}
+
+// resolve resolves the test cases of a manifest in place.
+func (r *extendsResolver) resolve(m *extproctorv1.TestManifest, path string) error {
+	var imported []*extproctorv1.TestManifest
+	var importPaths []string
+	for i, imp := range m.Imports {
+		importPath := imp
+		if !filepath.IsAbs(importPath) {
+			importPath = filepath.Join(filepath.Dir(path), importPath)
+		}
+		im, err := r.load(importPath)
+		if err != nil {
+			return fmt.Errorf("imports[%d]: %w", i, err)
+		}
+		imported = append(imported, im)
+		importPaths = append(importPaths, filepath.Clean(importPath))
+	}
+
+	local := make(map[string]*extproctorv1.TestCase, len(m.TestCases))
+	for _, tc := range m.TestCases {
+		if _, ok := local[tc.Name]; !ok {
+			local[tc.Name] = tc
+		}
+	}
+
+	// Local test cases take precedence over imported ones, which are returned
+	// with the path of their manifest
+	lookup := func(name string) (*extproctorv1.TestCase, string) {
+		if tc, ok := local[name]; ok {
+			return tc, ""
+		}
+		for i, im := range imported {
+			for _, tc := range im.TestCases {
+				if tc.Name == name {
+					return tc, importPaths[i]
+				}
+			}
+		}
+		return nil, ""
+	}
+
+	resolving := map[string]bool{}
+	var resolveCase func(tc *extproctorv1.TestCase) (*extproctorv1.TestCase, error)
+	resolveCase = func(tc *extproctorv1.TestCase) (*extproctorv1.TestCase, error) {
+		if tc.Extends == "" {
+			return tc, nil
+		}
+		if resolving[tc.Name] {
+			return nil, fmt.Errorf("test case %q: extends cycle", tc.Name)
+		}
+
+		base, basePath := lookup(tc.Extends)
+		if base == nil {
+			return nil, fmt.Errorf("test case %q: base test case %q not found", tc.Name, tc.Extends)
+		}
+		if basePath == "" {
+			// Imported test cases are already resolved
+			resolving[tc.Name] = true
+			resolved, err := resolveCase(base)
+			delete(resolving, tc.Name)
+			if err != nil {
+				return nil, err
+			}
+			base = resolved
+		}
+
+		merged := proto.Clone(base).(*extproctorv1.TestCase)
+		if basePath != "" && merged.GoldenFile != "" && !filepath.IsAbs(merged.GoldenFile) {
+			// An inherited golden file stays relative to the manifest declaring it
+			merged.GoldenFile = filepath.Join(filepath.Dir(basePath), merged.GoldenFile)
+		}
+		mergeOverride(merged.ProtoReflect(), proto.Clone(tc).ProtoReflect())
+		merged.Extends = ""
+		merged.Abstract = tc.Abstract
+
+		deps := slices.Clone(r.dependencies[base])
+		if basePath != "" && !slices.Contains(deps, basePath) {
+			deps = append(deps, basePath)
+		}
+		if len(deps) > 0 {
+			r.dependencies[merged] = deps
+		}
+		return merged, nil
+	}
+
+	resolved := make([]*extproctorv1.TestCase, len(m.TestCases))
+	for i, tc := range m.TestCases {
+		rc, err := resolveCase(tc)
+		if err != nil {
+			return err
+		}
+		resolved[i] = rc
+	}
+	m.TestCases = resolved
+
+	return nil
+}
+
+// load parses and resolves an imported manifest.
+func (r *extendsResolver) load(path string) (*extproctorv1.TestManifest, error) {
+	path = filepath.Clean(path)
+	if m, ok := r.manifests[path]; ok {
+		if m == nil {
+			return nil, fmt.Errorf("import cycle on %s", path)
+		}
+		return m, nil
+	}
+
+	r.manifests[path] = nil
+	m, discarded, err := r.loader.parseFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import %s: %w", path, err)
+	}
+	for _, d := range discarded {
+		r.warnings = append(r.warnings, fmt.Sprintf("imported %s: %s", path, d))
+	}
+	if err := r.loader.transform(m, path); err != nil {
+		return nil, fmt.Errorf("failed to import %s: %w", path, err)
+	}
+	if err := r.resolve(m, path); err != nil {
+		return nil, fmt.Errorf("failed to import %s: %w", path, err)
+	}
+	r.manifests[path] = m
+
+	return m, nil
+}
+
+// mergeOverride overrides the fields of dst with the fields set in src:
+// messages are merged recursively, map entries are added or replaced, and
+// scalar and repeated fields are replaced.
+func mergeOverride(dst, src protoreflect.Message) {
+	src.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsMap():
+			dstMap := dst.Mutable(fd).Map()
+			v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				dstMap.Set(k, mv)
+				return true
+			})
+		case fd.IsList():
+			dst.Set(fd, v)
+		case fd.Message() != nil && dst.Has(fd):
+			mergeOverride(dst.Mutable(fd).Message(), v.Message())
+		default:
+			dst.Set(fd, v)
+		}
+		return true
+	})
+}
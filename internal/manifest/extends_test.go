@@ -0,0 +1,292 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func writeManifest(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoader_LoadFile_Extends(t *testing.T) {
+	content := `
+name: "extends"
+test_cases: {
+  name: "base-auth-request"
+  request: {
+    method: "GET"
+    path: "/api"
+    headers: { key: "authorization" value: "Bearer token" }
+    headers: { key: "accept" value: "application/json" }
+  }
+  expectations: {
+    phase: REQUEST_HEADERS
+    headers_response: { set_headers: { key: "x-user" value: "alice" } }
+  }
+}
+test_cases: {
+  name: "admin-request"
+  extends: "base-auth-request"
+  tags: ["admin"]
+  request: {
+    path: "/admin"
+    headers: { key: "accept" value: "text/html" }
+  }
+  expectations: {
+    phase: REQUEST_HEADERS
+    immediate_response: { status_code: 403 }
+  }
+}
+`
+	path := writeManifest(t, t.TempDir(), "extends.textproto", content)
+
+	m, err := NewLoader().LoadFile(path)
+	require.NoError(t, err)
+	require.Len(t, m.TestCases, 2)
+
+	tc := m.TestCases[1]
+	assert.Equal(t, "admin-request", tc.Name)
+	assert.Empty(t, tc.Extends)
+	assert.Equal(t, []string{"admin"}, tc.Tags)
+	assert.Equal(t, "GET", tc.Request.Method)
+	assert.Equal(t, "/admin", tc.Request.Path)
+	assert.Equal(t, map[string]string{
+		"authorization": "Bearer token",
+		"accept":        "text/html",
+	}, tc.Request.Headers)
+	require.Len(t, tc.Expectations, 1)
+	assert.Equal(t, int32(403), tc.Expectations[0].GetImmediateResponse().GetStatusCode())
+
+	// The base is left untouched.
+	base := m.TestCases[0]
+	assert.Equal(t, "/api", base.Request.Path)
+	assert.Equal(t, "application/json", base.Request.Headers["accept"])
+}
+
+func TestLoader_LoadFile_ExtendsChain(t *testing.T) {
+	content := `
+test_cases: { name: "c" extends: "b" request: { path: "/c" } }
+test_cases: { name: "b" extends: "a" request: { method: "POST" } }
+test_cases: { name: "a" abstract: true request: { method: "GET" path: "/a" scheme: "https" } }
+`
+	path := writeManifest(t, t.TempDir(), "chain.textproto", content)
+
+	m, err := NewLoader().LoadFile(path)
+	require.NoError(t, err)
+	require.Len(t, m.TestCases, 2)
+
+	c := m.TestCases[0]
+	assert.Equal(t, "c", c.Name)
+	assert.Equal(t, "POST", c.Request.Method)
+	assert.Equal(t, "/c", c.Request.Path)
+	assert.Equal(t, "https", c.Request.Scheme)
+	assert.False(t, c.Abstract)
+}
+
+func TestLoader_LoadFile_ExtendsImport(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "common"), 0o755))
+	writeManifest(t, filepath.Join(dir, "common"), "base.textproto", `
+test_cases: {
+  name: "base-auth-request"
+  abstract: true
+  request: { method: "GET" headers: { key: "authorization" value: "Bearer token" } }
+}
+`)
+	path := writeManifest(t, dir, "suite.textproto", `
+imports: ["common/base.textproto"]
+test_cases: { name: "get-users" extends: "base-auth-request" request: { path: "/users" } }
+`)
+
+	m, err := NewLoader().LoadFile(path)
+	require.NoError(t, err)
+	require.Len(t, m.TestCases, 1)
+
+	tc := m.TestCases[0]
+	assert.Equal(t, "get-users", tc.Name)
+	assert.Equal(t, "GET", tc.Request.Method)
+	assert.Equal(t, "/users", tc.Request.Path)
+	assert.Equal(t, "Bearer token", tc.Request.Headers["authorization"])
+}
+
+func TestLoader_LoadFile_ExtendsImportGoldenFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "common"), 0o755))
+	writeManifest(t, filepath.Join(dir, "common"), "base.textproto", `
+test_cases: {
+  name: "base"
+  abstract: true
+  request: { method: "GET" path: "/" }
+  golden_file: "golden/{test_name}.textproto"
+}
+`)
+	path := writeManifest(t, dir, "suite.textproto", `
+imports: ["common/base.textproto"]
+test_cases: { name: "inherited" extends: "base" }
+test_cases: { name: "own" extends: "base" golden_file: "golden/own.textproto" }
+`)
+
+	m, err := NewLoader().LoadFile(path)
+	require.NoError(t, err)
+	require.Len(t, m.TestCases, 2)
+
+	// The inherited golden file is resolved against the base manifest, an
+	// overriding one against the extending manifest
+	assert.Equal(t, filepath.Join(dir, "common", "golden", "{test_name}.textproto"), m.TestCases[0].GoldenFile)
+	assert.Equal(t, "golden/own.textproto", m.TestCases[1].GoldenFile)
+}
+
+func TestLoader_LoadFile_ExtendsImportTransformed(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "base.textproto", `
+test_cases: { name: "base" abstract: true request: { method: "GET" path: "/" } }
+`)
+	path := writeManifest(t, dir, "suite.textproto", `
+imports: ["base.textproto"]
+test_cases: { name: "get" extends: "base" }
+`)
+
+	var paths []string
+	inject := TransformerFunc(func(m *extproctorv1.TestManifest, path string) error {
+		paths = append(paths, filepath.Base(path))
+		for _, tc := range m.TestCases {
+			if tc.Abstract {
+				tc.Request.Headers = map[string]string{"authorization": "Bearer secret"}
+			}
+		}
+		return nil
+	})
+
+	m, err := NewLoader(WithTransformers(inject)).LoadFile(path)
+	require.NoError(t, err)
+	require.Len(t, m.TestCases, 1)
+	assert.Equal(t, "Bearer secret", m.TestCases[0].Request.Headers["authorization"])
+	assert.Equal(t, []string{"suite.textproto", "base.textproto"}, paths)
+
+	failing := TransformerFunc(func(_ *extproctorv1.TestManifest, path string) error {
+		if filepath.Base(path) == "base.textproto" {
+			return fmt.Errorf("boom")
+		}
+		return nil
+	})
+	_, err = NewLoader(WithTransformers(failing)).LoadFile(path)
+	assert.ErrorContains(t, err, "failed to import "+filepath.Join(dir, "base.textproto")+": failed to transform manifest: boom")
+}
+
+func TestLoader_LoadFile_ExtendsDependencies(t *testing.T) {
+	dir := t.TempDir()
+	root := writeManifest(t, dir, "root.textproto", `
+test_cases: { name: "root" abstract: true request: { method: "GET" } }
+`)
+	base := writeManifest(t, dir, "base.textproto", `
+imports: ["root.textproto"]
+test_cases: { name: "base" abstract: true extends: "root" request: { path: "/" } }
+`)
+	path := writeManifest(t, dir, "suite.textproto", `
+imports: ["base.textproto"]
+test_cases: { name: "inherited" extends: "base" }
+test_cases: { name: "local" request: { method: "GET" path: "/" } }
+test_cases: { name: "chained" extends: "inherited" }
+`)
+
+	m, err := NewLoader().LoadFile(path)
+	require.NoError(t, err)
+	require.Len(t, m.TestCases, 3)
+
+	assert.ElementsMatch(t, []string{root, base}, m.Dependencies[m.TestCases[0]])
+	assert.Empty(t, m.Dependencies[m.TestCases[1]])
+	assert.ElementsMatch(t, []string{root, base}, m.Dependencies[m.TestCases[2]])
+}
+
+func TestLoader_LoadFile_ExtendsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "missing base",
+			files: map[string]string{
+				"main.textproto": `test_cases: { name: "a" extends: "unknown" }`,
+			},
+			wantErr: `test case "a": base test case "unknown" not found`,
+		},
+		{
+			name: "extends cycle",
+			files: map[string]string{
+				"main.textproto": `
+test_cases: { name: "a" extends: "b" }
+test_cases: { name: "b" extends: "a" }
+`,
+			},
+			wantErr: "extends cycle",
+		},
+		{
+			name: "self extends",
+			files: map[string]string{
+				"main.textproto": `test_cases: { name: "a" extends: "a" }`,
+			},
+			wantErr: "extends cycle",
+		},
+		{
+			name: "import cycle",
+			files: map[string]string{
+				"main.textproto":  `imports: ["other.textproto"]`,
+				"other.textproto": `imports: ["main.textproto"]`,
+			},
+			wantErr: "import cycle",
+		},
+		{
+			name: "missing import",
+			files: map[string]string{
+				"main.textproto": `imports: ["missing.textproto"]`,
+			},
+			wantErr: "imports[0]: failed to import",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				writeManifest(t, dir, name, content)
+			}
+
+			_, err := NewLoader().LoadFile(filepath.Join(dir, "main.textproto"))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestMergeOverride_ZeroValuesKeepBase(t *testing.T) {
+	base := &extproctorv1.TestCase{
+		Name:     "base",
+		Priority: 5,
+		Request:  &extproctorv1.HttpRequest{Method: "GET", Path: "/"},
+	}
+	child := &extproctorv1.TestCase{
+		Name:    "child",
+		Request: &extproctorv1.HttpRequest{Path: "/child"},
+	}
+
+	mergeOverride(base.ProtoReflect(), child.ProtoReflect())
+
+	assert.Equal(t, "child", base.Name)
+	assert.Equal(t, int32(5), base.Priority)
+	assert.Equal(t, "GET", base.Request.Method)
+	assert.Equal(t, "/child", base.Request.Path)
+}
@@ -4,6 +4,7 @@
 package manifest
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -12,7 +13,10 @@ import (
 	"strings"
 
 	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
 	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/paths"
+	"zntr.io/extproctor/internal/version"
 )
 
 const maxFileSize = 1024 * 1024 // 1MB
@@ -21,35 +25,189 @@ const maxFileSize = 1024 * 1024 // 1MB
 type LoadedManifest struct {
 	*extproctorv1.TestManifest
 	SourcePath string
+
+	// SkipReason is set when the test cases of the manifest must be reported
+	// as skipped instead of run, such as when its requirements are not met.
+	SkipReason string
+
+	// Warnings lists the fields discarded by a lenient loader because they
+	// are unknown to this extproctor.
+	Warnings []string
+
+	// Unfiltered holds the expectations of the test cases that dropped some
+	// because their condition does not match the environment, as declared,
+	// so that the dropped ones are still validated.
+	Unfiltered map[*extproctorv1.TestCase][]*extproctorv1.ExtProcExpectation
+
+	// Dependencies lists the imported manifests the test cases inherit from
+	// through extends, for the test cases that inherit from one.
+	Dependencies map[*extproctorv1.TestCase][]string
+}
+
+// ValidateTestCase validates a test case of the manifest along with the
+// expectations dropped by their condition, so that they are checked whatever
+// the environment.
+func (m *LoadedManifest) ValidateTestCase(tc *extproctorv1.TestCase) error {
+	all, ok := m.Unfiltered[tc]
+	if !ok {
+		return ValidateTestCase(tc)
+	}
+
+	declared := proto.Clone(tc).(*extproctorv1.TestCase)
+	declared.Expectations = all
+	return ValidateTestCase(declared)
+}
+
+// ConditionSkipped reports whether the conditions dropped all the
+// expectations of a test case without golden file, which then has nothing
+// to verify in the environment.
+func (m *LoadedManifest) ConditionSkipped(tc *extproctorv1.TestCase) bool {
+	return len(tc.Expectations) == 0 && tc.GoldenFile == "" && len(m.Unfiltered[tc]) > 0
 }
 
 // Loader handles loading and parsing of test manifest files.
 type Loader struct {
-	extensions []string
+	extensions     []string
+	env            Environment
+	transformers   []Transformer
+	followSymlinks bool
+
+	version         string
+	skipUnsupported bool
+	lenient         bool
+}
+
+// Transformer transforms the parsed manifests, loaded or imported, before
+// their inheritance, macros and conditions are resolved, e.g. to expand
+// org-specific macros, inject secrets or generate test cases. It backs the
+// --plugin flag and extproctest.WithTransformer.
+type Transformer interface {
+	Transform(m *extproctorv1.TestManifest, path string) error
+}
+
+// TransformerFunc adapts a function to a Transformer.
+type TransformerFunc func(m *extproctorv1.TestManifest, path string) error
+
+// Transform calls f(m, path).
+func (f TransformerFunc) Transform(m *extproctorv1.TestManifest, path string) error {
+	return f(m, path)
+}
+
+// LoaderOption configures the manifest loader.
+type LoaderOption func(*Loader)
+
+// WithProfile sets the profile conditional expectations are evaluated against.
+func WithProfile(profile string) LoaderOption {
+	return func(l *Loader) {
+		l.env.Profile = profile
+	}
+}
+
+// WithVars sets the variables conditional expectations are evaluated against.
+func WithVars(vars map[string]string) LoaderOption {
+	return func(l *Loader) {
+		l.env.Vars = vars
+	}
+}
+
+// WithTransformers adds transformers applied in order to each loaded or
+// imported manifest.
+func WithTransformers(transformers ...Transformer) LoaderOption {
+	return func(l *Loader) {
+		l.transformers = append(l.transformers, transformers...)
+	}
+}
+
+// WithFollowSymlinks sets whether symlinked directories are walked, which is
+// the default.
+func WithFollowSymlinks(follow bool) LoaderOption {
+	return func(l *Loader) {
+		l.followSymlinks = follow
+	}
+}
+
+// WithVersion sets the extproctor version the requirements of manifests are
+// checked against, the version of the binary by default.
+func WithVersion(v string) LoaderOption {
+	return func(l *Loader) {
+		l.version = v
+	}
+}
+
+// WithSkipUnsupported sets whether manifests whose requirements are not met
+// are loaded with their test cases marked skipped, instead of being refused.
+func WithSkipUnsupported(skip bool) LoaderOption {
+	return func(l *Loader) {
+		l.skipUnsupported = skip
+	}
+}
+
+// WithLenient sets whether the fields unknown to this extproctor are
+// discarded with a warning, instead of failing to parse the manifest, so that
+// suites written for a newer version run without their unsupported
+// assertions.
+func WithLenient(lenient bool) LoaderOption {
+	return func(l *Loader) {
+		l.lenient = lenient
+	}
 }
 
 // NewLoader creates a new manifest loader.
-func NewLoader() *Loader {
-	return &Loader{
-		extensions: []string{".textproto", ".prototext", ".txtpb"},
+func NewLoader(opts ...LoaderOption) *Loader {
+	l := &Loader{
+		extensions:     []string{".textproto", ".prototext", ".txtpb"},
+		followSymlinks: true,
+		version:        version.String(),
 	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
 }
 
-// LoadPaths loads manifests from multiple paths (files or directories).
-func (l *Loader) LoadPaths(paths []string) ([]*LoadedManifest, error) {
-	var manifests []*LoadedManifest
+// LoadPaths loads manifests from multiple paths (files, directories or glob
+// patterns). A file reached through several paths is loaded once.
+func (l *Loader) LoadPaths(args []string) ([]*LoadedManifest, error) {
+	expanded, err := l.ExpandPaths(args)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, path := range paths {
+	var manifests []*LoadedManifest
+	var loadedFiles []os.FileInfo
+	for _, path := range expanded {
 		loaded, err := l.LoadPath(path)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load %s: %w", path, err)
 		}
-		manifests = append(manifests, loaded...)
+
+	next:
+		for _, m := range loaded {
+			info, err := os.Stat(m.SourcePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to stat %s: %w", m.SourcePath, err)
+			}
+			for _, seen := range loadedFiles {
+				if os.SameFile(seen, info) {
+					continue next
+				}
+			}
+			loadedFiles = append(loadedFiles, info)
+			manifests = append(manifests, m)
+		}
 	}
 
 	return manifests, nil
 }
 
+// ExpandPaths expands the glob patterns of path arguments (e.g.
+// "./tests/**/auth*.textproto").
+func (l *Loader) ExpandPaths(args []string) ([]string, error) {
+	return paths.Expand(args, l.followSymlinks)
+}
+
 // LoadPath loads manifests from a single path (file or directory).
 func (l *Loader) LoadPath(path string) ([]*LoadedManifest, error) {
 	info, err := os.Stat(path)
@@ -69,11 +227,13 @@ func (l *Loader) LoadPath(path string) ([]*LoadedManifest, error) {
 	return []*LoadedManifest{manifest}, nil
 }
 
-// loadDirectory recursively loads all manifest files from a directory.
+// loadDirectory recursively loads all manifest files from a directory, except
+// the ones excluded by .extproctorignore files. Symlinked directories are
+// followed unless disabled.
 func (l *Loader) loadDirectory(dir string) ([]*LoadedManifest, error) {
 	var manifests []*LoadedManifest
 
-	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+	err := paths.Walk(dir, l.followSymlinks, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -104,34 +264,153 @@ func (l *Loader) loadDirectory(dir string) ([]*LoadedManifest, error) {
 
 // LoadFile loads a single manifest file.
 func (l *Loader) LoadFile(path string) (*LoadedManifest, error) {
+	manifest, warnings, err := l.parseFile(path)
+	if err == nil {
+		err = checkRequirements(manifest.Requires, l.version)
+	}
+	var unsupported *UnsupportedError
+	if errors.As(err, &unsupported) && l.skipUnsupported {
+		return skippedManifest(manifest, path, unsupported.Error()), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Set default name from filename if not specified.
+	if manifest.Name == "" {
+		manifest.Name = filepath.Base(path)
+	}
+
+	if err := l.transform(manifest, path); err != nil {
+		return nil, err
+	}
+
+	// Inherit the fields of the extended test cases.
+	imported, dependencies, err := l.resolveExtends(manifest, path)
+	if err != nil {
+		return nil, err
+	}
+	warnings = append(warnings, imported...)
+
+	unfiltered := make(map[*extproctorv1.TestCase][]*extproctorv1.ExtProcExpectation)
+	for _, tc := range manifest.TestCases {
+		// Expand the expectation macros.
+		if err := expandMacros(tc); err != nil {
+			return nil, fmt.Errorf("test case %q: %w", tc.Name, err)
+		}
+
+		// Reject invalid duration and size literals early.
+		if err := validateLiterals(tc); err != nil {
+			return nil, fmt.Errorf("test case %q: %w", tc.Name, err)
+		}
+
+		// Drop the expectations whose condition does not match the environment.
+		declared := slices.Clone(tc.Expectations)
+		tc.Expectations = l.env.filter(tc.Expectations)
+		if len(tc.Expectations) < len(declared) {
+			unfiltered[tc] = declared
+		}
+	}
+
+	return &LoadedManifest{
+		TestManifest: manifest,
+		SourcePath:   path,
+		Warnings:     warnings,
+		Unfiltered:   unfiltered,
+		Dependencies: dependencies,
+	}, nil
+}
+
+// transform applies the transformers in order to a parsed manifest, loaded
+// or imported.
+func (l *Loader) transform(m *extproctorv1.TestManifest, path string) error {
+	for _, t := range l.transformers {
+		if err := t.Transform(m, path); err != nil {
+			return fmt.Errorf("failed to transform manifest: %w", err)
+		}
+	}
+	return nil
+}
+
+// skippedManifest returns a manifest whose concrete test cases are reported
+// as skipped for the given reason, without interpreting them further.
+func skippedManifest(m *extproctorv1.TestManifest, path, reason string) *LoadedManifest {
+	if m.Name == "" {
+		m.Name = filepath.Base(path)
+	}
+	m.TestCases = slices.DeleteFunc(m.TestCases, func(tc *extproctorv1.TestCase) bool {
+		return tc.Abstract
+	})
+
+	return &LoadedManifest{
+		TestManifest: m,
+		SourcePath:   path,
+		SkipReason:   reason,
+	}
+}
+
+// parseFile reads and parses a manifest file, returning the fields discarded
+// by a lenient loader. When the manifest cannot be parsed because it needs a
+// newer extproctor, the fields known to this one are returned with an
+// UnsupportedError.
+func (l *Loader) parseFile(path string) (*extproctorv1.TestManifest, []string, error) {
 	// Open the file for reading.
 	f, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer func() { _ = f.Close() }()
 
 	// Read the file into a buffer with a maximum size of 1MB to avoid DOS attacks.
 	data, err := io.ReadAll(io.LimitReader(f, maxFileSize))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+		return nil, nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	// Unmarshal the prototext data into a TestManifest message.
-	manifest := &extproctorv1.TestManifest{}
-	if err := prototext.Unmarshal(data, manifest); err != nil {
-		return nil, fmt.Errorf("failed to parse prototext: %w", err)
+	if l.lenient {
+		manifest, discarded, err := parseLenient(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse prototext: %w", err)
+		}
+		resolveFiles(manifest, path)
+		return manifest, discarded, nil
 	}
 
-	// Set default name from filename if not specified.
-	if manifest.Name == "" {
-		manifest.Name = filepath.Base(path)
+	// Unmarshal the prototext data into a TestManifest message.
+	manifest, err := parseManifest(data)
+	if err != nil {
+		// A manifest written for a newer extproctor may use fields unknown to
+		// this one: report its unmet requirements rather than parse errors.
+		lenient := &extproctorv1.TestManifest{}
+		if (prototext.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, lenient) == nil {
+			var unsupported *UnsupportedError
+			if errors.As(checkRequirements(lenient.Requires, l.version), &unsupported) {
+				return lenient, nil, unsupported
+			}
+		}
+		return nil, nil, fmt.Errorf("failed to parse prototext: %w", err)
 	}
+	resolveFiles(manifest, path)
 
-	return &LoadedManifest{
-		TestManifest: manifest,
-		SourcePath:   path,
-	}, nil
+	return manifest, nil, nil
+}
+
+// resolveFiles makes the paths of the files referenced by the requests of a
+// manifest relative to its directory, before test cases of other manifests
+// inherit them.
+func resolveFiles(m *extproctorv1.TestManifest, path string) {
+	for _, tc := range m.TestCases {
+		for _, p := range tc.GetRequest().GetMultipart().GetParts() {
+			if file := p.GetFile(); file != "" && !filepath.IsAbs(file) {
+				p.Content = &extproctorv1.MultipartPart_File{File: filepath.Join(filepath.Dir(path), file)}
+			}
+		}
+		for _, m := range tc.GetRequest().GetGrpc().GetMessages() {
+			if file := m.GetFile(); file != "" && !filepath.IsAbs(file) {
+				m.Content = &extproctorv1.GrpcMessage_File{File: filepath.Join(filepath.Dir(path), file)}
+			}
+		}
+	}
 }
 
 // isManifestFile checks if a file has a recognized manifest extension.
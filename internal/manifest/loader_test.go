@@ -7,10 +7,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
 )
 
 func TestLoader_LoadFile(t *testing.T) {
@@ -408,3 +410,307 @@ func TestLoader_LoadDirectory_EmptyDirectory(t *testing.T) {
 	require.NoError(t, err)
 	assert.Empty(t, manifests)
 }
+
+func TestLoader_LoadFile_ConditionalExpectations(t *testing.T) {
+	content := `
+name: "conditional"
+test_cases: {
+  name: "test-case-1"
+  request: { method: "GET" path: "/" }
+  expectations: {
+    phase: REQUEST_HEADERS
+    headers_response: { set_headers: { key: "x-env" value: "prod" } }
+    when: { profiles: "prod" }
+  }
+  expectations: {
+    phase: REQUEST_HEADERS
+    headers_response: { set_headers: { key: "x-env" value: "dev" } }
+    when: { profiles: "dev" profiles: "local" }
+  }
+  expectations: {
+    phase: RESPONSE_HEADERS
+    headers_response: {}
+  }
+}
+`
+	tmpDir := t.TempDir()
+	manifestPath := filepath.Join(tmpDir, "test.textproto")
+	require.NoError(t, os.WriteFile(manifestPath, []byte(content), 0o644))
+
+	m, err := NewLoader(WithProfile("prod")).LoadFile(manifestPath)
+	require.NoError(t, err)
+	exps := m.TestCases[0].Expectations
+	require.Len(t, exps, 2)
+	assert.Equal(t, "prod", exps[0].GetHeadersResponse().SetHeaders["x-env"])
+	assert.Nil(t, exps[1].When)
+
+	m, err = NewLoader(WithProfile("local")).LoadFile(manifestPath)
+	require.NoError(t, err)
+	exps = m.TestCases[0].Expectations
+	require.Len(t, exps, 2)
+	assert.Equal(t, "dev", exps[0].GetHeadersResponse().SetHeaders["x-env"])
+
+	m, err = NewLoader().LoadFile(manifestPath)
+	require.NoError(t, err)
+	assert.Len(t, m.TestCases[0].Expectations, 1)
+}
+
+func TestLoader_LoadFile_ConditionalExpectations_Validated(t *testing.T) {
+	content := `
+name: "conditional"
+test_cases: {
+  name: "prod-only"
+  request: { method: "GET" path: "/" }
+  expectations: {
+    phase: REQUEST_HEADERS
+    headers_response: { set_headers: { key: "x-env" value: "prod" } }
+    when: { profiles: "prod" }
+  }
+}
+test_cases: {
+  name: "broken-in-dev"
+  request: { method: "GET" path: "/" }
+  expectations: { phase: REQUEST_HEADERS headers_response: {} }
+  expectations: {
+    phase: REQUEST_HEADERS
+    when: { profiles: "dev" }
+  }
+}
+`
+	tmpDir := t.TempDir()
+	manifestPath := filepath.Join(tmpDir, "test.textproto")
+	require.NoError(t, os.WriteFile(manifestPath, []byte(content), 0o644))
+
+	m, err := NewLoader(WithProfile("prod")).LoadFile(manifestPath)
+	require.NoError(t, err)
+
+	// Every expectation is dropped outside of prod: nothing to verify
+	prodOnly, broken := m.TestCases[0], m.TestCases[1]
+	assert.NoError(t, m.ValidateTestCase(prodOnly))
+	assert.False(t, m.ConditionSkipped(prodOnly))
+
+	// The dev expectation is validated although the profile drops it
+	require.Len(t, broken.Expectations, 1)
+	err = m.ValidateTestCase(broken)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expectations[1]")
+	assert.Len(t, broken.Expectations, 1)
+
+	m, err = NewLoader().LoadFile(manifestPath)
+	require.NoError(t, err)
+	prodOnly = m.TestCases[0]
+	assert.Empty(t, prodOnly.Expectations)
+	assert.NoError(t, m.ValidateTestCase(prodOnly))
+	assert.True(t, m.ConditionSkipped(prodOnly))
+}
+
+func TestLoader_LoadFile_InvalidLiterals(t *testing.T) {
+	content := `
+name: "literals"
+test_cases: {
+  name: "test-case-1"
+  timeout: "2"
+  request: { method: "GET" path: "/" body_chunk_size: "64kb" }
+  cost: { body_size: "1TB" duration: "-1s" }
+  expectations: { phase: REQUEST_HEADERS headers_response: {} }
+}
+`
+	tmpDir := t.TempDir()
+	manifestPath := filepath.Join(tmpDir, "test.textproto")
+	require.NoError(t, os.WriteFile(manifestPath, []byte(content), 0o644))
+
+	_, err := NewLoader().LoadFile(manifestPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `test case "test-case-1"`)
+	assert.Contains(t, err.Error(), "timeout: invalid duration")
+	assert.Contains(t, err.Error(), "request.body_chunk_size: invalid size")
+	assert.Contains(t, err.Error(), "cost.body_size: invalid size")
+	assert.Contains(t, err.Error(), "cost.duration: invalid duration")
+}
+
+func TestLoader_LoadFile_MetadataContext(t *testing.T) {
+	content := `
+name: "metadata"
+test_cases: {
+  name: "jwt-claims"
+  request: {
+    method: "GET"
+    path: "/"
+    metadata_context: {
+      key: "envoy.filters.http.jwt_authn"
+      value: {
+        fields: { key: "sub" value: { string_value: "alice" } }
+        fields: { key: "scopes" value: { list_value: { values: { string_value: "read" } } } }
+      }
+    }
+  }
+  expectations: { phase: REQUEST_HEADERS headers_response: {} }
+}
+test_cases: {
+  name: "unset-value"
+  request: {
+    method: "GET"
+    path: "/"
+    metadata_context: { key: "envoy.filters.http.jwt_authn" value: { fields: { key: "sub" value: {} } } }
+  }
+  expectations: { phase: REQUEST_HEADERS headers_response: {} }
+}
+`
+	tmpDir := t.TempDir()
+	manifestPath := filepath.Join(tmpDir, "test.textproto")
+	require.NoError(t, os.WriteFile(manifestPath, []byte(content), 0o644))
+
+	m, err := NewLoader().LoadFile(manifestPath)
+	require.NoError(t, err)
+	claims := m.TestCases[0].Request.MetadataContext["envoy.filters.http.jwt_authn"].GetFields()
+	assert.Equal(t, "alice", claims["sub"].GetStringValue())
+	assert.Equal(t, "read", claims["scopes"].GetListValue().GetValues()[0].GetStringValue())
+
+	err = ValidateManifest(m.TestManifest)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "request.metadata_context[envoy.filters.http.jwt_authn]: invalid struct")
+	assert.Len(t, strings.Split(err.Error(), "\n"), 1, "only the unset value is reported")
+}
+
+func TestLoader_LoadFile_Transformers(t *testing.T) {
+	path := writeManifest(t, t.TempDir(), "suite.textproto", `
+test_cases: {
+  name: "base"
+  abstract: true
+  request: { method: "GET" path: "/" }
+}
+`)
+
+	generate := TransformerFunc(func(m *extproctorv1.TestManifest, _ string) error {
+		m.TestCases = append(m.TestCases, &extproctorv1.TestCase{
+			Name:     "generated",
+			Extends:  "base",
+			UseMacro: []*extproctorv1.MacroInvocation{{Name: "expect_jwt_stripped"}},
+		})
+		return nil
+	})
+
+	m, err := NewLoader(WithTransformers(generate)).LoadFile(path)
+	require.NoError(t, err)
+	require.Len(t, m.TestCases, 1)
+	assert.Equal(t, "generated", m.TestCases[0].Name)
+	assert.Equal(t, "GET", m.TestCases[0].Request.Method)
+	assert.Len(t, m.TestCases[0].Expectations, 1)
+
+	failing := TransformerFunc(func(*extproctorv1.TestManifest, string) error {
+		return fmt.Errorf("boom")
+	})
+	_, err = NewLoader(WithTransformers(failing)).LoadFile(path)
+	assert.EqualError(t, err, "failed to transform manifest: boom")
+}
+
+func TestLoader_LoadDirectory_IgnoreFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "vendor"), 0o755))
+	writeManifest(t, dir, ".extproctorignore", "vendor/\n*.wip.textproto\n")
+	writeManifest(t, dir, "suite.textproto", `name: "suite"`)
+	writeManifest(t, dir, "draft.wip.textproto", `not a manifest`)
+	writeManifest(t, filepath.Join(dir, "vendor"), "fixture.textproto", `not a manifest`)
+
+	manifests, err := NewLoader().LoadPath(dir)
+	require.NoError(t, err)
+	require.Len(t, manifests, 1)
+	assert.Equal(t, "suite", manifests[0].Name)
+
+	// Explicit files are loaded even when ignored
+	_, err = NewLoader().LoadPath(filepath.Join(dir, "draft.wip.textproto"))
+	assert.Error(t, err)
+}
+
+func TestLoader_LoadPaths_GlobsAndDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "tests", "api"), 0o755))
+	writeManifest(t, filepath.Join(dir, "tests"), "auth.textproto", `name: "auth"`)
+	writeManifest(t, filepath.Join(dir, "tests", "api"), "auth-deny.textproto", `name: "auth-deny"`)
+	writeManifest(t, filepath.Join(dir, "tests", "api"), "users.textproto", `name: "users"`)
+	require.NoError(t, os.Symlink(filepath.Join(dir, "tests", "api"), filepath.Join(dir, "api-link")))
+
+	manifests, err := NewLoader().LoadPaths([]string{
+		filepath.Join(dir, "tests", "**", "auth*.textproto"),
+		filepath.Join(dir, "tests", "auth.textproto"),
+		filepath.Join(dir, "api-link"),
+	})
+	require.NoError(t, err)
+
+	var names []string
+	for _, m := range manifests {
+		names = append(names, m.Name)
+	}
+	assert.Equal(t, []string{"auth-deny", "auth", "users"}, names)
+
+	_, err = NewLoader().LoadPaths([]string{filepath.Join(dir, "*.txtpb")})
+	assert.ErrorContains(t, err, "no path matches")
+}
+
+func TestLoader_LoadDirectory_NoFollowSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	shared := t.TempDir()
+	writeManifest(t, dir, "suite.textproto", `name: "suite"`)
+	writeManifest(t, shared, "shared.textproto", `name: "shared"`)
+	require.NoError(t, os.Symlink(shared, filepath.Join(dir, "shared")))
+
+	manifests, err := NewLoader().LoadPath(dir)
+	require.NoError(t, err)
+	assert.Len(t, manifests, 2)
+
+	manifests, err = NewLoader(WithFollowSymlinks(false)).LoadPath(dir)
+	require.NoError(t, err)
+	require.Len(t, manifests, 1)
+	assert.Equal(t, "suite", manifests[0].Name)
+}
+
+func TestLoader_LoadFile_RequestFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "base"), 0o755))
+	base := `
+test_cases: {
+  name: "upload"
+  abstract: true
+  request: {
+    method: "POST"
+    path: "/upload"
+    multipart: { parts: { name: "doc" file: "fixtures/doc.pdf" } }
+  }
+}
+`
+	content := `
+imports: "base/base.textproto"
+test_cases: {
+  name: "inherited"
+  extends: "upload"
+}
+test_cases: {
+  name: "local"
+  request: {
+    method: "POST"
+    path: "/upload"
+    multipart: {
+      parts: { name: "field" value: "inline" }
+      parts: { name: "doc" file: "doc.pdf" }
+      parts: { name: "abs" file: "/data/abs.pdf" }
+    }
+    grpc: { messages: { file: "messages/get.bin" } }
+  }
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "base", "base.textproto"), []byte(base), 0o644))
+	manifestPath := filepath.Join(tmpDir, "upload.textproto")
+	require.NoError(t, os.WriteFile(manifestPath, []byte(content), 0o644))
+
+	manifest, err := NewLoader().LoadFile(manifestPath)
+	require.NoError(t, err)
+	require.Len(t, manifest.TestCases, 2)
+
+	assert.Equal(t, filepath.Join(tmpDir, "base", "fixtures", "doc.pdf"), manifest.TestCases[0].Request.Multipart.Parts[0].GetFile())
+
+	parts := manifest.TestCases[1].Request.Multipart.Parts
+	assert.Equal(t, []byte("inline"), parts[0].GetValue())
+	assert.Equal(t, filepath.Join(tmpDir, "doc.pdf"), parts[1].GetFile())
+	assert.Equal(t, "/data/abs.pdf", parts[2].GetFile())
+	assert.Equal(t, filepath.Join(tmpDir, "messages", "get.bin"), manifest.TestCases[1].Request.Grpc.Messages[0].GetFile())
+}
@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package manifest
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// macro is a named expectation pattern expanded by use_macro directives.
+type macro struct {
+	// phase is the phase the expectation applies to by default.
+	phase extproctorv1.ProcessingPhase
+
+	// params holds the accepted parameters with their default values.
+	params map[string]string
+
+	// expand builds the expected headers from the parameters.
+	expand func(params map[string]string) *extproctorv1.HeadersExpectation
+}
+
+// macros is the library of the expectation macros. Headers whose parameter
+// is empty are not asserted.
+var macros = map[string]macro{
+	"expect_cors_headers": {
+		phase: extproctorv1.ProcessingPhase_RESPONSE_HEADERS,
+		params: map[string]string{
+			"origin":      "*",
+			"methods":     "",
+			"headers":     "",
+			"credentials": "",
+			"max_age":     "",
+		},
+		expand: func(p map[string]string) *extproctorv1.HeadersExpectation {
+			return &extproctorv1.HeadersExpectation{
+				SetHeaders: nonEmpty(map[string]string{
+					"access-control-allow-origin":      p["origin"],
+					"access-control-allow-methods":     p["methods"],
+					"access-control-allow-headers":     p["headers"],
+					"access-control-allow-credentials": p["credentials"],
+					"access-control-max-age":           p["max_age"],
+				}),
+			}
+		},
+	},
+	"expect_security_headers": {
+		phase: extproctorv1.ProcessingPhase_RESPONSE_HEADERS,
+		params: map[string]string{
+			"hsts":                 "max-age=31536000; includeSubDomains",
+			"content_type_options": "nosniff",
+			"frame_options":        "DENY",
+			"referrer_policy":      "no-referrer",
+			"csp":                  "",
+		},
+		expand: func(p map[string]string) *extproctorv1.HeadersExpectation {
+			return &extproctorv1.HeadersExpectation{
+				SetHeaders: nonEmpty(map[string]string{
+					"strict-transport-security": p["hsts"],
+					"x-content-type-options":    p["content_type_options"],
+					"x-frame-options":           p["frame_options"],
+					"referrer-policy":           p["referrer_policy"],
+					"content-security-policy":   p["csp"],
+				}),
+			}
+		},
+	},
+	"expect_jwt_stripped": {
+		phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+		params: map[string]string{
+			"header":          "authorization",
+			"identity_header": "",
+			"identity":        "",
+		},
+		expand: func(p map[string]string) *extproctorv1.HeadersExpectation {
+			exp := &extproctorv1.HeadersExpectation{
+				RemoveHeaders: []string{p["header"]},
+			}
+			if p["identity_header"] != "" {
+				exp.SetHeaders = map[string]string{p["identity_header"]: p["identity"]}
+			}
+			return exp
+		},
+	},
+}
+
+// macroNames returns the names of the available expectation macros.
+func macroNames() []string {
+	return slices.Sorted(maps.Keys(macros))
+}
+
+// nonEmpty returns the headers with a non-empty value.
+func nonEmpty(headers map[string]string) map[string]string {
+	maps.DeleteFunc(headers, func(_, v string) bool { return v == "" })
+	return headers
+}
+
+// expandMacros expands the macros used by a test case into its expectations.
+// The expected headers are merged into the unconditional headers expectation
+// of the same phase, if any, without overriding the explicit ones.
+func expandMacros(tc *extproctorv1.TestCase) error {
+	for i, inv := range tc.UseMacro {
+		m, ok := macros[inv.Name]
+		if !ok {
+			return fmt.Errorf("use_macro[%d]: unknown macro %q (available: %s)", i, inv.Name, strings.Join(macroNames(), ", "))
+		}
+
+		params := maps.Clone(m.params)
+		for _, k := range slices.Sorted(maps.Keys(inv.Params)) {
+			if _, ok := params[k]; !ok {
+				return fmt.Errorf("use_macro[%d]: unknown parameter %q of macro %q", i, k, inv.Name)
+			}
+			params[k] = inv.Params[k]
+		}
+
+		phase := m.phase
+		switch inv.Phase {
+		case extproctorv1.ProcessingPhase_PROCESSING_PHASE_UNSPECIFIED:
+		case extproctorv1.ProcessingPhase_REQUEST_HEADERS, extproctorv1.ProcessingPhase_RESPONSE_HEADERS:
+			phase = inv.Phase
+		default:
+			return fmt.Errorf("use_macro[%d]: macro %q only applies to header phases, not %s", i, inv.Name, inv.Phase)
+		}
+
+		mergeMacroExpectation(tc, phase, m.expand(params))
+	}
+	tc.UseMacro = nil
+
+	return nil
+}
+
+// mergeMacroExpectation merges the headers expected by a macro into the test
+// case expectations.
+func mergeMacroExpectation(tc *extproctorv1.TestCase, phase extproctorv1.ProcessingPhase, headers *extproctorv1.HeadersExpectation) {
+	for _, exp := range tc.Expectations {
+		existing := exp.GetHeadersResponse()
+		if exp.Phase != phase || existing == nil || exp.When != nil {
+			continue
+		}
+
+		for k, v := range headers.SetHeaders {
+			if _, ok := existing.SetHeaders[k]; ok {
+				continue
+			}
+			if existing.SetHeaders == nil {
+				existing.SetHeaders = map[string]string{}
+			}
+			existing.SetHeaders[k] = v
+		}
+		for _, h := range headers.RemoveHeaders {
+			if !slices.Contains(existing.RemoveHeaders, h) {
+				existing.RemoveHeaders = append(existing.RemoveHeaders, h)
+			}
+		}
+		return
+	}
+
+	tc.Expectations = append(tc.Expectations, &extproctorv1.ExtProcExpectation{
+		Phase: phase,
+		Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+			HeadersResponse: headers,
+		},
+	})
+}
@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package manifest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func TestExpandMacros(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name: "macros",
+		UseMacro: []*extproctorv1.MacroInvocation{
+			{Name: "expect_cors_headers", Params: map[string]string{"origin": "https://app.example.com", "methods": "GET"}},
+			{Name: "expect_jwt_stripped", Params: map[string]string{"identity_header": "x-user", "identity": "alice"}},
+		},
+	}
+
+	require.NoError(t, expandMacros(tc))
+	assert.Empty(t, tc.UseMacro)
+	require.Len(t, tc.Expectations, 2)
+
+	cors := tc.Expectations[0]
+	assert.Equal(t, extproctorv1.ProcessingPhase_RESPONSE_HEADERS, cors.Phase)
+	assert.Equal(t, map[string]string{
+		"access-control-allow-origin":  "https://app.example.com",
+		"access-control-allow-methods": "GET",
+	}, cors.GetHeadersResponse().SetHeaders)
+
+	jwt := tc.Expectations[1]
+	assert.Equal(t, extproctorv1.ProcessingPhase_REQUEST_HEADERS, jwt.Phase)
+	assert.Equal(t, []string{"authorization"}, jwt.GetHeadersResponse().RemoveHeaders)
+	assert.Equal(t, map[string]string{"x-user": "alice"}, jwt.GetHeadersResponse().SetHeaders)
+}
+
+func TestExpandMacros_MergesIntoExistingExpectation(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name: "merge",
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			{
+				Phase: extproctorv1.ProcessingPhase_RESPONSE_HEADERS,
+				Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+					HeadersResponse: &extproctorv1.HeadersExpectation{
+						SetHeaders: map[string]string{"x-frame-options": "SAMEORIGIN"},
+					},
+				},
+			},
+		},
+		UseMacro: []*extproctorv1.MacroInvocation{
+			{Name: "expect_security_headers", Params: map[string]string{"hsts": ""}},
+		},
+	}
+
+	require.NoError(t, expandMacros(tc))
+	require.Len(t, tc.Expectations, 1)
+	assert.Equal(t, map[string]string{
+		"x-frame-options":        "SAMEORIGIN",
+		"x-content-type-options": "nosniff",
+		"referrer-policy":        "no-referrer",
+	}, tc.Expectations[0].GetHeadersResponse().SetHeaders)
+}
+
+func TestExpandMacros_PhaseOverride(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name: "phase",
+		UseMacro: []*extproctorv1.MacroInvocation{
+			{Name: "expect_cors_headers", Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS},
+		},
+	}
+
+	require.NoError(t, expandMacros(tc))
+	require.Len(t, tc.Expectations, 1)
+	assert.Equal(t, extproctorv1.ProcessingPhase_REQUEST_HEADERS, tc.Expectations[0].Phase)
+	assert.Equal(t, "*", tc.Expectations[0].GetHeadersResponse().SetHeaders["access-control-allow-origin"])
+}
+
+func TestExpandMacros_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		macro   *extproctorv1.MacroInvocation
+		wantErr string
+	}{
+		{
+			name:    "unknown macro",
+			macro:   &extproctorv1.MacroInvocation{Name: "expect_unknown"},
+			wantErr: `use_macro[0]: unknown macro "expect_unknown" (available: expect_cors_headers, expect_jwt_stripped, expect_security_headers)`,
+		},
+		{
+			name:    "unknown parameter",
+			macro:   &extproctorv1.MacroInvocation{Name: "expect_cors_headers", Params: map[string]string{"origins": "*"}},
+			wantErr: `use_macro[0]: unknown parameter "origins" of macro "expect_cors_headers"`,
+		},
+		{
+			name:    "body phase",
+			macro:   &extproctorv1.MacroInvocation{Name: "expect_cors_headers", Phase: extproctorv1.ProcessingPhase_RESPONSE_BODY},
+			wantErr: `use_macro[0]: macro "expect_cors_headers" only applies to header phases, not RESPONSE_BODY`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := &extproctorv1.TestCase{Name: "test", UseMacro: []*extproctorv1.MacroInvocation{tt.macro}}
+			assert.EqualError(t, expandMacros(tc), tt.wantErr)
+		})
+	}
+}
+
+func TestLoader_LoadFile_Macros(t *testing.T) {
+	path := writeManifest(t, t.TempDir(), "macros.textproto", `
+test_cases: {
+  name: "base"
+  abstract: true
+  request: { method: "GET" path: "/" }
+  use_macro: { name: "expect_security_headers" }
+}
+test_cases: {
+  name: "secured"
+  extends: "base"
+}
+`)
+
+	m, err := NewLoader().LoadFile(path)
+	require.NoError(t, err)
+	require.Len(t, m.TestCases, 1)
+	require.Len(t, m.TestCases[0].Expectations, 1)
+	assert.Equal(t, "nosniff", m.TestCases[0].Expectations[0].GetHeadersResponse().SetHeaders["x-content-type-options"])
+	assert.NoError(t, ValidateTestCase(m.TestCases[0]))
+}
@@ -0,0 +1,373 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// maxParseErrors is the maximum number of parse errors reported per file.
+const maxParseErrors = 10
+
+// parseErrorPattern extracts the position and message of prototext errors,
+// e.g. "proto: syntax error (line 2:8): invalid character".
+var parseErrorPattern = regexp.MustCompile(`\(line\D*(\d+):(\d+)\):(.*)$`)
+
+// ParseError is a prototext parse error located in a manifest file.
+type ParseError struct {
+	// Line and Column are 1-based, 0 when the position is unknown.
+	Line   int
+	Column int
+
+	// Message describes the error.
+	Message string
+
+	// Token is the offending token, reported unless the message contains it.
+	Token string
+
+	// Excerpt is the source line with a caret under the offending column.
+	Excerpt string
+}
+
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+	if e.Line > 0 {
+		fmt.Fprintf(&sb, "line %d:%d: ", e.Line, e.Column)
+	}
+	sb.WriteString(e.Message)
+	if e.Token != "" && !strings.Contains(e.Message, e.Token) {
+		fmt.Fprintf(&sb, " (at %q)", e.Token)
+	}
+	if e.Excerpt != "" {
+		sb.WriteString("\n")
+		sb.WriteString(e.Excerpt)
+	}
+	return sb.String()
+}
+
+// parseManifest unmarshals a manifest. On failure, parsing resumes at each
+// top-level field following the failing one, so that all the errors of a
+// hand-authored file are reported at once.
+func parseManifest(data []byte) (*extproctorv1.TestManifest, error) {
+	manifest := &extproctorv1.TestManifest{}
+	err := prototext.Unmarshal(data, manifest)
+	if err == nil {
+		return manifest, nil
+	}
+
+	src := string(data)
+	first := newParseError(src, err)
+	errs := []error{first}
+	if first.Line == 0 {
+		return nil, first
+	}
+
+	failed := offsetOf(src, first.Line, first.Column)
+	for _, seg := range topLevelFields(src) {
+		if seg[0] <= failed {
+			continue
+		}
+		if len(errs) == maxParseErrors {
+			break
+		}
+
+		// Blank the rest of the file so that positions stay accurate
+		if err := prototext.Unmarshal([]byte(isolate(src, seg[0], seg[1])), &extproctorv1.TestManifest{}); err != nil {
+			errs = append(errs, newParseError(src, err))
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// parseLenient unmarshals a manifest, discarding the fields unknown to this
+// extproctor, such as the assertions of a newer version. It returns the
+// located names of the discarded fields.
+func parseLenient(data []byte) (*extproctorv1.TestManifest, []string, error) {
+	manifest := &extproctorv1.TestManifest{}
+	if err := (prototext.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, manifest); err != nil {
+		// Report all the errors of the file
+		_, err := parseManifest(data)
+		return nil, nil, err
+	}
+
+	// Strict parsing stops at the first unknown field: blank it and parse
+	// again to find the next one.
+	src := string(data)
+	var discarded []string
+	for len(discarded) < maxParseErrors {
+		err := prototext.Unmarshal([]byte(src), &extproctorv1.TestManifest{})
+		if err == nil {
+			break
+		}
+		pe := newParseError(src, err)
+		name, ok := strings.CutPrefix(pe.Message, "unknown field: ")
+		if !ok || pe.Line == 0 {
+			break
+		}
+		discarded = append(discarded, fmt.Sprintf("line %d:%d: unknown field %s discarded", pe.Line, pe.Column, name))
+
+		start := offsetOf(src, pe.Line, pe.Column)
+		src = blank(src, start, fieldEnd(src, start))
+	}
+
+	return manifest, discarded, nil
+}
+
+// fieldEnd returns the byte offset following the field starting at the given
+// offset: its name, and its scalar, list or message value.
+func fieldEnd(src string, start int) int {
+	i := start
+	if src[i] == '[' {
+		// Extension or Any type URL
+		if end := strings.IndexByte(src[i:], ']'); end >= 0 {
+			i += end + 1
+		}
+	} else {
+		i += len(tokenAt(src, i))
+	}
+
+	i = skipSpace(src, i)
+	if i < len(src) && src[i] == ':' {
+		i = skipSpace(src, i+1)
+	}
+	if i >= len(src) {
+		return len(src)
+	}
+
+	depths := nestingDepths(src)
+	switch src[i] {
+	case '{', '<':
+		for j := i + 1; j < len(src); j++ {
+			if (src[j] == '}' || src[j] == '>') && depths[j] == depths[i] {
+				return j + 1
+			}
+		}
+		return len(src)
+	case '[':
+		for j := i + 1; j < len(src); j++ {
+			if src[j] == ']' && depths[j] == depths[i] {
+				return j + 1
+			}
+		}
+		return len(src)
+	}
+
+	// Adjacent strings are concatenated
+	for {
+		i += len(tokenAt(src, i))
+		next := skipSpace(src, i)
+		if next >= len(src) || (src[next] != '"' && src[next] != '\'') {
+			return i
+		}
+		i = next
+	}
+}
+
+// skipSpace returns the offset of the first byte from i that is neither
+// whitespace nor part of a comment.
+func skipSpace(src string, i int) int {
+	for i < len(src) {
+		switch src[i] {
+		case ' ', '\t', '\r', '\n':
+			i++
+		case '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// newParseError builds a located parse error from a prototext error.
+func newParseError(src string, err error) *ParseError {
+	msg := strings.TrimSpace(err.Error())
+	m := parseErrorPattern.FindStringSubmatch(msg)
+	if m == nil {
+		// Errors without position, such as an unexpected EOF, are reported at
+		// the end of the file.
+		msg = strings.TrimSpace(strings.TrimPrefix(msg, "proto:"))
+		if !strings.Contains(msg, "EOF") {
+			return &ParseError{Message: msg}
+		}
+		line := strings.Count(src, "\n") + 1
+		column := utf8.RuneCountInString(src[strings.LastIndexByte(src, '\n')+1:]) + 1
+		return &ParseError{
+			Line:    line,
+			Column:  column,
+			Message: msg,
+			Excerpt: excerpt(src, line, column),
+		}
+	}
+
+	line, _ := strconv.Atoi(m[1])
+	column, _ := strconv.Atoi(m[2])
+	return &ParseError{
+		Line:    line,
+		Column:  column,
+		Message: strings.TrimSpace(m[3]),
+		Token:   tokenAt(src, offsetOf(src, line, column)),
+		Excerpt: excerpt(src, line, column),
+	}
+}
+
+// offsetOf returns the byte offset of a 1-based line and rune column.
+func offsetOf(src string, line, column int) int {
+	offset := 0
+	for l := 1; l < line; l++ {
+		i := strings.IndexByte(src[offset:], '\n')
+		if i < 0 {
+			return len(src)
+		}
+		offset += i + 1
+	}
+	for c := 1; c < column && offset < len(src) && src[offset] != '\n'; c++ {
+		_, size := utf8.DecodeRuneInString(src[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// tokenAt returns the token starting at the given offset: an identifier or
+// number, a string up to the end of the line, or a single character.
+func tokenAt(src string, offset int) string {
+	if offset >= len(src) {
+		return ""
+	}
+
+	rest := src[offset:]
+	if i := strings.IndexByte(rest, '\n'); i >= 0 {
+		rest = rest[:i]
+	}
+	if rest == "" {
+		return ""
+	}
+
+	switch c := rest[0]; {
+	case c == '"' || c == '\'':
+		for i := 1; i < len(rest); i++ {
+			if rest[i] == '\\' {
+				i++
+				continue
+			}
+			if rest[i] == c {
+				return rest[:i+1]
+			}
+		}
+		return rest
+	case isIdentChar(c):
+		end := 1
+		for end < len(rest) && isIdentChar(rest[end]) {
+			end++
+		}
+		return rest[:end]
+	default:
+		_, size := utf8.DecodeRuneInString(rest)
+		return rest[:size]
+	}
+}
+
+// isIdentChar reports whether c may be part of an identifier or a number.
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || c == '+' ||
+		c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// excerpt renders the source line with a caret under the given column.
+func excerpt(src string, line, column int) string {
+	start := offsetOf(src, line, 1)
+	text := src[start:]
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	text = strings.ReplaceAll(text, "\t", " ")
+
+	gutter := strconv.Itoa(line)
+	return fmt.Sprintf("%s | %s\n%s | %s^",
+		gutter, text,
+		strings.Repeat(" ", len(gutter)), strings.Repeat(" ", max(column-1, 0)))
+}
+
+// topLevelFields returns the byte ranges of the top-level fields of the
+// source. A field starts with an identifier at the beginning of a line, out
+// of any message, list, string or comment, unless the previous line ends
+// with a colon awaiting the value.
+func topLevelFields(src string) [][2]int {
+	depths := nestingDepths(src)
+
+	var starts []int
+	brackets := 0
+	lineStart := true
+	awaitingValue := false
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if depths[i] != 0 {
+			if depths[i] > 0 {
+				awaitingValue = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '\n':
+			lineStart = true
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			continue
+		case c == '[':
+			brackets++
+		case c == ']':
+			brackets--
+		case lineStart && brackets == 0 && !awaitingValue && isIdentChar(c):
+			starts = append(starts, i)
+		}
+		lineStart = false
+		awaitingValue = c == ':'
+	}
+
+	segments := make([][2]int, len(starts))
+	for i, start := range starts {
+		end := len(src)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		segments[i] = [2]int{start, end}
+	}
+	return segments
+}
+
+// blank returns the source with every byte in [start, end) blanked, newlines
+// excepted.
+func blank(src string, start, end int) string {
+	b := []byte(src)
+	for i := start; i < end; i++ {
+		if b[i] != '\n' {
+			b[i] = ' '
+		}
+	}
+	return string(b)
+}
+
+// isolate returns the source with every byte outside [start, end) blanked,
+// newlines excepted.
+func isolate(src string, start, end int) string {
+	b := []byte(src)
+	for i := range b {
+		if (i < start || i >= end) && b[i] != '\n' {
+			b[i] = ' '
+		}
+	}
+	return string(b)
+}
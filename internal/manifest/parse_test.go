@@ -0,0 +1,208 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package manifest
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseErrors returns the parse errors joined in err.
+func parseErrors(t *testing.T, err error) []*ParseError {
+	t.Helper()
+
+	var errs []*ParseError
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			var pe *ParseError
+			require.True(t, errors.As(e, &pe))
+			errs = append(errs, pe)
+		}
+		return errs
+	}
+
+	var pe *ParseError
+	require.True(t, errors.As(err, &pe))
+	return []*ParseError{pe}
+}
+
+func TestParseManifest_Valid(t *testing.T) {
+	m, err := parseManifest([]byte(`name: "suite" test_cases: { name: "a" }`))
+	require.NoError(t, err)
+	assert.Equal(t, "suite", m.Name)
+}
+
+func TestParseManifest_MultipleErrors(t *testing.T) {
+	src := `name: "suite"
+test_cases: {
+  name: "a"
+  nme: "typo"
+}
+test_cases: {
+  name: "b"
+  request: { method: GET }
+}
+imports: [
+  "base.textproto"
+]
+test_cases: {
+  name: "c"
+  priority: "high"
+}
+test_cases: {
+  name: "d"
+}
+`
+
+	_, err := parseManifest([]byte(src))
+	require.Error(t, err)
+
+	errs := parseErrors(t, err)
+	require.Len(t, errs, 3)
+
+	assert.Equal(t, 4, errs[0].Line)
+	assert.Equal(t, 3, errs[0].Column)
+	assert.Equal(t, "unknown field: nme", errs[0].Message)
+	assert.Equal(t, "nme", errs[0].Token)
+	assert.Equal(t, "4 |   nme: \"typo\"\n  |   ^", errs[0].Excerpt)
+
+	assert.Equal(t, 8, errs[1].Line)
+	assert.Equal(t, 22, errs[1].Column)
+	assert.Equal(t, "GET", errs[1].Token)
+
+	assert.Equal(t, 15, errs[2].Line)
+	assert.Equal(t, 13, errs[2].Column)
+	assert.Equal(t, `"high"`, errs[2].Token)
+	assert.Equal(t, "15 |   priority: \"high\"\n   |             ^", errs[2].Excerpt)
+
+	assert.Equal(t, "line 4:3: unknown field: nme\n4 |   nme: \"typo\"\n  |   ^", errs[0].Error())
+}
+
+func TestParseManifest_SyntaxError(t *testing.T) {
+	_, err := parseManifest([]byte("test_cases: {\n  name: \"unterminated\n}\n"))
+	require.Error(t, err)
+
+	errs := parseErrors(t, err)
+	require.Len(t, errs, 1)
+	assert.Equal(t, 2, errs[0].Line)
+	assert.Equal(t, 9, errs[0].Column)
+	assert.Equal(t, `"unterminated`, errs[0].Token)
+	assert.Contains(t, errs[0].Error(), `(at "\"unterminated")`)
+}
+
+func TestParseManifest_UnexpectedEOF(t *testing.T) {
+	_, err := parseManifest([]byte("test_cases: {\n  name: \"a\""))
+	require.Error(t, err)
+
+	errs := parseErrors(t, err)
+	require.Len(t, errs, 1)
+	assert.Equal(t, 2, errs[0].Line)
+	assert.Equal(t, 12, errs[0].Column)
+	assert.Equal(t, "unexpected EOF", errs[0].Message)
+}
+
+func TestParseManifest_MaxErrors(t *testing.T) {
+	var src string
+	for range maxParseErrors + 5 {
+		src += "unknown: 1\n"
+	}
+
+	_, err := parseManifest([]byte(src))
+	require.Error(t, err)
+	assert.Len(t, parseErrors(t, err), maxParseErrors)
+}
+
+func TestLoader_LoadFile_ParseErrorLocation(t *testing.T) {
+	path := writeManifest(t, t.TempDir(), "bad.textproto", "name: \"suite\"\nowner: 42\n")
+
+	_, err := NewLoader().LoadFile(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse prototext: line 2:8: ")
+	assert.Contains(t, err.Error(), "2 | owner: 42\n  |        ^")
+}
+
+func TestParseLenient(t *testing.T) {
+	src := `name: "suite"
+new_option: true
+test_cases: {
+  name: "a"
+  request: { method: "GET" path: "/" }
+  expectations: {
+    phase: REQUEST_HEADERS
+    headers_response: { set_headers: { key: "x-a" value: "1" } }
+    future_assertion: {
+      nested: { value: "}" }
+    }
+  }
+  future_list: [1, 2]
+  future_string: "a" # comment
+    'b'
+  timeout: "1s"
+}
+`
+
+	m, discarded, err := parseLenient([]byte(src))
+	require.NoError(t, err)
+	assert.Equal(t, "suite", m.Name)
+	require.Len(t, m.TestCases, 1)
+	assert.Equal(t, "1s", m.TestCases[0].Timeout)
+	assert.Len(t, m.TestCases[0].Expectations, 1)
+
+	assert.Equal(t, []string{
+		"line 2:1: unknown field new_option discarded",
+		"line 9:5: unknown field future_assertion discarded",
+		"line 13:3: unknown field future_list discarded",
+		"line 14:3: unknown field future_string discarded",
+	}, discarded)
+}
+
+func TestParseLenient_SyntaxError(t *testing.T) {
+	_, _, err := parseLenient([]byte("test_cases: {\n  name: \"a\"\n  priority: \"high\"\n}\n"))
+	require.Error(t, err)
+
+	errs := parseErrors(t, err)
+	require.Len(t, errs, 1)
+	assert.Equal(t, 3, errs[0].Line)
+}
+
+func TestLoader_LoadFile_Lenient(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "base.textproto", "test_cases: { name: \"base\" abstract: true future: 1 }\n")
+	path := writeManifest(t, dir, "suite.textproto", `imports: ["base.textproto"]
+test_cases: { name: "a" extends: "base" future: 2 }
+`)
+
+	_, err := NewLoader().LoadFile(path)
+	require.Error(t, err)
+
+	m, err := NewLoader(WithLenient(true)).LoadFile(path)
+	require.NoError(t, err)
+	require.Len(t, m.TestCases, 1)
+	assert.Equal(t, []string{
+		"line 2:41: unknown field future discarded",
+		"imported " + filepath.Join(dir, "base.textproto") + ": line 1:43: unknown field future discarded",
+	}, m.Warnings)
+}
+
+func FuzzParseManifest(f *testing.F) {
+	f.Add([]byte(`name: "suite" test_cases: { name: "a" request: { method: "GET" path: "/" } }`))
+	f.Add([]byte("test_cases: {\n  nme: \"typo\"\n}\ntest_cases: {\n  priority: \"high\"\n}\n"))
+	f.Add([]byte("imports: [\n  \"a\",\n  \"b\"\n]\nname:\n  \"x\"\n"))
+	f.Add([]byte("test_cases: { name: \"\xff\xfe\" }\n# comment {\n\"unterminated"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		m, err := parseManifest(data)
+		if err == nil {
+			assert.NotNil(t, m)
+			return
+		}
+		for _, pe := range parseErrors(t, err) {
+			assert.NotEmpty(t, pe.Error())
+		}
+	})
+}
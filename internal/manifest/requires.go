@@ -0,0 +1,211 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package manifest
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// features are the named manifest features supported by this binary, which
+// manifests can require. A feature is added here with the manifest fields it
+// names.
+var features = []string{
+	"attributes",
+	"body_chunk_expectations",
+	"body_chunks",
+	"body_encoding",
+	"channel_overrides",
+	"conditions",
+	"continue_after_immediate",
+	"cost",
+	"downstream",
+	"exact_headers",
+	"exact_response",
+	"exact_trailers",
+	"expectation_groups",
+	"expected_failure",
+	"extends",
+	"features",
+	"forwarded_for",
+	"golden_files",
+	"golden_placeholders",
+	"graphql",
+	"grpc",
+	"grpc_metadata",
+	"header_entries",
+	"header_value_comparison",
+	"ignore_paths",
+	"macros",
+	"metadata_context",
+	"multipart",
+	"observability_mode",
+	"ordered_set_headers",
+	"parallel",
+	"passthrough",
+	"phase_sequence",
+	"phase_wildcards",
+	"priority",
+	"random_inputs",
+	"raw_headers",
+	"redaction",
+	"response_phases",
+	"set_header_options",
+	"size_literals",
+	"stream_expectations",
+	"trailer_entries",
+	"uid",
+	"upstream_response",
+	"websocket",
+}
+
+// Features returns the named manifest features supported by this binary, in
+// alphabetical order.
+func Features() []string {
+	return slices.Clone(features)
+}
+
+// UnsupportedError reports the requirements of a manifest not met by the
+// running binary.
+type UnsupportedError struct {
+	// Unmet describes each unmet requirement.
+	Unmet []string
+}
+
+func (e *UnsupportedError) Error() string {
+	return "manifest requires " + strings.Join(e.Unmet, " and ")
+}
+
+// checkRequirements returns an UnsupportedError when the given version of
+// extproctor does not meet the requirements of a manifest. Development builds,
+// whose version is not numeric, meet any version constraint.
+func checkRequirements(req *extproctorv1.Requirements, version string) error {
+	if req == nil {
+		return nil
+	}
+
+	var unmet []string
+	if c := req.GetExtproctorVersion(); c != "" {
+		constraint, err := parseConstraint(c)
+		if err != nil {
+			return err
+		}
+		if v, ok := parseVersion(version); ok && !constraint.matches(v) {
+			unmet = append(unmet, fmt.Sprintf("extproctor %s (running %s)", c, version))
+		}
+	}
+
+	var missing []string
+	for _, f := range req.GetFeatures() {
+		if !slices.Contains(features, f) {
+			missing = append(missing, strconv.Quote(f))
+		}
+	}
+	if len(missing) > 0 {
+		unmet = append(unmet, "unsupported features "+strings.Join(missing, ", "))
+	}
+
+	if len(unmet) > 0 {
+		return &UnsupportedError{Unmet: unmet}
+	}
+	return nil
+}
+
+// constraint is a conjunction of version comparisons.
+type constraint []comparison
+
+type comparison struct {
+	op      string
+	version []int
+}
+
+// parseConstraint parses comma-separated comparisons such as ">=2025.12, <2026".
+func parseConstraint(s string) (constraint, error) {
+	var c constraint
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		op := ">="
+		for _, candidate := range []string{">=", "<=", "==", ">", "<", "="} {
+			if strings.HasPrefix(part, candidate) {
+				op = candidate
+				part = strings.TrimSpace(part[len(candidate):])
+				break
+			}
+		}
+
+		v, ok := parseVersion(part)
+		if !ok {
+			return nil, fmt.Errorf("invalid extproctor_version %q: expected comparisons such as \">=2025.12\"", s)
+		}
+		c = append(c, comparison{op: op, version: v})
+	}
+	return c, nil
+}
+
+// matches reports whether a version satisfies all the comparisons.
+func (c constraint) matches(v []int) bool {
+	for _, comp := range c {
+		n := compareVersions(v, comp.version)
+		var ok bool
+		switch comp.op {
+		case ">=":
+			ok = n >= 0
+		case ">":
+			ok = n > 0
+		case "<=":
+			ok = n <= 0
+		case "<":
+			ok = n < 0
+		default:
+			ok = n == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// parseVersion parses the numeric components of a version such as
+// "v2025.12-2" ([2025 12 2]).
+func parseVersion(s string) ([]int, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if s == "" {
+		return nil, false
+	}
+
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == '.' || r == '-' })
+	v := make([]int, 0, len(parts))
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		v = append(v, n)
+	}
+	return v, true
+}
+
+// compareVersions compares two versions component by component, missing
+// components counting as zero.
+func compareVersions(a, b []int) int {
+	for i := range max(len(a), len(b)) {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if n := cmp.Compare(x, y); n != 0 {
+			return n
+		}
+	}
+	return 0
+}
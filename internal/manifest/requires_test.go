@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func TestCheckRequirements_Version(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		met        bool
+	}{
+		{">=2025.12", "v2025.12-2", true},
+		{"2025.12", "v2025.12-2", true},
+		{">=2026.1", "v2025.12-2", false},
+		{">2025.12", "v2025.12", false},
+		{">2025.12", "v2025.12-1", true},
+		{">=2025.6, <2026", "v2025.12-2", true},
+		{">=2025.6, <2025.12", "v2025.12-2", false},
+		{"=2025.12", "v2025.12.0", true},
+		{"<=2025.11", "v2025.12", false},
+		{">=0.5", "v0.5.1", true},
+		// Development builds meet any version constraint
+		{">=2099", "dev", true},
+		{">=2099", "2dcdfc7-dirty", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint+" "+tt.version, func(t *testing.T) {
+			err := checkRequirements(&extproctorv1.Requirements{ExtproctorVersion: tt.constraint}, tt.version)
+			if tt.met {
+				assert.NoError(t, err)
+			} else {
+				var unsupported *UnsupportedError
+				assert.ErrorAs(t, err, &unsupported)
+			}
+		})
+	}
+}
+
+func TestCheckRequirements_InvalidConstraint(t *testing.T) {
+	err := checkRequirements(&extproctorv1.Requirements{ExtproctorVersion: ">=latest"}, "v2025.12")
+	assert.ErrorContains(t, err, `invalid extproctor_version ">=latest"`)
+}
+
+func TestCheckRequirements_Features(t *testing.T) {
+	assert.NoError(t, checkRequirements(&extproctorv1.Requirements{Features: []string{"response_phases", "extends"}}, "v2025.12"))
+
+	err := checkRequirements(&extproctorv1.Requirements{
+		ExtproctorVersion: ">=2026",
+		Features:          []string{"response_phases", "cel", "wasm"},
+	}, "v2025.12")
+	assert.EqualError(t, err, `manifest requires extproctor >=2026 (running v2025.12) and unsupported features "cel", "wasm"`)
+}
+
+func TestFeatures_Sorted(t *testing.T) {
+	assert.True(t, slices.IsSorted(Features()))
+}
+
+const unsupportedManifest = `
+requires: { features: ["cel"] }
+test_cases: {
+  name: "base"
+  abstract: true
+}
+test_cases: {
+  name: "test-1"
+  request: { method: "GET" path: "/" }
+}
+`
+
+func TestLoader_Unsupported(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.textproto")
+	require.NoError(t, os.WriteFile(path, []byte(unsupportedManifest), 0o644))
+
+	_, err := NewLoader().LoadFile(path)
+	assert.EqualError(t, err, `manifest requires unsupported features "cel"`)
+
+	m, err := NewLoader(WithSkipUnsupported(true)).LoadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `manifest requires unsupported features "cel"`, m.SkipReason)
+	assert.Equal(t, "test.textproto", m.Name)
+	require.Len(t, m.TestCases, 1)
+	assert.Equal(t, "test-1", m.TestCases[0].Name)
+}
+
+func TestLoader_UnsupportedUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.textproto")
+	require.NoError(t, os.WriteFile(path, []byte(`
+requires: { extproctor_version: ">=2099" }
+test_cases: {
+  name: "test-1"
+  future_field: "value"
+}
+`), 0o644))
+
+	loader := NewLoader(WithVersion("v2025.12"))
+	_, err := loader.LoadFile(path)
+	assert.EqualError(t, err, "manifest requires extproctor >=2099 (running v2025.12)")
+
+	m, err := NewLoader(WithVersion("v2025.12"), WithSkipUnsupported(true)).LoadFile(path)
+	require.NoError(t, err)
+	assert.NotEmpty(t, m.SkipReason)
+	assert.Len(t, m.TestCases, 1)
+
+	// Without requirements, the parse error is reported
+	_, err = NewLoader(WithVersion("v2099.1")).LoadFile(path)
+	assert.ErrorContains(t, err, "failed to parse prototext")
+}
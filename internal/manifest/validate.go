@@ -4,10 +4,25 @@
 package manifest
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
 
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
 	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+	"zntr.io/extproctor/internal/golden"
+	"zntr.io/extproctor/internal/random"
+	"zntr.io/extproctor/internal/units"
 )
 
 // ValidationError represents a validation error with context.
@@ -42,6 +57,13 @@ func ValidateTestCase(tc *extproctorv1.TestCase) error {
 		}
 	}
 
+	if tc.Uid != "" && strings.ContainsFunc(tc.Uid, func(r rune) bool { return unicode.IsSpace(r) || !unicode.IsPrint(r) }) {
+		errs = append(errs, &ValidationError{
+			Field:   "uid",
+			Message: "uid must not contain whitespace or control characters",
+		})
+	}
+
 	if len(tc.Expectations) == 0 && tc.GoldenFile == "" {
 		errs = append(errs, &ValidationError{
 			Field:   "expectations",
@@ -49,12 +71,261 @@ func ValidateTestCase(tc *extproctorv1.TestCase) error {
 		})
 	}
 
+	if tc.GoldenFile != "" {
+		if err := golden.ValidatePathTemplate(tc.GoldenFile); err != nil {
+			errs = append(errs, &ValidationError{
+				Field:   "golden_file",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	if err := validateLiterals(tc); err != nil {
+		errs = append(errs, err)
+	}
+
 	for i, exp := range tc.Expectations {
 		if err := validateExpectation(i, exp); err != nil {
 			errs = append(errs, err)
 		}
 	}
 
+	if err := validateGroups(tc.Expectations); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := validateRedaction(tc.Redaction); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := validateStream(tc.Stream); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := validatePhaseSequence(tc); err != nil {
+		errs = append(errs, err)
+	}
+
+	if ch := tc.Channel; ch != nil && ch.Authority == "" && ch.ServerName == "" {
+		errs = append(errs, &ValidationError{
+			Field:   "channel",
+			Message: "authority or server_name is required",
+		})
+	}
+
+	if err := client.ValidateMetadata(tc.GrpcMetadata); err != nil {
+		errs = append(errs, &ValidationError{
+			Field:   "grpc_metadata",
+			Message: err.Error(),
+		})
+	}
+
+	return errors.Join(errs...)
+}
+
+// validatePhaseSequence checks that the phases of the phase sequence are
+// specified, and that the expected phases are sent.
+func validatePhaseSequence(tc *extproctorv1.TestCase) error {
+	if len(tc.PhaseSequence) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for i, phase := range tc.PhaseSequence {
+		switch {
+		case phase == extproctorv1.ProcessingPhase_PROCESSING_PHASE_UNSPECIFIED:
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("phase_sequence[%d]", i),
+				Message: "processing phase is required",
+			})
+		case isPhaseWildcard(phase):
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("phase_sequence[%d]", i),
+				Message: fmt.Sprintf("phase wildcard %s is only valid in expectations", phase),
+			})
+		}
+	}
+
+	for i, exp := range tc.Expectations {
+		if exp.Phase != extproctorv1.ProcessingPhase_PROCESSING_PHASE_UNSPECIFIED && !isPhaseWildcard(exp.Phase) && !slices.Contains(tc.PhaseSequence, exp.Phase) {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("expectations[%d].phase", i),
+				Message: fmt.Sprintf("phase %s is not in the phase sequence", exp.Phase),
+			})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateGroups checks that the members of each assertion group share a
+// phase and body chunk, and that only grouped expectations continue on
+// failure.
+func validateGroups(expectations []*extproctorv1.ExtProcExpectation) error {
+	var errs []error
+
+	firsts := make(map[string]*extproctorv1.ExtProcExpectation)
+	for i, exp := range expectations {
+		if exp.Group == "" {
+			if exp.ContinueOnFailure {
+				errs = append(errs, &ValidationError{
+					Field:   fmt.Sprintf("expectations[%d].continue_on_failure", i),
+					Message: "continue_on_failure requires a group",
+				})
+			}
+			continue
+		}
+
+		first, ok := firsts[exp.Group]
+		if !ok {
+			firsts[exp.Group] = exp
+			continue
+		}
+		if exp.Phase != first.Phase {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("expectations[%d].group", i),
+				Message: fmt.Sprintf("group %q expectations must share the %s phase", exp.Group, first.Phase),
+			})
+		} else if !proto.Equal(exp.Chunk, first.Chunk) {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("expectations[%d].group", i),
+				Message: fmt.Sprintf("group %q expectations must share the body chunk", exp.Group),
+			})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateRedaction validates the sensitive data detectors and patterns of a
+// redaction expectation.
+func validateRedaction(exp *extproctorv1.RedactionExpectation) error {
+	if exp == nil {
+		return nil
+	}
+
+	var errs []error
+
+	if len(exp.Detectors) == 0 && len(exp.Patterns) == 0 {
+		errs = append(errs, &ValidationError{
+			Field:   "redaction",
+			Message: "at least one detector or pattern is required",
+		})
+	}
+
+	for i, d := range exp.Detectors {
+		if d == extproctorv1.SensitiveData_SENSITIVE_DATA_UNSPECIFIED {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("redaction.detectors[%d]", i),
+				Message: "detector is required",
+			})
+		}
+	}
+
+	for i, p := range exp.Patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("redaction.patterns[%d]", i),
+				Message: fmt.Sprintf("invalid regular expression: %v", err),
+			})
+		} else if p == "" {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("redaction.patterns[%d]", i),
+				Message: "pattern must not be empty",
+			})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateStream validates the header names of the stream-level assertions.
+func validateStream(exp *extproctorv1.StreamExpectation) error {
+	if exp == nil {
+		return nil
+	}
+
+	var errs []error
+
+	setOnce := make(map[string]bool, len(exp.SetOnceHeaders))
+	for i, name := range exp.SetOnceHeaders {
+		if name == "" {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("stream.set_once_headers[%d]", i),
+				Message: "header name must not be empty",
+			})
+		}
+		setOnce[strings.ToLower(name)] = true
+	}
+
+	for i, name := range exp.NeverSetHeaders {
+		switch {
+		case name == "":
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("stream.never_set_headers[%d]", i),
+				Message: "header name must not be empty",
+			})
+		case setOnce[strings.ToLower(name)]:
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("stream.never_set_headers[%d]", i),
+				Message: fmt.Sprintf("header %q is also in set_once_headers", name),
+			})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateLiterals validates the duration and size literals of a test case.
+// It is also run by the loader, so invalid literals fail at load time.
+func validateLiterals(tc *extproctorv1.TestCase) error {
+	var errs []error
+
+	if tc.Timeout != "" {
+		if _, err := units.ParseDuration(tc.Timeout); err != nil {
+			errs = append(errs, &ValidationError{
+				Field:   "timeout",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	if tc.MaxLatency != "" {
+		if _, err := units.ParseDuration(tc.MaxLatency); err != nil {
+			errs = append(errs, &ValidationError{
+				Field:   "max_latency",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	if req := tc.Request; req != nil && req.BodyChunkSize != "" {
+		if _, err := units.ParseSize(req.BodyChunkSize); err != nil {
+			errs = append(errs, &ValidationError{
+				Field:   "request.body_chunk_size",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	if cost := tc.Cost; cost != nil && cost.BodySize != "" {
+		if _, err := units.ParseSize(cost.BodySize); err != nil {
+			errs = append(errs, &ValidationError{
+				Field:   "cost.body_size",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	if cost := tc.Cost; cost != nil && cost.Duration != "" {
+		if _, err := units.ParseDuration(cost.Duration); err != nil {
+			errs = append(errs, &ValidationError{
+				Field:   "cost.duration",
+				Message: err.Error(),
+			})
+		}
+	}
+
 	return errors.Join(errs...)
 }
 
@@ -62,23 +333,127 @@ func ValidateTestCase(tc *extproctorv1.TestCase) error {
 func validateHttpRequest(req *extproctorv1.HttpRequest) error {
 	var errs []error
 
-	if req.Method == "" {
+	// gRPC calls default to POST /<service>/<method>
+	grpc := req.GetGrpc()
+
+	if req.Method == "" && grpc == nil {
 		errs = append(errs, &ValidationError{
 			Field:   "request.method",
 			Message: "HTTP method is required",
 		})
 	}
 
-	if req.Path == "" {
+	if req.Path == "" && (grpc.GetService() == "" || grpc.GetMethod() == "") {
 		errs = append(errs, &ValidationError{
 			Field:   "request.path",
 			Message: "path is required",
 		})
 	}
 
+	if d := req.Downstream; d != nil {
+		if _, err := netip.ParseAddr(d.Address); err != nil {
+			errs = append(errs, &ValidationError{
+				Field:   "request.downstream.address",
+				Message: fmt.Sprintf("%q is not an IP address", d.Address),
+			})
+		}
+	}
+
+	errs = append(errs, validateStructs("request.attributes", "attribute namespace", req.Attributes)...)
+	errs = append(errs, validateStructs("request.metadata_context", "filter name", req.MetadataContext)...)
+
+	if g := req.Graphql; g != nil {
+		if strings.TrimSpace(g.Query) == "" {
+			errs = append(errs, &ValidationError{
+				Field:   "request.graphql.query",
+				Message: "GraphQL query is required",
+			})
+		}
+		var vars map[string]any
+		if g.Variables != "" && json.Unmarshal([]byte(g.Variables), &vars) != nil {
+			errs = append(errs, &ValidationError{
+				Field:   "request.graphql.variables",
+				Message: "variables must be a JSON object",
+			})
+		}
+	}
+
+	for i, h := range req.HeaderEntries {
+		if h.Key == "" {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("request.header_entries[%d].key", i),
+				Message: "header key must not be empty",
+			})
+		}
+	}
+
+	if _, ok := req.RawHeaders[""]; ok {
+		errs = append(errs, &ValidationError{
+			Field:   "request.raw_headers",
+			Message: "header key must not be empty",
+		})
+	}
+
+	for i, t := range req.TrailerEntries {
+		if t.Key == "" {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("request.trailer_entries[%d].key", i),
+				Message: "trailer key must not be empty",
+			})
+		}
+	}
+
+	for i, t := range req.ResponseTrailerEntries {
+		if t.Key == "" {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("request.response_trailer_entries[%d].key", i),
+				Message: "trailer key must not be empty",
+			})
+		}
+	}
+
+	if err := random.Validate(req); err != nil {
+		errs = append(errs, &ValidationError{
+			Field:   "request",
+			Message: err.Error(),
+		})
+	}
+
 	return errors.Join(errs...)
 }
 
+// validateStructs checks the Struct values of a map keyed by name.
+func validateStructs(field, key string, structs map[string]*structpb.Struct) []error {
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(structs)) {
+		field := fmt.Sprintf("%s[%s]", field, name)
+		if name == "" {
+			errs = append(errs, &ValidationError{Field: field, Message: key + " is required"})
+			continue
+		}
+		// Envoy builds the structs from JSON-compatible values, which
+		// excludes values without a kind and non-finite numbers
+		if _, err := protojson.Marshal(structs[name]); err != nil {
+			errs = append(errs, &ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("invalid struct: %v", err),
+			})
+		}
+	}
+	return errs
+}
+
+// isPhaseWildcard reports whether a phase is a wildcard matching the
+// responses of several phases.
+func isPhaseWildcard(phase extproctorv1.ProcessingPhase) bool {
+	switch phase {
+	case extproctorv1.ProcessingPhase_ANY_REQUEST, extproctorv1.ProcessingPhase_ANY_RESPONSE, extproctorv1.ProcessingPhase_ANY:
+		return true
+	default:
+		return false
+	}
+}
+
 // validateExpectation validates a single expectation.
 func validateExpectation(index int, exp *extproctorv1.ExtProcExpectation) error {
 	var errs []error
@@ -97,6 +472,145 @@ func validateExpectation(index int, exp *extproctorv1.ExtProcExpectation) error
 		})
 	}
 
+	if r, ok := exp.Response.(*extproctorv1.ExtProcExpectation_Passthrough); ok && !r.Passthrough {
+		errs = append(errs, &ValidationError{
+			Field:   fmt.Sprintf("expectations[%d].passthrough", index),
+			Message: "passthrough must be true",
+		})
+	}
+	if exp.GetPassthrough() && isPhaseWildcard(exp.Phase) {
+		errs = append(errs, &ValidationError{
+			Field:   fmt.Sprintf("expectations[%d].passthrough", index),
+			Message: fmt.Sprintf("passthrough requires a processing phase, not the %s wildcard", exp.Phase),
+		})
+	}
+
+	if r, ok := exp.Response.(*extproctorv1.ExtProcExpectation_NoResponse); ok && !r.NoResponse {
+		errs = append(errs, &ValidationError{
+			Field:   fmt.Sprintf("expectations[%d].no_response", index),
+			Message: "no_response must be true",
+		})
+	}
+	if exp.GetNoResponse() && exp.Group != "" {
+		errs = append(errs, &ValidationError{
+			Field:   fmt.Sprintf("expectations[%d].no_response", index),
+			Message: "no_response expectations cannot be grouped",
+		})
+	}
+
+	if exp.Chunk != nil {
+		switch {
+		case exp.Phase != extproctorv1.ProcessingPhase_REQUEST_BODY && exp.Phase != extproctorv1.ProcessingPhase_RESPONSE_BODY:
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("expectations[%d].chunk", index),
+				Message: "chunk requires a body phase",
+			})
+		case exp.Chunk.Last && exp.Chunk.Index > 0:
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("expectations[%d].chunk", index),
+				Message: "index and last are mutually exclusive",
+			})
+		}
+	}
+
+	for i, path := range exp.IgnorePaths {
+		if path == "" {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("expectations[%d].ignore_paths[%d]", index, i),
+				Message: "ignore path must not be empty",
+			})
+		}
+	}
+
+	for i, feature := range exp.Features {
+		if strings.TrimSpace(feature) == "" {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("expectations[%d].features[%d]", index, i),
+				Message: "feature ID must not be empty",
+			})
+		}
+	}
+
+	for i, h := range exp.GetHeadersResponse().GetOrderedSetHeaders() {
+		if h.Key == "" {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("expectations[%d].headers_response.ordered_set_headers[%d].key", index, i),
+				Message: "header key must not be empty",
+			})
+		}
+	}
+
+	for i, t := range exp.GetTrailersResponse().GetSetTrailerEntries() {
+		if t.Key == "" {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("expectations[%d].trailers_response.set_trailer_entries[%d].key", index, i),
+				Message: "trailer key must not be empty",
+			})
+		}
+	}
+
+	for i, h := range exp.GetHeadersResponse().GetSetHeaderOptions() {
+		if h.Key == "" {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("expectations[%d].headers_response.set_header_options[%d].key", index, i),
+				Message: "header key must not be empty",
+			})
+		}
+		if _, ok := corev3.HeaderValueOption_HeaderAppendAction_value[h.AppendAction]; h.AppendAction != "" && !ok {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("expectations[%d].headers_response.set_header_options[%d].append_action", index, i),
+				Message: fmt.Sprintf("unknown append action %q", h.AppendAction),
+			})
+		}
+	}
+
+	if up := exp.GetUpgradeResponse(); up != nil {
+		if up.Handling == extproctorv1.UpgradeHandling_UPGRADE_HANDLING_UNSPECIFIED {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("expectations[%d].upgrade_response.handling", index),
+				Message: "upgrade handling is required",
+			})
+		}
+		if exp.Phase != extproctorv1.ProcessingPhase_REQUEST_HEADERS {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("expectations[%d].upgrade_response", index),
+				Message: "upgrades are handled in the REQUEST_HEADERS phase",
+			})
+		}
+	}
+
+	for _, m := range []struct {
+		field string
+		g     *extproctorv1.GraphqlResponseMatcher
+	}{
+		{"body_response.graphql.data", exp.GetBodyResponse().GetGraphql()},
+		{"immediate_response.graphql.data", exp.GetImmediateResponse().GetGraphql()},
+	} {
+		if m.g.GetData() != "" && !json.Valid([]byte(m.g.GetData())) {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("expectations[%d].%s", index, m.field),
+				Message: "data must be valid JSON",
+			})
+		}
+	}
+
+	if exp.When != nil {
+		for i, profile := range exp.When.Profiles {
+			if profile == "" {
+				errs = append(errs, &ValidationError{
+					Field:   fmt.Sprintf("expectations[%d].when.profiles[%d]", index, i),
+					Message: "profile must not be empty",
+				})
+			}
+		}
+		if _, ok := exp.When.Vars[""]; ok {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("expectations[%d].when.vars", index),
+				Message: "variable name must not be empty",
+			})
+		}
+	}
+
 	return errors.Join(errs...)
 }
 
@@ -111,6 +625,13 @@ func ValidateManifest(m *extproctorv1.TestManifest) error {
 		})
 	}
 
+	if err := client.ValidateMetadata(m.GrpcMetadata); err != nil {
+		errs = append(errs, &ValidationError{
+			Field:   "grpc_metadata",
+			Message: err.Error(),
+		})
+	}
+
 	for _, tc := range m.TestCases {
 		if err := ValidateTestCase(tc); err != nil {
 			errs = append(errs, fmt.Errorf("test case %q: %w", tc.Name, err))
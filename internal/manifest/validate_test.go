@@ -4,10 +4,14 @@
 package manifest
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
 	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
 )
 
 func TestValidateTestCase_Valid(t *testing.T) {
@@ -412,3 +416,608 @@ func TestValidateTestCase_MultipleInvalidExpectations(t *testing.T) {
 	assert.Contains(t, err.Error(), "phase")
 	assert.Contains(t, err.Error(), "response")
 }
+
+func TestValidateTestCase_EmptyIgnorePath(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name: "empty-ignore-path",
+		Request: &extproctorv1.HttpRequest{
+			Method: "GET",
+			Path:   "/api/test",
+		},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+					HeadersResponse: &extproctorv1.HeadersExpectation{},
+				},
+				IgnorePaths: []string{"set_headers[x-request-id]", ""},
+			},
+		},
+	}
+
+	err := ValidateTestCase(tc)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "expectations[0].ignore_paths[1]")
+}
+
+func TestValidateTestCase_GoldenFileTemplate(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name: "test-with-golden-template",
+		Request: &extproctorv1.HttpRequest{
+			Method: "GET",
+			Path:   "/api/test",
+		},
+		GoldenFile: "golden/{target}/{test_name}.{phase}.textproto",
+	}
+	assert.NoError(t, ValidateTestCase(tc))
+
+	tc.GoldenFile = "golden/{env}/{test_name}.textproto"
+	err := ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "golden_file: unknown placeholder {env}")
+}
+
+func TestValidateTestCase_Condition(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name:    "conditional",
+		Request: &extproctorv1.HttpRequest{Method: "GET", Path: "/"},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+					HeadersResponse: &extproctorv1.HeadersExpectation{},
+				},
+				When: &extproctorv1.Condition{
+					Profiles: []string{""},
+					Vars:     map[string]string{"": "x"},
+				},
+			},
+		},
+	}
+
+	err := ValidateTestCase(tc)
+	assert.ErrorContains(t, err, "expectations[0].when.profiles[0]: profile must not be empty")
+	assert.ErrorContains(t, err, "expectations[0].when.vars: variable name must not be empty")
+}
+
+func TestValidateTestCase_OrderedSetHeadersEmptyKey(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name:    "test",
+		Request: &extproctorv1.HttpRequest{Method: "GET", Path: "/"},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+					HeadersResponse: &extproctorv1.HeadersExpectation{
+						OrderedSetHeaders: []*extproctorv1.HeaderEntry{{Value: "1.1 edge"}},
+					},
+				},
+			},
+		},
+	}
+
+	err := ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ordered_set_headers[0].key")
+}
+
+func TestValidateTestCase_SetHeaderOptions(t *testing.T) {
+	newTestCase := func(options ...*extproctorv1.SetHeaderExpectation) *extproctorv1.TestCase {
+		return &extproctorv1.TestCase{
+			Name:    "test",
+			Request: &extproctorv1.HttpRequest{Method: "GET", Path: "/"},
+			Expectations: []*extproctorv1.ExtProcExpectation{
+				{
+					Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+					Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+						HeadersResponse: &extproctorv1.HeadersExpectation{SetHeaderOptions: options},
+					},
+				},
+			},
+		}
+	}
+
+	assert.NoError(t, ValidateTestCase(newTestCase(&extproctorv1.SetHeaderExpectation{Key: "x-a", AppendAction: "OVERWRITE_IF_EXISTS_OR_ADD"})))
+
+	err := ValidateTestCase(newTestCase(&extproctorv1.SetHeaderExpectation{AppendAction: "REPLACE"}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "set_header_options[0].key")
+	assert.Contains(t, err.Error(), `unknown append action "REPLACE"`)
+}
+
+func TestValidateTestCase_TrailerEntries(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name: "test",
+		Request: &extproctorv1.HttpRequest{
+			Method:                 "GET",
+			Path:                   "/",
+			TrailerEntries:         []*extproctorv1.HeaderEntry{{Value: "a"}},
+			ResponseTrailerEntries: []*extproctorv1.HeaderEntry{{Key: "x-a", Value: "a"}, {Value: "b"}},
+		},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			{
+				Phase: extproctorv1.ProcessingPhase_RESPONSE_TRAILERS,
+				Response: &extproctorv1.ExtProcExpectation_TrailersResponse{
+					TrailersResponse: &extproctorv1.TrailersExpectation{
+						SetTrailerEntries: []*extproctorv1.HeaderEntry{{Value: "c"}},
+					},
+				},
+			},
+		},
+	}
+
+	err := ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "request.trailer_entries[0].key")
+	assert.Contains(t, err.Error(), "request.response_trailer_entries[1].key")
+	assert.Contains(t, err.Error(), "expectations[0].trailers_response.set_trailer_entries[0].key")
+}
+
+func TestValidateTestCase_Graphql(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name: "graphql",
+		Request: &extproctorv1.HttpRequest{
+			Method:  "POST",
+			Path:    "/graphql",
+			Graphql: &extproctorv1.GraphqlRequest{Variables: "[1]"},
+		},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extproctorv1.ExtProcExpectation_ImmediateResponse{
+					ImmediateResponse: &extproctorv1.ImmediateExpectation{
+						Graphql: &extproctorv1.GraphqlResponseMatcher{Data: "{invalid"},
+					},
+				},
+			},
+		},
+	}
+
+	err := ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "request.graphql.query: GraphQL query is required")
+	assert.Contains(t, err.Error(), "request.graphql.variables: variables must be a JSON object")
+	assert.Contains(t, err.Error(), "expectations[0].immediate_response.graphql.data: data must be valid JSON")
+}
+
+func TestValidateTestCase_GrpcDefaults(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name: "grpc",
+		Request: &extproctorv1.HttpRequest{
+			Grpc: &extproctorv1.GrpcRequest{Service: "acme.users.v1.UserService", Method: "GetUser"},
+		},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+					HeadersResponse: &extproctorv1.HeadersExpectation{},
+				},
+			},
+		},
+	}
+	assert.NoError(t, ValidateTestCase(tc))
+
+	tc.Request.Grpc.Method = ""
+	err := ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "request.path: path is required")
+	assert.NotContains(t, err.Error(), "request.method")
+}
+
+func TestValidateTestCase_UpgradeResponse(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name:    "websocket",
+		Request: &extproctorv1.HttpRequest{Method: "GET", Path: "/chat"},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_BODY,
+				Response: &extproctorv1.ExtProcExpectation_UpgradeResponse{
+					UpgradeResponse: &extproctorv1.UpgradeExpectation{},
+				},
+			},
+		},
+	}
+
+	err := ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expectations[0].upgrade_response.handling: upgrade handling is required")
+	assert.Contains(t, err.Error(), "expectations[0].upgrade_response: upgrades are handled in the REQUEST_HEADERS phase")
+}
+
+func TestValidateTestCase_Redaction(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name:    "redaction",
+		Request: &extproctorv1.HttpRequest{Method: "GET", Path: "/users"},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+					HeadersResponse: &extproctorv1.HeadersExpectation{},
+				},
+			},
+		},
+		Redaction: &extproctorv1.RedactionExpectation{
+			Detectors: []extproctorv1.SensitiveData{extproctorv1.SensitiveData_EMAIL},
+			Patterns:  []string{`EMP-\d{6}`},
+		},
+	}
+	assert.NoError(t, ValidateTestCase(tc))
+
+	tc.Redaction.Detectors = append(tc.Redaction.Detectors, extproctorv1.SensitiveData_SENSITIVE_DATA_UNSPECIFIED)
+	tc.Redaction.Patterns = append(tc.Redaction.Patterns, "(", "")
+	err := ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "redaction.detectors[1]: detector is required")
+	assert.Contains(t, err.Error(), "redaction.patterns[1]: invalid regular expression")
+	assert.Contains(t, err.Error(), "redaction.patterns[2]: pattern must not be empty")
+
+	tc.Redaction = &extproctorv1.RedactionExpectation{}
+	err = ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "redaction: at least one detector or pattern is required")
+}
+
+func TestValidateTestCase_Groups(t *testing.T) {
+	headers := func(phase extproctorv1.ProcessingPhase, group string, continueOnFailure bool) *extproctorv1.ExtProcExpectation {
+		return &extproctorv1.ExtProcExpectation{
+			Phase: phase,
+			Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+				HeadersResponse: &extproctorv1.HeadersExpectation{},
+			},
+			Group:             group,
+			ContinueOnFailure: continueOnFailure,
+		}
+	}
+
+	tc := &extproctorv1.TestCase{
+		Name:    "groups",
+		Request: &extproctorv1.HttpRequest{Method: "GET", Path: "/"},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			headers(extproctorv1.ProcessingPhase_REQUEST_HEADERS, "auth", true),
+			headers(extproctorv1.ProcessingPhase_REQUEST_HEADERS, "auth", false),
+			headers(extproctorv1.ProcessingPhase_RESPONSE_HEADERS, "cache", false),
+		},
+	}
+	assert.NoError(t, ValidateTestCase(tc))
+
+	tc.Expectations = append(tc.Expectations,
+		headers(extproctorv1.ProcessingPhase_RESPONSE_HEADERS, "auth", false),
+		headers(extproctorv1.ProcessingPhase_RESPONSE_HEADERS, "", true),
+	)
+	err := ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `expectations[3].group: group "auth" expectations must share the REQUEST_HEADERS phase`)
+	assert.Contains(t, err.Error(), "expectations[4].continue_on_failure: continue_on_failure requires a group")
+}
+
+func TestValidateTestCase_UID(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name:    "uid",
+		Uid:     "auth-deny",
+		Request: &extproctorv1.HttpRequest{Method: "GET", Path: "/"},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+					HeadersResponse: &extproctorv1.HeadersExpectation{},
+				},
+			},
+		},
+	}
+	assert.NoError(t, ValidateTestCase(tc))
+
+	tc.Uid = "auth deny"
+	err := ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "uid: uid must not contain whitespace or control characters")
+}
+
+func TestValidateTestCase_RandomTemplate(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name:    "random",
+		Request: &extproctorv1.HttpRequest{Method: "GET", Path: "/users/{{ random_int 1 100 }}"},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+					HeadersResponse: &extproctorv1.HeadersExpectation{},
+				},
+			},
+		},
+	}
+	assert.NoError(t, ValidateTestCase(tc))
+
+	tc.Request.Path = "/users/{{ random_int 1 }"
+	err := ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `request: invalid request template "/users/{{ random_int 1 }"`)
+}
+
+func TestValidateTestCase_PhaseSequence(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name:    "body-first",
+		Request: &extproctorv1.HttpRequest{Method: "POST", Path: "/", Body: []byte("{}")},
+		PhaseSequence: []extproctorv1.ProcessingPhase{
+			extproctorv1.ProcessingPhase_REQUEST_BODY,
+			extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+		},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+					HeadersResponse: &extproctorv1.HeadersExpectation{},
+				},
+			},
+		},
+	}
+	assert.NoError(t, ValidateTestCase(tc))
+
+	tc.PhaseSequence = append(tc.PhaseSequence, extproctorv1.ProcessingPhase_PROCESSING_PHASE_UNSPECIFIED)
+	tc.Expectations = append(tc.Expectations, &extproctorv1.ExtProcExpectation{
+		Phase: extproctorv1.ProcessingPhase_RESPONSE_HEADERS,
+		Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+			HeadersResponse: &extproctorv1.HeadersExpectation{},
+		},
+	})
+	err := ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "phase_sequence[2]: processing phase is required")
+	assert.Contains(t, err.Error(), "expectations[1].phase: phase RESPONSE_HEADERS is not in the phase sequence")
+}
+
+func TestValidateTestCase_PhaseWildcards(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name:    "tenant-header",
+		Request: &extproctorv1.HttpRequest{Method: "POST", Path: "/", Body: []byte("{}")},
+		PhaseSequence: []extproctorv1.ProcessingPhase{
+			extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			extproctorv1.ProcessingPhase_REQUEST_BODY,
+		},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			{
+				Phase: extproctorv1.ProcessingPhase_ANY_REQUEST,
+				Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+					HeadersResponse: &extproctorv1.HeadersExpectation{SetHeaders: map[string]string{"x-tenant": "acme"}},
+				},
+			},
+		},
+	}
+	assert.NoError(t, ValidateTestCase(tc))
+
+	tc.PhaseSequence = append(tc.PhaseSequence, extproctorv1.ProcessingPhase_ANY)
+	tc.Expectations = append(tc.Expectations, &extproctorv1.ExtProcExpectation{
+		Phase:    extproctorv1.ProcessingPhase_ANY_RESPONSE,
+		Response: &extproctorv1.ExtProcExpectation_Passthrough{Passthrough: true},
+	})
+	err := ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "phase_sequence[2]: phase wildcard ANY is only valid in expectations")
+	assert.Contains(t, err.Error(), "expectations[1].passthrough: passthrough requires a processing phase, not the ANY_RESPONSE wildcard")
+	assert.NotContains(t, err.Error(), "is not in the phase sequence")
+}
+
+func TestValidateTestCase_Stream(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name:    "authz-once",
+		Request: &extproctorv1.HttpRequest{Method: "GET", Path: "/"},
+		Stream: &extproctorv1.StreamExpectation{
+			SetOnceHeaders:      []string{"x-authz"},
+			NeverSetHeaders:     []string{"x-debug"},
+			NoImmediateResponse: true,
+		},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+					HeadersResponse: &extproctorv1.HeadersExpectation{},
+				},
+			},
+		},
+	}
+	assert.NoError(t, ValidateTestCase(tc))
+
+	tc.Stream.NeverSetHeaders = []string{"", "X-Authz"}
+	err := ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stream.never_set_headers[0]: header name must not be empty")
+	assert.Contains(t, err.Error(), `header "X-Authz" is also in set_once_headers`)
+}
+
+func TestValidateTestCase_Channel(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name:    "tenant-a",
+		Request: &extproctorv1.HttpRequest{Method: "GET", Path: "/"},
+		Channel: &extproctorv1.ChannelOverrides{Authority: "tenant-a.extproc.internal"},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+					HeadersResponse: &extproctorv1.HeadersExpectation{},
+				},
+			},
+		},
+	}
+	assert.NoError(t, ValidateTestCase(tc))
+
+	tc.Channel = &extproctorv1.ChannelOverrides{}
+	assert.ErrorContains(t, ValidateTestCase(tc), "channel: authority or server_name is required")
+}
+
+func TestValidate_GrpcMetadata(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name:         "tenant-a",
+		Request:      &extproctorv1.HttpRequest{Method: "GET", Path: "/"},
+		GrpcMetadata: map[string]string{"x-tenant-id": "acme"},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+					HeadersResponse: &extproctorv1.HeadersExpectation{},
+				},
+			},
+		},
+	}
+	assert.NoError(t, ValidateTestCase(tc))
+
+	tc.GrpcMetadata = map[string]string{"grpc-timeout": "1s"}
+	assert.ErrorContains(t, ValidateTestCase(tc), `grpc_metadata: metadata key "grpc-timeout" is reserved by gRPC`)
+
+	m := &extproctorv1.TestManifest{
+		TestCases:    []*extproctorv1.TestCase{tc},
+		GrpcMetadata: map[string]string{"x tenant": "acme"},
+	}
+	tc.GrpcMetadata = nil
+	assert.ErrorContains(t, ValidateManifest(m), `grpc_metadata: metadata key "x tenant" must only contain letters, digits, -, _ and .`)
+}
+
+func TestValidateTestCase_BodyChunk(t *testing.T) {
+	body := func(chunk *extproctorv1.BodyChunk, group string) *extproctorv1.ExtProcExpectation {
+		return &extproctorv1.ExtProcExpectation{
+			Phase: extproctorv1.ProcessingPhase_REQUEST_BODY,
+			Response: &extproctorv1.ExtProcExpectation_BodyResponse{
+				BodyResponse: &extproctorv1.BodyExpectation{},
+			},
+			Chunk: chunk,
+			Group: group,
+		}
+	}
+	tc := &extproctorv1.TestCase{
+		Name:    "chunks",
+		Request: &extproctorv1.HttpRequest{Method: "POST", Path: "/", Body: []byte("0123456789"), ProcessRequestBody: true, BodyChunkSize: "4B"},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			body(&extproctorv1.BodyChunk{Index: 0}, ""),
+			body(&extproctorv1.BodyChunk{Last: true}, "last"),
+			body(&extproctorv1.BodyChunk{Last: true}, "last"),
+		},
+	}
+	assert.NoError(t, ValidateTestCase(tc))
+
+	tc.Expectations = append(tc.Expectations,
+		body(&extproctorv1.BodyChunk{Index: 1, Last: true}, ""),
+		body(&extproctorv1.BodyChunk{Index: 1}, "last"),
+		&extproctorv1.ExtProcExpectation{
+			Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			Response: &extproctorv1.ExtProcExpectation_HeadersResponse{
+				HeadersResponse: &extproctorv1.HeadersExpectation{},
+			},
+			Chunk: &extproctorv1.BodyChunk{},
+		},
+	)
+	err := ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expectations[3].chunk: index and last are mutually exclusive")
+	assert.Contains(t, err.Error(), `expectations[4].group: group "last" expectations must share the body chunk`)
+	assert.Contains(t, err.Error(), "expectations[5].chunk: chunk requires a body phase")
+}
+
+func TestValidateTestCase_Passthrough(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name:    "health-check-ignored",
+		Request: &extproctorv1.HttpRequest{Method: "GET", Path: "/healthz"},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			{
+				Phase:    extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extproctorv1.ExtProcExpectation_Passthrough{Passthrough: true},
+			},
+		},
+	}
+	assert.NoError(t, ValidateTestCase(tc))
+
+	tc.Expectations[0].Response = &extproctorv1.ExtProcExpectation_Passthrough{}
+	err := ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expectations[0].passthrough: passthrough must be true")
+}
+
+func TestValidateTestCase_NoResponse(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name:    "observability",
+		Request: &extproctorv1.HttpRequest{Method: "GET", Path: "/", ObservabilityMode: true},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			{
+				Phase:    extproctorv1.ProcessingPhase_ANY_REQUEST,
+				Response: &extproctorv1.ExtProcExpectation_NoResponse{NoResponse: true},
+			},
+		},
+	}
+	assert.NoError(t, ValidateTestCase(tc))
+
+	tc.Expectations[0].Response = &extproctorv1.ExtProcExpectation_NoResponse{}
+	err := ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expectations[0].no_response: no_response must be true")
+
+	tc.Expectations[0].Response = &extproctorv1.ExtProcExpectation_NoResponse{NoResponse: true}
+	tc.Expectations[0].Group = "silent"
+	err = ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expectations[0].no_response: no_response expectations cannot be grouped")
+}
+
+func TestValidateTestCase_Attributes(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name: "route-metadata",
+		Request: &extproctorv1.HttpRequest{
+			Method: "GET",
+			Path:   "/",
+			Attributes: map[string]*structpb.Struct{
+				client.ExtProcAttributesKey: {Fields: map[string]*structpb.Value{
+					"request.path": structpb.NewStringValue("/"),
+				}},
+			},
+		},
+		GoldenFile: "golden/route-metadata.textproto",
+	}
+	assert.NoError(t, ValidateTestCase(tc))
+
+	tc.Request.Attributes[""] = &structpb.Struct{}
+	tc.Request.Attributes["custom"] = &structpb.Struct{Fields: map[string]*structpb.Value{"unset": {}}}
+	err := ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "request.attributes[]: attribute namespace is required")
+	assert.Contains(t, err.Error(), "request.attributes[custom]: invalid struct:")
+}
+
+func TestValidateTestCase_MetadataContext(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name: "jwt-claims",
+		Request: &extproctorv1.HttpRequest{
+			Method: "GET",
+			Path:   "/",
+			MetadataContext: map[string]*structpb.Struct{
+				"envoy.filters.http.jwt_authn": {Fields: map[string]*structpb.Value{
+					"sub": structpb.NewStringValue("alice"),
+				}},
+			},
+		},
+		GoldenFile: "golden/jwt-claims.textproto",
+	}
+	assert.NoError(t, ValidateTestCase(tc))
+
+	tc.Request.MetadataContext[""] = &structpb.Struct{}
+	tc.Request.MetadataContext["envoy.filters.http.jwt_authn"].Fields["exp"] = structpb.NewNumberValue(math.Inf(1))
+	err := ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "request.metadata_context[]: filter name is required")
+	assert.Contains(t, err.Error(), "request.metadata_context[envoy.filters.http.jwt_authn]: invalid struct:")
+}
+
+func TestValidateTestCase_Features(t *testing.T) {
+	tc := &extproctorv1.TestCase{
+		Name:    "deny-anonymous",
+		Request: &extproctorv1.HttpRequest{Method: "GET", Path: "/"},
+		Expectations: []*extproctorv1.ExtProcExpectation{
+			{
+				Phase:    extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extproctorv1.ExtProcExpectation_HeadersResponse{HeadersResponse: &extproctorv1.HeadersExpectation{}},
+				Features: []string{"REQ-1"},
+			},
+		},
+	}
+	assert.NoError(t, ValidateTestCase(tc))
+
+	tc.Expectations[0].Features = append(tc.Expectations[0].Features, " ")
+	err := ValidateTestCase(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expectations[0].features[1]: feature ID must not be empty")
+}
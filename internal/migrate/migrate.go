@@ -0,0 +1,234 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package migrate rewrites manifests from legacy field shapes to their
+// canonical ones. Manifests are edited as txtpbfmt syntax trees, so that
+// comments are preserved.
+package migrate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/protocolbuffers/txtpbfmt/ast"
+	"github.com/protocolbuffers/txtpbfmt/parser"
+)
+
+// Migration rewrites a legacy field of the requests of test cases to its
+// canonical field.
+type Migration struct {
+	// From is the legacy field, relative to the test case.
+	From string
+	// To is the canonical field, relative to the test case.
+	To string
+}
+
+// Migrations are the migrations applied by File, in order.
+var Migrations = []Migration{
+	// Maps cannot repeat keys and are sent in random order, header entries
+	// can and are sent in order.
+	{From: "request.headers", To: "request.header_entries"},
+	{From: "request.trailers", To: "request.trailer_entries"},
+	{From: "request.response_trailers", To: "request.response_trailer_entries"},
+}
+
+// Change counts the fields of a file rewritten by a migration.
+type Change struct {
+	Migration Migration
+	Count     int
+}
+
+// Result is the result of the migration of a manifest.
+type Result struct {
+	// Content is the migrated manifest, formatted by txtpbfmt.
+	Content []byte
+
+	// Changes lists the migrations applied to the manifest.
+	Changes []Change
+
+	// Skipped lists the test cases left unchanged because they take part in
+	// an inheritance: repeated fields are replaced when extending a test case
+	// while map entries are merged, so migrating them would change the
+	// inherited request.
+	Skipped []string
+}
+
+// Changed reports whether the manifest was rewritten.
+func (r *Result) Changed() bool {
+	return len(r.Changes) > 0
+}
+
+// File migrates the content of a manifest.
+func File(content []byte) (*Result, error) {
+	nodes, err := parser.Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("parse error: %w", err)
+	}
+
+	testCases := messages(nodes, "test_cases")
+
+	// Test cases extended by others, or extending others, keep their shape.
+	extended := map[string]bool{}
+	for _, tc := range testCases {
+		if base := stringField(tc, "extends"); base != "" {
+			extended[base] = true
+		}
+	}
+
+	result := &Result{Content: content}
+	counts := make([]int, len(Migrations))
+	for _, tc := range testCases {
+		name := stringField(tc, "name")
+		if extended[name] || stringField(tc, "extends") != "" || stringField(tc, "abstract") == "true" {
+			if len(messages(tc.Children, "request")) > 0 {
+				result.Skipped = append(result.Skipped, name)
+			}
+			continue
+		}
+
+		for _, req := range messages(tc.Children, "request") {
+			for i, m := range Migrations {
+				counts[i] += renameField(req, lastPart(m.From), lastPart(m.To))
+			}
+		}
+	}
+
+	for i, n := range counts {
+		if n > 0 {
+			result.Changes = append(result.Changes, Change{Migration: Migrations[i], Count: n})
+		}
+	}
+	if result.Changed() {
+		result.Content = parser.PrettyBytes(nodes, 0)
+	}
+
+	return result, nil
+}
+
+// Imports returns the imports of a manifest, as written.
+func Imports(content []byte) ([]string, error) {
+	nodes, err := parser.Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("parse error: %w", err)
+	}
+
+	var imports []string
+	for _, n := range nodes {
+		if n.Name != "imports" {
+			continue
+		}
+		for _, v := range n.Values {
+			imports = append(imports, unquote(v.Value))
+		}
+	}
+	return imports, nil
+}
+
+// renameField renames the entries of a map field of a message to a repeated
+// entry field, and returns the number of renamed entries. The renamed entries
+// are moved before the existing entries of the repeated field, as map entries
+// were sent first, and the entries overridden by a later one with the same
+// key are dropped.
+func renameField(msg *ast.Node, from, to string) int {
+	var renamed []*ast.Node
+	for _, n := range msg.Children {
+		if n.Name == from && !n.Deleted {
+			renamed = append(renamed, n)
+		}
+	}
+	if len(renamed) == 0 {
+		return 0
+	}
+
+	// A repeated key keeps the value of its last occurrence in a map.
+	seen := map[string]bool{}
+	count := 0
+	for i := len(renamed) - 1; i >= 0; i-- {
+		kept := 0
+		values := entries(renamed[i])
+		for j := len(values) - 1; j >= 0; j-- {
+			entry := values[j]
+			key := stringField(entry, "key")
+			if seen[key] {
+				entry.Deleted = true
+				continue
+			}
+			seen[key] = true
+			kept++
+		}
+		if kept == 0 {
+			renamed[i].Deleted = true
+		}
+		count += kept
+	}
+
+	children := make([]*ast.Node, 0, len(msg.Children))
+	inserted := false
+	for _, n := range msg.Children {
+		if (n.Name == from || n.Name == to) && !inserted {
+			children = append(children, renamed...)
+			inserted = true
+		}
+		if n.Name != from {
+			children = append(children, n)
+		}
+	}
+	for _, n := range renamed {
+		n.Name = to
+	}
+	msg.Children = children
+
+	return count
+}
+
+// messages returns the message values of the fields with the given name,
+// written either once per value or as a list.
+func messages(nodes []*ast.Node, name string) []*ast.Node {
+	var out []*ast.Node
+	for _, n := range nodes {
+		if n.Name != name || n.Deleted {
+			continue
+		}
+		out = append(out, entries(n)...)
+	}
+	return out
+}
+
+// entries returns the message values of a field node.
+func entries(n *ast.Node) []*ast.Node {
+	if n.ChildrenAsList {
+		return n.Children
+	}
+	return []*ast.Node{n}
+}
+
+// stringField returns the unquoted value of a scalar field of a message.
+func stringField(msg *ast.Node, name string) string {
+	for _, n := range msg.Children {
+		if n.Name != name || len(n.Values) == 0 {
+			continue
+		}
+		var sb strings.Builder
+		for _, v := range n.Values {
+			sb.WriteString(unquote(v.Value))
+		}
+		return sb.String()
+	}
+	return ""
+}
+
+// unquote unquotes a string literal, or returns other literals as is.
+func unquote(s string) string {
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func lastPart(path string) string {
+	return path[strings.LastIndexByte(path, '.')+1:]
+}
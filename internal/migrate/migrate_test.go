@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package migrate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile(t *testing.T) {
+	result, err := File([]byte(`# Manifest comment
+name: "m"
+test_cases: {
+  name: "a"
+  request: {
+    method: "GET"
+    # The accepted type
+    headers: { key: "accept" value: "application/json" }  # inline
+    path: "/"
+    header_entries: { key: "via" value: "1.1 proxy" }
+    headers: [{ key: "x-api-key" value: "1" }, { key: "x-api-key" value: "2" }]
+    trailers: { key: "grpc-status" value: "0" }
+  }
+}
+`))
+	require.NoError(t, err)
+
+	assert.True(t, result.Changed())
+	assert.Equal(t, []Change{
+		{Migration: Migrations[0], Count: 2},
+		{Migration: Migrations[1], Count: 1},
+	}, result.Changes)
+	assert.Empty(t, result.Skipped)
+	assert.Equal(t, `# Manifest comment
+name: "m"
+test_cases: {
+  name: "a"
+  request: {
+    method: "GET"
+    # The accepted type
+    header_entries: { key: "accept" value: "application/json" }  # inline
+    header_entries: [ { key: "x-api-key" value: "2" } ]
+    path: "/"
+    header_entries: { key: "via" value: "1.1 proxy" }
+    trailer_entries: { key: "grpc-status" value: "0" }
+  }
+}
+`, string(result.Content))
+}
+
+func TestFile_Unchanged(t *testing.T) {
+	content := []byte("name:   \"m\"\ntest_cases: { name: \"a\" request: { method: \"GET\" } }\n")
+
+	result, err := File(content)
+	require.NoError(t, err)
+	assert.False(t, result.Changed())
+	// Unchanged manifests are not reformatted
+	assert.Equal(t, content, result.Content)
+}
+
+func TestFile_Inheritance(t *testing.T) {
+	result, err := File([]byte(`
+test_cases: { name: "base" abstract: true request: { headers: { key: "a" value: "1" } } }
+test_cases: { name: "child" extends: "base" request: { headers: { key: "b" value: "2" } } }
+test_cases: { name: "parent" request: { headers: { key: "c" value: "3" } } }
+test_cases: { name: "other" extends: "parent" }
+test_cases: { name: "plain" request: { headers: { key: "d" value: "4" } } }
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"base", "child", "parent"}, result.Skipped)
+	assert.Equal(t, []Change{{Migration: Migrations[0], Count: 1}}, result.Changes)
+	assert.Contains(t, string(result.Content), `name: "plain" request: { header_entries: { key: "d" value: "4" } }`)
+}
+
+func TestFile_ParseError(t *testing.T) {
+	_, err := File([]byte(`test_cases: }`))
+	assert.ErrorContains(t, err, "parse error")
+}
+
+func TestImports(t *testing.T) {
+	imports, err := Imports([]byte(`imports: "base.textproto"
+imports: ["common/a.textproto", "common/b.textproto"]`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"base.textproto", "common/a.textproto", "common/b.textproto"}, imports)
+}
@@ -0,0 +1,199 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package paths discovers the files named by path arguments: it expands glob
+// patterns and walks directories, following symlinked directories and
+// honoring the .extproctorignore files.
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"zntr.io/extproctor/internal/glob"
+	"zntr.io/extproctor/internal/ignore"
+)
+
+// Expand expands the glob patterns among path arguments (e.g.
+// "./tests/**/auth*.textproto") into the matching files and directories, in
+// lexical order. Arguments naming an existing path are kept as is, and a
+// pattern matching nothing is an error.
+func Expand(args []string, followSymlinks bool) ([]string, error) {
+	var expanded []string
+	for _, arg := range args {
+		if _, err := os.Lstat(arg); err == nil || !glob.HasMeta(arg) {
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		matches, err := Glob(arg, followSymlinks)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no path matches %q", arg)
+		}
+		expanded = append(expanded, matches...)
+	}
+
+	return expanded, nil
+}
+
+// Glob returns the files and directories matching a glob pattern. The
+// directories below a matching directory are not searched.
+func Glob(pattern string, followSymlinks bool) ([]string, error) {
+	// Walk from the longest directory prefix without glob characters
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	static := 0
+	for static < len(segments)-1 && !glob.HasMeta(segments[static]) {
+		static++
+	}
+	root := strings.Join(segments[:static], "/")
+	switch {
+	case root == "" && static > 0:
+		root = "/"
+	case root == "":
+		root = "."
+	}
+
+	re, err := glob.Compile(strings.Join(segments[static:], "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	root = filepath.FromSlash(root)
+	if _, err := os.Stat(root); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	var matches []string
+	err = Walk(root, followSymlinks, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if re.MatchString(filepath.ToSlash(rel)) {
+			matches = append(matches, path)
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// Walk walks the file tree rooted at root in lexical order like
+// filepath.WalkDir, skipping the files and directories excluded by the
+// .extproctorignore files. Symlinked directories are followed when
+// followSymlinks is set, and skipped otherwise; each directory is walked once,
+// which breaks symlink cycles and collapses the paths a case-insensitive file
+// system resolves to the same directory.
+func Walk(root string, followSymlinks bool, fn fs.WalkDirFunc) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		return fn(root, nil, err)
+	}
+
+	w := &walker{
+		followSymlinks: followSymlinks,
+		fn:             fn,
+		matcher:        &ignore.Matcher{},
+	}
+	err = w.walk(root, fs.FileInfoToDirEntry(info), info)
+	if errors.Is(err, filepath.SkipDir) || errors.Is(err, filepath.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+// walker holds the state of a walk.
+type walker struct {
+	followSymlinks bool
+	fn             fs.WalkDirFunc
+	matcher        *ignore.Matcher
+
+	// visited holds the walked directories.
+	visited []os.FileInfo
+}
+
+// walk walks a file or a directory.
+func (w *walker) walk(path string, d fs.DirEntry, info os.FileInfo) error {
+	if !d.IsDir() {
+		return w.fn(path, d, nil)
+	}
+
+	for _, v := range w.visited {
+		if os.SameFile(v, info) {
+			return nil
+		}
+	}
+	w.visited = append(w.visited, info)
+
+	if err := w.fn(path, d, nil); err != nil {
+		if errors.Is(err, filepath.SkipDir) {
+			return nil
+		}
+		return err
+	}
+
+	if err := w.matcher.AddFile(path); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return w.fn(path, d, err)
+	}
+
+	for _, e := range entries {
+		child := filepath.Join(path, e.Name())
+
+		childInfo, err := os.Stat(child)
+		if err != nil {
+			// Broken symlinks are reported as files
+			if e.Type()&fs.ModeSymlink == 0 {
+				return w.fn(child, e, err)
+			}
+			childInfo, err = e.Info()
+			if err != nil {
+				return w.fn(child, e, err)
+			}
+		}
+		if e.Type()&fs.ModeSymlink != 0 && childInfo.IsDir() {
+			if !w.followSymlinks {
+				continue
+			}
+			e = fs.FileInfoToDirEntry(childInfo)
+		}
+
+		if w.matcher.Ignored(child, e.IsDir()) {
+			continue
+		}
+
+		if err := w.walk(child, e, childInfo); err != nil {
+			if errors.Is(err, filepath.SkipDir) {
+				// Skip the remaining files of the directory
+				return nil
+			}
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package paths
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTree creates files under dir, with their slash-separated path.
+func createTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+// walked returns the slash-separated files walked below dir.
+func walked(t *testing.T, dir string, followSymlinks bool) []string {
+	t.Helper()
+	var files []string
+	err := Walk(dir, followSymlinks, func(path string, d fs.DirEntry, err error) error {
+		require.NoError(t, err)
+		if !d.IsDir() {
+			rel, err := filepath.Rel(dir, path)
+			require.NoError(t, err)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return files
+}
+
+func TestWalk_IgnoreFiles(t *testing.T) {
+	dir := t.TempDir()
+	createTree(t, dir, map[string]string{
+		".extproctorignore":            "wip/\n",
+		"a.textproto":                  "",
+		"wip/b.textproto":              "",
+		"sub/.extproctorignore":        "c.textproto\n",
+		"sub/c.textproto":              "",
+		"sub/d.textproto":              "",
+		"other/c.textproto":            "",
+		"other/deeper/wip/e.textproto": "",
+	})
+
+	// The rules of sub/.extproctorignore do not apply to other/
+	assert.Equal(t, []string{
+		".extproctorignore",
+		"a.textproto",
+		"other/c.textproto",
+		"sub/.extproctorignore",
+		"sub/d.textproto",
+	}, walked(t, dir, true))
+}
+
+func TestWalk_Symlinks(t *testing.T) {
+	dir := t.TempDir()
+	createTree(t, dir, map[string]string{
+		"shared/common.textproto": "",
+		"tests/a.textproto":       "",
+	})
+	require.NoError(t, os.Symlink(filepath.Join(dir, "shared"), filepath.Join(dir, "tests", "linked")))
+	// A cycle back to the root
+	require.NoError(t, os.Symlink(dir, filepath.Join(dir, "tests", "loop")))
+
+	// Directories are walked once: shared/ through linked/ only, and tests/
+	// is not walked again through the loop
+	tests := filepath.Join(dir, "tests")
+	assert.Equal(t, []string{"a.textproto", "linked/common.textproto"}, walked(t, tests, true))
+	assert.Equal(t, []string{"a.textproto"}, walked(t, tests, false))
+}
+
+func TestWalk_SkipDir(t *testing.T) {
+	dir := t.TempDir()
+	createTree(t, dir, map[string]string{
+		"a/1.textproto": "",
+		"a/2.textproto": "",
+		"b/3.textproto": "",
+	})
+
+	var files []string
+	err := Walk(dir, true, func(path string, d fs.DirEntry, err error) error {
+		require.NoError(t, err)
+		if d.IsDir() && d.Name() == "b" {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() {
+			files = append(files, d.Name())
+			// Skip the remaining files of the directory
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.textproto"}, files)
+}
+
+func TestWalk_NonExistent(t *testing.T) {
+	err := Walk(filepath.Join(t.TempDir(), "missing"), true, func(_ string, _ fs.DirEntry, err error) error {
+		return err
+	})
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestGlob(t *testing.T) {
+	dir := t.TempDir()
+	createTree(t, dir, map[string]string{
+		"tests/auth.textproto":          "",
+		"tests/api/auth-deny.textproto": "",
+		"tests/api/users.textproto":     "",
+		"tests/wip/auth-wip.textproto":  "",
+		"tests/.extproctorignore":       "wip/\n",
+	})
+	t.Chdir(dir)
+
+	matches, err := Glob("./tests/**/auth*.textproto", true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join("tests", "api", "auth-deny.textproto"),
+		filepath.Join("tests", "auth.textproto"),
+	}, matches)
+
+	// Matching directories are not searched
+	matches, err = Glob("tests/a*", true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join("tests", "api"), filepath.Join("tests", "auth.textproto")}, matches)
+
+	matches, err = Glob(filepath.ToSlash(dir)+"/tests/*/users.textproto", true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "tests", "api", "users.textproto")}, matches)
+
+	matches, err = Glob("missing/*.textproto", true)
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+	createTree(t, dir, map[string]string{
+		"tests/a.textproto": "",
+		"tests/b.textproto": "",
+		"odd/[x].textproto": "",
+	})
+	t.Chdir(dir)
+
+	expanded, err := Expand([]string{"tests/*.textproto", "odd/[x].textproto", "missing"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join("tests", "a.textproto"),
+		filepath.Join("tests", "b.textproto"),
+		"odd/[x].textproto",
+		"missing",
+	}, expanded)
+
+	_, err = Expand([]string{"tests/*.json"}, true)
+	assert.EqualError(t, err, `no path matches "tests/*.json"`)
+}
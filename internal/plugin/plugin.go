@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package plugin runs external binaries transforming the loaded manifests.
+//
+// A plugin is executed once per manifest. It reads a PluginRequest from its
+// standard input and writes a PluginResponse to its standard output, both in
+// the protobuf binary encoding, like protoc plugins do.
+package plugin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// maxStderr is the maximum number of bytes of the plugin standard error
+// reported when it fails.
+const maxStderr = 4 << 10
+
+// Plugin is an external manifest transformer.
+type Plugin struct {
+	args []string
+}
+
+// New creates a plugin running the given command line, the binary path
+// optionally followed by arguments.
+func New(command string) (*Plugin, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("empty plugin command")
+	}
+
+	return &Plugin{args: args}, nil
+}
+
+// Transform sends the manifest to the plugin and replaces it with the
+// transformed one.
+func (p *Plugin) Transform(m *extproctorv1.TestManifest, path string) error {
+	req, err := proto.Marshal(&extproctorv1.PluginRequest{
+		Manifest:   m,
+		SourcePath: path,
+	})
+	if err != nil {
+		return fmt.Errorf("plugin %s: failed to encode request: %w", p.args[0], err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(p.args[0], p.args[1:]...)
+	cmd.Stdin = bytes.NewReader(req)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(truncate(stderr.String())); msg != "" {
+			return fmt.Errorf("plugin %s: %w: %s", p.args[0], err, msg)
+		}
+		return fmt.Errorf("plugin %s: %w", p.args[0], err)
+	}
+
+	var resp extproctorv1.PluginResponse
+	if err := proto.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return fmt.Errorf("plugin %s: failed to decode response: %w", p.args[0], err)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("plugin %s: %s", p.args[0], resp.Error)
+	}
+	if resp.Manifest == nil {
+		return fmt.Errorf("plugin %s: response has no manifest", p.args[0])
+	}
+
+	proto.Reset(m)
+	proto.Merge(m, resp.Manifest)
+
+	return nil
+}
+
+// truncate limits the reported plugin standard error.
+func truncate(s string) string {
+	if len(s) <= maxStderr {
+		return s
+	}
+	return s[:maxStderr] + "..."
+}
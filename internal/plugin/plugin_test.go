@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// pluginModeEnv makes the test binary behave as a plugin.
+const pluginModeEnv = "EXTPROCTOR_TEST_PLUGIN_MODE"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(pluginModeEnv); mode != "" {
+		runFakePlugin(mode)
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// runFakePlugin implements the plugin protocol for the tests.
+func runFakePlugin(mode string) {
+	data, _ := io.ReadAll(os.Stdin)
+	var req extproctorv1.PluginRequest
+	if err := proto.Unmarshal(data, &req); err != nil {
+		os.Exit(2)
+	}
+
+	var resp extproctorv1.PluginResponse
+	switch mode {
+	case "generate":
+		resp.Manifest = req.Manifest
+		resp.Manifest.TestCases = append(resp.Manifest.TestCases, &extproctorv1.TestCase{
+			Name:        "generated",
+			Description: req.SourcePath,
+		})
+	case "error":
+		resp.Error = "secret not found"
+	case "crash":
+		fmt.Fprintln(os.Stderr, "plugin crashed")
+		os.Exit(1)
+	case "garbage":
+		_, _ = os.Stdout.WriteString("not a protobuf message")
+		os.Exit(0)
+	}
+
+	out, _ := proto.Marshal(&resp)
+	_, _ = os.Stdout.Write(out)
+	os.Exit(0)
+}
+
+func TestNew_EmptyCommand(t *testing.T) {
+	_, err := New("  ")
+	assert.EqualError(t, err, "empty plugin command")
+}
+
+func TestPlugin_Transform(t *testing.T) {
+	t.Setenv(pluginModeEnv, "generate")
+	p, err := New(os.Args[0])
+	require.NoError(t, err)
+
+	m := &extproctorv1.TestManifest{
+		Name:      "suite",
+		TestCases: []*extproctorv1.TestCase{{Name: "existing"}},
+	}
+	require.NoError(t, p.Transform(m, "tests/suite.textproto"))
+
+	assert.Equal(t, "suite", m.Name)
+	if assert.Len(t, m.TestCases, 2) {
+		assert.Equal(t, "existing", m.TestCases[0].Name)
+		assert.Equal(t, "generated", m.TestCases[1].Name)
+		assert.Equal(t, "tests/suite.textproto", m.TestCases[1].Description)
+	}
+}
+
+func TestPlugin_Transform_Errors(t *testing.T) {
+	tests := []struct {
+		mode    string
+		wantErr string
+	}{
+		{mode: "error", wantErr: ": secret not found"},
+		{mode: "crash", wantErr: ": exit status 1: plugin crashed"},
+		{mode: "garbage", wantErr: ": failed to decode response"},
+		{mode: "empty", wantErr: ": response has no manifest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			t.Setenv(pluginModeEnv, tt.mode)
+			p, err := New(os.Args[0])
+			require.NoError(t, err)
+
+			m := &extproctorv1.TestManifest{Name: "suite"}
+			err = p.Transform(m, "suite.textproto")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.Equal(t, "suite", m.Name)
+		})
+	}
+}
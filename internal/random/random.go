@@ -0,0 +1,243 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package random renders the randomized inputs of test requests: template
+// functions drawing values from a per-test generator, seeded from the run
+// seed, so that a failing run can be replayed with the same inputs.
+package random
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// maxStringLength bounds the length of random strings.
+const maxStringLength = 1 << 20
+
+// alphabet is the alphabet of random strings.
+const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// templateDelim marks the strings rendered as templates.
+const templateDelim = "{{"
+
+// NewSeed returns a random run seed.
+func NewSeed() uint64 {
+	return rand.Uint64()
+}
+
+// ForTest returns the generator of a test: it depends on the run seed and the
+// test ID only, so the inputs of a test do not depend on the other tests nor
+// on the execution order.
+func ForTest(seed uint64, id string) *rand.Rand {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(id))
+	return rand.New(rand.NewPCG(seed, h.Sum64()))
+}
+
+// Funcs returns the template functions drawing values from the generator.
+func Funcs(rng *rand.Rand) template.FuncMap {
+	return template.FuncMap{
+		// random_string returns n random alphanumeric characters
+		"random_string": func(n int) (string, error) {
+			if n < 0 || n > maxStringLength {
+				return "", fmt.Errorf("random_string: length %d out of range [0, %d]", n, maxStringLength)
+			}
+			b := make([]byte, n)
+			for i := range b {
+				b[i] = alphabet[rng.IntN(len(alphabet))]
+			}
+			return string(b), nil
+		},
+		// random_int returns a random integer between a and b inclusive
+		"random_int": func(a, b int) (int, error) {
+			if a > b {
+				return 0, fmt.Errorf("random_int: empty range [%d, %d]", a, b)
+			}
+			span := uint64(b) - uint64(a)
+			if span == math.MaxUint64 {
+				return int(rng.Uint64()), nil
+			}
+			return a + int(rng.Uint64N(span+1)), nil
+		},
+	}
+}
+
+// IsTemplated reports whether a request uses template functions.
+func IsTemplated(req *extproctorv1.HttpRequest) bool {
+	templated := false
+	visit(req.ProtoReflect(), func(s string) {
+		templated = templated || strings.Contains(s, templateDelim)
+	})
+	return templated
+}
+
+// Validate parses the templates of a request.
+func Validate(req *extproctorv1.HttpRequest) error {
+	var errs []error
+	visit(req.ProtoReflect(), func(s string) {
+		if strings.Contains(s, templateDelim) {
+			if _, err := parse(s, nil); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// Render returns the request with its templates executed with the values of
+// the generator, or the request itself when it has none. Templates are
+// executed in field order, so that a generator yields the same request.
+func Render(req *extproctorv1.HttpRequest, rng *rand.Rand) (*extproctorv1.HttpRequest, error) {
+	if !IsTemplated(req) {
+		return req, nil
+	}
+
+	funcs := Funcs(rng)
+	rendered := proto.Clone(req).(*extproctorv1.HttpRequest)
+	if err := walk(rendered.ProtoReflect(), func(s string) (string, error) {
+		if !strings.Contains(s, templateDelim) {
+			return s, nil
+		}
+		tmpl, err := parse(s, funcs)
+		if err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, nil); err != nil {
+			return "", fmt.Errorf("failed to render request template: %w", err)
+		}
+		return buf.String(), nil
+	}); err != nil {
+		return nil, err
+	}
+	return rendered, nil
+}
+
+// parse parses a template, with placeholder functions when none are given.
+func parse(s string, funcs template.FuncMap) (*template.Template, error) {
+	if funcs == nil {
+		funcs = Funcs(rand.New(rand.NewPCG(0, 0)))
+	}
+	tmpl, err := template.New("request").Option("missingkey=error").Funcs(funcs).Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request template %q: %w", s, err)
+	}
+	return tmpl, nil
+}
+
+// walk replaces the strings and UTF-8 bytes of a message, recursively, in field
+// number order.
+func walk(m protoreflect.Message, fn func(string) (string, error)) error {
+	fields := m.Descriptor().Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		if !m.Has(fd) {
+			continue
+		}
+
+		switch {
+		case fd.IsMap():
+			mv := m.Mutable(fd).Map()
+			if fd.MapValue().Kind() != protoreflect.StringKind {
+				continue
+			}
+			// Walk the keys in order, for determinism
+			var keys []string
+			mv.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+				keys = append(keys, k.String())
+				return true
+			})
+			slices.Sort(keys)
+			for _, k := range keys {
+				mk := protoreflect.ValueOfString(k).MapKey()
+				v, err := fn(mv.Get(mk).String())
+				if err != nil {
+					return err
+				}
+				mv.Set(mk, protoreflect.ValueOfString(v))
+			}
+		case fd.IsList():
+			list := m.Mutable(fd).List()
+			for j := range list.Len() {
+				switch fd.Kind() {
+				case protoreflect.StringKind:
+					v, err := fn(list.Get(j).String())
+					if err != nil {
+						return err
+					}
+					list.Set(j, protoreflect.ValueOfString(v))
+				case protoreflect.MessageKind:
+					if err := walk(list.Get(j).Message(), fn); err != nil {
+						return err
+					}
+				}
+			}
+		case fd.Kind() == protoreflect.StringKind:
+			v, err := fn(m.Get(fd).String())
+			if err != nil {
+				return err
+			}
+			m.Set(fd, protoreflect.ValueOfString(v))
+		case fd.Kind() == protoreflect.BytesKind:
+			if !utf8.Valid(m.Get(fd).Bytes()) {
+				continue
+			}
+			v, err := fn(string(m.Get(fd).Bytes()))
+			if err != nil {
+				return err
+			}
+			m.Set(fd, protoreflect.ValueOfBytes([]byte(v)))
+		case fd.Kind() == protoreflect.MessageKind:
+			if err := walk(m.Mutable(fd).Message(), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// visit calls fn with the strings and UTF-8 bytes of a message, recursively, without
+// modifying it.
+func visit(m protoreflect.Message, fn func(string)) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Kind() == protoreflect.StringKind {
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					fn(mv.String())
+					return true
+				})
+			}
+		case fd.IsList():
+			for j := range v.List().Len() {
+				switch fd.Kind() {
+				case protoreflect.StringKind:
+					fn(v.List().Get(j).String())
+				case protoreflect.MessageKind:
+					visit(v.List().Get(j).Message(), fn)
+				}
+			}
+		case fd.Kind() == protoreflect.StringKind:
+			fn(v.String())
+		case fd.Kind() == protoreflect.BytesKind:
+			if utf8.Valid(v.Bytes()) {
+				fn(string(v.Bytes()))
+			}
+		case fd.Kind() == protoreflect.MessageKind:
+			visit(v.Message(), fn)
+		}
+		return true
+	})
+}
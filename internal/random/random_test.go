@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package random
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func templatedRequest() *extproctorv1.HttpRequest {
+	return &extproctorv1.HttpRequest{
+		Method:  "POST",
+		Path:    "/users/{{ random_int 1 1000 }}",
+		Headers: map[string]string{"x-request-id": "{{ random_string 12 }}", "x-static": "static"},
+		HeaderEntries: []*extproctorv1.HeaderEntry{
+			{Key: "x-nonce", Value: "{{ random_string 8 }}"},
+		},
+		Body: []byte(`{"name":"{{ random_string 6 }}"}`),
+	}
+}
+
+func TestFuncs(t *testing.T) {
+	funcs := Funcs(ForTest(42, "tests/a.textproto::t"))
+	randomString := funcs["random_string"].(func(int) (string, error))
+	randomInt := funcs["random_int"].(func(int, int) (int, error))
+
+	s, err := randomString(32)
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[a-zA-Z0-9]{32}$`), s)
+
+	for range 100 {
+		n, err := randomInt(-2, 2)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, n, -2)
+		assert.LessOrEqual(t, n, 2)
+	}
+
+	n, err := randomInt(7, 7)
+	require.NoError(t, err)
+	assert.Equal(t, 7, n)
+
+	_, err = randomInt(math.MinInt, math.MaxInt)
+	assert.NoError(t, err)
+
+	_, err = randomInt(2, 1)
+	assert.EqualError(t, err, "random_int: empty range [2, 1]")
+	_, err = randomString(-1)
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	req := templatedRequest()
+
+	rendered, err := Render(req, ForTest(42, "tests/a.textproto::t"))
+	require.NoError(t, err)
+
+	id, err := strconv.Atoi(rendered.Path[len("/users/"):])
+	require.NoError(t, err)
+	assert.True(t, id >= 1 && id <= 1000)
+	assert.Regexp(t, `^[a-zA-Z0-9]{12}$`, rendered.Headers["x-request-id"])
+	assert.Equal(t, "static", rendered.Headers["x-static"])
+	assert.Regexp(t, `^[a-zA-Z0-9]{8}$`, rendered.HeaderEntries[0].Value)
+	assert.Regexp(t, `^\{"name":"[a-zA-Z0-9]{6}"\}$`, string(rendered.Body))
+
+	// The source request is left untouched
+	assert.Equal(t, "/users/{{ random_int 1 1000 }}", req.Path)
+
+	// Same seed and test, same request
+	again, err := Render(req, ForTest(42, "tests/a.textproto::t"))
+	require.NoError(t, err)
+	assert.Equal(t, rendered.String(), again.String())
+
+	// Another seed or test draws other values
+	other, err := Render(req, ForTest(43, "tests/a.textproto::t"))
+	require.NoError(t, err)
+	assert.NotEqual(t, rendered.Headers["x-request-id"], other.Headers["x-request-id"])
+	other, err = Render(req, ForTest(42, "tests/a.textproto::u"))
+	require.NoError(t, err)
+	assert.NotEqual(t, rendered.Headers["x-request-id"], other.Headers["x-request-id"])
+}
+
+func TestRender_NotTemplated(t *testing.T) {
+	req := &extproctorv1.HttpRequest{Method: "GET", Path: "/", Body: []byte{0xff, '{', '{'}}
+	assert.False(t, IsTemplated(req))
+
+	rendered, err := Render(req, ForTest(1, "t"))
+	require.NoError(t, err)
+	assert.Same(t, req, rendered)
+}
+
+func TestRender_Error(t *testing.T) {
+	req := &extproctorv1.HttpRequest{Method: "GET", Path: "/{{ random_int 5 1 }}"}
+	_, err := Render(req, ForTest(1, "t"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "random_int: empty range [5, 1]")
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(templatedRequest()))
+
+	err := Validate(&extproctorv1.HttpRequest{
+		Path:    "/{{ random_int 1 }",
+		Headers: map[string]string{"x-id": "{{ uuid }}"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid request template "/{{ random_int 1 }"`)
+	assert.Contains(t, err.Error(), `function "uuid" not defined`)
+}
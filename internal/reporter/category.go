@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package reporter
+
+// Category classifies the failure of a test, to tell a broken filter apart
+// from a broken infrastructure.
+type Category string
+
+const (
+	// CategoryAssertion is a response of the filter not matching the
+	// expectations.
+	CategoryAssertion Category = "assertion_mismatch"
+
+	// CategoryGoldenMissing is a golden file of the test not existing.
+	CategoryGoldenMissing Category = "golden_missing"
+
+	// CategoryTestError is a test case that cannot run, e.g. an invalid
+	// request template or golden file.
+	CategoryTestError Category = "test_error"
+
+	// CategoryProtocol is the ExtProc service failing the processing stream
+	// or breaking the protocol.
+	CategoryProtocol Category = "protocol_error"
+
+	// CategoryTimeout is the ExtProc service not answering in time.
+	CategoryTimeout Category = "timeout"
+
+	// CategoryConnection is the ExtProc service not being reachable.
+	CategoryConnection Category = "connection_error"
+)
+
+// Categories lists the failure categories, the failures of the filter and
+// the tests first.
+var Categories = []Category{
+	CategoryAssertion,
+	CategoryGoldenMissing,
+	CategoryTestError,
+	CategoryProtocol,
+	CategoryTimeout,
+	CategoryConnection,
+}
+
+// Infra reports whether failures of the category come from the
+// infrastructure rather than from the filter or the tests.
+func (c Category) Infra() bool {
+	return c == CategoryTimeout || c == CategoryConnection
+}
+
+// CategoryCount is the number of failures of a category.
+type CategoryCount struct {
+	Category Category
+	Count    int
+}
+
+// CountCategories returns the number of failures of each category, in the
+// order of Categories, omitting the categories without failure.
+func CountCategories(categories []Category) []CategoryCount {
+	counts := make(map[Category]int, len(Categories))
+	for _, c := range categories {
+		counts[c]++
+	}
+
+	var out []CategoryCount
+	for _, c := range Categories {
+		if counts[c] > 0 {
+			out = append(out, CategoryCount{Category: c, Count: counts[c]})
+		}
+	}
+	return out
+}
+
+// InfraFailures returns the number of failures of the infrastructure
+// categories.
+func InfraFailures(counts []CategoryCount) int {
+	var total int
+	for _, c := range counts {
+		if c.Category.Infra() {
+			total += c.Count
+		}
+	}
+	return total
+}
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package reporter
+
+import (
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// Event is a structured event of a run, reported between the start and the
+// end of the tests: PhaseCompleted, GoldenUpdated, RetryAttempted or
+// TargetUnhealthy. Errors are reported as values, to be inspected with
+// errors.Is and errors.As.
+type Event interface {
+	event()
+}
+
+// TestRef identifies the test an event is about.
+type TestRef struct {
+	// ID is the stable test ID (see TestResult.ID).
+	ID string
+
+	// Name is the name of the test case.
+	Name string
+
+	// Target is the name of the target the test runs against.
+	Target string
+}
+
+// PhaseCompleted is reported when the ExtProc service answered a processing
+// phase of a test.
+type PhaseCompleted struct {
+	Test TestRef
+
+	Phase extproctorv1.ProcessingPhase
+
+	// Chunk is the index of the body chunk, for body phases.
+	Chunk int
+
+	// Latency is the time the service took to answer.
+	Latency time.Duration
+
+	Response *extprocv3.ProcessingResponse
+}
+
+// GoldenUpdated is reported when --update-golden rewrote the golden files of
+// a test, or failed to.
+type GoldenUpdated struct {
+	Test TestRef
+
+	// Path is the golden path of the test, with the placeholders other than
+	// {phase} resolved.
+	Path string
+
+	// Err is the error of the update, nil when the golden files were written.
+	Err error
+}
+
+// RetryAttempted is reported when a gRPC call of a test failed because the
+// service was unavailable, and is retried.
+type RetryAttempted struct {
+	Test TestRef
+
+	// Attempt is the number of the retry, from 1.
+	Attempt int
+
+	// Err is the error of the failed call.
+	Err error
+}
+
+// TargetUnhealthy is reported when the health checks of a target find it not
+// serving.
+type TargetUnhealthy struct {
+	// Target is the name of the target.
+	Target string
+
+	// Err is the error of the health check, or the serving status reported
+	// by the target.
+	Err error
+}
+
+func (PhaseCompleted) event()  {}
+func (GoldenUpdated) event()   {}
+func (RetryAttempted) event()  {}
+func (TargetUnhealthy) event() {}
@@ -6,10 +6,13 @@ package reporter
 import (
 	"fmt"
 	"io"
+	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/fatih/color"
 	"zntr.io/extproctor/internal/comparator"
+	"zntr.io/extproctor/internal/units"
 )
 
 // HumanReporter outputs test results in a human-readable format.
@@ -17,22 +20,83 @@ type HumanReporter struct {
 	out     io.Writer
 	verbose bool
 
+	// groupByOwner buffers the test results and prints them grouped by
+	// manifest owner at the end of the suite.
+	groupByOwner bool
+	buffered     []TestResult
+
+	// width wraps the difference values to the terminal width, 0 disables
+	// wrapping.
+	width int
+
+	durations units.DurationFormat
+
 	passColor *color.Color
 	failColor *color.Color
 	skipColor *color.Color
 	dimColor  *color.Color
 }
 
+// HumanOption configures the human-readable reporter.
+type HumanOption func(*HumanReporter)
+
+// WithGroupByOwner prints the test results grouped by manifest owner once the
+// suite completes, instead of as they finish.
+func WithGroupByOwner() HumanOption {
+	return func(r *HumanReporter) {
+		r.groupByOwner = true
+	}
+}
+
+// WithNoColor disables the colors of the output, e.g. for CI log viewers and
+// screen readers.
+func WithNoColor() HumanOption {
+	return func(r *HumanReporter) {
+		for _, c := range []*color.Color{r.passColor, r.failColor, r.skipColor, r.dimColor} {
+			c.DisableColor()
+		}
+	}
+}
+
+// WithASCII restricts the output to ASCII: ellipses and dashes are spelled
+// out, other non-ASCII characters are escaped (\u00e9).
+func WithASCII() HumanOption {
+	return func(r *HumanReporter) {
+		r.out = &asciiWriter{out: r.out}
+	}
+}
+
+// WithWidth wraps the difference values longer than the terminal width.
+func WithWidth(width int) HumanOption {
+	return func(r *HumanReporter) {
+		r.width = width
+	}
+}
+
+// WithDurationFormat sets the format of the durations.
+func WithDurationFormat(format units.DurationFormat) HumanOption {
+	return func(r *HumanReporter) {
+		r.durations = format
+	}
+}
+
 // NewHumanReporter creates a new human-readable reporter.
-func NewHumanReporter(out io.Writer, verbose bool) *HumanReporter {
-	return &HumanReporter{
+func NewHumanReporter(out io.Writer, verbose bool, opts ...HumanOption) *HumanReporter {
+	r := &HumanReporter{
 		out:       out,
 		verbose:   verbose,
 		passColor: color.New(color.FgGreen),
 		failColor: color.New(color.FgRed),
 		skipColor: color.New(color.FgYellow),
 		dimColor:  color.New(color.Faint),
+		durations: units.DurationHuman,
 	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
 }
 
 // StartSuite implements Reporter.
@@ -42,13 +106,27 @@ func (r *HumanReporter) StartSuite(total int) {
 
 // StartTest implements Reporter.
 func (r *HumanReporter) StartTest(name string) {
-	if r.verbose {
+	if r.verbose && !r.groupByOwner {
 		_, _ = fmt.Fprintf(r.out, "  %s ", name)
 	}
 }
 
 // EndTest implements Reporter.
 func (r *HumanReporter) EndTest(result TestResult) {
+	if r.groupByOwner {
+		r.buffered = append(r.buffered, result)
+		return
+	}
+
+	r.printTest(result, r.verbose)
+}
+
+// Event implements Reporter. The human output only reports the test results.
+func (r *HumanReporter) Event(Event) {}
+
+// printTest prints a test result. The inline form completes the line started
+// by StartTest in verbose mode.
+func (r *HumanReporter) printTest(result TestResult, inline bool) {
 	var status string
 	var statusColor *color.Color
 
@@ -64,19 +142,40 @@ func (r *HumanReporter) EndTest(result TestResult) {
 		statusColor = r.failColor
 	}
 
-	if r.verbose {
+	if inline {
 		_, _ = statusColor.Fprintf(r.out, "[%s]", status)
-		_, _ = r.dimColor.Fprintf(r.out, " (%s)\n", result.Duration)
+		_, _ = r.dimColor.Fprintf(r.out, " (%s)\n", r.durations.Format(result.Duration))
 	} else {
 		// Compact output
-		_, _ = statusColor.Fprintf(r.out, "  [%s] %s", status, result.Name)
-		_, _ = r.dimColor.Fprintf(r.out, " (%s)\n", result.Duration)
+		_, _ = statusColor.Fprintf(r.out, "  [%s] %s", status, DisplayName(result.Name, result.Target))
+		_, _ = r.dimColor.Fprintf(r.out, " (%s)\n", r.durations.Format(result.Duration))
+	}
+
+	if result.Skipped && result.SkipReason != "" {
+		_, _ = r.dimColor.Fprintf(r.out, "    Reason: %s\n", result.SkipReason)
+	}
+
+	// Show the test ID of failures, to correlate them with the ExtProc service
+	// logs and traces
+	if !result.Passed && !result.Skipped && result.ID != "" {
+		if result.UID != "" {
+			_, _ = r.dimColor.Fprintf(r.out, "    Test ID: %s (UID %s)\n", result.ID, result.UID)
+		} else {
+			_, _ = r.dimColor.Fprintf(r.out, "    Test ID: %s\n", result.ID)
+		}
 	}
 
 	// Show error if present
 	if result.Error != nil {
 		_, _ = r.failColor.Fprintf(r.out, "    Error: %v\n", result.Error)
 	}
+	if result.Category != "" && result.Category != CategoryAssertion {
+		_, _ = r.dimColor.Fprintf(r.out, "    Category: %s\n", result.Category)
+	}
+
+	for _, note := range result.Notes {
+		_, _ = r.dimColor.Fprintf(r.out, "    Note: %s\n", note)
+	}
 
 	// Show differences for failed tests
 	if !result.Passed && !result.Skipped {
@@ -84,8 +183,8 @@ func (r *HumanReporter) EndTest(result TestResult) {
 			_, _ = fmt.Fprintln(r.out, "    Differences:")
 			for _, d := range result.Differences {
 				_, _ = fmt.Fprintf(r.out, "      [%s] %s:\n", comparator.FormatDifferences([]comparator.Difference{d}), d.Path)
-				_, _ = r.failColor.Fprintf(r.out, "        expected: %s\n", d.Expected)
-				_, _ = r.passColor.Fprintf(r.out, "        actual:   %s\n", d.Actual)
+				_, _ = r.failColor.Fprintf(r.out, "        expected: %s\n", r.wrap(d.Expected, 18))
+				_, _ = r.passColor.Fprintf(r.out, "        actual:   %s\n", r.wrap(d.Actual, 18))
 			}
 		}
 
@@ -93,6 +192,23 @@ func (r *HumanReporter) EndTest(result TestResult) {
 			_, _ = fmt.Fprintln(r.out, "    Unmatched expectations:")
 			for _, exp := range result.Unmatched {
 				_, _ = fmt.Fprintf(r.out, "      - Phase: %s, Type: %T\n", exp.Phase, exp.Response)
+				if reason, ok := result.UnmatchedReasons[exp]; ok {
+					_, _ = r.dimColor.Fprintf(r.out, "        %s\n", reason)
+				}
+			}
+		}
+
+		if len(result.SkippedPhases) > 0 {
+			_, _ = fmt.Fprintln(r.out, "    Skipped phases (stream ended by an immediate response):")
+			for _, phase := range result.SkippedPhases {
+				_, _ = fmt.Fprintf(r.out, "      - Phase: %s\n", phase)
+			}
+		}
+
+		if len(result.SuppressedPhases) > 0 {
+			_, _ = fmt.Fprintln(r.out, "    Suppressed phases (disabled by a mode_override):")
+			for _, phase := range result.SuppressedPhases {
+				_, _ = fmt.Fprintf(r.out, "      - Phase: %s\n", phase)
 			}
 		}
 
@@ -102,11 +218,106 @@ func (r *HumanReporter) EndTest(result TestResult) {
 				_, _ = fmt.Fprintf(r.out, "      - Phase: %s, Type: %T\n", resp.Phase, resp.Response.Response)
 			}
 		}
+
+		if len(result.Logs) > 0 {
+			_, _ = fmt.Fprintln(r.out, "    Filter logs:")
+			for _, line := range result.Logs {
+				_, _ = r.dimColor.Fprintf(r.out, "      %s\n", line)
+			}
+		}
+	}
+}
+
+// printGroups prints the statistics of a breakdown, one line per group.
+func (r *HumanReporter) printGroups(title string, groups []GroupStats) {
+	_, _ = fmt.Fprintf(r.out, "\n%s\n", title)
+	for _, g := range groups {
+		_, _ = fmt.Fprintf(r.out, "  %s: ", g.Name)
+		_, _ = r.passColor.Fprintf(r.out, "%d passed", g.Passed)
+		_, _ = fmt.Fprintf(r.out, ", ")
+		if g.Failed > 0 {
+			_, _ = r.failColor.Fprintf(r.out, "%d failed", g.Failed)
+		} else {
+			_, _ = fmt.Fprintf(r.out, "%d failed", g.Failed)
+		}
+		if g.Skipped > 0 {
+			_, _ = fmt.Fprintf(r.out, ", ")
+			_, _ = r.skipColor.Fprintf(r.out, "%d skipped", g.Skipped)
+		}
+		_, _ = r.dimColor.Fprintf(r.out, " (%s)\n", r.durations.Format(g.Duration))
+	}
+}
+
+// printMatrix prints the status of the tests not passing on every target,
+// one line per test.
+func (r *HumanReporter) printMatrix(targets []string, matrix []MatrixRow) {
+	var divergent []MatrixRow
+	for _, row := range matrix {
+		for _, status := range row.Statuses {
+			if status != "passed" {
+				divergent = append(divergent, row)
+				break
+			}
+		}
+	}
+	if len(divergent) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintln(r.out, "\nTarget matrix (tests not passing on every target):")
+	for _, row := range divergent {
+		_, _ = fmt.Fprintf(r.out, "  %s:", row.Name)
+		for i, status := range row.Statuses {
+			if i > 0 {
+				_, _ = fmt.Fprint(r.out, ",")
+			}
+			_, _ = fmt.Fprintf(r.out, " %s ", targets[i])
+			switch status {
+			case "passed":
+				_, _ = r.passColor.Fprint(r.out, "PASS")
+			case "failed":
+				_, _ = r.failColor.Fprint(r.out, "FAIL")
+			case "skipped":
+				_, _ = r.skipColor.Fprint(r.out, "SKIP")
+			default:
+				_, _ = r.dimColor.Fprint(r.out, "-")
+			}
+		}
+		_, _ = r.dimColor.Fprintf(r.out, " (%s)\n", row.ID)
+	}
+}
+
+// printGroupedByOwner prints the buffered test results grouped by owner.
+func (r *HumanReporter) printGroupedByOwner() {
+	groups := map[string][]TestResult{}
+	var owners []string
+	for _, result := range r.buffered {
+		owner := result.Owner
+		if owner == "" {
+			owner = UnownedGroup
+		}
+		if _, ok := groups[owner]; !ok {
+			owners = append(owners, owner)
+		}
+		groups[owner] = append(groups[owner], result)
+	}
+	sort.Strings(owners)
+
+	for _, owner := range owners {
+		_, _ = fmt.Fprintf(r.out, "Owner: %s\n", owner)
+		for _, result := range groups[owner] {
+			r.printTest(result, false)
+		}
+		_, _ = fmt.Fprintln(r.out)
 	}
 }
 
 // EndSuite implements Reporter.
 func (r *HumanReporter) EndSuite(summary SuiteSummary) {
+	if r.groupByOwner {
+		r.printGroupedByOwner()
+	}
+
 	_, _ = fmt.Fprintln(r.out, strings.Repeat("-", 60))
 
 	// Summary line
@@ -123,9 +334,36 @@ func (r *HumanReporter) EndSuite(summary SuiteSummary) {
 		_, _ = r.skipColor.Fprintf(r.out, "%d skipped", summary.Skipped)
 	}
 	_, _ = fmt.Fprintf(r.out, " of %d total\n", summary.Total)
+	if len(summary.Failures) > 0 {
+		r.printFailures(summary.Failures)
+	}
 
 	// Duration
-	_, _ = r.dimColor.Fprintf(r.out, "Duration: %s\n", summary.Duration)
+	_, _ = r.dimColor.Fprintf(r.out, "Duration: %s\n", r.durations.Format(summary.Duration))
+	if summary.Randomized {
+		_, _ = r.dimColor.Fprintf(r.out, "Seed: %d (replay with --seed %d)\n", summary.Seed, summary.Seed)
+	}
+	if len(summary.Labels) > 0 {
+		_, _ = r.dimColor.Fprintf(r.out, "Labels: %s\n", formatLabels(summary.Labels))
+	}
+	if cost := summary.Cost; cost.Hinted > 0 {
+		_, _ = r.dimColor.Fprintf(r.out, "Cost: %s of bodies, %s declared (%d tests with cost hints)\n", units.FormatSize(cost.BodySize), r.durations.Format(cost.Duration), cost.Hinted)
+	}
+
+	// Breakdowns are only useful when the suite spans several groups
+	if len(summary.ByTag) > 1 {
+		r.printGroups("By tag:", summary.ByTag)
+	}
+	if len(summary.ByManifest) > 1 {
+		r.printGroups("By manifest:", summary.ByManifest)
+	}
+	if len(summary.ByOwner) > 1 {
+		r.printGroups("By owner:", summary.ByOwner)
+	}
+	if len(summary.Targets) > 1 {
+		r.printGroups("By target:", summary.ByTarget)
+		r.printMatrix(summary.Targets, summary.Matrix)
+	}
 
 	// Final status
 	_, _ = fmt.Fprintln(r.out)
@@ -135,3 +373,82 @@ func (r *HumanReporter) EndSuite(summary SuiteSummary) {
 		_, _ = r.passColor.Fprintln(r.out, "PASSED")
 	}
 }
+
+// printFailures prints the failures per category, the infrastructure ones
+// being counted apart.
+func (r *HumanReporter) printFailures(counts []CategoryCount) {
+	parts := make([]string, 0, len(counts))
+	for _, c := range counts {
+		parts = append(parts, fmt.Sprintf("%d %s", c.Count, c.Category))
+	}
+	line := "Failures: " + strings.Join(parts, ", ")
+	if infra := InfraFailures(counts); infra > 0 {
+		line += fmt.Sprintf(" (%d infrastructure)", infra)
+	}
+	_, _ = r.dimColor.Fprintln(r.out, line)
+}
+
+// formatLabels renders the labels of a run as key=value pairs sorted by key.
+func formatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+labels[k])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// wrap breaks a value printed after an indent into lines fitting the width,
+// continuation lines being aligned with the first one.
+func (r *HumanReporter) wrap(value string, indent int) string {
+	size := r.width - indent
+	if r.width <= 0 || size < 20 || utf8.RuneCountInString(value) <= size {
+		return value
+	}
+
+	var sb strings.Builder
+	runes := []rune(value)
+	for len(runes) > size {
+		sb.WriteString(string(runes[:size]))
+		sb.WriteString("\n")
+		sb.WriteString(strings.Repeat(" ", indent))
+		runes = runes[size:]
+	}
+	sb.WriteString(string(runes))
+	return sb.String()
+}
+
+// asciiReplacer spells out the glyphs of the output in ASCII.
+var asciiReplacer = strings.NewReplacer("…", "...", "—", "-", "–", "-", "→", "->")
+
+// asciiWriter escapes the non-ASCII characters written to a writer.
+type asciiWriter struct {
+	out io.Writer
+}
+
+// Write implements io.Writer.
+func (w *asciiWriter) Write(p []byte) (int, error) {
+	s := asciiReplacer.Replace(string(p))
+
+	var sb strings.Builder
+	for _, c := range s {
+		switch {
+		case c < utf8.RuneSelf:
+			sb.WriteRune(c)
+		case c > 0xFFFF:
+			fmt.Fprintf(&sb, "\\U%08x", c)
+		default:
+			fmt.Fprintf(&sb, "\\u%04x", c)
+		}
+	}
+
+	if _, err := io.WriteString(w.out, sb.String()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -10,12 +10,24 @@ import (
 	"time"
 
 	"zntr.io/extproctor/internal/comparator"
+	"zntr.io/extproctor/internal/units"
 )
 
 // JSONReporter outputs test results in JSON format for CI integration.
 type JSONReporter struct {
-	out     io.Writer
-	results *jsonResults
+	out       io.Writer
+	results   *jsonResults
+	durations units.DurationFormat
+}
+
+// JSONOption configures the JSON reporter.
+type JSONOption func(*JSONReporter)
+
+// WithJSONDurationFormat sets the format of the durations.
+func WithJSONDurationFormat(format units.DurationFormat) JSONOption {
+	return func(r *JSONReporter) {
+		r.durations = format
+	}
 }
 
 type jsonResults struct {
@@ -25,18 +37,40 @@ type jsonResults struct {
 }
 
 type jsonTest struct {
+	ID          string           `json:"id,omitempty"`
+	UID         string           `json:"uid,omitempty"`
 	Name        string           `json:"name"`
+	Manifest    string           `json:"manifest,omitempty"`
+	Owner       string           `json:"owner,omitempty"`
+	Target      string           `json:"target,omitempty"`
+	Tags        []string         `json:"tags,omitempty"`
 	Status      string           `json:"status"`
+	SkipReason  string           `json:"skip_reason,omitempty"`
 	Duration    string           `json:"duration"`
 	Error       string           `json:"error,omitempty"`
+	Category    string           `json:"category,omitempty"`
+	Mutations   *jsonMutations   `json:"mutations,omitempty"`
 	Differences []jsonDifference `json:"differences,omitempty"`
 	Unmatched   []jsonUnmatched  `json:"unmatched,omitempty"`
 	Unexpected  []jsonUnexpected `json:"unexpected,omitempty"`
+
+	SkippedPhases    []string `json:"skipped_phases,omitempty"`
+	SuppressedPhases []string `json:"suppressed_phases,omitempty"`
+	Logs             []string `json:"logs,omitempty"`
+	Notes            []string `json:"notes,omitempty"`
+}
+
+type jsonMutations struct {
+	HeadersSet         int   `json:"headers_set"`
+	HeadersRemoved     int   `json:"headers_removed"`
+	BodyBytes          int64 `json:"body_bytes"`
+	ImmediateResponses int   `json:"immediate_responses"`
 }
 
 type jsonUnmatched struct {
 	Phase        string `json:"phase"`
 	ResponseType string `json:"response_type"`
+	Reason       string `json:"reason,omitempty"`
 }
 
 type jsonUnexpected struct {
@@ -49,6 +83,7 @@ type jsonDifference struct {
 	Path     string `json:"path"`
 	Expected string `json:"expected"`
 	Actual   string `json:"actual"`
+	Group    string `json:"group,omitempty"`
 }
 
 type jsonSummary struct {
@@ -57,17 +92,68 @@ type jsonSummary struct {
 	Failed   int    `json:"failed"`
 	Skipped  int    `json:"skipped"`
 	Duration string `json:"duration"`
+
+	// Failures counts the failed tests per failure category, InfraFailed
+	// the ones of the infrastructure categories.
+	Failures    map[string]int `json:"failures,omitempty"`
+	InfraFailed int            `json:"infra_failed,omitempty"`
+
+	ByTag      []jsonGroup `json:"by_tag,omitempty"`
+	ByManifest []jsonGroup `json:"by_manifest,omitempty"`
+	ByOwner    []jsonGroup `json:"by_owner,omitempty"`
+	ByTarget   []jsonGroup `json:"by_target,omitempty"`
+
+	// Targets and Matrix are only reported for fan-out runs.
+	Targets []string        `json:"targets,omitempty"`
+	Matrix  []jsonMatrixRow `json:"matrix,omitempty"`
+
+	// Cost is only reported when a test declares cost hints.
+	Cost *jsonCost `json:"cost,omitempty"`
+
+	// Seed is only reported when a test request used random template
+	// functions.
+	Seed *uint64 `json:"seed,omitempty"`
+
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type jsonCost struct {
+	Hinted   int    `json:"hinted"`
+	BodySize int64  `json:"body_size"`
+	Duration string `json:"duration"`
+}
+
+type jsonMatrixRow struct {
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Results map[string]string `json:"results"`
+}
+
+type jsonGroup struct {
+	Name     string `json:"name"`
+	Total    int    `json:"total"`
+	Passed   int    `json:"passed"`
+	Failed   int    `json:"failed"`
+	Skipped  int    `json:"skipped"`
+	Duration string `json:"duration"`
 }
 
 // NewJSONReporter creates a new JSON reporter.
-func NewJSONReporter(out io.Writer) *JSONReporter {
-	return &JSONReporter{
+func NewJSONReporter(out io.Writer, opts ...JSONOption) *JSONReporter {
+	r := &JSONReporter{
 		out: out,
 		results: &jsonResults{
 			StartTime: time.Now(),
 			Tests:     make([]jsonTest, 0),
 		},
+		durations: units.DurationHuman,
+	}
+
+	for _, opt := range opts {
+		opt(r)
 	}
+
+	return r
 }
 
 // StartSuite implements Reporter.
@@ -82,29 +168,42 @@ func (r *JSONReporter) StartTest(name string) {
 
 // EndTest implements Reporter.
 func (r *JSONReporter) EndTest(result TestResult) {
-	status := "passed"
-	if result.Skipped {
-		status = "skipped"
-	} else if !result.Passed {
-		status = "failed"
-	}
-
 	test := jsonTest{
-		Name:     result.Name,
-		Status:   status,
-		Duration: result.Duration.String(),
+		ID:         result.ID,
+		UID:        result.UID,
+		Name:       result.Name,
+		Manifest:   result.Manifest,
+		Owner:      result.Owner,
+		Target:     result.Target,
+		Tags:       result.Tags,
+		Status:     Status(result.Passed, result.Skipped),
+		SkipReason: result.SkipReason,
+		Duration:   r.durations.Format(result.Duration),
+		Category:   string(result.Category),
+		Logs:       result.Logs,
+		Notes:      result.Notes,
 	}
 
 	if result.Error != nil {
 		test.Error = result.Error.Error()
 	}
 
+	if m := result.Mutations; m != nil {
+		test.Mutations = &jsonMutations{
+			HeadersSet:         m.HeadersSet,
+			HeadersRemoved:     m.HeadersRemoved,
+			BodyBytes:          m.BodyBytes,
+			ImmediateResponses: m.ImmediateResponses,
+		}
+	}
+
 	for _, d := range result.Differences {
 		test.Differences = append(test.Differences, jsonDifference{
 			Phase:    d.Phase.String(),
 			Path:     d.Path,
 			Expected: d.Expected,
 			Actual:   d.Actual,
+			Group:    d.Group,
 		})
 	}
 
@@ -112,6 +211,7 @@ func (r *JSONReporter) EndTest(result TestResult) {
 		test.Unmatched = append(test.Unmatched, jsonUnmatched{
 			Phase:        u.Phase.String(),
 			ResponseType: formatResponseType(u.Response),
+			Reason:       result.UnmatchedReasons[u],
 		})
 	}
 
@@ -122,17 +222,51 @@ func (r *JSONReporter) EndTest(result TestResult) {
 		})
 	}
 
+	for _, phase := range result.SkippedPhases {
+		test.SkippedPhases = append(test.SkippedPhases, phase.String())
+	}
+	for _, phase := range result.SuppressedPhases {
+		test.SuppressedPhases = append(test.SuppressedPhases, phase.String())
+	}
+
 	r.results.Tests = append(r.results.Tests, test)
 }
 
+// Event implements Reporter. The JSON output only reports the test results.
+func (r *JSONReporter) Event(Event) {}
+
 // EndSuite implements Reporter.
 func (r *JSONReporter) EndSuite(summary SuiteSummary) {
 	r.results.Summary = &jsonSummary{
-		Total:    summary.Total,
-		Passed:   summary.Passed,
-		Failed:   summary.Failed,
-		Skipped:  summary.Skipped,
-		Duration: summary.Duration.String(),
+		Total:      summary.Total,
+		Passed:     summary.Passed,
+		Failed:     summary.Failed,
+		Skipped:    summary.Skipped,
+		Duration:   r.durations.Format(summary.Duration),
+		ByTag:      r.formatGroups(summary.ByTag),
+		ByManifest: r.formatGroups(summary.ByManifest),
+		ByOwner:    r.formatGroups(summary.ByOwner),
+		ByTarget:   r.formatGroups(summary.ByTarget),
+		Targets:    summary.Targets,
+		Matrix:     formatMatrix(summary.Targets, summary.Matrix),
+		Labels:     summary.Labels,
+	}
+	if len(summary.Failures) > 0 {
+		r.results.Summary.Failures = make(map[string]int, len(summary.Failures))
+		for _, c := range summary.Failures {
+			r.results.Summary.Failures[string(c.Category)] = c.Count
+		}
+		r.results.Summary.InfraFailed = InfraFailures(summary.Failures)
+	}
+	if summary.Randomized {
+		r.results.Summary.Seed = &summary.Seed
+	}
+	if cost := summary.Cost; cost.Hinted > 0 {
+		r.results.Summary.Cost = &jsonCost{
+			Hinted:   cost.Hinted,
+			BodySize: cost.BodySize,
+			Duration: r.durations.Format(cost.Duration),
+		}
 	}
 
 	encoder := json.NewEncoder(r.out)
@@ -140,6 +274,38 @@ func (r *JSONReporter) EndSuite(summary SuiteSummary) {
 	_ = encoder.Encode(r.results)
 }
 
+// formatGroups converts group statistics for JSON output.
+func (r *JSONReporter) formatGroups(groups []GroupStats) []jsonGroup {
+	var out []jsonGroup
+	for _, g := range groups {
+		out = append(out, jsonGroup{
+			Name:     g.Name,
+			Total:    g.Total,
+			Passed:   g.Passed,
+			Failed:   g.Failed,
+			Skipped:  g.Skipped,
+			Duration: r.durations.Format(g.Duration),
+		})
+	}
+	return out
+}
+
+// formatMatrix converts the target matrix for JSON output, keying the
+// statuses by target.
+func formatMatrix(targets []string, matrix []MatrixRow) []jsonMatrixRow {
+	var out []jsonMatrixRow
+	for _, row := range matrix {
+		results := make(map[string]string, len(targets))
+		for i, status := range row.Statuses {
+			if status != "" && i < len(targets) {
+				results[targets[i]] = status
+			}
+		}
+		out = append(out, jsonMatrixRow{ID: row.ID, Name: row.Name, Results: results})
+	}
+	return out
+}
+
 // FormatDifference formats a difference for JSON output.
 func FormatDifference(d comparator.Difference) jsonDifference {
 	return jsonDifference{
@@ -147,6 +313,7 @@ func FormatDifference(d comparator.Difference) jsonDifference {
 		Path:     d.Path,
 		Expected: d.Expected,
 		Actual:   d.Actual,
+		Group:    d.Group,
 	}
 }
 
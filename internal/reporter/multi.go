@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package reporter
+
+// MultiReporter forwards the reporter calls to several reporters, in order.
+type MultiReporter struct {
+	reporters []Reporter
+}
+
+// NewMultiReporter creates a reporter forwarding to the given reporters.
+func NewMultiReporter(reporters ...Reporter) *MultiReporter {
+	return &MultiReporter{reporters: reporters}
+}
+
+// StartSuite implements Reporter.
+func (m *MultiReporter) StartSuite(total int) {
+	for _, r := range m.reporters {
+		r.StartSuite(total)
+	}
+}
+
+// StartTest implements Reporter.
+func (m *MultiReporter) StartTest(name string) {
+	for _, r := range m.reporters {
+		r.StartTest(name)
+	}
+}
+
+// EndTest implements Reporter.
+func (m *MultiReporter) EndTest(result TestResult) {
+	for _, r := range m.reporters {
+		r.EndTest(result)
+	}
+}
+
+// Event implements Reporter.
+func (m *MultiReporter) Event(event Event) {
+	for _, r := range m.reporters {
+		r.Event(event)
+	}
+}
+
+// EndSuite implements Reporter.
+func (m *MultiReporter) EndSuite(summary SuiteSummary) {
+	for _, r := range m.reporters {
+		r.EndSuite(summary)
+	}
+}
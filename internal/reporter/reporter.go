@@ -22,20 +22,57 @@ type Reporter interface {
 	// EndTest is called when a test completes.
 	EndTest(result TestResult)
 
+	// Event is called when something happens during the run, besides the
+	// start and end of the tests (see Event). Events are reported one at a
+	// time, but may be reported while another test starts or ends.
+	Event(event Event)
+
 	// EndSuite is called when the test suite completes.
 	EndSuite(summary SuiteSummary)
 }
 
 // TestResult contains the result of a single test.
 type TestResult struct {
+	ID          string
+	UID         string
 	Name        string
+	Manifest    string
+	Owner       string
+	Target      string
+	Tags        []string
 	Passed      bool
 	Skipped     bool
+	SkipReason  string
 	Duration    time.Duration
 	Error       error
 	Differences []comparator.Difference
 	Unmatched   []*extproctorv1.ExtProcExpectation
 	Unexpected  []*client.PhaseResponse
+
+	// Category classifies the failure of a failed test.
+	Category Category
+
+	// Mutations counts what the ExtProc service changed, nil when the test
+	// did not reach it.
+	Mutations *Mutations
+
+	// SkippedPhases lists the phases never sent because of an immediate response.
+	SkippedPhases []extproctorv1.ProcessingPhase
+
+	// SuppressedPhases lists the phases never sent because a mode_override
+	// of the ExtProc service disabled them.
+	SuppressedPhases []extproctorv1.ProcessingPhase
+
+	// UnmatchedReasons explains unmatched expectations whose phase was never sent.
+	UnmatchedReasons map[*extproctorv1.ExtProcExpectation]string
+
+	// Logs contains the ExtProc service log lines written while a failed
+	// test ran.
+	Logs []string
+
+	// Notes annotates the result, e.g. when the target was not serving while
+	// the test ran.
+	Notes []string
 }
 
 // SuiteSummary contains the summary of the entire test suite.
@@ -45,4 +82,109 @@ type SuiteSummary struct {
 	Failed   int
 	Skipped  int
 	Duration time.Duration
+
+	// ByTag, ByManifest, ByOwner and ByTarget break the results down per
+	// test tag, per manifest, per manifest owner and per fan-out target,
+	// sorted by name. A test counts once for each of its tags.
+	ByTag      []GroupStats
+	ByManifest []GroupStats
+	ByOwner    []GroupStats
+	ByTarget   []GroupStats
+
+	// Targets lists the fan-out targets in order, and Matrix the status of
+	// each test on each of them.
+	Targets []string
+	Matrix  []MatrixRow
+
+	// Cost aggregates the resource hints of the tests, reported when a test
+	// declares some.
+	Cost Cost
+
+	// Failures counts the failed tests per failure category, in the order of
+	// Categories.
+	Failures []CategoryCount
+
+	// Seed is the seed of the random template functions, to replay the run
+	// with --seed. It is only reported when Randomized is set because a test
+	// request used them.
+	Seed       uint64
+	Randomized bool
+
+	// Labels are the key=value labels attached to the run with --label, to
+	// slice the results of several runs (e.g. env=staging).
+	Labels map[string]string
+}
+
+const (
+	// UntaggedGroup names the tag group of tests declaring no tag.
+	UntaggedGroup = "(untagged)"
+	// UnownedGroup names the owner group of manifests declaring no owner.
+	UnownedGroup = "(unowned)"
+)
+
+// GroupStats contains the results of a group of tests.
+type GroupStats struct {
+	Name     string
+	Total    int
+	Passed   int
+	Failed   int
+	Skipped  int
+	Duration time.Duration
+}
+
+// Cost contains the resources a group of tests is expected to use.
+type Cost struct {
+	// Hinted is the number of tests declaring cost hints.
+	Hinted int
+
+	// BodySize is the size of the bodies sent by the tests, in bytes.
+	BodySize int64
+
+	// Duration is the declared duration of the tests.
+	Duration time.Duration
+}
+
+// Mutations counts the changes the ExtProc service made to a request and its
+// response.
+type Mutations struct {
+	// HeadersSet and HeadersRemoved count the header and trailer mutations.
+	HeadersSet     int
+	HeadersRemoved int
+
+	// BodyBytes is the size of the replaced or streamed bodies, in bytes.
+	BodyBytes int64
+
+	// ImmediateResponses counts the responses short-circuiting the stream.
+	ImmediateResponses int
+}
+
+// MatrixRow contains the status of a test on each fan-out target.
+type MatrixRow struct {
+	ID   string
+	Name string
+
+	// Statuses are the statuses of the test (see Status) in the order of the
+	// targets, empty when it did not run against a target.
+	Statuses []string
+}
+
+// Status returns the status of a test result: passed, failed or skipped.
+func Status(passed, skipped bool) string {
+	switch {
+	case skipped:
+		return "skipped"
+	case passed:
+		return "passed"
+	default:
+		return "failed"
+	}
+}
+
+// DisplayName returns the name of a test as displayed, suffixed with its
+// fan-out target when any.
+func DisplayName(name, target string) string {
+	if target == "" {
+		return name
+	}
+	return name + " @ " + target
 }
@@ -6,13 +6,17 @@ package reporter
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/fatih/color"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
 	"zntr.io/extproctor/internal/comparator"
+	"zntr.io/extproctor/internal/units"
 )
 
 func TestHumanReporter_StartSuite(t *testing.T) {
@@ -100,6 +104,37 @@ func TestHumanReporter_EndTest_Failed(t *testing.T) {
 	assert.Contains(t, output, "actual-value")
 }
 
+func TestHumanReporter_Accessibility(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	result := TestResult{
+		Name:     "café",
+		Duration: 100 * time.Millisecond,
+		Differences: []comparator.Difference{
+			{
+				Phase:    extproctorv1.ProcessingPhase_REQUEST_BODY,
+				Path:     "body_mutation.body",
+				Expected: "0123456789abcdefghijklmnopqrstuvwxyz0123456789",
+				Actual:   "short… (64 of 128)",
+			},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	NewHumanReporter(buf, false).EndTest(result)
+	assert.Contains(t, buf.String(), "\x1b[")
+	assert.Contains(t, buf.String(), "café")
+
+	buf.Reset()
+	NewHumanReporter(buf, false, WithNoColor(), WithASCII(), WithWidth(40)).EndTest(result)
+	assert.NotContains(t, buf.String(), "\x1b[")
+	assert.Contains(t, buf.String(), "  [FAIL] caf\\u00e9 (100ms)\n")
+	assert.Contains(t, buf.String(), "        expected: 0123456789abcdefghijkl\n                  mnopqrstuvwxyz01234567\n                  89\n")
+	assert.Contains(t, buf.String(), "        actual:   short... (64 of 128)\n")
+}
+
 func TestHumanReporter_EndTest_Skipped(t *testing.T) {
 	buf := &bytes.Buffer{}
 	reporter := NewHumanReporter(buf, false)
@@ -243,6 +278,30 @@ func TestJSONReporter_EndTest(t *testing.T) {
 	assert.Empty(t, buf.String())
 }
 
+func TestReporters_DurationFormat(t *testing.T) {
+	result := TestResult{Name: "test-1", Passed: true, Duration: 1500 * time.Millisecond}
+	summary := SuiteSummary{Total: 1, Passed: 1, Duration: 2 * time.Second}
+
+	buf := &bytes.Buffer{}
+	human := NewHumanReporter(buf, false, WithDurationFormat(units.DurationSeconds))
+	human.EndTest(result)
+	human.EndSuite(summary)
+	assert.Contains(t, buf.String(), "  [PASS] test-1 (1.500s)\n")
+	assert.Contains(t, buf.String(), "Duration: 2.000s\n")
+
+	buf.Reset()
+	jsonReporter := NewJSONReporter(buf, WithJSONDurationFormat(units.DurationMilliseconds))
+	jsonReporter.EndTest(result)
+	jsonReporter.EndSuite(summary)
+	var decoded struct {
+		Tests   []struct{ Duration string }
+		Summary struct{ Duration string }
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, "1500.000ms", decoded.Tests[0].Duration)
+	assert.Equal(t, "2000.000ms", decoded.Summary.Duration)
+}
+
 func TestJSONReporter_EndSuite(t *testing.T) {
 	buf := &bytes.Buffer{}
 	reporter := NewJSONReporter(buf)
@@ -531,3 +590,460 @@ func TestJSONReporter_StartTest_NoOp(t *testing.T) {
 	// Verify no output was written
 	assert.Empty(t, buf.String())
 }
+
+func TestHumanReporter_EndTest_WithSkippedPhases(t *testing.T) {
+	buf := &bytes.Buffer{}
+	reporter := NewHumanReporter(buf, false)
+
+	reporter.EndTest(TestResult{
+		Name:          "test-case-1",
+		Passed:        false,
+		Duration:      100 * time.Millisecond,
+		SkippedPhases: []extproctorv1.ProcessingPhase{extproctorv1.ProcessingPhase_REQUEST_BODY},
+	})
+
+	output := buf.String()
+	assert.Contains(t, output, "Skipped phases")
+	assert.Contains(t, output, "REQUEST_BODY")
+}
+
+func TestJSONReporter_EndTest_WithSkippedPhases(t *testing.T) {
+	buf := &bytes.Buffer{}
+	reporter := NewJSONReporter(buf)
+
+	reporter.StartSuite(1)
+	reporter.EndTest(TestResult{
+		Name:          "test-1",
+		Passed:        false,
+		SkippedPhases: []extproctorv1.ProcessingPhase{extproctorv1.ProcessingPhase_RESPONSE_HEADERS},
+	})
+	reporter.EndSuite(SuiteSummary{Total: 1, Failed: 1})
+
+	var result jsonResults
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	require.Len(t, result.Tests, 1)
+	assert.Equal(t, []string{"RESPONSE_HEADERS"}, result.Tests[0].SkippedPhases)
+}
+
+func TestReporters_EndTest_WithSuppressedPhases(t *testing.T) {
+	result := TestResult{
+		Name:             "test-1",
+		Passed:           false,
+		SuppressedPhases: []extproctorv1.ProcessingPhase{extproctorv1.ProcessingPhase_RESPONSE_BODY},
+	}
+
+	human := &bytes.Buffer{}
+	NewHumanReporter(human, false).EndTest(result)
+	assert.Contains(t, human.String(), "Suppressed phases (disabled by a mode_override)")
+	assert.Contains(t, human.String(), "RESPONSE_BODY")
+
+	buf := &bytes.Buffer{}
+	reporter := NewJSONReporter(buf)
+	reporter.StartSuite(1)
+	reporter.EndTest(result)
+	reporter.EndSuite(SuiteSummary{Total: 1, Failed: 1})
+
+	var results jsonResults
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &results))
+	require.Len(t, results.Tests, 1)
+	assert.Equal(t, []string{"RESPONSE_BODY"}, results.Tests[0].SuppressedPhases)
+}
+
+func TestHumanReporter_EndTest_WithUnmatchedReason(t *testing.T) {
+	buf := &bytes.Buffer{}
+	reporter := NewHumanReporter(buf, false)
+
+	exp := &extproctorv1.ExtProcExpectation{
+		Phase: extproctorv1.ProcessingPhase_REQUEST_BODY,
+		Response: &extproctorv1.ExtProcExpectation_BodyResponse{
+			BodyResponse: &extproctorv1.BodyExpectation{},
+		},
+	}
+	reporter.EndTest(TestResult{
+		Name:      "test-case-1",
+		Unmatched: []*extproctorv1.ExtProcExpectation{exp},
+		UnmatchedReasons: map[*extproctorv1.ExtProcExpectation]string{
+			exp: "phase REQUEST_BODY never reached: stream ended at REQUEST_HEADERS with 403",
+		},
+	})
+
+	assert.Contains(t, buf.String(), "phase REQUEST_BODY never reached: stream ended at REQUEST_HEADERS with 403")
+}
+
+func TestHumanReporter_EndSuite_Breakdowns(t *testing.T) {
+	buf := &bytes.Buffer{}
+	reporter := NewHumanReporter(buf, false)
+
+	reporter.EndSuite(SuiteSummary{
+		Total:    3,
+		Passed:   2,
+		Failed:   1,
+		Duration: 1 * time.Second,
+		ByTag: []GroupStats{
+			{Name: "auth", Total: 2, Passed: 1, Failed: 1, Duration: 600 * time.Millisecond},
+			{Name: "routing", Total: 1, Passed: 1, Duration: 400 * time.Millisecond},
+		},
+		ByManifest: []GroupStats{
+			{Name: "team-a", Total: 3, Passed: 2, Failed: 1, Duration: time.Second},
+		},
+	})
+
+	output := buf.String()
+	assert.Contains(t, output, "By tag:")
+	assert.Contains(t, output, "auth: 1 passed, 1 failed (600ms)")
+	assert.Contains(t, output, "routing: 1 passed, 0 failed (400ms)")
+	assert.NotContains(t, output, "By manifest:")
+}
+
+func TestJSONReporter_EndSuite_Breakdowns(t *testing.T) {
+	buf := &bytes.Buffer{}
+	reporter := NewJSONReporter(buf)
+
+	reporter.EndTest(TestResult{
+		Name:     "test-1",
+		Manifest: "team-a",
+		Tags:     []string{"auth"},
+		Passed:   true,
+	})
+	reporter.EndSuite(SuiteSummary{
+		Total:      1,
+		Passed:     1,
+		ByTag:      []GroupStats{{Name: "auth", Total: 1, Passed: 1}},
+		ByManifest: []GroupStats{{Name: "team-a", Total: 1, Passed: 1}},
+	})
+
+	var result jsonResults
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+
+	assert.Equal(t, "team-a", result.Tests[0].Manifest)
+	assert.Equal(t, []string{"auth"}, result.Tests[0].Tags)
+	require.Len(t, result.Summary.ByTag, 1)
+	assert.Equal(t, "auth", result.Summary.ByTag[0].Name)
+	assert.Equal(t, 1, result.Summary.ByTag[0].Passed)
+	require.Len(t, result.Summary.ByManifest, 1)
+	assert.Equal(t, "team-a", result.Summary.ByManifest[0].Name)
+}
+
+func TestHumanReporter_GroupByOwner(t *testing.T) {
+	buf := &bytes.Buffer{}
+	reporter := NewHumanReporter(buf, true, WithGroupByOwner())
+
+	reporter.StartSuite(3)
+	reporter.StartTest("search-test")
+	reporter.EndTest(TestResult{Name: "search-test", Owner: "search", Passed: true})
+	reporter.StartTest("orphan-test")
+	reporter.EndTest(TestResult{Name: "orphan-test", Passed: true})
+	reporter.StartTest("payments-test")
+	reporter.EndTest(TestResult{Name: "payments-test", Owner: "payments"})
+
+	// Results are only printed once the suite completes
+	assert.NotContains(t, buf.String(), "search-test")
+
+	reporter.EndSuite(SuiteSummary{Total: 3, Passed: 2, Failed: 1})
+
+	output := buf.String()
+	unowned := strings.Index(output, "Owner: "+UnownedGroup)
+	payments := strings.Index(output, "Owner: payments")
+	search := strings.Index(output, "Owner: search")
+	require.True(t, unowned >= 0 && payments > unowned && search > payments, output)
+	assert.Contains(t, output[payments:search], "[FAIL] payments-test")
+	assert.Contains(t, output[search:], "[PASS] search-test")
+}
+
+func TestJSONReporter_EndTest_Owner(t *testing.T) {
+	buf := &bytes.Buffer{}
+	reporter := NewJSONReporter(buf)
+
+	reporter.EndTest(TestResult{Name: "test-1", Owner: "payments", Passed: true})
+	reporter.EndSuite(SuiteSummary{
+		Total:   1,
+		Passed:  1,
+		ByOwner: []GroupStats{{Name: "payments", Total: 1, Passed: 1}},
+	})
+
+	var result jsonResults
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal(t, "payments", result.Tests[0].Owner)
+	require.Len(t, result.Summary.ByOwner, 1)
+	assert.Equal(t, "payments", result.Summary.ByOwner[0].Name)
+}
+
+func TestHumanReporter_EndTest_WithLogs(t *testing.T) {
+	buf := &bytes.Buffer{}
+	reporter := NewHumanReporter(buf, false)
+
+	reporter.EndTest(TestResult{
+		Name: "test-case-1",
+		Logs: []string{"level=error msg=\"policy not found\""},
+	})
+
+	assert.Contains(t, buf.String(), "Filter logs:")
+	assert.Contains(t, buf.String(), "policy not found")
+}
+
+func TestJSONReporter_EndTest_WithLogs(t *testing.T) {
+	buf := &bytes.Buffer{}
+	reporter := NewJSONReporter(buf)
+
+	reporter.StartSuite(1)
+	reporter.EndTest(TestResult{
+		Name: "test-1",
+		Logs: []string{"denied"},
+	})
+	reporter.EndSuite(SuiteSummary{Total: 1, Failed: 1})
+
+	var result jsonResults
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	require.Len(t, result.Tests, 1)
+	assert.Equal(t, []string{"denied"}, result.Tests[0].Logs)
+}
+
+func TestHumanReporter_EndTest_FailedShowsID(t *testing.T) {
+	buf := &bytes.Buffer{}
+	reporter := NewHumanReporter(buf, false)
+
+	reporter.EndTest(TestResult{ID: "tests/a.textproto::passing", Name: "passing", Passed: true})
+	reporter.EndTest(TestResult{ID: "tests/a.textproto::failing", Name: "failing"})
+
+	assert.NotContains(t, buf.String(), "tests/a.textproto::passing")
+	assert.Contains(t, buf.String(), "Test ID: tests/a.textproto::failing")
+}
+
+func TestReporters_UID(t *testing.T) {
+	buf := &bytes.Buffer{}
+	human := NewHumanReporter(buf, false)
+	human.EndTest(TestResult{ID: "tests/a.textproto::failing", UID: "0123456789abcdef", Name: "failing"})
+	assert.Contains(t, buf.String(), "Test ID: tests/a.textproto::failing (UID 0123456789abcdef)")
+
+	buf.Reset()
+	jsonReporter := NewJSONReporter(buf)
+	jsonReporter.StartSuite(1)
+	jsonReporter.EndTest(TestResult{ID: "tests/a.textproto::failing", UID: "0123456789abcdef", Name: "failing"})
+	jsonReporter.EndSuite(SuiteSummary{Total: 1, Failed: 1})
+
+	var result jsonResults
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	require.Len(t, result.Tests, 1)
+	assert.Equal(t, "0123456789abcdef", result.Tests[0].UID)
+}
+
+func TestReporters_Seed(t *testing.T) {
+	buf := &bytes.Buffer{}
+	human := NewHumanReporter(buf, false)
+	human.EndSuite(SuiteSummary{Total: 1, Passed: 1, Seed: 42})
+	assert.NotContains(t, buf.String(), "Seed")
+
+	buf.Reset()
+	human.EndSuite(SuiteSummary{Total: 1, Passed: 1, Seed: 42, Randomized: true})
+	assert.Contains(t, buf.String(), "Seed: 42 (replay with --seed 42)")
+
+	buf.Reset()
+	jsonReporter := NewJSONReporter(buf)
+	jsonReporter.EndSuite(SuiteSummary{Total: 1, Passed: 1, Seed: 0, Randomized: true})
+
+	var result jsonResults
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	require.NotNil(t, result.Summary.Seed)
+	assert.Equal(t, uint64(0), *result.Summary.Seed)
+}
+
+func TestReporters_Labels(t *testing.T) {
+	labels := map[string]string{"filter-version": "1.4.2", "env": "staging"}
+
+	buf := &bytes.Buffer{}
+	human := NewHumanReporter(buf, false)
+	human.EndSuite(SuiteSummary{Total: 1, Passed: 1})
+	assert.NotContains(t, buf.String(), "Labels")
+
+	buf.Reset()
+	human.EndSuite(SuiteSummary{Total: 1, Passed: 1, Labels: labels})
+	assert.Contains(t, buf.String(), "Labels: env=staging, filter-version=1.4.2")
+
+	buf.Reset()
+	jsonReporter := NewJSONReporter(buf)
+	jsonReporter.EndSuite(SuiteSummary{Total: 1, Passed: 1, Labels: labels})
+
+	var result jsonResults
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal(t, labels, result.Summary.Labels)
+}
+
+func TestReporters_Cost(t *testing.T) {
+	buf := &bytes.Buffer{}
+	human := NewHumanReporter(buf, false)
+	human.EndSuite(SuiteSummary{Total: 1, Passed: 1, Cost: Cost{BodySize: 512}})
+	assert.NotContains(t, buf.String(), "Cost")
+
+	cost := Cost{Hinted: 2, BodySize: 3 << 19, Duration: 90 * time.Second}
+	buf.Reset()
+	human.EndSuite(SuiteSummary{Total: 3, Passed: 3, Cost: cost})
+	assert.Contains(t, buf.String(), "Cost: 1.5MiB of bodies, 1m30s declared (2 tests with cost hints)")
+
+	buf.Reset()
+	jsonReporter := NewJSONReporter(buf)
+	jsonReporter.EndSuite(SuiteSummary{Total: 3, Passed: 3, Cost: cost})
+
+	var result jsonResults
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal(t, &jsonCost{Hinted: 2, BodySize: 3 << 19, Duration: "1m30s"}, result.Summary.Cost)
+}
+
+func TestReporters_Targets(t *testing.T) {
+	summary := SuiteSummary{
+		Total:  4,
+		Passed: 3,
+		Failed: 1,
+		ByTarget: []GroupStats{
+			{Name: "eu", Total: 2, Passed: 2},
+			{Name: "us", Total: 2, Passed: 1, Failed: 1},
+		},
+		Targets: []string{"eu", "us"},
+		Matrix: []MatrixRow{
+			{ID: "m::a", Name: "a", Statuses: []string{"passed", "failed"}},
+			{ID: "m::b", Name: "b", Statuses: []string{"passed", "passed"}},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	human := NewHumanReporter(buf, false)
+	human.EndTest(TestResult{Name: "a", Target: "us"})
+	human.EndSuite(summary)
+	output := buf.String()
+	assert.Contains(t, output, "[FAIL] a @ us")
+	assert.Contains(t, output, "By target:")
+	assert.Contains(t, output, "  us: 1 passed, 1 failed")
+	assert.Contains(t, output, "  a: eu PASS, us FAIL (m::a)")
+	assert.NotContains(t, output, "  b: eu PASS")
+
+	buf.Reset()
+	jsonReporter := NewJSONReporter(buf)
+	jsonReporter.EndTest(TestResult{Name: "a", Target: "us"})
+	jsonReporter.EndSuite(summary)
+
+	var result jsonResults
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal(t, "us", result.Tests[0].Target)
+	assert.Equal(t, []string{"eu", "us"}, result.Summary.Targets)
+	require.Len(t, result.Summary.ByTarget, 2)
+	require.Len(t, result.Summary.Matrix, 2)
+	assert.Equal(t, map[string]string{"eu": "passed", "us": "failed"}, result.Summary.Matrix[0].Results)
+}
+
+func TestReporters_Notes(t *testing.T) {
+	result := TestResult{Name: "a", Notes: []string{"the target was not serving while the test ran (NOT_SERVING)"}}
+
+	buf := &bytes.Buffer{}
+	NewHumanReporter(buf, false).EndTest(result)
+	assert.Contains(t, buf.String(), "    Note: the target was not serving while the test ran (NOT_SERVING)\n")
+
+	buf.Reset()
+	jsonReporter := NewJSONReporter(buf)
+	jsonReporter.EndTest(result)
+	jsonReporter.EndSuite(SuiteSummary{Total: 1, Failed: 1})
+
+	var results jsonResults
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &results))
+	assert.Equal(t, result.Notes, results.Tests[0].Notes)
+}
+
+func TestReporters_SkipReason(t *testing.T) {
+	buf := &bytes.Buffer{}
+	human := NewHumanReporter(buf, false)
+	human.EndTest(TestResult{Name: "slow", Skipped: true, SkipReason: "time budget exceeded"})
+	assert.Contains(t, buf.String(), "Reason: time budget exceeded")
+
+	buf.Reset()
+	reporter := NewJSONReporter(buf)
+	reporter.StartSuite(1)
+	reporter.EndTest(TestResult{Name: "slow", Skipped: true, SkipReason: "time budget exceeded"})
+	reporter.EndSuite(SuiteSummary{Total: 1, Skipped: 1})
+
+	var result jsonResults
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	require.Len(t, result.Tests, 1)
+	assert.Equal(t, "time budget exceeded", result.Tests[0].SkipReason)
+}
+
+func TestMultiReporter(t *testing.T) {
+	human := &bytes.Buffer{}
+	js := &bytes.Buffer{}
+	multi := NewMultiReporter(NewHumanReporter(human, false), NewJSONReporter(js))
+
+	multi.StartSuite(1)
+	multi.StartTest("test-1")
+	multi.EndTest(TestResult{ID: "a.textproto#test-1", Name: "test-1", Passed: true})
+	multi.EndSuite(SuiteSummary{Total: 1, Passed: 1})
+
+	assert.Contains(t, human.String(), "Running 1 test(s)")
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(js.Bytes(), &doc))
+	assert.Len(t, doc["tests"], 1)
+}
+
+// eventRecorder records the events of a run.
+type eventRecorder struct {
+	Reporter
+	events []Event
+}
+
+func (r *eventRecorder) Event(event Event) {
+	r.events = append(r.events, event)
+}
+
+func TestMultiReporter_Event(t *testing.T) {
+	recorder := &eventRecorder{}
+	multi := NewMultiReporter(NewHumanReporter(&bytes.Buffer{}, false), recorder)
+
+	err := errors.New("connection refused")
+	multi.Event(TargetUnhealthy{Target: "eu", Err: err})
+	require.Len(t, recorder.events, 1)
+	unhealthy, ok := recorder.events[0].(TargetUnhealthy)
+	require.True(t, ok)
+	assert.ErrorIs(t, unhealthy.Err, err)
+}
+
+func TestReporters_Categories(t *testing.T) {
+	failures := CountCategories([]Category{CategoryTimeout, CategoryAssertion, CategoryConnection, CategoryTimeout})
+	assert.Equal(t, []CategoryCount{
+		{Category: CategoryAssertion, Count: 1},
+		{Category: CategoryTimeout, Count: 2},
+		{Category: CategoryConnection, Count: 1},
+	}, failures)
+	assert.Equal(t, 3, InfraFailures(failures))
+	summary := SuiteSummary{Total: 4, Failed: 4, Failures: failures}
+
+	buf := &bytes.Buffer{}
+	human := NewHumanReporter(buf, false)
+	human.EndTest(TestResult{Name: "a", Category: CategoryAssertion})
+	human.EndTest(TestResult{Name: "b", Error: errors.New("connection refused"), Category: CategoryConnection})
+	human.EndSuite(summary)
+	output := buf.String()
+	assert.Equal(t, 1, strings.Count(output, "Category:"))
+	assert.Contains(t, output, "    Category: connection_error\n")
+	assert.Contains(t, output, "Failures: 1 assertion_mismatch, 2 timeout, 1 connection_error (3 infrastructure)\n")
+
+	buf.Reset()
+	jsonReporter := NewJSONReporter(buf)
+	jsonReporter.EndTest(TestResult{Name: "b", Category: CategoryConnection})
+	jsonReporter.EndSuite(summary)
+
+	var result jsonResults
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal(t, "connection_error", result.Tests[0].Category)
+	assert.Equal(t, map[string]int{"assertion_mismatch": 1, "timeout": 2, "connection_error": 1}, result.Summary.Failures)
+	assert.Equal(t, 3, result.Summary.InfraFailed)
+}
+
+func TestJSONReporter_Mutations(t *testing.T) {
+	buf := &bytes.Buffer{}
+	r := NewJSONReporter(buf)
+	r.EndTest(TestResult{Name: "a", Passed: true, Mutations: &Mutations{HeadersSet: 3, HeadersRemoved: 1, BodyBytes: 42}})
+	r.EndTest(TestResult{Name: "b", Error: errors.New("connection refused")})
+	r.EndSuite(SuiteSummary{Total: 2, Passed: 1, Failed: 1})
+
+	var result jsonResults
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal(t, &jsonMutations{HeadersSet: 3, HeadersRemoved: 1, BodyBytes: 42}, result.Tests[0].Mutations)
+	assert.Nil(t, result.Tests[1].Mutations)
+	assert.Contains(t, buf.String(), `"immediate_responses": 0`)
+}
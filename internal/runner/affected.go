@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"path/filepath"
+
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/golden"
+)
+
+// isAffected checks if a test case is affected by the changed files: its
+// manifest, a manifest it inherits from, a file its request reads or one of
+// its golden files changed.
+func (r *Runner) isAffected(tc *testCaseWithManifest) bool {
+	if r.changed == nil {
+		return true
+	}
+
+	for _, path := range r.testCaseFiles(tc) {
+		if r.changed[canonicalPath(path)] {
+			return true
+		}
+	}
+	return false
+}
+
+// testCaseFiles returns the files a test case is defined in or references.
+func (r *Runner) testCaseFiles(tc *testCaseWithManifest) []string {
+	vars := r.goldenVars(tc)
+	files := append([]string{tc.sourcePath}, tc.dependencies...)
+
+	// Request files, resolved against their manifest at load time
+	for _, p := range tc.testCase.GetRequest().GetMultipart().GetParts() {
+		if file := p.GetFile(); file != "" {
+			files = append(files, file)
+		}
+	}
+	for _, m := range tc.testCase.GetRequest().GetGrpc().GetMessages() {
+		if file := m.GetFile(); file != "" {
+			files = append(files, file)
+		}
+	}
+
+	if tc.testCase.GoldenFile == "" {
+		return files
+	}
+
+	pattern := r.resolveGoldenPath(tc)
+	if !golden.IsPerPhase(pattern) {
+		return append(files, golden.ExpandPath(pattern, vars, extproctorv1.ProcessingPhase_PROCESSING_PHASE_UNSPECIFIED))
+	}
+	for phase := range extproctorv1.ProcessingPhase_name {
+		if phase == int32(extproctorv1.ProcessingPhase_PROCESSING_PHASE_UNSPECIFIED) {
+			continue
+		}
+		files = append(files, golden.ExpandPath(pattern, vars, extproctorv1.ProcessingPhase(phase)))
+	}
+	return files
+}
+
+// canonicalPath returns an absolute path with symbolic links resolved, so
+// paths reported by git and paths given on the command line compare equal.
+// The parent directory is resolved for files that no longer exist.
+func canonicalPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved
+	}
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		return filepath.Join(dir, filepath.Base(abs))
+	}
+	return abs
+}
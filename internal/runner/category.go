@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"zntr.io/extproctor/internal/reporter"
+)
+
+// classifyError returns the failure category of an error of the processing
+// of a test: the gRPC status tells an unreachable or slow service apart from
+// a service failing the stream, and errors without status come from the
+// test case itself (e.g. an invalid phase sequence).
+func classifyError(err error) reporter.Category {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return reporter.CategoryTimeout
+	}
+	if errors.Is(err, io.EOF) {
+		return reporter.CategoryProtocol
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return reporter.CategoryTestError
+	}
+	switch st.Code() {
+	case codes.DeadlineExceeded:
+		return reporter.CategoryTimeout
+	case codes.Unavailable:
+		return reporter.CategoryConnection
+	default:
+		return reporter.CategoryProtocol
+	}
+}
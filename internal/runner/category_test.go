@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"zntr.io/extproctor/internal/reporter"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want reporter.Category
+	}{
+		{"deadline", fmt.Errorf("failed to receive response for request headers: %w", context.DeadlineExceeded), reporter.CategoryTimeout},
+		{"deadline status", status.Error(codes.DeadlineExceeded, "context deadline exceeded"), reporter.CategoryTimeout},
+		{"unavailable", fmt.Errorf("failed to start processing stream: %w", status.Error(codes.Unavailable, "connection refused")), reporter.CategoryConnection},
+		{"stream closed", fmt.Errorf("failed to receive response for request body: %w", io.EOF), reporter.CategoryProtocol},
+		{"service error", status.Error(codes.Internal, "boom"), reporter.CategoryProtocol},
+		{"test case", errors.New("empty phase sequence"), reporter.CategoryTestError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, classifyError(tt.err))
+		})
+	}
+}
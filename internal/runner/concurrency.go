@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"zntr.io/extproctor/internal/manifest"
+)
+
+// queue hands the pending test cases to the parallel workers, in order,
+// while capping the number of running test cases of the manifests setting
+// `parallel`, per target.
+type queue struct {
+	mu      sync.Mutex
+	pending []*testCaseWithManifest
+	running map[limiterKey]int
+
+	// freed is closed, then replaced, when a test case ends.
+	freed chan struct{}
+}
+
+// limiterKey identifies the test cases sharing a manifest limit.
+type limiterKey struct {
+	manifest *manifest.LoadedManifest
+	target   *Target
+}
+
+func newQueue(testCases []*testCaseWithManifest) *queue {
+	return &queue{
+		pending: slices.Clone(testCases),
+		running: make(map[limiterKey]int),
+		freed:   make(chan struct{}),
+	}
+}
+
+// manifestParallel returns the maximum number of running test cases of the
+// manifest of a test case, 0 when unlimited.
+func manifestParallel(tc *testCaseWithManifest) int {
+	if tc.manifest == nil || tc.manifest.TestManifest == nil {
+		return 0
+	}
+	return int(tc.manifest.GetParallel())
+}
+
+// next removes and returns the first pending test case whose manifest is
+// below its limit, counting it as running. It waits for a test case to end
+// when none is, and returns nil once the queue is empty or the context ended.
+func (q *queue) next(ctx context.Context) *testCaseWithManifest {
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.mu.Unlock()
+			return nil
+		}
+		for i, tc := range q.pending {
+			limit := manifestParallel(tc)
+			key := limiterKey{manifest: tc.manifest, target: tc.target}
+			if limit == 0 || q.running[key] < limit {
+				q.running[key]++
+				q.pending = slices.Delete(q.pending, i, i+1)
+				q.mu.Unlock()
+				return tc
+			}
+		}
+		freed := q.freed
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-freed:
+		}
+	}
+}
+
+// done records the end of a test case returned by next.
+func (q *queue) done(tc *testCaseWithManifest) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.running[limiterKey{manifest: tc.manifest, target: tc.target}]--
+	close(q.freed)
+	q.freed = make(chan struct{})
+}
+
+// expectedDurations returns the expected duration of the test cases under
+// parallelism, their duration in the previous runs. Tests without a recorded
+// duration are expected to last the average recorded one. Sequential runs
+// expect no duration, keeping the manifest order.
+func (r *Runner) expectedDurations(testCases []*testCaseWithManifest) func(*testCaseWithManifest) time.Duration {
+	var total time.Duration
+	var known int
+	if r.parallel > 1 {
+		for _, tc := range testCases {
+			if d, ok := r.durations[tc.id()]; ok {
+				total += d
+				known++
+			}
+		}
+	}
+	if known == 0 {
+		return func(*testCaseWithManifest) time.Duration { return 0 }
+	}
+
+	average := total / time.Duration(known)
+	return func(tc *testCaseWithManifest) time.Duration {
+		if d, ok := r.durations[tc.id()]; ok {
+			return d
+		}
+		return average
+	}
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/manifest"
+)
+
+func TestQueue_ManifestLimit(t *testing.T) {
+	heavy := scheduledTests(nil, "huge-1", "huge-2")
+	heavy[0].manifest.Parallel = 1
+	cheap := scheduledTests(nil, "headers")
+	eu := &testCaseWithManifest{testCase: heavy[1].testCase, manifest: heavy[1].manifest, target: &Target{Name: "eu"}}
+
+	ctx := context.Background()
+	q := newQueue(append(append(heavy, cheap...), eu))
+	assert.Same(t, heavy[0], q.next(ctx))
+
+	// The second heavy test waits, the cheap one starts instead, and each
+	// target has its own limit
+	assert.Same(t, cheap[0], q.next(ctx))
+	assert.Same(t, eu, q.next(ctx))
+
+	// The heavy test starts once the first one ended
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.done(heavy[0])
+	}()
+	assert.Same(t, heavy[1], q.next(ctx))
+	assert.Nil(t, q.next(ctx))
+}
+
+func TestQueue_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Nil(t, newQueue(scheduledTests(nil, "a")).next(ctx))
+}
+
+func TestRunParallel_ManifestLimit(t *testing.T) {
+	m := &manifest.LoadedManifest{
+		TestManifest: &extproctorv1.TestManifest{Parallel: 1},
+		SourcePath:   "m.textproto",
+		SkipReason:   "unsupported",
+	}
+	var tcs []*testCaseWithManifest
+	for _, name := range []string{"a", "b", "c"} {
+		tcs = append(tcs, &testCaseWithManifest{
+			testCase:   &extproctorv1.TestCase{Name: name},
+			manifest:   m,
+			sourcePath: m.SourcePath,
+		})
+	}
+
+	results := &Results{}
+	New(nil, WithParallel(4)).runParallel(context.Background(), tcs, results)
+	assert.Equal(t, 3, results.Skipped)
+}
+
+func TestOrderTests_Durations(t *testing.T) {
+	durations := map[string]time.Duration{
+		"m.textproto::short": time.Millisecond,
+		"m.textproto::long":  time.Second,
+		"m.textproto::smoke": time.Millisecond,
+	}
+
+	// Under parallelism, the longest tests start first, after the smoke
+	// tests; unknown tests are expected to last the average duration
+	tcs := scheduledTests(map[string]int32{"smoke": 1}, "short", "new", "long", "smoke")
+	New(nil, WithParallel(4), WithDurations(durations)).orderTests(tcs)
+	assert.Equal(t, []string{"smoke", "long", "new", "short"}, testNames(tcs))
+
+	// Sequential runs keep the manifest order
+	tcs = scheduledTests(nil, "short", "new", "long")
+	New(nil, WithDurations(durations)).orderTests(tcs)
+	assert.Equal(t, []string{"short", "new", "long"}, testNames(tcs))
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"zntr.io/extproctor/internal/reporter"
+	"zntr.io/extproctor/internal/units"
+)
+
+// Budget caps the resources of a run, checked against the cost hints of the
+// tests before the run starts. Zero values are unlimited.
+type Budget struct {
+	// Tests is the maximum number of test runs, counting each target of a
+	// fan-out run.
+	Tests int
+
+	// BodySize is the maximum size of the bodies sent by the tests, in bytes.
+	BodySize int64
+
+	// Duration is the maximum declared duration of the tests.
+	Duration time.Duration
+}
+
+// WithBudget refuses to run the tests when their cost exceeds the budget, so
+// that an accidentally expensive generated suite is flagged before it runs.
+func WithBudget(b Budget) Option {
+	return func(r *Runner) {
+		r.budget = b
+	}
+}
+
+// OverBudgetError reports a run whose cost exceeds its budget.
+type OverBudgetError struct {
+	// Exceeded describes each exceeded limit.
+	Exceeded []string
+}
+
+func (e *OverBudgetError) Error() string {
+	return "cost budget exceeded: " + strings.Join(e.Exceeded, ", ")
+}
+
+// testCost returns the cost of a test case: its cost hints, the size of its
+// request body otherwise.
+func testCost(tc *testCaseWithManifest) reporter.Cost {
+	cost := reporter.Cost{BodySize: int64(len(tc.testCase.GetRequest().GetBody()))}
+
+	hints := tc.testCase.Cost
+	if hints == nil {
+		return cost
+	}
+	cost.Hinted = 1
+
+	// The literals were validated at load time
+	if hints.BodySize != "" {
+		cost.BodySize, _ = units.ParseSize(hints.BodySize)
+	}
+	if hints.Duration != "" {
+		cost.Duration, _ = units.ParseDuration(hints.Duration)
+	}
+	return cost
+}
+
+// suiteCost returns the cost of the scheduled test cases.
+func suiteCost(testCases []*testCaseWithManifest) reporter.Cost {
+	var total reporter.Cost
+	for _, tc := range testCases {
+		cost := testCost(tc)
+		total.Hinted += cost.Hinted
+		total.BodySize += cost.BodySize
+		total.Duration += cost.Duration
+	}
+	return total
+}
+
+// checkBudget returns an OverBudgetError when the cost of the scheduled test
+// cases exceeds the budget.
+func (r *Runner) checkBudget(tests int, cost reporter.Cost) error {
+	var exceeded []string
+	if r.budget.Tests > 0 && tests > r.budget.Tests {
+		exceeded = append(exceeded, fmt.Sprintf("%d tests > %d", tests, r.budget.Tests))
+	}
+	if r.budget.BodySize > 0 && cost.BodySize > r.budget.BodySize {
+		exceeded = append(exceeded, fmt.Sprintf("body size %s > %s", units.FormatSize(cost.BodySize), units.FormatSize(r.budget.BodySize)))
+	}
+	if r.budget.Duration > 0 && cost.Duration > r.budget.Duration {
+		exceeded = append(exceeded, fmt.Sprintf("duration %s > %s", cost.Duration, r.budget.Duration))
+	}
+
+	if len(exceeded) > 0 {
+		return &OverBudgetError{Exceeded: exceeded}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/manifest"
+	"zntr.io/extproctor/internal/reporter"
+)
+
+func TestSuiteCost(t *testing.T) {
+	tcs := scheduledTests(nil, "huge", "hinted", "plain")
+	tcs[0].testCase.Cost = &extproctorv1.CostHints{BodySize: "64MiB", Duration: "30s"}
+	tcs[1].testCase.Cost = &extproctorv1.CostHints{Duration: "1s"}
+	tcs[1].testCase.Request = &extproctorv1.HttpRequest{Body: []byte("hello")}
+	tcs[2].testCase.Request = &extproctorv1.HttpRequest{Body: []byte("world!")}
+
+	assert.Equal(t, reporter.Cost{
+		Hinted:   2,
+		BodySize: 64<<20 + 11,
+		Duration: 31 * time.Second,
+	}, suiteCost(tcs))
+}
+
+func TestCheckBudget(t *testing.T) {
+	cost := reporter.Cost{BodySize: 3 << 19, Duration: time.Minute}
+	assert.NoError(t, New(nil).checkBudget(10, cost))
+	assert.NoError(t, New(nil, WithBudget(Budget{Tests: 10, BodySize: 2 << 20, Duration: time.Minute})).checkBudget(10, cost))
+
+	err := New(nil, WithBudget(Budget{Tests: 5, BodySize: 1 << 20, Duration: time.Second})).checkBudget(10, cost)
+	var overBudget *OverBudgetError
+	require.ErrorAs(t, err, &overBudget)
+	assert.EqualError(t, err, "cost budget exceeded: 10 tests > 5, body size 1.5MiB > 1MiB, duration 1m0s > 1s")
+}
+
+func TestRun_OverBudget(t *testing.T) {
+	tcs := scheduledTests(nil, "a", "b")
+	m := tcs[0].manifest
+	m.TestCases = []*extproctorv1.TestCase{tcs[0].testCase, tcs[1].testCase}
+
+	// Fan-out multiplies the cost by the number of targets
+	rep := &mockReporter{}
+	r := New(nil, WithReporter(rep), WithBudget(Budget{Tests: 3}), WithTargets(Target{Name: "eu"}, Target{Name: "us"}))
+	_, err := r.Run(context.Background(), []*manifest.LoadedManifest{m})
+	assert.EqualError(t, err, "cost budget exceeded: 4 tests > 3")
+	assert.Zero(t, rep.startSuiteCalled)
+}
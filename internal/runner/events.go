@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"context"
+
+	"zntr.io/extproctor/internal/client"
+	"zntr.io/extproctor/internal/reporter"
+)
+
+// emit reports an event, one at a time.
+func (r *Runner) emit(event reporter.Event) {
+	if r.reporter == nil {
+		return
+	}
+
+	r.events.Lock()
+	defer r.events.Unlock()
+	r.reporter.Event(event)
+}
+
+// testRef identifies a test case in events.
+func testRef(tc *testCaseWithManifest) reporter.TestRef {
+	return reporter.TestRef{
+		ID:     tc.id(),
+		Name:   tc.testCase.Name,
+		Target: tc.targetName(),
+	}
+}
+
+// observeRetries returns a context reporting the retries of the gRPC calls of
+// a test.
+func (r *Runner) observeRetries(ctx context.Context, tc *testCaseWithManifest) context.Context {
+	if r.reporter == nil {
+		return ctx
+	}
+	return client.WithRetryObserver(ctx, func(attempt int, err error) {
+		r.emit(reporter.RetryAttempted{Test: testRef(tc), Attempt: attempt, Err: err})
+	})
+}
+
+// reportPhases reports the phases the ExtProc service answered during a
+// test, including before the stream failed.
+func (r *Runner) reportPhases(tc *testCaseWithManifest, result *client.ProcessingResult) {
+	if result == nil {
+		return
+	}
+	for _, resp := range result.Responses {
+		r.emit(reporter.PhaseCompleted{
+			Test:     testRef(tc),
+			Phase:    resp.Phase,
+			Chunk:    resp.Chunk,
+			Latency:  resp.Latency,
+			Response: resp.Response,
+		})
+	}
+}
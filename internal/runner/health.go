@@ -0,0 +1,259 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"zntr.io/extproctor/internal/client"
+	"zntr.io/extproctor/internal/reporter"
+)
+
+const (
+	// healthCheckTimeout bounds the duration of a health check.
+	healthCheckTimeout = 5 * time.Second
+
+	// defaultHealthTimeout is how long tests wait for a target to serve
+	// again when no timeout is configured.
+	defaultHealthTimeout = time.Minute
+)
+
+// HealthCheck configures the gating of the tests on the gRPC health service
+// of the targets.
+type HealthCheck struct {
+	// Service is the name of the checked service, empty for the overall
+	// status of the server.
+	Service string
+
+	// Interval is the time between two checks during the run.
+	Interval time.Duration
+
+	// Timeout is how long the tests wait for a target to serve again. Once
+	// elapsed, the tests of the target are skipped until it serves again.
+	Timeout time.Duration
+
+	// Log receives a line per change of the serving status of a target.
+	Log io.Writer
+}
+
+// WithHealthCheck checks the gRPC health service of the targets before the
+// run and periodically during it. While a target is not serving, its tests
+// are not started; a test failing while it was not serving is annotated.
+// Targets not implementing the health service are always considered serving.
+func WithHealthCheck(hc HealthCheck) Option {
+	return func(r *Runner) {
+		if hc.Timeout <= 0 {
+			hc.Timeout = defaultHealthTimeout
+		}
+		r.healthCheck = &hc
+	}
+}
+
+// startHealthChecks checks the health of each target, then keeps checking it
+// periodically until the returned function is called.
+func (r *Runner) startHealthChecks(ctx context.Context) func() {
+	r.gates = nil
+	if r.healthCheck == nil {
+		return func() {}
+	}
+
+	targets := r.targets
+	if len(targets) == 0 {
+		targets = []Target{{Name: r.targetName, Client: r.client}}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	r.gates = make(map[*client.Client]*healthGate, len(targets))
+	for _, t := range targets {
+		c := t.Client
+		gate := newHealthGate(t.Name, func(ctx context.Context) (healthpb.HealthCheckResponse_ServingStatus, error) {
+			return c.Health(ctx, r.healthCheck.Service)
+		}, r.healthCheck.Timeout, r.healthCheck.Log)
+		gate.unhealthy = func(err error) {
+			r.emit(reporter.TargetUnhealthy{Target: gate.name, Err: err})
+		}
+		gate.update(ctx)
+		r.gates[c] = gate
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gate.watch(ctx, r.healthCheck.Interval)
+		}()
+	}
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// gateFor returns the health gate of the target of a test case, or nil
+// without health checks.
+func (r *Runner) gateFor(tc *testCaseWithManifest) *healthGate {
+	return r.gates[r.clientFor(tc)]
+}
+
+// healthGate tracks the serving status of a target.
+type healthGate struct {
+	name    string
+	check   func(context.Context) (healthpb.HealthCheckResponse_ServingStatus, error)
+	timeout time.Duration
+	log     io.Writer
+
+	// unhealthy is called when the target stops serving.
+	unhealthy func(err error)
+
+	mu      sync.Mutex
+	serving bool
+	reason  string
+	outages int
+	gaveUp  bool
+
+	// changed is closed, then replaced, when the serving status changes.
+	changed chan struct{}
+}
+
+func newHealthGate(name string, check func(context.Context) (healthpb.HealthCheckResponse_ServingStatus, error), timeout time.Duration, log io.Writer) *healthGate {
+	return &healthGate{
+		name:    name,
+		check:   check,
+		timeout: timeout,
+		log:     log,
+		serving: true,
+		changed: make(chan struct{}),
+	}
+}
+
+// watch checks the health of the target at the given interval until the
+// context ends.
+func (g *healthGate) watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.update(ctx)
+		}
+	}
+}
+
+// update checks the health of the target. A target not implementing the
+// health service is serving, an unreachable one is not.
+func (g *healthGate) update(ctx context.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	st, err := g.check(checkCtx)
+	if ctx.Err() != nil {
+		// The run ended, the check says nothing about the target
+		return
+	}
+
+	var cause error
+	switch {
+	case status.Code(err) == codes.Unimplemented:
+	case err != nil:
+		cause = err
+	case st != healthpb.HealthCheckResponse_SERVING:
+		cause = errors.New(st.String())
+	}
+
+	if g.set(cause) && cause != nil && g.unhealthy != nil {
+		g.unhealthy(cause)
+	}
+}
+
+// set records the serving status of the target, serving unless the check
+// failed, and returns whether it changed.
+func (g *healthGate) set(cause error) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	serving := cause == nil
+	g.reason = ""
+	if cause != nil {
+		g.reason = cause.Error()
+	}
+	if serving == g.serving {
+		return false
+	}
+
+	g.serving = serving
+	if serving {
+		g.gaveUp = false
+		g.logf("%s is serving again, resuming its tests", g.name)
+	} else {
+		g.outages++
+		g.logf("%s is not serving (%s), pausing its tests", g.name, g.reason)
+	}
+	close(g.changed)
+	g.changed = make(chan struct{})
+	return true
+}
+
+// logf writes a log line, when a log is configured.
+func (g *healthGate) logf(format string, args ...any) {
+	if g.log != nil {
+		fmt.Fprintf(g.log, "health: "+format+"\n", args...)
+	}
+}
+
+// state returns whether the target is serving, the number of times it stopped
+// serving, and why it is not serving.
+func (g *healthGate) state() (serving bool, outages int, reason string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.serving, g.outages, g.reason
+}
+
+// wait blocks until the target is serving, for up to the timeout of the
+// gate, and returns whether it is. Once the timeout elapsed, wait returns
+// immediately until the target serves again.
+func (g *healthGate) wait(ctx context.Context) bool {
+	g.mu.Lock()
+	if g.serving || g.gaveUp {
+		defer g.mu.Unlock()
+		return g.serving
+	}
+	changed := g.changed
+	g.mu.Unlock()
+
+	timer := time.NewTimer(g.timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			g.gaveUp = !g.serving
+			return g.serving
+		case <-changed:
+			g.mu.Lock()
+			if g.serving {
+				g.mu.Unlock()
+				return true
+			}
+			changed = g.changed
+			g.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"zntr.io/extproctor/internal/client"
+)
+
+// fakeHealth is a health check whose result is set by the test.
+type fakeHealth struct {
+	mu     sync.Mutex
+	status healthpb.HealthCheckResponse_ServingStatus
+	err    error
+}
+
+func (f *fakeHealth) set(st healthpb.HealthCheckResponse_ServingStatus, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status, f.err = st, err
+}
+
+func (f *fakeHealth) check(context.Context) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.status, f.err
+}
+
+func TestHealthGate(t *testing.T) {
+	ctx := context.Background()
+	log := &bytes.Buffer{}
+	health := &fakeHealth{status: healthpb.HealthCheckResponse_SERVING}
+	gate := newHealthGate("eu", health.check, 20*time.Millisecond, log)
+	var unhealthy []string
+	gate.unhealthy = func(err error) { unhealthy = append(unhealthy, err.Error()) }
+
+	gate.update(ctx)
+	assert.True(t, gate.wait(ctx))
+
+	health.set(healthpb.HealthCheckResponse_NOT_SERVING, nil)
+	gate.update(ctx)
+	serving, outages, reason := gate.state()
+	assert.False(t, serving)
+	assert.Equal(t, 1, outages)
+	assert.Equal(t, "NOT_SERVING", reason)
+	assert.Equal(t, "health: eu is not serving (NOT_SERVING), pausing its tests\n", log.String())
+
+	// The tests wait up to the timeout, then give up until the target serves
+	// again
+	start := time.Now()
+	assert.False(t, gate.wait(ctx))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	start = time.Now()
+	assert.False(t, gate.wait(ctx))
+	assert.Less(t, time.Since(start), 20*time.Millisecond)
+
+	health.set(healthpb.HealthCheckResponse_SERVING, nil)
+	gate.update(ctx)
+	assert.True(t, gate.wait(ctx))
+	assert.Contains(t, log.String(), "health: eu is serving again, resuming its tests\n")
+
+	// Unreachable targets are not serving, the ones without health service
+	// are
+	health.set(healthpb.HealthCheckResponse_UNKNOWN, errors.New("connection refused"))
+	gate.update(ctx)
+	serving, outages, reason = gate.state()
+	assert.False(t, serving)
+	assert.Equal(t, 2, outages)
+	assert.Equal(t, "connection refused", reason)
+
+	health.set(healthpb.HealthCheckResponse_UNKNOWN, status.Error(codes.Unimplemented, "unknown service"))
+	gate.update(ctx)
+	serving, _, _ = gate.state()
+	assert.True(t, serving)
+
+	// Each outage is reported once
+	assert.Equal(t, []string{"NOT_SERVING", "connection refused"}, unhealthy)
+}
+
+func TestHealthGate_WaitResumes(t *testing.T) {
+	ctx := context.Background()
+	health := &fakeHealth{status: healthpb.HealthCheckResponse_NOT_SERVING}
+	gate := newHealthGate("eu", health.check, time.Minute, nil)
+	gate.update(ctx)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		health.set(healthpb.HealthCheckResponse_SERVING, nil)
+		gate.update(ctx)
+	}()
+	assert.True(t, gate.wait(ctx))
+}
+
+func TestAnnotateHealth(t *testing.T) {
+	ctx := context.Background()
+	health := &fakeHealth{status: healthpb.HealthCheckResponse_SERVING}
+	gate := newHealthGate("eu", health.check, time.Minute, nil)
+
+	// Passing tests are not annotated
+	result := &TestResult{Passed: true}
+	annotateHealth(ctx, gate, 0, result)
+	assert.Empty(t, result.Notes)
+
+	// A failing test checks the target health at once
+	health.set(healthpb.HealthCheckResponse_NOT_SERVING, nil)
+	result = &TestResult{Error: errors.New("connection reset")}
+	annotateHealth(ctx, gate, 0, result)
+	assert.Equal(t, []string{"the target was not serving while the test ran (NOT_SERVING)"}, result.Notes)
+
+	// The target stopped serving, then recovered, while the test ran
+	health.set(healthpb.HealthCheckResponse_SERVING, nil)
+	gate.update(ctx)
+	result = &TestResult{}
+	annotateHealth(ctx, gate, 0, result)
+	assert.Len(t, result.Notes, 1)
+
+	result = &TestResult{}
+	annotateHealth(ctx, gate, 1, result)
+	assert.Empty(t, result.Notes)
+
+	annotateHealth(ctx, nil, 0, result)
+	assert.Empty(t, result.Notes)
+}
+
+func TestSkipReasonFor_NotServing(t *testing.T) {
+	health := &fakeHealth{status: healthpb.HealthCheckResponse_NOT_SERVING}
+	gate := newHealthGate("eu", health.check, time.Millisecond, nil)
+	gate.update(context.Background())
+
+	rep := &mockReporter{}
+	r := New(nil, WithReporter(rep))
+	r.gates = map[*client.Client]*healthGate{nil: gate}
+
+	results := &Results{}
+	r.runSequential(context.Background(), scheduledTests(nil, "a", "b"), results)
+	assert.Equal(t, 2, results.Skipped)
+	assert.True(t, results.NotServing)
+	assert.Equal(t, SkipReasonNotServing, rep.lastResult.SkipReason)
+}
+
+func TestStartHealthChecks(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("extproc", healthpb.HealthCheckResponse_NOT_SERVING)
+	grpcServer := grpc.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	go func() { _ = grpcServer.Serve(lis) }()
+	t.Cleanup(grpcServer.Stop)
+
+	c, err := client.New(client.WithTarget(lis.Addr().String()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	log := &bytes.Buffer{}
+	r := New(c, WithTargetName("local"), WithHealthCheck(HealthCheck{
+		Service:  "extproc",
+		Interval: 10 * time.Millisecond,
+		Log:      log,
+	}))
+	stop := r.startHealthChecks(context.Background())
+	defer stop()
+
+	gate := r.gateFor(&testCaseWithManifest{})
+	require.NotNil(t, gate)
+	serving, _, _ := gate.state()
+	assert.False(t, serving)
+
+	// The periodic check resumes the tests
+	healthServer.SetServingStatus("extproc", healthpb.HealthCheckResponse_SERVING)
+	assert.True(t, gate.wait(context.Background()))
+	stop()
+	assert.Contains(t, log.String(), "health: local is not serving (NOT_SERVING), pausing its tests\n")
+	assert.Contains(t, log.String(), "health: local is serving again, resuming its tests\n")
+}
@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/random"
+)
+
+// TestIDHeader is the request header carrying the test ID, letting the
+// ExtProc service logs and traces be correlated with tests.
+const TestIDHeader = "x-extproctor-test-id"
+
+// TestID returns the persistent ID of a test case: the slash-separated path
+// of its manifest, relative to the working directory when possible, and its
+// name (e.g. "tests/auth.textproto::deny-anonymous").
+func TestID(sourcePath, name string) string {
+	path := filepath.Clean(sourcePath)
+	if filepath.IsAbs(path) {
+		if cwd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(cwd, path); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+	}
+	return filepath.ToSlash(path) + "::" + name
+}
+
+// TestUID returns the stable UID of a test case, tracked by dashboards across
+// runs: its pinned uid, or 16 hex digits of the SHA-256 of its test ID. Unlike
+// the test ID, a pinned UID does not change when the test case is renamed or
+// moved to another manifest.
+func TestUID(id string, tc *extproctorv1.TestCase) string {
+	if tc.GetUid() != "" {
+		return tc.GetUid()
+	}
+
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:8])
+}
+
+func idSet(ids []string) map[string]bool {
+	if ids == nil {
+		return nil
+	}
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// testRequest returns the HTTP request of a test case, with the test ID header
+// injected unless disabled or already defined by the test.
+func (r *Runner) testRequest(tc *testCaseWithManifest) *extproctorv1.HttpRequest {
+	if !r.injectID || tc.testCase.Request == nil {
+		return tc.testCase.Request
+	}
+	for name := range tc.testCase.Request.Headers {
+		if strings.EqualFold(name, TestIDHeader) {
+			return tc.testCase.Request
+		}
+	}
+	for _, h := range tc.testCase.Request.HeaderEntries {
+		if strings.EqualFold(h.Key, TestIDHeader) {
+			return tc.testCase.Request
+		}
+	}
+	for name := range tc.testCase.Request.RawHeaders {
+		if strings.EqualFold(name, TestIDHeader) {
+			return tc.testCase.Request
+		}
+	}
+
+	// Share the body, which may weigh megabytes, instead of cloning it
+	req := shallowCopy(tc.testCase.Request)
+	req.Headers = maps.Clone(req.Headers)
+	if req.Headers == nil {
+		req.Headers = map[string]string{}
+	}
+	req.Headers[TestIDHeader] = tc.id()
+	return req
+}
+
+// request returns the request sent for a test case: the test request with its
+// random template functions rendered from the generator of the test.
+func (r *Runner) request(tc *testCaseWithManifest) (*extproctorv1.HttpRequest, error) {
+	req := r.testRequest(tc)
+	if req == nil || !random.IsTemplated(req) {
+		return req, nil
+	}
+
+	r.randomized.Store(true)
+	rendered, err := random.Render(req, random.ForTest(r.seed, tc.id()))
+	if err != nil {
+		return nil, fmt.Errorf("seed %d: %w", r.seed, err)
+	}
+	return rendered, nil
+}
+
+// artifactRequest returns the request sent for a test case, rendered again
+// with the same generator, or the unrendered one when rendering failed.
+func (r *Runner) artifactRequest(tc *testCaseWithManifest) *extproctorv1.HttpRequest {
+	if req, err := r.request(tc); err == nil {
+		return req
+	}
+	return r.testRequest(tc)
+}
+
+// shallowCopy returns a copy of the request sharing the values of its fields.
+func shallowCopy(req *extproctorv1.HttpRequest) *extproctorv1.HttpRequest {
+	dst := &extproctorv1.HttpRequest{}
+	m := dst.ProtoReflect()
+	req.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		m.Set(fd, v)
+		return true
+	})
+	return dst
+}
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+	"zntr.io/extproctor/internal/units"
+)
+
+// testLimits holds the parsed duration limits of a test case.
+type testLimits struct {
+	timeout    time.Duration
+	maxLatency time.Duration
+}
+
+// parseLimits parses the duration literals of a test case.
+func parseLimits(tc *extproctorv1.TestCase) (testLimits, error) {
+	var limits testLimits
+
+	if tc.Timeout != "" {
+		d, err := units.ParseDuration(tc.Timeout)
+		if err != nil {
+			return limits, fmt.Errorf("invalid timeout: %w", err)
+		}
+		limits.timeout = d
+	}
+
+	if tc.MaxLatency != "" {
+		d, err := units.ParseDuration(tc.MaxLatency)
+		if err != nil {
+			return limits, fmt.Errorf("invalid max_latency: %w", err)
+		}
+		limits.maxLatency = d
+	}
+
+	return limits, nil
+}
+
+// TimeoutError reports a test whose ExtProc session exceeded its timeout,
+// with the phase the ExtProc service stalled on.
+type TimeoutError struct {
+	// Phase is the phase waiting for the ExtProc service, unspecified when
+	// the stream could not be started.
+	Phase   extproctorv1.ProcessingPhase
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Phase == extproctorv1.ProcessingPhase_PROCESSING_PHASE_UNSPECIFIED {
+		return fmt.Sprintf("processing stream timed out after %s", e.Timeout)
+	}
+	return fmt.Sprintf("phase %s timed out after %s", e.Phase, e.Timeout)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// timeoutError returns the TimeoutError of a processing error caused by the
+// timeout of the test, the error itself otherwise.
+func timeoutError(ctx, processCtx context.Context, err error, timeout time.Duration) error {
+	if timeout <= 0 || ctx.Err() != nil || !errors.Is(processCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+
+	te := &TimeoutError{Timeout: timeout}
+	var phaseErr *client.PhaseError
+	if errors.As(err, &phaseErr) {
+		te.Phase = phaseErr.Phase
+	}
+	return te
+}
@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+)
+
+func TestParseLimits(t *testing.T) {
+	limits, err := parseLimits(&extproctorv1.TestCase{Timeout: "2s", MaxLatency: "150ms"})
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, limits.timeout)
+	assert.Equal(t, 150*time.Millisecond, limits.maxLatency)
+
+	limits, err = parseLimits(&extproctorv1.TestCase{})
+	require.NoError(t, err)
+	assert.Zero(t, limits)
+}
+
+func TestParseLimits_Invalid(t *testing.T) {
+	_, err := parseLimits(&extproctorv1.TestCase{Timeout: "2"})
+	assert.ErrorContains(t, err, "invalid timeout")
+
+	_, err = parseLimits(&extproctorv1.TestCase{MaxLatency: "fast"})
+	assert.ErrorContains(t, err, "invalid max_latency")
+}
+
+func TestTimeoutError(t *testing.T) {
+	expired, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-expired.Done()
+
+	phaseErr := &client.PhaseError{
+		Phase: extproctorv1.ProcessingPhase_RESPONSE_HEADERS,
+		Op:    "receive response for response headers",
+		Err:   context.DeadlineExceeded,
+	}
+	err := timeoutError(context.Background(), expired, phaseErr, 2*time.Second)
+	assert.EqualError(t, err, "phase RESPONSE_HEADERS timed out after 2s")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Errors without phase time out the stream
+	err = timeoutError(context.Background(), expired, errors.New("failed to start processing stream"), 2*time.Second)
+	assert.EqualError(t, err, "processing stream timed out after 2s")
+
+	// Errors not caused by the timeout of the test are kept
+	assert.Same(t, phaseErr, timeoutError(context.Background(), context.Background(), phaseErr, 2*time.Second))
+	assert.Same(t, phaseErr, timeoutError(expired, expired, phaseErr, 2*time.Second))
+	assert.Same(t, phaseErr, timeoutError(context.Background(), expired, phaseErr, 0))
+}
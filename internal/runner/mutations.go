@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"zntr.io/extproctor/internal/client"
+	"zntr.io/extproctor/internal/reporter"
+)
+
+// countMutations counts the changes made by the responses of the ExtProc
+// service.
+func countMutations(result *client.ProcessingResult) *reporter.Mutations {
+	m := &reporter.Mutations{}
+
+	addMutation := func(mutation *extprocv3.HeaderMutation) {
+		m.HeadersSet += len(mutation.GetSetHeaders())
+		m.HeadersRemoved += len(mutation.GetRemoveHeaders())
+	}
+	addCommon := func(common *extprocv3.CommonResponse) {
+		addMutation(common.GetHeaderMutation())
+		m.BodyBytes += int64(len(common.GetBodyMutation().GetBody()))
+		m.BodyBytes += int64(len(common.GetBodyMutation().GetStreamedResponse().GetBody()))
+	}
+
+	for _, pr := range result.Responses {
+		resp := pr.Response
+		switch {
+		case resp.GetImmediateResponse() != nil:
+			m.ImmediateResponses++
+		case resp.GetRequestHeaders() != nil:
+			addCommon(resp.GetRequestHeaders().GetResponse())
+		case resp.GetRequestBody() != nil:
+			addCommon(resp.GetRequestBody().GetResponse())
+		case resp.GetRequestTrailers() != nil:
+			addMutation(resp.GetRequestTrailers().GetHeaderMutation())
+		case resp.GetResponseHeaders() != nil:
+			addCommon(resp.GetResponseHeaders().GetResponse())
+		case resp.GetResponseBody() != nil:
+			addCommon(resp.GetResponseBody().GetResponse())
+		case resp.GetResponseTrailers() != nil:
+			addMutation(resp.GetResponseTrailers().GetHeaderMutation())
+		}
+	}
+
+	return m
+}
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+	"zntr.io/extproctor/internal/reporter"
+)
+
+func TestCountMutations(t *testing.T) {
+	result := &client.ProcessingResult{Responses: []*client.PhaseResponse{
+		{
+			Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			Response: &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestHeaders{
+				RequestHeaders: &extprocv3.HeadersResponse{Response: &extprocv3.CommonResponse{
+					HeaderMutation: &extprocv3.HeaderMutation{
+						SetHeaders: []*corev3.HeaderValueOption{
+							{Header: &corev3.HeaderValue{Key: "x-tenant", RawValue: []byte("acme")}},
+							{Header: &corev3.HeaderValue{Key: "x-user", RawValue: []byte("alice")}},
+						},
+						RemoveHeaders: []string{"authorization"},
+					},
+				}},
+			}},
+		},
+		{
+			Phase: extproctorv1.ProcessingPhase_REQUEST_BODY,
+			Response: &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestBody{
+				RequestBody: &extprocv3.BodyResponse{Response: &extprocv3.CommonResponse{
+					BodyMutation: &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: []byte(`{"redacted":true}`)}},
+				}},
+			}},
+		},
+		{
+			Phase: extproctorv1.ProcessingPhase_REQUEST_TRAILERS,
+			Response: &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestTrailers{
+				RequestTrailers: &extprocv3.TrailersResponse{HeaderMutation: &extprocv3.HeaderMutation{RemoveHeaders: []string{"x-checksum"}}},
+			}},
+		},
+		{
+			Phase: extproctorv1.ProcessingPhase_RESPONSE_HEADERS,
+			Response: &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+				ImmediateResponse: &extprocv3.ImmediateResponse{},
+			}},
+		},
+	}}
+
+	assert.Equal(t, &reporter.Mutations{
+		HeadersSet:         2,
+		HeadersRemoved:     2,
+		BodyBytes:          17,
+		ImmediateResponses: 1,
+	}, countMutations(result))
+	assert.Equal(t, &reporter.Mutations{}, countMutations(&client.ProcessingResult{}))
+}
@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"zntr.io/extproctor/internal/client"
+)
+
+// defaultReconnectTimeout is how long the tests wait for a lost target to be
+// reconnected when no timeout is configured.
+const defaultReconnectTimeout = time.Minute
+
+// Reconnect configures the reconnection to the targets whose connection
+// dropped during the run.
+type Reconnect struct {
+	// Max is the number of reconnections allowed per target. Once reached,
+	// the tests of the target fail as soon as its connection drops.
+	Max int
+
+	// Timeout is how long the tests wait for the connection to a target to
+	// be established again.
+	Timeout time.Duration
+
+	// Log receives a line per reconnection.
+	Log io.Writer
+}
+
+// WithReconnect pauses the tests of a target whose connection dropped until
+// it is established again, instead of failing them all at once. The test
+// failing with the connection error stays failed.
+func WithReconnect(rc Reconnect) Option {
+	return func(r *Runner) {
+		if rc.Timeout <= 0 {
+			rc.Timeout = defaultReconnectTimeout
+		}
+		r.reconnect = &rc
+	}
+}
+
+// startReconnects sets up the reconnection of each target.
+func (r *Runner) startReconnects() {
+	r.redialers = nil
+	if r.reconnect == nil || r.reconnect.Max <= 0 {
+		return
+	}
+
+	targets := r.targets
+	if len(targets) == 0 {
+		targets = []Target{{Name: r.targetName, Client: r.client}}
+	}
+
+	r.redialers = make(map[*client.Client]*redialer, len(targets))
+	for _, t := range targets {
+		name := t.Name
+		if name == "" {
+			name = t.Client.Target()
+		}
+		r.redialers[t.Client] = &redialer{
+			name:    name,
+			client:  t.Client,
+			max:     r.reconnect.Max,
+			timeout: r.reconnect.Timeout,
+			log:     r.reconnect.Log,
+		}
+	}
+}
+
+// redialerFor returns the redialer of the target of a test case, or nil
+// without reconnection.
+func (r *Runner) redialerFor(tc *testCaseWithManifest) *redialer {
+	return r.redialers[r.clientFor(tc)]
+}
+
+// redialer reconnects a target whose connection dropped.
+type redialer struct {
+	name    string
+	client  *client.Client
+	max     int
+	timeout time.Duration
+	log     io.Writer
+
+	mu         sync.Mutex
+	reconnects int
+
+	// done is closed when the reconnection in progress ends, nil when none
+	// is.
+	done chan struct{}
+}
+
+// redial waits for the connection to the target to be established again,
+// for up to the timeout of the redialer. Tests failing while a reconnection
+// is in progress wait for it rather than starting another one.
+func (d *redialer) redial(ctx context.Context) {
+	d.mu.Lock()
+	if done := d.done; done != nil {
+		d.mu.Unlock()
+		d.waitFor(ctx, done)
+		return
+	}
+	if d.client.Ready() {
+		// Reconnected by a previous test
+		d.mu.Unlock()
+		return
+	}
+	if d.reconnects >= d.max {
+		d.mu.Unlock()
+		return
+	}
+	d.reconnects++
+	attempt := d.reconnects
+	done := make(chan struct{})
+	d.done = done
+	d.mu.Unlock()
+
+	d.logf("connection to %s lost, pausing its tests until it reconnects (%d/%d)", d.name, attempt, d.max)
+	start := time.Now()
+	waitCtx, cancel := context.WithTimeout(ctx, d.timeout)
+	err := d.client.WaitReady(waitCtx)
+	cancel()
+	switch {
+	case err == nil:
+		d.logf("reconnected to %s after %s, resuming its tests", d.name, time.Since(start).Round(time.Millisecond))
+	case ctx.Err() == nil:
+		d.logf("failed to reconnect to %s: %v, resuming its tests", d.name, err)
+	}
+
+	d.mu.Lock()
+	d.done = nil
+	d.mu.Unlock()
+	close(done)
+}
+
+// wait blocks while a reconnection of the target is in progress.
+func (d *redialer) wait(ctx context.Context) {
+	d.mu.Lock()
+	done := d.done
+	d.mu.Unlock()
+	if done != nil {
+		d.waitFor(ctx, done)
+	}
+}
+
+func (d *redialer) waitFor(ctx context.Context, done <-chan struct{}) {
+	select {
+	case <-ctx.Done():
+	case <-done:
+	}
+}
+
+// logf writes a log line, when a log is configured.
+func (d *redialer) logf(format string, args ...any) {
+	if d.log != nil {
+		fmt.Fprintf(d.log, "reconnect: "+format+"\n", args...)
+	}
+}
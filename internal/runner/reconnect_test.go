@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+)
+
+// reconnectTests returns test cases expecting a headers response.
+func reconnectTests(names ...string) []*testCaseWithManifest {
+	tcs := scheduledTests(nil, names...)
+	for _, tc := range tcs {
+		tc.testCase.Request = &extproctorv1.HttpRequest{Method: "GET", Path: "/"}
+		tc.testCase.Expectations = []*extproctorv1.ExtProcExpectation{{
+			Phase:    extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			Response: &extproctorv1.ExtProcExpectation_HeadersResponse{HeadersResponse: &extproctorv1.HeadersExpectation{}},
+		}}
+	}
+	return tcs
+}
+
+// downTarget returns a client of a target not listening yet, and a function
+// starting the target.
+func downTarget(t *testing.T) (*client.Client, func()) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	c, err := client.New(client.WithTarget(address), client.WithReconnectBackoff(50*time.Millisecond))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c, func() {
+		lis, err := net.Listen("tcp", address)
+		require.NoError(t, err)
+		grpcServer := grpc.NewServer()
+		extprocv3.RegisterExternalProcessorServer(grpcServer, headersProcessor{})
+		go func() { _ = grpcServer.Serve(lis) }()
+		t.Cleanup(grpcServer.Stop)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	c, start := downTarget(t)
+	log := &bytes.Buffer{}
+	r := New(c, WithReconnect(Reconnect{Max: 1, Timeout: 5 * time.Second, Log: log}))
+	r.startReconnects()
+
+	// The target comes back while the first test waits for it
+	time.AfterFunc(200*time.Millisecond, start)
+	results := &Results{}
+	r.runSequential(context.Background(), reconnectTests("a", "b"), results)
+
+	assert.Equal(t, 1, results.Failed)
+	assert.Equal(t, 1, results.Passed)
+	assert.False(t, results.Tests[0].Passed)
+	assert.True(t, results.Tests[1].Passed, results.Tests[1].Error)
+	assert.Contains(t, log.String(), "(1/1)\n")
+	assert.Contains(t, log.String(), "resuming its tests\n")
+}
+
+func TestReconnect_MaxReconnects(t *testing.T) {
+	c, _ := downTarget(t)
+	log := &bytes.Buffer{}
+	r := New(c, WithReconnect(Reconnect{Max: 1, Timeout: 100 * time.Millisecond, Log: log}))
+	r.startReconnects()
+
+	results := &Results{}
+	r.runSequential(context.Background(), reconnectTests("a", "b", "c"), results)
+
+	// Once the reconnection failed, the tests fail without waiting
+	assert.Equal(t, 3, results.Failed)
+	assert.Equal(t, 1, strings.Count(log.String(), "pausing its tests"))
+	assert.Contains(t, log.String(), "failed to reconnect to ")
+}
+
+func TestStartReconnects_Disabled(t *testing.T) {
+	r := New(nil)
+	r.startReconnects()
+	assert.Nil(t, r.redialers)
+
+	r = New(nil, WithReconnect(Reconnect{}))
+	r.startReconnects()
+	assert.Nil(t, r.redialers)
+	assert.Equal(t, defaultReconnectTimeout, r.reconnect.Timeout)
+}
@@ -5,14 +5,21 @@ package runner
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/artifacts"
 	"zntr.io/extproctor/internal/client"
 	"zntr.io/extproctor/internal/comparator"
+	"zntr.io/extproctor/internal/filterlog"
 	"zntr.io/extproctor/internal/golden"
 	"zntr.io/extproctor/internal/manifest"
 	"zntr.io/extproctor/internal/reporter"
@@ -21,13 +28,56 @@ import (
 // Runner executes test cases against an ExtProc service.
 type Runner struct {
 	client       *client.Client
-	comparator   *comparator.Comparator
+	comparator   comparator.Interface
 	reporter     reporter.Reporter
 	parallel     int
 	verbose      bool
 	filter       string
 	tags         []string
+	owners       []string
+	targetName   string
+	targets      []Target
+	maxDiffBytes int
+	artifactsDir string
+	filterLog    *filterlog.Tail
+	changed      map[string]bool
+	only         map[string]bool
+	first        map[string]bool
+	durations    map[string]time.Duration
 	updateGolden bool
+	injectID     bool
+	smokeFirst   bool
+	failFast     bool
+	skipInfra    bool
+	keepResps    bool
+	maxDuration  time.Duration
+	testTimeout  time.Duration
+	seed         uint64
+	labels       map[string]string
+	healthCheck  *HealthCheck
+	reconnect    *Reconnect
+	budget       Budget
+
+	// gates tracks the health of each target client, set by Run when health
+	// checks are configured.
+	gates map[*client.Client]*healthGate
+
+	// redialers reconnects each target client, set by Run when reconnection
+	// is configured.
+	redialers map[*client.Client]*redialer
+
+	// deadline is the time after which no test is started, set by Run when a
+	// maximum duration is configured.
+	deadline time.Time
+
+	// aborted is set once a test fails with fail-fast enabled.
+	aborted atomic.Bool
+
+	// randomized is set once a test request used random template functions.
+	randomized atomic.Bool
+
+	// events serializes the events reported by the tests and health checks.
+	events sync.Mutex
 }
 
 // Option configures the runner.
@@ -68,6 +118,82 @@ func WithTags(tags []string) Option {
 	}
 }
 
+// WithOwners sets the manifest owner filter.
+func WithOwners(owners []string) Option {
+	return func(r *Runner) {
+		r.owners = owners
+	}
+}
+
+// WithTargetName sets the name substituted for {target} in golden paths.
+func WithTargetName(name string) Option {
+	return func(r *Runner) {
+		r.targetName = name
+	}
+}
+
+// WithMaxDiffBytes sets the length difference values are truncated to in
+// reports. Zero disables truncation.
+func WithMaxDiffBytes(n int) Option {
+	return func(r *Runner) {
+		r.maxDiffBytes = n
+	}
+}
+
+// WithChangedFiles restricts the run to the test cases defined in or
+// referencing (golden files) one of the given files. A nil list disables the
+// restriction.
+func WithChangedFiles(files []string) Option {
+	return func(r *Runner) {
+		if files == nil {
+			r.changed = nil
+			return
+		}
+		r.changed = make(map[string]bool, len(files))
+		for _, f := range files {
+			r.changed[canonicalPath(f)] = true
+		}
+	}
+}
+
+// WithArtifactsDir enables writing the request, raw responses and differences
+// of each failed test in a per-test folder of the given directory.
+func WithArtifactsDir(dir string) Option {
+	return func(r *Runner) {
+		r.artifactsDir = dir
+	}
+}
+
+// WithFilterLog enables capturing the lines written by the ExtProc service to
+// the given log file while each test runs, attached to failed tests.
+func WithFilterLog(path string) Option {
+	return func(r *Runner) {
+		r.filterLog = filterlog.NewTail(path)
+	}
+}
+
+// WithTestIDs restricts the run to the tests with the given IDs.
+func WithTestIDs(ids []string) Option {
+	return func(r *Runner) {
+		r.only = idSet(ids)
+	}
+}
+
+// WithFirst runs the tests with the given IDs before the others.
+func WithFirst(ids []string) Option {
+	return func(r *Runner) {
+		r.first = idSet(ids)
+	}
+}
+
+// WithDurations sets the duration of the tests in the previous runs, by test
+// ID. Under parallelism, the longest tests start first.
+func WithDurations(durations map[string]time.Duration) Option {
+	return func(r *Runner) {
+		r.durations = durations
+	}
+}
+
 // WithUpdateGolden enables golden file updates.
 func WithUpdateGolden(update bool) Option {
 	return func(r *Runner) {
@@ -75,12 +201,95 @@ func WithUpdateGolden(update bool) Option {
 	}
 }
 
+// WithTestIDHeader enables or disables the injection of the test ID in the
+// request headers (enabled by default).
+func WithTestIDHeader(enabled bool) Option {
+	return func(r *Runner) {
+		r.injectID = enabled
+	}
+}
+
+// WithSeed sets the seed of the random template functions of test requests.
+// Each test draws its values from a generator seeded from it and its test ID.
+func WithSeed(seed uint64) Option {
+	return func(r *Runner) {
+		r.seed = seed
+	}
+}
+
+// WithLabels attaches key=value labels to the run (e.g. env=staging),
+// reported in the suite summary so that results can be sliced across runs.
+func WithLabels(labels map[string]string) Option {
+	return func(r *Runner) {
+		r.labels = labels
+	}
+}
+
+// WithSmokeFirst runs the smoke tests (positive priority) to completion
+// before starting the other tests.
+func WithSmokeFirst(enabled bool) Option {
+	return func(r *Runner) {
+		r.smokeFirst = enabled
+	}
+}
+
+// WithFailFast stops starting tests after the first failure; the tests not
+// run are reported as skipped.
+func WithFailFast(enabled bool) Option {
+	return func(r *Runner) {
+		r.failFast = enabled
+	}
+}
+
+// WithSkipInfraFailures reports the tests failing because of the
+// infrastructure (connection errors and timeouts) as skipped instead of
+// failed.
+func WithSkipInfraFailures(enabled bool) Option {
+	return func(r *Runner) {
+		r.skipInfra = enabled
+	}
+}
+
+// WithMaxDuration stops starting tests once the run lasted the given
+// duration; the tests not run are reported as skipped.
+func WithMaxDuration(d time.Duration) Option {
+	return func(r *Runner) {
+		r.maxDuration = d
+	}
+}
+
+// WithComparator replaces the default comparator matching the responses of
+// the ExtProc service against the expectations of the tests.
+func WithComparator(c comparator.Interface) Option {
+	return func(r *Runner) {
+		r.comparator = c
+	}
+}
+
+// WithTestTimeout sets the timeout of the tests not declaring their own, so
+// that an ExtProc service stalling a stream fails the test instead of the
+// whole run.
+func WithTestTimeout(d time.Duration) Option {
+	return func(r *Runner) {
+		r.testTimeout = d
+	}
+}
+
+// WithResponses keeps the responses of the ExtProc service in the test
+// results, for callers asserting on them.
+func WithResponses(keep bool) Option {
+	return func(r *Runner) {
+		r.keepResps = keep
+	}
+}
+
 // New creates a new test runner.
 func New(client *client.Client, opts ...Option) *Runner {
 	r := &Runner{
 		client:     client,
 		comparator: comparator.New(),
 		parallel:   1,
+		injectID:   true,
 	}
 
 	for _, opt := range opts {
@@ -98,18 +307,92 @@ type Results struct {
 	Skipped  int
 	Duration time.Duration
 	Tests    []*TestResult
+
+	// BudgetExceeded is set when tests were skipped because the maximum
+	// duration of the run was reached.
+	BudgetExceeded bool
+
+	// NotServing is set when tests were skipped because their target did not
+	// serve again in time.
+	NotServing bool
+
+	// InfraSkipped counts the tests skipped because they failed because of
+	// the infrastructure.
+	InfraSkipped int
+
+	// Seed is the seed of the random template functions, reported when
+	// Randomized is set because a test request used them.
+	Seed       uint64
+	Randomized bool
+
+	// Labels are the key=value labels attached to the run.
+	Labels map[string]string
+
+	// ByTag, ByManifest, ByOwner and ByTarget break the results down per
+	// test tag, per manifest, per manifest owner and per fan-out target.
+	ByTag      []reporter.GroupStats
+	ByManifest []reporter.GroupStats
+	ByOwner    []reporter.GroupStats
+	ByTarget   []reporter.GroupStats
+
+	// Targets lists the fan-out targets, and Matrix the status of each test
+	// on each of them.
+	Targets []string
+	Matrix  []reporter.MatrixRow
+
+	// Cost aggregates the cost hints of the tests.
+	Cost reporter.Cost
+
+	// Failures counts the failed tests per failure category.
+	Failures []reporter.CategoryCount
 }
 
 // TestResult contains the result of a single test.
 type TestResult struct {
 	Name        string
+	ID          string
+	UID         string
+	Manifest    string
+	Owner       string
+	Target      string
+	Tags        []string
 	Passed      bool
 	Skipped     bool
+	SkipReason  string
 	Duration    time.Duration
 	Error       error
 	Differences []comparator.Difference
 	Unmatched   []*extproctorv1.ExtProcExpectation
 	Unexpected  []*client.PhaseResponse
+
+	// Category classifies the failure of a failed test.
+	Category reporter.Category
+
+	// Mutations counts what the ExtProc service changed, nil when the test
+	// did not reach it.
+	Mutations *reporter.Mutations
+
+	// Responses are the responses of the ExtProc service, only kept with
+	// WithResponses.
+	Responses []*client.PhaseResponse
+
+	// SkippedPhases lists the phases never sent because of an immediate response.
+	SkippedPhases []extproctorv1.ProcessingPhase
+
+	// SuppressedPhases lists the phases never sent because a mode_override
+	// of the ExtProc service disabled them.
+	SuppressedPhases []extproctorv1.ProcessingPhase
+
+	// UnmatchedReasons explains unmatched expectations whose phase was never sent.
+	UnmatchedReasons map[*extproctorv1.ExtProcExpectation]string
+
+	// Logs contains the ExtProc service log lines written while a failed
+	// test ran.
+	Logs []string
+
+	// Notes annotates the result, e.g. when the target was not serving while
+	// the test ran.
+	Notes []string
 }
 
 // Run executes all test cases from the loaded manifests.
@@ -117,17 +400,39 @@ func (r *Runner) Run(ctx context.Context, manifests []*manifest.LoadedManifest)
 	// Collect all test cases
 	var testCases []*testCaseWithManifest
 	for _, m := range manifests {
+		if !r.isOwned(m) {
+			continue
+		}
 		for _, tc := range m.TestCases {
-			if r.shouldRun(tc) {
-				testCases = append(testCases, &testCaseWithManifest{
-					testCase:   tc,
-					manifest:   m,
-					sourcePath: m.SourcePath,
-				})
+			if !r.shouldRun(tc) {
+				continue
+			}
+			twm := &testCaseWithManifest{
+				testCase:     tc,
+				manifest:     m,
+				sourcePath:   m.SourcePath,
+				dependencies: m.Dependencies[tc],
+			}
+			if !r.isAffected(twm) {
+				continue
+			}
+			if r.only != nil && !r.only[twm.id()] {
+				continue
 			}
+			testCases = append(testCases, twm)
 		}
 	}
 
+	r.orderTests(testCases)
+	testCases = r.fanOut(testCases)
+
+	cost := suiteCost(testCases)
+	if err := r.checkBudget(len(testCases), cost); err != nil {
+		return nil, err
+	}
+	r.aborted.Store(false)
+	r.randomized.Store(false)
+
 	results := &Results{
 		Total: len(testCases),
 		Tests: make([]*TestResult, 0, len(testCases)),
@@ -138,22 +443,49 @@ func (r *Runner) Run(ctx context.Context, manifests []*manifest.LoadedManifest)
 	}
 
 	startTime := time.Now()
+	r.deadline = time.Time{}
+	if r.maxDuration > 0 {
+		r.deadline = startTime.Add(r.maxDuration)
+	}
 
-	if r.parallel > 1 {
-		r.runParallel(ctx, testCases, results)
-	} else {
-		r.runSequential(ctx, testCases, results)
+	r.startReconnects()
+	stopHealthChecks := r.startHealthChecks(ctx)
+	for _, stage := range r.stages(testCases) {
+		if r.parallel > 1 {
+			r.runParallel(ctx, stage, results)
+		} else {
+			r.runSequential(ctx, stage, results)
+		}
 	}
+	stopHealthChecks()
 
 	results.Duration = time.Since(startTime)
+	results.ByTag, results.ByManifest, results.ByOwner, results.ByTarget = breakdown(results.Tests)
+	results.Targets = r.targetNames()
+	results.Matrix = matrix(results.Tests, results.Targets)
+	results.Cost = cost
+	results.Failures = failures(results.Tests)
+	results.Seed, results.Randomized = r.seed, r.randomized.Load()
+	results.Labels = r.labels
 
 	if r.reporter != nil {
 		r.reporter.EndSuite(reporter.SuiteSummary{
-			Total:    results.Total,
-			Passed:   results.Passed,
-			Failed:   results.Failed,
-			Skipped:  results.Skipped,
-			Duration: results.Duration,
+			Total:      results.Total,
+			Passed:     results.Passed,
+			Failed:     results.Failed,
+			Skipped:    results.Skipped,
+			Duration:   results.Duration,
+			ByTag:      results.ByTag,
+			ByManifest: results.ByManifest,
+			ByOwner:    results.ByOwner,
+			ByTarget:   results.ByTarget,
+			Targets:    results.Targets,
+			Matrix:     results.Matrix,
+			Cost:       results.Cost,
+			Failures:   results.Failures,
+			Seed:       results.Seed,
+			Randomized: results.Randomized,
+			Labels:     results.Labels,
 		})
 	}
 
@@ -164,6 +496,30 @@ type testCaseWithManifest struct {
 	testCase   *extproctorv1.TestCase
 	manifest   *manifest.LoadedManifest
 	sourcePath string
+
+	// dependencies lists the imported manifests the test case inherits from.
+	dependencies []string
+
+	// target is the fan-out target the test case runs against, if any.
+	target *Target
+}
+
+// id returns the persistent ID of the test case.
+func (tc *testCaseWithManifest) id() string {
+	return TestID(tc.sourcePath, tc.testCase.Name)
+}
+
+func (tc *testCaseWithManifest) uid() string {
+	return TestUID(tc.id(), tc.testCase)
+}
+
+// targetName returns the name of the fan-out target of the test case, or an
+// empty string.
+func (tc *testCaseWithManifest) targetName() string {
+	if tc.target == nil {
+		return ""
+	}
+	return tc.target.Name
 }
 
 // runSequential runs tests one at a time.
@@ -175,37 +531,48 @@ func (r *Runner) runSequential(ctx context.Context, testCases []*testCaseWithMan
 		default:
 		}
 
+		if reason := r.skipReasonFor(ctx, tc); reason != "" {
+			r.recordResult(results, r.skipTest(tc, reason))
+			continue
+		}
+
 		result := r.runTest(ctx, tc)
 		r.recordResult(results, result)
 	}
 }
 
-// runParallel runs tests concurrently.
+// runParallel runs tests concurrently on a pool of workers, each taking the
+// next test of the queue once its previous test ended. A test case whose
+// manifest reached its `parallel` limit waits, letting the next test cases of
+// other manifests start instead.
 func (r *Runner) runParallel(ctx context.Context, testCases []*testCaseWithManifest, results *Results) {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
-	sem := make(chan struct{}, r.parallel)
-
-	for _, tc := range testCases {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-		}
+	q := newQueue(testCases)
 
+	for range min(r.parallel, len(testCases)) {
 		wg.Add(1)
-		sem <- struct{}{}
-
-		go func(tc *testCaseWithManifest) {
+		go func() {
 			defer wg.Done()
-			defer func() { <-sem }()
+			for {
+				tc := q.next(ctx)
+				if tc == nil {
+					return
+				}
 
-			result := r.runTest(ctx, tc)
+				var result *TestResult
+				if reason := r.skipReasonFor(ctx, tc); reason != "" {
+					result = r.skipTest(tc, reason)
+				} else {
+					result = r.runTest(ctx, tc)
+				}
+				q.done(tc)
 
-			mu.Lock()
-			r.recordResult(results, result)
-			mu.Unlock()
-		}(tc)
+				mu.Lock()
+				r.recordResult(results, result)
+				mu.Unlock()
+			}
+		}()
 	}
 
 	wg.Wait()
@@ -214,44 +581,123 @@ func (r *Runner) runParallel(ctx context.Context, testCases []*testCaseWithManif
 // runTest executes a single test case.
 func (r *Runner) runTest(ctx context.Context, tc *testCaseWithManifest) *TestResult {
 	if r.reporter != nil {
-		r.reporter.StartTest(tc.testCase.Name)
+		r.reporter.StartTest(reporter.DisplayName(tc.testCase.Name, tc.targetName()))
+	}
+
+	gate := r.gateFor(tc)
+	var outages int
+	if gate != nil {
+		_, outages, _ = gate.state()
+	}
+
+	var logOffset int64
+	if r.filterLog != nil {
+		// A missing offset only means more log lines get attached
+		logOffset, _ = r.filterLog.Offset()
 	}
 
 	startTime := time.Now()
 	result := &TestResult{
-		Name: tc.testCase.Name,
+		ID:       tc.id(),
+		UID:      tc.uid(),
+		Name:     tc.testCase.Name,
+		Manifest: manifestName(tc.manifest),
+		Owner:    tc.manifest.GetOwner(),
+		Target:   tc.targetName(),
+		Tags:     tc.testCase.Tags,
+	}
+	finish := func(procResult *client.ProcessingResult) {
+		annotateHealth(ctx, gate, outages, result)
+		r.finishTest(tc, result, procResult, logOffset)
 	}
 
-	// Process the request
-	procResult, err := r.client.Process(ctx, tc.testCase.Request)
+	limits, err := parseLimits(tc.testCase)
 	if err != nil {
 		result.Error = err
+		result.Category = reporter.CategoryTestError
 		result.Duration = time.Since(startTime)
-		r.reportResult(result)
+		finish(nil)
 		return result
 	}
 
-	// Get expectations (from inline or golden file)
-	expectations, err := r.getExpectations(tc)
+	if limits.timeout == 0 {
+		limits.timeout = r.testTimeout
+	}
+
+	processCtx := r.observeRetries(ctx, tc)
+	if limits.timeout > 0 {
+		var cancel context.CancelFunc
+		processCtx, cancel = context.WithTimeout(processCtx, limits.timeout)
+		defer cancel()
+	}
+
+	req, err := r.request(tc)
+	if err != nil {
+		result.Error = err
+		result.Category = reporter.CategoryTestError
+		result.Duration = time.Since(startTime)
+		finish(nil)
+		return result
+	}
+
+	c, err := r.channelFor(tc)
 	if err != nil {
 		result.Error = err
+		result.Category = classifyError(err)
 		result.Duration = time.Since(startTime)
-		r.reportResult(result)
+		finish(nil)
+		return result
+	}
+
+	// Process the request
+	processStart := time.Now()
+	procResult, err := c.ProcessExchange(processCtx, req, tc.testCase.Response, tc.testCase.PhaseSequence)
+	latency := time.Since(processStart)
+	r.reportPhases(tc, procResult)
+	if err != nil {
+		result.Error = timeoutError(ctx, processCtx, err, limits.timeout)
+		result.Category = classifyError(err)
+		result.Duration = time.Since(startTime)
+		finish(procResult)
+		if d := r.redialerFor(tc); d != nil && result.Category == reporter.CategoryConnection {
+			d.redial(ctx)
+		}
 		return result
 	}
 
 	// Update golden file if requested
 	if r.updateGolden && tc.testCase.GoldenFile != "" {
 		goldenPath := r.resolveGoldenPath(tc)
-		if err := golden.Write(goldenPath, procResult); err != nil {
+		meta := golden.Metadata{RequestDigest: golden.RequestDigest(tc.testCase.Request)}
+		err := golden.WriteTemplate(goldenPath, r.goldenVars(tc), procResult, meta)
+		r.emit(reporter.GoldenUpdated{
+			Test: testRef(tc),
+			Path: golden.ExpandVars(goldenPath, r.goldenVars(tc)),
+			Err:  err,
+		})
+		if err != nil {
 			result.Error = err
+			result.Category = reporter.CategoryTestError
 			result.Duration = time.Since(startTime)
-			r.reportResult(result)
+			finish(procResult)
 			return result
 		}
 		result.Passed = true
 		result.Duration = time.Since(startTime)
-		r.reportResult(result)
+		finish(procResult)
+		return result
+	}
+
+	// Get expectations (from inline or golden file)
+	expectations, goldenMeta, err := r.getExpectations(tc)
+	if err != nil {
+		result.Error = err
+		result.Category = reporter.CategoryTestError
+		if errors.Is(err, fs.ErrNotExist) {
+			result.Category = reporter.CategoryGoldenMissing
+		}
+		result.Duration = time.Since(startTime)
+		finish(procResult)
 		return result
 	}
 
@@ -262,27 +708,92 @@ func (r *Runner) runTest(ctx context.Context, tc *testCaseWithManifest) *TestRes
 	result.Differences = compResult.Differences
 	result.Unmatched = compResult.Unmatched
 	result.Unexpected = compResult.Unexpected
+	result.SkippedPhases = procResult.SkippedPhases
+	result.SuppressedPhases = procResult.SuppressedPhases
+	result.UnmatchedReasons = compResult.UnmatchedReasons
+
+	// Test-level assertions, filtered once they all ran by the paths the
+	// expectations ignore
+	var testDiffs []comparator.Difference
+	if limits.maxLatency > 0 && latency > limits.maxLatency {
+		testDiffs = append(testDiffs, comparator.Difference{
+			Path:     "max_latency",
+			Expected: fmt.Sprintf("<= %s", limits.maxLatency),
+			Actual:   latency.String(),
+		})
+	}
+	if tc.testCase.Redaction != nil {
+		testDiffs = append(testDiffs, comparator.CompareRedaction(tc.testCase.Redaction, procResult)...)
+	}
+	if tc.testCase.Stream != nil {
+		testDiffs = append(testDiffs, comparator.CompareStream(tc.testCase.Stream, procResult)...)
+	}
+	if diffs := comparator.FilterIgnored(testDiffs, expectations); len(diffs) > 0 {
+		result.Passed = false
+		result.Differences = append(result.Differences, diffs...)
+	}
+
+	// Golden files generated from another request are likely stale
+	if goldenMeta.RequestDigest != "" && goldenMeta.RequestDigest != golden.RequestDigest(tc.testCase.Request) {
+		result.Notes = append(result.Notes, "the golden file is likely stale: the request changed since it was generated (regenerate it with --update-golden)")
+	}
+
+	// Known failures do not fail the run
+	if !result.Passed && tc.testCase.ExpectedFailure {
+		result.Skipped = true
+		result.SkipReason = SkipReasonExpectedFailure
+	}
+
 	result.Duration = time.Since(startTime)
 
-	r.reportResult(result)
+	finish(procResult)
 	return result
 }
 
-// getExpectations returns expectations from inline definitions or golden files.
-func (r *Runner) getExpectations(tc *testCaseWithManifest) ([]*extproctorv1.ExtProcExpectation, error) {
+// annotateHealth notes on a failed test that its target was not serving
+// while it ran. A test failing with an error checks the target health at
+// once, to pause the next tests without waiting for the periodic check.
+func annotateHealth(ctx context.Context, gate *healthGate, outages int, result *TestResult) {
+	if gate == nil || result.Passed || result.Skipped {
+		return
+	}
+	if result.Error != nil {
+		gate.update(ctx)
+	}
+	if serving, after, reason := gate.state(); !serving || after != outages {
+		note := "the target was not serving while the test ran"
+		if reason != "" {
+			note += " (" + reason + ")"
+		}
+		result.Notes = append(result.Notes, note)
+	}
+}
+
+// getExpectations returns expectations from inline definitions or golden
+// files, with the metadata of the golden files.
+func (r *Runner) getExpectations(tc *testCaseWithManifest) ([]*extproctorv1.ExtProcExpectation, golden.Metadata, error) {
 	if len(tc.testCase.Expectations) > 0 {
-		return tc.testCase.Expectations, nil
+		return tc.testCase.Expectations, golden.Metadata{}, nil
 	}
 
 	if tc.testCase.GoldenFile != "" {
 		goldenPath := r.resolveGoldenPath(tc)
-		return golden.Read(goldenPath)
+		return golden.ReadTemplate(goldenPath, r.goldenVars(tc))
 	}
 
-	return nil, nil
+	return nil, golden.Metadata{}, nil
+}
+
+// goldenVars returns the values substituted in the golden path template.
+func (r *Runner) goldenVars(tc *testCaseWithManifest) golden.PathVars {
+	return golden.PathVars{
+		TestName: tc.testCase.Name,
+		Target:   r.targetNameFor(tc),
+	}
 }
 
-// resolveGoldenPath resolves the golden file path relative to the manifest.
+// resolveGoldenPath resolves the golden file path template relative to the
+// manifest. Placeholders are resolved when reading or writing the file.
 func (r *Runner) resolveGoldenPath(tc *testCaseWithManifest) string {
 	if filepath.IsAbs(tc.testCase.GoldenFile) {
 		return tc.testCase.GoldenFile
@@ -290,20 +801,100 @@ func (r *Runner) resolveGoldenPath(tc *testCaseWithManifest) string {
 	return filepath.Join(filepath.Dir(tc.sourcePath), tc.testCase.GoldenFile)
 }
 
-// reportResult reports a test result to the reporter.
-func (r *Runner) reportResult(result *TestResult) {
-	if r.reporter != nil {
-		r.reporter.EndTest(reporter.TestResult{
-			Name:        result.Name,
-			Passed:      result.Passed,
-			Skipped:     result.Skipped,
-			Duration:    result.Duration,
+// finishTest attaches the ExtProc service logs and writes the artifacts of a
+// failed test, or removes the stale ones of a passing test, then reports its
+// result.
+func (r *Runner) finishTest(tc *testCaseWithManifest, result *TestResult, procResult *client.ProcessingResult, logOffset int64) {
+	failed := !result.Passed && !result.Skipped
+	if failed && result.Category == "" {
+		result.Category = reporter.CategoryAssertion
+	}
+	if procResult != nil {
+		result.Mutations = countMutations(procResult)
+		if r.keepResps {
+			result.Responses = procResult.Responses
+		}
+	}
+
+	if r.filterLog != nil && failed {
+		logs, err := r.filterLog.Since(logOffset)
+		if err != nil {
+			result.Error = errors.Join(result.Error, err)
+		}
+		result.Logs = logs
+	}
+
+	if r.artifactsDir != "" && failed {
+		_, err := artifacts.Write(r.artifactsDir, &artifacts.Test{
+			ID:          artifactID(result),
+			Request:     r.artifactRequest(tc),
+			Result:      procResult,
 			Error:       result.Error,
 			Differences: result.Differences,
 			Unmatched:   result.Unmatched,
 			Unexpected:  result.Unexpected,
+			Logs:        result.Logs,
 		})
+		if err != nil {
+			result.Error = errors.Join(result.Error, err)
+		}
+	} else if r.artifactsDir != "" {
+		// Drop the artifacts of a previous failure
+		_ = os.RemoveAll(artifacts.Dir(r.artifactsDir, artifactID(result)))
 	}
+
+	if failed && r.skipInfra && result.Category.Infra() {
+		result.Skipped = true
+		result.SkipReason = SkipReasonInfraFailure
+	}
+
+	r.reportResult(result)
+}
+
+// reportResult reports a test result to the reporter.
+func (r *Runner) reportResult(result *TestResult) {
+	if r.reporter != nil {
+		r.reporter.EndTest(reporter.TestResult{
+			ID:               result.ID,
+			UID:              result.UID,
+			Name:             result.Name,
+			Manifest:         result.Manifest,
+			Owner:            result.Owner,
+			Target:           result.Target,
+			Tags:             result.Tags,
+			Passed:           result.Passed,
+			Skipped:          result.Skipped,
+			SkipReason:       result.SkipReason,
+			Duration:         result.Duration,
+			Error:            result.Error,
+			Category:         result.Category,
+			Mutations:        result.Mutations,
+			Differences:      r.renderDifferences(result.Differences),
+			Unmatched:        result.Unmatched,
+			Unexpected:       result.Unexpected,
+			SkippedPhases:    result.SkippedPhases,
+			SuppressedPhases: result.SuppressedPhases,
+			UnmatchedReasons: result.UnmatchedReasons,
+			Logs:             result.Logs,
+			Notes:            result.Notes,
+		})
+	}
+}
+
+// renderDifferences returns the differences with values made safe for
+// terminals and reports (escaped and truncated).
+func (r *Runner) renderDifferences(diffs []comparator.Difference) []comparator.Difference {
+	if len(diffs) == 0 {
+		return diffs
+	}
+
+	rendered := make([]comparator.Difference, len(diffs))
+	for i, d := range diffs {
+		d.Expected = comparator.FormatValue(d.Expected, r.maxDiffBytes)
+		d.Actual = comparator.FormatValue(d.Actual, r.maxDiffBytes)
+		rendered[i] = d
+	}
+	return rendered
 }
 
 // recordResult records a test result in the overall results.
@@ -312,11 +903,35 @@ func (r *Runner) recordResult(results *Results, result *TestResult) {
 
 	if result.Skipped {
 		results.Skipped++
+		switch result.SkipReason {
+		case SkipReasonTimeBudget:
+			results.BudgetExceeded = true
+		case SkipReasonNotServing:
+			results.NotServing = true
+		case SkipReasonInfraFailure:
+			results.InfraSkipped++
+		}
 	} else if result.Passed {
 		results.Passed++
 	} else {
 		results.Failed++
+		if r.failFast {
+			r.aborted.Store(true)
+		}
+	}
+}
+
+// isOwned checks if a manifest matches the owner filter.
+func (r *Runner) isOwned(m *manifest.LoadedManifest) bool {
+	if len(r.owners) == 0 {
+		return true
+	}
+	for _, owner := range r.owners {
+		if strings.EqualFold(owner, m.GetOwner()) {
+			return true
+		}
 	}
+	return false
 }
 
 // shouldRun checks if a test case should be run based on filters.
@@ -4,15 +4,23 @@
 package runner
 
 import (
+	"context"
+	"errors"
+	"net"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/artifacts"
+	"zntr.io/extproctor/internal/client"
 	"zntr.io/extproctor/internal/comparator"
+	"zntr.io/extproctor/internal/manifest"
 	"zntr.io/extproctor/internal/reporter"
 )
 
@@ -360,6 +368,7 @@ type mockReporter struct {
 	lastTestName     string
 	lastResult       reporter.TestResult
 	lastSummary      reporter.SuiteSummary
+	events           []reporter.Event
 }
 
 func (m *mockReporter) StartSuite(total int) {
@@ -377,11 +386,26 @@ func (m *mockReporter) EndTest(result reporter.TestResult) {
 	m.lastResult = result
 }
 
+func (m *mockReporter) Event(event reporter.Event) {
+	m.events = append(m.events, event)
+}
+
 func (m *mockReporter) EndSuite(summary reporter.SuiteSummary) {
 	m.endSuiteCalled++
 	m.lastSummary = summary
 }
 
+func TestRun_Labels(t *testing.T) {
+	labels := map[string]string{"env": "staging"}
+	mock := &mockReporter{}
+
+	results, err := New(nil, WithReporter(mock), WithLabels(labels)).Run(context.Background(), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, labels, results.Labels)
+	assert.Equal(t, labels, mock.lastSummary.Labels)
+}
+
 func TestReportResult_CallsReporter(t *testing.T) {
 	mock := &mockReporter{}
 	r := New(nil, WithReporter(mock))
@@ -536,7 +560,7 @@ func TestGetExpectations_Inline(t *testing.T) {
 		},
 	}
 
-	result, err := r.getExpectations(tc)
+	result, _, err := r.getExpectations(tc)
 	assert.NoError(t, err)
 	assert.Equal(t, expectations, result)
 }
@@ -548,7 +572,7 @@ func TestGetExpectations_NoExpectationsOrGolden(t *testing.T) {
 		testCase: &extproctorv1.TestCase{},
 	}
 
-	result, err := r.getExpectations(tc)
+	result, _, err := r.getExpectations(tc)
 	assert.NoError(t, err)
 	assert.Nil(t, result)
 }
@@ -560,6 +584,7 @@ func TestGetExpectations_GoldenFile(t *testing.T) {
 	// Create a valid golden file
 	content := `
 name: "golden"
+request_digest: "sha256:0123"
 expectations: {
   phase: REQUEST_HEADERS
   headers_response: {
@@ -582,10 +607,11 @@ expectations: {
 		sourcePath: filepath.Join(tmpDir, "manifest.textproto"),
 	}
 
-	expectations, err := r.getExpectations(tc)
+	expectations, meta, err := r.getExpectations(tc)
 	require.NoError(t, err)
 	assert.NotNil(t, expectations)
 	assert.Len(t, expectations, 1)
+	assert.Equal(t, "sha256:0123", meta.RequestDigest)
 }
 
 func TestGetExpectations_GoldenFileNotFound(t *testing.T) {
@@ -600,6 +626,557 @@ func TestGetExpectations_GoldenFileNotFound(t *testing.T) {
 		sourcePath: filepath.Join(tmpDir, "manifest.textproto"),
 	}
 
-	_, err := r.getExpectations(tc)
+	_, _, err := r.getExpectations(tc)
 	assert.Error(t, err)
 }
+
+func TestIsOwned(t *testing.T) {
+	owned := &manifest.LoadedManifest{TestManifest: &extproctorv1.TestManifest{Owner: "Payments"}}
+	unowned := &manifest.LoadedManifest{TestManifest: &extproctorv1.TestManifest{}}
+
+	r := New(nil)
+	assert.True(t, r.isOwned(owned))
+	assert.True(t, r.isOwned(unowned))
+
+	r = New(nil, WithOwners([]string{"search", "payments"}))
+	assert.True(t, r.isOwned(owned))
+	assert.False(t, r.isOwned(unowned))
+}
+
+func TestRenderDifferences(t *testing.T) {
+	r := New(nil, WithMaxDiffBytes(4))
+
+	diffs := []comparator.Difference{
+		{Path: "body", Expected: "0123456789", Actual: "a\x00"},
+	}
+	rendered := r.renderDifferences(diffs)
+
+	require.Len(t, rendered, 1)
+	assert.Equal(t, "0123… (4 of 10 bytes)", rendered[0].Expected)
+	assert.Equal(t, `a\x00`, rendered[0].Actual)
+	// The raw values are kept in the test result
+	assert.Equal(t, "0123456789", diffs[0].Expected)
+}
+
+func TestIsAffected(t *testing.T) {
+	tmpDir := t.TempDir()
+	manifestPath := filepath.Join(tmpDir, "tests", "auth.textproto")
+
+	inline := &testCaseWithManifest{
+		testCase:   &extproctorv1.TestCase{Name: "inline"},
+		sourcePath: manifestPath,
+	}
+	withGolden := &testCaseWithManifest{
+		testCase:   &extproctorv1.TestCase{Name: "golden", GoldenFile: "golden/{test_name}.textproto"},
+		sourcePath: manifestPath,
+	}
+	perPhase := &testCaseWithManifest{
+		testCase:   &extproctorv1.TestCase{Name: "phases", GoldenFile: "golden/{test_name}.{phase}.textproto"},
+		sourcePath: manifestPath,
+	}
+
+	// No restriction
+	r := New(nil)
+	assert.True(t, r.isAffected(inline))
+
+	// Nothing changed
+	r = New(nil, WithChangedFiles([]string{}))
+	assert.False(t, r.isAffected(inline))
+
+	// Manifest changed
+	r = New(nil, WithChangedFiles([]string{manifestPath}))
+	assert.True(t, r.isAffected(inline))
+	assert.True(t, r.isAffected(withGolden))
+
+	// Golden file changed
+	r = New(nil, WithChangedFiles([]string{filepath.Join(tmpDir, "tests", "golden", "golden.textproto")}))
+	assert.False(t, r.isAffected(inline))
+	assert.True(t, r.isAffected(withGolden))
+	assert.False(t, r.isAffected(perPhase))
+
+	// Per-phase golden file changed
+	r = New(nil, WithChangedFiles([]string{filepath.Join(tmpDir, "tests", "golden", "phases.response_headers.textproto")}))
+	assert.True(t, r.isAffected(perPhase))
+}
+
+func TestIsAffected_Dependencies(t *testing.T) {
+	tmpDir := t.TempDir()
+	base := filepath.Join(tmpDir, "base.textproto")
+	require.NoError(t, os.WriteFile(base, []byte(`
+test_cases: { name: "base" abstract: true request: { method: "POST" path: "/upload" } }
+`), 0o644))
+	suite := filepath.Join(tmpDir, "suite.textproto")
+	require.NoError(t, os.WriteFile(suite, []byte(`
+imports: ["base.textproto"]
+test_cases: { name: "inherited" extends: "base" }
+test_cases: {
+  name: "upload"
+  request: {
+    method: "POST"
+    path: "/upload"
+    multipart: { parts: { name: "file" file: "payload.bin" } }
+  }
+}
+`), 0o644))
+
+	m, err := manifest.NewLoader().LoadFile(suite)
+	require.NoError(t, err)
+	require.Len(t, m.TestCases, 2)
+	tests := make([]*testCaseWithManifest, len(m.TestCases))
+	for i, tc := range m.TestCases {
+		tests[i] = &testCaseWithManifest{testCase: tc, manifest: m, sourcePath: m.SourcePath, dependencies: m.Dependencies[tc]}
+	}
+
+	// Only the imported base manifest changed
+	r := New(nil, WithChangedFiles([]string{base}))
+	assert.True(t, r.isAffected(tests[0]))
+	assert.False(t, r.isAffected(tests[1]))
+
+	// Only a multipart part file changed
+	r = New(nil, WithChangedFiles([]string{filepath.Join(tmpDir, "payload.bin")}))
+	assert.False(t, r.isAffected(tests[0]))
+	assert.True(t, r.isAffected(tests[1]))
+}
+
+func TestTestID(t *testing.T) {
+	assert.Equal(t, "tests/auth.textproto::deny", TestID("./tests/auth.textproto", "deny"))
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, "tests/auth.textproto::deny", TestID(filepath.Join(cwd, "tests", "auth.textproto"), "deny"))
+}
+
+func TestTestUID(t *testing.T) {
+	uid := TestUID("tests/auth.textproto::deny", &extproctorv1.TestCase{Name: "deny"})
+	assert.Len(t, uid, 16)
+	assert.Equal(t, uid, TestUID("tests/auth.textproto::deny", &extproctorv1.TestCase{Name: "deny", Priority: 1}))
+	assert.NotEqual(t, uid, TestUID("tests/auth.textproto::allow", &extproctorv1.TestCase{Name: "allow"}))
+
+	// A pinned UID survives renames
+	assert.Equal(t, "auth-deny", TestUID("tests/authz.textproto::deny-anonymous", &extproctorv1.TestCase{Uid: "auth-deny"}))
+}
+
+func TestWithTestIDs(t *testing.T) {
+	r := New(nil, WithTestIDs([]string{"a.textproto::one"}), WithFirst([]string{"b.textproto::two"}))
+	assert.True(t, r.only["a.textproto::one"])
+	assert.False(t, r.only["b.textproto::two"])
+	assert.True(t, r.first["b.textproto::two"])
+
+	r = New(nil)
+	assert.Nil(t, r.only)
+	assert.Nil(t, r.first)
+}
+
+func TestFinishTest_Artifacts(t *testing.T) {
+	root := t.TempDir()
+	r := New(nil, WithArtifactsDir(root))
+	tc := &testCaseWithManifest{
+		testCase: &extproctorv1.TestCase{Name: "t", Request: &extproctorv1.HttpRequest{Method: "GET"}},
+	}
+
+	r.finishTest(tc, &TestResult{ID: "m.textproto::t", Error: errors.New("boom")}, nil, 0)
+	assert.FileExists(t, filepath.Join(artifacts.Dir(root, "m.textproto::t"), artifacts.DiffFile))
+
+	// Passing again removes the artifacts
+	r.finishTest(tc, &TestResult{ID: "m.textproto::t", Passed: true}, nil, 0)
+	assert.NoDirExists(t, artifacts.Dir(root, "m.textproto::t"))
+}
+
+func TestFinishTest_FilterLog(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "filter.log")
+	require.NoError(t, os.WriteFile(logPath, []byte("startup\n"), 0o644))
+
+	r := New(nil, WithFilterLog(logPath))
+	offset, err := r.filterLog.Offset()
+	require.NoError(t, err)
+
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString("denied /admin\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	tc := &testCaseWithManifest{testCase: &extproctorv1.TestCase{Name: "t"}}
+
+	failed := &TestResult{ID: "m.textproto::t"}
+	r.finishTest(tc, failed, nil, offset)
+	assert.Equal(t, []string{"denied /admin"}, failed.Logs)
+
+	// Logs are only attached to failed tests
+	passed := &TestResult{ID: "m.textproto::t", Passed: true}
+	r.finishTest(tc, passed, nil, offset)
+	assert.Nil(t, passed.Logs)
+}
+
+func TestTestRequest(t *testing.T) {
+	tc := &testCaseWithManifest{
+		testCase:   &extproctorv1.TestCase{Name: "t", Request: &extproctorv1.HttpRequest{Method: "GET"}},
+		sourcePath: "m.textproto",
+	}
+
+	req := New(nil).testRequest(tc)
+	assert.Equal(t, "m.textproto::t", req.Headers[TestIDHeader])
+	// The test case is left untouched
+	assert.Nil(t, tc.testCase.Request.Headers)
+
+	// Opt-out
+	req = New(nil, WithTestIDHeader(false)).testRequest(tc)
+	assert.Same(t, tc.testCase.Request, req)
+
+	// Defined by the test
+	tc.testCase.Request.Headers = map[string]string{"X-ExtProctor-Test-ID": "custom"}
+	req = New(nil).testRequest(tc)
+	assert.Equal(t, map[string]string{"X-ExtProctor-Test-ID": "custom"}, req.Headers)
+
+	// Defined by the test as a header entry
+	tc.testCase.Request.Headers = nil
+	tc.testCase.Request.HeaderEntries = []*extproctorv1.HeaderEntry{{Key: "x-extproctor-test-id", Value: "custom"}}
+	req = New(nil).testRequest(tc)
+	assert.Same(t, tc.testCase.Request, req)
+
+	// Defined by the test as a raw header
+	tc.testCase.Request.HeaderEntries = nil
+	tc.testCase.Request.RawHeaders = map[string][]byte{"X-ExtProctor-Test-ID": []byte("custom")}
+	req = New(nil).testRequest(tc)
+	assert.Same(t, tc.testCase.Request, req)
+}
+
+func TestTestRequest_SharesBody(t *testing.T) {
+	tc := &testCaseWithManifest{
+		testCase: &extproctorv1.TestCase{Name: "t", Request: &extproctorv1.HttpRequest{
+			Method:  "POST",
+			Path:    "/upload",
+			Headers: map[string]string{"content-type": "application/octet-stream"},
+			Body:    make([]byte, 8<<20),
+		}},
+		sourcePath: "m.textproto",
+	}
+
+	req := New(nil).testRequest(tc)
+	assert.Equal(t, "POST", req.Method)
+	assert.Equal(t, "/upload", req.Path)
+	assert.Equal(t, "m.textproto::t", req.Headers[TestIDHeader])
+	assert.Equal(t, "application/octet-stream", req.Headers["content-type"])
+	assert.Same(t, &tc.testCase.Request.Body[0], &req.Body[0])
+
+	// The headers of the test case are left untouched
+	assert.Len(t, tc.testCase.Request.Headers, 1)
+}
+
+func TestRequest_Random(t *testing.T) {
+	tc := &testCaseWithManifest{
+		testCase: &extproctorv1.TestCase{Name: "t", Request: &extproctorv1.HttpRequest{
+			Method: "GET",
+			Path:   "/items/{{ random_string 10 }}",
+		}},
+		sourcePath: "m.textproto",
+	}
+
+	r := New(nil, WithSeed(7))
+	req, err := r.request(tc)
+	require.NoError(t, err)
+	assert.Regexp(t, `^/items/[a-zA-Z0-9]{10}$`, req.Path)
+	assert.Equal(t, "m.textproto::t", req.Headers[TestIDHeader])
+	assert.True(t, r.randomized.Load())
+
+	// The artifacts record the request sent
+	assert.Equal(t, req.Path, r.artifactRequest(tc).Path)
+
+	// Replaying the seed yields the same request
+	replayed, err := New(nil, WithSeed(7)).request(tc)
+	require.NoError(t, err)
+	assert.Equal(t, req.Path, replayed.Path)
+
+	// Requests without template functions are sent as is
+	static := &testCaseWithManifest{
+		testCase:   &extproctorv1.TestCase{Name: "s", Request: &extproctorv1.HttpRequest{Method: "GET", Path: "/"}},
+		sourcePath: "m.textproto",
+	}
+	r = New(nil, WithSeed(7), WithTestIDHeader(false))
+	req, err = r.request(static)
+	require.NoError(t, err)
+	assert.Same(t, static.testCase.Request, req)
+	assert.False(t, r.randomized.Load())
+
+	// Rendering errors name the seed
+	tc.testCase.Request.Path = "/{{ random_int 2 1 }}"
+	_, err = New(nil, WithSeed(7)).request(tc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "seed 7: ")
+}
+
+// headersProcessor is an ExtProc service answering every message with an
+// empty response of its phase.
+type headersProcessor struct {
+	extprocv3.UnimplementedExternalProcessorServer
+}
+
+func (headersProcessor) Process(stream extprocv3.ExternalProcessor_ProcessServer) error {
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+		resp := &extprocv3.ProcessingResponse{}
+		switch {
+		case req.GetRequestHeaders() != nil:
+			resp.Response = &extprocv3.ProcessingResponse_RequestHeaders{RequestHeaders: &extprocv3.HeadersResponse{}}
+		case req.GetResponseHeaders() != nil:
+			resp.Response = &extprocv3.ProcessingResponse_ResponseHeaders{ResponseHeaders: &extprocv3.HeadersResponse{}}
+		default:
+			resp.Response = &extprocv3.ProcessingResponse_RequestBody{RequestBody: &extprocv3.BodyResponse{}}
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// newProcessorClient starts a headersProcessor and returns a client
+// connected to it.
+func newProcessorClient(t *testing.T) *client.Client {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	extprocv3.RegisterExternalProcessorServer(grpcServer, headersProcessor{})
+	go func() { _ = grpcServer.Serve(lis) }()
+	t.Cleanup(grpcServer.Stop)
+
+	c, err := client.New(client.WithTarget(lis.Addr().String()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestRunTest_StaleGolden(t *testing.T) {
+	dir := t.TempDir()
+	tc := &testCaseWithManifest{
+		testCase: &extproctorv1.TestCase{
+			Name:       "users",
+			Request:    &extproctorv1.HttpRequest{Method: "GET", Path: "/api/users"},
+			GoldenFile: "golden/{test_name}.textproto",
+		},
+		manifest:   &manifest.LoadedManifest{TestManifest: &extproctorv1.TestManifest{}},
+		sourcePath: filepath.Join(dir, "manifest.textproto"),
+	}
+	c := newProcessorClient(t)
+
+	// The golden file records the digest of the request it was generated from
+	result := New(c, WithUpdateGolden(true)).runTest(context.Background(), tc)
+	require.True(t, result.Passed, result.Error)
+	data, err := os.ReadFile(filepath.Join(dir, "golden", "users.textproto"))
+	require.NoError(t, err)
+	assert.Regexp(t, `request_digest:\s+"sha256:[0-9a-f]{64}"`, string(data))
+
+	result = New(c).runTest(context.Background(), tc)
+	assert.True(t, result.Passed)
+	assert.Empty(t, result.Notes)
+
+	// Editing the request without regenerating the golden file is noted
+	tc.testCase.Request.Path = "/api/groups"
+	result = New(c).runTest(context.Background(), tc)
+	assert.True(t, result.Passed)
+	assert.Equal(t, []string{"the golden file is likely stale: the request changed since it was generated (regenerate it with --update-golden)"}, result.Notes)
+}
+
+func TestRunTest_Events(t *testing.T) {
+	dir := t.TempDir()
+	tc := &testCaseWithManifest{
+		testCase: &extproctorv1.TestCase{
+			Name:       "users",
+			Request:    &extproctorv1.HttpRequest{Method: "GET", Path: "/api/users"},
+			GoldenFile: "golden/{test_name}.textproto",
+		},
+		manifest:   &manifest.LoadedManifest{TestManifest: &extproctorv1.TestManifest{}},
+		sourcePath: filepath.Join(dir, "manifest.textproto"),
+	}
+	rep := &mockReporter{}
+
+	result := New(newProcessorClient(t), WithReporter(rep), WithUpdateGolden(true)).runTest(context.Background(), tc)
+	require.True(t, result.Passed, result.Error)
+
+	ref := reporter.TestRef{ID: tc.id(), Name: "users"}
+	require.Len(t, rep.events, 2)
+	phase, ok := rep.events[0].(reporter.PhaseCompleted)
+	require.True(t, ok)
+	assert.Equal(t, ref, phase.Test)
+	assert.Equal(t, extproctorv1.ProcessingPhase_REQUEST_HEADERS, phase.Phase)
+	assert.NotNil(t, phase.Response.GetRequestHeaders())
+	assert.Equal(t, reporter.GoldenUpdated{
+		Test: ref,
+		Path: filepath.Join(dir, "golden", "users.textproto"),
+	}, rep.events[1])
+}
+
+func TestRunTest_Categories(t *testing.T) {
+	dir := t.TempDir()
+	newTest := func(tc *extproctorv1.TestCase) *testCaseWithManifest {
+		tc.Name = "users"
+		tc.Request = &extproctorv1.HttpRequest{Method: "GET", Path: "/api/users"}
+		return &testCaseWithManifest{
+			testCase:   tc,
+			manifest:   &manifest.LoadedManifest{TestManifest: &extproctorv1.TestManifest{}},
+			sourcePath: filepath.Join(dir, "manifest.textproto"),
+		}
+	}
+	r := New(newProcessorClient(t))
+
+	result := r.runTest(context.Background(), newTest(&extproctorv1.TestCase{
+		Expectations: []*extproctorv1.ExtProcExpectation{{
+			Phase:    extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			Response: &extproctorv1.ExtProcExpectation_ImmediateResponse{ImmediateResponse: &extproctorv1.ImmediateExpectation{}},
+		}},
+	}))
+	assert.False(t, result.Passed)
+	assert.Equal(t, reporter.CategoryAssertion, result.Category)
+
+	result = r.runTest(context.Background(), newTest(&extproctorv1.TestCase{GoldenFile: "golden/{test_name}.textproto"}))
+	assert.False(t, result.Passed)
+	assert.Equal(t, reporter.CategoryGoldenMissing, result.Category)
+
+	result = r.runTest(context.Background(), newTest(&extproctorv1.TestCase{Timeout: "soon"}))
+	assert.Equal(t, reporter.CategoryTestError, result.Category)
+
+	// The service is unreachable
+	c, err := client.New(client.WithTarget("127.0.0.1:1"))
+	require.NoError(t, err)
+	defer func() { _ = c.Close() }()
+	result = New(c).runTest(context.Background(), newTest(&extproctorv1.TestCase{}))
+	assert.Equal(t, reporter.CategoryConnection, result.Category, result.Error)
+
+	// Passed tests have no category
+	result = r.runTest(context.Background(), newTest(&extproctorv1.TestCase{
+		Expectations: []*extproctorv1.ExtProcExpectation{{
+			Phase:    extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+			Response: &extproctorv1.ExtProcExpectation_HeadersResponse{HeadersResponse: &extproctorv1.HeadersExpectation{}},
+		}},
+	}))
+	assert.True(t, result.Passed, result.Error)
+	assert.Empty(t, result.Category)
+}
+
+func TestRunTest_SkipInfraFailures(t *testing.T) {
+	tc := &testCaseWithManifest{
+		testCase: &extproctorv1.TestCase{
+			Name:    "users",
+			Request: &extproctorv1.HttpRequest{Method: "GET", Path: "/api/users"},
+		},
+		manifest: &manifest.LoadedManifest{TestManifest: &extproctorv1.TestManifest{}},
+	}
+	c, err := client.New(client.WithTarget("127.0.0.1:1"))
+	require.NoError(t, err)
+	defer func() { _ = c.Close() }()
+
+	r := New(c, WithSkipInfraFailures(true))
+	results := &Results{}
+	r.recordResult(results, r.runTest(context.Background(), tc))
+	assert.Equal(t, 1, results.Skipped)
+	assert.Equal(t, 1, results.InfraSkipped)
+	assert.Equal(t, SkipReasonInfraFailure, results.Tests[0].SkipReason)
+	assert.Equal(t, reporter.CategoryConnection, results.Tests[0].Category)
+
+	// Failures of the filter still fail the test
+	result := New(newProcessorClient(t), WithSkipInfraFailures(true)).runTest(context.Background(), tc)
+	assert.False(t, result.Skipped)
+	assert.Equal(t, reporter.CategoryAssertion, result.Category)
+}
+
+// stallingProcessor is an ExtProc service answering the request headers and
+// never the request body.
+type stallingProcessor struct {
+	extprocv3.UnimplementedExternalProcessorServer
+}
+
+func (stallingProcessor) Process(stream extprocv3.ExternalProcessor_ProcessServer) error {
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+		if req.GetRequestHeaders() == nil {
+			<-stream.Context().Done()
+			return nil
+		}
+		resp := &extprocv3.ProcessingResponse{
+			Response: &extprocv3.ProcessingResponse_RequestHeaders{RequestHeaders: &extprocv3.HeadersResponse{}},
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func TestRunTest_Timeout(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	extprocv3.RegisterExternalProcessorServer(grpcServer, stallingProcessor{})
+	go func() { _ = grpcServer.Serve(lis) }()
+	t.Cleanup(grpcServer.Stop)
+
+	c, err := client.New(client.WithTarget(lis.Addr().String()))
+	require.NoError(t, err)
+	defer func() { _ = c.Close() }()
+
+	newTest := func(timeout string) *testCaseWithManifest {
+		return &testCaseWithManifest{
+			testCase: &extproctorv1.TestCase{
+				Name:    "upload",
+				Timeout: timeout,
+				Request: &extproctorv1.HttpRequest{
+					Method:             "POST",
+					Path:               "/upload",
+					Body:               []byte("payload"),
+					ProcessRequestBody: true,
+				},
+			},
+			manifest: &manifest.LoadedManifest{TestManifest: &extproctorv1.TestManifest{}},
+		}
+	}
+	r := New(c, WithTestTimeout(50*time.Millisecond))
+
+	// The default timeout applies to tests without their own
+	result := r.runTest(context.Background(), newTest(""))
+	assert.False(t, result.Passed)
+	assert.EqualError(t, result.Error, "phase REQUEST_BODY timed out after 50ms")
+	assert.ErrorIs(t, result.Error, context.DeadlineExceeded)
+	assert.Equal(t, reporter.CategoryTimeout, result.Category)
+
+	// The timeout field takes precedence
+	result = r.runTest(context.Background(), newTest("100ms"))
+	assert.EqualError(t, result.Error, "phase REQUEST_BODY timed out after 100ms")
+}
+
+// rejectingComparator fails every comparison.
+type rejectingComparator struct{}
+
+func (rejectingComparator) Compare([]*extproctorv1.ExtProcExpectation, *client.ProcessingResult) *comparator.ComparisonResult {
+	return &comparator.ComparisonResult{
+		Differences: []comparator.Difference{{Path: "custom", Expected: "accepted", Actual: "rejected"}},
+	}
+}
+
+func TestWithComparator(t *testing.T) {
+	tc := &testCaseWithManifest{
+		testCase: &extproctorv1.TestCase{
+			Name:    "users",
+			Request: &extproctorv1.HttpRequest{Method: "GET", Path: "/api/users"},
+			Expectations: []*extproctorv1.ExtProcExpectation{{
+				Phase:    extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extproctorv1.ExtProcExpectation_HeadersResponse{HeadersResponse: &extproctorv1.HeadersExpectation{}},
+			}},
+		},
+		manifest: &manifest.LoadedManifest{TestManifest: &extproctorv1.TestManifest{}},
+	}
+	c := newProcessorClient(t)
+
+	result := New(c).runTest(context.Background(), tc)
+	assert.True(t, result.Passed, result.Error)
+
+	result = New(c, WithComparator(rejectingComparator{})).runTest(context.Background(), tc)
+	assert.False(t, result.Passed)
+	require.Len(t, result.Differences, 1)
+	assert.Equal(t, "custom", result.Differences[0].Path)
+}
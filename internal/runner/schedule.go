@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"zntr.io/extproctor/internal/reporter"
+)
+
+// Reasons of skipped tests.
+const (
+	SkipReasonExpectedFailure = "expected failure"
+	SkipReasonFailFast        = "a previous test failed (fail-fast)"
+	SkipReasonTimeBudget      = "time budget exceeded"
+	SkipReasonNotServing      = "target not serving"
+	SkipReasonInfraFailure    = "infrastructure failure"
+	SkipReasonCondition       = "no expectation applies to the environment"
+)
+
+// orderTests sorts the test cases in execution order: the prioritized
+// (--rerun-failed-first) tests first, then by decreasing priority, then under
+// parallelism by decreasing duration in the previous runs so that the longest
+// tests do not start last and stretch the end of the run, keeping the
+// manifest order otherwise.
+func (r *Runner) orderTests(testCases []*testCaseWithManifest) {
+	expected := r.expectedDurations(testCases)
+	sort.SliceStable(testCases, func(i, j int) bool {
+		a, b := testCases[i], testCases[j]
+		if fa, fb := r.first[a.id()], r.first[b.id()]; fa != fb {
+			return fa
+		}
+		if a.testCase.Priority != b.testCase.Priority {
+			return a.testCase.Priority > b.testCase.Priority
+		}
+		return expected(a) > expected(b)
+	})
+}
+
+// stages splits the ordered test cases in the groups run one after the other.
+// With smoke-first, the smoke tests (positive priority) complete before the
+// others start, even under parallelism.
+func (r *Runner) stages(testCases []*testCaseWithManifest) [][]*testCaseWithManifest {
+	if !r.smokeFirst {
+		return [][]*testCaseWithManifest{testCases}
+	}
+
+	var smoke, others []*testCaseWithManifest
+	for _, tc := range testCases {
+		if tc.testCase.Priority > 0 {
+			smoke = append(smoke, tc)
+		} else {
+			others = append(others, tc)
+		}
+	}
+
+	return [][]*testCaseWithManifest{smoke, others}
+}
+
+// skipReason returns why tests can no longer be started, or an empty string
+// when they can.
+func (r *Runner) skipReason() string {
+	switch {
+	case r.aborted.Load():
+		return SkipReasonFailFast
+	case !r.deadline.IsZero() && time.Now().After(r.deadline):
+		return SkipReasonTimeBudget
+	default:
+		return ""
+	}
+}
+
+// skipReasonFor returns why a test case cannot be started, or an empty string
+// when it can. It waits for the target of the test case to reconnect when its
+// connection dropped, and to serve when health checks are configured.
+func (r *Runner) skipReasonFor(ctx context.Context, tc *testCaseWithManifest) string {
+	if tc.manifest != nil && tc.manifest.SkipReason != "" {
+		return tc.manifest.SkipReason
+	}
+	if tc.manifest != nil && tc.manifest.ConditionSkipped(tc.testCase) {
+		return SkipReasonCondition
+	}
+	if reason := r.skipReason(); reason != "" {
+		return reason
+	}
+	if d := r.redialerFor(tc); d != nil {
+		d.wait(ctx)
+	}
+	if gate := r.gateFor(tc); gate != nil && !gate.wait(ctx) && ctx.Err() == nil {
+		return SkipReasonNotServing
+	}
+	return ""
+}
+
+// skipTest reports a test case not run because the suite was aborted or its
+// manifest is not supported.
+func (r *Runner) skipTest(tc *testCaseWithManifest, reason string) *TestResult {
+	if r.reporter != nil {
+		r.reporter.StartTest(reporter.DisplayName(tc.testCase.Name, tc.targetName()))
+	}
+
+	result := &TestResult{
+		ID:         tc.id(),
+		UID:        tc.uid(),
+		Name:       tc.testCase.Name,
+		Manifest:   manifestName(tc.manifest),
+		Owner:      tc.manifest.GetOwner(),
+		Target:     tc.targetName(),
+		Tags:       tc.testCase.Tags,
+		Skipped:    true,
+		SkipReason: reason,
+	}
+	r.reportResult(result)
+	return result
+}
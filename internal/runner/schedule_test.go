@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/manifest"
+)
+
+func scheduledTests(priorities map[string]int32, names ...string) []*testCaseWithManifest {
+	m := &manifest.LoadedManifest{TestManifest: &extproctorv1.TestManifest{}, SourcePath: "m.textproto"}
+	var tcs []*testCaseWithManifest
+	for _, name := range names {
+		tcs = append(tcs, &testCaseWithManifest{
+			testCase:   &extproctorv1.TestCase{Name: name, Priority: priorities[name]},
+			manifest:   m,
+			sourcePath: m.SourcePath,
+		})
+	}
+	return tcs
+}
+
+func testNames(tcs []*testCaseWithManifest) []string {
+	names := make([]string, len(tcs))
+	for i, tc := range tcs {
+		names[i] = tc.testCase.Name
+	}
+	return names
+}
+
+func TestOrderTests(t *testing.T) {
+	tcs := scheduledTests(map[string]int32{"smoke": 10, "important": 5, "flaky": -1}, "a", "flaky", "important", "b", "smoke")
+
+	New(nil).orderTests(tcs)
+	assert.Equal(t, []string{"smoke", "important", "a", "b", "flaky"}, testNames(tcs))
+
+	// Previously failed tests come first
+	New(nil, WithFirst([]string{"m.textproto::b"})).orderTests(tcs)
+	assert.Equal(t, []string{"b", "smoke", "important", "a", "flaky"}, testNames(tcs))
+}
+
+func TestStages(t *testing.T) {
+	tcs := scheduledTests(map[string]int32{"smoke": 1}, "smoke", "a", "b")
+
+	stages := New(nil).stages(tcs)
+	require.Len(t, stages, 1)
+	assert.Equal(t, []string{"smoke", "a", "b"}, testNames(stages[0]))
+
+	stages = New(nil, WithSmokeFirst(true)).stages(tcs)
+	require.Len(t, stages, 2)
+	assert.Equal(t, []string{"smoke"}, testNames(stages[0]))
+	assert.Equal(t, []string{"a", "b"}, testNames(stages[1]))
+}
+
+func TestFailFast(t *testing.T) {
+	rep := &mockReporter{}
+	r := New(nil, WithFailFast(true), WithReporter(rep))
+	results := &Results{}
+
+	r.recordResult(results, &TestResult{Name: "passing", Passed: true})
+	assert.False(t, r.aborted.Load())
+
+	r.recordResult(results, &TestResult{Name: "failing"})
+	assert.True(t, r.aborted.Load())
+
+	// The remaining tests are not run
+	r.runSequential(context.Background(), scheduledTests(nil, "a", "b"), results)
+	assert.Equal(t, 2, results.Skipped)
+	assert.Equal(t, 2, rep.endTestCalled)
+	assert.True(t, rep.lastResult.Skipped)
+	assert.Equal(t, SkipReasonFailFast, rep.lastResult.SkipReason)
+
+	r.runParallel(context.Background(), scheduledTests(nil, "c", "d"), results)
+	assert.Equal(t, 4, results.Skipped)
+
+	// Without fail-fast
+	r = New(nil)
+	r.recordResult(&Results{}, &TestResult{Name: "failing"})
+	assert.False(t, r.aborted.Load())
+}
+
+func TestMaxDuration(t *testing.T) {
+	r := New(nil, WithMaxDuration(time.Minute))
+	assert.Empty(t, r.skipReason())
+
+	// Budget exhausted
+	r.deadline = time.Now().Add(-time.Second)
+	assert.Equal(t, SkipReasonTimeBudget, r.skipReason())
+
+	results := &Results{}
+	r.runSequential(context.Background(), scheduledTests(nil, "a", "b"), results)
+	assert.Equal(t, 2, results.Skipped)
+	assert.True(t, results.BudgetExceeded)
+	require.Len(t, results.Tests, 2)
+	assert.Equal(t, SkipReasonTimeBudget, results.Tests[0].SkipReason)
+}
+
+func TestUnsupportedManifest(t *testing.T) {
+	rep := &mockReporter{}
+	r := New(nil, WithReporter(rep))
+	results := &Results{}
+
+	tcs := scheduledTests(nil, "a", "b")
+	tcs[0].manifest.SkipReason = `manifest requires unsupported features "cel"`
+
+	r.runSequential(context.Background(), tcs, results)
+	assert.Equal(t, 2, results.Skipped)
+	assert.False(t, results.BudgetExceeded)
+	assert.Equal(t, `manifest requires unsupported features "cel"`, rep.lastResult.SkipReason)
+}
+
+func TestConditionSkipped(t *testing.T) {
+	rep := &mockReporter{}
+	r := New(nil, WithReporter(rep))
+	results := &Results{}
+
+	tcs := scheduledTests(nil, "prod-only")
+	tcs[0].manifest.Unfiltered = map[*extproctorv1.TestCase][]*extproctorv1.ExtProcExpectation{
+		tcs[0].testCase: {{Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS, When: &extproctorv1.Condition{Profiles: []string{"prod"}}}},
+	}
+
+	r.runSequential(context.Background(), tcs, results)
+	assert.Equal(t, 1, results.Skipped)
+	assert.Equal(t, SkipReasonCondition, rep.lastResult.SkipReason)
+}
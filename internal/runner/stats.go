@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"sort"
+
+	"zntr.io/extproctor/internal/manifest"
+	"zntr.io/extproctor/internal/reporter"
+)
+
+// manifestName returns the name used to group the tests of a manifest: its
+// declared name, or its source path when unnamed.
+func manifestName(m *manifest.LoadedManifest) string {
+	if m == nil {
+		return ""
+	}
+	if m.GetName() != "" {
+		return m.GetName()
+	}
+	return m.SourcePath
+}
+
+// breakdown computes the per-tag, per-manifest, per-owner and per-target
+// statistics of the tests. Tests run without fan-out targets have no target
+// group.
+func breakdown(tests []*TestResult) (byTag, byManifest, byOwner, byTarget []reporter.GroupStats) {
+	tagGroups := map[string]*reporter.GroupStats{}
+	manifestGroups := map[string]*reporter.GroupStats{}
+	ownerGroups := map[string]*reporter.GroupStats{}
+	targetGroups := map[string]*reporter.GroupStats{}
+
+	for _, t := range tests {
+		tags := t.Tags
+		if len(tags) == 0 {
+			tags = []string{reporter.UntaggedGroup}
+		}
+		for _, tag := range tags {
+			addToGroup(tagGroups, tag, t)
+		}
+		addToGroup(manifestGroups, t.Manifest, t)
+		addToGroup(ownerGroups, ownerGroup(t.Owner), t)
+		if t.Target != "" {
+			addToGroup(targetGroups, t.Target, t)
+		}
+	}
+
+	return sortedGroups(tagGroups), sortedGroups(manifestGroups), sortedGroups(ownerGroups), sortedGroups(targetGroups)
+}
+
+// failures counts the failed tests per failure category.
+func failures(tests []*TestResult) []reporter.CategoryCount {
+	var categories []reporter.Category
+	for _, t := range tests {
+		if !t.Passed && !t.Skipped {
+			categories = append(categories, t.Category)
+		}
+	}
+	return reporter.CountCategories(categories)
+}
+
+// ownerGroup returns the owner group name of a test.
+func ownerGroup(owner string) string {
+	if owner == "" {
+		return reporter.UnownedGroup
+	}
+	return owner
+}
+
+func addToGroup(groups map[string]*reporter.GroupStats, name string, t *TestResult) {
+	g, ok := groups[name]
+	if !ok {
+		g = &reporter.GroupStats{Name: name}
+		groups[name] = g
+	}
+
+	g.Total++
+	g.Duration += t.Duration
+	switch {
+	case t.Skipped:
+		g.Skipped++
+	case t.Passed:
+		g.Passed++
+	default:
+		g.Failed++
+	}
+}
+
+func sortedGroups(groups map[string]*reporter.GroupStats) []reporter.GroupStats {
+	out := make([]reporter.GroupStats, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/manifest"
+	"zntr.io/extproctor/internal/reporter"
+)
+
+func TestBreakdown(t *testing.T) {
+	tests := []*TestResult{
+		{Name: "a", Manifest: "m1", Owner: "team-a", Tags: []string{"auth", "smoke"}, Passed: true, Duration: time.Second},
+		{Name: "b", Manifest: "m1", Owner: "team-a", Tags: []string{"auth"}, Duration: 2 * time.Second},
+		{Name: "c", Manifest: "m2", Skipped: true},
+	}
+
+	byTag, byManifest, byOwner, byTarget := breakdown(tests)
+
+	assert.Equal(t, []reporter.GroupStats{
+		{Name: reporter.UntaggedGroup, Total: 1, Skipped: 1},
+		{Name: "auth", Total: 2, Passed: 1, Failed: 1, Duration: 3 * time.Second},
+		{Name: "smoke", Total: 1, Passed: 1, Duration: time.Second},
+	}, byTag)
+	assert.Equal(t, []reporter.GroupStats{
+		{Name: "m1", Total: 2, Passed: 1, Failed: 1, Duration: 3 * time.Second},
+		{Name: "m2", Total: 1, Skipped: 1},
+	}, byManifest)
+	assert.Equal(t, []reporter.GroupStats{
+		{Name: reporter.UnownedGroup, Total: 1, Skipped: 1},
+		{Name: "team-a", Total: 2, Passed: 1, Failed: 1, Duration: 3 * time.Second},
+	}, byOwner)
+	assert.Empty(t, byTarget)
+}
+
+func TestBreakdown_Targets(t *testing.T) {
+	tests := []*TestResult{
+		{Name: "a", Target: "eu", Passed: true},
+		{Name: "a", Target: "us"},
+		{Name: "b", Target: "eu", Passed: true},
+	}
+
+	_, _, _, byTarget := breakdown(tests)
+
+	assert.Equal(t, []reporter.GroupStats{
+		{Name: "eu", Total: 2, Passed: 2},
+		{Name: "us", Total: 1, Failed: 1},
+	}, byTarget)
+}
+
+func TestBreakdown_Empty(t *testing.T) {
+	byTag, byManifest, byOwner, byTarget := breakdown(nil)
+	assert.Empty(t, byTag)
+	assert.Empty(t, byManifest)
+	assert.Empty(t, byOwner)
+	assert.Empty(t, byTarget)
+}
+
+func TestManifestName(t *testing.T) {
+	assert.Equal(t, "named", manifestName(&manifest.LoadedManifest{
+		TestManifest: &extproctorv1.TestManifest{Name: "named"},
+		SourcePath:   "/tmp/a.textproto",
+	}))
+	assert.Equal(t, "/tmp/a.textproto", manifestName(&manifest.LoadedManifest{
+		TestManifest: &extproctorv1.TestManifest{},
+		SourcePath:   "/tmp/a.textproto",
+	}))
+	assert.Empty(t, manifestName(nil))
+}
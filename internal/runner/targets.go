@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+
+	"zntr.io/extproctor/internal/client"
+	"zntr.io/extproctor/internal/reporter"
+)
+
+// Target is an ExtProc service the suite runs against.
+type Target struct {
+	// Name identifies the target in reports, and is substituted for
+	// {target} in golden paths.
+	Name   string
+	Client *client.Client
+}
+
+// WithTargets runs every test case once per target instead of against the
+// client of the runner, e.g. to validate a filter across regions or versions.
+// The results are broken down per target, and a test counts once per target.
+func WithTargets(targets ...Target) Option {
+	return func(r *Runner) {
+		r.targets = targets
+	}
+}
+
+// fanOut returns the ordered test cases repeated for each target, a test
+// running against all the targets before the next one starts.
+func (r *Runner) fanOut(testCases []*testCaseWithManifest) []*testCaseWithManifest {
+	if len(r.targets) == 0 {
+		return testCases
+	}
+
+	out := make([]*testCaseWithManifest, 0, len(testCases)*len(r.targets))
+	for _, tc := range testCases {
+		for i := range r.targets {
+			copied := *tc
+			copied.target = &r.targets[i]
+			out = append(out, &copied)
+		}
+	}
+	return out
+}
+
+// clientFor returns the client of the target of a test case.
+func (r *Runner) clientFor(tc *testCaseWithManifest) *client.Client {
+	if tc.target != nil {
+		return tc.target.Client
+	}
+	return r.client
+}
+
+// channelFor returns the client of the target of a test case, on the channel
+// overrides and with the gRPC metadata of the test case.
+func (r *Runner) channelFor(tc *testCaseWithManifest) (*client.Client, error) {
+	c := r.clientFor(tc)
+
+	var ch client.Channel
+	if tc.testCase.Channel != nil {
+		ch.Authority = tc.testCase.Channel.Authority
+		ch.ServerName = tc.testCase.Channel.ServerName
+	}
+	ch.Metadata = testMetadata(tc)
+	if ch.Authority == "" && ch.ServerName == "" && len(ch.Metadata) == 0 {
+		return c, nil
+	}
+
+	derived, err := c.WithChannel(ch)
+	if err != nil {
+		return nil, fmt.Errorf("invalid channel overrides: %w", err)
+	}
+	return derived, nil
+}
+
+// testMetadata returns the gRPC metadata of a test case: the metadata of its
+// manifest, overridden by its own.
+func testMetadata(tc *testCaseWithManifest) map[string]string {
+	manifestMD := tc.manifest.GetGrpcMetadata()
+	if len(manifestMD) == 0 {
+		return tc.testCase.GrpcMetadata
+	}
+	md := maps.Clone(manifestMD)
+	maps.Copy(md, tc.testCase.GrpcMetadata)
+	return md
+}
+
+// targetNameFor returns the name of the target of a test case.
+func (r *Runner) targetNameFor(tc *testCaseWithManifest) string {
+	if tc.target != nil {
+		return tc.target.Name
+	}
+	return r.targetName
+}
+
+// targetNames returns the names of the fan-out targets.
+func (r *Runner) targetNames() []string {
+	names := make([]string, 0, len(r.targets))
+	for _, t := range r.targets {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// matrix returns the status of each test on each target, one row per test
+// sorted by test ID.
+func matrix(tests []*TestResult, targets []string) []reporter.MatrixRow {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	column := make(map[string]int, len(targets))
+	for i, name := range targets {
+		column[name] = i
+	}
+
+	rows := map[string]*reporter.MatrixRow{}
+	for _, t := range tests {
+		row, ok := rows[t.ID]
+		if !ok {
+			row = &reporter.MatrixRow{ID: t.ID, Name: t.Name, Statuses: make([]string, len(targets))}
+			rows[t.ID] = row
+		}
+		if i, ok := column[t.Target]; ok {
+			row.Statuses[i] = reporter.Status(t.Passed, t.Skipped)
+		}
+	}
+
+	out := make([]reporter.MatrixRow, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, *row)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// artifactID returns the ID naming the artifacts folder of a result, distinct
+// for each target of a test.
+func artifactID(result *TestResult) string {
+	if result.Target == "" {
+		return result.ID
+	}
+	return result.ID + "@" + result.Target
+}
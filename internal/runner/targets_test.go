@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+	"zntr.io/extproctor/internal/manifest"
+	"zntr.io/extproctor/internal/reporter"
+)
+
+func TestFanOut(t *testing.T) {
+	tcs := scheduledTests(nil, "a", "b")
+
+	// Without targets, the test cases run once
+	assert.Equal(t, tcs, New(nil).fanOut(tcs))
+
+	r := New(nil, WithTargetName("default"), WithTargets(Target{Name: "eu"}, Target{Name: "us"}))
+	fanned := r.fanOut(tcs)
+	require.Len(t, fanned, 4)
+	assert.Equal(t, []string{"a", "a", "b", "b"}, testNames(fanned))
+
+	var names []string
+	for _, tc := range fanned {
+		names = append(names, r.targetNameFor(tc))
+	}
+	assert.Equal(t, []string{"eu", "us", "eu", "us"}, names)
+	assert.Equal(t, "default", r.targetNameFor(tcs[0]))
+	assert.Empty(t, tcs[0].targetName())
+}
+
+func TestRun_Targets(t *testing.T) {
+	tcs := scheduledTests(nil, "a", "b")
+	m := tcs[0].manifest
+	m.TestCases = []*extproctorv1.TestCase{tcs[0].testCase, tcs[1].testCase}
+	m.SkipReason = "unsupported"
+
+	rep := &mockReporter{}
+	r := New(nil, WithReporter(rep), WithTargets(Target{Name: "eu"}, Target{Name: "us"}))
+	results, err := r.Run(context.Background(), []*manifest.LoadedManifest{m})
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, results.Total)
+	assert.Equal(t, 4, results.Skipped)
+	assert.Equal(t, "us", rep.lastResult.Target)
+	assert.Equal(t, "b @ us", rep.lastTestName)
+	assert.Equal(t, []string{"eu", "us"}, results.Targets)
+	assert.Equal(t, []reporter.GroupStats{
+		{Name: "eu", Total: 2, Skipped: 2},
+		{Name: "us", Total: 2, Skipped: 2},
+	}, results.ByTarget)
+	assert.Equal(t, []reporter.MatrixRow{
+		{ID: "m.textproto::a", Name: "a", Statuses: []string{"skipped", "skipped"}},
+		{ID: "m.textproto::b", Name: "b", Statuses: []string{"skipped", "skipped"}},
+	}, results.Matrix)
+}
+
+func TestMatrix(t *testing.T) {
+	tests := []*TestResult{
+		{ID: "m::b", Name: "b", Target: "us", Passed: true},
+		{ID: "m::a", Name: "a", Target: "eu", Passed: true},
+		{ID: "m::a", Name: "a", Target: "us"},
+		{ID: "m::b", Name: "b", Target: "eu", Skipped: true},
+	}
+
+	assert.Equal(t, []reporter.MatrixRow{
+		{ID: "m::a", Name: "a", Statuses: []string{"passed", "failed", ""}},
+		{ID: "m::b", Name: "b", Statuses: []string{"skipped", "passed", ""}},
+	}, matrix(tests, []string{"eu", "us", "ap"}))
+
+	assert.Nil(t, matrix(tests, nil))
+}
+
+func TestArtifactID(t *testing.T) {
+	assert.Equal(t, "m::a", artifactID(&TestResult{ID: "m::a"}))
+	assert.Equal(t, "m::a@eu", artifactID(&TestResult{ID: "m::a", Target: "eu"}))
+}
+
+func TestChannelFor(t *testing.T) {
+	c, err := client.New(client.WithTarget("localhost:50051"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	r := New(c)
+	tc := scheduledTests(nil, "a")[0]
+
+	// Without overrides, the test uses the client of its target
+	got, err := r.channelFor(tc)
+	require.NoError(t, err)
+	assert.Same(t, c, got)
+
+	tc.testCase.Channel = &extproctorv1.ChannelOverrides{Authority: "tenant-a.extproc.internal"}
+	got, err = r.channelFor(tc)
+	require.NoError(t, err)
+	assert.NotSame(t, c, got)
+
+	tc.testCase.Channel = &extproctorv1.ChannelOverrides{ServerName: "tenant-a.extproc.internal"}
+	_, err = r.channelFor(tc)
+	assert.EqualError(t, err, "invalid channel overrides: server name override requires TLS")
+
+	// gRPC metadata derives a client too
+	tc.testCase.Channel = nil
+	tc.testCase.GrpcMetadata = map[string]string{"x-tenant-id": "acme"}
+	got, err = r.channelFor(tc)
+	require.NoError(t, err)
+	assert.NotSame(t, c, got)
+}
+
+func TestTestMetadata(t *testing.T) {
+	tc := scheduledTests(nil, "a")[0]
+	assert.Empty(t, testMetadata(tc))
+
+	tc.manifest.GrpcMetadata = map[string]string{"x-tenant-id": "acme", "x-region": "eu"}
+	tc.testCase.GrpcMetadata = map[string]string{"x-tenant-id": "globex"}
+	assert.Equal(t, map[string]string{"x-tenant-id": "globex", "x-region": "eu"}, testMetadata(tc))
+
+	// The manifest metadata is left untouched
+	assert.Equal(t, "acme", tc.manifest.GrpcMetadata["x-tenant-id"])
+}
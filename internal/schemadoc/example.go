@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package schemadoc
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// example returns a text format snippet of a message setting each field once
+// with a placeholder value. Only the first field of a oneof is set, and nested
+// messages are left empty: their own example details them.
+func example(md protoreflect.MessageDescriptor) string {
+	var b strings.Builder
+	oneofs := map[protoreflect.FullName]bool{}
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		f := fields.Get(i)
+		if o := f.ContainingOneof(); o != nil && !o.IsSynthetic() {
+			if oneofs[o.FullName()] {
+				continue
+			}
+			oneofs[o.FullName()] = true
+		}
+
+		if f.IsMap() {
+			fmt.Fprintf(&b, "%s { key: %s value: %s }\n", f.Name(), placeholder(f.MapKey()), placeholder(f.MapValue()))
+			continue
+		}
+		switch f.Kind() {
+		case protoreflect.MessageKind, protoreflect.GroupKind:
+			fmt.Fprintf(&b, "%s {}\n", f.Name())
+		default:
+			fmt.Fprintf(&b, "%s: %s\n", f.Name(), placeholder(f))
+		}
+	}
+
+	return b.String()
+}
+
+// placeholder returns a placeholder value of a scalar or enum field, the first
+// non-zero value for enums.
+func placeholder(f protoreflect.FieldDescriptor) string {
+	switch f.Kind() {
+	case protoreflect.BoolKind:
+		return "true"
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return `"..."`
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return "1.5"
+	case protoreflect.EnumKind:
+		values := f.Enum().Values()
+		for i := 0; i < values.Len(); i++ {
+			if v := values.Get(i); v.Number() != 0 {
+				return string(v.Name())
+			}
+		}
+		return string(values.Get(0).Name())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return "{}"
+	default:
+		return "1"
+	}
+}
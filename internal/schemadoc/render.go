@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package schemadoc
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+)
+
+var markdownTemplate = texttemplate.Must(texttemplate.New("markdown").Funcs(texttemplate.FuncMap{
+	"cell": markdownCell,
+	"text": markdownText,
+}).Parse(`# Manifest Reference
+
+Reference of the ` + "`{{.Package}}`" + ` manifest schema, generated from its protobuf
+definitions by ` + "`extproctor docs manifest`" + `. Fields of a oneof are mutually
+exclusive: setting one of them selects the match mode (e.g. the expected
+response of an expectation).
+
+## Messages
+{{range .Messages}}
+### {{.Name}}
+{{with .Description}}
+{{text .}}
+{{end}}{{if .Fields}}
+| Field | Type | Description |
+|-------|------|-------------|
+{{range .Fields}}| ` + "`{{.Name}}`" + ` | {{with .Label}}{{.}} {{end}}{{if .Ref}}[` + "`{{.Type}}`" + `](#{{.Ref}}){{else}}` + "`{{.Type}}`" + `{{end}} | {{cell .Description}} |
+{{end}}
+Example:
+
+` + "```textproto" + `
+{{.Example}}` + "```" + `
+{{end}}{{end}}
+## Enums
+{{range .Enums}}
+### {{.Name}}
+{{with .Description}}
+{{text .}}
+{{end}}
+| Value | Number | Description |
+|-------|--------|-------------|
+{{range .Values}}| ` + "`{{.Name}}`" + ` | {{.Number}} | {{cell .Description}} |
+{{end}}{{end}}`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(htmltemplate.FuncMap{
+	"paragraphs": paragraphs,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Manifest Reference</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: 2em auto; padding: 0 1em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.5em; text-align: left; vertical-align: top; }
+pre { background: #f5f5f5; padding: 0.5em; }
+</style>
+</head>
+<body>
+<h1>Manifest Reference</h1>
+<p>Reference of the <code>{{.Package}}</code> manifest schema, generated from its protobuf
+definitions by <code>extproctor docs manifest</code>. Fields of a oneof are mutually
+exclusive: setting one of them selects the match mode (e.g. the expected
+response of an expectation).</p>
+<h2>Messages</h2>
+{{range .Messages}}<h3 id="{{.Anchor}}">{{.Name}}</h3>
+{{range paragraphs .Description}}<p>{{.}}</p>
+{{end}}{{if .Fields}}<table>
+<tr><th>Field</th><th>Type</th><th>Description</th></tr>
+{{range .Fields}}<tr><td><code>{{.Name}}</code></td><td>{{with .Label}}{{.}} {{end}}{{if .Ref}}<a href="#{{.Ref}}"><code>{{.Type}}</code></a>{{else}}<code>{{.Type}}</code>{{end}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>
+<p>Example:</p>
+<pre><code>{{.Example}}</code></pre>
+{{end}}{{end}}<h2>Enums</h2>
+{{range .Enums}}<h3 id="{{.Anchor}}">{{.Name}}</h3>
+{{range paragraphs .Description}}<p>{{.}}</p>
+{{end}}<table>
+<tr><th>Value</th><th>Number</th><th>Description</th></tr>
+{{range .Values}}<tr><td><code>{{.Name}}</code></td><td>{{.Number}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+// WriteMarkdown writes the reference in Markdown.
+func (s *Schema) WriteMarkdown(w io.Writer) error {
+	return markdownTemplate.Execute(w, s)
+}
+
+// WriteHTML writes the reference as a standalone HTML page.
+func (s *Schema) WriteHTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, s)
+}
+
+// markdownText escapes the characters of a description Markdown renderers
+// would take for HTML.
+func markdownText(s string) string {
+	return strings.NewReplacer("<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// markdownCell formats a description for a table cell, on a single line.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(markdownText(s), "|", `\|`)
+	return strings.ReplaceAll(s, "\n\n", "<br><br>")
+}
+
+// paragraphs splits a description into its paragraphs.
+func paragraphs(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n\n")
+}
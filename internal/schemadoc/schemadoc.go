@@ -0,0 +1,254 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package schemadoc documents the manifest schema from the descriptor of the
+// extproctor v1 protobuf definitions, comments included, so that the
+// reference always matches the compiled schema.
+package schemadoc
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	// Registers the envoy dependencies of the manifest schema.
+	_ "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// descriptorSet is the FileDescriptorSet of proto/extproctor/v1/manifest.proto
+// with its source info, built by make proto. The generated code strips the
+// comments from its embedded descriptor.
+//
+//go:embed manifest.binpb
+var descriptorSet []byte
+
+// Schema is the documentation of the manifest schema.
+type Schema struct {
+	Package  string
+	Messages []Message
+	Enums    []Enum
+}
+
+// Message documents a message type.
+type Message struct {
+	Name        string
+	Anchor      string
+	Description string
+	Fields      []Field
+	// Example is a text format snippet setting every field, with a
+	// placeholder value.
+	Example string
+}
+
+// Field documents a message field.
+type Field struct {
+	Name string
+	// Label is "repeated", "optional", "oneof <name>" or empty.
+	Label string
+	Type  string
+	// Ref is the anchor of the documented message or enum type of the field,
+	// empty for scalars and external types.
+	Ref         string
+	Description string
+}
+
+// Enum documents an enum type.
+type Enum struct {
+	Name        string
+	Anchor      string
+	Description string
+	Values      []EnumValue
+}
+
+// EnumValue documents an enum value.
+type EnumValue struct {
+	Name        string
+	Number      int32
+	Description string
+}
+
+// File returns the descriptor of the manifest schema, with its comments.
+func File() (protoreflect.FileDescriptor, error) {
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(descriptorSet, &set); err != nil {
+		return nil, fmt.Errorf("unable to decode the manifest descriptor: %w", err)
+	}
+	if len(set.GetFile()) != 1 {
+		return nil, fmt.Errorf("expected a single file in the manifest descriptor, got %d", len(set.GetFile()))
+	}
+
+	fd, err := protodesc.NewFile(set.GetFile()[0], protoregistry.GlobalFiles)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest descriptor: %w", err)
+	}
+	return fd, nil
+}
+
+// Load builds the documentation of the manifest schema.
+func Load() (*Schema, error) {
+	fd, err := File()
+	if err != nil {
+		return nil, err
+	}
+	return Build(fd), nil
+}
+
+// Build builds the documentation of a file, in declaration order.
+func Build(fd protoreflect.FileDescriptor) *Schema {
+	s := &Schema{Package: string(fd.Package())}
+	s.addMessages(fd, fd.Messages())
+	s.addEnums(fd, fd.Enums())
+	return s
+}
+
+func (s *Schema) addMessages(fd protoreflect.FileDescriptor, msgs protoreflect.MessageDescriptors) {
+	for i := 0; i < msgs.Len(); i++ {
+		md := msgs.Get(i)
+		if md.IsMapEntry() {
+			continue
+		}
+
+		m := Message{
+			Name:        localName(fd, md),
+			Anchor:      anchor(fd, md),
+			Description: comment(fd, md),
+			Example:     example(md),
+		}
+		fields := md.Fields()
+		for j := 0; j < fields.Len(); j++ {
+			m.Fields = append(m.Fields, field(fd, fields.Get(j)))
+		}
+		s.Messages = append(s.Messages, m)
+
+		s.addMessages(fd, md.Messages())
+		s.addEnums(fd, md.Enums())
+	}
+}
+
+func (s *Schema) addEnums(fd protoreflect.FileDescriptor, enums protoreflect.EnumDescriptors) {
+	for i := 0; i < enums.Len(); i++ {
+		ed := enums.Get(i)
+		e := Enum{
+			Name:        localName(fd, ed),
+			Anchor:      anchor(fd, ed),
+			Description: comment(fd, ed),
+		}
+		values := ed.Values()
+		for j := 0; j < values.Len(); j++ {
+			vd := values.Get(j)
+			e.Values = append(e.Values, EnumValue{
+				Name:        string(vd.Name()),
+				Number:      int32(vd.Number()),
+				Description: comment(fd, vd),
+			})
+		}
+		s.Enums = append(s.Enums, e)
+	}
+}
+
+// field documents a field, labelled with its cardinality or oneof.
+func field(fd protoreflect.FileDescriptor, f protoreflect.FieldDescriptor) Field {
+	out := Field{
+		Name:        string(f.Name()),
+		Description: comment(fd, f),
+	}
+	if o := f.ContainingOneof(); out.Description == "" && o != nil && !o.IsSynthetic() {
+		out.Description = comment(fd, o)
+	}
+
+	switch {
+	case f.IsMap():
+		out.Type = fmt.Sprintf("map<%s, %s>", typeName(fd, f.MapKey()), typeName(fd, f.MapValue()))
+		out.Ref = typeRef(fd, f.MapValue())
+		return out
+	case f.IsList():
+		out.Label = "repeated"
+	case f.ContainingOneof() != nil && !f.ContainingOneof().IsSynthetic():
+		out.Label = "oneof " + string(f.ContainingOneof().Name())
+	case f.HasOptionalKeyword():
+		out.Label = "optional"
+	}
+	out.Type = typeName(fd, f)
+	out.Ref = typeRef(fd, f)
+	return out
+}
+
+// typeName returns the type of a field, relative to the documented package.
+func typeName(fd protoreflect.FileDescriptor, f protoreflect.FieldDescriptor) string {
+	switch f.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return localName(fd, f.Message())
+	case protoreflect.EnumKind:
+		return localName(fd, f.Enum())
+	default:
+		return f.Kind().String()
+	}
+}
+
+// typeRef returns the anchor of the type of a field, when documented.
+func typeRef(fd protoreflect.FileDescriptor, f protoreflect.FieldDescriptor) string {
+	var d protoreflect.Descriptor
+	switch f.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		d = f.Message()
+	case protoreflect.EnumKind:
+		d = f.Enum()
+	default:
+		return ""
+	}
+	if d.ParentFile().Path() != fd.Path() {
+		return ""
+	}
+	return anchor(fd, d)
+}
+
+// localName returns the name of a type without the documented package, or
+// its full name when defined elsewhere.
+func localName(fd protoreflect.FileDescriptor, d protoreflect.Descriptor) string {
+	name := string(d.FullName())
+	if d.ParentFile().Path() != fd.Path() {
+		return name
+	}
+	return strings.TrimPrefix(name, string(fd.Package())+".")
+}
+
+// anchor returns the link target of a documented type.
+func anchor(fd protoreflect.FileDescriptor, d protoreflect.Descriptor) string {
+	return strings.ToLower(strings.ReplaceAll(localName(fd, d), ".", ""))
+}
+
+// comment returns the comment of a declaration, its lines unwrapped into
+// paragraphs separated by a blank line.
+func comment(fd protoreflect.FileDescriptor, d protoreflect.Descriptor) string {
+	loc := fd.SourceLocations().ByDescriptor(d)
+	text := loc.LeadingComments
+	if strings.TrimSpace(text) == "" {
+		text = loc.TrailingComments
+	}
+
+	var paragraphs []string
+	var lines []string
+	flush := func() {
+		if len(lines) > 0 {
+			paragraphs = append(paragraphs, strings.Join(lines, " "))
+			lines = nil
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			flush()
+			continue
+		}
+		lines = append(lines, line)
+	}
+	flush()
+
+	return strings.Join(paragraphs, "\n\n")
+}
@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package schemadoc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+func TestFile_MatchesGeneratedCode(t *testing.T) {
+	fd, err := File()
+	require.NoError(t, err)
+
+	embedded := protodesc.ToFileDescriptorProto(fd)
+	generated := protodesc.ToFileDescriptorProto(extproctorv1.File_extproctor_v1_manifest_proto)
+	embedded.SourceCodeInfo, embedded.Options = nil, nil
+	generated.SourceCodeInfo, generated.Options = nil, nil
+
+	assert.True(t, proto.Equal(generated, embedded),
+		"internal/schemadoc/manifest.binpb is out of date with proto/extproctor/v1/manifest.proto, run make proto")
+}
+
+func TestLoad(t *testing.T) {
+	s, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "extproctor.v1", s.Package)
+	messages := map[string]Message{}
+	for _, m := range s.Messages {
+		messages[m.Name] = m
+	}
+	enums := map[string]Enum{}
+	for _, e := range s.Enums {
+		enums[e.Name] = e
+	}
+
+	manifest := messages["TestManifest"]
+	assert.Equal(t, "testmanifest", manifest.Anchor)
+	assert.Equal(t, "TestManifest contains a collection of test cases to run against an ExtProc service.", manifest.Description)
+	assert.Contains(t, manifest.Fields, Field{
+		Name: "test_cases", Label: "repeated", Type: "TestCase", Ref: "testcase",
+		Description: "Test cases to execute",
+	})
+	assert.Contains(t, manifest.Fields, Field{
+		Name: "grpc_metadata", Type: "map<string, string>",
+		Description: `gRPC metadata sent on the processing stream of every test case (e.g. "x-tenant-id"), overriding the --metadata flags`,
+	})
+
+	expectation := messages["ExtProcExpectation"]
+	assert.Contains(t, expectation.Fields, Field{
+		Name: "headers_response", Label: "oneof response", Type: "HeadersExpectation", Ref: "headersexpectation",
+		Description: "The expected response for this phase",
+	})
+	assert.Contains(t, messages["ExactResponseExpectation"].Fields, Field{
+		Name: "response", Type: "envoy.service.ext_proc.v3.ProcessingResponse",
+		Description: "The expected response",
+	})
+	assert.Contains(t, messages["StreamExpectation"].Fields, Field{
+		Name: "max_set_headers", Label: "optional", Type: "uint32",
+		Description: "Maximum number of headers set (or appended) across all the phases",
+	})
+
+	assert.NotContains(t, messages, "TestManifest.GrpcMetadataEntry")
+	assert.Contains(t, enums["ProcessingPhase"].Values, EnumValue{Name: "REQUEST_BODY", Number: 2})
+}
+
+func TestLoad_ExamplesAreValid(t *testing.T) {
+	fd, err := File()
+	require.NoError(t, err)
+
+	msgs := fd.Messages()
+	for i := 0; i < msgs.Len(); i++ {
+		md := msgs.Get(i)
+		assert.NoError(t, prototext.Unmarshal([]byte(example(md)), dynamicpb.NewMessage(md)), md.FullName())
+	}
+}
+
+func TestExample(t *testing.T) {
+	md := extproctorv1.File_extproctor_v1_manifest_proto.Messages().ByName("ExtProcExpectation")
+
+	got := example(md)
+
+	assert.Contains(t, got, "phase: REQUEST_HEADERS\nheaders_response {}\nignore_paths: \"...\"\n")
+	assert.NotContains(t, got, "body_response", "only the first field of a oneof is set")
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	s, err := Load()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, s.WriteMarkdown(&buf))
+
+	out := buf.String()
+	assert.Contains(t, out, "# Manifest Reference\n")
+	assert.Contains(t, out, "### TestCase\n")
+	assert.Contains(t, out, "| `expectations` | repeated [`ExtProcExpectation`](#extprocexpectation) | ")
+	assert.Contains(t, out, "| `REQUEST_BODY` | 2 |  |\n")
+	assert.Contains(t, out, "```textproto\nname: \"...\"\n")
+	assert.Contains(t, out, "&gt;=2025.12, &lt;2026.6")
+}
+
+func TestWriteHTML(t *testing.T) {
+	s, err := Load()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, s.WriteHTML(&buf))
+
+	out := buf.String()
+	assert.Contains(t, out, "<!DOCTYPE html>")
+	assert.Contains(t, out, `<h3 id="testcase">TestCase</h3>`)
+	assert.Contains(t, out, `repeated <a href="#extprocexpectation"><code>ExtProcExpectation</code></a>`)
+	assert.Contains(t, out, "&gt;=2025.12, &lt;2026.6")
+}
+
+func TestMarkdownCell(t *testing.T) {
+	assert.Equal(t, `a \| b<br><br>&lt;c&gt;`, markdownCell("a | b\n\n<c>"))
+}
@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package security
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+)
+
+// Processor executes ExtProc sessions, malformed requests included.
+type Processor interface {
+	Process(ctx context.Context, req *extproctorv1.HttpRequest) (*client.ProcessingResult, error)
+	ProcessUnchecked(ctx context.Context, req *extproctorv1.HttpRequest) (*client.ProcessingResult, error)
+}
+
+// Result is the result of a probe.
+type Result struct {
+	Probe *Probe
+
+	// Findings lists the dangerous behaviors of the service, none when the
+	// probe passed.
+	Findings []string
+
+	Duration time.Duration
+}
+
+// Passed reports whether the service handled the probe safely.
+func (r *Result) Passed() bool {
+	return len(r.Findings) == 0
+}
+
+// Run sends the probes to the service, each within the timeout, and checks
+// that the service stays available after each of them.
+func Run(ctx context.Context, p Processor, probes []*Probe, timeout time.Duration) []*Result {
+	results := make([]*Result, 0, len(probes))
+	for _, probe := range probes {
+		start := time.Now()
+		probeCtx, cancel := context.WithTimeout(ctx, timeout)
+		resp, err := p.ProcessUnchecked(probeCtx, probe.Request)
+		cancel()
+
+		result := &Result{Probe: probe, Duration: time.Since(start)}
+		if err != nil {
+			result.Findings = append(result.Findings, fmt.Sprintf("processing failed: %v", err))
+		} else {
+			result.Findings = append(result.Findings, Check(probe, resp)...)
+		}
+
+		// A service crashing on a probe fails the sessions that follow
+		aliveCtx, cancel := context.WithTimeout(ctx, timeout)
+		if _, err := p.Process(aliveCtx, livenessRequest()); err != nil {
+			result.Findings = append(result.Findings, fmt.Sprintf("service unavailable after the probe: %v", err))
+		}
+		cancel()
+
+		results = append(results, result)
+	}
+	return results
+}
+
+// livenessRequest is the well-formed request checking that the service is
+// still available.
+func livenessRequest() *extproctorv1.HttpRequest {
+	return probeRequest("GET", "/")
+}
+
+// Check returns the dangerous mutations of the responses to a probe: header
+// names or values that are not valid HTTP, the injected header, and
+// mutations of the message framing headers.
+func Check(probe *Probe, result *client.ProcessingResult) []string {
+	var findings []string
+	for _, resp := range result.Responses {
+		phase := strings.ToLower(resp.Phase.String())
+		for _, h := range mutatedHeaders(resp.Response) {
+			for _, f := range checkHeader(probe, h) {
+				findings = append(findings, fmt.Sprintf("%s: %s", phase, f))
+			}
+		}
+	}
+	return findings
+}
+
+// mutatedHeaders returns the headers set by a response, including the headers
+// of an immediate response.
+func mutatedHeaders(resp *extprocv3.ProcessingResponse) []*corev3.HeaderValue {
+	var mutation *extprocv3.HeaderMutation
+	switch {
+	case resp.GetImmediateResponse() != nil:
+		mutation = resp.GetImmediateResponse().GetHeaders()
+	case resp.GetRequestHeaders() != nil:
+		mutation = resp.GetRequestHeaders().GetResponse().GetHeaderMutation()
+	case resp.GetRequestBody() != nil:
+		mutation = resp.GetRequestBody().GetResponse().GetHeaderMutation()
+	case resp.GetRequestTrailers() != nil:
+		mutation = resp.GetRequestTrailers().GetHeaderMutation()
+	case resp.GetResponseHeaders() != nil:
+		mutation = resp.GetResponseHeaders().GetResponse().GetHeaderMutation()
+	case resp.GetResponseBody() != nil:
+		mutation = resp.GetResponseBody().GetResponse().GetHeaderMutation()
+	case resp.GetResponseTrailers() != nil:
+		mutation = resp.GetResponseTrailers().GetHeaderMutation()
+	}
+
+	headers := make([]*corev3.HeaderValue, 0, len(mutation.GetSetHeaders()))
+	for _, h := range mutation.GetSetHeaders() {
+		if h.GetHeader() != nil {
+			headers = append(headers, h.GetHeader())
+		}
+	}
+	return headers
+}
+
+// checkHeader returns the dangerous aspects of a header set by the service.
+func checkHeader(probe *Probe, h *corev3.HeaderValue) []string {
+	key := strings.ToLower(h.GetKey())
+	value := h.GetValue()
+	if value == "" {
+		value = string(h.GetRawValue())
+	}
+
+	var findings []string
+	if strings.ContainsAny(value, "\r\n\x00") {
+		findings = append(findings, fmt.Sprintf("sets header %q with CR, LF or NUL in its value", h.GetKey()))
+	}
+	if name := strings.TrimPrefix(key, ":"); name == "" || strings.ContainsFunc(name, func(r rune) bool { return !isTokenRune(r) }) {
+		findings = append(findings, fmt.Sprintf("sets invalid header name %q", h.GetKey()))
+	}
+
+	switch key {
+	case InjectedHeader:
+		findings = append(findings, fmt.Sprintf("sets the injected header %q", h.GetKey()))
+	case "transfer-encoding":
+		findings = append(findings, "sets transfer-encoding, a request smuggling vector")
+	case "content-length":
+		if sendsHeader(probe, "transfer-encoding") {
+			findings = append(findings, "sets content-length on a request with transfer-encoding")
+		}
+	case ":path":
+		if !strings.HasPrefix(value, "/") {
+			findings = append(findings, fmt.Sprintf("sets :path %q not starting with /", value))
+		}
+	}
+
+	return findings
+}
+
+// sendsHeader reports whether the probe sends a header.
+func sendsHeader(probe *Probe, name string) bool {
+	return slices.ContainsFunc(probe.Request.GetHeaderEntries(), func(h *extproctorv1.HeaderEntry) bool {
+		return strings.EqualFold(h.Key, name)
+	})
+}
+
+// isTokenRune reports whether r is a RFC 9110 tchar.
+func isTokenRune(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	default:
+		return strings.ContainsRune("!#$%&'*+-.^_`|~", r)
+	}
+}
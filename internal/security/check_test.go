@@ -0,0 +1,206 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package security
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/client"
+)
+
+type fakeProcessor struct {
+	// probe answers the probes, the liveness requests succeed unless dead.
+	probe func(req *extproctorv1.HttpRequest) (*client.ProcessingResult, error)
+	dead  bool
+
+	unchecked int
+}
+
+func (f *fakeProcessor) Process(_ context.Context, _ *extproctorv1.HttpRequest) (*client.ProcessingResult, error) {
+	if f.dead {
+		return nil, errors.New("connection refused")
+	}
+	return &client.ProcessingResult{}, nil
+}
+
+func (f *fakeProcessor) ProcessUnchecked(_ context.Context, req *extproctorv1.HttpRequest) (*client.ProcessingResult, error) {
+	f.unchecked++
+	return f.probe(req)
+}
+
+func setHeaders(headers ...*corev3.HeaderValue) *client.ProcessingResult {
+	mutation := &extprocv3.HeaderMutation{}
+	for _, h := range headers {
+		mutation.SetHeaders = append(mutation.SetHeaders, &corev3.HeaderValueOption{Header: h})
+	}
+	return &client.ProcessingResult{Responses: []*client.PhaseResponse{{
+		Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+		Response: &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestHeaders{
+			RequestHeaders: &extprocv3.HeadersResponse{Response: &extprocv3.CommonResponse{HeaderMutation: mutation}},
+		}},
+	}}}
+}
+
+func probeNamed(t *testing.T, name string) *Probe {
+	t.Helper()
+	for _, p := range Probes() {
+		if p.Name == name {
+			return p
+		}
+	}
+	t.Fatalf("no probe %q", name)
+	return nil
+}
+
+func TestProbes(t *testing.T) {
+	names := map[string]bool{}
+	for _, p := range Probes() {
+		assert.False(t, names[p.Name], "duplicate probe %q", p.Name)
+		names[p.Name] = true
+		assert.Contains(t, Categories(), p.Category, p.Name)
+		assert.NotEmpty(t, p.Description, p.Name)
+		assert.NotNil(t, p.Request, p.Name)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		probe    string
+		result   *client.ProcessingResult
+		findings []string
+	}{
+		{
+			name:   "no mutation",
+			probe:  "crlf-header-value",
+			result: &client.ProcessingResult{},
+		},
+		{
+			name:   "safe header",
+			probe:  "crlf-header-value",
+			result: setHeaders(&corev3.HeaderValue{Key: "x-probe", Value: "sanitized"}),
+		},
+		{
+			name:     "reflected CRLF",
+			probe:    "crlf-header-value",
+			result:   setHeaders(&corev3.HeaderValue{Key: "x-copy", Value: "value\r\nx-extproctor-injected: 1"}),
+			findings: []string{`request_headers: sets header "x-copy" with CR, LF or NUL in its value`},
+		},
+		{
+			name:     "raw value",
+			probe:    "nul-header-value",
+			result:   setHeaders(&corev3.HeaderValue{Key: "x-copy", RawValue: []byte("a\x00b")}),
+			findings: []string{`request_headers: sets header "x-copy" with CR, LF or NUL in its value`},
+		},
+		{
+			name:     "injected header",
+			probe:    "crlf-header-value",
+			result:   setHeaders(&corev3.HeaderValue{Key: InjectedHeader, Value: "1"}),
+			findings: []string{`request_headers: sets the injected header "x-extproctor-injected"`},
+		},
+		{
+			name:     "invalid name",
+			probe:    "invalid-header-name",
+			result:   setHeaders(&corev3.HeaderValue{Key: "x-probe: x", Value: "1"}),
+			findings: []string{`request_headers: sets invalid header name "x-probe: x"`},
+		},
+		{
+			name:   "pseudo header",
+			probe:  "encoded-dot-segments",
+			result: setHeaders(&corev3.HeaderValue{Key: ":path", Value: "/admin"}),
+		},
+		{
+			name:     "relative path",
+			probe:    "encoded-dot-segments",
+			result:   setHeaders(&corev3.HeaderValue{Key: ":path", Value: "admin"}),
+			findings: []string{`request_headers: sets :path "admin" not starting with /`},
+		},
+		{
+			name:     "transfer-encoding",
+			probe:    "duplicate-content-length",
+			result:   setHeaders(&corev3.HeaderValue{Key: "Transfer-Encoding", Value: "chunked"}),
+			findings: []string{"request_headers: sets transfer-encoding, a request smuggling vector"},
+		},
+		{
+			name:     "content-length with transfer-encoding",
+			probe:    "content-length-and-transfer-encoding",
+			result:   setHeaders(&corev3.HeaderValue{Key: "content-length", Value: "4"}),
+			findings: []string{"request_headers: sets content-length on a request with transfer-encoding"},
+		},
+		{
+			name:   "content-length without transfer-encoding",
+			probe:  "duplicate-content-length",
+			result: setHeaders(&corev3.HeaderValue{Key: "content-length", Value: "4"}),
+		},
+		{
+			name:  "immediate response",
+			probe: "crlf-header-value",
+			result: &client.ProcessingResult{Responses: []*client.PhaseResponse{{
+				Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS,
+				Response: &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+					ImmediateResponse: &extprocv3.ImmediateResponse{Headers: &extprocv3.HeaderMutation{
+						SetHeaders: []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: InjectedHeader, Value: "1"}}},
+					}},
+				}},
+			}}},
+			findings: []string{`request_headers: sets the injected header "x-extproctor-injected"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.findings, Check(probeNamed(t, tt.probe), tt.result))
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	probes := []*Probe{probeNamed(t, "crlf-header-value"), probeNamed(t, "header-count")}
+
+	t.Run("passed", func(t *testing.T) {
+		p := &fakeProcessor{probe: func(*extproctorv1.HttpRequest) (*client.ProcessingResult, error) {
+			return &client.ProcessingResult{}, nil
+		}}
+
+		results := Run(context.Background(), p, probes, time.Second)
+		require.Len(t, results, 2)
+		assert.Equal(t, 2, p.unchecked)
+		for i, r := range results {
+			assert.Same(t, probes[i], r.Probe)
+			assert.True(t, r.Passed())
+		}
+	})
+
+	t.Run("findings", func(t *testing.T) {
+		p := &fakeProcessor{probe: func(req *extproctorv1.HttpRequest) (*client.ProcessingResult, error) {
+			if len(req.HeaderEntries) > 1 {
+				return nil, errors.New("stream reset")
+			}
+			return setHeaders(&corev3.HeaderValue{Key: InjectedHeader, Value: "1"}), nil
+		}}
+
+		results := Run(context.Background(), p, probes, time.Second)
+		require.Len(t, results, 2)
+		assert.Equal(t, []string{`request_headers: sets the injected header "x-extproctor-injected"`}, results[0].Findings)
+		assert.Equal(t, []string{"processing failed: stream reset"}, results[1].Findings)
+	})
+
+	t.Run("service down", func(t *testing.T) {
+		p := &fakeProcessor{dead: true, probe: func(*extproctorv1.HttpRequest) (*client.ProcessingResult, error) {
+			return &client.ProcessingResult{}, nil
+		}}
+
+		results := Run(context.Background(), p, probes[:1], time.Second)
+		require.Len(t, results, 1)
+		assert.Equal(t, []string{"service unavailable after the probe: connection refused"}, results[0].Findings)
+	})
+}
@@ -0,0 +1,217 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package security implements the built-in security test pack: probes sending
+// malformed or ambiguous requests (header injection, request smuggling hints,
+// oversized headers, unicode path tricks), asserting that the ExtProc service
+// neither crashes nor reflects dangerous mutations.
+package security
+
+import (
+	"fmt"
+	"strings"
+
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+)
+
+// Probe categories.
+const (
+	CategoryInjection = "injection"
+	CategorySmuggling = "smuggling"
+	CategoryLimits    = "limits"
+	CategoryPath      = "path"
+)
+
+// InjectedHeader is the header name smuggled by the injection probes. A filter
+// setting it has parsed the injected line as a header.
+const InjectedHeader = "x-extproctor-injected"
+
+// Probe is a request sent by the security pack.
+type Probe struct {
+	Name        string
+	Category    string
+	Description string
+	Request     *extproctorv1.HttpRequest
+}
+
+// Categories returns the probe categories, in order.
+func Categories() []string {
+	return []string{CategoryInjection, CategorySmuggling, CategoryLimits, CategoryPath}
+}
+
+// Probes returns the probes of the security pack, in order.
+func Probes() []*Probe {
+	injected := InjectedHeader + ": 1"
+
+	return []*Probe{
+		// Header injection
+		{
+			Name:        "crlf-header-value",
+			Category:    CategoryInjection,
+			Description: "CRLF followed by a header line in a header value",
+			Request:     probeRequest("GET", "/", header("x-probe", "value\r\n"+injected)),
+		},
+		{
+			Name:        "lf-header-value",
+			Category:    CategoryInjection,
+			Description: "bare LF followed by a header line in a header value",
+			Request:     probeRequest("GET", "/", header("x-probe", "value\n"+injected)),
+		},
+		{
+			Name:        "nul-header-value",
+			Category:    CategoryInjection,
+			Description: "NUL byte in a header value",
+			Request:     probeRequest("GET", "/", header("x-probe", "value\x00"+injected)),
+		},
+		{
+			Name:        "crlf-path",
+			Category:    CategoryInjection,
+			Description: "CRLF followed by a header line in the path",
+			Request:     probeRequest("GET", "/probe\r\n"+injected),
+		},
+		{
+			Name:        "crlf-authority",
+			Category:    CategoryInjection,
+			Description: "CRLF followed by a header line in the authority",
+			Request:     withAuthority(probeRequest("GET", "/"), "example.com\r\n"+injected),
+		},
+		{
+			Name:        "invalid-header-name",
+			Category:    CategoryInjection,
+			Description: "header name with a space and a colon",
+			Request:     probeRequest("GET", "/", header("x-probe: "+InjectedHeader, "1")),
+		},
+
+		// Request smuggling hints
+		{
+			Name:        "content-length-and-transfer-encoding",
+			Category:    CategorySmuggling,
+			Description: "both content-length and transfer-encoding: chunked",
+			Request: withBody(probeRequest("POST", "/",
+				header("content-length", "4"),
+				header("transfer-encoding", "chunked"),
+			), "0\r\n\r\n"),
+		},
+		{
+			Name:        "duplicate-content-length",
+			Category:    CategorySmuggling,
+			Description: "two content-length headers with different values",
+			Request: withBody(probeRequest("POST", "/",
+				header("content-length", "4"),
+				header("content-length", "40"),
+			), "body"),
+		},
+		{
+			Name:        "obfuscated-transfer-encoding",
+			Category:    CategorySmuggling,
+			Description: "transfer-encoding with an unknown coding before chunked",
+			Request: withBody(probeRequest("POST", "/",
+				header("transfer-encoding", "xchunked, chunked"),
+			), "0\r\n\r\n"),
+		},
+		{
+			Name:        "transfer-encoding-whitespace",
+			Category:    CategorySmuggling,
+			Description: "transfer-encoding with a leading tab in its value",
+			Request: withBody(probeRequest("POST", "/",
+				header("transfer-encoding", "\tchunked"),
+			), "0\r\n\r\n"),
+		},
+
+		// Oversized headers
+		{
+			Name:        "header-count",
+			Category:    CategoryLimits,
+			Description: "1000 distinct headers",
+			Request:     probeRequest("GET", "/", manyHeaders(1000)...),
+		},
+		{
+			Name:        "header-value-size",
+			Category:    CategoryLimits,
+			Description: "64KiB header value",
+			Request:     probeRequest("GET", "/", header("x-probe", strings.Repeat("a", 64<<10))),
+		},
+		{
+			Name:        "repeated-header",
+			Category:    CategoryLimits,
+			Description: "the same header repeated 1000 times",
+			Request:     probeRequest("GET", "/", repeatedHeader("cookie", "probe=1", 1000)...),
+		},
+
+		// Unicode and encoding path tricks
+		{
+			Name:        "encoded-dot-segments",
+			Category:    CategoryPath,
+			Description: "percent-encoded dot segments",
+			Request:     probeRequest("GET", "/public/%2e%2e/admin"),
+		},
+		{
+			Name:        "overlong-utf8-dots",
+			Category:    CategoryPath,
+			Description: "overlong UTF-8 encoding of dot segments",
+			Request:     probeRequest("GET", "/public/%c0%ae%c0%ae/admin"),
+		},
+		{
+			Name:        "unicode-slash",
+			Category:    CategoryPath,
+			Description: "U+2215 division slash in place of a slash",
+			Request:     probeRequest("GET", "/public∕..∕admin"),
+		},
+		{
+			Name:        "fullwidth-path",
+			Category:    CategoryPath,
+			Description: "fullwidth letters normalized to ASCII by NFKC",
+			Request:     probeRequest("GET", "/ａｄｍｉｎ"),
+		},
+		{
+			Name:        "encoded-nul-path",
+			Category:    CategoryPath,
+			Description: "percent-encoded NUL byte in the path",
+			Request:     probeRequest("GET", "/admin%00.png"),
+		},
+	}
+}
+
+// probeRequest returns a request with the given method, path and headers.
+func probeRequest(method, path string, headers ...*extproctorv1.HeaderEntry) *extproctorv1.HttpRequest {
+	return &extproctorv1.HttpRequest{
+		Method:        method,
+		Path:          path,
+		Scheme:        "https",
+		Authority:     "extproctor.test",
+		HeaderEntries: headers,
+	}
+}
+
+func withAuthority(req *extproctorv1.HttpRequest, authority string) *extproctorv1.HttpRequest {
+	req.Authority = authority
+	return req
+}
+
+// withBody sets the body of a request, sent to the service so that the body
+// phase is probed too.
+func withBody(req *extproctorv1.HttpRequest, body string) *extproctorv1.HttpRequest {
+	req.Body = []byte(body)
+	req.ProcessRequestBody = true
+	return req
+}
+
+func header(key, value string) *extproctorv1.HeaderEntry {
+	return &extproctorv1.HeaderEntry{Key: key, Value: value}
+}
+
+func manyHeaders(n int) []*extproctorv1.HeaderEntry {
+	headers := make([]*extproctorv1.HeaderEntry, n)
+	for i := range headers {
+		headers[i] = header(fmt.Sprintf("x-probe-%d", i), "1")
+	}
+	return headers
+}
+
+func repeatedHeader(key, value string, n int) []*extproctorv1.HeaderEntry {
+	headers := make([]*extproctorv1.HeaderEntry, n)
+	for i := range headers {
+		headers[i] = header(key, value)
+	}
+	return headers
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package sink
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"zntr.io/extproctor/internal/reporter"
+)
+
+// httpTimeout bounds the requests of the HTTP sinks.
+const httpTimeout = 30 * time.Second
+
+// HTTP sends the results as a JSON document in an HTTP request: POST to a
+// collecting endpoint, or PUT to an object storage URL.
+type HTTP struct {
+	method  string
+	url     string
+	headers map[string]string
+	client  *http.Client
+	doc     *document
+}
+
+// NewHTTP creates a sink sending the results with the given method and
+// additional headers.
+func NewHTTP(method, url string, headers map[string]string) *HTTP {
+	return &HTTP{
+		method:  method,
+		url:     url,
+		headers: headers,
+		client:  &http.Client{Timeout: httpTimeout},
+		doc:     newDocument(),
+	}
+}
+
+// Write implements ResultSink.
+func (s *HTTP) Write(result reporter.TestResult) error {
+	s.doc.rep.EndTest(result)
+	return nil
+}
+
+// Flush implements ResultSink.
+func (s *HTTP) Flush(summary reporter.SuiteSummary) error {
+	s.doc.rep.EndSuite(summary)
+
+	req, err := http.NewRequestWithContext(context.Background(), s.method, s.url, bytes.NewReader(s.doc.buf.Bytes()))
+	if err != nil {
+		return fmt.Errorf("http sink: invalid request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("http sink: failed to send results to %s: %w", redactURL(req.URL), unwrapURLError(err))
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("http sink: %s %s returned %s: %s", s.method, redactURL(req.URL), resp.Status, bytes.TrimSpace(body))
+	}
+
+	return nil
+}
+
+// redactURL renders a URL without its credentials and query, which may carry
+// a presigned signature.
+func redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	if redacted.RawQuery != "" {
+		redacted.RawQuery = "REDACTED"
+	}
+	return redacted.String()
+}
+
+// unwrapURLError strips the URL from the errors of the HTTP client.
+func unwrapURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
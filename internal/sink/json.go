@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package sink
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"zntr.io/extproctor/internal/reporter"
+)
+
+// document accumulates the results as the JSON document of the JSON reporter,
+// which `extproctor compare` reads.
+type document struct {
+	buf bytes.Buffer
+	rep *reporter.JSONReporter
+}
+
+func newDocument() *document {
+	d := &document{}
+	d.rep = reporter.NewJSONReporter(&d.buf)
+	return d
+}
+
+// JSONFile writes the results as a JSON document to a local file.
+type JSONFile struct {
+	path string
+	doc  *document
+}
+
+// NewJSONFile creates a sink writing to the given file.
+func NewJSONFile(path string) *JSONFile {
+	return &JSONFile{path: path, doc: newDocument()}
+}
+
+// Write implements ResultSink.
+func (s *JSONFile) Write(result reporter.TestResult) error {
+	s.doc.rep.EndTest(result)
+	return nil
+}
+
+// Flush implements ResultSink.
+func (s *JSONFile) Flush(summary reporter.SuiteSummary) error {
+	s.doc.rep.EndSuite(summary)
+	if err := os.WriteFile(s.path, s.doc.buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("json_file sink: failed to write results: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package sink writes test results to storage backends (local JSON files,
+// SQLite history databases, object storage and HTTP endpoints), independently
+// of how they are reported on the console.
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"sync"
+
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/reporter"
+)
+
+// ResultSink stores test results.
+type ResultSink interface {
+	// Write records the result of a test.
+	Write(result reporter.TestResult) error
+
+	// Flush records the summary of the suite and persists the results.
+	Flush(summary reporter.SuiteSummary) error
+}
+
+// New creates the result sink described by a configuration. Environment
+// variables referenced as ${NAME} in its strings are expanded.
+func New(cfg *extproctorv1.ResultSinkConfig) (ResultSink, error) {
+	switch s := cfg.Sink.(type) {
+	case *extproctorv1.ResultSinkConfig_JsonFile:
+		path := os.ExpandEnv(s.JsonFile.GetPath())
+		if path == "" {
+			return nil, errors.New("json_file sink: path is required")
+		}
+		return NewJSONFile(path), nil
+	case *extproctorv1.ResultSinkConfig_Sqlite:
+		path := os.ExpandEnv(s.Sqlite.GetPath())
+		if path == "" {
+			return nil, errors.New("sqlite sink: path is required")
+		}
+		return NewSQLite(path), nil
+	case *extproctorv1.ResultSinkConfig_Upload:
+		url := os.ExpandEnv(s.Upload.GetUrl())
+		if url == "" {
+			return nil, errors.New("upload sink: url is required")
+		}
+		return NewHTTP("PUT", url, expandHeaders(s.Upload.GetHeaders())), nil
+	case *extproctorv1.ResultSinkConfig_Http:
+		url := os.ExpandEnv(s.Http.GetUrl())
+		if url == "" {
+			return nil, errors.New("http sink: url is required")
+		}
+		return NewHTTP("POST", url, expandHeaders(s.Http.GetHeaders())), nil
+	default:
+		return nil, errors.New("result sink has no backend")
+	}
+}
+
+// FromConfig creates the result sinks of a configuration.
+func FromConfig(cfgs []*extproctorv1.ResultSinkConfig) ([]ResultSink, error) {
+	sinks := make([]ResultSink, 0, len(cfgs))
+	for i, cfg := range cfgs {
+		s, err := New(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("result_sinks[%d]: %w", i, err)
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks, nil
+}
+
+// expandHeaders expands the environment variables of header values.
+func expandHeaders(headers map[string]string) map[string]string {
+	expanded := maps.Clone(headers)
+	for k, v := range expanded {
+		expanded[k] = os.ExpandEnv(v)
+	}
+	return expanded
+}
+
+// Reporter feeds result sinks from the reporter calls of the test runner,
+// recording their errors instead of interrupting the run.
+type Reporter struct {
+	mu    sync.Mutex
+	sinks []ResultSink
+	errs  []error
+}
+
+// NewReporter creates a reporter writing to the given sinks.
+func NewReporter(sinks ...ResultSink) *Reporter {
+	return &Reporter{sinks: sinks}
+}
+
+// StartSuite implements reporter.Reporter.
+func (r *Reporter) StartSuite(total int) {}
+
+// StartTest implements reporter.Reporter.
+func (r *Reporter) StartTest(name string) {}
+
+// Event implements reporter.Reporter.
+func (r *Reporter) Event(reporter.Event) {}
+
+// EndTest implements reporter.Reporter.
+func (r *Reporter) EndTest(result reporter.TestResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range r.sinks {
+		if err := s.Write(result); err != nil {
+			r.errs = append(r.errs, err)
+		}
+	}
+}
+
+// EndSuite implements reporter.Reporter.
+func (r *Reporter) EndSuite(summary reporter.SuiteSummary) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range r.sinks {
+		if err := s.Flush(summary); err != nil {
+			r.errs = append(r.errs, err)
+		}
+	}
+}
+
+// Err returns the errors of the sinks.
+func (r *Reporter) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return errors.Join(r.errs...)
+}
+
+// status returns the status of a test result: passed, failed or skipped.
+func status(result reporter.TestResult) string {
+	switch {
+	case result.Skipped:
+		return "skipped"
+	case result.Passed:
+		return "passed"
+	default:
+		return "failed"
+	}
+}
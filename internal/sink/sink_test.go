@@ -0,0 +1,261 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package sink
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/reporter"
+)
+
+func results() ([]reporter.TestResult, reporter.SuiteSummary) {
+	return []reporter.TestResult{
+		{ID: "a.textproto#ok", Name: "ok", Manifest: "a.textproto", Passed: true, Duration: time.Millisecond, Mutations: &reporter.Mutations{HeadersSet: 2, BodyBytes: 12}},
+		{ID: "a.textproto#ko", UID: "0123456789abcdef", Name: "ko", Manifest: "a.textproto", Error: errors.New("boom"), Category: reporter.CategoryConnection, Duration: 2 * time.Millisecond},
+		{ID: "a.textproto#skip", Name: "skip", Manifest: "a.textproto", Skipped: true, SkipReason: "wip"},
+	}, reporter.SuiteSummary{
+		Total: 3, Passed: 1, Failed: 1, Skipped: 1, Duration: 3 * time.Millisecond,
+		Labels: map[string]string{"env": "staging"},
+	}
+}
+
+func feed(t *testing.T, s ResultSink) error {
+	t.Helper()
+
+	rs, summary := results()
+	for _, r := range rs {
+		require.NoError(t, s.Write(r))
+	}
+	return s.Flush(summary)
+}
+
+func TestNew(t *testing.T) {
+	t.Setenv("RESULTS_DIR", "/tmp/results")
+	t.Setenv("TOKEN", "secret")
+
+	s, err := New(&extproctorv1.ResultSinkConfig{Sink: &extproctorv1.ResultSinkConfig_JsonFile{
+		JsonFile: &extproctorv1.JsonFileSink{Path: "${RESULTS_DIR}/run.json"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/results/run.json", s.(*JSONFile).path)
+
+	s, err = New(&extproctorv1.ResultSinkConfig{Sink: &extproctorv1.ResultSinkConfig_Http{
+		Http: &extproctorv1.HttpSink{Url: "https://ci.example.com/results", Headers: map[string]string{"Authorization": "Bearer ${TOKEN}"}},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "POST", s.(*HTTP).method)
+	assert.Equal(t, "Bearer secret", s.(*HTTP).headers["Authorization"])
+
+	s, err = New(&extproctorv1.ResultSinkConfig{Sink: &extproctorv1.ResultSinkConfig_Upload{
+		Upload: &extproctorv1.UploadSink{Url: "https://bucket.example.com/run.json"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "PUT", s.(*HTTP).method)
+}
+
+func TestNew_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *extproctorv1.ResultSinkConfig
+		want string
+	}{
+		{"no backend", &extproctorv1.ResultSinkConfig{}, "result sink has no backend"},
+		{"json_file without path", &extproctorv1.ResultSinkConfig{Sink: &extproctorv1.ResultSinkConfig_JsonFile{JsonFile: &extproctorv1.JsonFileSink{}}}, "json_file sink: path is required"},
+		{"sqlite without path", &extproctorv1.ResultSinkConfig{Sink: &extproctorv1.ResultSinkConfig_Sqlite{Sqlite: &extproctorv1.SqliteSink{}}}, "sqlite sink: path is required"},
+		{"upload without url", &extproctorv1.ResultSinkConfig{Sink: &extproctorv1.ResultSinkConfig_Upload{Upload: &extproctorv1.UploadSink{}}}, "upload sink: url is required"},
+		{"http without url", &extproctorv1.ResultSinkConfig{Sink: &extproctorv1.ResultSinkConfig_Http{Http: &extproctorv1.HttpSink{}}}, "http sink: url is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
+
+func TestFromConfig_Index(t *testing.T) {
+	_, err := FromConfig([]*extproctorv1.ResultSinkConfig{
+		{Sink: &extproctorv1.ResultSinkConfig_JsonFile{JsonFile: &extproctorv1.JsonFileSink{Path: "a.json"}}},
+		{},
+	})
+	assert.EqualError(t, err, "result_sinks[1]: result sink has no backend")
+}
+
+func TestJSONFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.json")
+
+	require.NoError(t, feed(t, NewJSONFile(path)))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var doc struct {
+		Tests []struct {
+			ID     string `json:"id"`
+			Status string `json:"status"`
+		} `json:"tests"`
+		Summary struct {
+			Total  int               `json:"total"`
+			Labels map[string]string `json:"labels"`
+		} `json:"summary"`
+	}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	require.Len(t, doc.Tests, 3)
+	assert.Equal(t, "a.textproto#ko", doc.Tests[1].ID)
+	assert.Equal(t, "failed", doc.Tests[1].Status)
+	assert.Equal(t, 3, doc.Summary.Total)
+	assert.Equal(t, map[string]string{"env": "staging"}, doc.Summary.Labels)
+}
+
+func TestJSONFile_WriteError(t *testing.T) {
+	err := feed(t, NewJSONFile(filepath.Join(t.TempDir(), "missing", "results.json")))
+	assert.ErrorContains(t, err, "json_file sink: failed to write results")
+}
+
+func TestHTTP(t *testing.T) {
+	var method, contentType, auth string
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		contentType = r.Header.Get("Content-Type")
+		auth = r.Header.Get("Authorization")
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	require.NoError(t, feed(t, NewHTTP("PUT", srv.URL+"/run.json", map[string]string{"Authorization": "Bearer token"})))
+
+	assert.Equal(t, "PUT", method)
+	assert.Equal(t, "application/json", contentType)
+	assert.Equal(t, "Bearer token", auth)
+	assert.True(t, json.Valid(body))
+	assert.Contains(t, string(body), `"a.textproto#ok"`)
+}
+
+func TestHTTP_Status(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "access denied", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	err := feed(t, NewHTTP("PUT", srv.URL+"/run.json?X-Amz-Signature=secret", nil))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403 Forbidden: access denied")
+	assert.Contains(t, err.Error(), "/run.json?REDACTED")
+	assert.NotContains(t, err.Error(), "secret")
+}
+
+func TestSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+
+	require.NoError(t, feed(t, NewSQLite(path)))
+	require.NoError(t, feed(t, NewSQLite(path)))
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	var runs int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM runs`).Scan(&runs))
+	assert.Equal(t, 2, runs)
+
+	var env string
+	require.NoError(t, db.QueryRow(`SELECT json_extract(labels, '$.env') FROM runs LIMIT 1`).Scan(&env))
+	assert.Equal(t, "staging", env)
+
+	var failures int
+	var errMsg, category string
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*), MAX(error), MAX(category) FROM results WHERE test_id = ? AND status = 'failed'`, "a.textproto#ko").Scan(&failures, &errMsg, &category))
+	assert.Equal(t, 2, failures)
+	assert.Equal(t, "boom", errMsg)
+	assert.Equal(t, "connection_error", category)
+
+	var uids int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM results WHERE test_uid = ?`, "0123456789abcdef").Scan(&uids))
+	assert.Equal(t, 2, uids)
+
+	var set, bodyBytes int
+	require.NoError(t, db.QueryRow(`SELECT headers_set, body_bytes FROM results WHERE test_id = ?`, "a.textproto#ok").Scan(&set, &bodyBytes))
+	assert.Equal(t, 2, set)
+	assert.Equal(t, 12, bodyBytes)
+
+	// Tests that did not reach the service have no mutation counts
+	var unknown int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM results WHERE headers_set IS NULL`).Scan(&unknown))
+	assert.Equal(t, 4, unknown)
+}
+
+func TestSQLite_MigratesUID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+
+	// A database created before the labels, test_uid, target, category and
+	// mutation columns existed
+	schema := strings.Replace(sqliteSchema, ",\n\tlabels      TEXT NOT NULL DEFAULT ''", "", 1)
+	schema = strings.Replace(schema, "\ttest_uid    TEXT NOT NULL DEFAULT '',\n", "", 1)
+	schema = strings.Replace(schema, "\ttarget      TEXT NOT NULL DEFAULT '',\n", "", 1)
+	start, end := strings.Index(schema, ",\n\tcategory"), strings.Index(schema, "\n);\nCREATE INDEX")
+	schema = schema[:start] + schema[end:]
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	_, err = db.Exec(schema)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	require.NoError(t, feed(t, NewSQLite(path)))
+
+	db, err = sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	var uid, target, category string
+	require.NoError(t, db.QueryRow(`SELECT test_uid, target, category FROM results WHERE test_id = ?`, "a.textproto#ko").Scan(&uid, &target, &category))
+	assert.Equal(t, "0123456789abcdef", uid)
+	assert.Empty(t, target)
+	assert.Equal(t, "connection_error", category)
+
+	var set int
+	require.NoError(t, db.QueryRow(`SELECT headers_set FROM results WHERE test_id = ?`, "a.textproto#ok").Scan(&set))
+	assert.Equal(t, 2, set)
+
+	var labels string
+	require.NoError(t, db.QueryRow(`SELECT labels FROM runs`).Scan(&labels))
+	assert.JSONEq(t, `{"env":"staging"}`, labels)
+}
+
+type failingSink struct{}
+
+func (failingSink) Write(reporter.TestResult) error   { return errors.New("write failed") }
+func (failingSink) Flush(reporter.SuiteSummary) error { return errors.New("flush failed") }
+
+func TestReporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.json")
+	r := NewReporter(NewJSONFile(path), failingSink{})
+
+	rs, summary := results()
+	r.StartSuite(len(rs))
+	for _, res := range rs {
+		r.StartTest(res.Name)
+		r.EndTest(res)
+	}
+	r.EndSuite(summary)
+
+	assert.FileExists(t, path)
+	err := r.Err()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "write failed")
+	assert.Contains(t, err.Error(), "flush failed")
+}
@@ -0,0 +1,212 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package sink
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"zntr.io/extproctor/internal/reporter"
+
+	// Register the pure Go SQLite driver
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema creates the history tables: a row per run, and a row per test
+// result of a run.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	started_at  TEXT NOT NULL,
+	total       INTEGER NOT NULL,
+	passed      INTEGER NOT NULL,
+	failed      INTEGER NOT NULL,
+	skipped     INTEGER NOT NULL,
+	duration_ns INTEGER NOT NULL,
+	labels      TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS results (
+	run_id      INTEGER NOT NULL REFERENCES runs(id),
+	test_id     TEXT NOT NULL,
+	test_uid    TEXT NOT NULL DEFAULT '',
+	name        TEXT NOT NULL,
+	manifest    TEXT NOT NULL,
+	owner       TEXT NOT NULL,
+	target      TEXT NOT NULL DEFAULT '',
+	status      TEXT NOT NULL,
+	skip_reason TEXT NOT NULL,
+	duration_ns INTEGER NOT NULL,
+	error       TEXT NOT NULL,
+	category    TEXT NOT NULL DEFAULT '',
+
+	headers_set         INTEGER,
+	headers_removed     INTEGER,
+	body_bytes          INTEGER,
+	immediate_responses INTEGER
+);
+CREATE INDEX IF NOT EXISTS results_test_id ON results(test_id);
+`
+
+// sqliteUIDIndex indexes the stable test UIDs, once the column exists.
+const sqliteUIDIndex = `CREATE INDEX IF NOT EXISTS results_test_uid ON results(test_uid);`
+
+// sqliteAddedRunColumns are the columns of the runs table added after its
+// creation, with their definition.
+var sqliteAddedRunColumns = [][2]string{
+	{"labels", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// sqliteAddedColumns are the columns of the results table added after its
+// creation, with their definition.
+var sqliteAddedColumns = [][2]string{
+	{"test_uid", "TEXT NOT NULL DEFAULT ''"},
+	{"target", "TEXT NOT NULL DEFAULT ''"},
+	{"category", "TEXT NOT NULL DEFAULT ''"},
+	{"headers_set", "INTEGER"},
+	{"headers_removed", "INTEGER"},
+	{"body_bytes", "INTEGER"},
+	{"immediate_responses", "INTEGER"},
+}
+
+// SQLite appends the results of each run to a SQLite history database, to
+// track the flakiness and duration of tests over time.
+type SQLite struct {
+	path    string
+	started time.Time
+	results []reporter.TestResult
+}
+
+// NewSQLite creates a sink appending to the given database, created when
+// missing.
+func NewSQLite(path string) *SQLite {
+	return &SQLite{path: path, started: time.Now()}
+}
+
+// Write implements ResultSink.
+func (s *SQLite) Write(result reporter.TestResult) error {
+	s.results = append(s.results, result)
+	return nil
+}
+
+// Flush implements ResultSink.
+func (s *SQLite) Flush(summary reporter.SuiteSummary) error {
+	if err := s.flush(summary); err != nil {
+		return fmt.Errorf("sqlite sink: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLite) flush(summary reporter.SuiteSummary) error {
+	db, err := sql.Open("sqlite", s.path)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return fmt.Errorf("failed to create schema: %w", err)
+	}
+	if err := migrateSQLite(db); err != nil {
+		return fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Labels are stored as a JSON object, queried with json_extract
+	var labels string
+	if len(summary.Labels) > 0 {
+		b, err := json.Marshal(summary.Labels)
+		if err != nil {
+			return fmt.Errorf("failed to encode labels: %w", err)
+		}
+		labels = string(b)
+	}
+
+	res, err := tx.Exec(`INSERT INTO runs (started_at, total, passed, failed, skipped, duration_ns, labels) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		s.started.UTC().Format(time.RFC3339Nano), summary.Total, summary.Passed, summary.Failed, summary.Skipped, int64(summary.Duration), labels)
+	if err != nil {
+		return fmt.Errorf("failed to insert run: %w", err)
+	}
+	runID, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to insert run: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO results (run_id, test_id, test_uid, name, manifest, owner, target, status, skip_reason, duration_ns, error, category, headers_set, headers_removed, body_bytes, immediate_responses) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, r := range s.results {
+		var errMsg string
+		if r.Error != nil {
+			errMsg = r.Error.Error()
+		}
+		// Tests that did not reach the ExtProc service have no mutation counts
+		var set, removed, bodyBytes, immediate any
+		if m := r.Mutations; m != nil {
+			set, removed, bodyBytes, immediate = m.HeadersSet, m.HeadersRemoved, m.BodyBytes, m.ImmediateResponses
+		}
+		if _, err := stmt.Exec(runID, r.ID, r.UID, r.Name, r.Manifest, r.Owner, r.Target, status(r), r.SkipReason, int64(r.Duration), errMsg, string(r.Category), set, removed, bodyBytes, immediate); err != nil {
+			return fmt.Errorf("failed to insert result of %s: %w", r.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+
+	return nil
+}
+
+// migrateSQLite adds the columns of sqliteAddedRunColumns and
+// sqliteAddedColumns to databases created before they existed, then indexes
+// the test UIDs.
+func migrateSQLite(db *sql.DB) error {
+	if err := addColumns(db, "runs", sqliteAddedRunColumns); err != nil {
+		return err
+	}
+	if err := addColumns(db, "results", sqliteAddedColumns); err != nil {
+		return err
+	}
+	_, err := db.Exec(sqliteUIDIndex)
+	return err
+}
+
+// addColumns adds the missing columns to a table.
+func addColumns(db *sql.DB, table string, added [][2]string) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info('` + table + `')`)
+	if err != nil {
+		return err
+	}
+	columns := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		columns[name] = true
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	for _, column := range added {
+		if columns[column[0]] {
+			continue
+		}
+		if _, err := db.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column[0] + ` ` + column[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package traceability maps the filter features declared by expectations to
+// the tests verifying them and their latest outcome, to demonstrate the
+// requirement coverage of an ExtProc filter.
+package traceability
+
+import (
+	"sort"
+
+	"zntr.io/extproctor/internal/compare"
+	"zntr.io/extproctor/internal/manifest"
+	"zntr.io/extproctor/internal/runner"
+)
+
+// Test is a test verifying a feature, with its outcome over the runs.
+type Test struct {
+	ID       string
+	Name     string
+	Manifest string
+	Outcome  compare.Outcome
+}
+
+// Feature is a filter feature with the tests verifying it.
+type Feature struct {
+	ID    string
+	Tests []Test
+}
+
+// Outcome returns the outcome of a feature: failed when a test failed, flaky
+// when a test is flaky, passed when a test passed, none when no test ran.
+// Tests that did not run do not hide the outcome of the others.
+func (f Feature) Outcome() compare.Outcome {
+	var passed, flaky bool
+	for _, t := range f.Tests {
+		switch t.Outcome {
+		case compare.OutcomeFailed:
+			return compare.OutcomeFailed
+		case compare.OutcomeFlaky:
+			flaky = true
+		case compare.OutcomePassed:
+			passed = true
+		}
+	}
+
+	switch {
+	case flaky:
+		return compare.OutcomeFlaky
+	case passed:
+		return compare.OutcomePassed
+	default:
+		return compare.OutcomeNone
+	}
+}
+
+// Build maps the features declared by the expectations of the manifests to
+// the tests verifying them, with their outcome keyed by test ID. Features are
+// sorted by ID, and their tests by test ID. Abstract test cases, which never
+// run, are left out.
+func Build(manifests []*manifest.LoadedManifest, outcomes map[string]compare.Outcome) []Feature {
+	tests := make(map[string]map[string]Test)
+	for _, m := range manifests {
+		for _, tc := range m.TestCases {
+			if tc.Abstract {
+				continue
+			}
+
+			id := runner.TestID(m.SourcePath, tc.Name)
+			for _, exp := range tc.Expectations {
+				for _, feature := range exp.Features {
+					if tests[feature] == nil {
+						tests[feature] = make(map[string]Test)
+					}
+					tests[feature][id] = Test{
+						ID:       id,
+						Name:     tc.Name,
+						Manifest: m.SourcePath,
+						Outcome:  outcomes[id],
+					}
+				}
+			}
+		}
+	}
+
+	features := make([]Feature, 0, len(tests))
+	for id, byTest := range tests {
+		f := Feature{ID: id, Tests: make([]Test, 0, len(byTest))}
+		for _, t := range byTest {
+			f.Tests = append(f.Tests, t)
+		}
+		sort.Slice(f.Tests, func(i, j int) bool { return f.Tests[i].ID < f.Tests[j].ID })
+		features = append(features, f)
+	}
+	sort.Slice(features, func(i, j int) bool { return features[i].ID < features[j].ID })
+
+	return features
+}
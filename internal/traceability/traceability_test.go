@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package traceability
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extproctorv1 "zntr.io/extproctor/gen/extproctor/v1"
+	"zntr.io/extproctor/internal/compare"
+	"zntr.io/extproctor/internal/manifest"
+)
+
+func expectation(features ...string) *extproctorv1.ExtProcExpectation {
+	return &extproctorv1.ExtProcExpectation{Phase: extproctorv1.ProcessingPhase_REQUEST_HEADERS, Features: features}
+}
+
+func TestBuild(t *testing.T) {
+	manifests := []*manifest.LoadedManifest{
+		{
+			SourcePath: "tests/auth.textproto",
+			TestManifest: &extproctorv1.TestManifest{TestCases: []*extproctorv1.TestCase{
+				{Name: "deny-anonymous", Expectations: []*extproctorv1.ExtProcExpectation{expectation("REQ-2", "REQ-1"), expectation("REQ-1")}},
+				{Name: "allow-token", Expectations: []*extproctorv1.ExtProcExpectation{expectation("REQ-1")}},
+				{Name: "base", Abstract: true, Expectations: []*extproctorv1.ExtProcExpectation{expectation("REQ-3")}},
+				{Name: "untraced", Expectations: []*extproctorv1.ExtProcExpectation{expectation()}},
+			}},
+		},
+	}
+	outcomes := map[string]compare.Outcome{
+		"tests/auth.textproto::deny-anonymous": compare.OutcomePassed,
+		"tests/auth.textproto::allow-token":    compare.OutcomeFailed,
+	}
+
+	features := Build(manifests, outcomes)
+
+	require.Len(t, features, 2)
+	assert.Equal(t, "REQ-1", features[0].ID)
+	assert.Equal(t, []Test{
+		{ID: "tests/auth.textproto::allow-token", Name: "allow-token", Manifest: "tests/auth.textproto", Outcome: compare.OutcomeFailed},
+		{ID: "tests/auth.textproto::deny-anonymous", Name: "deny-anonymous", Manifest: "tests/auth.textproto", Outcome: compare.OutcomePassed},
+	}, features[0].Tests)
+	assert.Equal(t, compare.OutcomeFailed, features[0].Outcome())
+
+	assert.Equal(t, "REQ-2", features[1].ID)
+	assert.Len(t, features[1].Tests, 1)
+	assert.Equal(t, compare.OutcomePassed, features[1].Outcome())
+}
+
+func TestFeature_Outcome(t *testing.T) {
+	feature := func(outcomes ...compare.Outcome) Feature {
+		f := Feature{ID: "REQ-1"}
+		for _, o := range outcomes {
+			f.Tests = append(f.Tests, Test{Outcome: o})
+		}
+		return f
+	}
+
+	assert.Equal(t, compare.OutcomePassed, feature(compare.OutcomePassed, compare.OutcomeNone).Outcome())
+	assert.Equal(t, compare.OutcomeFlaky, feature(compare.OutcomePassed, compare.OutcomeFlaky).Outcome())
+	assert.Equal(t, compare.OutcomeFailed, feature(compare.OutcomeFlaky, compare.OutcomeFailed).Outcome())
+	assert.Equal(t, compare.OutcomeNone, feature(compare.OutcomeNone).Outcome())
+}
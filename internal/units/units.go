@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package units parses the human-friendly duration and size literals used in
+// manifests (e.g. "150ms", "64KiB").
+package units
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sizeUnits maps the accepted size suffixes to their multiplier.
+var sizeUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"KB":  1000,
+	"MB":  1000 * 1000,
+	"GB":  1000 * 1000 * 1000,
+	"KiB": 1 << 10,
+	"MiB": 1 << 20,
+	"GiB": 1 << 30,
+}
+
+// DurationFormat is a format of the durations printed in reports.
+type DurationFormat string
+
+const (
+	// DurationHuman formats durations like Go, e.g. "1m2.5s" or "150.2ms".
+	DurationHuman DurationFormat = "human"
+	// DurationMilliseconds formats durations in milliseconds with microsecond
+	// precision, e.g. "1500.250ms".
+	DurationMilliseconds DurationFormat = "ms"
+	// DurationSeconds formats durations in seconds with millisecond
+	// precision, e.g. "1.500s".
+	DurationSeconds DurationFormat = "s"
+)
+
+// ParseDurationFormat parses a duration format name.
+func ParseDurationFormat(s string) (DurationFormat, error) {
+	switch f := DurationFormat(s); f {
+	case DurationHuman, DurationMilliseconds, DurationSeconds:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid duration format %q (use ms, s or human)", s)
+	}
+}
+
+// Format formats a duration. Every format can be parsed back with
+// time.ParseDuration.
+func (f DurationFormat) Format(d time.Duration) string {
+	switch f {
+	case DurationMilliseconds:
+		return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64) + "ms"
+	case DurationSeconds:
+		return strconv.FormatFloat(d.Seconds(), 'f', 3, 64) + "s"
+	default:
+		return d.String()
+	}
+}
+
+// ParseDuration parses a positive duration literal such as "2s" or "150ms".
+func ParseDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: expected a value like \"150ms\" or \"2s\"", s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q: must be positive", s)
+	}
+	return d, nil
+}
+
+// ParseSize parses a positive size literal such as "512", "10KB" or "64KiB".
+// Decimal units (KB, MB, GB) are powers of 1000 and binary units (KiB, MiB,
+// GiB) powers of 1024.
+func ParseSize(s string) (int64, error) {
+	trimmed := strings.TrimSpace(s)
+
+	i := 0
+	for i < len(trimmed) && trimmed[i] >= '0' && trimmed[i] <= '9' {
+		i++
+	}
+
+	value, err := strconv.ParseInt(trimmed[:i], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: expected a value like \"512B\" or \"64KiB\"", s)
+	}
+
+	multiplier, ok := sizeUnits[strings.TrimSpace(trimmed[i:])]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q (use B, KB, MB, GB, KiB, MiB or GiB)", s, strings.TrimSpace(trimmed[i:]))
+	}
+	if value <= 0 {
+		return 0, fmt.Errorf("invalid size %q: must be positive", s)
+	}
+	if value > (1<<63-1)/multiplier {
+		return 0, fmt.Errorf("invalid size %q: too large", s)
+	}
+
+	return value * multiplier, nil
+}
+
+// FormatSize formats a size in bytes with the largest binary unit keeping a
+// value of at least 1, to one decimal, e.g. "512B", "64KiB" or "1.5MiB".
+func FormatSize(n int64) string {
+	for _, unit := range []struct {
+		suffix     string
+		multiplier int64
+	}{{"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10}} {
+		if n >= unit.multiplier {
+			value := strconv.FormatFloat(float64(n)/float64(unit.multiplier), 'f', 1, 64)
+			return strings.TrimSuffix(value, ".0") + unit.suffix
+		}
+	}
+	return strconv.FormatInt(n, 10) + "B"
+}
@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package units
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "2s", want: 2 * time.Second},
+		{in: "150ms", want: 150 * time.Millisecond},
+		{in: " 1m30s ", want: 90 * time.Second},
+		{in: "0s", wantErr: true},
+		{in: "-1s", wantErr: true},
+		{in: "2", wantErr: true},
+		{in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDuration(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "512", want: 512},
+		{in: "512B", want: 512},
+		{in: "10KB", want: 10_000},
+		{in: "64KiB", want: 64 << 10},
+		{in: "1 MiB", want: 1 << 20},
+		{in: "2MB", want: 2_000_000},
+		{in: "1GiB", want: 1 << 30},
+		{in: "0B", wantErr: true},
+		{in: "64kib", wantErr: true},
+		{in: "KiB", wantErr: true},
+		{in: "1.5KiB", wantErr: true},
+		{in: "99999999999GiB", wantErr: true},
+		{in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseSize(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "0B", FormatSize(0))
+	assert.Equal(t, "512B", FormatSize(512))
+	assert.Equal(t, "64KiB", FormatSize(64<<10))
+	assert.Equal(t, "1.5MiB", FormatSize(3<<19))
+	assert.Equal(t, "2GiB", FormatSize(2<<30))
+}
+
+func TestDurationFormat(t *testing.T) {
+	d := 1500*time.Millisecond + 250*time.Microsecond
+	assert.Equal(t, "1.50025s", DurationHuman.Format(d))
+	assert.Equal(t, "1500.250ms", DurationMilliseconds.Format(d))
+	assert.Equal(t, "1.500s", DurationSeconds.Format(d))
+
+	// Formatted durations are parsed back
+	for _, f := range []DurationFormat{DurationHuman, DurationMilliseconds, DurationSeconds} {
+		parsed, err := time.ParseDuration(f.Format(d))
+		assert.NoError(t, err)
+		assert.InDelta(t, d, parsed, float64(time.Millisecond))
+	}
+
+	f, err := ParseDurationFormat("ms")
+	assert.NoError(t, err)
+	assert.Equal(t, DurationMilliseconds, f)
+	_, err = ParseDurationFormat("minutes")
+	assert.EqualError(t, err, `invalid duration format "minutes" (use ms, s or human)`)
+}
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package vcs
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	dir, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+
+	runGit(t, dir, "init", "-q")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "tests"), 0o755))
+	for _, name := range []string{"tests/a.textproto", "tests/b.textproto", "tests/c.textproto"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("name: \"x\"\n"), 0o644))
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "initial")
+
+	// Committed change
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tests/a.textproto"), []byte("name: \"a\"\n"), 0o644))
+	runGit(t, dir, "commit", "-q", "-am", "change a")
+	// Unstaged change
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tests/b.textproto"), []byte("name: \"b\"\n"), 0o644))
+	// Untracked file
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tests/d.textproto"), []byte("name: \"d\"\n"), 0o644))
+
+	files, err := ChangedFiles(context.Background(), filepath.Join(dir, "tests"), "HEAD~1")
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{
+		filepath.Join(dir, "tests/a.textproto"),
+		filepath.Join(dir, "tests/b.textproto"),
+		filepath.Join(dir, "tests/d.textproto"),
+	}, files)
+}
+
+func TestChangedFiles_UnknownRef(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+
+	_, err := ChangedFiles(context.Background(), dir, "does-not-exist")
+	assert.ErrorContains(t, err, "git diff")
+}
@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+// Package version provides the version of the extproctor binary.
+package version
+
+import (
+	"runtime/debug"
+	"strings"
+)
+
+// Dev is the version of binaries built without a release version.
+const Dev = "dev"
+
+// Version is the release version, set at build time with
+// -ldflags "-X zntr.io/extproctor/internal/version.Version=v2025.12-2".
+var Version = ""
+
+// String returns the version of the binary: the release version set at build
+// time, the module version when installed with go install, or Dev for
+// development builds and pseudo-versions.
+func String() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		v := info.Main.Version
+		if v != "" && v != "(devel)" && !strings.HasPrefix(v, "v0.0.0-") {
+			return v
+		}
+	}
+	return Dev
+}
@@ -0,0 +1,18 @@
+// SPDX-FileCopyrightText: 2025 Thibault NORMAND
+// SPDX-License-Identifier: MIT
+
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestString(t *testing.T) {
+	assert.Equal(t, Dev, String())
+
+	Version = "v2025.12-2"
+	t.Cleanup(func() { Version = "" })
+	assert.Equal(t, "v2025.12-2", String())
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	samplev1 "zntr.io/extproctor/gen/extproctor/sample/v1"
+)
+
+// defaultListenAddress is the address the server listens on when neither the
+// configuration nor --addr sets one.
+const defaultListenAddress = ":50051"
+
+// defaultConfig returns the configuration of the server started without
+// --config: every header phase sets an x-extproc-* header.
+func defaultConfig() *samplev1.ServerConfig {
+	return &samplev1.ServerConfig{
+		ListenAddress:    defaultListenAddress,
+		RequestHeaders:   setHeader("x-extproc-processed", "true"),
+		RequestTrailers:  setHeader("x-extproc-request-trailer", "processed"),
+		ResponseHeaders:  setHeader("x-extproc-response", "processed"),
+		ResponseTrailers: setHeader("x-extproc-trailer", "processed"),
+	}
+}
+
+func setHeader(key, value string) *samplev1.PhaseBehavior {
+	return &samplev1.PhaseBehavior{SetHeaders: []*samplev1.Header{{Key: key, Value: value}}}
+}
+
+// loadConfig reads and validates a prototext configuration file. The fields
+// it leaves unset keep their default.
+func loadConfig(path string) (*samplev1.ServerConfig, error) {
+	if path == "" {
+		return defaultConfig(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	cfg := &samplev1.ServerConfig{}
+	if err := prototext.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	if err := validateConfig(cfg); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	defaults := defaultConfig()
+	if cfg.ListenAddress == "" {
+		cfg.ListenAddress = defaults.ListenAddress
+	}
+	if cfg.RequestHeaders == nil {
+		cfg.RequestHeaders = defaults.RequestHeaders
+	}
+	if cfg.RequestTrailers == nil {
+		cfg.RequestTrailers = defaults.RequestTrailers
+	}
+	if cfg.ResponseHeaders == nil {
+		cfg.ResponseHeaders = defaults.ResponseHeaders
+	}
+	if cfg.ResponseTrailers == nil {
+		cfg.ResponseTrailers = defaults.ResponseTrailers
+	}
+
+	return cfg, nil
+}
+
+// validateConfig returns all the errors of a configuration, joined.
+func validateConfig(cfg *samplev1.ServerConfig) error {
+	var errs []error
+	fail := func(field, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	if addr := cfg.GetListenAddress(); addr != "" {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			fail("listen_address", "invalid address %q: %v", addr, err)
+		}
+	}
+
+	if t := cfg.GetTls(); t != nil {
+		if t.GetCertFile() == "" || t.GetKeyFile() == "" {
+			fail("tls", "cert_file and key_file are required")
+		}
+	}
+
+	phases := []struct {
+		name     string
+		behavior *samplev1.PhaseBehavior
+	}{
+		{"request_headers", cfg.GetRequestHeaders()},
+		{"request_trailers", cfg.GetRequestTrailers()},
+		{"response_headers", cfg.GetResponseHeaders()},
+		{"response_trailers", cfg.GetResponseTrailers()},
+	}
+	for _, p := range phases {
+		for i, h := range p.behavior.GetSetHeaders() {
+			if err := validateHeaderName(h.GetKey()); err != nil {
+				fail(fmt.Sprintf("%s.set_headers[%d]", p.name, i), "%v", err)
+			}
+		}
+		for i, name := range p.behavior.GetRemoveHeaders() {
+			if err := validateHeaderName(name); err != nil {
+				fail(fmt.Sprintf("%s.remove_headers[%d]", p.name, i), "%v", err)
+			}
+		}
+	}
+
+	for i, rule := range cfg.GetDeny() {
+		field := fmt.Sprintf("deny[%d]", i)
+		if !strings.HasPrefix(rule.GetPathPrefix(), "/") {
+			fail(field+".path_prefix", "path prefix %q must start with /", rule.GetPathPrefix())
+		}
+		if s := rule.GetStatus(); s != 0 && http.StatusText(int(s)) == "" {
+			fail(field+".status", "unknown HTTP status %d", s)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateHeaderName checks that Envoy accepts mutations of a header.
+func validateHeaderName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("header name is required")
+	case strings.HasPrefix(name, ":"):
+		return fmt.Errorf("pseudo-header %s cannot be mutated", name)
+	case name != strings.ToLower(name):
+		return fmt.Errorf("header name %s must be lowercase", name)
+	default:
+		return nil
+	}
+}
+
+// tlsConfig returns the TLS configuration of the server, nil without TLS.
+func tlsConfig(cfg *samplev1.TlsConfig) (*tls.Config, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.GetCertFile(), cfg.GetKeyFile())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if caFile := cfg.GetClientCaFile(); caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", caFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
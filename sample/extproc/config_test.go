@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	samplev1 "zntr.io/extproctor/gen/extproctor/sample/v1"
+)
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := loadConfig("")
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(defaultConfig(), cfg))
+
+	cfg, err = loadConfig("server.textproto")
+	require.NoError(t, err)
+	assert.Equal(t, ":50051", cfg.GetListenAddress())
+	assert.Len(t, cfg.GetRequestHeaders().GetSetHeaders(), 2)
+	assert.Equal(t, "x-extproc-response", cfg.GetResponseHeaders().GetSetHeaders()[0].GetKey())
+
+	path := filepath.Join(t.TempDir(), "server.textproto")
+	require.NoError(t, os.WriteFile(path, []byte(`listen_address: "50051"`+"\n"+`unknown: 1`), 0o644))
+	_, err = loadConfig(path)
+	assert.ErrorContains(t, err, "failed to parse config")
+}
+
+func TestValidateConfig(t *testing.T) {
+	err := validateConfig(&samplev1.ServerConfig{
+		ListenAddress: "50051",
+		Tls:           &samplev1.TlsConfig{CertFile: "server.pem"},
+		RequestHeaders: &samplev1.PhaseBehavior{
+			SetHeaders:    []*samplev1.Header{{Key: ":path", Value: "/"}},
+			RemoveHeaders: []string{"X-Debug"},
+		},
+		Deny: []*samplev1.DenyRule{{PathPrefix: "admin", Status: 999}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `listen_address: invalid address "50051"`)
+	assert.Contains(t, err.Error(), "tls: cert_file and key_file are required")
+	assert.Contains(t, err.Error(), "request_headers.set_headers[0]: pseudo-header :path cannot be mutated")
+	assert.Contains(t, err.Error(), "request_headers.remove_headers[0]: header name X-Debug must be lowercase")
+	assert.Contains(t, err.Error(), `deny[0].path_prefix: path prefix "admin" must start with /`)
+	assert.Contains(t, err.Error(), "deny[0].status: unknown HTTP status 999")
+}
+
+func TestDenyRule(t *testing.T) {
+	s := &ExtProcServer{config: &samplev1.ServerConfig{Deny: []*samplev1.DenyRule{{PathPrefix: "/admin"}}}}
+	assert.Nil(t, s.denyRule("/users"))
+
+	rule := s.denyRule("/admin/users")
+	require.NotNil(t, rule)
+	assert.Equal(t, typev3.StatusCode_Forbidden, denyResponse(rule).GetImmediateResponse().GetStatus().GetCode())
+}
@@ -4,27 +4,37 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
+	samplev1 "zntr.io/extproctor/gen/extproctor/sample/v1"
 )
 
 // ExtProcServer implements the Envoy ExternalProcessor service.
 type ExtProcServer struct {
 	extprocv3.UnimplementedExternalProcessorServer
+
+	config *samplev1.ServerConfig
 }
 
 // Process handles the bidirectional streaming RPC for external processing.
@@ -85,23 +95,18 @@ func (s *ExtProcServer) handleRequestHeaders(headers *extprocv3.HttpHeaders) (*e
 		getHeader(headers, ":method"),
 		getHeader(headers, ":path"))
 
+	if rule := s.denyRule(getHeader(headers, ":path")); rule != nil {
+		return denyResponse(rule), nil
+	}
+
 	return &extprocv3.ProcessingResponse{
 		Response: &extprocv3.ProcessingResponse_RequestHeaders{
 			RequestHeaders: &extprocv3.HeadersResponse{
 				Response: &extprocv3.CommonResponse{
 					// Continue processing the request
 					Status: extprocv3.CommonResponse_CONTINUE,
-					// Example: Add a custom header to the request
-					HeaderMutation: &extprocv3.HeaderMutation{
-						SetHeaders: []*corev3.HeaderValueOption{
-							{
-								Header: &corev3.HeaderValue{
-									Key:   "x-extproc-processed",
-									Value: "true",
-								},
-							},
-						},
-					},
+					// Mutate the request headers as configured
+					HeaderMutation: headerMutation(s.config.GetRequestHeaders()),
 				},
 			},
 		},
@@ -133,16 +138,7 @@ func (s *ExtProcServer) handleRequestTrailers(trailers *extprocv3.HttpTrailers)
 	return &extprocv3.ProcessingResponse{
 		Response: &extprocv3.ProcessingResponse_RequestTrailers{
 			RequestTrailers: &extprocv3.TrailersResponse{
-				HeaderMutation: &extprocv3.HeaderMutation{
-					SetHeaders: []*corev3.HeaderValueOption{
-						{
-							Header: &corev3.HeaderValue{
-								Key:   "x-extproc-request-trailer",
-								Value: "processed",
-							},
-						},
-					},
-				},
+				HeaderMutation: headerMutation(s.config.GetRequestTrailers()),
 			},
 		},
 	}, nil
@@ -159,17 +155,8 @@ func (s *ExtProcServer) handleResponseHeaders(headers *extprocv3.HttpHeaders) (*
 			ResponseHeaders: &extprocv3.HeadersResponse{
 				Response: &extprocv3.CommonResponse{
 					Status: extprocv3.CommonResponse_CONTINUE,
-					// Example: Add a custom header to the response
-					HeaderMutation: &extprocv3.HeaderMutation{
-						SetHeaders: []*corev3.HeaderValueOption{
-							{
-								Header: &corev3.HeaderValue{
-									Key:   "x-extproc-response",
-									Value: "processed",
-								},
-							},
-						},
-					},
+					// Mutate the response headers as configured
+					HeaderMutation: headerMutation(s.config.GetResponseHeaders()),
 				},
 			},
 		},
@@ -202,21 +189,57 @@ func (s *ExtProcServer) handleResponseTrailers(trailers *extprocv3.HttpTrailers)
 	return &extprocv3.ProcessingResponse{
 		Response: &extprocv3.ProcessingResponse_ResponseTrailers{
 			ResponseTrailers: &extprocv3.TrailersResponse{
-				HeaderMutation: &extprocv3.HeaderMutation{
-					SetHeaders: []*corev3.HeaderValueOption{
-						{
-							Header: &corev3.HeaderValue{
-								Key:   "x-extproc-trailer",
-								Value: "processed",
-							},
-						},
-					},
-				},
+				HeaderMutation: headerMutation(s.config.GetResponseTrailers()),
 			},
 		},
 	}, nil
 }
 
+// headerMutation returns the header mutation of a configured phase behavior.
+func headerMutation(behavior *samplev1.PhaseBehavior) *extprocv3.HeaderMutation {
+	if behavior == nil {
+		return nil
+	}
+
+	mutation := &extprocv3.HeaderMutation{RemoveHeaders: behavior.GetRemoveHeaders()}
+	for _, h := range behavior.GetSetHeaders() {
+		mutation.SetHeaders = append(mutation.SetHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{
+				Key:   h.GetKey(),
+				Value: h.GetValue(),
+			},
+		})
+	}
+	return mutation
+}
+
+// denyRule returns the first deny rule matching a request path, or nil.
+func (s *ExtProcServer) denyRule(path string) *samplev1.DenyRule {
+	for _, rule := range s.config.GetDeny() {
+		if strings.HasPrefix(path, rule.GetPathPrefix()) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// denyResponse returns the immediate response of a deny rule.
+func denyResponse(rule *samplev1.DenyRule) *extprocv3.ProcessingResponse {
+	code := typev3.StatusCode(rule.GetStatus())
+	if code == 0 {
+		code = typev3.StatusCode_Forbidden
+	}
+
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status: &typev3.HttpStatus{Code: code},
+				Body:   []byte(rule.GetBody()),
+			},
+		},
+	}
+}
+
 // getHeader extracts a header value by key from the HttpHeaders message.
 func getHeader(headers *extprocv3.HttpHeaders, key string) string {
 	if headers == nil || headers.Headers == nil {
@@ -224,21 +247,50 @@ func getHeader(headers *extprocv3.HttpHeaders, key string) string {
 	}
 	for _, h := range headers.Headers.Headers {
 		if h.Key == key {
-			return string(h.RawValue)
+			// Envoy sends raw_value, value is kept for older clients
+			if len(h.RawValue) > 0 {
+				return string(h.RawValue)
+			}
+			return h.Value
 		}
 	}
 	return ""
 }
 
 func main() {
-	addr := flag.String("addr", ":50051", "gRPC server address")
+	addr := flag.String("addr", defaultListenAddress, "gRPC server address (overrides the configuration)")
+	configPath := flag.String("config", "", "prototext configuration file (extproctor.sample.v1.ServerConfig)")
 	flag.Parse()
 
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "addr" {
+			config.ListenAddress = *addr
+		}
+	})
+
+	// Record metrics and access logs, and recover panics
+	metrics := &registry{}
+	telemetry := newTelemetry(metrics, slog.New(slog.NewJSONHandler(os.Stderr, nil)))
+	opts := telemetry.serverOptions()
+
+	// Serve over TLS when configured
+	tlsConf, err := tlsConfig(config.GetTls())
+	if err != nil {
+		log.Fatalf("Failed to configure TLS: %v", err)
+	}
+	if tlsConf != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConf)))
+	}
+
 	// Create gRPC server
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(opts...)
 
 	// Register ExtProc service
-	extprocv3.RegisterExternalProcessorServer(grpcServer, &ExtProcServer{})
+	extprocv3.RegisterExternalProcessorServer(grpcServer, &ExtProcServer{config: config})
 
 	// Register health service for load balancer health checks
 	healthServer := health.NewServer()
@@ -246,22 +298,39 @@ func main() {
 	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
 
 	// Create listener
-	lis, err := net.Listen("tcp", *addr)
+	lis, err := net.Listen("tcp", config.GetListenAddress())
 	if err != nil {
-		log.Fatalf("Failed to listen on %s: %v", *addr, err)
+		log.Fatalf("Failed to listen on %s: %v", config.GetListenAddress(), err)
 	}
 
 	// Handle graceful shutdown
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	// Expose the metrics when configured
+	var metricsServer *http.Server
+	if metricsAddr := config.GetMetricsAddress(); metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics)
+		metricsServer = &http.Server{Addr: metricsAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("Failed to serve metrics: %v", err)
+			}
+		}()
+		fmt.Printf("Metrics available on %s/metrics\n", metricsAddr)
+	}
+
 	go func() {
 		<-ctx.Done()
 		log.Println("Shutting down gRPC server...")
 		grpcServer.GracefulStop()
+		if metricsServer != nil {
+			_ = metricsServer.Close()
+		}
 	}()
 
-	fmt.Printf("ExtProc server listening on %s\n", *addr)
+	fmt.Printf("ExtProc server listening on %s\n", config.GetListenAddress())
 	if err := grpcServer.Serve(lis); err != nil {
 		log.Fatalf("Failed to serve: %v", err)
 	}
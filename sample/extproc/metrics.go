@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultBuckets are the upper bounds, in seconds, of the histogram buckets.
+var defaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// metric is a metric family written in the Prometheus text exposition format.
+type metric interface {
+	writeTo(w io.Writer)
+}
+
+// registry serves its metrics in the Prometheus text exposition format, which
+// is all a sample server needs without depending on the Prometheus client.
+type registry struct {
+	metrics []metric
+}
+
+// register adds metrics to the registry, written in registration order.
+func (r *registry) register(metrics ...metric) {
+	r.metrics = append(r.metrics, metrics...)
+}
+
+// ServeHTTP implements http.Handler.
+func (r *registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	for _, m := range r.metrics {
+		m.writeTo(bw)
+	}
+	_ = bw.Flush()
+}
+
+// counter is a counter family, partitioned by the values of a single label
+// when label is set.
+type counter struct {
+	name  string
+	help  string
+	label string
+
+	mu     sync.Mutex
+	values map[string]uint64
+}
+
+func newCounter(name, help, label string) *counter {
+	c := &counter{name: name, help: help, label: label, values: map[string]uint64{}}
+	if label == "" {
+		c.values[""] = 0
+	}
+	return c
+}
+
+// inc increments the counter of a label value, ignored without label.
+func (c *counter) inc(labelValue string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[labelValue]++
+}
+
+// value returns the counter of a label value.
+func (c *counter) value(labelValue string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[labelValue]
+}
+
+func (c *counter) writeTo(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	writeFamily(w, c.name, c.help, "counter")
+	for _, v := range slices.Sorted(maps.Keys(c.values)) {
+		if c.label == "" {
+			_, _ = fmt.Fprintf(w, "%s %d\n", c.name, c.values[v])
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "%s{%s=%s} %d\n", c.name, c.label, labelValue(v), c.values[v])
+	}
+}
+
+// histogram is a histogram of durations in seconds, with the default buckets.
+type histogram struct {
+	name string
+	help string
+
+	mu     sync.Mutex
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(name, help string) *histogram {
+	return &histogram{name: name, help: help, counts: make([]uint64, len(defaultBuckets))}
+}
+
+// observe records a value in the first bucket it fits in.
+func (h *histogram) observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if i, ok := slices.BinarySearch(defaultBuckets, v); ok || i < len(defaultBuckets) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+func (h *histogram) writeTo(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	writeFamily(w, h.name, h.help, "histogram")
+	var cumulative uint64
+	for i, upper := range defaultBuckets {
+		cumulative += h.counts[i]
+		_, _ = fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", h.name, formatFloat(upper), cumulative)
+	}
+	_, _ = fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", h.name, h.count)
+	_, _ = fmt.Fprintf(w, "%s_sum %s\n", h.name, formatFloat(h.sum))
+	_, _ = fmt.Fprintf(w, "%s_count %d\n", h.name, h.count)
+}
+
+// writeFamily writes the metadata of a metric family.
+func writeFamily(w io.Writer, name, help, kind string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// labelValue quotes and escapes a label value.
+func labelValue(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
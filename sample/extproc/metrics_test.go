@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	reg := &registry{}
+	streams := newCounter("extproc_streams_total", "Processing streams handled.", "code")
+	duration := newHistogram("extproc_stream_duration_seconds", "Duration of the processing streams.")
+	panics := newCounter("extproc_panics_total", "Panics recovered.", "")
+	reg.register(streams, duration, panics)
+
+	streams.inc("OK")
+	streams.inc("OK")
+	streams.inc(`Internal"`)
+	duration.observe(0.02)
+	duration.observe(0.3)
+	duration.observe(42)
+
+	rec := httptest.NewRecorder()
+	reg.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	body := rec.Body.String()
+	assert.Contains(t, body, "# HELP extproc_streams_total Processing streams handled.\n# TYPE extproc_streams_total counter\n"+
+		"extproc_streams_total{code=\"Internal\\\"\"} 1\nextproc_streams_total{code=\"OK\"} 2\n")
+	assert.Contains(t, body, "# TYPE extproc_stream_duration_seconds histogram\n")
+	assert.Contains(t, body, "extproc_stream_duration_seconds_bucket{le=\"0.01\"} 0\n")
+	assert.Contains(t, body, "extproc_stream_duration_seconds_bucket{le=\"0.025\"} 1\n")
+	assert.Contains(t, body, "extproc_stream_duration_seconds_bucket{le=\"0.5\"} 2\n")
+	assert.Contains(t, body, "extproc_stream_duration_seconds_bucket{le=\"10\"} 2\n")
+	assert.Contains(t, body, "extproc_stream_duration_seconds_bucket{le=\"+Inf\"} 3\n")
+	assert.Contains(t, body, "extproc_stream_duration_seconds_sum 42.32\nextproc_stream_duration_seconds_count 3\n")
+	assert.Contains(t, body, "# TYPE extproc_panics_total counter\nextproc_panics_total 0\n")
+}
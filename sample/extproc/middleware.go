@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// telemetry records the metrics and access logs of the processing streams,
+// and recovers the panics of the handlers.
+type telemetry struct {
+	logger *slog.Logger
+
+	streams        *counter
+	streamDuration *histogram
+	phases         *counter
+	panics         *counter
+}
+
+// newTelemetry registers the metrics of the server.
+func newTelemetry(reg *registry, logger *slog.Logger) *telemetry {
+	t := &telemetry{
+		logger:         logger,
+		streams:        newCounter("extproc_streams_total", "Processing streams handled, by gRPC status code.", "code"),
+		streamDuration: newHistogram("extproc_stream_duration_seconds", "Duration of the processing streams."),
+		phases:         newCounter("extproc_phase_requests_total", "Processing requests received, by processing phase.", "phase"),
+		panics:         newCounter("extproc_panics_total", "Panics recovered in the gRPC handlers.", ""),
+	}
+	reg.register(t.streams, t.streamDuration, t.phases, t.panics)
+	return t
+}
+
+// serverOptions returns the interceptors of the server: the access logs and
+// metrics of the processing streams, around the panic recovery.
+func (t *telemetry) serverOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainStreamInterceptor(t.observeStream, t.recoverStream),
+		grpc.ChainUnaryInterceptor(t.recoverUnary),
+	}
+}
+
+// observeStream records the metrics and writes the access log of each
+// processing stream.
+func (t *telemetry) observeStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if info.FullMethod != extprocv3.ExternalProcessor_Process_FullMethodName {
+		return handler(srv, ss)
+	}
+
+	start := time.Now()
+	stream := &observedStream{ServerStream: ss, phases: t.phases}
+	err := handler(srv, stream)
+	duration := time.Since(start)
+
+	code := status.Code(err)
+	t.streams.inc(code.String())
+	t.streamDuration.observe(duration.Seconds())
+
+	level := slog.LevelInfo
+	attrs := []slog.Attr{
+		slog.String("method", stream.method),
+		slog.String("path", stream.path),
+		slog.String("authority", stream.authority),
+		slog.Int("phases", stream.count),
+		slog.Duration("duration", duration),
+		slog.String("code", code.String()),
+	}
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	t.logger.LogAttrs(ss.Context(), level, "stream", attrs...)
+
+	return err
+}
+
+// recoverStream turns a panic of a stream handler into an Internal error.
+func (t *telemetry) recoverStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer t.recover(ss.Context(), info.FullMethod, &err)
+	return handler(srv, ss)
+}
+
+// recoverUnary turns a panic of a unary handler into an Internal error.
+func (t *telemetry) recoverUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer t.recover(ctx, info.FullMethod, &err)
+	return handler(ctx, req)
+}
+
+func (t *telemetry) recover(ctx context.Context, method string, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	t.panics.inc("")
+	t.logger.ErrorContext(ctx, "panic", "grpc_method", method, "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+	*err = status.Errorf(codes.Internal, "panic: %v", r)
+}
+
+// observedStream records the processing requests received on a stream.
+type observedStream struct {
+	grpc.ServerStream
+
+	phases *counter
+
+	// method, path and authority are the pseudo-headers of the request
+	// headers, and count the number of processing requests received.
+	method    string
+	path      string
+	authority string
+	count     int
+}
+
+// RecvMsg implements grpc.ServerStream.
+func (s *observedStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	req, ok := m.(*extprocv3.ProcessingRequest)
+	if !ok {
+		return nil
+	}
+	s.count++
+	s.phases.inc(requestPhase(req))
+	if headers := req.GetRequestHeaders(); headers != nil {
+		s.method = getHeader(headers, ":method")
+		s.path = getHeader(headers, ":path")
+		s.authority = getHeader(headers, ":authority")
+	}
+	return nil
+}
+
+// requestPhase returns the name of the processing phase of a request.
+func requestPhase(req *extprocv3.ProcessingRequest) string {
+	switch req.Request.(type) {
+	case *extprocv3.ProcessingRequest_RequestHeaders:
+		return "request_headers"
+	case *extprocv3.ProcessingRequest_RequestBody:
+		return "request_body"
+	case *extprocv3.ProcessingRequest_RequestTrailers:
+		return "request_trailers"
+	case *extprocv3.ProcessingRequest_ResponseHeaders:
+		return "response_headers"
+	case *extprocv3.ProcessingRequest_ResponseBody:
+		return "response_body"
+	case *extprocv3.ProcessingRequest_ResponseTrailers:
+		return "response_trailers"
+	default:
+		return "unknown"
+	}
+}
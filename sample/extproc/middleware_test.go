@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestTelemetry(t *testing.T) {
+	logs := &bytes.Buffer{}
+	tel := newTelemetry(&registry{}, slog.New(slog.NewJSONHandler(logs, nil)))
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(tel.serverOptions()...)
+	extprocv3.RegisterExternalProcessorServer(srv, &ExtProcServer{config: defaultConfig()})
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	stream, err := extprocv3.NewExternalProcessorClient(conn).Process(t.Context())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_RequestHeaders{
+		RequestHeaders: &extprocv3.HttpHeaders{Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+			{Key: ":method", RawValue: []byte("GET")},
+			{Key: ":path", RawValue: []byte("/users")},
+		}}},
+	}}))
+	_, err = stream.Recv()
+	require.NoError(t, err)
+	require.NoError(t, stream.CloseSend())
+	_, err = stream.Recv()
+	require.Error(t, err)
+	srv.GracefulStop()
+
+	assert.Equal(t, uint64(1), tel.streams.value("OK"))
+	assert.Equal(t, uint64(1), tel.phases.value("request_headers"))
+	assert.Contains(t, logs.String(), `"msg":"stream","method":"GET","path":"/users","authority":"","phases":1`)
+	assert.Contains(t, logs.String(), `"code":"OK"`)
+}
+
+func TestTelemetry_RecoverPanics(t *testing.T) {
+	logs := &bytes.Buffer{}
+	tel := newTelemetry(&registry{}, slog.New(slog.NewJSONHandler(logs, nil)))
+
+	_, err := tel.recoverUnary(t.Context(), nil, &grpc.UnaryServerInfo{FullMethod: "/test/Panic"}, func(context.Context, any) (any, error) {
+		panic("boom")
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.ErrorContains(t, err, "panic: boom")
+	assert.Equal(t, uint64(1), tel.panics.value(""))
+	assert.Contains(t, logs.String(), `"msg":"panic","grpc_method":"/test/Panic","panic":"boom"`)
+}